@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -16,6 +15,9 @@ import (
 	"github.com/airplne/calendar-app/server/internal/caldav"
 	"github.com/airplne/calendar-app/server/internal/data"
 	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/integrations/imip"
+	"github.com/airplne/calendar-app/server/internal/integrations/notify"
+	"github.com/airplne/calendar-app/server/internal/planner"
 	"github.com/airplne/calendar-app/server/internal/services"
 	"github.com/airplne/calendar-app/server/internal/webui"
 	"github.com/go-chi/chi/v5"
@@ -32,9 +34,14 @@ const (
 
 func main() {
 	flag.Parse()
+	usersCommand := flag.Arg(0) == "users"
 
-	// Initialize structured logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// Initialize structured logger (on stderr for CLI commands, whose output is on stdout)
+	logOutput := os.Stdout
+	if usersCommand {
+		logOutput = os.Stderr
+	}
+	logger := slog.New(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 	slog.SetDefault(logger)
@@ -46,7 +53,7 @@ func main() {
 	appEnv := getEnv("CALENDARAPP_ENV", "development")
 
 	// Production mode security check: require explicit credentials
-	if appEnv == "production" {
+	if appEnv == "production" && !usersCommand {
 		if os.Getenv("CALENDARAPP_USER") == "" || os.Getenv("CALENDARAPP_PASS") == "" {
 			slog.Error("SECURITY ERROR: Production mode requires explicit credentials",
 				"hint", "Set CALENDARAPP_USER and CALENDARAPP_PASS environment variables",
@@ -88,58 +95,190 @@ func main() {
 	calendarRepo := data.NewSQLiteCalendarRepo(db)
 	eventRepo := data.NewSQLiteEventRepo(db)
 	operationRepo := data.NewSQLiteCalDAVOperationRepo(db)
+	appPasswordRepo := data.NewSQLiteAppPasswordRepo(db)
 
-	// Load auth config to get configured username
+	// Load auth config to get the bootstrap credentials
 	authConfig := caldav.LoadAuthConfig()
 
-	// Ensure configured user exists (MVP single-user mode)
+	// Failed Basic Auth sign-ins count together across /dav and /freebusy
+	loginThrottle := services.NewLoginThrottle(services.DefaultLoginThrottleConfig())
+	userService := services.NewUserService(userRepo, calendarRepo)
 	ctx := context.Background()
-	user, err := userRepo.GetByUsername(ctx, authConfig.Username)
-	if errors.Is(err, domain.ErrNotFound) {
-		user, err = userRepo.Create(ctx, authConfig.Username)
-		if err != nil {
-			slog.Error("Failed to create default user", "error", err)
+
+	// `calendarapp users ...` manages accounts and exits
+	if usersCommand {
+		if err := runUsersCommand(ctx, userService, flag.Args()[1:], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
 			os.Exit(1)
 		}
-		slog.Info("Created default user", "username", authConfig.Username)
+		return
 	}
 
-	// Ensure default calendar exists for user
-	_, err = calendarRepo.GetByName(ctx, user.ID, "default")
-	if errors.Is(err, domain.ErrNotFound) {
-		defaultCal := &domain.Calendar{
-			UserID:      user.ID,
-			Name:        "default",
-			DisplayName: "Calendar",
-		}
-		if err := calendarRepo.Create(ctx, defaultCal); err != nil {
-			slog.Error("Failed to create default calendar", "error", err)
+	// Ensure the bootstrap user and its default and tasks calendars exist
+	if _, err := userService.EnsureBootstrapUser(ctx, authConfig.Username, authConfig.Password); err != nil {
+		slog.Error("Failed to set up bootstrap user", "error", err)
+		os.Exit(1)
+	}
+
+	// Scheduling messages for attendees outside this server go out as iMIP
+	// email when an SMTP relay is configured; replies come back through
+	// /api/v1/imip/inbound or a maildir drop
+	var scheduleDelivery domain.ScheduleDelivery
+	smtpConfig, smtpEnabled, err := imip.LoadSMTPConfig()
+	if err != nil {
+		slog.Error("Invalid SMTP configuration", "error", err)
+		os.Exit(1)
+	}
+	var mailer imip.Mailer
+	if smtpEnabled {
+		mailer = imip.NewSMTPMailer(smtpConfig)
+		scheduleDelivery = imip.NewSender(mailer, smtpConfig.From)
+		slog.Info("iMIP invitations enabled", "smtp_host", smtpConfig.Host, "smtp_port", smtpConfig.Port)
+	}
+	// Calendar, event and Sync Health changes are published to the /events
+	// stream of the users they concern, and queued for their webhooks, which
+	// only reach public addresses and the networks the admin allows. CalDAV
+	// writes queue their webhook deliveries in the transaction of the change
+	notifications := services.NewNotificationBus(services.DefaultNotificationHistory)
+	webhookNetworks, err := notify.ParseWebhookNetworks(os.Getenv("CALENDARAPP_WEBHOOK_ALLOWED_NETWORKS"))
+	if err != nil {
+		slog.Error("Invalid webhook networks", "error", err)
+		os.Exit(1)
+	}
+	webhookService := services.NewWebhookService(data.NewSQLiteWebhookRepo(db), notify.NewSignedWebhookSender(webhookNetworks...))
+	publisher := services.NotificationFanout{notifications, webhookService}
+
+	// Calendar data changed outside CalDAV requests (iMIP replies, reminder
+	// acknowledgements) goes through the same backend
+	backend := caldav.NewBackend(db, userRepo, calendarRepo, eventRepo)
+	backend.SetPublisher(notifications)
+	backend.SetWebhooks(webhookService)
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
+	go webhookService.Run(backgroundCtx, services.DefaultWebhookInterval)
+	if maildir := os.Getenv("CALENDARAPP_IMIP_MAILDIR"); maildir != "" {
+		go imip.WatchMaildir(backgroundCtx, maildir, time.Minute, backend)
+		slog.Info("Watching maildir for iMIP replies", "dir", maildir)
+	}
+
+	// VALARM reminders go to the event stream and webhook subscriptions, and
+	// to a fixed webhook and email (ACTION:EMAIL alarms) when configured
+	if indexed, err := backend.IndexAlarms(ctx); err != nil {
+		slog.Error("Failed to index alarms", "error", err)
+	} else if indexed > 0 {
+		slog.Info("Indexed alarms of existing events", "events", indexed)
+	}
+	reminderChannels := []domain.ReminderChannel{notifications, webhookService}
+	if webhookURL := os.Getenv("CALENDARAPP_REMINDER_WEBHOOK_URL"); webhookURL != "" {
+		webhook, err := notify.NewWebhook(webhookURL)
+		if err != nil {
+			slog.Error("Invalid reminder webhook", "error", err)
 			os.Exit(1)
 		}
-		slog.Info("Created default calendar for user", "username", authConfig.Username)
+		reminderChannels = append(reminderChannels, webhook)
+	}
+	if smtpEnabled {
+		reminderChannels = append(reminderChannels, notify.NewEmail(mailer, smtpConfig.From, userRepo))
+	}
+	reminderService := services.NewReminderService(data.NewSQLiteAlarmRepo(db), calendarRepo, backend, reminderChannels...)
+	go reminderService.Run(backgroundCtx, services.DefaultReminderInterval)
+
+	// Forwarded client addresses are only taken from trusted reverse
+	// proxies: the login throttle keys on them
+	trustedProxies, err := api.ParseTrustedProxies(os.Getenv("CALENDARAPP_TRUSTED_PROXIES"))
+	if err != nil {
+		slog.Error("Invalid trusted proxies", "error", err)
+		os.Exit(1)
 	}
 
 	// Initialize router (Chi per locked MVP decisions)
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(api.RealIP(trustedProxies))
 	r.Use(middleware.Recoverer)
 
 	// Health endpoint
 	r.Get("/health", handleHealth)
 
-	// SSE endpoint stub
-	r.Get("/events", handleSSE)
-
-	// Sync Health API
-	syncHealthService := services.NewSyncHealthService(operationRepo, services.UnknownGreenSyncProvider())
-	r.Mount("/api/v1/sync-health", api.NewSyncHealthHandler(syncHealthService).Routes())
+	// JSON API and the SSE stream share one authentication middleware:
+	// session cookies (web UI, with CSRF protection) or personal access tokens
+	apiAuthService := services.NewAPIAuthService(userService, userRepo, data.NewSQLiteSessionRepo(db), data.NewSQLitePersonalAccessTokenRepo(db))
+	authenticate := api.Authenticate(apiAuthService)
+
+	// Server-Sent Events stream of changes and reminders
+	r.With(authenticate).Method(http.MethodGet, "/events", api.NewEventStreamHandler(notifications, api.CurrentUser))
+
+	r.Route("/api/v1", func(r chi.Router) {
+		// Login is public; everything else requires authentication
+		r.Mount("/auth", api.NewAuthHandler(apiAuthService, loginThrottle).Routes(authenticate))
+
+		r.Group(func(r chi.Router) {
+			r.Use(authenticate)
+
+			// Sync Health API
+			syncHealthService := services.NewSyncHealthService(operationRepo, services.UnknownGreenSyncProvider())
+			syncHealthService.PublishTransitions(publisher)
+			go syncHealthService.Watch(backgroundCtx, services.DefaultSyncHealthWatchInterval)
+			r.Mount("/sync-health", api.NewSyncHealthHandler(syncHealthService).Routes())
+
+			// Calendar sharing between users
+			shareService := services.NewCalendarShareService(data.NewSQLiteCalendarShareRepo(db), calendarRepo, userRepo, publisher)
+			r.Mount("/calendars", api.NewCalendarSharesHandler(shareService, api.CurrentUser).Routes())
+
+			// iMIP replies posted as .eml
+			r.Mount("/imip", api.NewIMIPHandler(backend, api.CurrentUser).Routes())
+
+			// Fired VALARM reminders and their acknowledgement
+			r.Mount("/reminders", api.NewRemindersHandler(reminderService, api.CurrentUser).Routes())
+
+			// Outgoing webhook subscriptions and their delivery log
+			r.Mount("/webhooks", api.NewWebhooksHandler(webhookService, api.CurrentUser).Routes())
+
+			// Plan proposals: reviewable calendar changes, applied through the
+			// backend like CalDAV writes and recorded in the audit log
+			proposalRepo := data.NewSQLitePlanProposalRepo(db)
+			preferenceService := planner.NewPreferenceService(data.NewSQLitePreferenceRepo(db))
+			proposalService := planner.NewProposalService(proposalRepo, calendarRepo, eventRepo, preferenceService)
+			auditRepo := data.NewSQLiteAuditLogRepo(db)
+			applyService := planner.NewApplyService(proposalService, proposalRepo, auditRepo, backend)
+			r.Mount("/proposals", api.NewProposalsHandler(proposalService, applyService, api.CurrentUser).Routes())
+
+			// Planning preferences the proposal constraints use
+			r.Mount("/preferences", api.NewPreferencesHandler(preferenceService, api.CurrentUser).Routes())
+
+			// Audit log of applied plans and their rollback
+			rollbackService := planner.NewRollbackService(eventRepo, proposalRepo, auditRepo, backend)
+			r.Mount("/audit", api.NewAuditHandler(rollbackService, api.CurrentUser).Routes())
+
+			// Focus time suggestions across all of the user's calendars
+			r.Mount("/focus", api.NewFocusHandler(planner.NewFocusService(proposalService, applyService), api.CurrentUser).Routes())
+
+			// User administration (admins only)
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(api.RequireScope(domain.ScopeAdmin))
+				r.Use(api.RequireAdmin(api.CurrentUser))
+				r.Mount("/users", api.NewAdminUsersHandler(userService, api.CurrentUser).Routes())
+			})
+
+			// Credentials of the signed-in user; minting them takes a web UI session
+			r.Group(func(r chi.Router) {
+				r.Use(api.RequireSession)
+				appPasswordService := services.NewAppPasswordService(appPasswordRepo, userRepo)
+				r.Mount("/app-passwords", api.NewAppPasswordsHandler(appPasswordService, api.CurrentUser).Routes())
+				r.Mount("/tokens", api.NewAccessTokensHandler(apiAuthService, api.CurrentUser).Routes())
+			})
+		})
+	})
 
 	// Well-known CalDAV auto-discovery endpoint
-	r.Get("/.well-known/caldav", caldav.NewWellKnownRoutes(authConfig.Username).ServeHTTP)
+	r.Get("/.well-known/caldav", caldav.NewWellKnownRoutes().ServeHTTP)
+
+	// Free-busy publishing (busy periods only, no event details)
+	freeBusyService := services.NewFreeBusyService(calendarRepo, eventRepo)
+	r.Mount("/freebusy", caldav.NewFreeBusyPublishHandler(userRepo, freeBusyService).Routes(authConfig, appPasswordRepo, loginThrottle))
 
 	// CalDAV mount point with repository access
-	r.Mount("/dav", caldav.NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, operationRepo))
+	r.Mount("/dav", caldav.NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, operationRepo, loginThrottle, scheduleDelivery, notifications, webhookService))
 
 	// Web UI (embedded in production; placeholder when dist not built)
 	r.Mount("/", webui.Handler())
@@ -168,6 +307,7 @@ func main() {
 	<-quit
 
 	slog.Info("Shutting down server...")
+	stopBackground()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -185,37 +325,6 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, `{"status":"ok"}`)
 }
 
-func handleSSE(w http.ResponseWriter, r *http.Request) {
-	// SSE endpoint stub - will implement real-time push updates
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
-		return
-	}
-
-	// Send initial connection event
-	fmt.Fprintf(w, "data: {\"type\":\"connected\"}\n\n")
-	flusher.Flush()
-
-	// Keep connection alive (stub - will add real event broadcasting)
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case <-ticker.C:
-			fmt.Fprintf(w, ": keepalive\n\n")
-			flusher.Flush()
-		}
-	}
-}
-
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
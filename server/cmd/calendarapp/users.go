@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+const usersUsage = `usage: calendarapp users <command> [arguments]
+
+Commands:
+  list                          List accounts
+  add [-admin] <username>       Create an account (password read from stdin)
+  disable <username>            Block an account from signing in
+  enable <username>             Allow a disabled account to sign in again
+  reset-password [-revoke-credentials] <username>
+                                Replace a password (read from stdin) and sign
+                                out sessions; optionally revoke app passwords
+                                and access tokens
+  set-email <username> [email]  Set the scheduling address (empty clears it)`
+
+// runUsersCommand implements `calendarapp users ...`. Passwords are read from
+// the first line of stdin so they never appear in shell history or ps output.
+func runUsersCommand(ctx context.Context, users *services.UserService, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usersUsage)
+	}
+
+	cmd := flag.NewFlagSet("users "+args[0], flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	admin := cmd.Bool("admin", false, "grant admin rights")
+	revoke := cmd.Bool("revoke-credentials", false, "revoke app passwords and access tokens")
+	if err := cmd.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w\n\n%s", err, usersUsage)
+	}
+
+	if args[0] == "list" {
+		list, err := users.List(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "USERNAME\tEMAIL\tADMIN\tDISABLED\tCREATED")
+		for _, user := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%t\t%t\t%s\n", user.Username, user.Email, user.IsAdmin, user.Disabled(), user.CreatedAt.Format("2006-01-02"))
+		}
+		return tw.Flush()
+	}
+
+	if args[0] == "set-email" {
+		if cmd.NArg() < 1 || cmd.NArg() > 2 {
+			return errors.New(usersUsage)
+		}
+		user, err := users.SetEmail(ctx, cmd.Arg(0), cmd.Arg(1))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "set email of %s to %q\n", user.Username, user.Email)
+		return nil
+	}
+
+	if cmd.NArg() != 1 {
+		return errors.New(usersUsage)
+	}
+	username := cmd.Arg(0)
+
+	switch args[0] {
+	case "add":
+		password, err := readPassword(stdin)
+		if err != nil {
+			return err
+		}
+		if _, err := users.CreateUser(ctx, username, password, *admin); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "created %s\n", username)
+	case "disable", "enable":
+		if _, err := users.SetDisabled(ctx, username, args[0] == "disable"); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%sd %s\n", args[0], username)
+	case "reset-password":
+		password, err := readPassword(stdin)
+		if err != nil {
+			return err
+		}
+		if _, err := users.ResetPassword(ctx, username, password, *revoke); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "reset password of %s\n", username)
+	default:
+		return errors.New(usersUsage)
+	}
+	return nil
+}
+
+func readPassword(stdin io.Reader) (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
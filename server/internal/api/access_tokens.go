@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// AccessTokensHandler serves /api/v1/tokens, the signed-in user's personal
+// access tokens. Mount it behind Authenticate and RequireSession.
+type AccessTokensHandler struct {
+	auth        *services.APIAuthService
+	currentUser UserFromContext
+}
+
+func NewAccessTokensHandler(auth *services.APIAuthService, currentUser UserFromContext) *AccessTokensHandler {
+	return &AccessTokensHandler{auth: auth, currentUser: currentUser}
+}
+
+func (h *AccessTokensHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Post("/", h.handleCreate)
+	r.Delete("/{id}", h.handleRevoke)
+	return r
+}
+
+// accessTokenJSON never includes the token or its hash.
+type accessTokenJSON struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Revoked    bool       `json:"revoked"`
+}
+
+// createdAccessTokenJSON is the only response that carries the token.
+type createdAccessTokenJSON struct {
+	accessTokenJSON
+	Token string `json:"token"`
+}
+
+type createAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0: does not expire
+}
+
+func (h *AccessTokensHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	tokens, err := h.auth.ListTokens(r.Context(), user.ID)
+	if err != nil {
+		writeAccessTokenError(w, err)
+		return
+	}
+	result := make([]accessTokenJSON, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, toAccessTokenJSON(token))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tokens": result})
+}
+
+func (h *AccessTokensHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var req createAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ExpiresInDays < 0 {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON with a name, scopes and optional expires_in_days.")
+		return
+	}
+	scopes := make([]domain.TokenScope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, domain.TokenScope(scope))
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		at := time.Now().UTC().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &at
+	}
+
+	token, secret, err := h.auth.CreateToken(r.Context(), user, req.Name, scopes, expiresAt)
+	if err != nil {
+		writeAccessTokenError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createdAccessTokenJSON{accessTokenJSON: toAccessTokenJSON(token), Token: secret})
+}
+
+func (h *AccessTokensHandler) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeAccessTokenError(w, domain.ErrNotFound)
+		return
+	}
+	if err := h.auth.RevokeToken(r.Context(), user, id); err != nil {
+		writeAccessTokenError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toAccessTokenJSON(token *domain.PersonalAccessToken) accessTokenJSON {
+	scopes := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	return accessTokenJSON{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		Revoked:    token.RevokedAt != nil,
+	}
+}
+
+func writeAccessTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTokenName), errors.Is(err, services.ErrInvalidTokenScopes):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, services.ErrAdminScopeDenied):
+		writeJSONError(w, http.StatusForbidden, "admin_required", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "token_not_found", "No active token with this ID.")
+	default:
+		slog.Error("access token request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
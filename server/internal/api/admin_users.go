@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// AdminUsersHandler serves /api/v1/admin/users. Mount it behind
+// authentication and RequireAdmin.
+type AdminUsersHandler struct {
+	users       *services.UserService
+	currentUser UserFromContext
+}
+
+func NewAdminUsersHandler(users *services.UserService, currentUser UserFromContext) *AdminUsersHandler {
+	return &AdminUsersHandler{users: users, currentUser: currentUser}
+}
+
+func (h *AdminUsersHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Post("/", h.handleCreate)
+	r.Post("/{username}/disable", h.handleSetDisabled(true))
+	r.Post("/{username}/enable", h.handleSetDisabled(false))
+	r.Post("/{username}/reset-password", h.handleResetPassword)
+	r.Post("/{username}/email", h.handleSetEmail)
+	return r
+}
+
+// userJSON never includes the password hash.
+type userJSON struct {
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Admin      bool       `json:"admin"`
+	Disabled   bool       `json:"disabled"`
+	DisabledAt *time.Time `json:"disabled_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+type resetPasswordRequest struct {
+	Password          string `json:"password"`
+	RevokeCredentials bool   `json:"revoke_credentials"` // Also revoke app passwords and access tokens
+}
+
+type setEmailRequest struct {
+	Email string `json:"email"` // Empty clears the address
+}
+
+func (h *AdminUsersHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	users, err := h.users.List(r.Context())
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	result := make([]userJSON, 0, len(users))
+	for _, user := range users {
+		result = append(result, toUserJSON(user))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"users": result})
+}
+
+func (h *AdminUsersHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	user, err := h.users.CreateUser(r.Context(), req.Username, req.Password, req.Admin)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toUserJSON(user))
+}
+
+func (h *AdminUsersHandler) handleSetDisabled(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+		if current := h.currentUser(r.Context()); disabled && current != nil && current.Username == username {
+			writeJSONError(w, http.StatusConflict, "cannot_disable_self", "Admins cannot disable their own account.")
+			return
+		}
+		user, err := h.users.SetDisabled(r.Context(), username, disabled)
+		if err != nil {
+			writeUserError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toUserJSON(user))
+	}
+}
+
+func (h *AdminUsersHandler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	user, err := h.users.ResetPassword(r.Context(), chi.URLParam(r, "username"), req.Password, req.RevokeCredentials)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserJSON(user))
+}
+
+func (h *AdminUsersHandler) handleSetEmail(w http.ResponseWriter, r *http.Request) {
+	var req setEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	user, err := h.users.SetEmail(r.Context(), chi.URLParam(r, "username"), req.Email)
+	if errors.Is(err, domain.ErrConflict) {
+		writeJSONError(w, http.StatusConflict, "email_taken", "Another user has this email address.")
+		return
+	}
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserJSON(user))
+}
+
+func toUserJSON(user *domain.User) userJSON {
+	return userJSON{
+		Username:   user.Username,
+		Email:      user.Email,
+		Admin:      user.IsAdmin,
+		Disabled:   user.Disabled(),
+		DisabledAt: user.DisabledAt,
+		CreatedAt:  user.CreatedAt,
+	}
+}
+
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidUsername), errors.Is(err, services.ErrWeakPassword), errors.Is(err, services.ErrInvalidEmail):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		writeJSONError(w, http.StatusConflict, "user_exists", "A user with this username already exists.")
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "user_not_found", "No user with this username.")
+	default:
+		slog.Error("admin user request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
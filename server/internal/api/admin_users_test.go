@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+type contextUserKey struct{}
+
+func testUserFromContext(ctx context.Context) *domain.User {
+	user, _ := ctx.Value(contextUserKey{}).(*domain.User)
+	return user
+}
+
+func newAdminUsersTestHandler(t *testing.T) (http.Handler, *services.UserService) {
+	t.Helper()
+
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	users := services.NewUserService(data.NewSQLiteUserRepo(db), data.NewSQLiteCalendarRepo(db))
+	handler := RequireAdmin(testUserFromContext)(NewAdminUsersHandler(users, testUserFromContext).Routes())
+	return handler, users
+}
+
+func adminRequest(handler http.Handler, user *domain.User, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), contextUserKey{}, user))
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminUsersAPI(t *testing.T) {
+	handler, users := newAdminUsersTestHandler(t)
+	admin := &domain.User{Username: "root", IsAdmin: true}
+
+	rr := adminRequest(handler, admin, http.MethodPost, "/", `{"username":"alice","password":"alice-password"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201; body=%s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "alice-password") || strings.Contains(rr.Body.String(), "$2a$") {
+		t.Fatalf("response leaked credentials: %s", rr.Body.String())
+	}
+	if rr := adminRequest(handler, admin, http.MethodPost, "/", `{"username":"alice","password":"alice-password"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("duplicate status = %d, want 409", rr.Code)
+	}
+	if rr := adminRequest(handler, admin, http.MethodPost, "/", `{"username":"bob","password":"short"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("weak password status = %d, want 400", rr.Code)
+	}
+
+	rr = adminRequest(handler, admin, http.MethodPost, "/alice/disable", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("disable status = %d, want 200", rr.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body["disabled"] != true {
+		t.Fatalf("disable response = %s", rr.Body.String())
+	}
+	if _, err := users.Authenticate(context.Background(), "alice", "alice-password"); err != domain.ErrUserDisabled {
+		t.Fatalf("disabled user authenticate error = %v", err)
+	}
+
+	if rr := adminRequest(handler, admin, http.MethodPost, "/alice/enable", ""); rr.Code != http.StatusOK {
+		t.Fatalf("enable status = %d, want 200", rr.Code)
+	}
+	if rr := adminRequest(handler, admin, http.MethodPost, "/alice/reset-password", `{"password":"new-alice-password"}`); rr.Code != http.StatusOK {
+		t.Fatalf("reset status = %d, want 200", rr.Code)
+	}
+	if _, err := users.Authenticate(context.Background(), "alice", "new-alice-password"); err != nil {
+		t.Fatalf("authenticate with reset password: %v", err)
+	}
+
+	if rr := adminRequest(handler, admin, http.MethodPost, "/alice/email", `{"email":"alice@example.com"}`); rr.Code != http.StatusOK {
+		t.Fatalf("set email status = %d, want 200; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := adminRequest(handler, admin, http.MethodPost, "/alice/email", `{"email":"alice at example"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid email status = %d, want 400", rr.Code)
+	}
+
+	rr = adminRequest(handler, admin, http.MethodGet, "/", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"email":"alice@example.com"`) {
+		t.Fatalf("list = %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := adminRequest(handler, admin, http.MethodPost, "/nobody/disable", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown user status = %d, want 404", rr.Code)
+	}
+	if rr := adminRequest(handler, admin, http.MethodPost, "/root/disable", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("self-disable status = %d, want 409", rr.Code)
+	}
+}
+
+func TestAdminUsersAPIRequiresAdmin(t *testing.T) {
+	handler, _ := newAdminUsersTestHandler(t)
+
+	if rr := adminRequest(handler, nil, http.MethodGet, "/", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d, want 401", rr.Code)
+	}
+	if rr := adminRequest(handler, &domain.User{Username: "alice"}, http.MethodGet, "/", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("non-admin status = %d, want 403", rr.Code)
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// AppPasswordsHandler serves /api/v1/app-passwords, the authenticated user's
+// per-device passwords. Mount it behind Authenticate and RequireSession.
+type AppPasswordsHandler struct {
+	passwords   *services.AppPasswordService
+	currentUser UserFromContext
+}
+
+func NewAppPasswordsHandler(passwords *services.AppPasswordService, currentUser UserFromContext) *AppPasswordsHandler {
+	return &AppPasswordsHandler{passwords: passwords, currentUser: currentUser}
+}
+
+func (h *AppPasswordsHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Post("/", h.handleCreate)
+	r.Delete("/{id}", h.handleRevoke)
+	return r
+}
+
+// appPasswordJSON never includes the secret or its hash. Credential matches
+// the credential label Sync Health reports for each client.
+type appPasswordJSON struct {
+	ID                    int64      `json:"id"`
+	Name                  string     `json:"name"`
+	Credential            string     `json:"credential"`
+	CreatedAt             time.Time  `json:"created_at"`
+	LastUsedAt            *time.Time `json:"last_used_at"`
+	LastClientFingerprint string     `json:"last_client_fingerprint,omitempty"`
+	Revoked               bool       `json:"revoked"`
+	RevokedAt             *time.Time `json:"revoked_at"`
+}
+
+// createdAppPasswordJSON is the only response that carries the secret.
+type createdAppPasswordJSON struct {
+	appPasswordJSON
+	Password string `json:"password"`
+}
+
+type createAppPasswordRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *AppPasswordsHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	passwords, err := h.passwords.List(r.Context(), user.ID)
+	if err != nil {
+		writeAppPasswordError(w, err)
+		return
+	}
+	result := make([]appPasswordJSON, 0, len(passwords))
+	for _, p := range passwords {
+		result = append(result, toAppPasswordJSON(p))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"app_passwords": result})
+}
+
+func (h *AppPasswordsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var req createAppPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	password, secret, err := h.passwords.Create(r.Context(), user, req.Name)
+	if err != nil {
+		writeAppPasswordError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createdAppPasswordJSON{appPasswordJSON: toAppPasswordJSON(password), Password: secret})
+}
+
+func (h *AppPasswordsHandler) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "app_password_not_found", "No active app password with this ID.")
+		return
+	}
+	if err := h.passwords.Revoke(r.Context(), user, id); err != nil {
+		writeAppPasswordError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toAppPasswordJSON(p *domain.AppPassword) appPasswordJSON {
+	return appPasswordJSON{
+		ID:                    p.ID,
+		Name:                  p.Name,
+		Credential:            domain.AppPasswordCredential(p.ID),
+		CreatedAt:             p.CreatedAt,
+		LastUsedAt:            p.LastUsedAt,
+		LastClientFingerprint: p.LastClientFingerprint,
+		Revoked:               p.Revoked(),
+		RevokedAt:             p.RevokedAt,
+	}
+}
+
+func writeAppPasswordError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAppPasswordName):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "app_password_not_found", "No active app password with this ID.")
+	default:
+		slog.Error("app password request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
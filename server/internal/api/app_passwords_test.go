@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestAppPasswordsAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	alice, err := services.NewUserService(userRepo, data.NewSQLiteCalendarRepo(db)).CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	devices := services.NewAppPasswordService(data.NewSQLiteAppPasswordRepo(db), userRepo)
+	handler := NewAppPasswordsHandler(devices, testUserFromContext).Routes()
+
+	rr := adminRequest(handler, alice, http.MethodPost, "/", `{"name":"iPhone"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201; body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		ID         int64  `json:"id"`
+		Password   string `json:"password"`
+		Credential string `json:"credential"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Password == "" {
+		t.Fatalf("create response = %s", rr.Body.String())
+	}
+	if created.Credential != domain.AppPasswordCredential(created.ID) {
+		t.Errorf("credential = %q", created.Credential)
+	}
+	if _, _, err := devices.Authenticate(ctx, "alice", created.Password, ""); err != nil {
+		t.Fatalf("authenticate with minted app password: %v", err)
+	}
+
+	rr = adminRequest(handler, alice, http.MethodGet, "/", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list status = %d", rr.Code)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `"name":"iPhone"`) || strings.Contains(body, created.Password) {
+		t.Fatalf("list leaked the secret: %s", body)
+	}
+
+	// Another user cannot revoke it
+	bob := &domain.User{ID: alice.ID + 100, Username: "bob"}
+	if rr := adminRequest(handler, bob, http.MethodDelete, "/"+strconv.FormatInt(created.ID, 10), ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("foreign revoke status = %d, want 404", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodDelete, "/"+strconv.FormatInt(created.ID, 10), ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("revoke status = %d, want 204", rr.Code)
+	}
+	if _, _, err := devices.Authenticate(ctx, "alice", created.Password, ""); err != domain.ErrInvalidCredentials {
+		t.Fatalf("revoked app password error = %v", err)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/", `{"name":""}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("blank name status = %d, want 400", rr.Code)
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/planner"
+)
+
+// AuditHandler serves /api/v1/audit, the authenticated user's audit log and
+// the rollback of its entries. Mount it behind Authenticate.
+type AuditHandler struct {
+	rollback    *planner.RollbackService
+	currentUser UserFromContext
+}
+
+func NewAuditHandler(rollback *planner.RollbackService, currentUser UserFromContext) *AuditHandler {
+	return &AuditHandler{rollback: rollback, currentUser: currentUser}
+}
+
+func (h *AuditHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Get("/{id}", h.handleGet)
+	r.Post("/{id}/rollback", h.handleRollback)
+	return r
+}
+
+// auditEntryJSON leaves out the calendar objects of the changes.
+type auditEntryJSON struct {
+	ID                int64             `json:"id"`
+	Action            string            `json:"action"`
+	EntityType        string            `json:"entity_type,omitempty"`
+	EntityID          string            `json:"entity_id,omitempty"`
+	Summary           string            `json:"summary"`
+	RollbackAvailable bool              `json:"rollback_available"`
+	Changes           []auditChangeJSON `json:"changes"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+type auditChangeJSON struct {
+	CalendarID int64  `json:"calendar_id"`
+	UID        string `json:"uid"`
+	Summary    string `json:"summary"`
+	Reverted   bool   `json:"reverted"`
+}
+
+// rollbackResultJSON is the outcome of a rollback. When nothing could be
+// reverted it carries error_code and message as well.
+type rollbackResultJSON struct {
+	Status                  string                 `json:"status"`
+	AuditLogEntryID         int64                  `json:"audit_log_entry_id"`
+	RollbackAuditLogEntryID int64                  `json:"rollback_audit_log_entry_id,omitempty"`
+	RollbackAvailable       bool                   `json:"rollback_available"`
+	RevertedChanges         []auditChangeJSON      `json:"reverted_changes"`
+	Conflicts               []rollbackConflictJSON `json:"conflicts"`
+	ErrorCode               string                 `json:"error_code,omitempty"`
+	Message                 string                 `json:"message,omitempty"`
+}
+
+type rollbackConflictJSON struct {
+	CalendarID int64  `json:"calendar_id"`
+	UID        string `json:"uid"`
+	Summary    string `json:"summary"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message"`
+}
+
+func (h *AuditHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer.")
+			return
+		}
+	}
+	entries, err := h.rollback.ListAudit(r.Context(), user.ID, limit)
+	if err != nil {
+		writeAuditError(w, err)
+		return
+	}
+	result := make([]auditEntryJSON, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, toAuditEntryJSON(entry))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": result})
+}
+
+func (h *AuditHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeAuditError(w, domain.ErrNotFound)
+		return
+	}
+	entry, err := h.rollback.Audit(r.Context(), user.ID, id)
+	if err != nil {
+		writeAuditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAuditEntryJSON(entry))
+}
+
+// handleRollback reverts the changes of an applied plan. Changes whose events
+// were edited since are reported as conflicts; when every change left
+// conflicts it answers 409 with error_code rollback_conflict.
+func (h *AuditHandler) handleRollback(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeAuditError(w, domain.ErrNotFound)
+		return
+	}
+	rolledBack, err := h.rollback.Rollback(r.Context(), user, id)
+	if err != nil && !errors.Is(err, planner.ErrRollbackConflict) {
+		writeAuditError(w, err)
+		return
+	}
+
+	result := rollbackResultJSON{
+		Status:            "rolled_back",
+		AuditLogEntryID:   id,
+		RollbackAvailable: rolledBack.Original.RollbackAvailable(),
+		RevertedChanges:   []auditChangeJSON{},
+		Conflicts:         make([]rollbackConflictJSON, 0, len(rolledBack.Conflicts)),
+	}
+	for _, c := range rolledBack.Conflicts {
+		result.Conflicts = append(result.Conflicts, rollbackConflictJSON{
+			CalendarID: c.CalendarID,
+			UID:        c.UID,
+			Summary:    c.Summary,
+			Reason:     c.Reason,
+			Message:    rollbackConflictMessage(c.Reason),
+		})
+	}
+	if err != nil {
+		result.Status = "rollback_conflict"
+		result.ErrorCode = "rollback_conflict"
+		result.Message = "Every event left to roll back was changed since the plan was applied."
+		writeJSON(w, http.StatusConflict, result)
+		return
+	}
+	if len(result.Conflicts) > 0 {
+		result.Status = "partially_rolled_back"
+	}
+	result.RollbackAuditLogEntryID = rolledBack.Entry.ID
+	for _, c := range rolledBack.Entry.Changes {
+		result.RevertedChanges = append(result.RevertedChanges, auditChangeJSON{CalendarID: c.CalendarID, UID: c.UID, Summary: c.Summary, Reverted: true})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func rollbackConflictMessage(reason string) string {
+	switch reason {
+	case planner.RollbackConflictDeleted:
+		return "The event was deleted since the plan was applied."
+	default:
+		return "The event was edited since the plan was applied. Roll it back by hand."
+	}
+}
+
+func toAuditEntryJSON(entry *domain.AuditLogEntry) auditEntryJSON {
+	changes := make([]auditChangeJSON, 0, len(entry.Changes))
+	for _, c := range entry.Changes {
+		changes = append(changes, auditChangeJSON{CalendarID: c.CalendarID, UID: c.UID, Summary: c.Summary, Reverted: c.RevertedBy != 0})
+	}
+	return auditEntryJSON{
+		ID:                entry.ID,
+		Action:            string(entry.Action),
+		EntityType:        entry.EntityType,
+		EntityID:          entry.EntityID,
+		Summary:           entry.Summary,
+		RollbackAvailable: entry.RollbackAvailable(),
+		Changes:           changes,
+		CreatedAt:         entry.CreatedAt,
+	}
+}
+
+func writeAuditError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, planner.ErrRollbackUnavailable):
+		writeJSONError(w, http.StatusConflict, "rollback_unavailable", "The entry has no changes left to roll back.")
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "audit_log_entry_not_found", "No audit log entry with this ID.")
+	default:
+		slog.Error("audit request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
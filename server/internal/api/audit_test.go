@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/caldav"
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/planner"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestAuditAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	alice, err := users.CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	bob, err := users.CreateUser(ctx, "bob", "bob-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	calendar, err := calendarRepo.GetByName(ctx, alice.ID, domain.DefaultCalendarName)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+
+	eventRepo := data.NewSQLiteEventRepo(db)
+	proposalRepo := data.NewSQLitePlanProposalRepo(db)
+	auditRepo := data.NewSQLiteAuditLogRepo(db)
+	proposals := planner.NewProposalService(proposalRepo, calendarRepo, eventRepo, planner.NewPreferenceService(data.NewSQLitePreferenceRepo(db)))
+	backend := caldav.NewBackend(db, userRepo, calendarRepo, eventRepo)
+	apply := planner.NewApplyService(proposals, proposalRepo, auditRepo, backend)
+	handler := NewAuditHandler(planner.NewRollbackService(eventRepo, proposalRepo, auditRepo, backend), testUserFromContext).Routes()
+
+	start := time.Date(2026, 11, 2, 13, 0, 0, 0, time.UTC)
+	proposal, err := proposals.Create(ctx, alice, "Focus on Monday", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: calendar.ID, Start: start, End: start.Add(2 * time.Hour)},
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: calendar.ID, Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	applied, err := apply.Apply(ctx, alice, proposal.ID)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	path := "/" + strconv.FormatInt(applied.Entry.ID, 10)
+
+	if rr := adminRequest(handler, bob, http.MethodGet, path, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("other user's get status = %d, want 404", rr.Code)
+	}
+	if rr := adminRequest(handler, bob, http.MethodPost, path+"/rollback", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("other user's rollback status = %d, want 404", rr.Code)
+	}
+	rr := adminRequest(handler, alice, http.MethodGet, "/?limit=10", "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"action":"plan_apply"`) ||
+		!strings.Contains(body, `"rollback_available":true`) || strings.Contains(body, "BEGIN:VCALENDAR") {
+		t.Fatalf("list = %d %s", rr.Code, body)
+	}
+
+	// A client deletes the second block; the rollback removes the first and
+	// reports the second
+	if err := eventRepo.Delete(ctx, calendar.ID, proposal.Changes[1].UID); err != nil {
+		t.Fatalf("delete focus block: %v", err)
+	}
+	rr = adminRequest(handler, alice, http.MethodPost, path+"/rollback", "")
+	var result struct {
+		Status                  string `json:"status"`
+		RollbackAuditLogEntryID int64  `json:"rollback_audit_log_entry_id"`
+		RollbackAvailable       bool   `json:"rollback_available"`
+		RevertedChanges         []struct {
+			UID string `json:"uid"`
+		} `json:"reverted_changes"`
+		Conflicts []struct {
+			UID    string `json:"uid"`
+			Reason string `json:"reason"`
+		} `json:"conflicts"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("rollback = %d %s", rr.Code, rr.Body.String())
+	}
+	if result.Status != "partially_rolled_back" || result.RollbackAuditLogEntryID == 0 || !result.RollbackAvailable ||
+		len(result.RevertedChanges) != 1 || result.RevertedChanges[0].UID != proposal.Changes[0].UID ||
+		len(result.Conflicts) != 1 || result.Conflicts[0].Reason != planner.RollbackConflictDeleted {
+		t.Fatalf("rollback = %s", rr.Body.String())
+	}
+	if _, err := eventRepo.GetByUID(ctx, calendar.ID, proposal.Changes[0].UID); err == nil {
+		t.Fatal("focus block not deleted by the rollback")
+	}
+
+	rr = adminRequest(handler, alice, http.MethodPost, path+"/rollback", "")
+	if body := rr.Body.String(); rr.Code != http.StatusConflict || !strings.Contains(body, `"error_code":"rollback_conflict"`) ||
+		!strings.Contains(body, `"reason":"event_deleted"`) {
+		t.Fatalf("second rollback = %d %s", rr.Code, body)
+	}
+	rollbackPath := "/" + strconv.FormatInt(result.RollbackAuditLogEntryID, 10)
+	rr = adminRequest(handler, alice, http.MethodGet, rollbackPath, "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"action":"plan_rollback"`) || !strings.Contains(body, `"rollback_available":false`) {
+		t.Fatalf("get rollback entry = %d %s", rr.Code, body)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, rollbackPath+"/rollback", ""); rr.Code != http.StatusConflict ||
+		!strings.Contains(rr.Body.String(), "rollback_unavailable") {
+		t.Fatalf("rollback of a rollback = %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// AuthHandler serves /api/v1/auth: web UI login and logout.
+type AuthHandler struct {
+	auth     *services.APIAuthService
+	throttle *services.LoginThrottle
+}
+
+// NewAuthHandler returns the login handler. Pass the server's shared throttle
+// so failed logins here and over Basic Auth count toward the same lockouts;
+// nil disables lockouts.
+func NewAuthHandler(auth *services.APIAuthService, throttle *services.LoginThrottle) *AuthHandler {
+	return &AuthHandler{auth: auth, throttle: throttle}
+}
+
+// Routes returns the router to mount at /api/v1/auth. Login is public; the
+// other routes run behind authenticate.
+func (h *AuthHandler) Routes(authenticate func(http.Handler) http.Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/login", h.handleLogin)
+	r.Group(func(r chi.Router) {
+		r.Use(authenticate)
+		r.Get("/session", h.handleSession)
+		r.Post("/logout", h.handleLogout)
+	})
+	return r
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// sessionJSON is what the web UI needs after login or a reload: who is
+// signed in and the CSRF token to send with unsafe requests.
+type sessionJSON struct {
+	Username  string    `json:"username"`
+	Admin     bool      `json:"admin"`
+	CSRFToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	// Only JSON bodies: cross-site forms cannot send them without a CORS
+	// preflight, which protects the login itself from CSRF
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+
+	// Locked out clients and usernames are refused before the password is
+	// checked, even if it is right
+	ip := clientIP(r)
+	if h.throttle != nil {
+		if wait := h.throttle.Check(ip, req.Username); wait > 0 {
+			writeTooManyAttempts(w, wait)
+			return
+		}
+	}
+
+	user, session, secret, err := h.auth.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) || errors.Is(err, domain.ErrUserDisabled) {
+			if h.throttle != nil {
+				if lockout := h.throttle.Failure(ip, req.Username); lockout > 0 {
+					writeTooManyAttempts(w, lockout)
+					return
+				}
+			}
+			writeJSONError(w, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password.")
+			return
+		}
+		writeAuthError(w, err)
+		return
+	}
+	if h.throttle != nil {
+		h.throttle.Success(req.Username)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    secret,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeJSON(w, http.StatusOK, toSessionJSON(user, session))
+}
+
+func (h *AuthHandler) handleSession(w http.ResponseWriter, r *http.Request) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil || principal.Session == nil {
+		writeJSONError(w, http.StatusForbidden, "session_required", "Not signed in with a session.")
+		return
+	}
+	writeJSON(w, http.StatusOK, toSessionJSON(principal.User, principal.Session))
+}
+
+func (h *AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil || principal.Session == nil {
+		writeJSONError(w, http.StatusForbidden, "session_required", "Not signed in with a session.")
+		return
+	}
+	if err := h.auth.Logout(r.Context(), principal.Session); err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toSessionJSON(user *domain.User, session *domain.Session) sessionJSON {
+	return sessionJSON{
+		Username:  user.Username,
+		Admin:     user.IsAdmin,
+		CSRFToken: session.CSRFToken,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+// writeTooManyAttempts answers a locked out login with 429 and Retry-After.
+func writeTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeJSONError(w, http.StatusTooManyRequests, "too_many_attempts", "Too many failed sign-ins. Try again later.")
+}
+
+// clientIP returns the host part of RemoteAddr: the TCP peer, or the client
+// a trusted proxy forwarded for when the server's api.RealIP middleware
+// replaced it. Forwarded headers from anyone else are ignored.
+// It keys the login throttle the same way as CalDAV's Basic Auth.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isHTTPS reports whether the client reached us over TLS, directly or through
+// a reverse proxy.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
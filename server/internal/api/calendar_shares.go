@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// CalendarSharesHandler serves /api/v1/calendars/{calendar}/shares, the users
+// a calendar of the authenticated user is shared with. Grantees see the
+// calendar in their CalDAV calendar home as {calendar}@{owner}.
+type CalendarSharesHandler struct {
+	shares      *services.CalendarShareService
+	currentUser UserFromContext
+}
+
+func NewCalendarSharesHandler(shares *services.CalendarShareService, currentUser UserFromContext) *CalendarSharesHandler {
+	return &CalendarSharesHandler{shares: shares, currentUser: currentUser}
+}
+
+func (h *CalendarSharesHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{calendar}/shares", h.handleList)
+	r.Put("/{calendar}/shares/{username}", h.handlePut)
+	r.Delete("/{calendar}/shares/{username}", h.handleDelete)
+	return r
+}
+
+type calendarShareJSON struct {
+	Username  string    `json:"username"`
+	Privilege string    `json:"privilege"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type putCalendarShareRequest struct {
+	Privilege string `json:"privilege"`
+}
+
+func (h *CalendarSharesHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	grants, err := h.shares.List(r.Context(), user, chi.URLParam(r, "calendar"))
+	if err != nil {
+		writeCalendarShareError(w, err)
+		return
+	}
+	result := make([]calendarShareJSON, 0, len(grants))
+	for _, g := range grants {
+		result = append(result, toCalendarShareJSON(g))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"shares": result})
+}
+
+func (h *CalendarSharesHandler) handlePut(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var req putCalendarShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	grant, err := h.shares.Share(r.Context(), user, chi.URLParam(r, "calendar"), chi.URLParam(r, "username"), domain.SharePrivilege(req.Privilege))
+	if err != nil {
+		writeCalendarShareError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toCalendarShareJSON(*grant))
+}
+
+func (h *CalendarSharesHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	if err := h.shares.Unshare(r.Context(), user, chi.URLParam(r, "calendar"), chi.URLParam(r, "username")); err != nil {
+		writeCalendarShareError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toCalendarShareJSON(g services.CalendarGrant) calendarShareJSON {
+	return calendarShareJSON{
+		Username:  g.Grantee,
+		Privilege: string(g.Share.Privilege),
+		CreatedAt: g.Share.CreatedAt,
+		UpdatedAt: g.Share.UpdatedAt,
+	}
+}
+
+func writeCalendarShareError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSharePrivilege), errors.Is(err, services.ErrShareWithSelf):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, services.ErrShareGranteeNotFound):
+		writeJSONError(w, http.StatusNotFound, "user_not_found", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "not_found", "No such calendar or share.")
+	default:
+		slog.Error("calendar share request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
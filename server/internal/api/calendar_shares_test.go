@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestCalendarSharesAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	alice, err := users.CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	bob, _ := users.CreateUser(ctx, "bob", "bob-password", false)
+	shareRepo := data.NewSQLiteCalendarShareRepo(db)
+	handler := NewCalendarSharesHandler(services.NewCalendarShareService(shareRepo, calendarRepo, userRepo, nil), testUserFromContext).Routes()
+
+	rr := adminRequest(handler, alice, http.MethodPut, "/default/shares/bob", `{"privilege":"read"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"privilege":"read"`) {
+		t.Fatalf("share status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+	rr = adminRequest(handler, alice, http.MethodGet, "/default/shares", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"username":"bob"`) {
+		t.Fatalf("list status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+
+	// Bob cannot see or change the shares of alice's calendar: it is not in his account
+	if rr := adminRequest(handler, bob, http.MethodGet, "/default/shares", ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "bob") {
+		t.Fatalf("grantee list status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := adminRequest(handler, alice, http.MethodPut, "/default/shares/bob", `{"privilege":"owner"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid privilege status = %d, want 400", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPut, "/default/shares/nobody", `{"privilege":"read"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown grantee status = %d, want 404", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPut, "/missing/shares/bob", `{"privilege":"read"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown calendar status = %d, want 404", rr.Code)
+	}
+
+	if rr := adminRequest(handler, alice, http.MethodDelete, "/default/shares/bob", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("unshare status = %d, want 204", rr.Code)
+	}
+	if shares, _ := shareRepo.ListByGrantee(ctx, bob.ID); len(shares) != 0 {
+		t.Fatalf("share not removed: %+v", shares)
+	}
+	if rr := adminRequest(handler, alice, http.MethodDelete, "/default/shares/bob", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("second unshare status = %d, want 404", rr.Code)
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// eventStreamKeepAlive is how often an idle event stream sends a comment, so
+// proxies keep the connection open.
+const eventStreamKeepAlive = 30 * time.Second
+
+// EventStreamHandler serves /events, a Server-Sent Events stream of what
+// happens to the authenticated user's data: event and calendar changes in
+// their own and shared calendars, Sync Health status changes and fired
+// reminders. Every notification carries an id; a client that reconnects with
+// Last-Event-ID gets what it missed, or a "resync" event when the server no
+// longer has it.
+type EventStreamHandler struct {
+	bus         *services.NotificationBus
+	currentUser UserFromContext
+}
+
+func NewEventStreamHandler(bus *services.NotificationBus, currentUser UserFromContext) *EventStreamHandler {
+	return &EventStreamHandler{bus: bus, currentUser: currentUser}
+}
+
+type streamEventJSON struct {
+	Type       string                `json:"type"`
+	OccurredAt *time.Time            `json:"occurred_at,omitempty"`
+	Calendar   *streamCalendarJSON   `json:"calendar,omitempty"`
+	UID        string                `json:"uid,omitempty"`
+	Event      *streamEventDataJSON  `json:"event,omitempty"`
+	Grantee    string                `json:"grantee,omitempty"`
+	SyncHealth *streamSyncHealthJSON `json:"sync_health,omitempty"`
+	Reminder   *reminderJSON         `json:"reminder,omitempty"`
+}
+
+type streamCalendarJSON struct {
+	ID          int64  `json:"id"`
+	OwnerID     int64  `json:"owner_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Color       string `json:"color"`
+}
+
+type streamEventDataJSON struct {
+	ETag      string    `json:"etag"`
+	Summary   string    `json:"summary"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	AllDay    bool      `json:"all_day"`
+	Recurring bool      `json:"recurring"`
+}
+
+type streamSyncHealthJSON struct {
+	From    string                 `json:"from"`
+	To      string                 `json:"to"`
+	Reasons []syncHealthReasonJSON `json:"reasons"`
+}
+
+func (h *EventStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the server's WriteTimeout, which is meant for
+	// ordinary requests
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("event stream write deadline not cleared", "error", err)
+	}
+
+	// Reconnecting clients send the ID of the last notification they saw. One
+	// we cannot resume from, e.g. from before a restart, means a resync.
+	var after int64
+	resync := false
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			resync = true
+		} else {
+			after = id
+		}
+	}
+	sub, err := h.bus.Subscribe(user.ID, after)
+	if err == services.ErrNotificationsExpired {
+		resync = true
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(id int64, event streamEventJSON) {
+		data, _ := json.Marshal(event)
+		if id > 0 {
+			fmt.Fprintf(w, "id: %d\n", id)
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	// A client catching up keeps its place until the missed notifications
+	// arrive; anyone else resumes from the connection
+	if after > 0 && !resync {
+		send(0, streamEventJSON{Type: "connected"})
+	} else {
+		send(sub.LastID, streamEventJSON{Type: "connected"})
+	}
+	if resync {
+		send(0, streamEventJSON{Type: "resync"})
+	}
+	for _, n := range sub.Missed {
+		send(n.ID, toStreamEventJSON(n))
+	}
+
+	ticker := time.NewTicker(eventStreamKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n, ok := <-sub.C:
+			if !ok {
+				// Fell behind; the client reconnects and catches up
+				return
+			}
+			send(n.ID, toStreamEventJSON(n))
+		case <-ticker.C:
+			fmt.Fprintf(w, ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func toStreamEventJSON(n *domain.Notification) streamEventJSON {
+	occurredAt := n.OccurredAt
+	event := streamEventJSON{Type: string(n.Type), OccurredAt: &occurredAt, UID: n.UID}
+	if n.Calendar != nil {
+		event.Calendar = &streamCalendarJSON{
+			ID:          n.Calendar.ID,
+			OwnerID:     n.Calendar.UserID,
+			Name:        n.Calendar.Name,
+			DisplayName: n.Calendar.DisplayName,
+			Color:       n.Calendar.Color,
+		}
+	}
+	if n.Event != nil {
+		event.Event = &streamEventDataJSON{
+			ETag:      n.Event.ETag,
+			Summary:   n.Event.Summary,
+			Start:     n.Event.StartTime,
+			End:       n.Event.EndTime,
+			AllDay:    n.Event.AllDay,
+			Recurring: n.Event.RecurrenceRule != "" || n.Event.RecurrenceDates != "",
+		}
+	}
+	if n.Grantee != nil {
+		event.Grantee = n.Grantee.Username
+	}
+	if n.SyncHealth != nil {
+		event.SyncHealth = &streamSyncHealthJSON{
+			From:    string(n.SyncHealth.From),
+			To:      string(n.SyncHealth.To),
+			Reasons: toReasonsJSON(n.SyncHealth.Reasons),
+		}
+	}
+	if n.Reminder != nil {
+		payload := toReminderJSON(n.Reminder)
+		event.Reminder = &payload
+	}
+	return event
+}
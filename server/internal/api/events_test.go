@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// streamMessage is one message of an event stream.
+type streamMessage struct {
+	id   string
+	data string
+}
+
+func openEventStream(t *testing.T, url, lastEventID string) func() streamMessage {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	lines := bufio.NewScanner(resp.Body)
+	return func() streamMessage {
+		t.Helper()
+		var msg streamMessage
+		for lines.Scan() {
+			line := lines.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				msg.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				msg.data = strings.TrimPrefix(line, "data: ")
+			case line == "" && msg.data != "":
+				return msg
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return msg
+	}
+}
+
+func TestEventStreamResumesFromLastEventID(t *testing.T) {
+	ctx := context.Background()
+	bus := services.NewNotificationBus(0)
+	alice := &domain.User{ID: 1, Username: "alice"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), contextUserKey{}, alice))
+		NewEventStreamHandler(bus, testUserFromContext).ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close) // after the streams are closed
+
+	calendar := &domain.Calendar{ID: 7, UserID: 1, Name: "default", DisplayName: "Personal"}
+	next := openEventStream(t, srv.URL, "")
+	connected := next()
+	if !strings.Contains(connected.data, `"type":"connected"`) || connected.id == "" {
+		t.Fatalf("first message = %+v, want connected with an id", connected)
+	}
+
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventCreated, UserIDs: []int64{1, 2}, Calendar: calendar, UID: "standup",
+		Event: &domain.Event{UID: "standup", ETag: `"abc"`, Summary: "Standup"}})
+	created := next()
+	if !strings.Contains(created.data, `"type":"event.created"`) || !strings.Contains(created.data, `"uid":"standup"`) ||
+		!strings.Contains(created.data, `"summary":"Standup"`) || !strings.Contains(created.data, `"display_name":"Personal"`) {
+		t.Fatalf("event.created message = %s", created.data)
+	}
+	if id, _ := strconv.ParseInt(created.id, 10, 64); id <= 0 {
+		t.Fatalf("event.created id = %q", created.id)
+	}
+
+	// Published while the client was away
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventDeleted, UserIDs: []int64{2}, Calendar: calendar, UID: "private"})
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventDeleted, UserIDs: []int64{1}, Calendar: calendar, UID: "standup"})
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationSyncHealthChanged,
+		SyncHealth: &domain.SyncHealthTransition{From: domain.SyncHealthHealthy, To: domain.SyncHealthWarning}})
+
+	resumed := openEventStream(t, srv.URL, created.id)
+	if msg := resumed(); !strings.Contains(msg.data, `"type":"connected"`) || msg.id != "" {
+		t.Fatalf("resumed connection message = %+v, want connected without an id", msg)
+	}
+	if msg := resumed(); !strings.Contains(msg.data, `"type":"event.deleted"`) || !strings.Contains(msg.data, `"uid":"standup"`) {
+		t.Fatalf("first missed message = %s, want alice's deletion only", msg.data)
+	}
+	if msg := resumed(); !strings.Contains(msg.data, `"type":"sync_health.changed"`) || !strings.Contains(msg.data, `"from":"healthy","to":"warning"`) {
+		t.Fatalf("second missed message = %s", msg.data)
+	}
+
+	// IDs the server does not know call for a resync
+	stale := openEventStream(t, srv.URL, "12")
+	if msg := stale(); !strings.Contains(msg.data, `"type":"connected"`) || msg.id == "" {
+		t.Fatalf("stale connection message = %+v, want connected with an id", msg)
+	}
+	if msg := stale(); !strings.Contains(msg.data, `"type":"resync"`) {
+		t.Fatalf("stale resume message = %s, want resync", msg.data)
+	}
+}
+
+func TestEventStreamOutlivesWriteTimeout(t *testing.T) {
+	bus := services.NewNotificationBus(0)
+	alice := &domain.User{ID: 1, Username: "alice"}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), contextUserKey{}, alice))
+		NewEventStreamHandler(bus, testUserFromContext).ServeHTTP(w, r)
+	}))
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	next := openEventStream(t, srv.URL, "")
+	if msg := next(); !strings.Contains(msg.data, `"type":"connected"`) {
+		t.Fatalf("first message = %+v, want connected", msg)
+	}
+	time.Sleep(3 * srv.Config.WriteTimeout)
+	bus.Publish(context.Background(), &domain.Notification{Type: domain.NotificationEventDeleted, UserIDs: []int64{1},
+		Calendar: &domain.Calendar{ID: 7, UserID: 1, Name: "default"}, UID: "standup"})
+	if msg := next(); !strings.Contains(msg.data, `"type":"event.deleted"`) {
+		t.Fatalf("message after the write timeout = %+v, want event.deleted", msg)
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/planner"
+)
+
+// FocusHandler serves /api/v1/focus: focus time suggestions across all of
+// the authenticated user's calendars, and booking them. Mount it behind
+// Authenticate.
+type FocusHandler struct {
+	focus       *planner.FocusService
+	currentUser UserFromContext
+}
+
+func NewFocusHandler(focus *planner.FocusService, currentUser UserFromContext) *FocusHandler {
+	return &FocusHandler{focus: focus, currentUser: currentUser}
+}
+
+func (h *FocusHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/suggestions", h.handleSuggestions)
+	r.Post("/blocks", h.handleBook)
+	return r
+}
+
+// focusIntervalJSON is a free interval or a suggestion; times are in the
+// user's time zone. Score and fragmented_minutes are only set on suggestions.
+type focusIntervalJSON struct {
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	Minutes           int       `json:"minutes"`
+	Score             *int      `json:"score,omitempty"`
+	FragmentedMinutes *int      `json:"fragmented_minutes,omitempty"`
+}
+
+type focusSuggestionsJSON struct {
+	TimeZone    string              `json:"time_zone"`
+	Start       time.Time           `json:"start"`
+	End         time.Time           `json:"end"`
+	Free        []focusIntervalJSON `json:"free"`
+	Suggestions []focusIntervalJSON `json:"suggestions"`
+}
+
+type bookFocusRequest struct {
+	CalendarID int64      `json:"calendar_id"`
+	Start      *time.Time `json:"start"`
+	End        *time.Time `json:"end"`
+	Summary    string     `json:"summary"`
+}
+
+// handleSuggestions answers GET /suggestions?date=YYYY-MM-DD&range=day|week
+// with the free working time of the day or week and the best focus blocks in
+// it. Optional min_minutes, max_minutes, time_of_day (any, morning,
+// afternoon) and limit shape the suggestions; date defaults to today.
+func (h *FocusHandler) handleSuggestions(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	query := r.URL.Query()
+	q := planner.FocusQuery{TimeOfDay: planner.TimeOfDay(query.Get("time_of_day"))}
+	var minMinutes, maxMinutes int
+	if value := query.Get("date"); value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "date must be YYYY-MM-DD.")
+			return
+		}
+		q.Date = date
+	}
+	switch query.Get("range") {
+	case "", "day":
+	case "week":
+		q.Week = true
+	default:
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "range must be day or week.")
+		return
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"min_minutes", &minMinutes}, {"max_minutes", &maxMinutes}, {"limit", &q.Limit}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", param.name+" must be a positive integer.")
+			return
+		}
+		*param.value = n
+	}
+	q.MinDuration = time.Duration(minMinutes) * time.Minute
+	q.MaxDuration = time.Duration(maxMinutes) * time.Minute
+
+	suggestions, err := h.focus.Suggest(r.Context(), user.ID, q)
+	if err != nil {
+		writeFocusError(w, err)
+		return
+	}
+	loc := suggestions.Location
+	result := focusSuggestionsJSON{
+		TimeZone:    loc.String(),
+		Start:       suggestions.Start.In(loc),
+		End:         suggestions.End.In(loc),
+		Free:        make([]focusIntervalJSON, 0, len(suggestions.Free)),
+		Suggestions: make([]focusIntervalJSON, 0, len(suggestions.Suggestions)),
+	}
+	for _, free := range suggestions.Free {
+		result.Free = append(result.Free, focusIntervalJSON{
+			Start:   free.Start.In(loc),
+			End:     free.End.In(loc),
+			Minutes: int(free.End.Sub(free.Start) / time.Minute),
+		})
+	}
+	for _, s := range suggestions.Suggestions {
+		score, fragmented := s.Score, int(s.Fragmentation/time.Minute)
+		result.Suggestions = append(result.Suggestions, focusIntervalJSON{
+			Start:             s.Start.In(loc),
+			End:               s.End.In(loc),
+			Minutes:           int(s.End.Sub(s.Start) / time.Minute),
+			Score:             &score,
+			FragmentedMinutes: &fragmented,
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleBook writes a focus event to the calendar in the body. It is applied
+// like a plan proposal and answered the same way; a block that breaks a
+// planning constraint is answered with 409 and error_code validation_failed
+// with the violations.
+func (h *FocusHandler) handleBook(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var req bookFocusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	block := planner.FocusBlock{CalendarID: req.CalendarID, Summary: req.Summary}
+	if req.Start != nil {
+		block.Start = *req.Start
+	}
+	if req.End != nil {
+		block.End = *req.End
+	}
+
+	booking, err := h.focus.Book(r.Context(), user, block)
+	if errors.Is(err, planner.ErrProposalHasViolations) {
+		proposal := toProposalJSON(booking.Proposal)
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error_code":  "validation_failed",
+			"message":     "The focus block cannot be booked.",
+			"proposal_id": proposal.ID,
+			"violations":  proposal.Violations,
+		})
+		return
+	}
+	if err != nil {
+		writeFocusError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toApplyResultJSON(booking.Applied))
+}
+
+func writeFocusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, planner.ErrInvalidFocusQuery), errors.Is(err, planner.ErrInvalidProposal):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, planner.ErrProposalStale):
+		writeJSONError(w, http.StatusConflict, "stale_proposal", "Calendar changed. Try again.")
+	default:
+		slog.Error("focus request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
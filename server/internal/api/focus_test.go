@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/caldav"
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/planner"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestFocusAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	alice, err := users.CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	calendar, err := calendarRepo.GetByName(ctx, alice.ID, domain.DefaultCalendarName)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+
+	eventRepo := data.NewSQLiteEventRepo(db)
+	proposalRepo := data.NewSQLitePlanProposalRepo(db)
+	proposals := planner.NewProposalService(proposalRepo, calendarRepo, eventRepo, planner.NewPreferenceService(data.NewSQLitePreferenceRepo(db)))
+	backend := caldav.NewBackend(db, userRepo, calendarRepo, eventRepo)
+	apply := planner.NewApplyService(proposals, proposalRepo, data.NewSQLiteAuditLogRepo(db), backend)
+	handler := NewFocusHandler(planner.NewFocusService(proposals, apply), testUserFromContext).Routes()
+
+	// A Monday far enough ahead to be free all day
+	monday := time.Now().UTC().AddDate(0, 0, 14)
+	monday = time.Date(monday.Year(), monday.Month(), monday.Day()-(int(monday.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	path := "/suggestions?date=" + monday.Format(time.DateOnly)
+
+	for _, query := range []string{"&range=month", "&min_minutes=0", "&limit=x", "&time_of_day=evening", "&min_minutes=120&max_minutes=60"} {
+		if rr := adminRequest(handler, alice, http.MethodGet, path+query, ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("suggestions%s = %d, want 400", query, rr.Code)
+		}
+	}
+
+	rr := adminRequest(handler, alice, http.MethodGet, path+"&time_of_day=morning&max_minutes=120&limit=2", "")
+	var suggestions struct {
+		TimeZone string `json:"time_zone"`
+		Free     []struct {
+			Minutes int `json:"minutes"`
+		} `json:"free"`
+		Suggestions []struct {
+			Start   time.Time `json:"start"`
+			End     time.Time `json:"end"`
+			Minutes int       `json:"minutes"`
+			Score   int       `json:"score"`
+		} `json:"suggestions"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &suggestions); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("suggestions = %d %s", rr.Code, rr.Body.String())
+	}
+	if suggestions.TimeZone != "UTC" || len(suggestions.Free) != 1 || suggestions.Free[0].Minutes != 480 ||
+		len(suggestions.Suggestions) != 2 || suggestions.Suggestions[0].Minutes != 120 || !suggestions.Suggestions[0].Start.Equal(monday.Add(9*time.Hour)) {
+		t.Fatalf("suggestions = %s", rr.Body.String())
+	}
+
+	first := suggestions.Suggestions[0]
+	body := `{"calendar_id": ` + strconv.FormatInt(calendar.ID, 10) + `, "start": "` + first.Start.Format(time.RFC3339) +
+		`", "end": "` + first.End.Format(time.RFC3339) + `", "summary": "Deep work"}`
+	rr = adminRequest(handler, alice, http.MethodPost, "/blocks", body)
+	var booked struct {
+		Status          string `json:"status"`
+		AuditLogEntryID int64  `json:"audit_log_entry_id"`
+		AppliedChanges  []struct {
+			UID string `json:"uid"`
+		} `json:"applied_changes"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &booked); err != nil || rr.Code != http.StatusCreated ||
+		booked.Status != string(domain.ProposalApplied) || booked.AuditLogEntryID == 0 || len(booked.AppliedChanges) != 1 {
+		t.Fatalf("book = %d %s", rr.Code, rr.Body.String())
+	}
+	event, err := eventRepo.GetByUID(ctx, calendar.ID, booked.AppliedChanges[0].UID)
+	if err != nil || !strings.Contains(event.ICS, planner.PropFocus+":TRUE") || event.Summary != "Deep work" {
+		t.Fatalf("focus event = %+v, %v", event, err)
+	}
+
+	// The booked block is no longer free
+	rr = adminRequest(handler, alice, http.MethodGet, path, "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"minutes":360`) || strings.Contains(body, `"minutes":480`) {
+		t.Fatalf("suggestions after booking = %d %s", rr.Code, body)
+	}
+	rr = adminRequest(handler, alice, http.MethodPost, "/blocks", body)
+	if body := rr.Body.String(); rr.Code != http.StatusConflict || !strings.Contains(body, `"error_code":"validation_failed"`) ||
+		!strings.Contains(body, `"code":"time_conflict"`) {
+		t.Fatalf("booking the same block again = %d %s", rr.Code, body)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/blocks", `{"calendar_id": 0}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("book without a calendar = %d, want 400", rr.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/integrations/imip"
+)
+
+// maxInboundEmailSize bounds the body of POST /api/v1/imip/inbound.
+const maxInboundEmailSize = 10 << 20
+
+// IMIPHandler serves /api/v1/imip/inbound, which takes an iMIP reply email
+// (.eml, message/rfc822) addressed to the authenticated user and applies it
+// to their copy of the meeting. Admins may post replies for any local user,
+// e.g. from a mail server hook.
+type IMIPHandler struct {
+	inbound     domain.ScheduleInbound
+	currentUser UserFromContext
+}
+
+func NewIMIPHandler(inbound domain.ScheduleInbound, currentUser UserFromContext) *IMIPHandler {
+	return &IMIPHandler{inbound: inbound, currentUser: currentUser}
+}
+
+func (h *IMIPHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/inbound", h.handleInbound)
+	return r
+}
+
+type inboundIMIPJSON struct {
+	Method     string   `json:"method"`
+	UID        string   `json:"uid"`
+	Originator string   `json:"originator"`
+	Recipients []string `json:"recipients"`
+}
+
+func (h *IMIPHandler) handleInbound(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	msg, err := imip.ParseMessage(http.MaxBytesReader(w, r.Body, maxInboundEmailSize))
+	if err != nil {
+		writeIMIPParseError(w, err)
+		return
+	}
+	if !user.IsAdmin && !addressedTo(msg, user) {
+		writeJSONError(w, http.StatusForbidden, "forbidden", "The message is not addressed to you.")
+		return
+	}
+	if err := h.inbound.ReceiveITIP(r.Context(), msg); err != nil {
+		writeIMIPError(w, err)
+		return
+	}
+	slog.Info("imip.inbound.received", "username", user.Username, "method", msg.Method, "uid", msg.UID)
+	writeJSON(w, http.StatusOK, inboundIMIPJSON{Method: msg.Method, UID: msg.UID, Originator: msg.Originator, Recipients: msg.Recipients})
+}
+
+// addressedTo reports whether user's email is one of the message's recipients.
+func addressedTo(msg *domain.ITIPMessage, user *domain.User) bool {
+	if user.Email == "" {
+		return false
+	}
+	for _, recipient := range msg.Recipients {
+		if domain.SameCalendarAddress(recipient, domain.MailtoAddress(user.Email)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIMIPParseError answers a message that could not be read as iMIP.
+func writeIMIPParseError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "too_large", "The message is too large.")
+	case errors.Is(err, domain.ErrUnsupportedITIPMethod):
+		writeJSONError(w, http.StatusUnprocessableEntity, "unsupported_method", "Only iTIP replies are accepted.")
+	case errors.Is(err, imip.ErrOriginatorMismatch):
+		writeJSONError(w, http.StatusUnprocessableEntity, "originator_mismatch", err.Error())
+	default:
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	}
+}
+
+func writeIMIPError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedITIPMethod):
+		writeJSONError(w, http.StatusUnprocessableEntity, "unsupported_method", "Only iTIP replies are accepted.")
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "no_local_recipient", "No recipient of the message is a user of this server.")
+	default:
+		slog.Error("imip inbound request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type fakeScheduleInbound struct {
+	received []*domain.ITIPMessage
+	err      error
+}
+
+func (f *fakeScheduleInbound) ReceiveITIP(ctx context.Context, msg *domain.ITIPMessage) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.received = append(f.received, msg)
+	return nil
+}
+
+func imipReplyEmail(from string) string {
+	return strings.ReplaceAll(`From: `+from+`
+To: alice@example.com
+Subject: Accepted: Planning
+MIME-Version: 1.0
+Content-Type: text/calendar; method=REPLY; charset=utf-8
+
+BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//Test//EN
+METHOD:REPLY
+BEGIN:VEVENT
+UID:planning
+DTSTAMP:20260116T080000Z
+DTSTART:20260120T100000Z
+ORGANIZER:mailto:alice@example.com
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:carol@remote.example
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n")
+}
+
+func TestIMIPInboundAPI(t *testing.T) {
+	inbound := &fakeScheduleInbound{}
+	handler := NewIMIPHandler(inbound, testUserFromContext).Routes()
+	alice := &domain.User{Username: "alice", Email: "Alice@example.com"}
+	bob := &domain.User{Username: "bob", Email: "bob@example.com"}
+
+	rr := adminRequest(handler, alice, http.MethodPost, "/inbound", imipReplyEmail("carol@remote.example"))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"method":"REPLY"`) {
+		t.Fatalf("inbound status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if len(inbound.received) != 1 || inbound.received[0].Originator != "mailto:carol@remote.example" {
+		t.Fatalf("received = %+v", inbound.received)
+	}
+
+	if rr := adminRequest(handler, bob, http.MethodPost, "/inbound", imipReplyEmail("carol@remote.example")); rr.Code != http.StatusForbidden {
+		t.Errorf("reply for another user: status = %d, want 403", rr.Code)
+	}
+	if rr := adminRequest(handler, &domain.User{Username: "root", IsAdmin: true}, http.MethodPost, "/inbound", imipReplyEmail("carol@remote.example")); rr.Code != http.StatusOK {
+		t.Errorf("admin posting for alice: status = %d, want 200", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/inbound", imipReplyEmail("mallory@evil.example")); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("forged sender: status = %d, want 422", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/inbound", "not an email"); rr.Code != http.StatusBadRequest {
+		t.Errorf("garbage: status = %d, want 400", rr.Code)
+	}
+
+	inbound.err = domain.ErrNotFound
+	if rr := adminRequest(handler, alice, http.MethodPost, "/inbound", imipReplyEmail("carol@remote.example")); rr.Code != http.StatusNotFound {
+		t.Errorf("no local recipient: status = %d, want 404", rr.Code)
+	}
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+const (
+	// SessionCookieName is the HttpOnly cookie that carries a web UI session.
+	SessionCookieName = "calendarapp_session"
+
+	// CSRFHeader must carry the session's CSRF token on requests that change
+	// state. Cross-site pages can make the browser send the cookie but cannot
+	// read the token.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// UserFromContext returns the authenticated user of a request, or nil.
+type UserFromContext func(ctx context.Context) *domain.User
+
+// Principal is who a request authenticated as, and with what: exactly one of
+// Session and Token is set.
+type Principal struct {
+	User    *domain.User
+	Session *domain.Session
+	Token   *domain.PersonalAccessToken
+}
+
+type principalContextKey struct{}
+
+// PrincipalFromContext returns the principal set by Authenticate, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+// CurrentUser returns the user authenticated by Authenticate, or nil. It
+// satisfies UserFromContext.
+func CurrentUser(ctx context.Context) *domain.User {
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		return principal.User
+	}
+	return nil
+}
+
+// Authenticate is the shared authentication middleware of /api/v1/* and
+// /events. It accepts a personal access token (Authorization: Bearer) or a
+// session cookie. Tokens must carry the read scope for safe methods and the
+// write scope otherwise; sessions must send CSRFHeader with unsafe methods.
+func Authenticate(auth *services.APIAuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			principal := &Principal{}
+			var err error
+
+			if header := r.Header.Get("Authorization"); header != "" {
+				secret, ok := strings.CutPrefix(header, "Bearer ")
+				if !ok {
+					writeAuthError(w, domain.ErrInvalidCredentials)
+					return
+				}
+				principal.User, principal.Token, err = auth.AuthenticateToken(ctx, strings.TrimSpace(secret))
+				if err != nil {
+					writeAuthError(w, err)
+					return
+				}
+				scope := domain.ScopeWrite
+				if isSafeMethod(r.Method) {
+					scope = domain.ScopeRead
+				}
+				if !principal.Token.HasScope(scope) {
+					writeJSONError(w, http.StatusForbidden, "insufficient_scope", "This token lacks the "+string(scope)+" scope.")
+					return
+				}
+			} else if cookie, cookieErr := r.Cookie(SessionCookieName); cookieErr == nil && cookie.Value != "" {
+				principal.User, principal.Session, err = auth.AuthenticateSession(ctx, cookie.Value)
+				if err != nil {
+					writeAuthError(w, err)
+					return
+				}
+				if !isSafeMethod(r.Method) && subtle.ConstantTimeCompare([]byte(r.Header.Get(CSRFHeader)), []byte(principal.Session.CSRFToken)) != 1 {
+					writeJSONError(w, http.StatusForbidden, "csrf_token_invalid", "Missing or invalid "+CSRFHeader+" header.")
+					return
+				}
+			} else {
+				writeAuthError(w, domain.ErrInvalidCredentials)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, principalContextKey{}, principal)))
+		})
+	}
+}
+
+// RequireScope rejects personal access tokens without scope. Sessions carry
+// every scope. It must run after Authenticate.
+func RequireScope(scope domain.TokenScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal := PrincipalFromContext(r.Context()); principal != nil && principal.Token != nil && !principal.Token.HasScope(scope) {
+				writeJSONError(w, http.StatusForbidden, "insufficient_scope", "This token lacks the "+string(scope)+" scope.")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects requests authenticated with a personal access token.
+// Minting credentials takes an interactive login. It must run after
+// Authenticate.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal := PrincipalFromContext(r.Context()); principal == nil || principal.Session == nil {
+			writeJSONError(w, http.StatusForbidden, "session_required", "Sign in to the web UI to manage credentials.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin rejects requests whose authenticated user is not an admin.
+// It must run after the authentication middleware.
+func RequireAdmin(currentUser UserFromContext) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := currentUser(r.Context())
+			if user == nil {
+				writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+				return
+			}
+			if !user.IsAdmin {
+				writeJSONError(w, http.StatusForbidden, "admin_required", "Only admins can manage users.")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RealIP replaces a request's RemoteAddr with the client address a trusted
+// reverse proxy passed on: the last X-Forwarded-For entry that is not itself
+// a trusted proxy, or else X-Real-IP. Requests whose TCP peer is not in
+// trusted keep their RemoteAddr, so clients cannot pick the address the login
+// throttle keys on by sending the headers themselves.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(value string) bool {
+		addr, err := netip.ParseAddr(strings.TrimSpace(value))
+		if err != nil {
+			return false
+		}
+		for _, prefix := range trusted {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			client := ""
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop := strings.TrimSpace(hops[i])
+				if _, err := netip.ParseAddr(hop); err != nil {
+					break
+				}
+				client = hop
+				if !isTrusted(hop) {
+					break
+				}
+			}
+			if client == "" {
+				if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+					client = addr.String()
+				}
+			}
+			if client != "" {
+				r.RemoteAddr = net.JoinHostPort(client, "0")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParseTrustedProxies parses a comma-separated list of CIDR prefixes and
+// single IP addresses, as in CALENDARAPP_TRUSTED_PROXIES.
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(field); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", field)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials), errors.Is(err, domain.ErrUserDisabled):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="calendar-app"`)
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+	default:
+		slog.Error("API authentication failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
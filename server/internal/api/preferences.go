@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/planner"
+)
+
+// PreferencesHandler serves /api/v1/preferences, the authenticated user's
+// planning preferences. Mount it behind Authenticate.
+type PreferencesHandler struct {
+	preferences *planner.PreferenceService
+	currentUser UserFromContext
+}
+
+func NewPreferencesHandler(preferences *planner.PreferenceService, currentUser UserFromContext) *PreferencesHandler {
+	return &PreferencesHandler{preferences: preferences, currentUser: currentUser}
+}
+
+func (h *PreferencesHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleGet)
+	r.Patch("/", h.handlePatch)
+	return r
+}
+
+// handleGet returns every planning preference, defaults filled in.
+func (h *PreferencesHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	prefs, err := h.preferences.Get(r.Context(), user.ID)
+	if err != nil {
+		writePreferencesError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs.Values())
+}
+
+// handlePatch sets the preferences in the body; null resets one to its
+// default.
+func (h *PreferencesHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var values map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil || values == nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be a JSON object.")
+		return
+	}
+	prefs, err := h.preferences.Update(r.Context(), user.ID, values)
+	if err != nil {
+		writePreferencesError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs.Values())
+}
+
+func writePreferencesError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, planner.ErrInvalidPreferences):
+		writeJSONError(w, http.StatusBadRequest, "invalid_preferences", err.Error())
+	default:
+		slog.Error("preferences request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
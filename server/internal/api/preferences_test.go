@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/planner"
+)
+
+func TestPreferencesAPI(t *testing.T) {
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	alice, err := data.NewSQLiteUserRepo(db).Create(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	handler := NewPreferencesHandler(planner.NewPreferenceService(data.NewSQLitePreferenceRepo(db)), testUserFromContext).Routes()
+
+	if rr := adminRequest(handler, nil, http.MethodGet, "/", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous get status = %d, want 401", rr.Code)
+	}
+	rr := adminRequest(handler, alice, http.MethodGet, "/", "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"time_zone":"UTC"`) ||
+		!strings.Contains(body, `"mon":{"end":"17:00","start":"09:00"}`) || !strings.Contains(body, `"no_meeting_days":[]`) {
+		t.Fatalf("get = %d %s", rr.Code, body)
+	}
+
+	rr = adminRequest(handler, alice, http.MethodPatch, "/", `{"min_buffer_minutes":"ten"}`)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"error_code":"invalid_preferences"`) {
+		t.Fatalf("invalid patch = %d %s", rr.Code, rr.Body.String())
+	}
+	rr = adminRequest(handler, alice, http.MethodPatch, "/", `{"time_zone":"Europe/Berlin","min_buffer_minutes":10,"no_meeting_days":["wed"]}`)
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"time_zone":"Europe/Berlin"`) ||
+		!strings.Contains(body, `"min_buffer_minutes":10`) || !strings.Contains(body, `"no_meeting_days":["wed"]`) {
+		t.Fatalf("patch = %d %s", rr.Code, body)
+	}
+	rr = adminRequest(handler, alice, http.MethodPatch, "/", `{"time_zone":null}`)
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"time_zone":"UTC"`) || !strings.Contains(body, `"min_buffer_minutes":10`) {
+		t.Fatalf("reset = %d %s", rr.Code, body)
+	}
+}
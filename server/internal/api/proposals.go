@@ -0,0 +1,322 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/planner"
+)
+
+// ProposalsHandler serves /api/v1/proposals, the authenticated user's plan
+// proposals. Mount it behind Authenticate.
+type ProposalsHandler struct {
+	proposals   *planner.ProposalService
+	apply       *planner.ApplyService
+	currentUser UserFromContext
+}
+
+func NewProposalsHandler(proposals *planner.ProposalService, apply *planner.ApplyService, currentUser UserFromContext) *ProposalsHandler {
+	return &ProposalsHandler{proposals: proposals, apply: apply, currentUser: currentUser}
+}
+
+func (h *ProposalsHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Post("/", h.handleCreate)
+	r.Get("/{id}", h.handleGet)
+	r.Post("/{id}/reject", h.handleReject)
+	r.Post("/{id}/apply", h.handleApply)
+	return r
+}
+
+type proposalJSON struct {
+	ID           int64                   `json:"id"`
+	Status       string                  `json:"status"`
+	StatusReason string                  `json:"status_reason,omitempty"`
+	Summary      string                  `json:"summary"`
+	CanApply     bool                    `json:"can_apply"`
+	Changes      []proposalChangeJSON    `json:"changes"`
+	Violations   []proposalViolationJSON `json:"violations"`
+	ExpiresAt    time.Time               `json:"expires_at"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// proposalChangeJSON is a change as requested and as returned; etag is only
+// returned.
+type proposalChangeJSON struct {
+	Type       string     `json:"type"`
+	CalendarID int64      `json:"calendar_id"`
+	UID        string     `json:"uid,omitempty"`
+	ETag       string     `json:"etag,omitempty"`
+	Summary    string     `json:"summary,omitempty"`
+	Start      *time.Time `json:"start,omitempty"`
+	End        *time.Time `json:"end,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// proposalViolationJSON omits change for violations of the whole proposal.
+type proposalViolationJSON struct {
+	Code    string `json:"code"`
+	Change  *int   `json:"change,omitempty"`
+	Message string `json:"message"`
+}
+
+// applyResultJSON is the outcome of an apply. Failures carry error_code and
+// message; a stale proposal also carries the status_reason.
+type applyResultJSON struct {
+	Status            string              `json:"status"`
+	ProposalID        int64               `json:"proposal_id"`
+	AuditLogEntryID   int64               `json:"audit_log_entry_id,omitempty"`
+	RollbackAvailable bool                `json:"rollback_available"`
+	CanApply          bool                `json:"can_apply"`
+	Summary           string              `json:"summary,omitempty"`
+	AppliedChanges    []appliedChangeJSON `json:"applied_changes"`
+	StatusReason      string              `json:"status_reason,omitempty"`
+	ErrorCode         string              `json:"error_code,omitempty"`
+	Message           string              `json:"message,omitempty"`
+}
+
+type appliedChangeJSON struct {
+	Type       string     `json:"type"`
+	CalendarID int64      `json:"calendar_id"`
+	UID        string     `json:"uid"`
+	Summary    string     `json:"summary"`
+	Start      *time.Time `json:"start,omitempty"`
+	End        *time.Time `json:"end,omitempty"`
+}
+
+type createProposalRequest struct {
+	Summary string               `json:"summary"`
+	Changes []proposalChangeJSON `json:"changes"`
+}
+
+func (h *ProposalsHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer.")
+			return
+		}
+	}
+	proposals, err := h.proposals.List(r.Context(), user.ID, limit)
+	if err != nil {
+		writeProposalError(w, err)
+		return
+	}
+	result := make([]proposalJSON, 0, len(proposals))
+	for _, p := range proposals {
+		result = append(result, toProposalJSON(p))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"proposals": result})
+}
+
+func (h *ProposalsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var req createProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	changes := make([]domain.ProposalChange, 0, len(req.Changes))
+	for _, c := range req.Changes {
+		change := domain.ProposalChange{
+			Type:       domain.ProposalChangeType(c.Type),
+			CalendarID: c.CalendarID,
+			UID:        c.UID,
+			Summary:    c.Summary,
+			Reason:     c.Reason,
+		}
+		if c.Start != nil {
+			change.Start = *c.Start
+		}
+		if c.End != nil {
+			change.End = *c.End
+		}
+		changes = append(changes, change)
+	}
+	proposal, err := h.proposals.Create(r.Context(), user, req.Summary, changes)
+	if err != nil {
+		writeProposalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toProposalJSON(proposal))
+}
+
+func (h *ProposalsHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProposalError(w, domain.ErrNotFound)
+		return
+	}
+	proposal, err := h.proposals.Get(r.Context(), user.ID, id)
+	if err != nil {
+		writeProposalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toProposalJSON(proposal))
+}
+
+func (h *ProposalsHandler) handleReject(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProposalError(w, domain.ErrNotFound)
+		return
+	}
+	proposal, err := h.proposals.Reject(r.Context(), user.ID, id)
+	if err != nil {
+		writeProposalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toProposalJSON(proposal))
+}
+
+// handleApply writes the changes of a valid proposal. A proposal that went
+// stale is answered with 409 and error_code stale_proposal.
+func (h *ProposalsHandler) handleApply(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProposalError(w, domain.ErrNotFound)
+		return
+	}
+	applied, err := h.apply.Apply(r.Context(), user, id)
+	if errors.Is(err, planner.ErrProposalStale) {
+		result := applyResultJSON{
+			Status:         string(domain.ProposalStale),
+			ProposalID:     id,
+			AppliedChanges: []appliedChangeJSON{},
+			ErrorCode:      "stale_proposal",
+			Message:        "Calendar changed. Regenerate before applying.",
+		}
+		if proposal, err := h.proposals.Get(r.Context(), user.ID, id); err == nil {
+			result.StatusReason = proposal.StatusReason
+		}
+		writeJSON(w, http.StatusConflict, result)
+		return
+	}
+	if err != nil {
+		writeProposalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toApplyResultJSON(applied))
+}
+
+func toApplyResultJSON(applied *planner.AppliedPlan) applyResultJSON {
+	result := applyResultJSON{
+		Status:            string(applied.Proposal.Status),
+		ProposalID:        applied.Proposal.ID,
+		AuditLogEntryID:   applied.Entry.ID,
+		RollbackAvailable: true,
+		Summary:           applied.Entry.Summary,
+		AppliedChanges:    make([]appliedChangeJSON, 0, len(applied.Proposal.Changes)),
+	}
+	for i, c := range applied.Proposal.Changes {
+		change := appliedChangeJSON{Type: string(c.Type), CalendarID: c.CalendarID, UID: c.UID, Summary: applied.Entry.Changes[i].Summary}
+		if !c.Start.IsZero() {
+			start := c.Start
+			change.Start = &start
+		}
+		if !c.End.IsZero() {
+			end := c.End
+			change.End = &end
+		}
+		result.AppliedChanges = append(result.AppliedChanges, change)
+	}
+	return result
+}
+
+func toProposalJSON(p *domain.PlanProposal) proposalJSON {
+	changes := make([]proposalChangeJSON, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		change := proposalChangeJSON{
+			Type:       string(c.Type),
+			CalendarID: c.CalendarID,
+			UID:        c.UID,
+			ETag:       c.ETag,
+			Summary:    c.Summary,
+			Reason:     c.Reason,
+		}
+		if !c.Start.IsZero() {
+			start := c.Start
+			change.Start = &start
+		}
+		if !c.End.IsZero() {
+			end := c.End
+			change.End = &end
+		}
+		changes = append(changes, change)
+	}
+	violations := make([]proposalViolationJSON, 0, len(p.Violations))
+	for _, v := range p.Violations {
+		violation := proposalViolationJSON{Code: v.Code, Message: v.Message}
+		if v.Change >= 0 {
+			index := v.Change
+			violation.Change = &index
+		}
+		violations = append(violations, violation)
+	}
+	return proposalJSON{
+		ID:           p.ID,
+		Status:       string(p.Status),
+		StatusReason: p.StatusReason,
+		Summary:      p.Summary,
+		CanApply:     p.Status == domain.ProposalValid,
+		Changes:      changes,
+		Violations:   violations,
+		ExpiresAt:    p.ExpiresAt,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
+
+func writeProposalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, planner.ErrInvalidProposal):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, planner.ErrProposalHasViolations):
+		writeJSONError(w, http.StatusConflict, "validation_failed", "The proposal has violations and cannot be applied.")
+	case errors.Is(err, planner.ErrProposalExpired):
+		writeJSONError(w, http.StatusConflict, "proposal_expired", "The proposal expired. Regenerate before applying.")
+	case errors.Is(err, planner.ErrProposalNotPending):
+		writeJSONError(w, http.StatusConflict, "proposal_not_pending", "The proposal was already applied, rejected or expired.")
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "proposal_not_found", "No proposal with this ID.")
+	default:
+		slog.Error("proposal request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/caldav"
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/planner"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestProposalsAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	alice, err := users.CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	bob, err := users.CreateUser(ctx, "bob", "bob-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	calendar, err := calendarRepo.GetByName(ctx, alice.ID, domain.DefaultCalendarName)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+
+	eventRepo := data.NewSQLiteEventRepo(db)
+	proposalRepo := data.NewSQLitePlanProposalRepo(db)
+	proposals := planner.NewProposalService(proposalRepo, calendarRepo, eventRepo, planner.NewPreferenceService(data.NewSQLitePreferenceRepo(db)))
+	backend := caldav.NewBackend(db, userRepo, calendarRepo, eventRepo)
+	apply := planner.NewApplyService(proposals, proposalRepo, data.NewSQLiteAuditLogRepo(db), backend)
+	handler := NewProposalsHandler(proposals, apply, testUserFromContext).Routes()
+	calendarID := strconv.FormatInt(calendar.ID, 10)
+
+	if rr := adminRequest(handler, alice, http.MethodPost, "/", `{"changes":[{"type":"delete_event","calendar_id":`+calendarID+`,"uid":"x"}]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown change type status = %d, want 400", rr.Code)
+	}
+
+	rr := adminRequest(handler, alice, http.MethodPost, "/", `{"summary":"Focus on Monday","changes":[
+		{"type":"create_focus_block","calendar_id":`+calendarID+`,"start":"2026-11-02T13:00:00Z","end":"2026-11-02T15:00:00Z"},
+		{"type":"move_event","calendar_id":`+calendarID+`,"uid":"gone","start":"2026-11-02T16:00:00Z","end":"2026-11-02T17:00:00Z"}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201; body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		ID         int64  `json:"id"`
+		Status     string `json:"status"`
+		CanApply   bool   `json:"can_apply"`
+		Violations []struct {
+			Code   string `json:"code"`
+			Change *int   `json:"change"`
+		} `json:"violations"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.Status != string(domain.ProposalDraft) || created.CanApply || len(created.Violations) != 1 ||
+		created.Violations[0].Code != planner.ViolationEventNotFound || created.Violations[0].Change == nil || *created.Violations[0].Change != 1 {
+		t.Fatalf("created = %s", rr.Body.String())
+	}
+	path := "/" + strconv.FormatInt(created.ID, 10)
+
+	if rr := adminRequest(handler, bob, http.MethodGet, path, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("other user's get status = %d, want 404", rr.Code)
+	}
+	rr = adminRequest(handler, alice, http.MethodGet, path, "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"type":"create_focus_block"`) || !strings.Contains(body, `"uid":"focus-`) {
+		t.Fatalf("get = %d %s", rr.Code, body)
+	}
+	rr = adminRequest(handler, alice, http.MethodGet, "/?limit=10", "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"summary":"Focus on Monday"`) {
+		t.Fatalf("list = %d %s", rr.Code, body)
+	}
+
+	rr = adminRequest(handler, alice, http.MethodPost, path+"/reject", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"rejected"`) {
+		t.Fatalf("reject = %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, path+"/reject", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("second reject status = %d, want 409", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, path+"/apply", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("apply of a rejected proposal status = %d, want 409", rr.Code)
+	}
+
+	// A valid proposal applies once
+	rr = adminRequest(handler, alice, http.MethodPost, "/", `{"changes":[
+		{"type":"create_focus_block","calendar_id":`+calendarID+`,"start":"2026-11-03T13:00:00Z","end":"2026-11-03T15:00:00Z"}]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", rr.Code, rr.Body.String())
+	}
+	path = "/" + strconv.FormatInt(created.ID, 10)
+	rr = adminRequest(handler, alice, http.MethodPost, path+"/apply", "")
+	var applied struct {
+		Status            string `json:"status"`
+		AuditLogEntryID   int64  `json:"audit_log_entry_id"`
+		RollbackAvailable bool   `json:"rollback_available"`
+		AppliedChanges    []struct {
+			Type    string `json:"type"`
+			UID     string `json:"uid"`
+			Summary string `json:"summary"`
+		} `json:"applied_changes"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &applied); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("apply = %d %s", rr.Code, rr.Body.String())
+	}
+	if applied.Status != "applied" || applied.AuditLogEntryID == 0 || !applied.RollbackAvailable || len(applied.AppliedChanges) != 1 ||
+		!strings.HasPrefix(applied.AppliedChanges[0].Summary, `Created "Focus time"`) {
+		t.Fatalf("apply = %s", rr.Body.String())
+	}
+	if _, err := eventRepo.GetByUID(ctx, calendar.ID, applied.AppliedChanges[0].UID); err != nil {
+		t.Fatalf("focus block not written: %v", err)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, path+"/apply", ""); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "proposal_not_pending") {
+		t.Fatalf("second apply = %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Moving the block is stale once a client deleted it
+	rr = adminRequest(handler, alice, http.MethodPost, "/", `{"changes":[{"type":"move_event","calendar_id":`+calendarID+
+		`,"uid":"`+applied.AppliedChanges[0].UID+`","start":"2026-11-03T14:00:00Z","end":"2026-11-03T16:00:00Z"}]}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"can_apply":true`) {
+		t.Fatalf("create move = %d %s", rr.Code, rr.Body.String())
+	}
+	var move struct {
+		ID int64 `json:"id"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &move)
+	if err := eventRepo.Delete(ctx, calendar.ID, applied.AppliedChanges[0].UID); err != nil {
+		t.Fatalf("delete focus block: %v", err)
+	}
+	rr = adminRequest(handler, alice, http.MethodPost, "/"+strconv.FormatInt(move.ID, 10)+"/apply", "")
+	if body := rr.Body.String(); rr.Code != http.StatusConflict || !strings.Contains(body, `"error_code":"stale_proposal"`) ||
+		!strings.Contains(body, `"status_reason":"event_deleted"`) || !strings.Contains(body, `"rollback_available":false`) {
+		t.Fatalf("stale apply = %d %s", rr.Code, body)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// defaultRemindersSince is how far back GET /api/v1/reminders looks without
+// a since parameter.
+const defaultRemindersSince = 24 * time.Hour
+
+// RemindersHandler serves /api/v1/reminders, the VALARM reminders fired for
+// the authenticated user. They are also pushed over /events as they fire.
+type RemindersHandler struct {
+	reminders   *services.ReminderService
+	currentUser UserFromContext
+}
+
+func NewRemindersHandler(reminders *services.ReminderService, currentUser UserFromContext) *RemindersHandler {
+	return &RemindersHandler{reminders: reminders, currentUser: currentUser}
+}
+
+func (h *RemindersHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Post("/{id}/acknowledge", h.handleAcknowledge)
+	return r
+}
+
+type reminderJSON struct {
+	ID             int64      `json:"id"`
+	CalendarID     int64      `json:"calendar_id"`
+	UID            string     `json:"uid"`
+	AlarmKey       string     `json:"alarm_key"`
+	Action         string     `json:"action"`
+	Summary        string     `json:"summary"`
+	Description    string     `json:"description,omitempty"`
+	Occurrence     time.Time  `json:"occurrence"`
+	InstanceStart  time.Time  `json:"instance_start"`
+	TriggerAt      time.Time  `json:"trigger_at"`
+	FiredAt        time.Time  `json:"fired_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+}
+
+func toReminderJSON(r *domain.Reminder) reminderJSON {
+	return reminderJSON{
+		ID:             r.ID,
+		CalendarID:     r.CalendarID,
+		UID:            r.UID,
+		AlarmKey:       r.AlarmKey,
+		Action:         r.Action,
+		Summary:        r.Summary,
+		Description:    r.Description,
+		Occurrence:     r.Occurrence.UTC(),
+		InstanceStart:  r.InstanceStart.UTC(),
+		TriggerAt:      r.TriggerAt.UTC(),
+		FiredAt:        r.FiredAt.UTC(),
+		AcknowledgedAt: r.AcknowledgedAt,
+	}
+}
+
+func (h *RemindersHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	since := time.Now().Add(-defaultRemindersSince)
+	if s := r.URL.Query().Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "since must be an RFC 3339 time.")
+			return
+		}
+		since = t
+	}
+	reminders, err := h.reminders.ListRecent(r.Context(), user.ID, since)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+	result := make([]reminderJSON, 0, len(reminders))
+	for _, reminder := range reminders {
+		result = append(result, toReminderJSON(reminder))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"reminders": result})
+}
+
+func (h *RemindersHandler) handleAcknowledge(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "not_found", "Reminder not found.")
+		return
+	}
+	reminder, err := h.reminders.Acknowledge(r.Context(), user.ID, id)
+	if err != nil {
+		writeReminderError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toReminderJSON(reminder))
+}
+
+func writeReminderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "not_found", "Reminder not found.")
+	default:
+		slog.Error("reminders request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
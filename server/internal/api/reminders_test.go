@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+type fakeAlarmAcknowledger struct {
+	alarms []string
+}
+
+func (f *fakeAlarmAcknowledger) AcknowledgeAlarm(ctx context.Context, eventID int64, alarmKey string, at time.Time) error {
+	f.alarms = append(f.alarms, alarmKey)
+	return nil
+}
+
+func TestRemindersAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	alice, err := users.CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	bob, _ := users.CreateUser(ctx, "bob", "bob-password", false)
+	cal, _ := calendarRepo.GetByName(ctx, alice.ID, domain.DefaultCalendarName)
+	event := &domain.Event{CalendarID: cal.ID, UID: "review", ICS: "x", StartTime: time.Now(), EndTime: time.Now(), ETag: `"x"`, Status: "CONFIRMED"}
+	if err := data.NewSQLiteEventRepo(db).Create(ctx, event); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	alarmRepo := data.NewSQLiteAlarmRepo(db)
+	reminder := &domain.Reminder{UserID: alice.ID, EventID: event.ID, AlarmKey: "remind", Action: "DISPLAY", Summary: "Review",
+		Occurrence: event.StartTime, InstanceStart: event.StartTime, TriggerAt: event.StartTime.Add(-15 * time.Minute)}
+	if err := alarmRepo.RecordFiring(ctx, reminder); err != nil {
+		t.Fatalf("record firing: %v", err)
+	}
+
+	acknowledger := &fakeAlarmAcknowledger{}
+	handler := NewRemindersHandler(services.NewReminderService(alarmRepo, calendarRepo, acknowledger), testUserFromContext).Routes()
+
+	rr := adminRequest(handler, alice, http.MethodGet, "/", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"uid":"review"`) || !strings.Contains(rr.Body.String(), `"acknowledged_at":null`) {
+		t.Fatalf("list status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := adminRequest(handler, alice, http.MethodGet, "/?since=yesterday", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid since status = %d, want 400", rr.Code)
+	}
+	if rr := adminRequest(handler, bob, http.MethodGet, "/", ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "review") {
+		t.Fatalf("other user's list status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+
+	path := "/" + strconv.FormatInt(reminder.ID, 10) + "/acknowledge"
+	if rr := adminRequest(handler, bob, http.MethodPost, path, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("other user's acknowledge status = %d, want 404", rr.Code)
+	}
+	rr = adminRequest(handler, alice, http.MethodPost, path, "")
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"acknowledged_at":null`) {
+		t.Fatalf("acknowledge status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if len(acknowledger.alarms) != 1 || acknowledger.alarms[0] != "remind" {
+		t.Errorf("ACKNOWLEDGED written for %v, want [remind]", acknowledger.alarms)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/999/acknowledge", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown reminder status = %d, want 404", rr.Code)
+	}
+}
+
+func TestEventStreamDeliversReminders(t *testing.T) {
+	stream := services.NewNotificationBus(0)
+	alice := &domain.User{ID: 1, Username: "alice"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), contextUserKey{}, alice))
+		NewEventStreamHandler(stream, testUserFromContext).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		for lines.Scan() {
+			if line := lines.Text(); strings.HasPrefix(line, "data: ") {
+				return line
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return ""
+	}
+	if line := next(); !strings.Contains(line, `"type":"connected"`) {
+		t.Fatalf("first event = %s", line)
+	}
+
+	// Other users' reminders are not streamed
+	stream.Notify(context.Background(), &domain.Reminder{ID: 1, UserID: 2, UID: "secret"})
+	stream.Notify(context.Background(), &domain.Reminder{ID: 2, UserID: 1, UID: "review"})
+	if line := next(); !strings.Contains(line, `"type":"reminder"`) || !strings.Contains(line, `"uid":"review"`) {
+		t.Fatalf("reminder event = %s", line)
+	}
+}
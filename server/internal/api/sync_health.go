@@ -73,6 +73,7 @@ type clientSummaryJSON struct {
 	LastSeenAt        time.Time `json:"last_seen_at"`
 	OperationCount    int       `json:"operation_count"`
 	OperationCount24h int       `json:"operation_count_24h"`
+	Credentials       []string  `json:"credentials,omitempty"`
 }
 
 type recentOperationJSON struct {
@@ -82,6 +83,7 @@ type recentOperationJSON struct {
 	StatusCode        int       `json:"status_code"`
 	DurationMillis    int64     `json:"duration_ms"`
 	ClientFingerprint string    `json:"client_fingerprint"`
+	Credential        string    `json:"credential,omitempty"`
 	ETagOutcome       string    `json:"etag_outcome"`
 	OperationKind     string    `json:"operation_kind"`
 	Outcome           string    `json:"outcome"`
@@ -191,6 +193,7 @@ func toClientSummaryJSON(client services.SyncClientSummary) clientSummaryJSON {
 		LastSeenAt:        client.LastSeenAt,
 		OperationCount:    client.OperationCount,
 		OperationCount24h: client.OperationCount24h,
+		Credentials:       client.Credentials,
 	}
 }
 
@@ -205,6 +208,7 @@ func toRecentOperationJSON(op *domain.CalDAVOperation) recentOperationJSON {
 		StatusCode:        op.StatusCode,
 		DurationMillis:    op.DurationMillis,
 		ClientFingerprint: op.ClientFingerprint,
+		Credential:        op.Credential,
 		ETagOutcome:       string(op.ETagOutcome),
 		OperationKind:     string(op.OperationKind),
 		Outcome:           string(op.Outcome),
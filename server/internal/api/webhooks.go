@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// WebhooksHandler serves /api/v1/webhooks, the authenticated user's webhook
+// subscriptions and their delivery log. Mount it behind Authenticate.
+type WebhooksHandler struct {
+	webhooks    *services.WebhookService
+	currentUser UserFromContext
+}
+
+func NewWebhooksHandler(webhooks *services.WebhookService, currentUser UserFromContext) *WebhooksHandler {
+	return &WebhooksHandler{webhooks: webhooks, currentUser: currentUser}
+}
+
+func (h *WebhooksHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.handleList)
+	r.Post("/", h.handleCreate)
+	r.Delete("/{id}", h.handleDelete)
+	r.Get("/{id}/deliveries", h.handleDeliveries)
+	return r
+}
+
+// webhookJSON never includes the signing secret.
+type webhookJSON struct {
+	ID             int64     `json:"id"`
+	URL            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	IncludeContent bool      `json:"include_content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// createdWebhookJSON is the only response that carries the secret.
+type createdWebhookJSON struct {
+	webhookJSON
+	Secret string `json:"secret"`
+}
+
+type createWebhookRequest struct {
+	URL            string   `json:"url"`
+	EventTypes     []string `json:"event_types"`
+	IncludeContent bool     `json:"include_content"`
+}
+
+type webhookDeliveryJSON struct {
+	ID             int64           `json:"id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func (h *WebhooksHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	subscriptions, err := h.webhooks.List(r.Context(), user.ID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	result := make([]webhookJSON, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		result = append(result, toWebhookJSON(s))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"webhooks": result})
+}
+
+func (h *WebhooksHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", "Request body must be JSON.")
+		return
+	}
+	subscription, err := h.webhooks.Create(r.Context(), user, req.URL, req.EventTypes, req.IncludeContent)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createdWebhookJSON{webhookJSON: toWebhookJSON(subscription), Secret: subscription.Secret})
+}
+
+func (h *WebhooksHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeWebhookError(w, domain.ErrNotFound)
+		return
+	}
+	if err := h.webhooks.Delete(r.Context(), user, id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeliveries returns the delivery log of a subscription, newest first.
+// ?limit= bounds it (default and maximum services.MaxWebhookDeliveryLog).
+func (h *WebhooksHandler) handleDeliveries(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Authentication required.")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeWebhookError(w, domain.ErrNotFound)
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer.")
+			return
+		}
+	}
+	deliveries, err := h.webhooks.Deliveries(r.Context(), user.ID, id, limit)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	result := make([]webhookDeliveryJSON, 0, len(deliveries))
+	for _, d := range deliveries {
+		result = append(result, webhookDeliveryJSON{
+			ID:             d.ID,
+			EventType:      string(d.EventType),
+			Status:         string(d.Status),
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			LastAttemptAt:  d.LastAttemptAt,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+			Payload:        json.RawMessage(d.Payload),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": result})
+}
+
+func toWebhookJSON(s *domain.WebhookSubscription) webhookJSON {
+	eventTypes := make([]string, 0, len(s.EventTypes))
+	for _, t := range s.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+	return webhookJSON{
+		ID:             s.ID,
+		URL:            s.URL,
+		EventTypes:     eventTypes,
+		IncludeContent: s.IncludeContent,
+		CreatedAt:      s.CreatedAt,
+	}
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEventType),
+		errors.Is(err, services.ErrWebhookURLNotAllowed):
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "webhook_not_found", "No webhook with this ID.")
+	default:
+		slog.Error("webhook request failed", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Request failed.")
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/integrations/notify"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestWebhooksAPI(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	alice, err := services.NewUserService(userRepo, data.NewSQLiteCalendarRepo(db)).CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// The receiver checks every signature against the secret it was given
+	var secret string
+	received := make(chan string, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(notify.HeaderWebhookTimestamp), 10, 64)
+		if r.Header.Get(notify.HeaderWebhookSignature) != notify.SignWebhook(secret, timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	// The receiver runs on loopback, which only an allowed network reaches
+	webhooks := services.NewWebhookService(data.NewSQLiteWebhookRepo(db), notify.NewSignedWebhookSender(netip.MustParsePrefix("127.0.0.0/8")))
+	handler := NewWebhooksHandler(webhooks, testUserFromContext).Routes()
+
+	if rr := adminRequest(handler, alice, http.MethodPost, "/", `{"url":"mailto:alice@example.com"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid URL status = %d, want 400", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/", `{"url":"http://169.254.169.254/latest"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("link-local URL status = %d, want 400", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodPost, "/", `{"url":"https://example.com","event_types":["event.moved"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown event type status = %d, want 400", rr.Code)
+	}
+	rr := adminRequest(handler, alice, http.MethodPost, "/", `{"url":"`+receiver.URL+`","event_types":["event.*"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201; body=%s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		ID     int64  `json:"id"`
+		Secret string `json:"secret"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Secret == "" {
+		t.Fatalf("create response = %s", rr.Body.String())
+	}
+	secret = created.Secret
+
+	rr = adminRequest(handler, alice, http.MethodGet, "/", "")
+	if body := rr.Body.String(); rr.Code != http.StatusOK || !strings.Contains(body, `"event_types":["event.*"]`) || strings.Contains(body, secret) {
+		t.Fatalf("list = %d %s", rr.Code, body)
+	}
+
+	webhooks.Publish(ctx, &domain.Notification{ID: 9, Type: domain.NotificationEventCreated, UserIDs: []int64{alice.ID},
+		Calendar: &domain.Calendar{ID: 1, UserID: alice.ID, Name: "default"}, UID: "dentist", Event: &domain.Event{ETag: `"e1"`, Summary: "Dentist"}})
+	webhooks.Publish(ctx, &domain.Notification{Type: domain.NotificationCalendarCreated, UserIDs: []int64{alice.ID}})
+	if n, err := webhooks.Tick(ctx); err != nil || n != 1 {
+		t.Fatalf("Tick = %d, %v; want one delivery", n, err)
+	}
+	if body := <-received; !strings.Contains(body, `"type":"event.created"`) || strings.Contains(body, "Dentist") {
+		t.Errorf("received %s, want a redacted event.created", body)
+	}
+
+	deliveriesPath := "/" + strconv.FormatInt(created.ID, 10) + "/deliveries"
+	rr = adminRequest(handler, alice, http.MethodGet, deliveriesPath+"?limit=10", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("deliveries status = %d; body=%s", rr.Code, rr.Body.String())
+	}
+	var log struct {
+		Deliveries []struct {
+			Status         string          `json:"status"`
+			Attempts       int             `json:"attempts"`
+			LastStatusCode int             `json:"last_status_code"`
+			Payload        json.RawMessage `json:"payload"`
+		} `json:"deliveries"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &log); err != nil || len(log.Deliveries) != 1 {
+		t.Fatalf("deliveries = %s", rr.Body.String())
+	}
+	if d := log.Deliveries[0]; d.Status != "delivered" || d.Attempts != 1 || d.LastStatusCode != 204 || !strings.Contains(string(d.Payload), `"etag":"\"e1\""`) {
+		t.Errorf("delivery = %+v", d)
+	}
+
+	// Other users see neither the log nor the subscription
+	bob := &domain.User{ID: alice.ID + 100, Username: "bob"}
+	if rr := adminRequest(handler, bob, http.MethodGet, deliveriesPath, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("foreign deliveries status = %d, want 404", rr.Code)
+	}
+	if rr := adminRequest(handler, bob, http.MethodDelete, "/"+strconv.FormatInt(created.ID, 10), ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("foreign delete status = %d, want 404", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodDelete, "/"+strconv.FormatInt(created.ID, 10), ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", rr.Code)
+	}
+	if rr := adminRequest(handler, alice, http.MethodGet, deliveriesPath, ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("deliveries of a deleted webhook status = %d, want 404", rr.Code)
+	}
+}
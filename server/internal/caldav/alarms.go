@@ -0,0 +1,77 @@
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/recurrence"
+)
+
+// IndexAlarms indexes the alarms of events stored before alarms were indexed,
+// so the reminder scheduler sees them. It returns how many events it indexed.
+func (b *Backend) IndexAlarms(ctx context.Context) (int, error) {
+	events, err := b.alarmRepo.ListUnindexedEvents(ctx)
+	if err != nil {
+		return 0, err
+	}
+	indexed := 0
+	for _, event := range events {
+		cal, err := parseICalendar(event.ICS)
+		if err != nil {
+			slog.Warn("caldav.alarms.unparseable", "event_id", event.ID, "error", err)
+			continue
+		}
+		alarms := extractEventAlarms(cal)
+		if len(alarms) == 0 {
+			continue
+		}
+		if err := b.alarmRepo.ReplaceEventAlarms(ctx, event.ID, alarms); err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+	return indexed, nil
+}
+
+// AcknowledgeAlarm writes ACKNOWLEDGED (RFC 9074 §6) into the VALARM with the
+// given key of a stored event, so clients that support it dismiss the alarm
+// too. The change is synced like any other edit but leaves the Schedule-Tag
+// alone: alarms are personal. Implements domain.AlarmAcknowledger.
+func (b *Backend) AcknowledgeAlarm(ctx context.Context, eventID int64, alarmKey string, at time.Time) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	event, err := b.eventRepo.WithTx(tx).GetByID(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	cal, err := b.calendarRepo.WithTx(tx).GetByID(ctx, event.CalendarID)
+	if err != nil {
+		return err
+	}
+	data, err := parseICalendar(event.ICS)
+	if err != nil {
+		return fmt.Errorf("failed to parse event %s: %w", event.UID, err)
+	}
+	if err := recurrence.Acknowledge(data, ical.CompEvent, alarmKey, at); err != nil {
+		return domain.ErrNotFound
+	}
+	var notes txNotifications
+	if err := b.writeEventInTx(ctx, tx, cal, event, event.UID, data, true, &notes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	slog.Info("caldav.alarm.acknowledged", "calendar_id", cal.ID, "uid", event.UID, "alarm", alarmKey)
+	b.publish(ctx, notes)
+	return nil
+}
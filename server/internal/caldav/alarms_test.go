@@ -0,0 +1,96 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestCalDAV_AlarmIndexAndAcknowledge(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	eventRepo := data.NewSQLiteEventRepo(db)
+	alarmRepo := data.NewSQLiteAlarmRepo(db)
+	if _, err := services.NewUserService(userRepo, calendarRepo).CreateUser(ctx, "alice", "alice-password", false); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	srv := httptest.NewServer(NewHandlerWithRepos(db, userRepo, calendarRepo, eventRepo))
+	t.Cleanup(srv.Close)
+
+	put := func(name, ics string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+caldavBase+"/calendars/alice/default/"+name, strings.NewReader(ics))
+		req.SetBasicAuth("alice", "alice-password")
+		req.Header.Set("Content-Type", "text/calendar")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PUT failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("PUT %s: expected 201, got %d", name, resp.StatusCode)
+		}
+	}
+	alarm := "BEGIN:VALARM\r\nUID:remind\r\nACTION:DISPLAY\r\nDESCRIPTION:Soon\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\n"
+	put("review.ics", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VEVENT\r\nUID:review\r\nDTSTAMP:20260101T000000Z\r\n"+
+		"DTSTART:20260302T090000Z\r\nDTEND:20260302T100000Z\r\nSUMMARY:Review\r\n"+alarm+"END:VEVENT\r\nEND:VCALENDAR\r\n")
+	put("standup.ics", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VEVENT\r\nUID:standup\r\nDTSTAMP:20260101T000000Z\r\n"+
+		"DTSTART:20260105T090000Z\r\nDTEND:20260105T093000Z\r\nRRULE:FREQ=WEEKLY\r\nSUMMARY:Standup\r\n"+alarm+"END:VEVENT\r\nEND:VCALENDAR\r\n")
+
+	// The review's trigger is indexed; the recurring standup is always a candidate.
+	due, err := alarmRepo.ListDueEvents(ctx, time.Date(2026, 3, 2, 8, 40, 0, 0, time.UTC), time.Date(2026, 3, 2, 8, 50, 0, 0, time.UTC))
+	if err != nil || len(due) != 2 {
+		t.Fatalf("ListDueEvents = %d events, %v; want 2", len(due), err)
+	}
+	due, err = alarmRepo.ListDueEvents(ctx, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(due) != 1 || due[0].UID != "standup" {
+		t.Fatalf("ListDueEvents after the review = %d events, %v; want the standup", len(due), err)
+	}
+
+	calendarID := mustCalendarID(t, calendarRepo, userRepo, "alice")
+	review, err := eventRepo.GetByUID(ctx, calendarID, "review")
+	if err != nil {
+		t.Fatalf("GetByUID failed: %v", err)
+	}
+	calendar, _ := calendarRepo.GetByID(ctx, calendarID)
+
+	backend := NewBackend(db, userRepo, calendarRepo, eventRepo)
+	if err := backend.AcknowledgeAlarm(ctx, review.ID, "remind", time.Date(2026, 3, 2, 8, 46, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AcknowledgeAlarm failed: %v", err)
+	}
+	acknowledged, _ := eventRepo.GetByID(ctx, review.ID)
+	if !strings.Contains(acknowledged.ICS, "ACKNOWLEDGED:20260302T084600Z") {
+		t.Errorf("Expected ACKNOWLEDGED in the stored object:\n%s", acknowledged.ICS)
+	}
+	if acknowledged.ETag == review.ETag || acknowledged.ScheduleTag != review.ScheduleTag {
+		t.Errorf("Expected a new ETag and the same Schedule-Tag, got %s / %s", acknowledged.ETag, acknowledged.ScheduleTag)
+	}
+	if after, _ := calendarRepo.GetByID(ctx, calendarID); after.SyncToken == calendar.SyncToken {
+		t.Error("Expected acknowledging to bump the sync token")
+	}
+	// Every trigger is acknowledged now
+	due, _ = alarmRepo.ListDueEvents(ctx, time.Date(2026, 3, 2, 8, 40, 0, 0, time.UTC), time.Date(2026, 3, 2, 8, 50, 0, 0, time.UTC))
+	if len(due) != 1 {
+		t.Errorf("Expected the acknowledged review to leave the index, got %d events", len(due))
+	}
+	if err := backend.AcknowledgeAlarm(ctx, review.ID, "missing", time.Now()); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for an unknown alarm, got %v", err)
+	}
+}
@@ -1,14 +1,25 @@
 package caldav
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
 )
 
-// AuthConfig holds authentication configuration
+// AuthConfig holds the bootstrap credentials from the environment. The
+// configured user is created on startup; per-user passwords live in the
+// database.
 type AuthConfig struct {
 	Username string
 	Password string
@@ -48,32 +59,160 @@ func LoadAuthConfig() AuthConfig {
 	}
 }
 
-// BasicAuthMiddleware creates HTTP Basic auth middleware
-func BasicAuthMiddleware(config AuthConfig, userRepo domain.UserRepo) func(http.Handler) http.Handler {
+// BasicAuthMiddleware creates HTTP Basic auth middleware. Credentials are
+// checked against the user's app passwords (when appPasswords is not nil) and
+// then against the per-user password hashes in the database; see
+// authenticateBasic for the bootstrap account. When throttle is not nil,
+// repeated failures from a client IP or for a username are locked out with
+// 429 Too Many Requests and Retry-After, even if the password is right.
+func BasicAuthMiddleware(config AuthConfig, userRepo domain.UserRepo, appPasswords domain.AppPasswordRepo, throttle *services.LoginThrottle) func(http.Handler) http.Handler {
+	users := services.NewUserService(userRepo, nil)
+	var devices *services.AppPasswordService
+	if appPasswords != nil {
+		devices = services.NewAppPasswordService(appPasswords, userRepo)
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			username, password, ok := r.BasicAuth()
-
-			if !ok || username != config.Username || password != config.Password {
-				w.Header().Set("WWW-Authenticate", `Basic realm="CalDAV"`)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if !ok {
+				writeUnauthorized(w)
 				return
 			}
 
-			// Get user from database
-			user, err := userRepo.GetByUsername(r.Context(), username)
+			ip := clientIP(r)
+			if throttle != nil {
+				if wait := throttle.Check(ip, username); wait > 0 {
+					writeTooManyRequests(w, wait)
+					return
+				}
+			}
+
+			ctx := r.Context()
+			var user *domain.User
+			var err error
+			credential := domain.CredentialAccountPassword
+			if devices != nil {
+				var appPassword *domain.AppPassword
+				user, appPassword, err = devices.Authenticate(ctx, username, password, NormalizeClientFingerprint(r.UserAgent()))
+				if err == nil {
+					credential = domain.AppPasswordCredential(appPassword.ID)
+				}
+			}
+			if devices == nil || errors.Is(err, domain.ErrInvalidCredentials) {
+				user, err = authenticateBasic(ctx, config, users, userRepo, username, password)
+			}
 			if err != nil {
-				slog.Error("Failed to get user for auth", "username", username, "error", err)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				if !errors.Is(err, domain.ErrInvalidCredentials) && !errors.Is(err, domain.ErrUserDisabled) {
+					slog.Error("Failed to authenticate user", "username", username, "error", err)
+				} else if throttle != nil {
+					if lockout := throttle.Failure(ip, username); lockout > 0 {
+						writeTooManyRequests(w, lockout)
+						return
+					}
+				}
+				writeUnauthorized(w)
 				return
 			}
+			if throttle != nil {
+				throttle.Success(username)
+			}
 
-			// Add user to context
-			ctx := SetUserInContext(r.Context(), user)
+			// Add user to context and the credential to operation metadata
+			recordCredential(ctx, credential)
+			ctx = SetUserInContext(ctx, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// authenticateBasic verifies a username and password. Accounts without a
+// stored password, such as the bootstrap user of an install that predates
+// per-user passwords, accept the CALENDARAPP_USER/CALENDARAPP_PASS credentials.
+func authenticateBasic(ctx context.Context, config AuthConfig, users *services.UserService, userRepo domain.UserRepo, username, password string) (*domain.User, error) {
+	user, err := users.Authenticate(ctx, username, password)
+	if !errors.Is(err, domain.ErrInvalidCredentials) || username != config.Username {
+		return user, err
+	}
+
+	user, err = userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if user.PasswordHash != "" || subtle.ConstantTimeCompare([]byte(password), []byte(config.Password)) != 1 {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+	return user, nil
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="CalDAV"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// writeTooManyRequests answers a locked-out sign-in. Retry-After is rounded up
+// to whole seconds so well-behaved clients never retry early.
+func writeTooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
+
+// clientIP returns the host part of RemoteAddr: the TCP peer, or the client
+// a trusted proxy forwarded for when the server's api.RealIP middleware
+// replaced it. Forwarded headers from anyone else are ignored.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// UserPathMiddleware rejects requests for another user's principal or
+// calendar home with 403. Backend methods resolve calendars by name within the
+// authenticated user's account, so without this check /dav/calendars/bob/
+// would serve alice's own calendars. MKCALENDAR and MKCOL are left to
+// MkCalendarHandler, which answers with the calendar-collection-location-ok
+// precondition.
+func UserPathMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "MKCALENDAR" || r.Method == "MKCOL" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user := getUserFromContext(r.Context())
+		if owner := pathOwner(r.URL.Path); user != nil && owner != "" && owner != user.Username {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pathOwner returns the {username} of a /calendars/{username}/... or
+// /principals/{username}/ path, or "" when the path names no user.
+func pathOwner(urlPath string) string {
+	if owner := calendarHomeUser(urlPath); owner != "" {
+		return owner
+	}
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i, part := range parts {
+		if part == "principals" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// UserFromContext returns the user authenticated by BasicAuthMiddleware, or nil.
+func UserFromContext(ctx context.Context) *domain.User {
+	return getUserFromContext(ctx)
+}
+
 // Note: SetUserInContext, GetUserFromContext, and context key helpers
 // are defined in backend.go to avoid circular dependencies
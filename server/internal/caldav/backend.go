@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -16,14 +17,38 @@ import (
 
 	"github.com/airplne/calendar-app/server/internal/data"
 	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/recurrence"
+	"github.com/airplne/calendar-app/server/internal/services"
 )
 
+// errNoAuthenticatedUser is returned when a backend method runs without a user in context
+var errNoAuthenticatedUser = errors.New("no authenticated user")
+
 // Backend implements caldav.Backend using our domain repositories
 type Backend struct {
 	db           *sql.DB
 	userRepo     domain.UserRepo
 	calendarRepo *data.SQLiteCalendarRepo
 	eventRepo    *data.SQLiteEventRepo
+	taskRepo     *data.SQLiteTaskRepo // VTODO objects in task collections
+	shareRepo    *data.SQLiteCalendarShareRepo
+	inboxRepo    *data.SQLiteScheduleInboxRepo // iTIP messages delivered to local users
+	alarmRepo    *data.SQLiteAlarmRepo
+
+	// delivery sends iTIP messages to calendar users outside this server; nil
+	// drops them with a warning.
+	delivery domain.ScheduleDelivery
+
+	// publisher hears of stored calendar and event changes; nil publishes
+	// nothing.
+	publisher domain.NotificationPublisher
+
+	// webhooks queues deliveries for the changes in their transaction; nil
+	// queues none.
+	webhooks    *services.WebhookService
+	webhookRepo *data.SQLiteWebhookRepo
+
+	deadPropertyRepo *data.SQLiteDeadPropertyRepo // PROPPATCH-set properties we do not interpret
 
 	// Current authenticated user (set by auth middleware via context)
 	// For MVP single-user, we'll use a fixed user
@@ -38,6 +63,13 @@ func NewBackend(db *sql.DB, userRepo domain.UserRepo, calendarRepo *data.SQLiteC
 		userRepo:     userRepo,
 		calendarRepo: calendarRepo,
 		eventRepo:    eventRepo,
+		taskRepo:     data.NewSQLiteTaskRepo(db),
+		shareRepo:    data.NewSQLiteCalendarShareRepo(db),
+		inboxRepo:    data.NewSQLiteScheduleInboxRepo(db),
+		alarmRepo:    data.NewSQLiteAlarmRepo(db),
+		webhookRepo:  data.NewSQLiteWebhookRepo(db),
+
+		deadPropertyRepo: data.NewSQLiteDeadPropertyRepo(db),
 	}
 }
 
@@ -61,7 +93,8 @@ func (b *Backend) CurrentUserPrincipal(ctx context.Context) (string, error) {
 	return fmt.Sprintf("/dav/principals/%s/", user.Username), nil
 }
 
-// ListCalendars returns all calendars for the current user
+// ListCalendars returns the current user's calendars followed by the
+// calendars shared with them
 func (b *Backend) ListCalendars(ctx context.Context) ([]caldav.Calendar, error) {
 	user := getUserFromContext(ctx)
 	if user == nil {
@@ -73,9 +106,17 @@ func (b *Backend) ListCalendars(ctx context.Context) ([]caldav.Calendar, error)
 		return nil, fmt.Errorf("failed to list calendars: %w", err)
 	}
 
-	result := make([]caldav.Calendar, 0, len(cals))
+	shared, err := b.sharedCalendars(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shared calendars: %w", err)
+	}
+
+	result := make([]caldav.Calendar, 0, len(cals)+len(shared))
 	for _, cal := range cals {
-		result = append(result, b.domainCalendarToCalDAV(cal, user.Username))
+		result = append(result, b.domainCalendarToCalDAV(cal, user.Username, cal.Name))
+	}
+	for _, s := range shared {
+		result = append(result, b.domainCalendarToCalDAV(s.Calendar, user.Username, s.Name))
 	}
 	return result, nil
 }
@@ -93,7 +134,7 @@ func (b *Backend) GetCalendar(ctx context.Context, urlPath string) (*caldav.Cale
 		return nil, webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
 	}
 
-	cal, err := b.calendarRepo.GetByName(ctx, user.ID, calName)
+	cal, _, err := b.calendarByName(ctx, user, calName)
 	if err != nil {
 		if err == domain.ErrNotFound {
 			return nil, webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
@@ -101,11 +142,12 @@ func (b *Backend) GetCalendar(ctx context.Context, urlPath string) (*caldav.Cale
 		return nil, fmt.Errorf("failed to get calendar: %w", err)
 	}
 
-	result := b.domainCalendarToCalDAV(cal, user.Username)
+	result := b.domainCalendarToCalDAV(cal, user.Username, calName)
 	return &result, nil
 }
 
-// CreateCalendar creates a new calendar
+// CreateCalendar creates a new calendar. MKCALENDAR and MKCOL are served by
+// MkCalendarHandler; this is only reached through go-webdav's own code paths.
 func (b *Backend) CreateCalendar(ctx context.Context, calendar *caldav.Calendar) error {
 	user := getUserFromContext(ctx)
 	if user == nil {
@@ -115,17 +157,21 @@ func (b *Backend) CreateCalendar(ctx context.Context, calendar *caldav.Calendar)
 	calName := extractCalendarName(calendar.Path)
 
 	domainCal := &domain.Calendar{
-		UserID:      user.ID,
-		Name:        calName,
-		DisplayName: calendar.Name,
-		Description: calendar.Description,
+		UserID:       user.ID,
+		Name:         calName,
+		DisplayName:  calendar.Name,
+		Description:  calendar.Description,
+		ComponentSet: calendar.SupportedComponentSet,
+	}
+	if err := domainCal.Validate(); err != nil {
+		return webdav.NewHTTPError(403, err)
 	}
 
-	if err := b.calendarRepo.Create(ctx, domainCal); err != nil {
+	if err := b.createCalendar(ctx, domainCal, nil); err != nil {
 		if err == domain.ErrConflict {
 			return webdav.NewHTTPError(409, fmt.Errorf("calendar already exists"))
 		}
-		return fmt.Errorf("failed to create calendar: %w", err)
+		return err
 	}
 
 	slog.Info("caldav.calendar.created", "username", user.Username, "calendar", calName)
@@ -145,20 +191,16 @@ func (b *Backend) GetCalendarObject(ctx context.Context, urlPath string, req *ca
 		return nil, webdav.NewHTTPError(404, fmt.Errorf("event not found"))
 	}
 
-	cal, err := b.calendarRepo.GetByName(ctx, user.ID, calName)
+	cal, _, err := b.calendarByName(ctx, user, calName)
 	if err != nil {
 		return nil, webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
 	}
 
-	event, err := b.eventRepo.GetByUID(ctx, cal.ID, uid)
-	if err != nil {
-		if err == domain.ErrNotFound {
-			return nil, webdav.NewHTTPError(404, fmt.Errorf("event not found"))
-		}
-		return nil, fmt.Errorf("failed to get event: %w", err)
+	calObj, err := b.getCalendarObject(ctx, cal, uid, urlPath)
+	if err == domain.ErrNotFound {
+		return nil, webdav.NewHTTPError(404, fmt.Errorf("event not found"))
 	}
-
-	return b.domainEventToCalDAV(event, urlPath)
+	return calObj, err
 }
 
 // ListCalendarObjects returns all events in a calendar
@@ -169,7 +211,7 @@ func (b *Backend) ListCalendarObjects(ctx context.Context, urlPath string, req *
 	}
 
 	calName := extractCalendarName(urlPath)
-	cal, err := b.calendarRepo.GetByName(ctx, user.ID, calName)
+	cal, _, err := b.calendarByName(ctx, user, calName)
 	if err != nil {
 		return nil, webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
 	}
@@ -189,7 +231,12 @@ func (b *Backend) ListCalendarObjects(ctx context.Context, urlPath string, req *
 		}
 		result = append(result, *calObj)
 	}
-	return result, nil
+
+	tasks, err := b.listTaskObjects(ctx, cal, urlPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, tasks...), nil
 }
 
 // QueryCalendarObjects queries events with filters (time range, etc.)
@@ -200,23 +247,58 @@ func (b *Backend) QueryCalendarObjects(ctx context.Context, urlPath string, quer
 	}
 
 	calName := extractCalendarName(urlPath)
-	cal, err := b.calendarRepo.GetByName(ctx, user.ID, calName)
+	cal, _, err := b.calendarByName(ctx, user, calName)
 	if err != nil {
 		return nil, webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
 	}
 
-	// For MVP: if query has time-range filter, use List; otherwise ListAll
-	var events []*domain.Event
-	if query != nil && !query.CompFilter.Start.IsZero() && !query.CompFilter.End.IsZero() {
-		events, err = b.eventRepo.List(ctx, cal.ID, query.CompFilter.Start, query.CompFilter.End)
-	} else {
-		events, err = b.eventRepo.ListAll(ctx, cal.ID)
+	compName, start, end := queryTimeRange(query)
+	if compName == "" {
+		events, err := b.eventRepo.ListAll(ctx, cal.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query events: %w", err)
+		}
+		return b.filterCalendarObjects(ctx, cal, events, urlPath, query, "", start, end)
+	}
+
+	// Stored start/end only describe the first instance of a recurring event,
+	// so recurring events are always candidates and get expanded in memory.
+	rangeStart, rangeEnd := start, end
+	if rangeEnd.IsZero() {
+		rangeEnd = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+	events, err := b.eventRepo.List(ctx, cal.ID, rangeStart, rangeEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
+	recurring, err := b.eventRepo.ListRecurring(ctx, cal.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
+	seen := make(map[int64]bool, len(events))
+	for _, event := range events {
+		seen[event.ID] = true
+	}
+	for _, event := range recurring {
+		if !seen[event.ID] {
+			events = append(events, event)
+		}
+	}
 
-	result := make([]caldav.CalendarObject, 0, len(events))
+	return b.filterCalendarObjects(ctx, cal, events, urlPath, query, compName, start, end)
+}
+
+// filterCalendarObjects converts events (and the collection's tasks) to
+// calendar objects and keeps those matching query. When compName is set, the
+// time range is matched against the expanded instances of that component
+// instead of go-webdav's matcher, which does not understand recurrence.
+func (b *Backend) filterCalendarObjects(ctx context.Context, cal *domain.Calendar, events []*domain.Event, urlPath string, query *caldav.CalendarQuery, compName string, start, end time.Time) ([]caldav.CalendarObject, error) {
+	var filter caldav.CompFilter
+	if query != nil {
+		filter = withoutTimeRange(query.CompFilter, compName)
+	}
+
+	candidates := make([]caldav.CalendarObject, 0, len(events))
 	for _, event := range events {
 		objPath := fmt.Sprintf("%s%s.ics", ensureTrailingSlash(urlPath), event.UID)
 		calObj, err := b.domainEventToCalDAV(event, objPath)
@@ -224,11 +306,75 @@ func (b *Backend) QueryCalendarObjects(ctx context.Context, urlPath string, quer
 			// Fail the entire operation on first parse error (strict approach)
 			return nil, err
 		}
+		candidates = append(candidates, *calObj)
+	}
+	tasks, err := b.listTaskObjects(ctx, cal, urlPath)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, tasks...)
+
+	result := make([]caldav.CalendarObject, 0, len(candidates))
+	for i := range candidates {
+		calObj := &candidates[i]
+
+		if compName != "" {
+			instances, err := recurrence.Expand(calObj.Data, compName, start, end, time.UTC)
+			if err != nil {
+				slog.Warn("caldav.query.expand_failed", "path", calObj.Path, "error", err)
+				continue
+			}
+			if len(instances) == 0 {
+				continue
+			}
+		}
+		if filter.Name != "" {
+			matched, err := caldav.Match(filter, calObj)
+			if err != nil {
+				return nil, webdav.NewHTTPError(400, err)
+			}
+			if !matched {
+				continue
+			}
+		}
 		result = append(result, *calObj)
 	}
 	return result, nil
 }
 
+// queryTimeRange finds the component-level time-range of a calendar-query,
+// e.g. VCALENDAR > VEVENT > time-range. Returns an empty compName when the
+// query has none.
+func queryTimeRange(query *caldav.CalendarQuery) (compName string, start, end time.Time) {
+	if query == nil {
+		return "", time.Time{}, time.Time{}
+	}
+	for _, child := range query.CompFilter.Comps {
+		if !child.Start.IsZero() || !child.End.IsZero() {
+			return child.Name, child.Start, child.End
+		}
+	}
+	return "", time.Time{}, time.Time{}
+}
+
+// withoutTimeRange returns a copy of filter with the time-range of the
+// compName child removed, leaving prop-filters and nested filters intact.
+func withoutTimeRange(filter caldav.CompFilter, compName string) caldav.CompFilter {
+	if compName == "" {
+		return filter
+	}
+	comps := make([]caldav.CompFilter, len(filter.Comps))
+	copy(comps, filter.Comps)
+	for i := range comps {
+		if comps[i].Name == compName {
+			comps[i].Start = time.Time{}
+			comps[i].End = time.Time{}
+		}
+	}
+	filter.Comps = comps
+	return filter
+}
+
 // PutCalendarObject creates or updates an event
 // This is the CRITICAL method for CalDAV sync with ETag conflict detection
 // Event write and sync token bump are atomic (single transaction).
@@ -243,10 +389,13 @@ func (b *Backend) PutCalendarObject(ctx context.Context, urlPath string, icalDat
 		return nil, webdav.NewHTTPError(400, fmt.Errorf("invalid path"))
 	}
 
-	cal, err := b.calendarRepo.GetByName(ctx, user.ID, calName)
+	cal, access, err := b.calendarByName(ctx, user, calName)
 	if err != nil {
 		return nil, webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
 	}
+	if !access.canWrite() {
+		return nil, webdav.NewHTTPError(403, errReadOnlyCalendar)
+	}
 
 	// Encode iCalendar to bytes (this is what we persist)
 	icsBytes, err := encodeICalendar(icalData)
@@ -262,34 +411,68 @@ func (b *Backend) PutCalendarObject(ctx context.Context, urlPath string, icalDat
 		return nil, webdav.NewHTTPError(400, fmt.Errorf("no UID in iCalendar data"))
 	}
 
+	// The object's component type must be one the calendar accepts
+	compType := mainComponentName(icalData)
+	if !cal.SupportsComponent(compType) {
+		return nil, webdav.NewHTTPError(403, fmt.Errorf("calendar does not support %s components", compType))
+	}
+	if compType == domain.ComponentTodo {
+		return b.putTask(ctx, user, cal, urlPath, uid, icalData, icsBytes, opts)
+	}
+
 	// Generate ETag from the ICS bytes we're persisting
 	etag := domain.GenerateETag(icsBytes)
 
 	// Extract metadata for SQL queries
-	summary, dtStart, dtEnd, rrule, sequence := extractEventMetadata(icalData)
+	summary, dtStart, dtEnd, rrule, rdates, sequence := extractEventMetadata(icalData)
+	status, allDay := extractEventState(icalData)
+	overrides, err := extractEventOverrides(icalData, uid)
+	if err != nil {
+		return nil, webdav.NewHTTPError(400, err)
+	}
+	alarms := extractEventAlarms(icalData)
 
 	// Check if event exists
 	existing, err := b.eventRepo.GetByUID(ctx, cal.ID, uid)
 	isNew := err == domain.ErrNotFound
 
+	// Meetings notify their attendees or organizer (RFC 6638 implicit scheduling)
+	owner, err := b.calendarOwner(ctx, user, cal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar owner: %w", err)
+	}
+	var previous *ical.Calendar
+	if !isNew {
+		// Stored data that no longer parses is treated as a new meeting
+		previous, _ = parseICalendar(existing.ICS)
+	}
+	messages, keepScheduleTag := putSchedulingMessages(owner, previous, icalData)
+
 	if isNew {
 		// Creating new event
-		// Check If-None-Match: * (client expects resource to not exist)
-		if opts != nil && opts.IfNoneMatch.IsSet() && !opts.IfNoneMatch.IsWildcard() {
-			return nil, webdav.NewHTTPError(412, fmt.Errorf("resource exists"))
+		// If-Match never matches a missing resource; If-None-Match: * holds
+		if opts != nil && opts.IfMatch.IsSet() && !checkIfMatch(ctx, opts.IfMatch, "") {
+			return nil, webdav.NewHTTPError(412, fmt.Errorf("resource does not exist"))
+		}
+		if opts != nil && opts.IfNoneMatch.IsSet() {
+			recordETagOutcome(ctx, domain.CalDAVETagMissing)
 		}
 
 		event := &domain.Event{
-			CalendarID:     cal.ID,
-			UID:            uid,
-			ICS:            string(icsBytes),
-			Summary:        summary,
-			StartTime:      dtStart,
-			EndTime:        dtEnd,
-			RecurrenceRule: rrule,
-			ETag:           etag,
-			Sequence:       sequence,
-			Status:         "CONFIRMED",
+			CalendarID:      cal.ID,
+			UID:             uid,
+			ICS:             string(icsBytes),
+			Summary:         summary,
+			StartTime:       dtStart,
+			EndTime:         dtEnd,
+			RecurrenceRule:  rrule,
+			RecurrenceDates: rdates,
+			ETag:            etag,
+			AllDay:          allDay,
+			Sequence:        sequence,
+			Status:          status,
+			Overrides:       overrides,
+			Alarms:          alarms,
 		}
 
 		// Begin transaction for atomic event create + sync token bump
@@ -306,16 +489,27 @@ func (b *Backend) PutCalendarObject(ctx context.Context, urlPath string, icalDat
 			return nil, fmt.Errorf("failed to create event: %w", err)
 		}
 
-		// Increment calendar sync token (in same transaction)
-		if _, err := calendarRepoTx.IncrementSyncToken(ctx, cal.ID); err != nil {
+		// Record the change and bump the calendar sync token (in same transaction)
+		if _, err := calendarRepoTx.RecordChange(ctx, cal.ID, uid, domain.CalendarChangeCreated); err != nil {
 			return nil, fmt.Errorf("failed to increment sync token: %w", err)
 		}
 
+		var notes txNotifications
+		if err := b.notifyEventInTx(ctx, tx, &notes, cal, uid, domain.CalendarChangeCreated, event); err != nil {
+			return nil, err
+		}
+		outbound, err := b.deliverInTx(ctx, tx, owner, messages, &notes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deliver scheduling messages: %w", err)
+		}
+
 		if err := tx.Commit(); err != nil {
 			return nil, fmt.Errorf("failed to commit transaction: %w", err)
 		}
 
 		slog.Info("caldav.event.created", "username", user.Username, "calendar", calName, "uid", uid, "etag", etag)
+		b.publish(ctx, notes)
+		b.deliverOutbound(ctx, outbound)
 		return b.domainEventToCalDAV(event, urlPath)
 	}
 
@@ -323,15 +517,15 @@ func (b *Backend) PutCalendarObject(ctx context.Context, urlPath string, icalDat
 	// Save the old ETag for precondition checking
 	oldETag := existing.ETag
 
+	// Check If-None-Match: * (client expects resource to not exist)
+	if opts != nil && opts.IfNoneMatch.IsSet() && opts.IfNoneMatch.IsWildcard() {
+		recordETagOutcome(ctx, domain.CalDAVETagMismatched)
+		return nil, webdav.NewHTTPError(412, fmt.Errorf("resource exists"))
+	}
+
 	// Check If-Match header (ETag validation)
-	if opts != nil && opts.IfMatch.IsSet() {
-		if !opts.IfMatch.IsWildcard() {
-			clientETag := string(opts.IfMatch)
-			if clientETag != oldETag {
-				slog.Debug("caldav.conflict", "expected_etag", clientETag, "actual_etag", oldETag, "status", 412)
-				return nil, webdav.NewHTTPError(412, fmt.Errorf("ETag mismatch"))
-			}
-		}
+	if opts != nil && opts.IfMatch.IsSet() && !checkIfMatch(ctx, opts.IfMatch, oldETag) {
+		return nil, webdav.NewHTTPError(412, fmt.Errorf("ETag mismatch"))
 	}
 
 	// Update the event
@@ -340,8 +534,16 @@ func (b *Backend) PutCalendarObject(ctx context.Context, urlPath string, icalDat
 	existing.StartTime = dtStart
 	existing.EndTime = dtEnd
 	existing.RecurrenceRule = rrule
+	existing.RecurrenceDates = rdates
+	existing.AllDay = allDay
 	existing.ETag = etag
 	existing.Sequence = sequence
+	existing.Status = status
+	existing.Overrides = overrides
+	existing.Alarms = alarms
+	if !keepScheduleTag {
+		existing.ScheduleTag = etag
+	}
 
 	// Begin transaction for atomic event update + sync token bump
 	tx, err := b.db.BeginTx(ctx, nil)
@@ -356,21 +558,33 @@ func (b *Backend) PutCalendarObject(ctx context.Context, urlPath string, icalDat
 	if err := eventRepoTx.Update(ctx, existing, oldETag); err != nil {
 		if err == domain.ErrPreconditionFailed {
 			slog.Debug("caldav.conflict", "expected_etag", oldETag, "actual_etag", "changed", "status", 412)
+			recordETagOutcome(ctx, domain.CalDAVETagMismatched)
 			return nil, webdav.NewHTTPError(412, fmt.Errorf("concurrent modification"))
 		}
 		return nil, fmt.Errorf("failed to update event: %w", err)
 	}
 
-	// Increment calendar sync token (in same transaction)
-	if _, err := calendarRepoTx.IncrementSyncToken(ctx, cal.ID); err != nil {
+	// Record the change and bump the calendar sync token (in same transaction)
+	if _, err := calendarRepoTx.RecordChange(ctx, cal.ID, uid, domain.CalendarChangeUpdated); err != nil {
 		return nil, fmt.Errorf("failed to increment sync token: %w", err)
 	}
 
+	var notes txNotifications
+	if err := b.notifyEventInTx(ctx, tx, &notes, cal, uid, domain.CalendarChangeUpdated, existing); err != nil {
+		return nil, err
+	}
+	outbound, err := b.deliverInTx(ctx, tx, owner, messages, &notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deliver scheduling messages: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	slog.Info("caldav.event.updated", "username", user.Username, "calendar", calName, "uid", uid, "etag", etag)
+	b.publish(ctx, notes)
+	b.deliverOutbound(ctx, outbound)
 	return b.domainEventToCalDAV(existing, urlPath)
 }
 
@@ -383,10 +597,23 @@ func (b *Backend) DeleteCalendarObject(ctx context.Context, urlPath string) erro
 	}
 
 	calName, uid := extractCalendarAndUID(urlPath)
-	cal, err := b.calendarRepo.GetByName(ctx, user.ID, calName)
+	cal, access, err := b.calendarByName(ctx, user, calName)
 	if err != nil {
 		return webdav.NewHTTPError(404, fmt.Errorf("calendar not found"))
 	}
+	if uid == "" {
+		if access != accessOwner {
+			return b.leaveSharedCalendar(ctx, user, cal, urlPath)
+		}
+		return b.deleteCalendar(ctx, user, cal, urlPath)
+	}
+	if !access.canWrite() {
+		return webdav.NewHTTPError(403, errReadOnlyCalendar)
+	}
+	owner, err := b.calendarOwner(ctx, user, cal)
+	if err != nil {
+		return fmt.Errorf("failed to get calendar owner: %w", err)
+	}
 
 	// Begin transaction for atomic event delete + sync token bump
 	tx, err := b.db.BeginTx(ctx, nil)
@@ -398,34 +625,87 @@ func (b *Backend) DeleteCalendarObject(ctx context.Context, urlPath string) erro
 	eventRepoTx := b.eventRepo.WithTx(tx)
 	calendarRepoTx := b.calendarRepo.WithTx(tx)
 
-	if err := eventRepoTx.Delete(ctx, cal.ID, uid); err != nil {
+	// Deleting a meeting cancels it, or declines it for an attendee
+	var messages []itipMessage
+	event, err := eventRepoTx.GetByUID(ctx, cal.ID, uid)
+	if err != nil && err != domain.ErrNotFound {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+	if event != nil {
+		if data, err := parseICalendar(event.ICS); err == nil {
+			if msg := deleteSchedulingMessage(owner, data); msg != nil {
+				messages = append(messages, *msg)
+			}
+		}
+	}
+
+	if pre := preconditionsFromContext(ctx); pre.IfMatch.IsSet() || pre.IfScheduleTagMatch.IsSet() {
+		// Tasks are not scheduled, so their Schedule-Tag is their ETag
+		etag, scheduleTag := "", ""
+		if event != nil {
+			etag, scheduleTag = event.ETag, event.ScheduleTag
+		} else if etag, err = b.objectETagInTx(ctx, tx, cal.ID, uid); err != nil {
+			return err
+		} else {
+			scheduleTag = etag
+		}
+		if !pre.check(ctx, etag, scheduleTag) {
+			return webdav.NewHTTPError(412, fmt.Errorf("precondition failed"))
+		}
+	}
+
+	err = eventRepoTx.Delete(ctx, cal.ID, uid)
+	if err == domain.ErrNotFound {
+		// Not an event; VTODO collections store tasks under the same paths
+		err = b.deleteTaskInTx(ctx, tx, cal.ID, uid)
+	}
+	if err != nil {
 		if err == domain.ErrNotFound {
 			return webdav.NewHTTPError(404, fmt.Errorf("event not found"))
 		}
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
 
-	// Increment calendar sync token (in same transaction)
-	if _, err := calendarRepoTx.IncrementSyncToken(ctx, cal.ID); err != nil {
+	if err := b.deadPropertyRepo.WithTx(tx).DeleteByPathPrefix(ctx, user.ID, davResourcePath(urlPath)); err != nil {
+		return err
+	}
+
+	// Record a tombstone and bump the calendar sync token (in same transaction)
+	if _, err := calendarRepoTx.RecordChange(ctx, cal.ID, uid, domain.CalendarChangeDeleted); err != nil {
 		return fmt.Errorf("failed to increment sync token: %w", err)
 	}
 
+	var notes txNotifications
+	if event != nil {
+		if err := b.notifyEventInTx(ctx, tx, &notes, cal, uid, domain.CalendarChangeDeleted, nil); err != nil {
+			return err
+		}
+	}
+	outbound, err := b.deliverInTx(ctx, tx, owner, messages, &notes)
+	if err != nil {
+		return fmt.Errorf("failed to deliver scheduling messages: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	slog.Info("caldav.event.deleted", "username", user.Username, "calendar", calName, "uid", uid)
+	b.publish(ctx, notes)
+	b.deliverOutbound(ctx, outbound)
 	return nil
 }
 
 // Helper functions
 
-func (b *Backend) domainCalendarToCalDAV(cal *domain.Calendar, username string) caldav.Calendar {
+// domainCalendarToCalDAV describes cal as the calendar called name in the
+// calendar home of username; name differs from cal.Name for shared calendars.
+func (b *Backend) domainCalendarToCalDAV(cal *domain.Calendar, username, name string) caldav.Calendar {
 	return caldav.Calendar{
-		Path:                  fmt.Sprintf("/dav/calendars/%s/%s/", username, cal.Name),
+		Path:                  fmt.Sprintf("/dav/calendars/%s/%s/", username, name),
 		Name:                  cal.DisplayName,
 		Description:           cal.Description,
-		SupportedComponentSet: []string{"VEVENT"},
+		SupportedComponentSet: cal.Components(),
 	}
 }
 
@@ -451,6 +731,20 @@ func (b *Backend) domainEventToCalDAV(event *domain.Event, urlPath string) (*cal
 	}, nil
 }
 
+// etagMatches compares an If-Match/If-None-Match value against a stored ETag.
+// Stored ETags already carry their quotes and go-webdav quotes them again in
+// the ETag header, so clients may echo either form.
+func etagMatches(cond webdav.ConditionalMatch, stored string) bool {
+	if cond.IsWildcard() {
+		return true
+	}
+	value := string(cond)
+	if unquoted, err := cond.ETag(); err == nil {
+		value = unquoted
+	}
+	return value == stored || `"`+value+`"` == stored
+}
+
 // Context key for user
 type contextKey string
 
@@ -548,49 +842,206 @@ func extractUIDFromICalendar(cal *ical.Calendar) string {
 	return ""
 }
 
-// extractEventMetadata extracts metadata from VEVENT for SQL storage
-func extractEventMetadata(cal *ical.Calendar) (summary string, dtStart, dtEnd time.Time, rrule string, sequence int) {
+// extractEventMetadata extracts metadata from the master VEVENT for SQL storage.
+// RECURRENCE-ID overrides are indexed separately by extractEventOverrides.
+func extractEventMetadata(cal *ical.Calendar) (summary string, dtStart, dtEnd time.Time, rrule, rdates string, sequence int) {
+	comp := masterComponent(cal, "VEVENT")
+	if comp == nil {
+		return
+	}
+
+	// Extract SUMMARY
+	if prop := comp.Props.Get("SUMMARY"); prop != nil {
+		summary = prop.Value
+	}
+
+	// Extract DTSTART and DTEND (or DTSTART + DURATION)
+	dtStart, dtEnd = componentTimes(comp)
+
+	// Extract RRULE
+	if prop := comp.Props.Get("RRULE"); prop != nil {
+		rrule = prop.Value
+	}
+
+	// Extract RDATE (may be repeated)
+	var dates []string
+	for _, prop := range comp.Props.Values("RDATE") {
+		dates = append(dates, prop.Value)
+	}
+	rdates = strings.Join(dates, ",")
+
+	// Extract SEQUENCE
+	sequence = componentSequence(comp)
+	return
+}
+
+// extractEventState returns the STATUS of the master VEVENT, CONFIRMED when
+// it has none, and whether it is an all-day event (DTSTART is a DATE).
+func extractEventState(cal *ical.Calendar) (status string, allDay bool) {
+	status = "CONFIRMED"
+	comp := masterComponent(cal, "VEVENT")
+	if comp == nil {
+		return
+	}
+	if prop := comp.Props.Get("STATUS"); prop != nil && prop.Value != "" {
+		status = strings.ToUpper(prop.Value)
+	}
+	allDay = isDateValue(comp.Props.Get("DTSTART"))
+	return
+}
+
+// isDateValue reports whether a date-time property holds a DATE.
+func isDateValue(prop *ical.Prop) bool {
+	return prop != nil && (strings.EqualFold(prop.Params.Get(ical.ParamValue), "DATE") || len(prop.Value) == len("20060102"))
+}
+
+// masterComponent returns the component of the given type without a
+// RECURRENCE-ID. A calendar object that only carries overrides (e.g. an
+// invitation to a single instance) falls back to its first component.
+func masterComponent(cal *ical.Calendar, name string) *ical.Component {
+	var first *ical.Component
 	for _, comp := range cal.Children {
-		if comp.Name == "VEVENT" {
-			// Extract SUMMARY
-			if prop := comp.Props.Get("SUMMARY"); prop != nil {
-				summary = prop.Value
-			}
+		if comp.Name != name {
+			continue
+		}
+		if comp.Props.Get("RECURRENCE-ID") == nil {
+			return comp
+		}
+		if first == nil {
+			first = comp
+		}
+	}
+	return first
+}
 
-			// Extract DTSTART
-			if prop := comp.Props.Get("DTSTART"); prop != nil {
-				dtStart, _ = parseICalTime(prop)
-			}
+// extractEventOverrides indexes the RECURRENCE-ID components of a calendar
+// object. All components must share the object's UID (RFC 4791 §4.1) and each
+// RECURRENCE-ID may appear only once.
+func extractEventOverrides(cal *ical.Calendar, uid string) ([]domain.EventOverride, error) {
+	var overrides []domain.EventOverride
+	seen := make(map[int64]bool)
+	for _, comp := range cal.Children {
+		if comp.Name != "VEVENT" {
+			continue
+		}
+		if prop := comp.Props.Get("UID"); prop != nil && prop.Value != uid {
+			return nil, fmt.Errorf("components with different UIDs in one calendar object")
+		}
+		ridProp := comp.Props.Get("RECURRENCE-ID")
+		if ridProp == nil {
+			continue
+		}
+		recurrenceID, err := parseICalTime(ridProp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RECURRENCE-ID: %w", err)
+		}
+		if seen[recurrenceID.Unix()] {
+			return nil, fmt.Errorf("duplicate RECURRENCE-ID %s", ridProp.Value)
+		}
+		seen[recurrenceID.Unix()] = true
 
-			// Extract DTEND or calculate from DURATION
-			if prop := comp.Props.Get("DTEND"); prop != nil {
-				dtEnd, _ = parseICalTime(prop)
-			} else if prop := comp.Props.Get("DURATION"); prop != nil {
-				// Parse duration and add to dtStart
-				duration, err := parseICalDuration(prop.Value)
-				if err == nil {
-					dtEnd = dtStart.Add(duration)
-				}
-			}
+		start, end := componentTimes(comp)
+		if start.IsZero() {
+			start = recurrenceID
+		}
+		if end.IsZero() || end.Before(start) {
+			end = start
+		}
+		override := domain.EventOverride{
+			RecurrenceID:  recurrenceID,
+			ThisAndFuture: strings.EqualFold(ridProp.Params.Get("RANGE"), "THISANDFUTURE"),
+			StartTime:     start,
+			EndTime:       end,
+			AllDay:        isDateValue(comp.Props.Get("DTSTART")),
+			Sequence:      componentSequence(comp),
+			Status:        "CONFIRMED",
+		}
+		if prop := comp.Props.Get("SUMMARY"); prop != nil {
+			override.Summary = prop.Value
+		}
+		if prop := comp.Props.Get("STATUS"); prop != nil && prop.Value != "" {
+			override.Status = strings.ToUpper(prop.Value)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
 
-			// Extract RRULE
-			if prop := comp.Props.Get("RRULE"); prop != nil {
-				rrule = prop.Value
-			}
+// extractEventAlarms indexes the VALARMs of a calendar object. Triggers of a
+// recurring event depend on its recurrence set and are only computed when
+// near; those of other events are bounded here. Floating and all-day times
+// are taken as UTC, as in time-range queries.
+func extractEventAlarms(cal *ical.Calendar) []domain.EventAlarm {
+	recurring := false
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompEvent && (recurrence.IsRecurring(comp) || comp.Props.Get(ical.PropRecurrenceID) != nil) {
+			recurring = true
+		}
+	}
 
-			// Extract SEQUENCE
-			if prop := comp.Props.Get("SEQUENCE"); prop != nil {
-				seq, err := strconv.Atoi(prop.Value)
-				if err == nil {
-					sequence = seq
-				}
+	var alarms []domain.EventAlarm
+	index := make(map[string]int)
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		for _, alarm := range recurrence.ComponentAlarms(comp) {
+			// Overrides often repeat the master's alarm UIDs
+			if _, ok := index[alarm.Key]; ok {
+				continue
 			}
+			index[alarm.Key] = len(alarms)
+			alarms = append(alarms, domain.EventAlarm{
+				Key:         alarm.Key,
+				Action:      alarm.Action,
+				Description: alarm.Description,
+				Recurring:   recurring,
+			})
+		}
+	}
+	if recurring || len(alarms) == 0 {
+		return alarms
+	}
 
-			// Only process the first VEVENT
-			break
+	triggers, err := recurrence.Triggers(cal, ical.CompEvent, time.Unix(0, 0), time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC)
+	if err != nil {
+		return alarms
+	}
+	for _, trigger := range triggers {
+		a := &alarms[index[trigger.Alarm.Key]]
+		if a.FirstTrigger.IsZero() || trigger.At.Before(a.FirstTrigger) {
+			a.FirstTrigger = trigger.At
+		}
+		if trigger.At.After(a.LastTrigger) {
+			a.LastTrigger = trigger.At
 		}
 	}
-	return
+	return alarms
+}
+
+// componentTimes returns DTSTART and DTEND (or DTSTART + DURATION) of a component.
+func componentTimes(comp *ical.Component) (dtStart, dtEnd time.Time) {
+	if prop := comp.Props.Get("DTSTART"); prop != nil {
+		dtStart, _ = parseICalTime(prop)
+	}
+	if prop := comp.Props.Get("DTEND"); prop != nil {
+		dtEnd, _ = parseICalTime(prop)
+	} else if prop := comp.Props.Get("DURATION"); prop != nil {
+		duration, err := parseICalDuration(prop.Value)
+		if err == nil {
+			dtEnd = dtStart.Add(duration)
+		}
+	}
+	return dtStart, dtEnd
+}
+
+func componentSequence(comp *ical.Component) int {
+	if prop := comp.Props.Get("SEQUENCE"); prop != nil {
+		if seq, err := strconv.Atoi(prop.Value); err == nil {
+			return seq
+		}
+	}
+	return 0
 }
 
 // parseICalTime parses an iCalendar DATE-TIME or DATE property
@@ -0,0 +1,182 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/emersion/go-webdav/caldav"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var calendarMultigetName = xml.Name{Space: caldavNS, Local: "calendar-multiget"}
+
+// calendarMultigetRequest is the body of an RFC 4791 §7.9 CALDAV:calendar-multiget REPORT.
+type calendarMultigetRequest struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:caldav calendar-multiget"`
+	Prop    *davProp `xml:"DAV: prop"`
+	Hrefs   []string `xml:"DAV: href"`
+}
+
+// CalendarMultigetHandler serves CALDAV:calendar-multiget REPORTs. go-webdav
+// resolves each href on its own (a calendar and an object lookup per href);
+// here every calendar is looked up once and its objects in one batched query.
+// The calendar-data expand and limit-recurrence-set modifiers are applied to
+// every object as in calendar-query.
+type CalendarMultigetHandler struct {
+	backend *Backend
+}
+
+func NewCalendarMultigetHandler(backend *Backend) *CalendarMultigetHandler {
+	return &CalendarMultigetHandler{backend: backend}
+}
+
+func (h *CalendarMultigetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readXMLBody(r)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var req calendarMultigetRequest
+	var dataProp calendarDataProp
+	if err := xml.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Malformed calendar-multiget request", http.StatusBadRequest)
+		return
+	}
+	if err := xml.Unmarshal(body, &dataProp); err != nil {
+		http.Error(w, "Malformed calendar-multiget request", http.StatusBadRequest)
+		return
+	}
+
+	var dataReq calendarDataRequest
+	if dataProp.Prop.CalendarData != nil {
+		dataReq = *dataProp.Prop.CalendarData
+	}
+
+	objects, err := h.backend.MultigetCalendarObjects(r.Context(), req.Hrefs)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	names := []xml.Name{getETagName, getContentTypeName}
+	if req.Prop != nil {
+		names = req.Prop.Names()
+	}
+
+	ms := &davMultistatus{}
+	for _, href := range req.Hrefs {
+		obj, ok := objects[href]
+		if !ok {
+			ms.Responses = append(ms.Responses, davResponse{
+				Hrefs:  []string{href},
+				Status: davStatus(http.StatusNotFound),
+			})
+			continue
+		}
+		data, err := applyCalendarDataRequest(obj.Data, &dataReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		obj.Data = data
+		ms.Responses = append(ms.Responses, calendarObjectResponse(obj, names))
+	}
+
+	slog.Debug("caldav.calendar_multiget", "requested", len(req.Hrefs), "found", len(objects))
+	writeMultistatus(w, ms)
+}
+
+// MultigetCalendarObjects resolves calendar object hrefs with one calendar
+// lookup per distinct calendar and one batched object query per calendar.
+// The result is keyed by href; hrefs that do not name an existing object of
+// the current user are absent.
+func (b *Backend) MultigetCalendarObjects(ctx context.Context, hrefs []string) (map[string]*caldav.CalendarObject, error) {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return nil, errNoAuthenticatedUser
+	}
+
+	// Group the requested UIDs by calendar, remembering which href asked for which UID
+	type calendarBatch struct {
+		uids  []string
+		hrefs map[string][]string // uid -> hrefs
+	}
+	batches := make(map[string]*calendarBatch)
+	var order []string
+	for _, href := range hrefs {
+		calName, uid := extractCalendarAndUID(hrefPath(href))
+		if calName == "" || uid == "" {
+			continue
+		}
+		batch, ok := batches[calName]
+		if !ok {
+			batch = &calendarBatch{hrefs: make(map[string][]string)}
+			batches[calName] = batch
+			order = append(order, calName)
+		}
+		if _, seen := batch.hrefs[uid]; !seen {
+			batch.uids = append(batch.uids, uid)
+		}
+		batch.hrefs[uid] = append(batch.hrefs[uid], href)
+	}
+
+	result := make(map[string]*caldav.CalendarObject, len(hrefs))
+	for _, calName := range order {
+		batch := batches[calName]
+		cal, _, err := b.calendarByName(ctx, user, calName)
+		if err != nil {
+			if err == domain.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		events, err := b.eventRepo.GetByUIDs(ctx, cal.ID, batch.uids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events: %w", err)
+		}
+		for _, event := range events {
+			for _, href := range batch.hrefs[event.UID] {
+				obj, err := b.domainEventToCalDAV(event, href)
+				if err != nil {
+					return nil, err
+				}
+				result[href] = obj
+			}
+		}
+
+		if !cal.SupportsComponent(domain.ComponentTodo) {
+			continue
+		}
+		tasks, err := b.taskRepo.GetByUIDs(ctx, cal.ID, batch.uids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tasks: %w", err)
+		}
+		for _, task := range tasks {
+			for _, href := range batch.hrefs[task.UID] {
+				obj, err := b.domainTaskToCalDAV(task, href)
+				if err != nil {
+					return nil, err
+				}
+				result[href] = obj
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// hrefPath returns the unescaped path of a DAV:href, which may be an absolute
+// URL or an absolute path.
+func hrefPath(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return u.Path
+}
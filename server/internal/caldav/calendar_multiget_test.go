@@ -0,0 +1,123 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCalDAV_CalendarMultiget(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	putTestEvent(t, srv.URL, "multi-a")
+	putTestEvent(t, srv.URL, "multi-b")
+
+	base := caldavBase + "/calendars/testuser/default/"
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <d:href>` + base + `multi-a.ics</d:href>
+  <d:href>` + base + `missing.ics</d:href>
+  <d:href>` + base + `multi-b.ics</d:href>
+</c:calendar-multiget>`
+
+	status, data := doXMLRequest(t, "REPORT", srv.URL+base, "1", body)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("calendar-multiget: expected 207, got %d. Body: %s", status, string(data))
+	}
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		t.Fatalf("Failed to decode multiget response: %v", err)
+	}
+	if len(ms.Responses) != 3 {
+		t.Fatalf("Expected 3 responses, got %d", len(ms.Responses))
+	}
+
+	for i, want := range []string{"multi-a.ics", "missing.ics", "multi-b.ics"} {
+		if !strings.HasSuffix(ms.Responses[i].Hrefs[0], want) {
+			t.Errorf("Response %d: expected href %s, got %s", i, want, ms.Responses[i].Hrefs[0])
+		}
+	}
+	if ms.Responses[1].Status != "HTTP/1.1 404 Not Found" {
+		t.Errorf("Missing href: expected 404 status, got %q", ms.Responses[1].Status)
+	}
+	for _, i := range []int{0, 2} {
+		statuses := propStatuses(ms.Responses[i])
+		if statuses["getetag"] != "HTTP/1.1 200 OK" || statuses["calendar-data"] != "HTTP/1.1 200 OK" {
+			t.Errorf("Response %d: expected getetag and calendar-data, got %v", i, statuses)
+		}
+	}
+	if !strings.Contains(string(data), "UID:multi-b") {
+		t.Errorf("Expected calendar data for multi-b in response: %s", string(data))
+	}
+}
+
+func TestCalDAV_CalendarMultiget_Expand(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	putWeeklyEvent(t, srv.URL, "weekly-multiget")
+	putTestEvent(t, srv.URL, "single-multiget") // 2026-01-16 only
+
+	base := caldavBase + "/calendars/testuser/default/"
+	multiget := func(calendarData string) (int, string) {
+		body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/>` + calendarData + `</d:prop>
+  <d:href>` + base + `weekly-multiget.ics</d:href>
+  <d:href>` + base + `single-multiget.ics</d:href>
+</c:calendar-multiget>`
+		status, data := doXMLRequest(t, "REPORT", srv.URL+base, "1", body)
+		return status, string(data)
+	}
+	calendarData := func(t *testing.T, data string) []string {
+		t.Helper()
+		var ms davMultistatus
+		if err := xml.Unmarshal([]byte(data), &ms); err != nil || len(ms.Responses) != 2 {
+			t.Fatalf("multiget response: %v\n%s", err, data)
+		}
+		var objects []string
+		for _, resp := range ms.Responses {
+			for _, ps := range resp.PropStats {
+				for _, v := range ps.Prop.Values {
+					if v.XMLName == calendarDataName {
+						objects = append(objects, string(v.Inner))
+					}
+				}
+			}
+		}
+		return objects
+	}
+
+	// Expanded like calendar-query: instances in the range, no RRULE; an
+	// event outside the range keeps no instance
+	status, data := multiget(`<c:calendar-data><c:expand start="20260601T000000Z" end="20260615T000000Z"/></c:calendar-data>`)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("calendar-multiget with expand: expected 207, got %d. Body: %s", status, data)
+	}
+	objects := calendarData(t, data)
+	if len(objects) != 2 {
+		t.Fatalf("Expected calendar-data for both hrefs, got %d", len(objects))
+	}
+	weekly := objects[0]
+	if strings.Contains(weekly, "RRULE") || strings.Count(weekly, "BEGIN:VEVENT") != 2 ||
+		!strings.Contains(weekly, "RECURRENCE-ID:20260601T090000Z") || !strings.Contains(weekly, "RECURRENCE-ID:20260608T090000Z") {
+		t.Errorf("Expected 2 expanded instances:\n%s", weekly)
+	}
+	if strings.Contains(objects[1], "BEGIN:VEVENT") {
+		t.Errorf("Expected no instance of the event outside the range:\n%s", objects[1])
+	}
+
+	// limit-recurrence-set keeps the master with its RRULE
+	status, data = multiget(`<c:calendar-data><c:limit-recurrence-set start="20260601T000000Z" end="20260615T000000Z"/></c:calendar-data>`)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("calendar-multiget with limit-recurrence-set: expected 207, got %d. Body: %s", status, data)
+	}
+	if objects := calendarData(t, data); !strings.Contains(objects[0], "RRULE:FREQ=WEEKLY") {
+		t.Errorf("Expected the master with its RRULE:\n%s", objects[0])
+	}
+
+	if status, data := multiget(`<c:calendar-data><c:expand start="20260601T000000Z"/></c:calendar-data>`); status != http.StatusBadRequest {
+		t.Errorf("calendar-multiget with an open expand range: expected 400, got %d. Body: %s", status, data)
+	}
+}
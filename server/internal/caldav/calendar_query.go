@@ -0,0 +1,248 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav/caldav"
+
+	"github.com/airplne/calendar-app/server/internal/recurrence"
+)
+
+var (
+	calendarQueryName = xml.Name{Space: caldavNS, Local: "calendar-query"}
+	validFilterName   = xml.Name{Space: caldavNS, Local: "valid-filter"}
+)
+
+// calendarQueryRequest is the body of an RFC 4791 §7.8 CALDAV:calendar-query
+// REPORT. We serve it ourselves because go-webdav neither expands recurrences
+// when matching time ranges nor honours the calendar-data expand and
+// limit-recurrence-set modifiers.
+type calendarQueryRequest struct {
+	XMLName xml.Name           `xml:"urn:ietf:params:xml:ns:caldav calendar-query"`
+	Prop    *davProp           `xml:"DAV: prop"`
+	Filter  *compFilterElement `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+// calendarDataProp picks the calendar-data element out of a REPORT's DAV:prop
+// so its children are decoded with namespaces resolved (davProp keeps them as
+// raw inner XML).
+type calendarDataProp struct {
+	Prop struct {
+		CalendarData *calendarDataRequest `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	} `xml:"DAV: prop"`
+}
+
+// calendarDataRequest holds the RFC 4791 §9.6 modifiers we act on.
+type calendarDataRequest struct {
+	Expand             *timeRangeElement `xml:"urn:ietf:params:xml:ns:caldav expand"`
+	LimitRecurrenceSet *timeRangeElement `xml:"urn:ietf:params:xml:ns:caldav limit-recurrence-set"`
+}
+
+type timeRangeElement struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+type compFilterElement struct {
+	Name         string              `xml:"name,attr"`
+	IsNotDefined *struct{}           `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *timeRangeElement   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	PropFilters  []propFilterElement `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+	CompFilters  []compFilterElement `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type propFilterElement struct {
+	Name         string               `xml:"name,attr"`
+	IsNotDefined *struct{}            `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *timeRangeElement    `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	TextMatch    *textMatchElement    `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+	ParamFilters []paramFilterElement `xml:"urn:ietf:params:xml:ns:caldav param-filter"`
+}
+
+type paramFilterElement struct {
+	Name         string            `xml:"name,attr"`
+	IsNotDefined *struct{}         `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TextMatch    *textMatchElement `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type textMatchElement struct {
+	Text            string `xml:",chardata"`
+	NegateCondition string `xml:"negate-condition,attr"`
+}
+
+// parse parses the UTC "date with UTC time" attributes of a
+// time-range, expand or limit-recurrence-set element. Missing attributes are
+// returned as zero times (open range).
+func (t *timeRangeElement) parse() (start, end time.Time, err error) {
+	const layout = "20060102T150405Z"
+	if t.Start != "" {
+		if start, err = time.Parse(layout, t.Start); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start %q", t.Start)
+		}
+	}
+	if t.End != "" {
+		if end, err = time.Parse(layout, t.End); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end %q", t.End)
+		}
+	}
+	return start, end, nil
+}
+
+func (el *compFilterElement) decode() (caldav.CompFilter, error) {
+	cf := caldav.CompFilter{Name: el.Name, IsNotDefined: el.IsNotDefined != nil}
+	if el.TimeRange != nil {
+		start, end, err := el.TimeRange.parse()
+		if err != nil {
+			return cf, err
+		}
+		cf.Start, cf.End = start, end
+	}
+	for i := range el.PropFilters {
+		pf, err := el.PropFilters[i].decode()
+		if err != nil {
+			return cf, err
+		}
+		cf.Props = append(cf.Props, pf)
+	}
+	for i := range el.CompFilters {
+		child, err := el.CompFilters[i].decode()
+		if err != nil {
+			return cf, err
+		}
+		cf.Comps = append(cf.Comps, child)
+	}
+	return cf, nil
+}
+
+func (el *propFilterElement) decode() (caldav.PropFilter, error) {
+	pf := caldav.PropFilter{Name: el.Name, IsNotDefined: el.IsNotDefined != nil}
+	if el.TimeRange != nil {
+		start, end, err := el.TimeRange.parse()
+		if err != nil {
+			return pf, err
+		}
+		pf.Start, pf.End = start, end
+	}
+	pf.TextMatch = el.TextMatch.decode()
+	for _, param := range el.ParamFilters {
+		pf.ParamFilter = append(pf.ParamFilter, caldav.ParamFilter{
+			Name:         param.Name,
+			IsNotDefined: param.IsNotDefined != nil,
+			TextMatch:    param.TextMatch.decode(),
+		})
+	}
+	return pf, nil
+}
+
+func (el *textMatchElement) decode() *caldav.TextMatch {
+	if el == nil {
+		return nil
+	}
+	return &caldav.TextMatch{Text: el.Text, NegateCondition: el.NegateCondition == "yes"}
+}
+
+// CalendarQueryHandler serves CALDAV:calendar-query REPORTs on calendar collections.
+type CalendarQueryHandler struct {
+	backend *Backend
+}
+
+func NewCalendarQueryHandler(backend *Backend) *CalendarQueryHandler {
+	return &CalendarQueryHandler{backend: backend}
+}
+
+func (h *CalendarQueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readXMLBody(r)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var req calendarQueryRequest
+	var dataProp calendarDataProp
+	if err := xml.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Malformed calendar-query request", http.StatusBadRequest)
+		return
+	}
+	if err := xml.Unmarshal(body, &dataProp); err != nil {
+		http.Error(w, "Malformed calendar-query request", http.StatusBadRequest)
+		return
+	}
+	if req.Filter == nil || req.Filter.Name != ical.CompCalendar {
+		writeDAVError(w, http.StatusForbidden, validFilterName)
+		return
+	}
+	filter, err := req.Filter.decode()
+	if err != nil {
+		writeDAVError(w, http.StatusForbidden, validFilterName)
+		return
+	}
+
+	var dataReq calendarDataRequest
+	if dataProp.Prop.CalendarData != nil {
+		dataReq = *dataProp.Prop.CalendarData
+	}
+
+	objects, err := h.backend.QueryCalendarObjects(r.Context(), r.URL.Path, &caldav.CalendarQuery{CompFilter: filter})
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	names := []xml.Name{getETagName, getContentTypeName}
+	if req.Prop != nil {
+		names = req.Prop.Names()
+	}
+
+	ms := &davMultistatus{}
+	for i := range objects {
+		obj := objects[i]
+		data, err := applyCalendarDataRequest(obj.Data, &dataReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		obj.Data = data
+		ms.Responses = append(ms.Responses, calendarObjectResponse(&obj, names))
+	}
+
+	slog.Debug("caldav.calendar_query", "matched", len(objects), "expand", dataReq.Expand != nil)
+	writeMultistatus(w, ms)
+}
+
+// applyCalendarDataRequest rewrites a calendar object per the expand or
+// limit-recurrence-set modifiers of a calendar-data request.
+func applyCalendarDataRequest(cal *ical.Calendar, req *calendarDataRequest) (*ical.Calendar, error) {
+	compName := mainComponentName(cal)
+	if compName == "" {
+		return cal, nil
+	}
+	switch {
+	case req.Expand != nil:
+		start, end, err := req.Expand.parse()
+		if err != nil || start.IsZero() || end.IsZero() {
+			return nil, fmt.Errorf("expand requires start and end in UTC")
+		}
+		return recurrence.ExpandCalendar(cal, compName, start, end)
+	case req.LimitRecurrenceSet != nil:
+		start, end, err := req.LimitRecurrenceSet.parse()
+		if err != nil || start.IsZero() || end.IsZero() {
+			return nil, fmt.Errorf("limit-recurrence-set requires start and end in UTC")
+		}
+		return recurrence.LimitRecurrenceSet(cal, compName, start, end)
+	}
+	return cal, nil
+}
+
+// mainComponentName returns the name of the first non-timezone component.
+func mainComponentName(cal *ical.Calendar) string {
+	for _, child := range cal.Children {
+		if child.Name != ical.CompTimezone {
+			return child.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func putWeeklyEvent(t *testing.T, baseURL, uid string) {
+	t.Helper()
+
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+		"\r\nDTSTAMP:20260105T080000Z\r\nSUMMARY:Weekly\r\nDTSTART:20260105T090000Z\r\nDTEND:20260105T100000Z\r\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=MO\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	req, _ := http.NewRequest("PUT", baseURL+caldavBase+"/calendars/testuser/default/"+uid+".ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PUT %s: expected 201, got %d. Body: %s", uid, resp.StatusCode, string(body))
+	}
+}
+
+func calendarQuery(t *testing.T, baseURL, calendarData, start, end string) davMultistatus {
+	t.Helper()
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/>` + calendarData + `</d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="` + start + `" end="` + end + `"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+	status, data := doXMLRequest(t, "REPORT", baseURL+caldavBase+"/calendars/testuser/default/", "1", body)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("calendar-query: expected 207, got %d. Body: %s", status, string(data))
+	}
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		t.Fatalf("Failed to decode calendar-query response: %v", err)
+	}
+	return ms
+}
+
+func TestCalDAV_CalendarQuery_RecurringEventMatchesLaterWeek(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	putWeeklyEvent(t, srv.URL, "weekly")
+	putTestEvent(t, srv.URL, "single") // 2026-01-16 only
+
+	// A week months after the first instance: only the recurring event matches.
+	ms := calendarQuery(t, srv.URL, "", "20260601T000000Z", "20260608T000000Z")
+	if len(ms.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(ms.Responses))
+	}
+	if want := caldavBase + "/calendars/testuser/default/weekly.ics"; ms.Responses[0].Hrefs[0] != want {
+		t.Errorf("Expected %s, got %s", want, ms.Responses[0].Hrefs[0])
+	}
+
+	// A Tuesday-to-Sunday window misses every Monday instance.
+	ms = calendarQuery(t, srv.URL, "", "20260602T000000Z", "20260607T000000Z")
+	if len(ms.Responses) != 0 {
+		t.Errorf("Expected no responses, got %d", len(ms.Responses))
+	}
+}
+
+func TestCalDAV_CalendarQuery_Expand(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	putWeeklyEvent(t, srv.URL, "weekly-expand")
+
+	calendarData := `<c:calendar-data><c:expand start="20260601T000000Z" end="20260615T000000Z"/></c:calendar-data>`
+	ms := calendarQuery(t, srv.URL, calendarData, "20260601T000000Z", "20260615T000000Z")
+	if len(ms.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(ms.Responses))
+	}
+
+	var ics string
+	for _, ps := range ms.Responses[0].PropStats {
+		for _, v := range ps.Prop.Values {
+			if v.XMLName == calendarDataName {
+				ics = string(v.Inner)
+			}
+		}
+	}
+	if strings.Contains(ics, "RRULE") {
+		t.Errorf("Expanded calendar-data still has RRULE:\n%s", ics)
+	}
+	if got := strings.Count(ics, "BEGIN:VEVENT"); got != 2 {
+		t.Errorf("Expected 2 expanded instances, got %d:\n%s", got, ics)
+	}
+	for _, want := range []string{"RECURRENCE-ID:20260601T090000Z", "RECURRENCE-ID:20260608T090000Z"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Expected %q in expanded calendar-data:\n%s", want, ics)
+		}
+	}
+}
+
+func TestCalDAV_RecurrenceOverride_IndexedAndRoundTripped(t *testing.T) {
+	srv, userRepo, calRepo, eventRepo := setupTestServer(t)
+
+	// Override first: metadata must still come from the master.
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:override\r\nDTSTAMP:20260105T080000Z\r\nSUMMARY:Weekly (moved)\r\n" +
+		"RECURRENCE-ID:20260112T090000Z\r\nDTSTART:20260310T140000Z\r\nDTEND:20260310T150000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:override\r\nDTSTAMP:20260105T080000Z\r\nSUMMARY:Weekly\r\n" +
+		"DTSTART:20260105T090000Z\r\nDTEND:20260105T100000Z\r\nRRULE:FREQ=WEEKLY;COUNT=3\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	req, _ := http.NewRequest("PUT", srv.URL+caldavBase+"/calendars/testuser/default/override.ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT: expected 201, got %d", resp.StatusCode)
+	}
+
+	user, _ := userRepo.GetByUsername(context.Background(), "testuser")
+	cal, _ := calRepo.GetByName(context.Background(), user.ID, "default")
+	event, err := eventRepo.GetByUID(context.Background(), cal.ID, "override")
+	if err != nil {
+		t.Fatalf("GetByUID failed: %v", err)
+	}
+	if event.Summary != "Weekly" || event.RecurrenceRule == "" {
+		t.Errorf("Expected master metadata, got summary %q rrule %q", event.Summary, event.RecurrenceRule)
+	}
+	if len(event.Overrides) != 1 || event.Overrides[0].Summary != "Weekly (moved)" {
+		t.Fatalf("Expected 1 indexed override, got %+v", event.Overrides)
+	}
+
+	// The moved instance matches its new week, and its original slot is gone.
+	if ms := calendarQuery(t, srv.URL, "", "20260309T000000Z", "20260316T000000Z"); len(ms.Responses) != 1 {
+		t.Errorf("Expected moved instance to match, got %d responses", len(ms.Responses))
+	}
+	if ms := calendarQuery(t, srv.URL, "", "20260112T000000Z", "20260113T000000Z"); len(ms.Responses) != 0 {
+		t.Errorf("Expected original slot to be free, got %d responses", len(ms.Responses))
+	}
+
+	// Both components round-trip.
+	req, _ = http.NewRequest("GET", srv.URL+caldavBase+"/calendars/testuser/default/override.ics", nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if got := strings.Count(string(body), "BEGIN:VEVENT"); got != 2 {
+		t.Errorf("Expected 2 VEVENTs after roundtrip, got %d", got)
+	}
+}
+
+func TestCalDAV_RecurrenceOverride_MismatchedUID(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:a\r\nDTSTAMP:20260105T080000Z\r\nDTSTART:20260105T090000Z\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:b\r\nDTSTAMP:20260105T080000Z\r\nRECURRENCE-ID:20260106T090000Z\r\nDTSTART:20260106T100000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	req, _ := http.NewRequest("PUT", srv.URL+caldavBase+"/calendars/testuser/default/a.ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for mixed UIDs, got %d", resp.StatusCode)
+	}
+}
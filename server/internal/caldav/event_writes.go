@@ -0,0 +1,128 @@
+package caldav
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// WriteEvents stores each write the way a PUT or DELETE from the calendar's
+// owner would: the calendar's sync token is bumped, the change is published
+// and meetings notify their attendees or organizer. Every event must still
+// have its ExpectedETag, or WriteEvents fails with
+// domain.ErrPreconditionFailed. inTx runs in the same transaction with the
+// stored events (nil for deletions) so callers can record the writes;
+// nothing is written when it fails.
+func (b *Backend) WriteEvents(ctx context.Context, writes []domain.EventWrite, inTx func(tx *sql.Tx, written []*domain.Event) error) error {
+	var notes txNotifications
+	var outbound []*domain.ITIPMessage
+	err := data.WithTx(ctx, b.db, func(tx *sql.Tx) error {
+		written := make([]*domain.Event, len(writes))
+		for i, write := range writes {
+			event, messages, err := b.writeEventChangeInTx(ctx, tx, write, &notes)
+			if err != nil {
+				return err
+			}
+			written[i] = event
+			outbound = append(outbound, messages...)
+		}
+		if inTx != nil {
+			return inTx(tx, written)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("caldav.events.written", "count", len(writes))
+	b.publish(ctx, notes)
+	b.deliverOutbound(ctx, outbound)
+	return nil
+}
+
+// writeEventChangeInTx makes one write of WriteEvents and returns the stored
+// event and the scheduling messages for users outside this server.
+func (b *Backend) writeEventChangeInTx(ctx context.Context, tx *sql.Tx, write domain.EventWrite, notes *txNotifications) (*domain.Event, []*domain.ITIPMessage, error) {
+	cal, err := b.calendarRepo.WithTx(tx).GetByID(ctx, write.CalendarID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cal.SupportsComponent(domain.ComponentEvent) {
+		return nil, nil, fmt.Errorf("calendar %d does not support %s components", cal.ID, domain.ComponentEvent)
+	}
+	owner, err := b.userRepo.GetByID(ctx, cal.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get calendar owner: %w", err)
+	}
+
+	existing, err := b.eventRepo.WithTx(tx).GetByUID(ctx, cal.ID, write.UID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	currentETag := ""
+	if existing != nil {
+		currentETag = existing.ETag
+	}
+	if currentETag != write.ExpectedETag {
+		return nil, nil, fmt.Errorf("%w: event %s changed", domain.ErrPreconditionFailed, write.UID)
+	}
+	var previous *ical.Calendar
+	if existing != nil {
+		// Stored data that no longer parses is treated as a new meeting
+		previous, _ = parseICalendar(existing.ICS)
+	}
+
+	if write.ICS == "" {
+		if existing == nil {
+			return nil, nil, domain.ErrNotFound
+		}
+		var messages []itipMessage
+		if previous != nil {
+			if msg := deleteSchedulingMessage(owner, previous); msg != nil {
+				messages = append(messages, *msg)
+			}
+		}
+		if err := b.eventRepo.WithTx(tx).Delete(ctx, cal.ID, write.UID); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete event: %w", err)
+		}
+		if _, err := b.calendarRepo.WithTx(tx).RecordChange(ctx, cal.ID, write.UID, domain.CalendarChangeDeleted); err != nil {
+			return nil, nil, err
+		}
+		if err := b.notifyEventInTx(ctx, tx, notes, cal, write.UID, domain.CalendarChangeDeleted, nil); err != nil {
+			return nil, nil, err
+		}
+		outbound, err := b.deliverInTx(ctx, tx, owner, messages, notes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to deliver scheduling messages: %w", err)
+		}
+		return nil, outbound, nil
+	}
+
+	updated, err := parseICalendar(write.ICS)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse event %s: %w", write.UID, err)
+	}
+	if uid := extractUIDFromICalendar(updated); uid != write.UID || mainComponentName(updated) != ical.CompEvent {
+		return nil, nil, fmt.Errorf("calendar object is not the event %s", write.UID)
+	}
+	messages, keepScheduleTag := putSchedulingMessages(owner, previous, updated)
+	if err := b.writeEventInTx(ctx, tx, cal, existing, write.UID, updated, keepScheduleTag, notes); err != nil {
+		return nil, nil, err
+	}
+	outbound, err := b.deliverInTx(ctx, tx, owner, messages, notes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to deliver scheduling messages: %w", err)
+	}
+	event, err := b.eventRepo.WithTx(tx).GetByUID(ctx, cal.ID, write.UID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event, outbound, nil
+}
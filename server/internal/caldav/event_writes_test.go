@@ -0,0 +1,75 @@
+package caldav
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestBackend_WriteEvents(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	eventRepo := data.NewSQLiteEventRepo(db)
+	if _, err := services.NewUserService(userRepo, calendarRepo).CreateUser(ctx, "alice", "alice-password", false); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	calendarID := mustCalendarID(t, calendarRepo, userRepo, "alice")
+	backend := NewBackend(db, userRepo, calendarRepo, eventRepo)
+
+	ics := func(summary string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VEVENT\r\nUID:focus\r\nDTSTAMP:20260101T000000Z\r\n" +
+			"DTSTART:20260302T090000Z\r\nDTEND:20260302T110000Z\r\nSUMMARY:" + summary + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+
+	// Nothing is written when inTx fails
+	errRecord := errors.New("record failed")
+	err = backend.WriteEvents(ctx, []domain.EventWrite{{CalendarID: calendarID, UID: "focus", ICS: ics("Focus")}},
+		func(tx *sql.Tx, written []*domain.Event) error { return errRecord })
+	if !errors.Is(err, errRecord) {
+		t.Fatalf("WriteEvents error = %v, want the inTx error", err)
+	}
+	if _, err := eventRepo.GetByUID(ctx, calendarID, "focus"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("event written despite the failed transaction: %v", err)
+	}
+
+	var created *domain.Event
+	err = backend.WriteEvents(ctx, []domain.EventWrite{{CalendarID: calendarID, UID: "focus", ICS: ics("Focus")}},
+		func(tx *sql.Tx, written []*domain.Event) error {
+			created = written[0]
+			return nil
+		})
+	if err != nil || created == nil || created.Summary != "Focus" || created.ScheduleTag != created.ETag {
+		t.Fatalf("WriteEvents = %+v, %v", created, err)
+	}
+
+	// Writes are checked against the ETag the caller last saw
+	err = backend.WriteEvents(ctx, []domain.EventWrite{{CalendarID: calendarID, UID: "focus", ExpectedETag: `"stale"`, ICS: ics("Deep work")}}, nil)
+	if !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Fatalf("WriteEvents with a stale ETag error = %v, want ErrPreconditionFailed", err)
+	}
+	if err := backend.WriteEvents(ctx, []domain.EventWrite{{CalendarID: calendarID, UID: "focus", ExpectedETag: created.ETag}}, nil); err != nil {
+		t.Fatalf("WriteEvents delete failed: %v", err)
+	}
+	if _, err := eventRepo.GetByUID(ctx, calendarID, "focus"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("event not deleted: %v", err)
+	}
+	changes, err := calendarRepo.ListChangesSince(ctx, calendarID, 0)
+	if err != nil || len(changes) != 2 || changes[1].Type != domain.CalendarChangeDeleted {
+		t.Fatalf("changes = %+v, %v; want created and deleted", changes, err)
+	}
+}
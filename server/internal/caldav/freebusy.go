@@ -0,0 +1,192 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+var freeBusyQueryName = xml.Name{Space: caldavNS, Local: "free-busy-query"}
+
+const (
+	freeBusyProdID = "-//airplne//calendar-app//EN"
+
+	// Window served by the .ifb endpoint when the client does not ask for one.
+	freeBusyPublishPast   = 7 * 24 * time.Hour
+	freeBusyPublishFuture = 60 * 24 * time.Hour
+)
+
+// freeBusyQueryRequest is the body of an RFC 4791 §7.10 CALDAV:free-busy-query REPORT.
+type freeBusyQueryRequest struct {
+	XMLName   xml.Name          `xml:"urn:ietf:params:xml:ns:caldav free-busy-query"`
+	TimeRange *timeRangeElement `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+}
+
+// FreeBusyQueryHandler serves CALDAV:free-busy-query REPORTs on calendar
+// collections. The response is a VFREEBUSY; event details are never included.
+type FreeBusyQueryHandler struct {
+	freeBusy *services.FreeBusyService
+}
+
+func NewFreeBusyQueryHandler(freeBusy *services.FreeBusyService) *FreeBusyQueryHandler {
+	return &FreeBusyQueryHandler{freeBusy: freeBusy}
+}
+
+func (h *FreeBusyQueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeBackendError(w, errNoAuthenticatedUser)
+		return
+	}
+
+	var req freeBusyQueryRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed free-busy-query request", http.StatusBadRequest)
+		return
+	}
+	if req.TimeRange == nil {
+		http.Error(w, "free-busy-query requires a time-range", http.StatusBadRequest)
+		return
+	}
+	start, end, err := req.TimeRange.parse()
+	if err != nil || start.IsZero() || end.IsZero() || !end.After(start) {
+		http.Error(w, "free-busy-query requires start and end in UTC", http.StatusBadRequest)
+		return
+	}
+
+	calName := extractCalendarName(r.URL.Path)
+	if calName == "" {
+		http.Error(w, "free-busy-query must target a calendar collection", http.StatusForbidden)
+		return
+	}
+
+	periods, err := h.freeBusy.BusyPeriods(r.Context(), user.ID, []string{calName}, start, end)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	slog.Debug("caldav.free_busy_query", "calendar", calName, "busy_periods", len(periods))
+	writeFreeBusy(w, user, start, end, periods)
+}
+
+// FreeBusyPublishHandler serves /freebusy/{user}.ifb so other people's
+// calendar apps can poll a user's availability. Any authenticated user may
+// read it; only busy periods are exposed.
+type FreeBusyPublishHandler struct {
+	userRepo domain.UserRepo
+	freeBusy *services.FreeBusyService
+	now      func() time.Time
+}
+
+func NewFreeBusyPublishHandler(userRepo domain.UserRepo, freeBusy *services.FreeBusyService) *FreeBusyPublishHandler {
+	return &FreeBusyPublishHandler{userRepo: userRepo, freeBusy: freeBusy, now: time.Now}
+}
+
+// Routes returns the authenticated router to mount at /freebusy. Subscribed
+// clients may sign in with an app password when appPasswords is not nil;
+// throttle, when not nil, is shared with the CalDAV handler.
+func (h *FreeBusyPublishHandler) Routes(authConfig AuthConfig, appPasswords domain.AppPasswordRepo, throttle *services.LoginThrottle) http.Handler {
+	r := chi.NewRouter()
+	r.Use(BasicAuthMiddleware(authConfig, h.userRepo, appPasswords, throttle))
+	r.Get("/{file}", h.serveIFB)
+	return r
+}
+
+// serveIFB handles GET /freebusy/{user}.ifb. Optional query parameters:
+// start and end (UTC, 20060102T150405Z) and calendar (repeatable) to restrict
+// the calendars considered.
+func (h *FreeBusyPublishHandler) serveIFB(w http.ResponseWriter, r *http.Request) {
+	username, ok := strings.CutSuffix(chi.URLParam(r, "file"), ".ifb")
+	if !ok || username == "" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	owner, err := h.userRepo.GetByUsername(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		writeBackendError(w, err)
+		return
+	}
+
+	now := h.now().UTC().Truncate(time.Second)
+	window := timeRangeElement{Start: r.URL.Query().Get("start"), End: r.URL.Query().Get("end")}
+	start, end, err := window.parse()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if start.IsZero() {
+		start = now.Add(-freeBusyPublishPast)
+	}
+	if end.IsZero() {
+		end = now.Add(freeBusyPublishFuture)
+	}
+	if !end.After(start) {
+		http.Error(w, "end must be after start", http.StatusBadRequest)
+		return
+	}
+
+	periods, err := h.freeBusy.BusyPeriods(r.Context(), owner.ID, r.URL.Query()["calendar"], start, end)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	slog.Debug("caldav.free_busy_publish", "username", owner.Username, "busy_periods", len(periods))
+	writeFreeBusy(w, owner, start, end, periods)
+}
+
+// writeFreeBusy renders busy periods as a VCALENDAR containing one VFREEBUSY.
+func writeFreeBusy(w http.ResponseWriter, owner *domain.User, start, end time.Time, periods []domain.BusyPeriod) {
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(freeBusyCalendar(owner, start, end, periods)); err != nil {
+		slog.Error("failed to encode VFREEBUSY", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// freeBusyCalendar returns a VCALENDAR with one VFREEBUSY of owner's busy
+// periods between start and end.
+func freeBusyCalendar(owner *domain.User, start, end time.Time, periods []domain.BusyPeriod) *ical.Calendar {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, freeBusyProdID)
+
+	fb := ical.NewComponent(ical.CompFreeBusy)
+	fb.Props.SetText(ical.PropUID, fmt.Sprintf("freebusy-%s-%d-%d", owner.Username, start.Unix(), end.Unix()))
+	fb.Props.SetDateTime(ical.PropDateTimeStamp, time.Now().UTC())
+	fb.Props.SetDateTime(ical.PropDateTimeStart, start.UTC())
+	fb.Props.SetDateTime(ical.PropDateTimeEnd, end.UTC())
+	for _, period := range periods {
+		prop := ical.NewProp(ical.PropFreeBusy)
+		prop.Params.Set(ical.ParamFreeBusyType, string(period.Type))
+		prop.Value = formatUTC(period.Start) + "/" + formatUTC(period.End)
+		fb.Props.Add(prop)
+	}
+	cal.Children = append(cal.Children, fb)
+	return cal
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,143 @@
+package caldav
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func putEventICS(t *testing.T, baseURL, uid, body string) {
+	t.Helper()
+
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+		"\r\nDTSTAMP:20260105T080000Z\r\n" + body + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	req, _ := http.NewRequest("PUT", baseURL+caldavBase+"/calendars/testuser/default/"+uid+".ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		data, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PUT %s: expected 201, got %d. Body: %s", uid, resp.StatusCode, string(data))
+	}
+}
+
+func putFreeBusyFixtures(t *testing.T, baseURL string) {
+	t.Helper()
+
+	putWeeklyEvent(t, baseURL, "fb-weekly")
+	putEventICS(t, baseURL, "fb-transparent",
+		"SUMMARY:Secret Holiday\r\nDTSTART:20260106T090000Z\r\nDTEND:20260106T100000Z\r\nTRANSP:TRANSPARENT\r\n")
+	putEventICS(t, baseURL, "fb-cancelled",
+		"SUMMARY:Secret Cancelled\r\nDTSTART:20260107T090000Z\r\nDTEND:20260107T100000Z\r\nSTATUS:CANCELLED\r\n")
+	putEventICS(t, baseURL, "fb-tentative",
+		"SUMMARY:Secret Maybe\r\nDTSTART:20260108T090000Z\r\nDTEND:20260108T100000Z\r\nSTATUS:TENTATIVE\r\n")
+}
+
+func TestCalDAV_FreeBusyQuery(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+	putFreeBusyFixtures(t, srv.URL)
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:free-busy-query xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <c:time-range start="20260105T000000Z" end="20260119T000000Z"/>
+</c:free-busy-query>`
+
+	status, data := doXMLRequest(t, "REPORT", srv.URL+caldavBase+"/calendars/testuser/default/", "1", body)
+	if status != http.StatusOK {
+		t.Fatalf("free-busy-query: expected 200, got %d. Body: %s", status, string(data))
+	}
+	got := string(data)
+
+	for _, want := range []string{
+		"BEGIN:VFREEBUSY",
+		"DTSTART:20260105T000000Z",
+		"DTEND:20260119T000000Z",
+		"FREEBUSY;FBTYPE=BUSY:20260105T090000Z/20260105T100000Z",
+		"FREEBUSY;FBTYPE=BUSY:20260112T090000Z/20260112T100000Z",
+		"FREEBUSY;FBTYPE=BUSY-TENTATIVE:20260108T090000Z/20260108T100000Z",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in response:\n%s", want, got)
+		}
+	}
+	for _, leak := range []string{"20260106T090000Z", "20260107T090000Z", "SUMMARY", "Secret"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("Response must not contain %q:\n%s", leak, got)
+		}
+	}
+}
+
+func TestCalDAV_FreeBusyQuery_RequiresTimeRange(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:free-busy-query xmlns:c="urn:ietf:params:xml:ns:caldav"/>`
+
+	status, _ := doXMLRequest(t, "REPORT", srv.URL+caldavBase+"/calendars/testuser/default/", "1", body)
+	if status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without time-range, got %d", status)
+	}
+}
+
+func TestFreeBusyPublish_IFB(t *testing.T) {
+	srv, userRepo, calendarRepo, eventRepo := setupTestServer(t)
+	putFreeBusyFixtures(t, srv.URL)
+
+	publisher := NewFreeBusyPublishHandler(userRepo, services.NewFreeBusyService(calendarRepo, eventRepo))
+	ifb := httptest.NewServer(publisher.Routes(LoadAuthConfig(), nil, nil))
+	t.Cleanup(ifb.Close)
+
+	url := ifb.URL + "/testuser.ifb?start=20260105T000000Z&end=20260112T000000Z"
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without credentials, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", resp.StatusCode, string(data))
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("Expected text/calendar, got %q", ct)
+	}
+	got := string(data)
+	if !strings.Contains(got, "FREEBUSY;FBTYPE=BUSY:20260105T090000Z/20260105T100000Z") {
+		t.Errorf("Expected weekly busy period in .ifb:\n%s", got)
+	}
+	if strings.Contains(got, "20260112T090000Z") || strings.Contains(got, "Secret") {
+		t.Errorf(".ifb leaked data outside the window or event details:\n%s", got)
+	}
+
+	req, _ = http.NewRequest("GET", ifb.URL+"/nobody.ifb", nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown user, got %d", resp.StatusCode)
+	}
+}
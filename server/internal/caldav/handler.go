@@ -2,6 +2,7 @@ package caldav
 
 import (
 	"database/sql"
+	"encoding/xml"
 	"net/http"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/airplne/calendar-app/server/internal/data"
 	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
 )
 
 func init() {
@@ -18,6 +20,7 @@ func init() {
 	chi.RegisterMethod("PROPPATCH")
 	chi.RegisterMethod("REPORT")
 	chi.RegisterMethod("MKCOL")
+	chi.RegisterMethod("MKCALENDAR")
 	chi.RegisterMethod("MOVE")
 	chi.RegisterMethod("COPY")
 }
@@ -47,14 +50,22 @@ func NewHandler() http.Handler {
 // The db parameter is required for transaction support (atomic event write + sync token bump).
 // The calendarRepo and eventRepo must be concrete SQLite repos to support WithTx.
 func NewHandlerWithRepos(db *sql.DB, userRepo domain.UserRepo, calendarRepo *data.SQLiteCalendarRepo, eventRepo *data.SQLiteEventRepo) http.Handler {
-	return NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, data.NewSQLiteCalDAVOperationRepo(db))
+	return NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, data.NewSQLiteCalDAVOperationRepo(db), services.NewLoginThrottle(services.DefaultLoginThrottleConfig()), nil, nil, nil)
 }
 
 // NewHandlerWithReposAndOperationRecorder creates the real CalDAV handler with optional redacted operation recording.
-func NewHandlerWithReposAndOperationRecorder(db *sql.DB, userRepo domain.UserRepo, calendarRepo *data.SQLiteCalendarRepo, eventRepo *data.SQLiteEventRepo, operationRepo domain.CalDAVOperationRepo) http.Handler {
+// Pass the server's shared throttle so every Basic Auth endpoint counts failures together; nil disables lockouts.
+// Scheduling messages for attendees outside this server go to delivery; nil only logs them.
+// Stored calendar and event changes are published to publisher; nil publishes nothing.
+// Their webhook deliveries are queued through webhooks in the same transaction; nil queues none.
+func NewHandlerWithReposAndOperationRecorder(db *sql.DB, userRepo domain.UserRepo, calendarRepo *data.SQLiteCalendarRepo, eventRepo *data.SQLiteEventRepo, operationRepo domain.CalDAVOperationRepo, throttle *services.LoginThrottle, delivery domain.ScheduleDelivery, publisher domain.NotificationPublisher, webhooks *services.WebhookService) http.Handler {
 	authConfig := LoadAuthConfig()
 
 	backend := NewBackend(db, userRepo, calendarRepo, eventRepo)
+	backend.delivery = delivery
+	backend.publisher = publisher
+	backend.webhooks = webhooks
+	freeBusy := services.NewFreeBusyService(calendarRepo, eventRepo)
 
 	// Create go-webdav CalDAV handler
 	caldavHandler := &caldav.Handler{
@@ -68,12 +79,33 @@ func NewHandlerWithReposAndOperationRecorder(db *sql.DB, userRepo domain.UserRep
 	// Record redacted CalDAV operation metadata before auth so auth failures are visible.
 	r.Use(OperationMetadataMiddleware(operationRepo))
 
-	// Apply Basic Auth middleware
-	r.Use(BasicAuthMiddleware(authConfig, userRepo))
+	// Apply Basic Auth middleware (account or app passwords) with failed sign-in lockouts
+	r.Use(BasicAuthMiddleware(authConfig, userRepo, data.NewSQLiteAppPasswordRepo(db), throttle))
 
-	// PROPPATCH interception middleware (Apple Calendar compatibility)
+	// Principals and calendar homes resolve per authenticated user
+	r.Use(UserPathMiddleware)
+
+	// Conditional headers of DELETE, which go-webdav does not pass to the backend
+	r.Use(PreconditionMiddleware)
+
+	// Schedule inbox and outbox (RFC 6638), which go-webdav does not know about
+	scheduleHandler := NewScheduleCollectionHandler(backend, freeBusy)
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if collection, _ := scheduleCollectionOf(req.URL.Path); collection != "" {
+				scheduleHandler.ServeHTTP(w, req)
+				return
+			}
+			if req.Method == http.MethodOptions {
+				w.Header().Add("DAV", "calendar-auto-schedule")
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	// PROPPATCH interception middleware (go-webdav cannot persist properties)
 	// Must be before caldavHandler since r.Handle("/*") would catch all methods
-	proppatchHandler := NewPropPatchHandler()
+	proppatchHandler := NewPropPatchHandler(backend)
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			if req.Method == "PROPPATCH" {
@@ -84,6 +116,42 @@ func NewHandlerWithReposAndOperationRecorder(db *sql.DB, userRepo domain.UserRep
 		})
 	})
 
+	// MKCALENDAR and extended MKCOL with initial properties (go-webdav has neither)
+	mkcalendarHandler := NewMkCalendarHandler(backend)
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == "MKCALENDAR" || req.Method == "MKCOL" {
+				mkcalendarHandler.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	// MOVE and COPY between calendars (go-webdav's CalDAV backend answers 501)
+	moveCopyHandler := NewMoveCopyHandler(backend)
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == "MOVE" || req.Method == "COPY" {
+				moveCopyHandler.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	// REPORT types go-webdav does not implement are dispatched to our own handlers
+	reports := map[xml.Name]http.Handler{
+		syncCollectionName:   NewSyncCollectionHandler(backend),
+		calendarQueryName:    NewCalendarQueryHandler(backend),
+		calendarMultigetName: NewCalendarMultigetHandler(backend),
+		freeBusyQueryName:    NewFreeBusyQueryHandler(freeBusy),
+	}
+	r.Use(ReportDispatchMiddleware(reports))
+
+	// Fill in collection properties go-webdav cannot serve (sync-token, getctag)
+	r.Use(PropFindExtensionMiddleware(backend))
+
 	// Content-Type hardening middleware for .ics GET responses
 	// Ensures proper Content-Type for CalDAV clients sensitive to header values
 	r.Use(ICSContentTypeMiddleware)
@@ -95,9 +163,33 @@ func NewHandlerWithReposAndOperationRecorder(db *sql.DB, userRepo domain.UserRep
 }
 
 // NewWellKnownRoutes returns a handler for /.well-known/caldav
-// The username parameter determines the principal redirect target
-func NewWellKnownRoutes(username string) http.Handler {
-	return NewWellKnownHandler("/dav/principals/" + username + "/")
+// It redirects to the DAV root, whose current-user-principal resolves to the
+// principal of whoever authenticates (RFC 6764 §6).
+func NewWellKnownRoutes() http.Handler {
+	return NewWellKnownHandler("/dav/")
+}
+
+// ReportDispatchMiddleware routes REPORT requests by the root element of their
+// body. Reports without a registered handler fall through to go-webdav.
+func ReportDispatchMiddleware(reports map[xml.Name]http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "REPORT" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := readXMLBody(r)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if handler, ok := reports[xmlRootName(body)]; ok {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // ICSContentTypeMiddleware ensures GET requests for .ics files return proper Content-Type.
@@ -13,6 +13,7 @@ import (
 
 	"github.com/airplne/calendar-app/server/internal/data"
 	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
 )
 
 // caldavBase is the mount point for CalDAV endpoints (matches main.go)
@@ -176,6 +177,38 @@ END:VCALENDAR`
 	}
 }
 
+func TestCalDAV_PUT_StoresStatusAndAllDay(t *testing.T) {
+	srv, _, _, eventRepo := setupTestServer(t)
+	put := func(status string) {
+		t.Helper()
+		icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:holiday\r\nDTSTAMP:20260116T080000Z\r\n" +
+			"SUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20260116\r\nDTEND;VALUE=DATE:20260117\r\n" + status + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+		req, _ := http.NewRequest("PUT", srv.URL+caldavBase+"/calendars/testuser/default/holiday.ics", strings.NewReader(icsData))
+		req.SetBasicAuth("testuser", "testpass")
+		req.Header.Set("Content-Type", "text/calendar")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("PUT status = %d", resp.StatusCode)
+		}
+	}
+
+	// The columns follow the master VEVENT on create and on update
+	put("")
+	event, err := eventRepo.GetByUID(context.Background(), 1, "holiday")
+	if err != nil || !event.AllDay || event.Status != "CONFIRMED" {
+		t.Fatalf("created event = %+v, %v; want all-day and CONFIRMED", event, err)
+	}
+	put("STATUS:cancelled\r\n")
+	event, err = eventRepo.GetByUID(context.Background(), 1, "holiday")
+	if err != nil || !event.AllDay || event.Status != "CANCELLED" {
+		t.Fatalf("updated event = %+v, %v; want all-day and CANCELLED", event, err)
+	}
+}
+
 func TestCalDAV_GET_RetrieveEvent(t *testing.T) {
 	srv, _, calRepo, eventRepo := setupTestServer(t)
 	ctx := context.Background()
@@ -325,6 +358,56 @@ func TestCalDAV_DELETE_Event(t *testing.T) {
 	}
 }
 
+func TestCalDAV_DELETE_Preconditions(t *testing.T) {
+	srv, _, calRepo, eventRepo := setupTestServer(t)
+	ctx := context.Background()
+
+	cal, err := calRepo.GetByName(ctx, 1, "default")
+	if err != nil {
+		t.Fatalf("Failed to get calendar: %v", err)
+	}
+	putTestEvent(t, srv.URL, "guarded")
+	event, err := eventRepo.GetByUID(ctx, cal.ID, "guarded")
+	if err != nil {
+		t.Fatalf("Failed to get event: %v", err)
+	}
+
+	del := func(header, value string) int {
+		req, _ := http.NewRequest("DELETE", srv.URL+caldavBase+"/calendars/testuser/default/guarded.ics", nil)
+		req.SetBasicAuth("testuser", "testpass")
+		req.Header.Set(header, value)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A stale ETag or Schedule-Tag must not delete an event another device edited
+	if status := del("If-Match", `"stale"`); status != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with stale If-Match: expected 412, got %d", status)
+	}
+	if status := del("If-Schedule-Tag-Match", `"stale"`); status != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with stale If-Schedule-Tag-Match: expected 412, got %d", status)
+	}
+	if _, err := eventRepo.GetByUID(ctx, cal.ID, "guarded"); err != nil {
+		t.Fatal("Event should survive a failed conditional DELETE")
+	}
+
+	if status := del("If-Match", event.ETag); status != http.StatusNoContent {
+		t.Errorf("DELETE with current If-Match: expected 204, got %d", status)
+	}
+	if _, err := eventRepo.GetByUID(ctx, cal.ID, "guarded"); err != domain.ErrNotFound {
+		t.Error("Event should be deleted")
+	}
+
+	// If-Match never matches a resource that is gone
+	if status := del("If-Match", "*"); status != http.StatusPreconditionFailed {
+		t.Errorf("DELETE of missing event with If-Match: expected 412, got %d", status)
+	}
+}
+
 func TestCalDAV_PROPPATCH_AppleCompatibility(t *testing.T) {
 	srv, _, _, _ := setupTestServer(t)
 
@@ -528,3 +611,187 @@ func TestCalDAV_GET_CorruptStoredICS_Returns500(t *testing.T) {
 
 	t.Logf("Regression test passed: corrupt ICS correctly returns HTTP 500 instead of empty calendar")
 }
+
+func TestCalDAV_MultiUser_PerUserCredentialsAndHomes(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+
+	users := services.NewUserService(userRepo, calRepo)
+	if _, err := users.CreateUser(ctx, "alice", "alice-password", false); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	propfind := func(path, username, password string) (int, string) {
+		req, _ := http.NewRequest("PROPFIND", srv.URL+path, strings.NewReader(`<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:current-user-principal/></d:prop></d:propfind>`))
+		req.SetBasicAuth(username, password)
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		req.Header.Set("Depth", "0")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PROPFIND failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := propfind(caldavBase+"/", "alice", "alice-password")
+	if status != http.StatusMultiStatus || !strings.Contains(body, "/dav/principals/alice/") {
+		t.Fatalf("alice's principal: expected 207 with /dav/principals/alice/, got %d: %s", status, body)
+	}
+	if status, _ := propfind(caldavBase+"/calendars/alice/default/", "alice", "alice-password"); status != http.StatusMultiStatus {
+		t.Errorf("alice's own calendar: expected 207, got %d", status)
+	}
+	if status, _ := propfind(caldavBase+"/calendars/testuser/default/", "alice", "alice-password"); status != http.StatusForbidden {
+		t.Errorf("another user's calendar home: expected 403, got %d", status)
+	}
+	if status, _ := propfind(caldavBase+"/", "alice", "testpass"); status != http.StatusUnauthorized {
+		t.Errorf("wrong password: expected 401, got %d", status)
+	}
+
+	if _, err := users.SetDisabled(ctx, "alice", true); err != nil {
+		t.Fatalf("SetDisabled failed: %v", err)
+	}
+	if status, _ := propfind(caldavBase+"/", "alice", "alice-password"); status != http.StatusUnauthorized {
+		t.Errorf("disabled user: expected 401, got %d", status)
+	}
+}
+
+func TestCalDAV_AppPasswords_PerDeviceRevocation(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	appPasswordRepo := data.NewSQLiteAppPasswordRepo(db)
+	alice, err := services.NewUserService(userRepo, calendarRepo).CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	devices := services.NewAppPasswordService(appPasswordRepo, userRepo)
+	phone, phoneSecret, err := devices.Create(ctx, alice, "iPhone")
+	if err != nil {
+		t.Fatalf("Create app password failed: %v", err)
+	}
+	_, laptopSecret, _ := devices.Create(ctx, alice, "Thunderbird laptop")
+
+	var operations []domain.CalDAVOperation
+	recorder := operationRecorderFunc(func(op *domain.CalDAVOperation) error {
+		operations = append(operations, *op)
+		return nil
+	})
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, data.NewSQLiteEventRepo(db), recorder, nil, nil, nil, nil))
+	defer srv.Close()
+
+	propfind := func(password, userAgent string) int {
+		req, _ := http.NewRequest("PROPFIND", srv.URL+caldavBase+"/calendars/alice/", strings.NewReader(`<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`))
+		req.SetBasicAuth("alice", password)
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		req.Header.Set("Depth", "0")
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PROPFIND failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := propfind(phoneSecret, "iOS/17.0 (21A329) dataaccessd/1.0"); status != http.StatusMultiStatus {
+		t.Fatalf("app password: expected 207, got %d", status)
+	}
+	if got := operations[len(operations)-1].Credential; got != domain.AppPasswordCredential(phone.ID) {
+		t.Errorf("recorded credential = %q, want %q", got, domain.AppPasswordCredential(phone.ID))
+	}
+	stored, _ := appPasswordRepo.ListByUser(ctx, alice.ID)
+	for _, p := range stored {
+		if p.ID == phone.ID && (p.LastUsedAt == nil || p.LastClientFingerprint != domain.CalDAVClientAppleCalendar) {
+			t.Errorf("phone use not recorded: %+v", p)
+		}
+	}
+
+	// A lost phone is cut off; the laptop and the account password keep working
+	if err := devices.Revoke(ctx, alice, phone.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if status := propfind(phoneSecret, "iOS/17.0 (21A329) dataaccessd/1.0"); status != http.StatusUnauthorized {
+		t.Errorf("revoked app password: expected 401, got %d", status)
+	}
+	if status := propfind(laptopSecret, "Mozilla/5.0 Thunderbird/115.0"); status != http.StatusMultiStatus {
+		t.Errorf("other app password: expected 207, got %d", status)
+	}
+	if status := propfind("alice-password", "Mozilla/5.0 Thunderbird/115.0"); status != http.StatusMultiStatus {
+		t.Errorf("account password: expected 207, got %d", status)
+	}
+	if got := operations[len(operations)-1].Credential; got != domain.CredentialAccountPassword {
+		t.Errorf("recorded credential = %q, want %q", got, domain.CredentialAccountPassword)
+	}
+}
+
+func TestCalDAV_BasicAuthLockout(t *testing.T) {
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	if _, err := services.NewUserService(userRepo, calendarRepo).CreateUser(context.Background(), "alice", "alice-password", false); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	var operations []*domain.CalDAVOperation
+	recorder := operationRecorderFunc(func(op *domain.CalDAVOperation) error {
+		copied := *op
+		operations = append(operations, &copied)
+		return nil
+	})
+	throttle := services.NewLoginThrottle(services.LoginThrottleConfig{UserFreeAttempts: 2, BaseLockout: time.Minute})
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, data.NewSQLiteEventRepo(db), recorder, throttle, nil, nil, nil))
+	defer srv.Close()
+
+	propfind := func(password string) *http.Response {
+		req, _ := http.NewRequest("PROPFIND", srv.URL+caldavBase+"/calendars/alice/", nil)
+		req.SetBasicAuth("alice", password)
+		req.Header.Set("Depth", "0")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PROPFIND failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp := propfind("guess"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("failure %d: expected 401, got %d", i+1, resp.StatusCode)
+		}
+	}
+	resp := propfind("guess")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "60" {
+		t.Fatalf("third failure: expected 429 with Retry-After 60, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	// The right password does not get through a lockout
+	if resp := propfind("alice-password"); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("locked out: expected 429 with Retry-After, got %d", resp.StatusCode)
+	}
+
+	last := operations[len(operations)-1]
+	if last.ErrorCode != domain.CalDAVErrorAuthLockedOut {
+		t.Errorf("recorded error code = %q, want %q", last.ErrorCode, domain.CalDAVErrorAuthLockedOut)
+	}
+	if summary := domain.SummarizeCalDAVOperations(operations); summary.AuthLockouts != 2 {
+		t.Errorf("AuthLockouts = %d, want 2", summary.AuthLockouts)
+	}
+}
@@ -0,0 +1,443 @@
+package caldav
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// iTIP (RFC 5546) message construction for implicit scheduling (RFC 6638
+// §3.2). These functions only look at calendar data; routing the messages to
+// inboxes and calendars is done by the Backend in scheduling.go.
+
+const (
+	paramScheduleAgent  = "SCHEDULE-AGENT"
+	paramScheduleStatus = "SCHEDULE-STATUS"
+)
+
+// schedulingRole is the part the owner of a calendar object plays in the
+// meeting it describes.
+type schedulingRole int
+
+const (
+	roleNone      schedulingRole = iota // Not a scheduling object, or not one of the owner's meetings
+	roleOrganizer                       // The owner organizes the meeting
+	roleAttendee                        // The owner is invited to the meeting
+)
+
+// itipMessage is one scheduling message before it is routed to local inboxes
+// or the outbound delivery.
+type itipMessage struct {
+	Method     string
+	UID        string
+	Originator string   // Calendar user address of the sender
+	Recipients []string // Calendar user addresses
+	Calendar   *ical.Calendar
+}
+
+// schedulingRoleOf returns the role of the calendar user with the given
+// addresses in an event, and the address they appear with. Events without
+// both an ORGANIZER and an ATTENDEE are not scheduling objects.
+func schedulingRoleOf(cal *ical.Calendar, addresses []string) (schedulingRole, string) {
+	organizer := eventOrganizer(cal)
+	if organizer == nil || len(eventAttendees(cal)) == 0 {
+		return roleNone, ""
+	}
+	if addressIn(organizer.Value, addresses) {
+		return roleOrganizer, organizer.Value
+	}
+	for _, attendee := range eventAttendees(cal) {
+		if addressIn(attendee.Value, addresses) {
+			return roleAttendee, attendee.Value
+		}
+	}
+	return roleNone, ""
+}
+
+// organizerMessages returns the messages the organizer's server sends when the
+// organizer stores a new version of a meeting: REQUEST to every attendee when
+// something attendees care about changed, or CANCEL when the meeting is
+// cancelled, and CANCEL to attendees who were removed. old is nil for new
+// meetings.
+func organizerMessages(organizer string, old, updated *ical.Calendar) []itipMessage {
+	uid := extractUIDFromICalendar(updated)
+	current := serverScheduledAttendees(updated, organizer)
+
+	var messages []itipMessage
+	if old == nil || schedulingFingerprint(old) != schedulingFingerprint(updated) {
+		if len(current) > 0 {
+			method := domain.ITIPRequest
+			if eventCancelled(updated) {
+				method = domain.ITIPCancel
+			}
+			messages = append(messages, itipMessage{
+				Method:     method,
+				UID:        uid,
+				Originator: organizer,
+				Recipients: current,
+				Calendar:   itipCalendar(method, updated, nil),
+			})
+		}
+	}
+
+	if old != nil {
+		var removed []string
+		for _, address := range serverScheduledAttendees(old, organizer) {
+			if !addressIn(address, current) {
+				removed = append(removed, address)
+			}
+		}
+		if len(removed) > 0 {
+			messages = append(messages, itipMessage{
+				Method:     domain.ITIPCancel,
+				UID:        uid,
+				Originator: organizer,
+				Recipients: removed,
+				Calendar:   itipCalendar(domain.ITIPCancel, old, nil),
+			})
+		}
+	}
+	return messages
+}
+
+// cancelMessage returns the CANCEL the organizer's server sends to every
+// attendee when the organizer deletes a meeting, or nil when nobody is left to
+// notify.
+func cancelMessage(organizer string, cal *ical.Calendar) *itipMessage {
+	recipients := serverScheduledAttendees(cal, organizer)
+	if len(recipients) == 0 {
+		return nil
+	}
+	return &itipMessage{
+		Method:     domain.ITIPCancel,
+		UID:        extractUIDFromICalendar(cal),
+		Originator: organizer,
+		Recipients: recipients,
+		Calendar:   itipCalendar(domain.ITIPCancel, cal, nil),
+	}
+}
+
+// replyMessage returns the REPLY an attendee's server sends to the organizer
+// when the attendee's participation status changed in any instance, or nil.
+// old is nil when the attendee stores their copy for the first time; a reply
+// is only sent then if they already answered the invitation.
+func replyMessage(attendee string, old, updated *ical.Calendar) *itipMessage {
+	organizer := eventOrganizer(updated)
+	if organizer == nil || !serverScheduled(organizer) {
+		return nil
+	}
+
+	var changed []*ical.Component
+	for _, comp := range updated.Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		prop := findAttendee(comp, attendee)
+		if prop == nil {
+			continue
+		}
+		partstat := participationStatus(prop)
+		previous := domain.PartStatNeedsAction
+		if old != nil {
+			if oldComp := matchingInstance(old, comp); oldComp != nil {
+				if oldProp := findAttendee(oldComp, attendee); oldProp != nil {
+					previous = participationStatus(oldProp)
+				}
+			}
+		}
+		if partstat != previous {
+			changed = append(changed, replyComponent(comp, organizer, prop))
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return &itipMessage{
+		Method:     domain.ITIPReply,
+		UID:        extractUIDFromICalendar(updated),
+		Originator: attendee,
+		Recipients: []string{organizer.Value},
+		Calendar:   itipCalendar(domain.ITIPReply, updated, changed),
+	}
+}
+
+// declineMessage returns the REPLY declining every instance that an
+// attendee's server sends when the attendee deletes their copy, or nil.
+func declineMessage(attendee string, cal *ical.Calendar) *itipMessage {
+	organizer := eventOrganizer(cal)
+	if organizer == nil || !serverScheduled(organizer) {
+		return nil
+	}
+	comp := masterComponent(cal, ical.CompEvent)
+	prop := findAttendee(comp, attendee)
+	if prop == nil {
+		return nil
+	}
+	declined := copyProp(prop)
+	declined.Params.Set(ical.ParamParticipationStatus, domain.PartStatDeclined)
+	declined.Params.Del(ical.ParamRSVP)
+	return &itipMessage{
+		Method:     domain.ITIPReply,
+		UID:        extractUIDFromICalendar(cal),
+		Originator: attendee,
+		Recipients: []string{organizer.Value},
+		Calendar:   itipCalendar(domain.ITIPReply, cal, []*ical.Component{replyComponent(comp, organizer, declined)}),
+	}
+}
+
+// applyReply copies the participation status an attendee sent in a REPLY into
+// the organizer's copy of the meeting. It reports whether anything changed.
+func applyReply(organizerCopy, reply *ical.Calendar, attendee string) bool {
+	changed := false
+	for _, replyComp := range reply.Children {
+		if replyComp.Name != ical.CompEvent {
+			continue
+		}
+		replyProp := findAttendee(replyComp, attendee)
+		if replyProp == nil {
+			continue
+		}
+		comp := matchingInstance(organizerCopy, replyComp)
+		if comp == nil {
+			continue
+		}
+		prop := findAttendee(comp, attendee)
+		if prop == nil || participationStatus(prop) == participationStatus(replyProp) {
+			continue
+		}
+		prop.Params.Set(ical.ParamParticipationStatus, participationStatus(replyProp))
+		prop.Params.Del(ical.ParamRSVP)
+		changed = true
+	}
+	return changed
+}
+
+// itipCalendar builds the VCALENDAR of a message: the given components, or
+// every component of cal without its alarms when comps is nil, with METHOD
+// set. CANCEL messages mark their events cancelled.
+func itipCalendar(method string, cal *ical.Calendar, comps []*ical.Component) *ical.Calendar {
+	msg := ical.NewCalendar()
+	msg.Props.SetText(ical.PropVersion, "2.0")
+	msg.Props.SetText(ical.PropProductID, freeBusyProdID)
+	msg.Props.SetText(ical.PropMethod, method)
+
+	if comps == nil {
+		for _, comp := range cal.Children {
+			copied := copyComponent(comp)
+			// Alarms are personal to the organizer
+			children := copied.Children[:0]
+			for _, child := range copied.Children {
+				if child.Name != ical.CompAlarm {
+					children = append(children, child)
+				}
+			}
+			copied.Children = children
+			comps = append(comps, copied)
+		}
+	}
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompTimezone {
+			msg.Children = append(msg.Children, copyComponent(comp))
+		}
+	}
+	for _, comp := range comps {
+		if comp.Name == ical.CompTimezone {
+			continue
+		}
+		if method == domain.ITIPCancel && comp.Name == ical.CompEvent {
+			comp.Props.SetText(ical.PropStatus, "CANCELLED")
+		}
+		msg.Children = append(msg.Children, comp)
+	}
+	return msg
+}
+
+// replyComponent is the VEVENT of a REPLY for one instance: enough for the
+// organizer to match it (RFC 5546 §3.2.3) and the attendee's answer.
+func replyComponent(comp *ical.Component, organizer, attendee *ical.Prop) *ical.Component {
+	reply := ical.NewComponent(ical.CompEvent)
+	for _, name := range []string{ical.PropUID, ical.PropRecurrenceID, ical.PropSequence, ical.PropDateTimeStart, ical.PropDateTimeEnd, ical.PropDuration, ical.PropSummary} {
+		if prop := comp.Props.Get(name); prop != nil {
+			reply.Props.Set(copyProp(prop))
+		}
+	}
+	reply.Props.SetDateTime(ical.PropDateTimeStamp, time.Now().UTC())
+	reply.Props.Set(copyProp(organizer))
+	answer := copyProp(attendee)
+	answer.Params.Del(paramScheduleStatus)
+	reply.Props.Set(answer)
+	return reply
+}
+
+// schedulingFingerprint summarizes the parts of an event that attendees need
+// to hear about. Timestamps, alarms and participation statuses are left out,
+// so attendees are not re-invited when only those change.
+func schedulingFingerprint(cal *ical.Calendar) string {
+	var parts []string
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		for name, props := range comp.Props {
+			switch name {
+			case ical.PropDateTimeStamp, ical.PropLastModified, ical.PropCreated:
+				continue
+			}
+			for _, prop := range props {
+				params := make([]string, 0, len(prop.Params))
+				for param, values := range prop.Params {
+					switch param {
+					case ical.ParamParticipationStatus, ical.ParamRSVP, paramScheduleStatus:
+						continue
+					}
+					params = append(params, param+"="+strings.Join(values, ","))
+				}
+				sort.Strings(params)
+				parts = append(parts, name+";"+strings.Join(params, ";")+":"+prop.Value)
+			}
+		}
+		parts = append(parts, "--")
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n")
+}
+
+// eventOrganizer returns the ORGANIZER of the master event, or nil.
+func eventOrganizer(cal *ical.Calendar) *ical.Prop {
+	comp := masterComponent(cal, ical.CompEvent)
+	if comp == nil {
+		return nil
+	}
+	return comp.Props.Get(ical.PropOrganizer)
+}
+
+// eventAttendees returns the ATTENDEEs of every instance, one per address.
+func eventAttendees(cal *ical.Calendar) []*ical.Prop {
+	var attendees []*ical.Prop
+	var seen []string
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		props := comp.Props.Values(ical.PropAttendee)
+		for i := range props {
+			if !addressIn(props[i].Value, seen) {
+				seen = append(seen, props[i].Value)
+				attendees = append(attendees, &props[i])
+			}
+		}
+	}
+	return attendees
+}
+
+// serverScheduledAttendees returns the addresses of the attendees the server
+// schedules for: everyone but the organizer and attendees whose client
+// handles scheduling itself (SCHEDULE-AGENT, RFC 6638 §7.1).
+func serverScheduledAttendees(cal *ical.Calendar, organizer string) []string {
+	var addresses []string
+	for _, attendee := range eventAttendees(cal) {
+		if serverScheduled(attendee) && !domain.SameCalendarAddress(attendee.Value, organizer) {
+			addresses = append(addresses, attendee.Value)
+		}
+	}
+	return addresses
+}
+
+func serverScheduled(prop *ical.Prop) bool {
+	agent := prop.Params.Get(paramScheduleAgent)
+	return agent == "" || strings.EqualFold(agent, "SERVER")
+}
+
+func eventCancelled(cal *ical.Calendar) bool {
+	comp := masterComponent(cal, ical.CompEvent)
+	if comp == nil {
+		return false
+	}
+	prop := comp.Props.Get(ical.PropStatus)
+	return prop != nil && strings.EqualFold(prop.Value, "CANCELLED")
+}
+
+func participationStatus(prop *ical.Prop) string {
+	if partstat := prop.Params.Get(ical.ParamParticipationStatus); partstat != "" {
+		return strings.ToUpper(partstat)
+	}
+	return domain.PartStatNeedsAction
+}
+
+// findAttendee returns the ATTENDEE of comp with the given address, or nil.
+// The returned property may be modified in place.
+func findAttendee(comp *ical.Component, address string) *ical.Prop {
+	if comp == nil {
+		return nil
+	}
+	props := comp.Props[ical.PropAttendee]
+	for i := range props {
+		if domain.SameCalendarAddress(props[i].Value, address) {
+			return &props[i]
+		}
+	}
+	return nil
+}
+
+// matchingInstance returns the VEVENT of cal with the same RECURRENCE-ID as
+// comp (the master when comp has none), or nil.
+func matchingInstance(cal *ical.Calendar, comp *ical.Component) *ical.Component {
+	var want time.Time
+	if prop := comp.Props.Get(ical.PropRecurrenceID); prop != nil {
+		var err error
+		if want, err = parseICalTime(prop); err != nil {
+			return nil
+		}
+	}
+	for _, candidate := range cal.Children {
+		if candidate.Name != ical.CompEvent {
+			continue
+		}
+		prop := candidate.Props.Get(ical.PropRecurrenceID)
+		if prop == nil {
+			if want.IsZero() {
+				return candidate
+			}
+			continue
+		}
+		if got, err := parseICalTime(prop); err == nil && !want.IsZero() && got.Equal(want) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func addressIn(address string, addresses []string) bool {
+	for _, a := range addresses {
+		if domain.SameCalendarAddress(address, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyComponent(comp *ical.Component) *ical.Component {
+	copied := ical.NewComponent(comp.Name)
+	for _, props := range comp.Props {
+		for i := range props {
+			copied.Props.Add(copyProp(&props[i]))
+		}
+	}
+	for _, child := range comp.Children {
+		copied.Children = append(copied.Children, copyComponent(child))
+	}
+	return copied
+}
+
+func copyProp(prop *ical.Prop) *ical.Prop {
+	copied := ical.NewProp(prop.Name)
+	copied.Value = prop.Value
+	for name, values := range prop.Params {
+		copied.Params[name] = append([]string(nil), values...)
+	}
+	return copied
+}
@@ -0,0 +1,142 @@
+package caldav
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func parseTestCalendar(t *testing.T, ics string) *ical.Calendar {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(strings.ReplaceAll(ics, "\n", "\r\n"))).Decode()
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+	return cal
+}
+
+func meetingICS(summary, bobPartStat string, extraAttendees ...string) string {
+	ics := `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//Test//EN
+BEGIN:VEVENT
+UID:meeting-1
+DTSTAMP:20260116T080000Z
+DTSTART:20260120T100000Z
+DTEND:20260120T110000Z
+SUMMARY:` + summary + `
+ORGANIZER:mailto:alice@example.com
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:alice@example.com
+ATTENDEE;PARTSTAT=` + bobPartStat + `;RSVP=TRUE:mailto:bob@example.com
+`
+	for _, attendee := range extraAttendees {
+		ics += "ATTENDEE;PARTSTAT=NEEDS-ACTION:" + attendee + "\n"
+	}
+	return ics + `BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+DESCRIPTION:Reminder
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`
+}
+
+func TestSchedulingRoleOf(t *testing.T) {
+	cal := parseTestCalendar(t, meetingICS("Planning", "NEEDS-ACTION"))
+	tests := []struct {
+		addresses []string
+		role      schedulingRole
+	}{
+		{[]string{"MAILTO:Alice@Example.com"}, roleOrganizer},
+		{[]string{"/dav/principals/bob/", "mailto:bob@example.com"}, roleAttendee},
+		{[]string{"mailto:carol@example.com"}, roleNone},
+	}
+	for _, tt := range tests {
+		if role, _ := schedulingRoleOf(cal, tt.addresses); role != tt.role {
+			t.Errorf("schedulingRoleOf(%v) = %v, want %v", tt.addresses, role, tt.role)
+		}
+	}
+
+	personal := parseTestCalendar(t, strings.Replace(meetingICS("Planning", "NEEDS-ACTION"), "ORGANIZER:mailto:alice@example.com\n", "", 1))
+	if role, _ := schedulingRoleOf(personal, []string{"mailto:alice@example.com"}); role != roleNone {
+		t.Errorf("event without ORGANIZER: role = %v, want roleNone", role)
+	}
+}
+
+func TestOrganizerMessages(t *testing.T) {
+	organizer := "mailto:alice@example.com"
+	created := parseTestCalendar(t, meetingICS("Planning", "NEEDS-ACTION", "mailto:carol@example.com"))
+
+	messages := organizerMessages(organizer, nil, created)
+	if len(messages) != 1 || messages[0].Method != domain.ITIPRequest {
+		t.Fatalf("new meeting: expected one REQUEST, got %+v", messages)
+	}
+	if got := messages[0].Recipients; len(got) != 2 || got[0] != "mailto:bob@example.com" || got[1] != "mailto:carol@example.com" {
+		t.Errorf("REQUEST recipients = %v, want bob and carol (not the organizer)", got)
+	}
+	if len(messages[0].Calendar.Children[0].Children) != 0 {
+		t.Error("Expected alarms to be stripped from the REQUEST")
+	}
+
+	// Bob's answer being copied in, or a new DTSTAMP, does not reschedule the meeting.
+	answered := parseTestCalendar(t, strings.Replace(meetingICS("Planning", "ACCEPTED", "mailto:carol@example.com"), "DTSTAMP:20260116T080000Z", "DTSTAMP:20260117T080000Z", 1))
+	if messages := organizerMessages(organizer, created, answered); len(messages) != 0 {
+		t.Errorf("PARTSTAT-only change: expected no messages, got %+v", messages)
+	}
+
+	// Renaming the meeting and removing carol sends a REQUEST to bob and a CANCEL to carol.
+	renamed := parseTestCalendar(t, meetingICS("Roadmap", "ACCEPTED"))
+	messages = organizerMessages(organizer, created, renamed)
+	if len(messages) != 2 {
+		t.Fatalf("update: expected REQUEST and CANCEL, got %+v", messages)
+	}
+	if messages[0].Method != domain.ITIPRequest || len(messages[0].Recipients) != 1 {
+		t.Errorf("update: expected REQUEST to bob, got %+v", messages[0])
+	}
+	if messages[1].Method != domain.ITIPCancel || len(messages[1].Recipients) != 1 || messages[1].Recipients[0] != "mailto:carol@example.com" {
+		t.Errorf("update: expected CANCEL to carol, got %+v", messages[1])
+	}
+
+	// Attendees the client schedules itself are left alone.
+	clientScheduled := parseTestCalendar(t, strings.Replace(meetingICS("Planning", "NEEDS-ACTION"), "ATTENDEE;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:", "ATTENDEE;PARTSTAT=NEEDS-ACTION;SCHEDULE-AGENT=CLIENT:", 1))
+	if messages := organizerMessages(organizer, nil, clientScheduled); len(messages) != 0 {
+		t.Errorf("SCHEDULE-AGENT=CLIENT: expected no messages, got %+v", messages)
+	}
+}
+
+func TestReplyMessageAndApplyReply(t *testing.T) {
+	attendee := "mailto:bob@example.com"
+	invited := parseTestCalendar(t, meetingICS("Planning", "NEEDS-ACTION"))
+	if msg := replyMessage(attendee, nil, invited); msg != nil {
+		t.Errorf("unanswered invitation: expected no REPLY, got %+v", msg)
+	}
+
+	accepted := parseTestCalendar(t, meetingICS("Planning", "ACCEPTED"))
+	msg := replyMessage(attendee, invited, accepted)
+	if msg == nil || msg.Method != domain.ITIPReply || len(msg.Recipients) != 1 || msg.Recipients[0] != "mailto:alice@example.com" {
+		t.Fatalf("accepted: expected REPLY to alice, got %+v", msg)
+	}
+	if attendees := msg.Calendar.Children[0].Props.Values(ical.PropAttendee); len(attendees) != 1 || attendees[0].Value != attendee {
+		t.Errorf("REPLY should only carry the replying attendee, got %d attendees", len(attendees))
+	}
+
+	organizerCopy := parseTestCalendar(t, meetingICS("Planning", "NEEDS-ACTION"))
+	if !applyReply(organizerCopy, msg.Calendar, attendee) {
+		t.Fatal("applyReply reported no change")
+	}
+	if got := participationStatus(findAttendee(organizerCopy.Children[0], attendee)); got != domain.PartStatAccepted {
+		t.Errorf("organizer copy PARTSTAT = %s, want ACCEPTED", got)
+	}
+	if applyReply(organizerCopy, msg.Calendar, attendee) {
+		t.Error("applying the same REPLY twice should report no change")
+	}
+
+	decline := declineMessage(attendee, accepted)
+	if decline == nil || participationStatus(findAttendee(decline.Calendar.Children[0], attendee)) != domain.PartStatDeclined {
+		t.Errorf("declineMessage: expected a DECLINED REPLY, got %+v", decline)
+	}
+}
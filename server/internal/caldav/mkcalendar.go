@@ -0,0 +1,399 @@
+package caldav
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	mkcalendarResponseName            = xml.Name{Space: caldavNS, Local: "mkcalendar-response"}
+	mkcolResponseName                 = xml.Name{Space: davNS, Local: "mkcol-response"}
+	resourceTypeName                  = xml.Name{Space: davNS, Local: "resourcetype"}
+	supportedCalendarComponentSetName = xml.Name{Space: caldavNS, Local: "supported-calendar-component-set"}
+	calendarTimezoneName              = xml.Name{Space: caldavNS, Local: "calendar-timezone"}
+	calendarCollectionLocationOkName  = xml.Name{Space: caldavNS, Local: "calendar-collection-location-ok"}
+)
+
+// errCalendarLocation is returned when MKCALENDAR targets anything but a direct
+// child of the user's calendar home.
+var errCalendarLocation = errors.New("calendars can only be created in the calendar home")
+
+// mkcolRequest is the body of a CALDAV:mkcalendar (RFC 4791 §5.3.1) or an
+// extended DAV:mkcol (RFC 5689) request.
+type mkcolRequest struct {
+	XMLName xml.Name
+	Sets    []struct {
+		Prop davProp `xml:"DAV: prop"`
+	} `xml:"DAV: set"`
+}
+
+// mkcalendarProps picks the structured initial properties out of a request
+// body so their children are decoded with namespaces resolved (davProp keeps
+// them as raw inner XML).
+type mkcalendarProps struct {
+	Sets []struct {
+		Prop struct {
+			ResourceType *resourceTypeValue `xml:"DAV: resourcetype"`
+			ComponentSet *componentSetValue `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set"`
+		} `xml:"DAV: prop"`
+	} `xml:"DAV: set"`
+}
+
+// mkcalendarRequest is a decoded MKCALENDAR or extended MKCOL request.
+type mkcalendarRequest struct {
+	Extended     bool // MKCOL rather than MKCALENDAR
+	Changes      []propertyChange
+	ResourceType *resourceTypeValue
+	ComponentSet *componentSetValue
+}
+
+// resourceTypeValue is the content of a DAV:resourcetype property.
+type resourceTypeValue struct {
+	Collection *struct{} `xml:"DAV: collection"`
+	Calendar   *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+}
+
+// componentSetValue is the content of a CALDAV:supported-calendar-component-set property.
+type componentSetValue struct {
+	Comps []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"urn:ietf:params:xml:ns:caldav comp"`
+}
+
+// mkcolResponse reports per-property results of a failed MKCALENDAR or MKCOL.
+type mkcolResponse struct {
+	XMLName   xml.Name
+	PropStats []davPropStat `xml:"DAV: propstat"`
+}
+
+// MkCalendarHandler serves MKCALENDAR and MKCOL. go-webdav has no MKCALENDAR
+// and ignores everything in an extended MKCOL body but the display name, so
+// both are handled here with the initial properties applied like a PROPPATCH.
+type MkCalendarHandler struct {
+	backend *Backend
+}
+
+func NewMkCalendarHandler(backend *Backend) *MkCalendarHandler {
+	return &MkCalendarHandler{backend: backend}
+}
+
+func (h *MkCalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readXMLBody(r)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	mkreq := &mkcalendarRequest{Extended: r.Method == "MKCOL"}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		var req mkcolRequest
+		var typed mkcalendarProps
+		if err := xml.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Malformed "+r.Method+" request", http.StatusBadRequest)
+			return
+		}
+		if err := xml.Unmarshal(body, &typed); err != nil {
+			http.Error(w, "Malformed "+r.Method+" request", http.StatusBadRequest)
+			return
+		}
+		want := xml.Name{Space: caldavNS, Local: "mkcalendar"}
+		if mkreq.Extended {
+			want = xml.Name{Space: davNS, Local: "mkcol"}
+		}
+		if req.XMLName != want {
+			http.Error(w, "Unexpected "+r.Method+" request body", http.StatusUnsupportedMediaType)
+			return
+		}
+		for _, set := range req.Sets {
+			for _, value := range set.Prop.Values {
+				mkreq.Changes = append(mkreq.Changes, propertyChange{Value: value})
+			}
+		}
+		for _, set := range typed.Sets {
+			if set.Prop.ResourceType != nil {
+				mkreq.ResourceType = set.Prop.ResourceType
+			}
+			if set.Prop.ComponentSet != nil {
+				mkreq.ComponentSet = set.Prop.ComponentSet
+			}
+		}
+	}
+
+	failed, err := h.backend.MakeCalendar(r.Context(), r.URL.Path, mkreq)
+	switch {
+	case errors.Is(err, errCalendarLocation):
+		writeDAVError(w, http.StatusForbidden, calendarCollectionLocationOkName)
+		return
+	case errors.Is(err, domain.ErrConflict):
+		// RFC 4791 §5.3.1: MKCALENDAR on an existing resource
+		http.Error(w, "Calendar already exists", http.StatusMethodNotAllowed)
+		return
+	case err != nil:
+		writeBackendError(w, err)
+		return
+	}
+	if failed != nil {
+		resp := mkcolResponse{XMLName: mkcalendarResponseName, PropStats: failed.PropStats}
+		if mkreq.Extended {
+			resp.XMLName = mkcolResponseName
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, xml.Header)
+		if err := xml.NewEncoder(w).Encode(resp); err != nil {
+			slog.Error("Failed to encode MKCALENDAR response", "error", err)
+		}
+		return
+	}
+
+	w.Header().Set("Location", ensureTrailingSlash(r.URL.Path))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// MakeCalendar creates the calendar collection at urlPath with the given
+// initial properties. When a property cannot be set nothing is created and the
+// per-property results are returned instead (403/409 for the culprits, 424 for
+// the rest).
+func (b *Backend) MakeCalendar(ctx context.Context, urlPath string, req *mkcalendarRequest) (*davResponse, error) {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return nil, errNoAuthenticatedUser
+	}
+
+	calName, ok := newCalendarName(urlPath, user.Username)
+	if !ok {
+		return nil, errCalendarLocation
+	}
+	if _, err := b.calendarRepo.GetByName(ctx, user.ID, calName); err == nil {
+		return nil, domain.ErrConflict
+	} else if err != domain.ErrNotFound {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+
+	cal := &domain.Calendar{UserID: user.ID, Name: calName, DisplayName: calName}
+	changes := req.Changes
+	statuses := make([]int, len(changes))
+	var deadProps []propertyChange
+	failed := false
+	for i, change := range changes {
+		statuses[i] = http.StatusOK
+		switch change.Value.XMLName {
+		case resourceTypeName:
+			// Extended MKCOL names the resource type; only calendars can be created.
+			rt := req.ResourceType
+			if !req.Extended || rt == nil || rt.Collection == nil || rt.Calendar == nil {
+				statuses[i] = http.StatusForbidden
+			}
+		case supportedCalendarComponentSetName:
+			set := req.ComponentSet
+			if set == nil || len(set.Comps) == 0 {
+				statuses[i] = http.StatusForbidden
+				break
+			}
+			cal.ComponentSet = nil
+			for _, comp := range set.Comps {
+				cal.ComponentSet = append(cal.ComponentSet, strings.ToUpper(comp.Name))
+			}
+			if err := cal.Validate(); err != nil {
+				statuses[i] = http.StatusForbidden
+			}
+		default:
+			statuses[i] = validatePropertyChange(change, true)
+			if statuses[i] == http.StatusOK && !applyCalendarProperty(cal, change) {
+				deadProps = append(deadProps, change)
+			}
+		}
+		if statuses[i] != http.StatusOK {
+			failed = true
+		}
+	}
+
+	if failed {
+		resp := &davResponse{Hrefs: []string{urlPath}}
+		for i, change := range changes {
+			code := statuses[i]
+			if code == http.StatusOK {
+				code = http.StatusFailedDependency
+			}
+			resp.addProp(code, davEmptyValue(change.Value.XMLName))
+		}
+		return resp, nil
+	}
+
+	err := b.createCalendar(ctx, cal, func(tx *sql.Tx) error {
+		deadPropertyRepoTx := b.deadPropertyRepo.WithTx(tx)
+		for _, change := range deadProps {
+			name := change.Value.XMLName
+			if err := deadPropertyRepoTx.Set(ctx, &domain.DeadProperty{
+				UserID:    user.ID,
+				Path:      davResourcePath(urlPath),
+				Namespace: name.Space,
+				Name:      name.Local,
+				Value:     string(change.Value.Inner),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("caldav.calendar.created", "username", user.Username, "calendar", calName, "components", strings.Join(cal.Components(), ","))
+	return nil, nil
+}
+
+// createCalendar inserts cal and gives it a sync token newer than any token a
+// deleted calendar of the same name may have issued. extra runs in the same
+// transaction. Returns domain.ErrConflict if the name is taken.
+func (b *Backend) createCalendar(ctx context.Context, cal *domain.Calendar, extra func(tx *sql.Tx) error) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	calendarRepoTx := b.calendarRepo.WithTx(tx)
+	if err := calendarRepoTx.Create(ctx, cal); err != nil {
+		if err == domain.ErrConflict {
+			return err
+		}
+		return fmt.Errorf("failed to create calendar: %w", err)
+	}
+	if cal.SyncToken, err = calendarRepoTx.IncrementSyncToken(ctx, cal.ID); err != nil {
+		return fmt.Errorf("failed to increment sync token: %w", err)
+	}
+	if extra != nil {
+		if err := extra(tx); err != nil {
+			return err
+		}
+	}
+	var notes txNotifications
+	if err := b.notifyInTx(ctx, tx, &notes, &domain.Notification{Type: domain.NotificationCalendarCreated, Calendar: cal}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	b.publish(ctx, notes)
+	return nil
+}
+
+// deleteCalendar removes a calendar collection with everything in it and
+// leaves a tombstone. The default calendar cannot be deleted.
+func (b *Backend) deleteCalendar(ctx context.Context, user *domain.User, cal *domain.Calendar, urlPath string) error {
+	if cal.Name == domain.DefaultCalendarName {
+		return webdav.NewHTTPError(http.StatusForbidden, fmt.Errorf("the default calendar cannot be deleted"))
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	calendarRepoTx := b.calendarRepo.WithTx(tx)
+	if err := calendarRepoTx.RecordTombstone(ctx, cal); err != nil {
+		return err
+	}
+	if err := b.deadPropertyRepo.WithTx(tx).DeleteByPathPrefix(ctx, user.ID, davResourcePath(urlPath)); err != nil {
+		return err
+	}
+	// The users it is shared with are told before the shares go
+	var notes txNotifications
+	if err := b.notifyInTx(ctx, tx, &notes, &domain.Notification{Type: domain.NotificationCalendarDeleted, Calendar: cal}); err != nil {
+		return err
+	}
+	// Events, tasks and the change log go with the calendar (ON DELETE CASCADE)
+	if err := calendarRepoTx.Delete(ctx, cal.ID); err != nil {
+		return fmt.Errorf("failed to delete calendar: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Info("caldav.calendar.deleted", "username", user.Username, "calendar", cal.Name)
+	b.publish(ctx, notes)
+	return nil
+}
+
+// leaveSharedCalendar handles DELETE of a calendar shared with the user: the
+// share is removed from their calendar home and the owner's calendar is kept.
+func (b *Backend) leaveSharedCalendar(ctx context.Context, user *domain.User, cal *domain.Calendar, urlPath string) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	if err := b.deadPropertyRepo.WithTx(tx).DeleteByPathPrefix(ctx, user.ID, davResourcePath(urlPath)); err != nil {
+		return err
+	}
+	if err := b.shareRepo.WithTx(tx).Delete(ctx, cal.ID, user.ID); err != nil {
+		return err
+	}
+	var notes txNotifications
+	if err := b.queueInTx(ctx, tx, &notes, &domain.Notification{
+		Type:     domain.NotificationCalendarUnshared,
+		UserIDs:  []int64{cal.UserID, user.ID},
+		Calendar: cal,
+		Grantee:  user,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Info("caldav.calendar.share_left", "username", user.Username, "calendar_id", cal.ID)
+	b.publish(ctx, notes)
+	return nil
+}
+
+// newCalendarName returns the calendar name for a MKCALENDAR path, which must
+// be a direct child of the user's calendar home. Names with
+// domain.SharedCalendarSeparator are reserved for shared calendars.
+func newCalendarName(urlPath, username string) (string, bool) {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i, part := range parts {
+		if part == "calendars" {
+			rest := parts[i+1:]
+			if len(rest) != 2 || rest[0] != username || rest[1] == "" || strings.HasSuffix(rest[1], ".ics") || strings.Contains(rest[1], domain.SharedCalendarSeparator) {
+				return "", false
+			}
+			return rest[1], true
+		}
+	}
+	return "", false
+}
+
+// validCalendarTimezone reports whether text is an iCalendar object holding
+// exactly one VTIMEZONE, as CALDAV:calendar-timezone requires.
+func validCalendarTimezone(text string) bool {
+	cal, err := ical.NewDecoder(strings.NewReader(text)).Decode()
+	if err != nil {
+		return false
+	}
+	count := 0
+	for _, child := range cal.Children {
+		if child.Name == ical.CompTimezone {
+			count++
+		}
+	}
+	return count == 1
+}
@@ -0,0 +1,180 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testTimezone = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n" +
+	"BEGIN:STANDARD\r\nDTSTART:19701025T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\nEND:VCALENDAR\r\n"
+
+func TestCalDAV_MKCALENDAR_InitialProperties(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:mkcalendar xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+  <d:set><d:prop>
+    <d:displayname>Chores</d:displayname>
+    <c:supported-calendar-component-set><c:comp name="VTODO"/></c:supported-calendar-component-set>
+    <a:calendar-color>#44A703FF</a:calendar-color>
+    <c:calendar-timezone><![CDATA[` + testTimezone + `]]></c:calendar-timezone>
+  </d:prop></d:set>
+</c:mkcalendar>`
+
+	status, data := doXMLRequest(t, "MKCALENDAR", srv.URL+caldavBase+"/calendars/testuser/chores/", "", body)
+	if status != http.StatusCreated {
+		t.Fatalf("MKCALENDAR: expected 201, got %d. Body: %s", status, string(data))
+	}
+
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	cal, err := calRepo.GetByName(ctx, user.ID, "chores")
+	if err != nil {
+		t.Fatalf("Calendar not created: %v", err)
+	}
+	if cal.DisplayName != "Chores" || cal.Color != "#44A703FF" {
+		t.Errorf("Initial properties not applied: %+v", cal)
+	}
+	if !cal.SupportsComponent("VTODO") || cal.SupportsComponent("VEVENT") {
+		t.Errorf("Expected VTODO-only component set, got %v", cal.ComponentSet)
+	}
+	if !strings.Contains(cal.Timezone, "TZID:Europe/Berlin") {
+		t.Errorf("Timezone not stored: %q", cal.Timezone)
+	}
+	if cal.SyncToken == "" {
+		t.Error("New calendar should start with a sync token")
+	}
+
+	// Creating it again fails
+	status, _ = doXMLRequest(t, "MKCALENDAR", srv.URL+caldavBase+"/calendars/testuser/chores/", "", "")
+	if status != http.StatusMethodNotAllowed {
+		t.Errorf("MKCALENDAR on existing calendar: expected 405, got %d", status)
+	}
+}
+
+func TestCalDAV_ExtendedMKCOL(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:mkcol xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:set><d:prop>
+    <d:resourcetype><d:collection/><c:calendar/></d:resourcetype>
+    <d:displayname>Work</d:displayname>
+  </d:prop></d:set>
+</d:mkcol>`
+
+	status, data := doXMLRequest(t, "MKCOL", srv.URL+caldavBase+"/calendars/testuser/work/", "", body)
+	if status != http.StatusCreated {
+		t.Fatalf("MKCOL: expected 201, got %d. Body: %s", status, string(data))
+	}
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	cal, err := calRepo.GetByName(ctx, user.ID, "work")
+	if err != nil {
+		t.Fatalf("Calendar not created: %v", err)
+	}
+	if cal.DisplayName != "Work" {
+		t.Errorf("Expected display name Work, got %q", cal.DisplayName)
+	}
+
+	// Plain collections are not supported
+	plain := strings.Replace(body, "<c:calendar/>", "", 1)
+	status, data = doXMLRequest(t, "MKCOL", srv.URL+caldavBase+"/calendars/testuser/plain/", "", plain)
+	if status != http.StatusForbidden || !strings.Contains(string(data), "mkcol-response") {
+		t.Errorf("MKCOL of a plain collection: expected 403 mkcol-response, got %d: %s", status, string(data))
+	}
+}
+
+func TestCalDAV_MKCALENDAR_Rejected(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:mkcalendar xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:set><d:prop>
+    <d:displayname>Journal</d:displayname>
+    <c:supported-calendar-component-set><c:comp name="VJOURNAL"/></c:supported-calendar-component-set>
+  </d:prop></d:set>
+</c:mkcalendar>`
+	status, data := doXMLRequest(t, "MKCALENDAR", srv.URL+caldavBase+"/calendars/testuser/journal/", "", body)
+	if status != http.StatusForbidden {
+		t.Fatalf("Expected 403 for unsupported component, got %d", status)
+	}
+	if !strings.Contains(string(data), "424 Failed Dependency") || !strings.Contains(string(data), "mkcalendar-response") {
+		t.Errorf("Expected mkcalendar-response with 424 for the display name: %s", string(data))
+	}
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	if _, err := calRepo.GetByName(ctx, user.ID, "journal"); err == nil {
+		t.Error("Calendar must not be created when a property fails")
+	}
+
+	// Only direct children of the user's own calendar home
+	status, data = doXMLRequest(t, "MKCALENDAR", srv.URL+caldavBase+"/calendars/someoneelse/x/", "", "")
+	if status != http.StatusForbidden || !strings.Contains(string(data), "calendar-collection-location-ok") {
+		t.Errorf("Expected 403 calendar-collection-location-ok, got %d: %s", status, string(data))
+	}
+}
+
+func TestCalDAV_DeleteCalendar_Tombstone(t *testing.T) {
+	srv, userRepo, calRepo, eventRepo := setupTestServer(t)
+	ctx := context.Background()
+	calURL := srv.URL + caldavBase + "/calendars/testuser/trips/"
+
+	if status, data := doXMLRequest(t, "MKCALENDAR", calURL, "", ""); status != http.StatusCreated {
+		t.Fatalf("MKCALENDAR: expected 201, got %d. Body: %s", status, string(data))
+	}
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:trip-1\r\nDTSTAMP:20260116T080000Z\r\n" +
+		"SUMMARY:Trip\r\nDTSTART:20260116T090000Z\r\nDTEND:20260116T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	req, _ := http.NewRequest("PUT", calURL+"trip-1.ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	resp.Body.Close()
+
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	old, _ := calRepo.GetByName(ctx, user.ID, "trips")
+
+	status, _ := doXMLRequest(t, "DELETE", calURL, "", "")
+	if status != http.StatusNoContent {
+		t.Fatalf("DELETE calendar: expected 204, got %d", status)
+	}
+	if _, err := calRepo.GetByName(ctx, user.ID, "trips"); err == nil {
+		t.Fatal("Calendar should be deleted")
+	}
+	if _, err := eventRepo.GetByUID(ctx, old.ID, "trip-1"); err == nil {
+		t.Error("Events of a deleted calendar should be deleted")
+	}
+
+	// A calendar recreated under the same name rejects the old sync token
+	if status, _ := doXMLRequest(t, "MKCALENDAR", calURL, "", ""); status != http.StatusCreated {
+		t.Fatalf("Recreate: expected 201, got %d", status)
+	}
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:">
+  <d:sync-token>` + old.SyncToken + `</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop><d:getetag/></d:prop>
+</d:sync-collection>`
+	status, data := doXMLRequest(t, "REPORT", calURL, "", body)
+	if status != http.StatusForbidden || !strings.Contains(string(data), "valid-sync-token") {
+		t.Errorf("Old sync token on recreated calendar: expected 403 valid-sync-token, got %d: %s", status, string(data))
+	}
+}
+
+func TestCalDAV_DeleteDefaultCalendar_Forbidden(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+
+	status, _ := doXMLRequest(t, "DELETE", srv.URL+caldavBase+"/calendars/testuser/default/", "", "")
+	if status != http.StatusForbidden {
+		t.Errorf("Expected 403 deleting the default calendar, got %d", status)
+	}
+	user, _ := userRepo.GetByUsername(context.Background(), "testuser")
+	if _, err := calRepo.GetByName(context.Background(), user.ID, "default"); err != nil {
+		t.Errorf("Default calendar should still exist: %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/emersion/go-webdav"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	noUIDConflictName              = xml.Name{Space: caldavNS, Local: "no-uid-conflict"}
+	supportedCalendarComponentName = xml.Name{Space: caldavNS, Local: "supported-calendar-component"}
+)
+
+var (
+	// errTransferForbidden is returned for MOVE/COPY requests we never allow:
+	// collections, a destination equal to the source, or a destination outside
+	// the user's calendar home.
+	errTransferForbidden = errors.New("calendar object cannot be transferred to that destination")
+
+	// errUIDConflict is returned when the destination calendar already holds
+	// another object with the same UID (RFC 4791 §5.3.2.1 CALDAV:no-uid-conflict).
+	errUIDConflict = errors.New("destination calendar already contains an object with this UID")
+
+	// errUnsupportedComponent is returned when the destination calendar does not
+	// accept the object's component type (CALDAV:supported-calendar-component).
+	errUnsupportedComponent = errors.New("destination calendar does not support the component type")
+)
+
+// transferOptions are the request headers that control a MOVE or COPY.
+type transferOptions struct {
+	Move      bool
+	Overwrite bool
+	IfMatch   webdav.ConditionalMatch
+}
+
+// MoveCopyHandler serves MOVE and COPY of calendar objects between the user's
+// calendars (go-webdav's CalDAV backend answers both with 501). The UID and
+// calendar data are preserved; both calendars record the change in one
+// transaction so their sync tokens move together.
+type MoveCopyHandler struct {
+	backend *Backend
+}
+
+func NewMoveCopyHandler(backend *Backend) *MoveCopyHandler {
+	return &MoveCopyHandler{backend: backend}
+}
+
+func (h *MoveCopyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dest, err := url.Parse(r.Header.Get("Destination"))
+	if err != nil || dest.Path == "" {
+		http.Error(w, "Missing or invalid Destination header", http.StatusBadRequest)
+		return
+	}
+	if dest.Host != "" && dest.Host != r.Host {
+		// RFC 4918 §9.9.4: the destination is on another server
+		http.Error(w, "Destination is on another server", http.StatusBadGateway)
+		return
+	}
+
+	opts := transferOptions{Move: r.Method == "MOVE", Overwrite: true}
+	switch r.Header.Get("Overwrite") {
+	case "", "T":
+	case "F":
+		opts.Overwrite = false
+	default:
+		http.Error(w, "Invalid Overwrite header", http.StatusBadRequest)
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		opts.IfMatch = webdav.ConditionalMatch(ifMatch)
+	}
+
+	created, err := h.backend.TransferCalendarObject(r.Context(), r.URL.Path, dest.Path, opts)
+	switch {
+	case errors.Is(err, errTransferForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	case errors.Is(err, errUIDConflict):
+		writeDAVError(w, http.StatusConflict, noUIDConflictName)
+		return
+	case errors.Is(err, errUnsupportedComponent):
+		writeDAVError(w, http.StatusForbidden, supportedCalendarComponentName)
+		return
+	case err != nil:
+		writeBackendError(w, err)
+		return
+	}
+
+	if created {
+		w.Header().Set("Location", dest.Path)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TransferCalendarObject moves or copies the calendar object at srcPath to
+// dstPath, which may be in another of the user's calendars. It reports whether
+// the destination was created (as opposed to overwritten).
+//
+// Errors: domain.ErrNotFound when the source does not exist, domain.ErrConflict
+// when the destination calendar does not exist, domain.ErrPreconditionFailed
+// when If-Match fails or the destination exists and Overwrite is F,
+// errReadOnlyCalendar when a shared calendar it would change is read-only, plus
+// errTransferForbidden, errUIDConflict and errUnsupportedComponent.
+func (b *Backend) TransferCalendarObject(ctx context.Context, srcPath, dstPath string, opts transferOptions) (created bool, err error) {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return false, errNoAuthenticatedUser
+	}
+
+	srcCalName, srcUID := extractCalendarAndUID(srcPath)
+	dstCalName, dstUID := extractCalendarAndUID(dstPath)
+	if srcUID == "" || dstUID == "" || !strings.HasSuffix(dstPath, ".ics") {
+		// Only calendar objects can be moved; collections are not
+		return false, errTransferForbidden
+	}
+	if calendarHomeUser(dstPath) != user.Username {
+		return false, errTransferForbidden
+	}
+	if srcCalName == dstCalName && srcUID == dstUID {
+		return false, errTransferForbidden
+	}
+
+	srcCal, srcAccess, err := b.calendarByName(ctx, user, srcCalName)
+	if err != nil {
+		return false, err
+	}
+	dstCal, dstAccess := srcCal, srcAccess
+	if dstCalName != srcCalName {
+		dstCal, dstAccess, err = b.calendarByName(ctx, user, dstCalName)
+		if err == domain.ErrNotFound {
+			// RFC 4918 §9.8.5: the destination collection does not exist
+			return false, domain.ErrConflict
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if !dstAccess.canWrite() || (opts.Move && !srcAccess.canWrite()) {
+		return false, errReadOnlyCalendar
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	eventRepoTx := b.eventRepo.WithTx(tx)
+	taskRepoTx := b.taskRepo.WithTx(tx)
+	calendarRepoTx := b.calendarRepo.WithTx(tx)
+	deadPropertyRepoTx := b.deadPropertyRepo.WithTx(tx)
+
+	// Load the source, which is either an event or a task
+	event, err := eventRepoTx.GetByUID(ctx, srcCal.ID, srcUID)
+	if err != nil && err != domain.ErrNotFound {
+		return false, fmt.Errorf("failed to get event: %w", err)
+	}
+	var task *domain.Task
+	if event == nil {
+		task, err = taskRepoTx.GetByUID(ctx, srcCal.ID, srcUID)
+		if err != nil {
+			if err == domain.ErrNotFound {
+				return false, err
+			}
+			return false, fmt.Errorf("failed to get task: %w", err)
+		}
+	}
+
+	ics, etag := "", ""
+	if event != nil {
+		ics, etag = event.ICS, event.ETag
+	} else {
+		ics, etag = task.ICS, task.ETag
+	}
+	if opts.IfMatch.IsSet() && !checkIfMatch(ctx, opts.IfMatch, etag) {
+		return false, domain.ErrPreconditionFailed
+	}
+
+	icalData, err := parseICalendar(ics)
+	if err != nil {
+		return false, fmt.Errorf("corrupt stored ICS for %s: %w", srcUID, err)
+	}
+	if !dstCal.SupportsComponent(mainComponentName(icalData)) {
+		return false, errUnsupportedComponent
+	}
+
+	// Objects are keyed by resource name, which clients set to the UID. Another
+	// object under the UID's own name in the destination is a UID conflict,
+	// unless it is the source being moved within its calendar.
+	if icsUID := extractUIDFromICalendar(icalData); icsUID != "" && icsUID != dstUID {
+		sameObject := opts.Move && dstCal.ID == srcCal.ID && icsUID == srcUID
+		if !sameObject {
+			exists, err := calendarObjectExists(ctx, eventRepoTx, taskRepoTx, dstCal.ID, icsUID)
+			if err != nil {
+				return false, err
+			}
+			if exists {
+				return false, errUIDConflict
+			}
+		}
+	}
+
+	// Replace an existing destination when Overwrite allows it
+	srcResource := davResourcePath(srcPath)
+	dstResource := davResourcePath(dstPath)
+	exists, err := calendarObjectExists(ctx, eventRepoTx, taskRepoTx, dstCal.ID, dstUID)
+	if err != nil {
+		return false, err
+	}
+	created = !exists
+	if exists {
+		if !opts.Overwrite {
+			// A 412 for Overwrite, not an ETag conflict
+			recordETagOutcome(ctx, domain.CalDAVETagNotApplicable)
+			return false, domain.ErrPreconditionFailed
+		}
+		err = eventRepoTx.Delete(ctx, dstCal.ID, dstUID)
+		if err == domain.ErrNotFound {
+			err = b.deleteTaskInTx(ctx, tx, dstCal.ID, dstUID)
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to replace destination: %w", err)
+		}
+		if err := deadPropertyRepoTx.DeleteByPathPrefix(ctx, user.ID, dstResource); err != nil {
+			return false, err
+		}
+	}
+
+	var stored *domain.Event // the destination event; nil for tasks
+	if event != nil {
+		overrides, err := extractEventOverrides(icalData, dstUID)
+		if err != nil {
+			return false, err
+		}
+		copied := *event
+		copied.ID = 0
+		copied.CalendarID = dstCal.ID
+		copied.UID = dstUID
+		copied.Overrides = overrides
+		copied.Alarms = extractEventAlarms(icalData)
+		if err := eventRepoTx.Create(ctx, &copied); err != nil {
+			return false, fmt.Errorf("failed to create event: %w", err)
+		}
+		stored = &copied
+	} else {
+		copied := *task
+		copied.ID = 0
+		copied.TodoistID = nil
+		copied.CalendarID = &dstCal.ID
+		copied.UID = dstUID
+		if err := taskRepoTx.Create(ctx, &copied); err != nil {
+			return false, fmt.Errorf("failed to create task: %w", err)
+		}
+	}
+
+	// Dead properties travel with the resource
+	props, err := deadPropertyRepoTx.ListByPath(ctx, user.ID, srcResource)
+	if err != nil {
+		return false, err
+	}
+	for _, prop := range props {
+		prop.Path = dstResource
+		if err := deadPropertyRepoTx.Set(ctx, prop); err != nil {
+			return false, err
+		}
+	}
+
+	if opts.Move {
+		if event != nil {
+			err = eventRepoTx.Delete(ctx, srcCal.ID, srcUID)
+		} else {
+			err = taskRepoTx.Delete(ctx, task.ID)
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to delete source: %w", err)
+		}
+		if err := deadPropertyRepoTx.DeleteByPathPrefix(ctx, user.ID, srcResource); err != nil {
+			return false, err
+		}
+		if _, err := calendarRepoTx.RecordChange(ctx, srcCal.ID, srcUID, domain.CalendarChangeDeleted); err != nil {
+			return false, fmt.Errorf("failed to increment sync token: %w", err)
+		}
+	}
+
+	changeType := domain.CalendarChangeUpdated
+	if created {
+		changeType = domain.CalendarChangeCreated
+	}
+	if _, err := calendarRepoTx.RecordChange(ctx, dstCal.ID, dstUID, changeType); err != nil {
+		return false, fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	var notes txNotifications
+	if stored != nil {
+		if opts.Move {
+			if err := b.notifyEventInTx(ctx, tx, &notes, srcCal, srcUID, domain.CalendarChangeDeleted, nil); err != nil {
+				return false, err
+			}
+		}
+		if err := b.notifyEventInTx(ctx, tx, &notes, dstCal, dstUID, changeType, stored); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Info("caldav.object.transferred", "username", user.Username, "move", opts.Move,
+		"from_calendar", srcCalName, "from_uid", srcUID, "to_calendar", dstCalName, "to_uid", dstUID, "created", created)
+	b.publish(ctx, notes)
+	return created, nil
+}
+
+// calendarObjectExists reports whether a calendar holds an event or task under uid.
+func calendarObjectExists(ctx context.Context, eventRepo *data.SQLiteEventRepo, taskRepo *data.SQLiteTaskRepo, calendarID int64, uid string) (bool, error) {
+	if _, err := eventRepo.GetByUID(ctx, calendarID, uid); err != domain.ErrNotFound {
+		return err == nil, err
+	}
+	if _, err := taskRepo.GetByUID(ctx, calendarID, uid); err != domain.ErrNotFound {
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// calendarHomeUser returns the {username} segment of a
+// /dav/calendars/{username}/... path, or "" when there is none.
+func calendarHomeUser(urlPath string) string {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i, part := range parts {
+		if part == "calendars" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func transferRequest(t *testing.T, method, srcURL, dstURL string, headers map[string]string) int {
+	t.Helper()
+
+	req, _ := http.NewRequest(method, srcURL, nil)
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Destination", dstURL)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestCalDAV_MOVE_BetweenCalendars(t *testing.T) {
+	srv, userRepo, calRepo, eventRepo := setupTestServer(t)
+	ctx := context.Background()
+	home := srv.URL + caldavBase + "/calendars/testuser/"
+
+	if status, _ := doXMLRequest(t, "MKCALENDAR", home+"personal/", "", ""); status != http.StatusCreated {
+		t.Fatalf("MKCALENDAR: expected 201, got %d", status)
+	}
+	putTestEvent(t, srv.URL, "move-me")
+
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	work, _ := calRepo.GetByName(ctx, user.ID, "default")
+	personal, _ := calRepo.GetByName(ctx, user.ID, "personal")
+	event, _ := eventRepo.GetByUID(ctx, work.ID, "move-me")
+
+	// If-Match must match the source ETag
+	status := transferRequest(t, "MOVE", home+"default/move-me.ics", home+"personal/move-me.ics", map[string]string{"If-Match": `"stale"`})
+	if status != http.StatusPreconditionFailed {
+		t.Fatalf("MOVE with stale If-Match: expected 412, got %d", status)
+	}
+
+	status = transferRequest(t, "MOVE", home+"default/move-me.ics", home+"personal/move-me.ics", map[string]string{"If-Match": event.ETag})
+	if status != http.StatusCreated {
+		t.Fatalf("MOVE: expected 201, got %d", status)
+	}
+
+	if _, err := eventRepo.GetByUID(ctx, work.ID, "move-me"); err == nil {
+		t.Error("Source should be gone after MOVE")
+	}
+	moved, err := eventRepo.GetByUID(ctx, personal.ID, "move-me")
+	if err != nil {
+		t.Fatalf("Destination should exist after MOVE: %v", err)
+	}
+	if moved.ETag != event.ETag || moved.ICS != event.ICS {
+		t.Error("MOVE should preserve the calendar data and ETag")
+	}
+
+	workAfter, _ := calRepo.GetByName(ctx, user.ID, "default")
+	personalAfter, _ := calRepo.GetByName(ctx, user.ID, "personal")
+	if workAfter.SyncToken == work.SyncToken || personalAfter.SyncToken == personal.SyncToken {
+		t.Error("MOVE should bump the sync tokens of both calendars")
+	}
+
+	// The source now reports a deletion to sync clients
+	ms := syncCollection(t, srv.URL, work.SyncToken)
+	if len(ms.Responses) != 1 || ms.Responses[0].Status != "HTTP/1.1 404 Not Found" {
+		t.Errorf("Expected a deletion in the source calendar's sync delta, got %+v", ms.Responses)
+	}
+}
+
+func TestCalDAV_COPY_OverwriteAndUIDConflict(t *testing.T) {
+	srv, userRepo, calRepo, eventRepo := setupTestServer(t)
+	ctx := context.Background()
+	home := srv.URL + caldavBase + "/calendars/testuser/"
+
+	if status, _ := doXMLRequest(t, "MKCALENDAR", home+"personal/", "", ""); status != http.StatusCreated {
+		t.Fatalf("MKCALENDAR: expected 201, got %d", status)
+	}
+	putTestEvent(t, srv.URL, "copy-me")
+
+	status := transferRequest(t, "COPY", home+"default/copy-me.ics", home+"personal/copy-me.ics", nil)
+	if status != http.StatusCreated {
+		t.Fatalf("COPY: expected 201, got %d", status)
+	}
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	work, _ := calRepo.GetByName(ctx, user.ID, "default")
+	if _, err := eventRepo.GetByUID(ctx, work.ID, "copy-me"); err != nil {
+		t.Error("Source should remain after COPY")
+	}
+
+	// Overwrite: F refuses an existing destination, the default replaces it
+	status = transferRequest(t, "COPY", home+"default/copy-me.ics", home+"personal/copy-me.ics", map[string]string{"Overwrite": "F"})
+	if status != http.StatusPreconditionFailed {
+		t.Errorf("COPY with Overwrite F: expected 412, got %d", status)
+	}
+	status = transferRequest(t, "COPY", home+"default/copy-me.ics", home+"personal/copy-me.ics", nil)
+	if status != http.StatusNoContent {
+		t.Errorf("COPY over existing: expected 204, got %d", status)
+	}
+
+	// A second resource carrying the same UID in one calendar is a UID conflict
+	status = transferRequest(t, "COPY", home+"default/copy-me.ics", home+"personal/other-name.ics", nil)
+	if status != http.StatusConflict {
+		t.Errorf("COPY with duplicate UID: expected 409, got %d", status)
+	}
+
+	// Unknown destination calendar and foreign calendar homes
+	if status := transferRequest(t, "COPY", home+"default/copy-me.ics", home+"missing/copy-me.ics", nil); status != http.StatusConflict {
+		t.Errorf("COPY into missing calendar: expected 409, got %d", status)
+	}
+	if status := transferRequest(t, "COPY", home+"default/copy-me.ics", srv.URL+caldavBase+"/calendars/someone/default/copy-me.ics", nil); status != http.StatusForbidden {
+		t.Errorf("COPY into another user's home: expected 403, got %d", status)
+	}
+	if status := transferRequest(t, "MOVE", home+"default/absent.ics", home+"personal/absent.ics", nil); status != http.StatusNotFound {
+		t.Errorf("MOVE of missing object: expected 404, got %d", status)
+	}
+}
@@ -0,0 +1,237 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// XML namespaces used by the hand-written DAV responses in this package.
+// go-webdav's caldav.Handler only covers the core RFC 4791 surface, so REPORTs
+// and properties it does not know about are served from here.
+const (
+	caldavNS         = "urn:ietf:params:xml:ns:caldav"
+	calendarServerNS = "http://calendarserver.org/ns/"
+)
+
+var (
+	syncTokenName          = xml.Name{Space: davNS, Local: "sync-token"}
+	getETagName            = xml.Name{Space: davNS, Local: "getetag"}
+	getContentTypeName     = xml.Name{Space: davNS, Local: "getcontenttype"}
+	supportedReportSetName = xml.Name{Space: davNS, Local: "supported-report-set"}
+	validSyncTokenName     = xml.Name{Space: davNS, Local: "valid-sync-token"}
+	calendarDataName       = xml.Name{Space: caldavNS, Local: "calendar-data"}
+	getCTagName            = xml.Name{Space: calendarServerNS, Local: "getctag"}
+)
+
+// davMultistatus is a 207 Multi-Status body whose property values are kept as
+// raw XML so responses produced by go-webdav can be decoded, amended and
+// re-encoded without a typed struct per property.
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"DAV: response"`
+	SyncToken string        `xml:"DAV: sync-token,omitempty"`
+}
+
+type davResponse struct {
+	Hrefs     []string      `xml:"DAV: href"`
+	Status    string        `xml:"DAV: status,omitempty"`
+	PropStats []davPropStat `xml:"DAV: propstat"`
+}
+
+type davPropStat struct {
+	Prop   davProp `xml:"DAV: prop"`
+	Status string  `xml:"DAV: status"`
+}
+
+type davProp struct {
+	Values []davRawValue `xml:",any"`
+}
+
+// davRawValue is a single property element with its inner XML preserved as-is.
+type davRawValue struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// Names returns the element names of the properties, in document order.
+func (p davProp) Names() []xml.Name {
+	names := make([]xml.Name, 0, len(p.Values))
+	for _, v := range p.Values {
+		names = append(names, v.XMLName)
+	}
+	return names
+}
+
+// davTextValue builds a property element with escaped character data.
+func davTextValue(name xml.Name, text string) davRawValue {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(text))
+	return davRawValue{XMLName: name, Inner: buf.Bytes()}
+}
+
+// davEmptyValue builds a property element with no content (used in 404 propstats).
+func davEmptyValue(name xml.Name) davRawValue {
+	return davRawValue{XMLName: name}
+}
+
+// davStatus renders an HTTP status line for a DAV:status element.
+func davStatus(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// addProp appends value to the propstat with the given status code, creating it if needed.
+func (r *davResponse) addProp(code int, value davRawValue) {
+	status := davStatus(code)
+	for i := range r.PropStats {
+		if r.PropStats[i].Status == status {
+			r.PropStats[i].Prop.Values = append(r.PropStats[i].Prop.Values, value)
+			return
+		}
+	}
+	r.PropStats = append(r.PropStats, davPropStat{Prop: davProp{Values: []davRawValue{value}}, Status: status})
+}
+
+// propNames returns the names of the properties in the propstat with the given status code.
+func (r *davResponse) propNames(code int) []xml.Name {
+	status := davStatus(code)
+	var names []xml.Name
+	for _, ps := range r.PropStats {
+		if ps.Status == status {
+			names = append(names, ps.Prop.Names()...)
+		}
+	}
+	return names
+}
+
+// removeProp drops the named property from every propstat with the given status
+// code and reports whether it was present. Empty propstats are removed.
+func (r *davResponse) removeProp(code int, name xml.Name) bool {
+	status := davStatus(code)
+	found := false
+	kept := r.PropStats[:0]
+	for _, ps := range r.PropStats {
+		if ps.Status == status {
+			values := ps.Prop.Values[:0]
+			for _, v := range ps.Prop.Values {
+				if v.XMLName == name {
+					found = true
+					continue
+				}
+				values = append(values, v)
+			}
+			ps.Prop.Values = values
+			if len(values) == 0 {
+				continue
+			}
+		}
+		kept = append(kept, ps)
+	}
+	r.PropStats = kept
+	return found
+}
+
+// sanitizeNamespaceAttrs drops decoded xmlns declarations; the encoder re-emits
+// them from XMLName so keeping them would duplicate attributes.
+func sanitizeNamespaceAttrs(ms *davMultistatus) {
+	for i := range ms.Responses {
+		for j := range ms.Responses[i].PropStats {
+			values := ms.Responses[i].PropStats[j].Prop.Values
+			for k := range values {
+				attrs := values[k].Attrs[:0]
+				for _, a := range values[k].Attrs {
+					if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+						continue
+					}
+					attrs = append(attrs, a)
+				}
+				values[k].Attrs = attrs
+			}
+		}
+	}
+}
+
+// writeMultistatus serves a 207 response.
+func writeMultistatus(w http.ResponseWriter, ms *davMultistatus) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+
+	io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(ms); err != nil {
+		slog.Error("Failed to encode multistatus response", "error", err)
+	}
+}
+
+// davErrorBody is a DAV:error body naming a failed precondition.
+type davErrorBody struct {
+	XMLName   xml.Name      `xml:"DAV: error"`
+	Condition []davRawValue `xml:",any"`
+}
+
+// writeDAVError serves an error response with a precondition element per RFC 4918 §16.
+func writeDAVError(w http.ResponseWriter, code int, condition xml.Name) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+
+	io.WriteString(w, xml.Header)
+	body := davErrorBody{Condition: []davRawValue{davEmptyValue(condition)}}
+	if err := xml.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to encode DAV error response", "error", err)
+	}
+}
+
+// writeBackendError maps errors returned by Backend helpers used from this
+// package's own handlers onto HTTP responses.
+func writeBackendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSyncToken):
+		writeDAVError(w, http.StatusForbidden, validSyncTokenName)
+	case errors.Is(err, errNoAuthenticatedUser):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, errReadOnlyCalendar):
+		writeDAVError(w, http.StatusForbidden, needPrivilegesName)
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, "Not Found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		http.Error(w, "Precondition Failed", http.StatusPreconditionFailed)
+	case errors.Is(err, domain.ErrConflict):
+		http.Error(w, "Conflict", http.StatusConflict)
+	default:
+		slog.Error("CalDAV request failed", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// readXMLBody reads the request body and replaces it with a fresh reader so
+// downstream handlers can decode it again.
+func readXMLBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// xmlRootName returns the name of the document element, or the zero name for an
+// empty or malformed body.
+func xmlRootName(body []byte) xml.Name {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.Name{}
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name
+		}
+	}
+}
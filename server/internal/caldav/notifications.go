@@ -0,0 +1,88 @@
+package caldav
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// SetPublisher makes the backend publish the calendar and event changes it
+// stores, e.g. to the /events stream.
+func (b *Backend) SetPublisher(publisher domain.NotificationPublisher) {
+	b.publisher = publisher
+}
+
+// SetWebhooks makes the backend queue webhook deliveries for the calendar and
+// event changes it stores, in the transaction that stores them. Do not also
+// pass webhooks to SetPublisher.
+func (b *Backend) SetWebhooks(webhooks *services.WebhookService) {
+	b.webhooks = webhooks
+}
+
+// txNotifications collects the notifications for the changes of one
+// transaction. They are published once it commits, so subscribers never hear
+// of changes that were rolled back.
+type txNotifications []*domain.Notification
+
+// notifyInTx queues n for the owner of n.Calendar and the users it is shared
+// with. It does nothing without a publisher or webhooks.
+func (b *Backend) notifyInTx(ctx context.Context, tx *sql.Tx, notes *txNotifications, n *domain.Notification) error {
+	if b.publisher == nil && b.webhooks == nil {
+		return nil
+	}
+	shares, err := b.shareRepo.WithTx(tx).ListByCalendar(ctx, n.Calendar.ID)
+	if err != nil {
+		return err
+	}
+	n.UserIDs = []int64{n.Calendar.UserID}
+	for _, share := range shares {
+		n.UserIDs = append(n.UserIDs, share.GranteeUserID)
+	}
+	return b.queueInTx(ctx, tx, notes, n)
+}
+
+// queueInTx stores the webhook deliveries of n in tx, so they are neither
+// lost if the server stops right after the commit nor sent for a change that
+// rolled back, and keeps n to be published after the commit.
+func (b *Backend) queueInTx(ctx context.Context, tx *sql.Tx, notes *txNotifications, n *domain.Notification) error {
+	if b.webhooks != nil {
+		if _, err := b.webhooks.Queue(ctx, b.webhookRepo.WithTx(tx), n); err != nil {
+			return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+		}
+	}
+	*notes = append(*notes, n)
+	return nil
+}
+
+// notifyEventInTx queues the notification for a change of the event with the
+// given UID in cal. event is the stored event; it is nil for deletions.
+func (b *Backend) notifyEventInTx(ctx context.Context, tx *sql.Tx, notes *txNotifications, cal *domain.Calendar, uid string, change domain.CalendarChangeType, event *domain.Event) error {
+	typ := domain.NotificationEventUpdated
+	switch change {
+	case domain.CalendarChangeCreated:
+		typ = domain.NotificationEventCreated
+	case domain.CalendarChangeDeleted:
+		typ = domain.NotificationEventDeleted
+		event = nil
+	}
+	return b.notifyInTx(ctx, tx, notes, &domain.Notification{Type: typ, Calendar: cal, UID: uid, Event: event})
+}
+
+// publish hands the notifications of a committed transaction to the publisher
+// and wakes the webhook sender for the deliveries queued with them.
+func (b *Backend) publish(ctx context.Context, notes txNotifications) {
+	if len(notes) == 0 {
+		return
+	}
+	if b.publisher != nil {
+		for _, n := range notes {
+			b.publisher.Publish(ctx, n)
+		}
+	}
+	if b.webhooks != nil {
+		b.webhooks.Wake()
+	}
+}
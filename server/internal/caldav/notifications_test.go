@@ -0,0 +1,265 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// recordingPublisher collects published notifications.
+type recordingPublisher struct {
+	mu            sync.Mutex
+	notifications []*domain.Notification
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, n *domain.Notification) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notifications = append(p.notifications, n)
+}
+
+// take returns the notifications published since the last call.
+func (p *recordingPublisher) take() []*domain.Notification {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	taken := p.notifications
+	p.notifications = nil
+	return taken
+}
+
+func TestCalDAV_PublishesChanges(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	ids := make(map[string]int64)
+	for _, name := range []string{"alice", "bob"} {
+		user, err := users.CreateUser(ctx, name, name+"-password", false)
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if _, err := users.SetEmail(ctx, name, name+"@example.com"); err != nil {
+			t.Fatalf("SetEmail failed: %v", err)
+		}
+		ids[name] = user.ID
+	}
+	alice, _ := userRepo.GetByUsername(ctx, "alice")
+	shares := services.NewCalendarShareService(data.NewSQLiteCalendarShareRepo(db), calendarRepo, userRepo, nil)
+	if _, err := shares.Share(ctx, alice, "default", "bob", domain.SharePrivilegeRead); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+
+	publisher := &recordingPublisher{}
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, data.NewSQLiteEventRepo(db), data.NewSQLiteCalDAVOperationRepo(db), nil, nil, publisher, nil))
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, body string, header ...string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.SetBasicAuth("alice", "alice-password")
+		if method == http.MethodPut {
+			req.Header.Set("Content-Type", "text/calendar")
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	expect := func(step string, want ...domain.NotificationType) []*domain.Notification {
+		t.Helper()
+		got := publisher.take()
+		if len(got) != len(want) {
+			t.Fatalf("%s: published %d notifications, want %v", step, len(got), want)
+		}
+		for i, n := range got {
+			if n.Type != want[i] {
+				t.Fatalf("%s: notification %d is %s, want %s", step, i, n.Type, want[i])
+			}
+		}
+		return got
+	}
+	audience := func(n *domain.Notification, names ...string) {
+		t.Helper()
+		if len(n.UserIDs) != len(names) {
+			t.Fatalf("%s for users %v, want %v", n.Type, n.UserIDs, names)
+		}
+		for i, name := range names {
+			if n.UserIDs[i] != ids[name] {
+				t.Fatalf("%s for users %v, want %v", n.Type, n.UserIDs, names)
+			}
+		}
+	}
+	event := func(summary, attendee string) string {
+		return strings.ReplaceAll(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//Test//EN
+BEGIN:VEVENT
+UID:standup
+DTSTAMP:20260116T080000Z
+DTSTART:20260120T090000Z
+DTEND:20260120T091500Z
+SUMMARY:`+summary+`
+`+attendee+`END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n")
+	}
+	eventPath := caldavBase + "/calendars/alice/default/standup.ics"
+
+	// Event changes go to the owner and the users the calendar is shared with
+	if status := do(http.MethodPut, eventPath, event("Standup", "")); status != http.StatusCreated {
+		t.Fatalf("PUT: expected 201, got %d", status)
+	}
+	created := expect("create", domain.NotificationEventCreated)[0]
+	audience(created, "alice", "bob")
+	if created.UID != "standup" || created.Calendar.Name != "default" || created.Event == nil || created.Event.ETag == "" {
+		t.Errorf("created notification = %+v, want the stored event", created)
+	}
+
+	if status := do(http.MethodPut, eventPath, event("Standup", ""), "If-Match", `"stale"`); status != http.StatusPreconditionFailed {
+		t.Fatalf("PUT with stale If-Match: expected 412, got %d", status)
+	}
+	expect("rejected update")
+
+	if status := do(http.MethodPut, eventPath, event("Daily standup", "")); status != http.StatusNoContent && status != http.StatusCreated {
+		t.Fatalf("PUT update: got %d", status)
+	}
+	if updated := expect("update", domain.NotificationEventUpdated)[0]; updated.Event.Summary != "Daily standup" {
+		t.Errorf("updated notification summary = %q", updated.Event.Summary)
+	}
+
+	if status := do(http.MethodDelete, eventPath, ""); status != http.StatusNoContent {
+		t.Fatalf("DELETE: expected 204, got %d", status)
+	}
+	if deleted := expect("delete", domain.NotificationEventDeleted)[0]; deleted.UID != "standup" || deleted.Event != nil {
+		t.Errorf("deleted notification = %+v", deleted)
+	}
+
+	// Invitations also notify the attendee of their copy
+	invitation := event("Standup", "ORGANIZER:mailto:alice@example.com\nATTENDEE:mailto:bob@example.com\n")
+	if status := do(http.MethodPut, eventPath, invitation); status != http.StatusCreated {
+		t.Fatalf("PUT meeting: expected 201, got %d", status)
+	}
+	got := expect("invite", domain.NotificationEventCreated, domain.NotificationEventCreated)
+	audience(got[0], "alice", "bob")
+	audience(got[1], "bob")
+
+	// Calendar changes
+	if status := do("MKCALENDAR", caldavBase+"/calendars/alice/work/", ""); status != http.StatusCreated {
+		t.Fatalf("MKCALENDAR: expected 201, got %d", status)
+	}
+	audience(expect("mkcalendar", domain.NotificationCalendarCreated)[0], "alice")
+	if status := do("PROPPATCH", caldavBase+"/calendars/alice/default/", `<?xml version="1.0"?>
+<d:propertyupdate xmlns:d="DAV:"><d:set><d:prop><d:displayname>Home</d:displayname></d:prop></d:set></d:propertyupdate>`,
+		"Content-Type", "application/xml"); status != http.StatusMultiStatus {
+		t.Fatalf("PROPPATCH: expected 207, got %d", status)
+	}
+	if updated := expect("proppatch", domain.NotificationCalendarUpdated)[0]; updated.Calendar.DisplayName != "Home" {
+		t.Errorf("calendar.updated display name = %q", updated.Calendar.DisplayName)
+	}
+	if status := do(http.MethodDelete, caldavBase+"/calendars/alice/work/", ""); status != http.StatusNoContent {
+		t.Fatalf("DELETE calendar: expected 204, got %d", status)
+	}
+	expect("delete calendar", domain.NotificationCalendarDeleted)
+}
+
+// acceptingWebhookSender accepts every delivery; deliveries stay queued
+// unless the webhook service runs.
+type acceptingWebhookSender struct{}
+
+func (acceptingWebhookSender) Send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	return http.StatusNoContent, nil
+}
+
+func TestCalDAV_QueuesWebhookDeliveriesWithChanges(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	webhookRepo := data.NewSQLiteWebhookRepo(db)
+	webhooks := services.NewWebhookService(webhookRepo, acceptingWebhookSender{})
+	subscriptions := make(map[string]*domain.WebhookSubscription)
+	for _, name := range []string{"alice", "bob"} {
+		user, err := users.CreateUser(ctx, name, name+"-password", false)
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if subscriptions[name], err = webhooks.Create(ctx, user, "https://hooks.example.com/"+name, nil, false); err != nil {
+			t.Fatalf("Create webhook failed: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, data.NewSQLiteEventRepo(db), data.NewSQLiteCalDAVOperationRepo(db), nil, nil, nil, webhooks))
+	t.Cleanup(srv.Close)
+	put := func(header ...string) int {
+		body := strings.ReplaceAll("BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//Test//Test//EN\nBEGIN:VEVENT\nUID:standup\nDTSTAMP:20260116T080000Z\nDTSTART:20260120T090000Z\nDTEND:20260120T091500Z\nSUMMARY:Standup\nEND:VEVENT\nEND:VCALENDAR\n", "\n", "\r\n")
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+caldavBase+"/calendars/alice/default/standup.ics", strings.NewReader(body))
+		req.SetBasicAuth("alice", "alice-password")
+		req.Header.Set("Content-Type", "text/calendar")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PUT failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	queued := func(name string) []*domain.WebhookDelivery {
+		t.Helper()
+		log, err := webhookRepo.ListDeliveries(ctx, subscriptions[name].ID, 10)
+		if err != nil {
+			t.Fatalf("ListDeliveries failed: %v", err)
+		}
+		return log
+	}
+
+	// The delivery is stored by the request that stores the event, for the
+	// owner's subscription only
+	if status := put(); status != http.StatusCreated {
+		t.Fatalf("PUT: expected 201, got %d", status)
+	}
+	if log := queued("alice"); len(log) != 1 || log[0].EventType != domain.NotificationEventCreated || log[0].Status != domain.WebhookDeliveryPending {
+		t.Fatalf("alice's deliveries = %+v, want one pending event.created", log)
+	}
+	if log := queued("bob"); len(log) != 0 {
+		t.Fatalf("bob's deliveries = %+v, want none for a calendar bob cannot see", log)
+	}
+
+	// Rejected writes queue nothing
+	if status := put("If-Match", `"stale"`); status != http.StatusPreconditionFailed {
+		t.Fatalf("PUT with stale If-Match: expected 412, got %d", status)
+	}
+	if log := queued("alice"); len(log) != 1 {
+		t.Fatalf("alice's deliveries after a rejected write = %d, want 1", len(log))
+	}
+}
@@ -80,20 +80,24 @@ func RedactCalDAVPath(rawPath string) string {
 
 func ClassifyOperationKind(method string) domain.CalDAVOperationKind {
 	switch strings.ToUpper(method) {
-	case http.MethodPut, http.MethodDelete, "PROPPATCH", "MKCOL", "MOVE", "COPY", http.MethodPost, http.MethodPatch:
+	case http.MethodPut, http.MethodDelete, "PROPPATCH", "MKCOL", "MKCALENDAR", "MOVE", "COPY", http.MethodPost, http.MethodPatch:
 		return domain.CalDAVOperationWrite
 	default:
 		return domain.CalDAVOperationRead
 	}
 }
 
-func ClassifyETagOutcome(method string, statusCode int, requestHeader http.Header, responseHeader http.Header) domain.CalDAVETagOutcome {
+// ClassifyETagOutcome labels a request's ETag handling. backendOutcome is the
+// result the backend recorded while evaluating preconditions and wins when set;
+// otherwise the outcome is inferred from the status code and headers. An
+// If-Match the backend never evaluated is not counted as matched.
+func ClassifyETagOutcome(method string, statusCode int, backendOutcome domain.CalDAVETagOutcome, requestHeader http.Header, responseHeader http.Header) domain.CalDAVETagOutcome {
+	if backendOutcome != "" {
+		return backendOutcome
+	}
 	if statusCode == http.StatusPreconditionFailed {
 		return domain.CalDAVETagMismatched
 	}
-	if requestHeader.Get("If-Match") != "" {
-		return domain.CalDAVETagMatched
-	}
 	if requestHeader.Get("If-None-Match") != "" {
 		return domain.CalDAVETagMissing
 	}
@@ -123,6 +127,9 @@ func RedactedCalDAVError(statusCode int, method string) (domain.CalDAVErrorCode,
 	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
 		return domain.CalDAVErrorAuthFailed, "Authentication failed."
 	}
+	if statusCode == http.StatusTooManyRequests {
+		return domain.CalDAVErrorAuthLockedOut, "Sign-in temporarily locked after repeated failures."
+	}
 	if statusCode == http.StatusPreconditionFailed {
 		return domain.CalDAVErrorETagConflict, "ETag precondition failed."
 	}
@@ -138,10 +145,10 @@ func RedactedCalDAVError(statusCode int, method string) (domain.CalDAVErrorCode,
 	return domain.CalDAVErrorUnknown, "CalDAV operation failed."
 }
 
-func BuildCalDAVOperation(method string, rawPath string, statusCode int, duration time.Duration, requestHeader http.Header, responseHeader http.Header, userAgent string, requestSize int64, responseSize int64) domain.CalDAVOperation {
+func BuildCalDAVOperation(method string, rawPath string, statusCode int, duration time.Duration, backendETagOutcome domain.CalDAVETagOutcome, requestHeader http.Header, responseHeader http.Header, userAgent string, requestSize int64, responseSize int64) domain.CalDAVOperation {
 	kind := ClassifyOperationKind(method)
 	errorCode, redactedError := RedactedCalDAVError(statusCode, method)
-	etagOutcome := ClassifyETagOutcome(method, statusCode, requestHeader, responseHeader)
+	etagOutcome := ClassifyETagOutcome(method, statusCode, backendETagOutcome, requestHeader, responseHeader)
 	if errorCode == domain.CalDAVErrorETagConflict {
 		etagOutcome = domain.CalDAVETagMismatched
 	}
@@ -64,7 +64,7 @@ func TestRedactCalDAVPath(t *testing.T) {
 }
 
 func TestOperationClassification(t *testing.T) {
-	readOp := BuildCalDAVOperation("GET", "/dav/calendars/user/default/event.ics", http.StatusOK, 10*time.Millisecond, http.Header{}, http.Header{}, "Custom/1", 0, 100)
+	readOp := BuildCalDAVOperation("GET", "/dav/calendars/user/default/event.ics", http.StatusOK, 10*time.Millisecond, "", http.Header{}, http.Header{}, "Custom/1", 0, 100)
 	if readOp.OperationKind != domain.CalDAVOperationRead {
 		t.Fatalf("GET kind = %q, want read", readOp.OperationKind)
 	}
@@ -74,7 +74,7 @@ func TestOperationClassification(t *testing.T) {
 
 	responseHeader := http.Header{}
 	responseHeader.Set("ETag", "abc")
-	writeOp := BuildCalDAVOperation("PUT", "/dav/calendars/user/default/event.ics", http.StatusCreated, 10*time.Millisecond, http.Header{}, responseHeader, "Custom/1", 200, 0)
+	writeOp := BuildCalDAVOperation("PUT", "/dav/calendars/user/default/event.ics", http.StatusCreated, 10*time.Millisecond, "", http.Header{}, responseHeader, "Custom/1", 200, 0)
 	if writeOp.OperationKind != domain.CalDAVOperationWrite {
 		t.Fatalf("PUT kind = %q, want write", writeOp.OperationKind)
 	}
@@ -82,7 +82,7 @@ func TestOperationClassification(t *testing.T) {
 		t.Fatalf("PUT ETag outcome = %q, want generated", writeOp.ETagOutcome)
 	}
 
-	conflictOp := BuildCalDAVOperation("PUT", "/dav/calendars/user/default/event.ics", http.StatusPreconditionFailed, 10*time.Millisecond, http.Header{"If-Match": []string{"wrong"}}, http.Header{}, "Custom/1", 200, 0)
+	conflictOp := BuildCalDAVOperation("PUT", "/dav/calendars/user/default/event.ics", http.StatusPreconditionFailed, 10*time.Millisecond, "", http.Header{"If-Match": []string{"wrong"}}, http.Header{}, "Custom/1", 200, 0)
 	if conflictOp.Outcome != domain.CalDAVOperationRecoverableFailure {
 		t.Fatalf("412 outcome = %q, want recoverable_failure", conflictOp.Outcome)
 	}
@@ -123,7 +123,7 @@ func TestOperationMetadataMiddlewareRecordsRedactedRequest(t *testing.T) {
 		t.Fatalf("create calendar: %v", err)
 	}
 
-	handler := NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, operationRepo)
+	handler := NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, operationRepo, nil, nil, nil, nil)
 	srv := httptest.NewServer(handler)
 	defer srv.Close()
 
@@ -184,3 +184,75 @@ END:VCALENDAR`
 		}
 	}
 }
+
+type operationRecorderFunc func(*domain.CalDAVOperation) error
+
+func (f operationRecorderFunc) Record(op *domain.CalDAVOperation) error { return f(op) }
+func (f operationRecorderFunc) Prune() error                            { return nil }
+
+func TestClassifyETagOutcome_PrefersBackendResult(t *testing.T) {
+	ifMatch := http.Header{"If-Match": []string{`"abc"`}}
+	if got := ClassifyETagOutcome("DELETE", http.StatusNoContent, domain.CalDAVETagMatched, ifMatch, http.Header{}); got != domain.CalDAVETagMatched {
+		t.Fatalf("backend matched = %q, want matched", got)
+	}
+	if got := ClassifyETagOutcome("PUT", http.StatusForbidden, "", ifMatch, http.Header{}); got == domain.CalDAVETagMatched {
+		t.Fatal("an If-Match the backend never evaluated must not count as matched")
+	}
+}
+
+func TestOperationMetadataMiddlewareRecordsBackendETagOutcome(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	eventRepo := data.NewSQLiteEventRepo(db)
+	user, err := userRepo.Create(ctx, "testuser")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := calendarRepo.Create(ctx, &domain.Calendar{UserID: user.ID, Name: "default", DisplayName: "Calendar"}); err != nil {
+		t.Fatalf("create calendar: %v", err)
+	}
+
+	var operations []domain.CalDAVOperation
+	recorder := operationRecorderFunc(func(op *domain.CalDAVOperation) error {
+		operations = append(operations, *op)
+		return nil
+	})
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, recorder, nil, nil, nil, nil))
+	defer srv.Close()
+
+	putTestEvent(t, srv.URL, "outcome-event")
+	cal, _ := calendarRepo.GetByName(ctx, user.ID, "default")
+	event, _ := eventRepo.GetByUID(ctx, cal.ID, "outcome-event")
+
+	send := func(method, path, ifMatch string) domain.CalDAVOperation {
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		req.SetBasicAuth("testuser", "testpass")
+		req.Header.Set("If-Match", ifMatch)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return operations[len(operations)-1]
+	}
+
+	if op := send("DELETE", "/dav/calendars/testuser/missing/outcome-event.ics", event.ETag); op.ETagOutcome == domain.CalDAVETagMatched {
+		t.Errorf("DELETE rejected before the ETag check recorded %q", op.ETagOutcome)
+	}
+	if op := send("DELETE", "/dav/calendars/testuser/default/outcome-event.ics", `"stale"`); op.ETagOutcome != domain.CalDAVETagMismatched {
+		t.Errorf("stale DELETE ETag outcome = %q, want mismatched", op.ETagOutcome)
+	}
+	if op := send("DELETE", "/dav/calendars/testuser/default/outcome-event.ics", event.ETag); op.ETagOutcome != domain.CalDAVETagMatched {
+		t.Errorf("current DELETE ETag outcome = %q, want matched", op.ETagOutcome)
+	}
+}
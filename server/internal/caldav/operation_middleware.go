@@ -1,6 +1,7 @@
 package caldav
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"time"
@@ -19,6 +20,8 @@ func OperationMetadataMiddleware(recorder domain.CalDAVOperationRepo) func(http.
 			}
 
 			started := time.Now()
+			notes := &operationNotes{}
+			r = r.WithContext(context.WithValue(r.Context(), operationNotesContextKey, notes))
 			wrapped := &operationResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 			next.ServeHTTP(wrapped, r)
 
@@ -27,12 +30,14 @@ func OperationMetadataMiddleware(recorder domain.CalDAVOperationRepo) func(http.
 				r.URL.Path,
 				wrapped.statusCode,
 				time.Since(started),
+				notes.etagOutcome,
 				r.Header,
 				wrapped.Header(),
 				r.UserAgent(),
 				r.ContentLength,
 				wrapped.bytesWritten,
 			)
+			operation.Credential = notes.credential
 			if err := recorder.Record(&operation); err != nil {
 				// Recording must never break CalDAV request handling.
 				slog.Warn("failed to record CalDAV operation metadata", "error", err)
@@ -41,6 +46,32 @@ func OperationMetadataMiddleware(recorder domain.CalDAVOperationRepo) func(http.
 	}
 }
 
+const operationNotesContextKey contextKey = "operation_notes"
+
+// operationNotes carries what inner handlers learn about a request back to
+// OperationMetadataMiddleware: the result of the backend's precondition checks,
+// so the recorded ETag outcome reflects what the backend decided rather than
+// which headers the client sent, and the credential the request signed in with.
+type operationNotes struct {
+	etagOutcome domain.CalDAVETagOutcome
+	credential  string
+}
+
+// recordETagOutcome notes the precondition outcome of the current request. It
+// is a no-op when operation metadata is not being recorded.
+func recordETagOutcome(ctx context.Context, outcome domain.CalDAVETagOutcome) {
+	if notes, ok := ctx.Value(operationNotesContextKey).(*operationNotes); ok {
+		notes.etagOutcome = outcome
+	}
+}
+
+// recordCredential notes which credential authenticated the current request.
+func recordCredential(ctx context.Context, credential string) {
+	if notes, ok := ctx.Value(operationNotesContextKey).(*operationNotes); ok {
+		notes.credential = credential
+	}
+}
+
 type operationResponseWriter struct {
 	http.ResponseWriter
 	statusCode   int
@@ -0,0 +1,71 @@
+package caldav
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/emersion/go-webdav"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const preconditionsContextKey contextKey = "preconditions"
+
+// requestPreconditions are the conditional headers of a DELETE. go-webdav calls
+// Backend.DeleteCalendarObject with the path only, so PreconditionMiddleware
+// stashes them in the request context.
+type requestPreconditions struct {
+	IfMatch            webdav.ConditionalMatch
+	IfScheduleTagMatch webdav.ConditionalMatch // RFC 6638 §3.2.10
+}
+
+// PreconditionMiddleware makes the If-Match and If-Schedule-Tag-Match headers
+// of DELETE requests available to the backend.
+func PreconditionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			pre := requestPreconditions{
+				IfMatch:            webdav.ConditionalMatch(r.Header.Get("If-Match")),
+				IfScheduleTagMatch: webdav.ConditionalMatch(r.Header.Get("If-Schedule-Tag-Match")),
+			}
+			r = r.WithContext(context.WithValue(r.Context(), preconditionsContextKey, pre))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func preconditionsFromContext(ctx context.Context) requestPreconditions {
+	pre, _ := ctx.Value(preconditionsContextKey).(requestPreconditions)
+	return pre
+}
+
+// check reports whether the preconditions hold for an object with the given
+// ETag and Schedule-Tag ("" when the object does not exist), recording the
+// outcome for operation metadata.
+func (p requestPreconditions) check(ctx context.Context, etag, scheduleTag string) bool {
+	if !p.IfMatch.IsSet() && !p.IfScheduleTagMatch.IsSet() {
+		return true
+	}
+	ok := true
+	if p.IfMatch.IsSet() {
+		ok = checkIfMatch(ctx, p.IfMatch, etag)
+	}
+	if ok && p.IfScheduleTagMatch.IsSet() {
+		ok = checkIfMatch(ctx, p.IfScheduleTagMatch, scheduleTag)
+	}
+	return ok
+}
+
+// checkIfMatch evaluates an If-Match style condition against the stored ETag
+// of an object ("" when it does not exist) and records the outcome for
+// operation metadata. A condition never matches a missing object (RFC 9110 §13.1.1).
+func checkIfMatch(ctx context.Context, cond webdav.ConditionalMatch, stored string) bool {
+	if stored == "" || !etagMatches(cond, stored) {
+		slog.Debug("caldav.conflict", "expected_etag", string(cond), "actual_etag", stored, "status", 412)
+		recordETagOutcome(ctx, domain.CalDAVETagMismatched)
+		return false
+	}
+	recordETagOutcome(ctx, domain.CalDAVETagMatched)
+	return true
+}
@@ -0,0 +1,204 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// propfindRequest is the subset of a DAV:propfind body we need to decide
+// whether to amend go-webdav's response. allprop and propname requests are left
+// untouched (RFC 6578 §4: sync-token is not returned for allprop).
+type propfindRequest struct {
+	XMLName xml.Name  `xml:"DAV: propfind"`
+	Prop    *davProp  `xml:"DAV: prop"`
+	AllProp *struct{} `xml:"DAV: allprop"`
+}
+
+// CollectionProperties returns the extra properties of a calendar collection,
+// or nil when urlPath is not a calendar in the current user's calendar home.
+// The time zone, Apple calendar-color and calendar-order are only present once
+// set.
+func (b *Backend) CollectionProperties(ctx context.Context, urlPath string) map[xml.Name]davRawValue {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return nil
+	}
+	calName, uid := extractCalendarAndUID(urlPath)
+	if calName == "" || uid != "" {
+		return nil
+	}
+	cal, _, err := b.calendarByName(ctx, user, calName)
+	if err != nil {
+		return nil
+	}
+
+	revision, err := domain.ParseSyncToken(cal.SyncToken)
+	if err != nil {
+		revision = 0
+	}
+	token := domain.FormatSyncToken(revision)
+
+	props := map[xml.Name]davRawValue{
+		syncTokenName:          davTextValue(syncTokenName, token),
+		getCTagName:            davTextValue(getCTagName, token),
+		supportedReportSetName: supportedReportSet(),
+	}
+	if cal.Color != "" {
+		props[calendarColorName] = davTextValue(calendarColorName, cal.Color)
+	}
+	if cal.Timezone != "" {
+		props[calendarTimezoneName] = davTextValue(calendarTimezoneName, cal.Timezone)
+	}
+	if cal.Order != nil {
+		props[calendarOrderName] = davTextValue(calendarOrderName, strconv.Itoa(*cal.Order))
+	}
+	return props
+}
+
+// supportedReportSet advertises the REPORTs a calendar collection answers.
+// DAVx5 only attempts sync-collection when it is listed here.
+func supportedReportSet() davRawValue {
+	reports := []xml.Name{
+		{Space: caldavNS, Local: "calendar-query"},
+		{Space: caldavNS, Local: "calendar-multiget"},
+		syncCollectionName,
+	}
+	var buf bytes.Buffer
+	for _, report := range reports {
+		buf.WriteString(`<supported-report xmlns="DAV:"><report xmlns="DAV:"><` + report.Local + ` xmlns="` + report.Space + `"/></report></supported-report>`)
+	}
+	return davRawValue{XMLName: supportedReportSetName, Inner: buf.Bytes()}
+}
+
+// PropFindExtensionMiddleware fills in properties go-webdav reports as 404:
+// collection properties (sync-token, getctag, supported-report-set,
+// calendar-timezone, Apple calendar-color and calendar-order), scheduling
+// properties of principals and calendar objects, and dead properties set by PROPPATCH. It
+// also replaces go-webdav's current-user-privilege-set, which is read-write for
+// every calendar, with the user's actual privileges. It only buffers the
+// response when the client named the properties it wants.
+func PropFindExtensionMiddleware(backend *Backend) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "PROPFIND" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := readXMLBody(r)
+			if err != nil || len(bytes.TrimSpace(body)) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var req propfindRequest
+			if err := xml.Unmarshal(body, &req); err != nil || req.Prop == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buffered := &bufferedResponseWriter{header: http.Header{}}
+			next.ServeHTTP(buffered, r)
+
+			if buffered.statusCode != http.StatusMultiStatus {
+				buffered.flushTo(w)
+				return
+			}
+			var ms davMultistatus
+			if err := xml.Unmarshal(buffered.body.Bytes(), &ms); err != nil {
+				slog.Warn("failed to decode PROPFIND response for extension", "error", err)
+				buffered.flushTo(w)
+				return
+			}
+			sanitizeNamespaceAttrs(&ms)
+
+			// go-webdav only answers at the principal URL when it is one
+			// segment below the prefix, so /dav/principals/{user}/ comes back
+			// empty. Start from a response where every property is missing.
+			if user := getUserFromContext(r.Context()); len(ms.Responses) == 0 && user != nil && strings.TrimSuffix(r.URL.Path, "/")+"/" == principalPath(user.Username) {
+				principal := davResponse{Hrefs: []string{principalPath(user.Username)}}
+				for _, name := range req.Prop.Names() {
+					principal.addProp(http.StatusNotFound, davEmptyValue(name))
+				}
+				ms.Responses = append(ms.Responses, principal)
+			}
+
+			for i := range ms.Responses {
+				resp := &ms.Responses[i]
+				if len(resp.Hrefs) == 0 {
+					continue
+				}
+				if privileges, ok := backend.CurrentUserPrivilegeSet(r.Context(), resp.Hrefs[0]); ok && resp.removeProp(http.StatusOK, currentUserPrivilegeSetName) {
+					resp.addProp(http.StatusOK, privileges)
+				}
+				missing := resp.propNames(http.StatusNotFound)
+				if len(missing) == 0 {
+					continue
+				}
+				props := backend.DeadProperties(r.Context(), resp.Hrefs[0])
+				for name, value := range backend.CollectionProperties(r.Context(), resp.Hrefs[0]) {
+					props[name] = value
+				}
+				for name, value := range backend.SchedulingProperties(r.Context(), resp.Hrefs[0]) {
+					props[name] = value
+				}
+				for _, name := range missing {
+					if value, ok := props[name]; ok && resp.removeProp(http.StatusNotFound, name) {
+						resp.addProp(http.StatusOK, value)
+					}
+				}
+			}
+
+			for key, values := range buffered.header {
+				if key == "Content-Length" {
+					continue
+				}
+				w.Header()[key] = values
+			}
+			writeMultistatus(w, &ms)
+		})
+	}
+}
+
+// bufferedResponseWriter captures a response so it can be rewritten before
+// being sent to the client.
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if w.statusCode == 0 {
+		w.statusCode = code
+	}
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+// flushTo copies the captured response to w unchanged.
+func (w *bufferedResponseWriter) flushTo(dst http.ResponseWriter) {
+	for key, values := range w.header {
+		dst.Header()[key] = values
+	}
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	dst.WriteHeader(w.statusCode)
+	dst.Write(w.body.Bytes())
+}
@@ -1,42 +1,91 @@
 package caldav
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
 )
 
 // DAV XML namespace
 const davNS = "DAV:"
 
-// PropPatchHandler handles PROPPATCH requests for Apple Calendar compatibility
-// Returns HTTP 207 Multi-Status with all properties marked as successfully set
-// (This is a no-op - we don't actually modify properties, but Apple Calendar requires this response)
-type PropPatchHandler struct{}
+// Apple iCal namespace (calendar-color, calendar-order)
+const appleICalNS = "http://apple.com/ns/ical/"
+
+var (
+	displayNameName         = xml.Name{Space: davNS, Local: "displayname"}
+	calendarDescriptionName = xml.Name{Space: caldavNS, Local: "calendar-description"}
+	calendarColorName       = xml.Name{Space: appleICalNS, Local: "calendar-color"}
+	calendarOrderName       = xml.Name{Space: appleICalNS, Local: "calendar-order"}
+)
 
-func NewPropPatchHandler() *PropPatchHandler {
-	return &PropPatchHandler{}
+// protectedPropertyNames are live properties computed by the server. PROPPATCH
+// on them fails with 403 (RFC 4918 §9.2).
+var protectedPropertyNames = map[xml.Name]bool{
+	{Space: davNS, Local: "resourcetype"}:                        true,
+	{Space: davNS, Local: "getetag"}:                             true,
+	{Space: davNS, Local: "getcontenttype"}:                      true,
+	{Space: davNS, Local: "getcontentlength"}:                    true,
+	{Space: davNS, Local: "getlastmodified"}:                     true,
+	{Space: davNS, Local: "creationdate"}:                        true,
+	{Space: davNS, Local: "current-user-principal"}:              true,
+	{Space: davNS, Local: "current-user-privilege-set"}:          true,
+	{Space: davNS, Local: "owner"}:                               true,
+	{Space: davNS, Local: "principal-URL"}:                       true,
+	{Space: davNS, Local: "lockdiscovery"}:                       true,
+	{Space: davNS, Local: "supportedlock"}:                       true,
+	syncTokenName:                                                true,
+	supportedReportSetName:                                       true,
+	getCTagName:                                                  true,
+	{Space: caldavNS, Local: "calendar-home-set"}:                true,
+	{Space: caldavNS, Local: "supported-calendar-component-set"}: true,
+	{Space: caldavNS, Local: "supported-calendar-data"}:          true,
+	{Space: caldavNS, Local: "max-resource-size"}:                true,
+	{Space: caldavNS, Local: "calendar-data"}:                    true,
 }
 
-// multistatus is the root element of a 207 response
-type multistatus struct {
-	XMLName   xml.Name   `xml:"DAV: multistatus"`
-	Responses []response `xml:"response"`
+// calendarColorPattern accepts #RRGGBB and Apple's #RRGGBBAA.
+var calendarColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}([0-9A-Fa-f]{2})?$`)
+
+// propertyUpdate is the body of a PROPPATCH request. Set and remove
+// instructions are kept in document order (RFC 4918 §14.19).
+type propertyUpdate struct {
+	XMLName xml.Name            `xml:"DAV: propertyupdate"`
+	Ops     []propertyUpdateOps `xml:",any"`
 }
 
-type response struct {
-	Href     string   `xml:"href"`
-	PropStat propstat `xml:"propstat"`
+type propertyUpdateOps struct {
+	XMLName xml.Name
+	Prop    davProp `xml:"DAV: prop"`
 }
 
-type propstat struct {
-	Prop   prop   `xml:"prop"`
-	Status string `xml:"status"`
+// propertyChange is a single set or remove instruction.
+type propertyChange struct {
+	Remove bool
+	Value  davRawValue
 }
 
-type prop struct {
-	// We echo back whatever properties the client sent
-	// For simplicity, just mark them all as successful
+// PropPatchHandler handles PROPPATCH requests. Calendar display name,
+// description, time zone, Apple calendar-color and calendar-order are
+// persisted on the calendar; any other property is stored as a dead property of the resource.
+// Protected properties are rejected with 403 and, because PROPPATCH is atomic,
+// the remaining properties of a failed request are reported as 424.
+type PropPatchHandler struct {
+	backend *Backend
+}
+
+func NewPropPatchHandler(backend *Backend) *PropPatchHandler {
+	return &PropPatchHandler{backend: backend}
 }
 
 func (h *PropPatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -46,28 +95,265 @@ func (h *PropPatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.Debug("PROPPATCH request (no-op)", "path", r.URL.Path)
+	body, err := readXMLBody(r)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var changes []propertyChange
+	if len(bytes.TrimSpace(body)) > 0 {
+		var req propertyUpdate
+		if err := xml.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Malformed propertyupdate request", http.StatusBadRequest)
+			return
+		}
+		for _, op := range req.Ops {
+			if op.XMLName.Space != davNS || (op.XMLName.Local != "set" && op.XMLName.Local != "remove") {
+				continue
+			}
+			for _, value := range op.Prop.Values {
+				changes = append(changes, propertyChange{Remove: op.XMLName.Local == "remove", Value: value})
+			}
+		}
+	}
+
+	resp, err := h.backend.PatchProperties(r.Context(), r.URL.Path, changes)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	slog.Debug("caldav.proppatch", "properties", len(changes))
+	writeMultistatus(w, &davMultistatus{Responses: []davResponse{*resp}})
+}
+
+// PatchProperties applies PROPPATCH changes to the resource at urlPath and
+// returns the per-property result. Nothing is written unless every change
+// succeeds.
+func (b *Backend) PatchProperties(ctx context.Context, urlPath string, changes []propertyChange) (*davResponse, error) {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return nil, errNoAuthenticatedUser
+	}
+
+	resourcePath := davResourcePath(urlPath)
+	calName, uid := extractCalendarAndUID(urlPath)
+
+	var cal *domain.Calendar
+	access := accessOwner
+	if calName != "" {
+		var err error
+		cal, access, err = b.calendarByName(ctx, user, calName)
+		if err != nil {
+			return nil, err
+		}
+		if uid != "" {
+			if _, err := b.getCalendarObject(ctx, cal, uid, urlPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+	isCalendar := cal != nil && uid == ""
+
+	// Validate every change first; PROPPATCH is all-or-nothing.
+	statuses := make([]int, len(changes))
+	failed := false
+	for i, change := range changes {
+		statuses[i] = validatePropertyChange(change, isCalendar)
+		if statuses[i] == http.StatusOK && isCalendar && !access.canWrite() && isCalendarProperty(change.Value.XMLName) {
+			// Read-only grantees keep their own dead properties but cannot
+			// rename or recolor the owner's calendar
+			statuses[i] = http.StatusForbidden
+		}
+		if statuses[i] != http.StatusOK {
+			failed = true
+		}
+	}
+
+	resp := &davResponse{Hrefs: []string{urlPath}}
+	if failed {
+		for i, change := range changes {
+			code := statuses[i]
+			if code == http.StatusOK {
+				code = http.StatusFailedDependency
+			}
+			resp.addProp(code, davEmptyValue(change.Value.XMLName))
+		}
+		return resp, nil
+	}
+	if len(changes) == 0 {
+		return resp, nil
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
 
-	// Build 207 Multi-Status response
-	// We mark all property updates as successful even though we don't actually store them
-	resp := multistatus{
-		Responses: []response{
-			{
-				Href: r.URL.Path,
-				PropStat: propstat{
-					Prop:   prop{},
-					Status: "HTTP/1.1 200 OK",
-				},
-			},
-		},
+	deadPropertyRepoTx := b.deadPropertyRepo.WithTx(tx)
+	calendarChanged := false
+	for _, change := range changes {
+		name := change.Value.XMLName
+		if isCalendar && applyCalendarProperty(cal, change) {
+			calendarChanged = true
+			continue
+		}
+		if change.Remove {
+			err = deadPropertyRepoTx.Remove(ctx, user.ID, resourcePath, name.Space, name.Local)
+		} else {
+			err = deadPropertyRepoTx.Set(ctx, &domain.DeadProperty{
+				UserID:    user.ID,
+				Path:      resourcePath,
+				Namespace: name.Space,
+				Name:      name.Local,
+				Value:     string(change.Value.Inner),
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-	w.WriteHeader(http.StatusMultiStatus) // 207
+	var notes txNotifications
+	if calendarChanged {
+		calendarRepoTx := b.calendarRepo.WithTx(tx)
+		if err := calendarRepoTx.Update(ctx, cal); err != nil {
+			return nil, fmt.Errorf("failed to update calendar: %w", err)
+		}
+		// Bump the collection's sync token so clients polling getctag refetch properties
+		if _, err := calendarRepoTx.IncrementSyncToken(ctx, cal.ID); err != nil {
+			return nil, fmt.Errorf("failed to increment sync token: %w", err)
+		}
+		if err := b.notifyInTx(ctx, tx, &notes, &domain.Notification{Type: domain.NotificationCalendarUpdated, Calendar: cal}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	b.publish(ctx, notes)
+
+	for _, change := range changes {
+		resp.addProp(http.StatusOK, davEmptyValue(change.Value.XMLName))
+	}
+	slog.Info("caldav.proppatch.applied", "username", user.Username, "path", resourcePath, "properties", len(changes), "calendar_changed", calendarChanged)
+	return resp, nil
+}
+
+// validatePropertyChange returns the status a change would get: 200 when it
+// can be applied, 403 for protected properties and 409 for values we cannot
+// store.
+func validatePropertyChange(change propertyChange, isCalendar bool) int {
+	name := change.Value.XMLName
+	if protectedPropertyNames[name] {
+		return http.StatusForbidden
+	}
+	if !isCalendar || change.Remove {
+		return http.StatusOK
+	}
+	text := strings.TrimSpace(davValueText(change.Value))
+	switch name {
+	case calendarColorName:
+		if !calendarColorPattern.MatchString(text) {
+			return http.StatusConflict
+		}
+	case calendarOrderName:
+		if _, err := strconv.Atoi(text); err != nil {
+			return http.StatusConflict
+		}
+	case calendarTimezoneName:
+		if !validCalendarTimezone(text) {
+			return http.StatusConflict
+		}
+	}
+	return http.StatusOK
+}
+
+// applyCalendarProperty copies a live calendar property onto cal and reports
+// whether the property was one of them.
+func applyCalendarProperty(cal *domain.Calendar, change propertyChange) bool {
+	text := ""
+	if !change.Remove {
+		text = strings.TrimSpace(davValueText(change.Value))
+	}
+	switch change.Value.XMLName {
+	case displayNameName:
+		cal.DisplayName = text
+	case calendarDescriptionName:
+		cal.Description = text
+	case calendarColorName:
+		cal.Color = text
+	case calendarTimezoneName:
+		cal.Timezone = text
+	case calendarOrderName:
+		cal.Order = nil
+		if text != "" {
+			order, _ := strconv.Atoi(text)
+			cal.Order = &order
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// isCalendarProperty reports whether name is one of the live calendar
+// properties applyCalendarProperty stores on the calendar itself.
+func isCalendarProperty(name xml.Name) bool {
+	switch name {
+	case displayNameName, calendarDescriptionName, calendarColorName, calendarTimezoneName, calendarOrderName:
+		return true
+	}
+	return false
+}
+
+// DeadProperties returns the stored dead properties of the resource at urlPath.
+// The map is never nil so callers can merge other properties into it.
+func (b *Backend) DeadProperties(ctx context.Context, urlPath string) map[xml.Name]davRawValue {
+	values := make(map[xml.Name]davRawValue)
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return values
+	}
+	props, err := b.deadPropertyRepo.ListByPath(ctx, user.ID, davResourcePath(urlPath))
+	if err != nil {
+		slog.Warn("failed to load dead properties", "error", err)
+		return values
+	}
+	for _, p := range props {
+		name := xml.Name{Space: p.Namespace, Local: p.Name}
+		values[name] = davRawValue{XMLName: name, Inner: []byte(p.Value)}
+	}
+	return values
+}
+
+// davValueText returns the character data of a property value.
+func davValueText(v davRawValue) string {
+	var buf strings.Builder
+	dec := xml.NewDecoder(bytes.NewReader(v.Inner))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				// Fall back to the raw value for fragments the decoder rejects
+				return string(v.Inner)
+			}
+			return buf.String()
+		}
+		if data, ok := tok.(xml.CharData); ok {
+			buf.Write(data)
+		}
+	}
+}
 
-	enc := xml.NewEncoder(w)
-	enc.Indent("", "  ")
-	if err := enc.Encode(resp); err != nil {
-		slog.Error("Failed to encode PROPPATCH response", "error", err)
+// davResourcePath normalises a request path into the key dead properties are
+// stored under: collections always end in a slash, objects never do.
+func davResourcePath(urlPath string) string {
+	if strings.HasSuffix(urlPath, ".ics") {
+		return urlPath
 	}
+	return ensureTrailingSlash(urlPath)
 }
@@ -0,0 +1,166 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func proppatch(t *testing.T, url, body string) davMultistatus {
+	t.Helper()
+
+	status, data := doXMLRequest(t, "PROPPATCH", url, "", body)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("PROPPATCH: expected 207, got %d. Body: %s", status, string(data))
+	}
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		t.Fatalf("Failed to decode PROPPATCH response: %v", err)
+	}
+	if len(ms.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(ms.Responses))
+	}
+	return ms
+}
+
+// propStatuses maps each property local name to the status it was reported with.
+func propStatuses(resp davResponse) map[string]string {
+	statuses := map[string]string{}
+	for _, ps := range resp.PropStats {
+		for _, v := range ps.Prop.Values {
+			statuses[v.XMLName.Local] = ps.Status
+		}
+	}
+	return statuses
+}
+
+func TestCalDAV_PROPPATCH_PersistsCalendarProperties(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+	calURL := srv.URL + caldavBase + "/calendars/testuser/default/"
+
+	before, _ := calRepo.GetByName(ctx, 1, "default")
+
+	ms := proppatch(t, calURL, `<?xml version="1.0" encoding="utf-8"?>
+<d:propertyupdate xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/" xmlns:x="urn:example:client">
+  <d:set><d:prop>
+    <d:displayname>Work</d:displayname>
+    <c:calendar-description>Team meetings</c:calendar-description>
+    <a:calendar-color>#FF2968FF</a:calendar-color>
+    <a:calendar-order>4</a:calendar-order>
+    <x:pinned>yes</x:pinned>
+  </d:prop></d:set>
+</d:propertyupdate>`)
+	for name, status := range propStatuses(ms.Responses[0]) {
+		if status != "HTTP/1.1 200 OK" {
+			t.Errorf("%s: expected 200, got %q", name, status)
+		}
+	}
+
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	cal, err := calRepo.GetByName(ctx, user.ID, "default")
+	if err != nil {
+		t.Fatalf("Failed to get calendar: %v", err)
+	}
+	if cal.DisplayName != "Work" || cal.Description != "Team meetings" || cal.Color != "#FF2968FF" {
+		t.Errorf("Calendar properties not persisted: %+v", cal)
+	}
+	if cal.Order == nil || *cal.Order != 4 {
+		t.Errorf("calendar-order not persisted: %v", cal.Order)
+	}
+	if cal.SyncToken == before.SyncToken {
+		t.Error("Sync token should advance after a property change")
+	}
+
+	status, data := doXMLRequest(t, "PROPFIND", calURL, "0", `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:a="http://apple.com/ns/ical/" xmlns:x="urn:example:client">
+  <d:prop><d:displayname/><a:calendar-color/><a:calendar-order/><x:pinned/><x:unset/></d:prop>
+</d:propfind>`)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND: expected 207, got %d. Body: %s", status, string(data))
+	}
+	var found davMultistatus
+	if err := xml.Unmarshal(data, &found); err != nil {
+		t.Fatalf("Failed to decode PROPFIND response: %v", err)
+	}
+	statuses := propStatuses(found.Responses[0])
+	for _, name := range []string{"displayname", "calendar-color", "calendar-order", "pinned"} {
+		if statuses[name] != "HTTP/1.1 200 OK" {
+			t.Errorf("PROPFIND %s: expected 200, got %q", name, statuses[name])
+		}
+	}
+	if statuses["unset"] != "HTTP/1.1 404 Not Found" {
+		t.Errorf("PROPFIND unset: expected 404, got %q", statuses["unset"])
+	}
+	for _, want := range []string{"Work", "#FF2968FF", ">4<", ">yes<"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %q in PROPFIND response: %s", want, string(data))
+		}
+	}
+
+	// Removing properties clears them
+	proppatch(t, calURL, `<?xml version="1.0" encoding="utf-8"?>
+<d:propertyupdate xmlns:d="DAV:" xmlns:a="http://apple.com/ns/ical/" xmlns:x="urn:example:client">
+  <d:remove><d:prop><a:calendar-order/><x:pinned/></d:prop></d:remove>
+</d:propertyupdate>`)
+	cal, _ = calRepo.GetByName(ctx, user.ID, "default")
+	if cal.Order != nil {
+		t.Errorf("calendar-order should be cleared, got %d", *cal.Order)
+	}
+	_, data = doXMLRequest(t, "PROPFIND", calURL, "0", `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:x="urn:example:client"><d:prop><x:pinned/></d:prop></d:propfind>`)
+	if strings.Contains(string(data), ">yes<") {
+		t.Errorf("Removed dead property still served: %s", string(data))
+	}
+}
+
+func TestCalDAV_PROPPATCH_ProtectedPropertyFailsAtomically(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+
+	ms := proppatch(t, srv.URL+caldavBase+"/calendars/testuser/default/", `<?xml version="1.0" encoding="utf-8"?>
+<d:propertyupdate xmlns:d="DAV:">
+  <d:set><d:prop>
+    <d:displayname>Renamed</d:displayname>
+    <d:getetag>"forged"</d:getetag>
+  </d:prop></d:set>
+</d:propertyupdate>`)
+
+	statuses := propStatuses(ms.Responses[0])
+	if statuses["getetag"] != "HTTP/1.1 403 Forbidden" {
+		t.Errorf("getetag: expected 403, got %q", statuses["getetag"])
+	}
+	if statuses["displayname"] != "HTTP/1.1 424 Failed Dependency" {
+		t.Errorf("displayname: expected 424, got %q", statuses["displayname"])
+	}
+
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	cal, _ := calRepo.GetByName(ctx, user.ID, "default")
+	if cal.DisplayName != "Calendar" {
+		t.Errorf("Failed PROPPATCH must not persist anything, display name is %q", cal.DisplayName)
+	}
+}
+
+func TestCalDAV_PROPPATCH_InvalidColor(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	ms := proppatch(t, srv.URL+caldavBase+"/calendars/testuser/default/", `<?xml version="1.0" encoding="utf-8"?>
+<d:propertyupdate xmlns:d="DAV:" xmlns:a="http://apple.com/ns/ical/">
+  <d:set><d:prop><a:calendar-color>red</a:calendar-color></d:prop></d:set>
+</d:propertyupdate>`)
+	if got := propStatuses(ms.Responses[0])["calendar-color"]; got != "HTTP/1.1 409 Conflict" {
+		t.Errorf("Expected 409 for an invalid color, got %q", got)
+	}
+}
+
+func TestCalDAV_PROPPATCH_UnknownCalendar(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	status, _ := doXMLRequest(t, "PROPPATCH", srv.URL+caldavBase+"/calendars/testuser/missing/", "", `<?xml version="1.0" encoding="utf-8"?>
+<d:propertyupdate xmlns:d="DAV:"><d:set><d:prop><d:displayname>X</d:displayname></d:prop></d:set></d:propertyupdate>`)
+	if status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing calendar, got %d", status)
+	}
+}
@@ -0,0 +1,393 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// Resource names of the schedule inbox and outbox in a calendar home.
+const (
+	scheduleInboxSegment  = "inbox"
+	scheduleOutboxSegment = "outbox"
+)
+
+var (
+	scheduleInboxName          = xml.Name{Space: caldavNS, Local: "schedule-inbox"}
+	scheduleOutboxName         = xml.Name{Space: caldavNS, Local: "schedule-outbox"}
+	scheduleInboxURLName       = xml.Name{Space: caldavNS, Local: "schedule-inbox-URL"}
+	scheduleOutboxURLName      = xml.Name{Space: caldavNS, Local: "schedule-outbox-URL"}
+	calendarUserAddressSetName = xml.Name{Space: caldavNS, Local: "calendar-user-address-set"}
+	calendarHomeSetName        = xml.Name{Space: caldavNS, Local: "calendar-home-set"}
+	currentUserPrincipalName   = xml.Name{Space: davNS, Local: "current-user-principal"}
+	scheduleTagName            = xml.Name{Space: caldavNS, Local: "schedule-tag"}
+	validSchedulingMessageName = xml.Name{Space: caldavNS, Local: "valid-scheduling-message"}
+	organizerAllowedName       = xml.Name{Space: caldavNS, Local: "organizer-allowed"}
+	getContentLengthName       = xml.Name{Space: davNS, Local: "getcontentlength"}
+)
+
+// scheduleCollectionOf returns the schedule collection ("inbox" or "outbox")
+// of a /calendars/{user}/... path and the name of the item in it, or "" when
+// the path is not in one.
+func scheduleCollectionOf(urlPath string) (collection, item string) {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i, part := range parts {
+		if part != "calendars" || i+2 >= len(parts) {
+			continue
+		}
+		if parts[i+2] != scheduleInboxSegment && parts[i+2] != scheduleOutboxSegment {
+			return "", ""
+		}
+		if i+3 < len(parts) {
+			item = parts[i+3]
+		}
+		return parts[i+2], item
+	}
+	return "", ""
+}
+
+// scheduleCollectionPath returns the URL of a user's schedule inbox or outbox.
+func scheduleCollectionPath(username, collection string) string {
+	return fmt.Sprintf("/dav/calendars/%s/%s/", username, collection)
+}
+
+// ScheduleCollectionHandler serves the schedule inbox and outbox of the
+// user's calendar home (RFC 6638 §2.1-2.2), which go-webdav does not know
+// about. Inbox messages are listed with PROPFIND, read with GET and removed
+// with DELETE; the server delivers them, so clients cannot write them. POST to
+// the outbox answers free-busy requests for local users (RFC 6638 §5).
+type ScheduleCollectionHandler struct {
+	backend  *Backend
+	freeBusy *services.FreeBusyService
+}
+
+func NewScheduleCollectionHandler(backend *Backend, freeBusy *services.FreeBusyService) *ScheduleCollectionHandler {
+	return &ScheduleCollectionHandler{backend: backend, freeBusy: freeBusy}
+}
+
+func (h *ScheduleCollectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+	if user == nil {
+		writeBackendError(w, errNoAuthenticatedUser)
+		return
+	}
+	collection, item := scheduleCollectionOf(r.URL.Path)
+
+	allow := "OPTIONS, PROPFIND"
+	switch {
+	case collection == scheduleInboxSegment && item != "":
+		allow = "OPTIONS, PROPFIND, GET, HEAD, DELETE"
+	case collection == scheduleOutboxSegment && item == "":
+		allow = "OPTIONS, PROPFIND, POST"
+	}
+
+	switch {
+	case r.Method == http.MethodOptions:
+		w.Header().Set("Allow", allow)
+		w.Header().Add("DAV", "1, 3, calendar-access, calendar-auto-schedule")
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "PROPFIND" && (item == "" || collection == scheduleInboxSegment):
+		h.propfind(w, r, user, collection, item)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && collection == scheduleInboxSegment && item != "":
+		h.getMessage(w, r, user, item)
+	case r.Method == http.MethodDelete && collection == scheduleInboxSegment && item != "":
+		h.deleteMessage(w, r, user, item)
+	case r.Method == http.MethodPost && collection == scheduleOutboxSegment && item == "":
+		h.postFreeBusyRequest(w, r, user)
+	default:
+		if collection == scheduleOutboxSegment && item != "" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Allow", allow)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ScheduleCollectionHandler) propfind(w http.ResponseWriter, r *http.Request, user *domain.User, collection, item string) {
+	var req propfindRequest
+	body, err := readXMLBody(r)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := xml.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Malformed PROPFIND request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	collectionPath := scheduleCollectionPath(user.Username, collection)
+	var ms davMultistatus
+	if item != "" {
+		msg, err := h.backend.inboxRepo.Get(r.Context(), user.ID, item)
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		ms.Responses = append(ms.Responses, propfindResponse(collectionPath+item, scheduleMessageProperties(msg), req.Prop))
+		writeMultistatus(w, &ms)
+		return
+	}
+
+	var messages []*domain.ScheduleMessage
+	if collection == scheduleInboxSegment {
+		if messages, err = h.backend.inboxRepo.ListByUser(r.Context(), user.ID); err != nil {
+			writeBackendError(w, err)
+			return
+		}
+	}
+	ms.Responses = append(ms.Responses, propfindResponse(collectionPath, scheduleCollectionProperties(collection, messages), req.Prop))
+	if r.Header.Get("Depth") != "0" {
+		for _, msg := range messages {
+			ms.Responses = append(ms.Responses, propfindResponse(collectionPath+msg.Name, scheduleMessageProperties(msg), req.Prop))
+		}
+	}
+	writeMultistatus(w, &ms)
+}
+
+func (h *ScheduleCollectionHandler) getMessage(w http.ResponseWriter, r *http.Request, user *domain.User, name string) {
+	msg, err := h.backend.inboxRepo.Get(r.Context(), user.ID, name)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("ETag", msg.ETag)
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		io.WriteString(w, msg.ICS)
+	}
+}
+
+func (h *ScheduleCollectionHandler) deleteMessage(w http.ResponseWriter, r *http.Request, user *domain.User, name string) {
+	msg, err := h.backend.inboxRepo.Get(r.Context(), user.ID, name)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+	if pre := preconditionsFromContext(r.Context()); !pre.check(r.Context(), msg.ETag, msg.ETag) {
+		http.Error(w, "Precondition Failed", http.StatusPreconditionFailed)
+		return
+	}
+	if err := h.backend.inboxRepo.Delete(r.Context(), user.ID, name); err != nil {
+		writeBackendError(w, err)
+		return
+	}
+	slog.Info("caldav.schedule.inbox_item_deleted", "username", user.Username, "inbox_item", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scheduleResponse is the body of a POST to the outbox (RFC 6638 §10.1).
+type scheduleResponse struct {
+	XMLName   xml.Name                    `xml:"urn:ietf:params:xml:ns:caldav schedule-response"`
+	Responses []scheduleRecipientResponse `xml:"urn:ietf:params:xml:ns:caldav response"`
+}
+
+type scheduleRecipientResponse struct {
+	Recipient     davHref `xml:"urn:ietf:params:xml:ns:caldav recipient"`
+	RequestStatus string  `xml:"urn:ietf:params:xml:ns:caldav request-status"`
+	CalendarData  string  `xml:"urn:ietf:params:xml:ns:caldav calendar-data,omitempty"`
+}
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+// postFreeBusyRequest answers a VFREEBUSY REQUEST posted to the outbox with
+// the busy time of each local attendee. The organizer must be the current
+// user; other calendar users cannot be queried.
+func (h *ScheduleCollectionHandler) postFreeBusyRequest(w http.ResponseWriter, r *http.Request, user *domain.User) {
+	cal, err := ical.NewDecoder(r.Body).Decode()
+	if err != nil {
+		writeDAVError(w, http.StatusBadRequest, validSchedulingMessageName)
+		return
+	}
+	var fb *ical.Component
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompFreeBusy {
+			fb = comp
+			break
+		}
+	}
+	method := cal.Props.Get(ical.PropMethod)
+	if fb == nil || method == nil || !strings.EqualFold(method.Value, domain.ITIPRequest) {
+		writeDAVError(w, http.StatusBadRequest, validSchedulingMessageName)
+		return
+	}
+	start, end := componentTimes(fb)
+	if start.IsZero() || !end.After(start) {
+		writeDAVError(w, http.StatusBadRequest, validSchedulingMessageName)
+		return
+	}
+	organizer := fb.Props.Get(ical.PropOrganizer)
+	if organizer == nil || !addressIn(organizer.Value, calendarUserAddresses(user)) {
+		writeDAVError(w, http.StatusForbidden, organizerAllowedName)
+		return
+	}
+
+	var resp scheduleResponse
+	for _, attendee := range fb.Props.Values(ical.PropAttendee) {
+		result := scheduleRecipientResponse{Recipient: davHref{Href: attendee.Value}}
+		data, err := h.freeBusyReply(r.Context(), organizer, &attendee, start, end)
+		switch {
+		case err == domain.ErrNotFound:
+			result.RequestStatus = "3.7;Invalid calendar user"
+		case err != nil:
+			writeBackendError(w, err)
+			return
+		default:
+			result.RequestStatus = "2.0;Success"
+			result.CalendarData = data
+		}
+		resp.Responses = append(resp.Responses, result)
+	}
+
+	slog.Debug("caldav.schedule.freebusy_request", "username", user.Username, "recipients", len(resp.Responses))
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Failed to encode schedule-response", "error", err)
+	}
+}
+
+// freeBusyReply returns the VFREEBUSY REPLY of a local attendee, or
+// domain.ErrNotFound when the attendee is not a local user.
+func (h *ScheduleCollectionHandler) freeBusyReply(ctx context.Context, organizer, attendee *ical.Prop, start, end time.Time) (string, error) {
+	recipient, err := h.backend.localUserByAddress(ctx, attendee.Value)
+	if err != nil {
+		return "", err
+	}
+	periods, err := h.freeBusy.BusyPeriods(ctx, recipient.ID, nil, start, end)
+	if err != nil {
+		return "", err
+	}
+	reply := freeBusyCalendar(recipient, start, end, periods)
+	reply.Props.SetText(ical.PropMethod, domain.ITIPReply)
+	for _, comp := range reply.Children {
+		comp.Props.Set(copyProp(organizer))
+		comp.Props.Set(copyProp(attendee))
+	}
+	data, err := encodeICalendar(reply)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// scheduleCollectionProperties returns the properties of a schedule inbox or
+// outbox. The inbox getctag changes whenever a message arrives or is removed.
+func scheduleCollectionProperties(collection string, messages []*domain.ScheduleMessage) map[xml.Name]davRawValue {
+	kind, displayName := scheduleInboxName, "Inbox"
+	privileges := []xml.Name{{Space: davNS, Local: "read"}, {Space: davNS, Local: "read-current-user-privilege-set"}, {Space: davNS, Local: "unbind"}}
+	if collection == scheduleOutboxSegment {
+		kind, displayName = scheduleOutboxName, "Outbox"
+		privileges = []xml.Name{{Space: davNS, Local: "read"}, {Space: davNS, Local: "read-current-user-privilege-set"}, {Space: caldavNS, Local: "schedule-send"}}
+	}
+
+	var inner []byte
+	for _, p := range privileges {
+		inner = append(inner, `<privilege xmlns="DAV:"><`+p.Local+` xmlns="`+p.Space+`"/></privilege>`...)
+	}
+	var lastID int64
+	for _, msg := range messages {
+		lastID = max(lastID, msg.ID)
+	}
+	return map[xml.Name]davRawValue{
+		resourceTypeName:            {XMLName: resourceTypeName, Inner: []byte(`<collection xmlns="DAV:"/><` + kind.Local + ` xmlns="` + kind.Space + `"/>`)},
+		displayNameName:             davTextValue(displayNameName, displayName),
+		currentUserPrivilegeSetName: {XMLName: currentUserPrivilegeSetName, Inner: inner},
+		getCTagName:                 davTextValue(getCTagName, fmt.Sprintf("%d-%d", len(messages), lastID)),
+	}
+}
+
+// scheduleMessageProperties returns the properties of an inbox message.
+func scheduleMessageProperties(msg *domain.ScheduleMessage) map[xml.Name]davRawValue {
+	return map[xml.Name]davRawValue{
+		resourceTypeName:     davEmptyValue(resourceTypeName),
+		getETagName:          davTextValue(getETagName, msg.ETag),
+		getContentTypeName:   davTextValue(getContentTypeName, "text/calendar; charset=utf-8; component=VEVENT"),
+		getContentLengthName: davTextValue(getContentLengthName, fmt.Sprint(len(msg.ICS))),
+	}
+}
+
+// propfindResponse answers a PROPFIND for one resource: the requested
+// properties it has with 200 and the others with 404, or every property when
+// requested is nil (allprop or an empty body).
+func propfindResponse(href string, available map[xml.Name]davRawValue, requested *davProp) davResponse {
+	resp := davResponse{Hrefs: []string{href}}
+	if requested == nil {
+		for _, value := range available {
+			resp.addProp(http.StatusOK, value)
+		}
+		return resp
+	}
+	for _, name := range requested.Names() {
+		if value, ok := available[name]; ok {
+			resp.addProp(http.StatusOK, value)
+		} else {
+			resp.addProp(http.StatusNotFound, davEmptyValue(name))
+		}
+	}
+	return resp
+}
+
+// SchedulingProperties returns the scheduling properties of the resource at
+// urlPath: the calendar home, schedule inbox and outbox URLs and calendar user
+// addresses of the current user's principal (RFC 6638 §2.1-2.4.1), or the
+// Schedule-Tag of a calendar object (RFC 6638 §3.2.10).
+func (b *Backend) SchedulingProperties(ctx context.Context, urlPath string) map[xml.Name]davRawValue {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return nil
+	}
+	if strings.TrimSuffix(urlPath, "/")+"/" == principalPath(user.Username) {
+		var addresses []byte
+		for _, address := range calendarUserAddresses(user) {
+			addresses = append(addresses, davHrefXML(address)...)
+		}
+		return map[xml.Name]davRawValue{
+			calendarHomeSetName:        {XMLName: calendarHomeSetName, Inner: davHrefXML(fmt.Sprintf("/dav/calendars/%s/", user.Username))},
+			currentUserPrincipalName:   {XMLName: currentUserPrincipalName, Inner: davHrefXML(principalPath(user.Username))},
+			calendarUserAddressSetName: {XMLName: calendarUserAddressSetName, Inner: addresses},
+			scheduleInboxURLName:       {XMLName: scheduleInboxURLName, Inner: davHrefXML(scheduleCollectionPath(user.Username, scheduleInboxSegment))},
+			scheduleOutboxURLName:      {XMLName: scheduleOutboxURLName, Inner: davHrefXML(scheduleCollectionPath(user.Username, scheduleOutboxSegment))},
+		}
+	}
+
+	calName, uid := extractCalendarAndUID(urlPath)
+	if calName == "" || uid == "" {
+		return nil
+	}
+	cal, _, err := b.calendarByName(ctx, user, calName)
+	if err != nil {
+		return nil
+	}
+	event, err := b.eventRepo.GetByUID(ctx, cal.ID, uid)
+	if err != nil {
+		return nil
+	}
+	return map[xml.Name]davRawValue{scheduleTagName: davTextValue(scheduleTagName, event.ScheduleTag)}
+}
+
+// davHrefXML renders a DAV:href element with escaped content.
+func davHrefXML(href string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<href xmlns="DAV:">`)
+	_ = xml.EscapeText(&buf, []byte(href))
+	buf.WriteString(`</href>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,476 @@
+package caldav
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// Implicit scheduling (RFC 6638 §3.2). When a user stores or deletes a
+// meeting, the messages it causes are delivered to local users in the same
+// transaction: each lands in the recipient's schedule inbox, REQUEST and
+// CANCEL from the organizer update the recipient's copy of the meeting, and
+// REPLY updates the attendee's PARTSTAT in the organizer's copy. Messages for other calendar
+// users go to the Backend's ScheduleDelivery after the transaction commits.
+
+// principalPath returns the principal URL of a user, which is also one of
+// their calendar user addresses.
+func principalPath(username string) string {
+	return fmt.Sprintf("/dav/principals/%s/", username)
+}
+
+// calendarUserAddresses returns the addresses a user is known by in ORGANIZER
+// and ATTENDEE properties: their email as a mailto: address, when set, and
+// their principal URL.
+func calendarUserAddresses(user *domain.User) []string {
+	addresses := []string{principalPath(user.Username)}
+	if user.Email != "" {
+		addresses = append([]string{domain.MailtoAddress(user.Email)}, addresses...)
+	}
+	return addresses
+}
+
+// localUserByAddress returns the user with a calendar user address, or
+// domain.ErrNotFound for addresses outside this server.
+func (b *Backend) localUserByAddress(ctx context.Context, address string) (*domain.User, error) {
+	if email, ok := domain.AddressEmail(address); ok {
+		return b.userRepo.GetByEmail(ctx, email)
+	}
+	if u, err := url.Parse(address); err == nil && strings.Contains(u.Path, "/principals/") {
+		if username := pathOwner(u.Path); username != "" {
+			return b.userRepo.GetByUsername(ctx, username)
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// calendarOwner returns the owner of cal, which is user unless cal was shared
+// with them. Scheduling acts for the owner: a delegate editing a shared
+// calendar sends invitations in the owner's name.
+func (b *Backend) calendarOwner(ctx context.Context, user *domain.User, cal *domain.Calendar) (*domain.User, error) {
+	if cal.UserID == user.ID {
+		return user, nil
+	}
+	return b.userRepo.GetByID(ctx, cal.UserID)
+}
+
+// putSchedulingMessages returns the messages caused by owner storing updated
+// in place of previous (nil for new objects), and whether the change is an
+// attendee answering, which keeps the object's Schedule-Tag (RFC 6638
+// §3.2.10).
+func putSchedulingMessages(owner *domain.User, previous, updated *ical.Calendar) (messages []itipMessage, keepScheduleTag bool) {
+	addresses := calendarUserAddresses(owner)
+	role, address := schedulingRoleOf(updated, addresses)
+	switch role {
+	case roleOrganizer:
+		if previous != nil {
+			if previousRole, _ := schedulingRoleOf(previous, addresses); previousRole != roleOrganizer {
+				previous = nil
+			}
+		}
+		return organizerMessages(address, previous, updated), false
+	case roleAttendee:
+		if msg := replyMessage(address, previous, updated); msg != nil {
+			messages = append(messages, *msg)
+		}
+		return messages, previous != nil
+	}
+	return nil, false
+}
+
+// deleteSchedulingMessage returns the message caused by owner deleting a
+// meeting: CANCEL from the organizer, or a declining REPLY from an attendee.
+func deleteSchedulingMessage(owner *domain.User, cal *ical.Calendar) *itipMessage {
+	if eventCancelled(cal) {
+		return nil
+	}
+	role, address := schedulingRoleOf(cal, calendarUserAddresses(owner))
+	switch role {
+	case roleOrganizer:
+		return cancelMessage(address, cal)
+	case roleAttendee:
+		return declineMessage(address, cal)
+	}
+	return nil
+}
+
+// deliverInTx delivers messages sent by sender to local recipients within tx
+// and returns the messages for everyone else, to be handed to
+// deliverOutbound once tx has committed. sender is nil for messages from
+// outside this server. Changes to the recipients' calendars are added to
+// notes.
+func (b *Backend) deliverInTx(ctx context.Context, tx *sql.Tx, sender *domain.User, messages []itipMessage, notes *txNotifications) ([]*domain.ITIPMessage, error) {
+	var outbound []*domain.ITIPMessage
+	for _, msg := range messages {
+		icsBytes, err := encodeICalendar(msg.Calendar)
+		if err != nil {
+			return nil, err
+		}
+		remote := &domain.ITIPMessage{Method: msg.Method, UID: msg.UID, Originator: msg.Originator, ICS: string(icsBytes)}
+		for _, address := range msg.Recipients {
+			recipient, err := b.localUserByAddress(ctx, address)
+			if err == domain.ErrNotFound {
+				remote.Recipients = append(remote.Recipients, address)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if sender != nil && recipient.ID == sender.ID {
+				continue
+			}
+			if err := b.deliverLocalInTx(ctx, tx, recipient, msg, string(icsBytes), notes); err != nil {
+				return nil, err
+			}
+		}
+		if len(remote.Recipients) > 0 {
+			outbound = append(outbound, remote)
+		}
+	}
+	return outbound, nil
+}
+
+// deliverLocalInTx puts a message in a local user's schedule inbox and
+// applies it to their copy of the meeting. A REQUEST or CANCEL is applied
+// only when it comes from the organizer of that copy and names the recipient
+// as an attendee; a first REQUEST adds the meeting to the recipient's default
+// calendar with their PARTSTAT at NEEDS-ACTION. A REPLY is applied only to a
+// meeting the recipient organizes. Anything else stays in the inbox.
+func (b *Backend) deliverLocalInTx(ctx context.Context, tx *sql.Tx, recipient *domain.User, msg itipMessage, ics string, notes *txNotifications) error {
+	name, err := scheduleMessageName()
+	if err != nil {
+		return err
+	}
+	inboxMsg := &domain.ScheduleMessage{
+		UserID: recipient.ID,
+		Name:   name,
+		UID:    msg.UID,
+		Method: msg.Method,
+		ICS:    ics,
+		ETag:   domain.GenerateETag([]byte(ics)),
+	}
+	if err := b.inboxRepo.WithTx(tx).Create(ctx, inboxMsg); err != nil {
+		return err
+	}
+
+	cal, existing, err := b.findEventCopyInTx(ctx, tx, recipient, msg.UID)
+	if err != nil && err != domain.ErrNotFound {
+		return err
+	}
+	addresses := calendarUserAddresses(recipient)
+
+	switch msg.Method {
+	case domain.ITIPRequest, domain.ITIPCancel:
+		// UIDs are visible to every invitee and anyone can reuse one: only the
+		// organizer of the recipient's copy may update it
+		var previous *ical.Calendar
+		if existing != nil {
+			if previous, err = parseICalendar(existing.ICS); err != nil || !organizedBy(previous, msg.Originator, addresses) {
+				slog.Warn("caldav.schedule.not_applied", "method", msg.Method, "uid", msg.UID, "recipient", recipient.Username, "reason", "copy not organized by originator")
+				break
+			}
+		}
+		if !organizedBy(msg.Calendar, msg.Originator, addresses) {
+			slog.Warn("caldav.schedule.not_applied", "method", msg.Method, "uid", msg.UID, "recipient", recipient.Username, "reason", "recipient is not an attendee")
+			break
+		}
+		if existing == nil {
+			if msg.Method == domain.ITIPCancel {
+				break
+			}
+			if cal, err = b.defaultCalendarInTx(ctx, tx, recipient); err == domain.ErrNotFound {
+				break
+			} else if err != nil {
+				return err
+			}
+		}
+		data := calendarObjectFromMessage(msg.Calendar)
+		if previous != nil {
+			keepAlarms(previous, data)
+		} else {
+			awaitAnswer(data, addresses)
+		}
+		if err := b.writeEventInTx(ctx, tx, cal, existing, msg.UID, data, false, notes); err != nil {
+			return err
+		}
+	case domain.ITIPReply:
+		if existing == nil {
+			break
+		}
+		data, err := parseICalendar(existing.ICS)
+		if err != nil {
+			return fmt.Errorf("failed to parse organizer's copy of %s: %w", msg.UID, err)
+		}
+		// Only the recipient's own meetings take replies
+		if role, _ := schedulingRoleOf(data, addresses); role != roleOrganizer {
+			slog.Warn("caldav.schedule.not_applied", "method", msg.Method, "uid", msg.UID, "recipient", recipient.Username, "reason", "recipient is not the organizer")
+			break
+		}
+		if applyReply(data, msg.Calendar, msg.Originator) {
+			if err := b.writeEventInTx(ctx, tx, cal, existing, msg.UID, data, true, notes); err != nil {
+				return err
+			}
+		}
+	}
+
+	slog.Info("caldav.schedule.delivered", "method", msg.Method, "uid", msg.UID, "recipient", recipient.Username, "inbox_item", name)
+	return nil
+}
+
+// deliverOutbound hands messages for calendar users outside this server to the
+// ScheduleDelivery. Failures are logged: the change that caused them is
+// already stored.
+func (b *Backend) deliverOutbound(ctx context.Context, messages []*domain.ITIPMessage) {
+	for _, msg := range messages {
+		if b.delivery == nil {
+			slog.Warn("caldav.schedule.undeliverable", "method", msg.Method, "uid", msg.UID, "recipients", len(msg.Recipients))
+			continue
+		}
+		if err := b.delivery.Deliver(ctx, msg); err != nil {
+			slog.Error("caldav.schedule.outbound_failed", "method", msg.Method, "uid", msg.UID, "recipients", len(msg.Recipients), "error", err)
+			continue
+		}
+		slog.Info("caldav.schedule.sent", "method", msg.Method, "uid", msg.UID, "recipients", len(msg.Recipients))
+	}
+}
+
+// ReceiveITIP delivers a reply from a calendar user outside this server to
+// its local recipients, updating the organizer's copy of the meeting. Only
+// replies are accepted: anyone can send email, and invitations from outside
+// would add events to local calendars. Recipients outside this server are
+// dropped rather than relayed. It implements domain.ScheduleInbound.
+func (b *Backend) ReceiveITIP(ctx context.Context, msg *domain.ITIPMessage) error {
+	if msg.Method != domain.ITIPReply {
+		return domain.ErrUnsupportedITIPMethod
+	}
+	cal, err := parseICalendar(msg.ICS)
+	if err != nil {
+		return fmt.Errorf("invalid iTIP message: %w", err)
+	}
+
+	var local []string
+	for _, address := range msg.Recipients {
+		if _, err := b.localUserByAddress(ctx, address); err == nil {
+			local = append(local, address)
+		} else if err != domain.ErrNotFound {
+			return err
+		}
+	}
+	if len(local) == 0 {
+		return domain.ErrNotFound
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	message := itipMessage{Method: msg.Method, UID: msg.UID, Originator: msg.Originator, Recipients: local, Calendar: cal}
+	var notes txNotifications
+	if _, err := b.deliverInTx(ctx, tx, nil, []itipMessage{message}, &notes); err != nil {
+		return fmt.Errorf("failed to deliver scheduling message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	slog.Info("caldav.schedule.received", "method", msg.Method, "uid", msg.UID, "recipients", len(local))
+	b.publish(ctx, notes)
+	return nil
+}
+
+// findEventCopyInTx returns the calendar and event of user's copy of the
+// meeting with the given UID, or domain.ErrNotFound.
+func (b *Backend) findEventCopyInTx(ctx context.Context, tx *sql.Tx, user *domain.User, uid string) (*domain.Calendar, *domain.Event, error) {
+	cals, err := b.calendarRepo.WithTx(tx).ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, cal := range cals {
+		if !cal.SupportsComponent(domain.ComponentEvent) {
+			continue
+		}
+		event, err := b.eventRepo.WithTx(tx).GetByUID(ctx, cal.ID, uid)
+		if err == nil {
+			return cal, event, nil
+		}
+		if err != domain.ErrNotFound {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, domain.ErrNotFound
+}
+
+// defaultCalendarInTx returns the calendar invitations are added to: the
+// user's default calendar, or their first calendar that holds events.
+func (b *Backend) defaultCalendarInTx(ctx context.Context, tx *sql.Tx, user *domain.User) (*domain.Calendar, error) {
+	cals, err := b.calendarRepo.WithTx(tx).ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	var first *domain.Calendar
+	for _, cal := range cals {
+		if !cal.SupportsComponent(domain.ComponentEvent) {
+			continue
+		}
+		if cal.Name == domain.DefaultCalendarName {
+			return cal, nil
+		}
+		if first == nil {
+			first = cal
+		}
+	}
+	if first == nil {
+		return nil, domain.ErrNotFound
+	}
+	return first, nil
+}
+
+// writeEventInTx stores data as the event with the given UID in cal, creating
+// it when existing is nil, records the change and adds it to notes.
+// keepScheduleTag leaves the Schedule-Tag of an existing event unchanged.
+func (b *Backend) writeEventInTx(ctx context.Context, tx *sql.Tx, cal *domain.Calendar, existing *domain.Event, uid string, data *ical.Calendar, keepScheduleTag bool, notes *txNotifications) error {
+	icsBytes, err := encodeICalendar(data)
+	if err != nil {
+		return err
+	}
+	overrides, err := extractEventOverrides(data, uid)
+	if err != nil {
+		return err
+	}
+	summary, dtStart, dtEnd, rrule, rdates, sequence := extractEventMetadata(data)
+	status, allDay := extractEventState(data)
+	etag := domain.GenerateETag(icsBytes)
+
+	if existing == nil {
+		event := &domain.Event{
+			CalendarID:      cal.ID,
+			UID:             uid,
+			ICS:             string(icsBytes),
+			Summary:         summary,
+			StartTime:       dtStart,
+			EndTime:         dtEnd,
+			RecurrenceRule:  rrule,
+			RecurrenceDates: rdates,
+			ETag:            etag,
+			AllDay:          allDay,
+			Sequence:        sequence,
+			Status:          status,
+			Overrides:       overrides,
+			Alarms:          extractEventAlarms(data),
+		}
+		if err := b.eventRepo.WithTx(tx).Create(ctx, event); err != nil {
+			return fmt.Errorf("failed to create event: %w", err)
+		}
+		if _, err := b.calendarRepo.WithTx(tx).RecordChange(ctx, cal.ID, uid, domain.CalendarChangeCreated); err != nil {
+			return err
+		}
+		return b.notifyEventInTx(ctx, tx, notes, cal, uid, domain.CalendarChangeCreated, event)
+	}
+
+	oldETag := existing.ETag
+	existing.ICS = string(icsBytes)
+	existing.Summary = summary
+	existing.StartTime = dtStart
+	existing.EndTime = dtEnd
+	existing.RecurrenceRule = rrule
+	existing.RecurrenceDates = rdates
+	existing.AllDay = allDay
+	existing.ETag = etag
+	existing.Sequence = sequence
+	existing.Status = status
+	existing.Overrides = overrides
+	existing.Alarms = extractEventAlarms(data)
+	if !keepScheduleTag {
+		existing.ScheduleTag = etag
+	}
+	if err := b.eventRepo.WithTx(tx).Update(ctx, existing, oldETag); err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	if _, err := b.calendarRepo.WithTx(tx).RecordChange(ctx, cal.ID, uid, domain.CalendarChangeUpdated); err != nil {
+		return err
+	}
+	return b.notifyEventInTx(ctx, tx, notes, cal, uid, domain.CalendarChangeUpdated, existing)
+}
+
+// calendarObjectFromMessage returns the calendar object stored for an iTIP
+// message: the same data without METHOD (RFC 4791 §4.1).
+func calendarObjectFromMessage(msg *ical.Calendar) *ical.Calendar {
+	cal := ical.NewCalendar()
+	for name, props := range msg.Props {
+		if name == ical.PropMethod {
+			continue
+		}
+		for i := range props {
+			cal.Props.Add(copyProp(&props[i]))
+		}
+	}
+	for _, comp := range msg.Children {
+		cal.Children = append(cal.Children, copyComponent(comp))
+	}
+	return cal
+}
+
+// keepAlarms copies the alarms of a recipient's copy into the new version of
+// each instance the organizer sent, since alarms are not shared.
+func keepAlarms(previous, updated *ical.Calendar) {
+	for _, comp := range updated.Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		old := matchingInstance(previous, comp)
+		if old == nil {
+			continue
+		}
+		for _, child := range old.Children {
+			if child.Name == ical.CompAlarm {
+				comp.Children = append(comp.Children, copyComponent(child))
+			}
+		}
+	}
+}
+
+// organizedBy reports whether cal is a meeting organized by organizer with
+// the calendar user with the given addresses among its attendees.
+func organizedBy(cal *ical.Calendar, organizer string, addresses []string) bool {
+	prop := eventOrganizer(cal)
+	if prop == nil || !domain.SameCalendarAddress(prop.Value, organizer) {
+		return false
+	}
+	role, _ := schedulingRoleOf(cal, addresses)
+	return role == roleAttendee
+}
+
+// awaitAnswer sets the PARTSTAT of the attendee with the given addresses to
+// NEEDS-ACTION in every instance of a new copy, so an invitation cannot
+// answer for its recipient.
+func awaitAnswer(cal *ical.Calendar, addresses []string) {
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompEvent {
+			continue
+		}
+		for _, address := range addresses {
+			if prop := findAttendee(comp, address); prop != nil {
+				prop.Params.Set(ical.ParamParticipationStatus, domain.PartStatNeedsAction)
+			}
+		}
+	}
+}
+
+// scheduleMessageName returns a random resource name for an inbox message.
+func scheduleMessageName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate inbox item name: %w", err)
+	}
+	return hex.EncodeToString(buf) + ".ics", nil
+}
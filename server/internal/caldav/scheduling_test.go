@@ -0,0 +1,350 @@
+package caldav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+// recordingDelivery collects the messages sent to remote calendar users.
+type recordingDelivery struct {
+	mu       sync.Mutex
+	messages []*domain.ITIPMessage
+}
+
+func (d *recordingDelivery) Deliver(ctx context.Context, msg *domain.ITIPMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, msg)
+	return nil
+}
+
+func (d *recordingDelivery) sent() []*domain.ITIPMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*domain.ITIPMessage(nil), d.messages...)
+}
+
+func TestCalDAV_ImplicitScheduling(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	eventRepo := data.NewSQLiteEventRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := users.CreateUser(ctx, name, name+"-password", false); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if _, err := users.SetEmail(ctx, name, name+"@example.com"); err != nil {
+			t.Fatalf("SetEmail failed: %v", err)
+		}
+	}
+	delivery := &recordingDelivery{}
+
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, data.NewSQLiteCalDAVOperationRepo(db), nil, delivery, nil, nil))
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, username, depth, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.SetBasicAuth(username, username+"-password")
+		if method == http.MethodPut || method == http.MethodPost {
+			req.Header.Set("Content-Type", "text/calendar")
+		} else {
+			req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		}
+		if depth != "" {
+			req.Header.Set("Depth", depth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+	meeting := func(bobPartStat string) string {
+		return strings.ReplaceAll(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//Test//EN
+BEGIN:VEVENT
+UID:planning
+DTSTAMP:20260116T080000Z
+DTSTART:20260120T100000Z
+DTEND:20260120T110000Z
+SUMMARY:Planning
+ORGANIZER:mailto:alice@example.com
+ATTENDEE;PARTSTAT=ACCEPTED:mailto:alice@example.com
+ATTENDEE;PARTSTAT=`+bobPartStat+`:mailto:bob@example.com
+ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:carol@remote.example
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n")
+	}
+	alicePath := caldavBase + "/calendars/alice/default/planning.ics"
+	bobPath := caldavBase + "/calendars/bob/default/planning.ics"
+	bobInbox := caldavBase + "/calendars/bob/inbox/"
+
+	// The principal advertises its addresses, inbox and outbox.
+	resp, body := do("PROPFIND", caldavBase+"/principals/bob/", "bob", "0",
+		`<?xml version="1.0"?><d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><c:calendar-user-address-set/><c:schedule-inbox-URL/><c:schedule-outbox-URL/></d:prop></d:propfind>`)
+	if resp.StatusCode != http.StatusMultiStatus || !strings.Contains(body, "mailto:bob@example.com") || !strings.Contains(body, "/dav/calendars/bob/inbox/") || !strings.Contains(body, "/dav/calendars/bob/outbox/") {
+		t.Fatalf("principal scheduling properties: got %d: %s", resp.StatusCode, body)
+	}
+	if resp, _ := do(http.MethodOptions, caldavBase+"/calendars/bob/default/", "bob", "", ""); !strings.Contains(strings.Join(resp.Header.Values("DAV"), ","), "calendar-auto-schedule") {
+		t.Errorf("OPTIONS: expected calendar-auto-schedule in DAV header, got %v", resp.Header.Values("DAV"))
+	}
+
+	// Alice invites bob (local) and carol (remote).
+	if resp, body := do(http.MethodPut, alicePath, "alice", "", meeting("NEEDS-ACTION")); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("organizer PUT: expected 201, got %d: %s", resp.StatusCode, body)
+	}
+	if resp, body := do(http.MethodGet, bobPath, "bob", "", ""); resp.StatusCode != http.StatusOK || !strings.Contains(body, "SUMMARY:Planning") || strings.Contains(body, "METHOD:") {
+		t.Fatalf("bob's copy: expected the invitation without METHOD, got %d: %s", resp.StatusCode, body)
+	}
+	resp, body = do("PROPFIND", bobInbox, "bob", "1", "")
+	if resp.StatusCode != http.StatusMultiStatus || strings.Count(body, ".ics") != 1 || !strings.Contains(body, "schedule-inbox") {
+		t.Fatalf("bob's inbox: expected one message, got %d: %s", resp.StatusCode, body)
+	}
+	sent := delivery.sent()
+	if len(sent) != 1 || sent[0].Method != domain.ITIPRequest || len(sent[0].Recipients) != 1 || sent[0].Recipients[0] != "mailto:carol@remote.example" || !strings.Contains(sent[0].ICS, "METHOD:REQUEST") {
+		t.Fatalf("remote delivery: expected one REQUEST to carol, got %+v", sent)
+	}
+	aliceEvent, err := eventRepo.GetByUID(ctx, mustCalendarID(t, calendarRepo, userRepo, "alice"), "planning")
+	if err != nil {
+		t.Fatalf("GetByUID failed: %v", err)
+	}
+	scheduleTag := aliceEvent.ScheduleTag
+
+	// Bob accepts; alice's copy records it without a new Schedule-Tag.
+	if resp, body := do(http.MethodPut, bobPath, "bob", "", meeting("ACCEPTED")); resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusCreated {
+		t.Fatalf("attendee PUT: got %d: %s", resp.StatusCode, body)
+	}
+	if resp, body := do(http.MethodGet, alicePath, "alice", "", ""); !strings.Contains(body, "PARTSTAT=ACCEPTED:mailto:bob@example.com") {
+		t.Fatalf("alice's copy: expected bob ACCEPTED, got %d: %s", resp.StatusCode, body)
+	}
+	aliceEvent, _ = eventRepo.GetByUID(ctx, aliceEvent.CalendarID, "planning")
+	if aliceEvent.ScheduleTag != scheduleTag || aliceEvent.ETag == scheduleTag {
+		t.Errorf("reply: expected a new ETag and unchanged Schedule-Tag, got etag %s tag %s (was %s)", aliceEvent.ETag, aliceEvent.ScheduleTag, scheduleTag)
+	}
+	resp, body = do("PROPFIND", caldavBase+"/calendars/alice/default/planning.ics", "alice", "0",
+		`<?xml version="1.0"?><d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:schedule-tag/></d:prop></d:propfind>`)
+	if !strings.Contains(body, strings.Trim(scheduleTag, `"`)) {
+		t.Errorf("PROPFIND schedule-tag: expected %s, got %d: %s", scheduleTag, resp.StatusCode, body)
+	}
+
+	// Inbox items can be read and removed.
+	_, listing := do("PROPFIND", bobInbox, "bob", "1", "")
+	name := inboxItemName(t, listing)
+	if resp, body := do(http.MethodGet, bobInbox+name, "bob", "", ""); resp.StatusCode != http.StatusOK || !strings.Contains(body, "METHOD:REQUEST") {
+		t.Errorf("inbox GET: got %d: %s", resp.StatusCode, body)
+	}
+	if resp, _ := do(http.MethodPut, bobInbox+name, "bob", "", meeting("ACCEPTED")); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("inbox PUT: expected 405, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(http.MethodDelete, bobInbox+name, "bob", "", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("inbox DELETE: expected 204, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(http.MethodGet, bobInbox+name, "bob", "", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted inbox item: expected 404, got %d", resp.StatusCode)
+	}
+	if resp, _ := do("PROPFIND", bobInbox, "alice", "1", ""); resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusNotFound {
+		t.Errorf("another user's inbox: expected 403 or 404, got %d", resp.StatusCode)
+	}
+
+	// Alice's outbox answers free-busy requests for local users only.
+	freeBusyRequest := strings.ReplaceAll(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//Test//EN
+METHOD:REQUEST
+BEGIN:VFREEBUSY
+UID:fb-1
+DTSTAMP:20260116T080000Z
+DTSTART:20260120T000000Z
+DTEND:20260121T000000Z
+ORGANIZER:mailto:alice@example.com
+ATTENDEE:mailto:bob@example.com
+ATTENDEE:mailto:dave@remote.example
+END:VFREEBUSY
+END:VCALENDAR
+`, "\n", "\r\n")
+	resp, body = do(http.MethodPost, caldavBase+"/calendars/alice/outbox/", "alice", "", freeBusyRequest)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "2.0;Success") || !strings.Contains(body, "3.7;Invalid calendar user") || !strings.Contains(body, "20260120T100000Z/20260120T110000Z") {
+		t.Fatalf("outbox POST: got %d: %s", resp.StatusCode, body)
+	}
+	if resp, _ := do(http.MethodPost, caldavBase+"/calendars/bob/outbox/", "bob", "", freeBusyRequest); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("outbox POST as someone else's organizer: expected 403, got %d", resp.StatusCode)
+	}
+
+	// Carol's emailed reply updates alice's copy; only replies are accepted from outside.
+	inbound := NewBackend(db, userRepo, calendarRepo, eventRepo)
+	carolReply := strings.Replace(strings.Replace(meeting("ACCEPTED"), "VERSION:2.0", "VERSION:2.0\r\nMETHOD:REPLY", 1), "ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:carol", "ATTENDEE;PARTSTAT=TENTATIVE:mailto:carol", 1)
+	reply := &domain.ITIPMessage{Method: domain.ITIPReply, UID: "planning", Originator: "mailto:carol@remote.example", Recipients: []string{"mailto:alice@example.com"}, ICS: carolReply}
+	if err := inbound.ReceiveITIP(ctx, reply); err != nil {
+		t.Fatalf("ReceiveITIP failed: %v", err)
+	}
+	if _, body := do(http.MethodGet, alicePath, "alice", "", ""); !strings.Contains(body, "PARTSTAT=TENTATIVE:mailto:carol@remote.example") {
+		t.Errorf("alice's copy after carol's reply: expected TENTATIVE, got %s", body)
+	}
+	if err := inbound.ReceiveITIP(ctx, &domain.ITIPMessage{Method: domain.ITIPRequest, UID: "planning", Recipients: []string{"mailto:bob@example.com"}, ICS: carolReply}); err != domain.ErrUnsupportedITIPMethod {
+		t.Errorf("inbound REQUEST: expected ErrUnsupportedITIPMethod, got %v", err)
+	}
+	reply.Recipients = []string{"mailto:dave@remote.example"}
+	if err := inbound.ReceiveITIP(ctx, reply); err != domain.ErrNotFound {
+		t.Errorf("reply without local recipient: expected ErrNotFound, got %v", err)
+	}
+
+	// Alice deletes the meeting: bob's copy is cancelled and carol gets a CANCEL.
+	if resp, body := do(http.MethodDelete, alicePath, "alice", "", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("organizer DELETE: got %d: %s", resp.StatusCode, body)
+	}
+	if _, body := do(http.MethodGet, bobPath, "bob", "", ""); !strings.Contains(body, "STATUS:CANCELLED") {
+		t.Errorf("bob's copy after cancel: expected STATUS:CANCELLED, got %s", body)
+	}
+	if sent := delivery.sent(); len(sent) != 2 || sent[1].Method != domain.ITIPCancel {
+		t.Errorf("remote delivery: expected a CANCEL to carol, got %+v", sent)
+	}
+}
+
+// inboxItemName returns the first inbox item listed in a PROPFIND response.
+func inboxItemName(t *testing.T, body string) string {
+	t.Helper()
+	const prefix = "/dav/calendars/bob/inbox/"
+	for _, part := range strings.Split(body, prefix)[1:] {
+		if end := strings.Index(part, "<"); end > 0 {
+			return part[:end]
+		}
+	}
+	t.Fatalf("no inbox item in %s", body)
+	return ""
+}
+
+func mustCalendarID(t *testing.T, calendarRepo *data.SQLiteCalendarRepo, userRepo *data.SQLiteUserRepo, username string) int64 {
+	t.Helper()
+	user, err := userRepo.GetByUsername(context.Background(), username)
+	if err != nil {
+		t.Fatalf("GetByUsername failed: %v", err)
+	}
+	cal, err := calendarRepo.GetByName(context.Background(), user.ID, domain.DefaultCalendarName)
+	if err != nil {
+		t.Fatalf("GetByName failed: %v", err)
+	}
+	return cal.ID
+}
+
+func TestCalDAV_ImplicitScheduling_OnlyOrganizerUpdatesCopies(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	eventRepo := data.NewSQLiteEventRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	for _, name := range []string{"alice", "bob", "mallory"} {
+		if _, err := users.CreateUser(ctx, name, name+"-password", false); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if _, err := users.SetEmail(ctx, name, name+"@example.com"); err != nil {
+			t.Fatalf("SetEmail failed: %v", err)
+		}
+	}
+	srv := httptest.NewServer(NewHandlerWithReposAndOperationRecorder(db, userRepo, calendarRepo, eventRepo, data.NewSQLiteCalDAVOperationRepo(db), nil, nil, nil, nil))
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, username, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.SetBasicAuth(username, username+"-password")
+		req.Header.Set("Content-Type", "text/calendar")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+	meeting := func(uid, summary, organizer string, attendees ...string) string {
+		ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+			"\r\nDTSTAMP:20260116T080000Z\r\nDTSTART:20260120T100000Z\r\nDTEND:20260120T110000Z\r\nSUMMARY:" + summary +
+			"\r\nORGANIZER:mailto:" + organizer + "@example.com\r\n"
+		for _, attendee := range attendees {
+			ics += "ATTENDEE;PARTSTAT=ACCEPTED:mailto:" + attendee + "@example.com\r\n"
+		}
+		return ics + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+	path := func(username, uid string) string {
+		return caldavBase + "/calendars/" + username + "/default/" + uid + ".ics"
+	}
+	inboxCount := func(username string) int {
+		user, _ := userRepo.GetByUsername(ctx, username)
+		messages, err := data.NewSQLiteScheduleInboxRepo(db).ListByUser(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("ListByUser failed: %v", err)
+		}
+		return len(messages)
+	}
+
+	// Bob organizes a review with alice, alice a lunch with bob; UIDs are
+	// visible to every invitee, so mallory may know both
+	if resp, body := do(http.MethodPut, path("bob", "review"), "bob", meeting("review", "Review", "bob", "bob", "alice")); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("bob's PUT: got %d: %s", resp.StatusCode, body)
+	}
+	if resp, body := do(http.MethodPut, path("alice", "lunch"), "alice", meeting("lunch", "Lunch", "alice", "alice", "bob")); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("alice's PUT: got %d: %s", resp.StatusCode, body)
+	}
+
+	// Mallory reuses both UIDs, naming the victims as attendees
+	for _, uid := range []string{"review", "lunch"} {
+		if resp, body := do(http.MethodPut, path("mallory", uid), "mallory", meeting(uid, "Hijacked", "mallory", "mallory", "alice", "bob")); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("mallory's PUT of %s: got %d: %s", uid, resp.StatusCode, body)
+		}
+	}
+	for _, copy := range []struct{ username, uid string }{{"bob", "review"}, {"alice", "review"}, {"bob", "lunch"}, {"alice", "lunch"}} {
+		resp, body := do(http.MethodGet, path(copy.username, copy.uid), copy.username, "")
+		if strings.Contains(body, "Hijacked") || (resp.StatusCode == http.StatusOK && strings.Contains(body, "mallory")) {
+			t.Errorf("%s's copy of %s was replaced: %s", copy.username, copy.uid, body)
+		}
+	}
+	if resp, body := do(http.MethodDelete, path("mallory", "review"), "mallory", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("mallory's DELETE: got %d: %s", resp.StatusCode, body)
+	}
+	if _, body := do(http.MethodGet, path("bob", "review"), "bob", ""); !strings.Contains(body, "SUMMARY:Review") || strings.Contains(body, "CANCELLED") {
+		t.Errorf("bob's review was cancelled by mallory: %s", body)
+	}
+	// The messages still reach the inboxes
+	if got := inboxCount("bob"); got != 4 {
+		t.Errorf("bob's inbox: got %d messages, want 4 (lunch, two hijack REQUESTs, CANCEL)", got)
+	}
+
+	// A new invitation waits for its recipient's answer
+	if resp, body := do(http.MethodPut, path("mallory", "party"), "mallory", meeting("party", "Party", "mallory", "mallory", "bob")); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("mallory's PUT of party: got %d: %s", resp.StatusCode, body)
+	}
+	if _, body := do(http.MethodGet, path("bob", "party"), "bob", ""); !strings.Contains(body, "PARTSTAT=NEEDS-ACTION:mailto:bob@example.com") {
+		t.Errorf("bob's copy of a new invitation: want PARTSTAT=NEEDS-ACTION, got %s", body)
+	}
+}
@@ -0,0 +1,131 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	currentUserPrivilegeSetName = xml.Name{Space: davNS, Local: "current-user-privilege-set"}
+	needPrivilegesName          = xml.Name{Space: davNS, Local: "need-privileges"}
+)
+
+// errReadOnlyCalendar is returned for writes to a calendar shared read-only
+// with the current user, and for changes only the owner may make.
+var errReadOnlyCalendar = errors.New("insufficient privileges on shared calendar")
+
+// calendarAccess is what the current user may do with a calendar of their
+// calendar home.
+type calendarAccess int
+
+const (
+	accessRead      calendarAccess = iota + 1 // Shared read-only
+	accessReadWrite                           // Shared read-write
+	accessOwner
+)
+
+// canWrite reports whether calendar objects may be created, changed and deleted.
+func (a calendarAccess) canWrite() bool {
+	return a >= accessReadWrite
+}
+
+// calendarByName resolves a calendar of the user's calendar home: one of their
+// own, or a calendar another user shared with them, which is named
+// {calendar}@{owner}. Calendars that are not shared with the user are
+// domain.ErrNotFound, so their existence is not revealed.
+func (b *Backend) calendarByName(ctx context.Context, user *domain.User, name string) (*domain.Calendar, calendarAccess, error) {
+	cal, err := b.calendarRepo.GetByName(ctx, user.ID, name)
+	if err == nil {
+		return cal, accessOwner, nil
+	}
+	calName, ownerName, ok := domain.ParseSharedCalendarName(name)
+	if err != domain.ErrNotFound || !ok || ownerName == user.Username {
+		return nil, 0, err
+	}
+
+	owner, err := b.userRepo.GetByUsername(ctx, ownerName)
+	if err != nil {
+		return nil, 0, err
+	}
+	cal, err = b.calendarRepo.GetByName(ctx, owner.ID, calName)
+	if err != nil {
+		return nil, 0, err
+	}
+	share, err := b.shareRepo.Get(ctx, cal.ID, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if share.Privilege.CanWrite() {
+		return cal, accessReadWrite, nil
+	}
+	return cal, accessRead, nil
+}
+
+// sharedCalendar is a calendar of another user as it appears in the
+// grantee's calendar home.
+type sharedCalendar struct {
+	Name     string // {calendar}@{owner}
+	Calendar *domain.Calendar
+}
+
+// sharedCalendars returns the calendars shared with the user.
+func (b *Backend) sharedCalendars(ctx context.Context, user *domain.User) ([]sharedCalendar, error) {
+	shares, err := b.shareRepo.ListByGrantee(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]sharedCalendar, 0, len(shares))
+	for _, share := range shares {
+		cal, err := b.calendarRepo.GetByID(ctx, share.CalendarID)
+		if err != nil {
+			return nil, err
+		}
+		owner, err := b.userRepo.GetByID(ctx, cal.UserID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sharedCalendar{Name: domain.SharedCalendarName(cal.Name, owner.Username), Calendar: cal})
+	}
+	return result, nil
+}
+
+// CurrentUserPrivilegeSet returns the DAV:current-user-privilege-set (RFC
+// 3744 §5.4) of the calendar collection at urlPath, replacing the read-write
+// set go-webdav reports for every calendar. ok is false for other resources.
+func (b *Backend) CurrentUserPrivilegeSet(ctx context.Context, urlPath string) (value davRawValue, ok bool) {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return davRawValue{}, false
+	}
+	calName, uid := extractCalendarAndUID(urlPath)
+	if calName == "" || uid != "" {
+		return davRawValue{}, false
+	}
+	_, access, err := b.calendarByName(ctx, user, calName)
+	if err != nil {
+		return davRawValue{}, false
+	}
+
+	privileges := []xml.Name{
+		{Space: davNS, Local: "read"},
+		{Space: davNS, Local: "read-current-user-privilege-set"},
+		{Space: caldavNS, Local: "read-free-busy"},
+	}
+	if access.canWrite() {
+		privileges = append(privileges,
+			xml.Name{Space: davNS, Local: "write"},
+			xml.Name{Space: davNS, Local: "write-properties"},
+			xml.Name{Space: davNS, Local: "write-content"},
+			xml.Name{Space: davNS, Local: "bind"},
+			xml.Name{Space: davNS, Local: "unbind"},
+		)
+	}
+	var inner []byte
+	for _, p := range privileges {
+		inner = append(inner, `<privilege xmlns="DAV:"><`+p.Local+` xmlns="`+p.Space+`"/></privilege>`...)
+	}
+	return davRawValue{XMLName: currentUserPrivilegeSetName, Inner: inner}, true
+}
@@ -0,0 +1,132 @@
+package caldav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/services"
+)
+
+func TestCalDAV_SharedCalendar_ReadAndReadWrite(t *testing.T) {
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open test DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := data.RunMigrations(db, getMigrationsDir(t)); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := data.NewSQLiteUserRepo(db)
+	calendarRepo := data.NewSQLiteCalendarRepo(db)
+	shareRepo := data.NewSQLiteCalendarShareRepo(db)
+	users := services.NewUserService(userRepo, calendarRepo)
+	alice, err := users.CreateUser(ctx, "alice", "alice-password", false)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	for _, name := range []string{"bob", "carol"} {
+		if _, err := users.CreateUser(ctx, name, name+"-password", false); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	if err := calendarRepo.Create(ctx, &domain.Calendar{UserID: alice.ID, Name: "family", DisplayName: "Family"}); err != nil {
+		t.Fatalf("Create calendar failed: %v", err)
+	}
+	shares := services.NewCalendarShareService(shareRepo, calendarRepo, userRepo, nil)
+
+	srv := httptest.NewServer(NewHandlerWithRepos(db, userRepo, calendarRepo, data.NewSQLiteEventRepo(db)))
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, username, depth, body string) (int, string) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.SetBasicAuth(username, username+"-password")
+		if method == http.MethodPut {
+			req.Header.Set("Content-Type", "text/calendar")
+		} else {
+			req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		}
+		if depth != "" {
+			req.Header.Set("Depth", depth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+	event := func(uid string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+			"\r\nDTSTAMP:20260116T080000Z\r\nSUMMARY:Family dinner\r\nDTSTART:20260116T180000Z\r\nDTEND:20260116T200000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+	privilegeSet := `<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:current-user-privilege-set/></d:prop></d:propfind>`
+	shared := caldavBase + "/calendars/bob/family@alice/"
+
+	if status, body := do(http.MethodPut, caldavBase+"/calendars/alice/family/dinner.ics", "alice", "", event("dinner")); status != http.StatusCreated {
+		t.Fatalf("owner PUT: expected 201, got %d: %s", status, body)
+	}
+	if status, _ := do("PROPFIND", shared, "bob", "0", privilegeSet); status != http.StatusNotFound {
+		t.Errorf("unshared calendar: expected 404, got %d", status)
+	}
+
+	if _, err := shares.Share(ctx, alice, "family", "bob", domain.SharePrivilegeRead); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	status, body := do("PROPFIND", caldavBase+"/calendars/bob/", "bob", "1", `<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:displayname/></d:prop></d:propfind>`)
+	if status != http.StatusMultiStatus || !strings.Contains(body, shared) {
+		t.Fatalf("grantee home: expected 207 listing %s, got %d: %s", shared, status, body)
+	}
+	status, body = do("PROPFIND", shared, "bob", "0", privilegeSet)
+	if status != http.StatusMultiStatus || !strings.Contains(body, "read-current-user-privilege-set") || strings.Contains(body, "write") {
+		t.Errorf("read-only privilege set: got %d: %s", status, body)
+	}
+	if status, body := do(http.MethodGet, shared+"dinner.ics", "bob", "", ""); status != http.StatusOK || !strings.Contains(body, "Family dinner") {
+		t.Errorf("read-only GET: expected 200, got %d: %s", status, body)
+	}
+	if status, body := do(http.MethodPut, shared+"lunch.ics", "bob", "", event("lunch")); status != http.StatusForbidden {
+		t.Errorf("read-only PUT: expected 403, got %d: %s", status, body)
+	}
+	if status, _ := do(http.MethodDelete, shared+"dinner.ics", "bob", "", ""); status != http.StatusForbidden {
+		t.Errorf("read-only DELETE: expected 403, got %d", status)
+	}
+	if status, _ := do("PROPFIND", caldavBase+"/calendars/carol/family@alice/", "carol", "0", privilegeSet); status != http.StatusNotFound {
+		t.Errorf("calendar not shared with carol: expected 404, got %d", status)
+	}
+
+	if _, err := shares.Share(ctx, alice, "family", "bob", domain.SharePrivilegeReadWrite); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	status, body = do("PROPFIND", shared, "bob", "0", privilegeSet)
+	if status != http.StatusMultiStatus || !strings.Contains(body, "write-content") {
+		t.Errorf("read-write privilege set: got %d: %s", status, body)
+	}
+	if status, body := do(http.MethodPut, shared+"lunch.ics", "bob", "", event("lunch")); status != http.StatusCreated {
+		t.Fatalf("read-write PUT: expected 201, got %d: %s", status, body)
+	}
+	if status, body := do(http.MethodGet, caldavBase+"/calendars/alice/family/lunch.ics", "alice", "", ""); status != http.StatusOK {
+		t.Errorf("owner GET of grantee's event: expected 200, got %d: %s", status, body)
+	}
+
+	// Deleting the shared calendar from the grantee's home only removes the share
+	if status, _ := do(http.MethodDelete, shared, "bob", "", ""); status != http.StatusNoContent {
+		t.Fatalf("grantee DELETE of shared calendar: expected 204, got %d", status)
+	}
+	if grants, err := shares.List(ctx, alice, "family"); err != nil || len(grants) != 0 {
+		t.Fatalf("shares after leaving: %+v, %v", grants, err)
+	}
+	if status, _ := do("PROPFIND", shared, "bob", "0", privilegeSet); status != http.StatusNotFound {
+		t.Errorf("after leaving: expected 404, got %d", status)
+	}
+	if status, _ := do(http.MethodGet, caldavBase+"/calendars/alice/family/dinner.ics", "alice", "", ""); status != http.StatusOK {
+		t.Errorf("owner's calendar after grantee left: expected 200, got %d", status)
+	}
+}
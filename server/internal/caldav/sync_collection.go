@@ -0,0 +1,207 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav/caldav"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var syncCollectionName = xml.Name{Space: davNS, Local: "sync-collection"}
+
+// syncCollectionRequest is the body of an RFC 6578 DAV:sync-collection REPORT.
+type syncCollectionRequest struct {
+	XMLName   xml.Name `xml:"DAV: sync-collection"`
+	SyncToken string   `xml:"DAV: sync-token"`
+	SyncLevel string   `xml:"DAV: sync-level"`
+	Prop      davProp  `xml:"DAV: prop"`
+}
+
+// SyncCollectionResult holds the delta between a client's sync token and the
+// calendar's current state.
+type SyncCollectionResult struct {
+	SyncToken string
+	Changed   []caldav.CalendarObject
+	Removed   []string // Object paths that no longer exist
+}
+
+// SyncCollection returns the objects created, updated or deleted since
+// syncToken. An empty token means initial sync: every current object is
+// returned as changed. Returns domain.ErrInvalidSyncToken for tokens this
+// server did not issue for the calendar.
+func (b *Backend) SyncCollection(ctx context.Context, urlPath string, syncToken string) (*SyncCollectionResult, error) {
+	user := getUserFromContext(ctx)
+	if user == nil {
+		return nil, errNoAuthenticatedUser
+	}
+
+	calName := extractCalendarName(urlPath)
+	cal, _, err := b.calendarByName(ctx, user, calName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calendars that have never changed (or still carry a pre-change-log
+	// token) start at revision 0.
+	currentRevision, err := domain.ParseSyncToken(cal.SyncToken)
+	if err != nil {
+		currentRevision = 0
+	}
+	result := &SyncCollectionResult{SyncToken: domain.FormatSyncToken(currentRevision)}
+	collectionPath := ensureTrailingSlash(urlPath)
+
+	if syncToken == "" {
+		events, err := b.eventRepo.ListAll(ctx, cal.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+		for _, event := range events {
+			calObj, err := b.domainEventToCalDAV(event, collectionPath+event.UID+".ics")
+			if err != nil {
+				return nil, err
+			}
+			result.Changed = append(result.Changed, *calObj)
+		}
+		tasks, err := b.listTaskObjects(ctx, cal, collectionPath)
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = append(result.Changed, tasks...)
+		return result, nil
+	}
+
+	since, err := domain.ParseSyncToken(syncToken)
+	if err != nil || since > currentRevision {
+		return nil, domain.ErrInvalidSyncToken
+	}
+	// Revisions are shared by all calendars, so a token issued by this
+	// calendar names an entry of its own change log; one of another
+	// calendar's does not.
+	if since > 0 {
+		issued, err := b.calendarRepo.HasChange(ctx, cal.ID, since)
+		if err != nil {
+			return nil, err
+		}
+		if !issued {
+			return nil, domain.ErrInvalidSyncToken
+		}
+	}
+	// A token issued by a deleted calendar of the same name must not be
+	// mistaken for one of this calendar: the client has to resync from scratch.
+	tombstone, err := b.calendarRepo.GetTombstone(ctx, cal.UserID, cal.Name)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, err
+	}
+	if tombstone != nil && since <= tombstone.Revision {
+		return nil, domain.ErrInvalidSyncToken
+	}
+
+	changes, err := b.calendarRepo.ListChangesSince(ctx, cal.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collapse the log to the latest change per UID, ordered by when each
+	// object was last touched.
+	latest := make(map[string]*domain.CalendarChange, len(changes))
+	for _, change := range changes {
+		latest[change.UID] = change
+	}
+	var order []string
+	for _, change := range changes {
+		if latest[change.UID] == change {
+			order = append(order, change.UID)
+		}
+	}
+
+	for _, uid := range order {
+		objPath := collectionPath + uid + ".ics"
+		if latest[uid].Type == domain.CalendarChangeDeleted {
+			result.Removed = append(result.Removed, objPath)
+			continue
+		}
+		calObj, err := b.getCalendarObject(ctx, cal, uid, objPath)
+		if err == domain.ErrNotFound {
+			result.Removed = append(result.Removed, objPath)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = append(result.Changed, *calObj)
+	}
+
+	return result, nil
+}
+
+// SyncCollectionHandler serves DAV:sync-collection REPORTs on calendar collections.
+type SyncCollectionHandler struct {
+	backend *Backend
+}
+
+func NewSyncCollectionHandler(backend *Backend) *SyncCollectionHandler {
+	return &SyncCollectionHandler{backend: backend}
+}
+
+func (h *SyncCollectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req syncCollectionRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Malformed sync-collection request", http.StatusBadRequest)
+		return
+	}
+	if req.SyncLevel != "" && req.SyncLevel != "1" && req.SyncLevel != "infinite" {
+		http.Error(w, "Unsupported sync-level", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.backend.SyncCollection(r.Context(), r.URL.Path, req.SyncToken)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	ms := &davMultistatus{SyncToken: result.SyncToken}
+	for i := range result.Changed {
+		ms.Responses = append(ms.Responses, calendarObjectResponse(&result.Changed[i], req.Prop.Names()))
+	}
+	for _, removed := range result.Removed {
+		ms.Responses = append(ms.Responses, davResponse{
+			Hrefs:  []string{removed},
+			Status: davStatus(http.StatusNotFound),
+		})
+	}
+
+	slog.Debug("caldav.sync_collection", "changed", len(result.Changed), "removed", len(result.Removed))
+	writeMultistatus(w, ms)
+}
+
+// calendarObjectResponse renders the requested properties of a calendar object.
+// Properties we cannot serve are reported in a 404 propstat.
+func calendarObjectResponse(obj *caldav.CalendarObject, names []xml.Name) davResponse {
+	resp := davResponse{Hrefs: []string{obj.Path}}
+	for _, name := range names {
+		switch name {
+		case getETagName:
+			resp.addProp(http.StatusOK, davTextValue(name, obj.ETag))
+		case getContentTypeName:
+			resp.addProp(http.StatusOK, davTextValue(name, ical.MIMEType))
+		case calendarDataName:
+			var buf bytes.Buffer
+			if err := ical.NewEncoder(&buf).Encode(obj.Data); err != nil {
+				resp.addProp(http.StatusInternalServerError, davEmptyValue(name))
+				continue
+			}
+			resp.addProp(http.StatusOK, davTextValue(name, buf.String()))
+		default:
+			resp.addProp(http.StatusNotFound, davEmptyValue(name))
+		}
+	}
+	return resp
+}
@@ -0,0 +1,221 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func putTestEvent(t *testing.T, baseURL, uid string) {
+	t.Helper()
+
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+		"\r\nDTSTAMP:20260116T080000Z\r\nSUMMARY:Sync Test\r\nDTSTART:20260116T090000Z\r\nDTEND:20260116T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	req, _ := http.NewRequest("PUT", baseURL+caldavBase+"/calendars/testuser/default/"+uid+".ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PUT %s: expected 201 or 204, got %d. Body: %s", uid, resp.StatusCode, string(body))
+	}
+}
+
+func doXMLRequest(t *testing.T, method, url, depth, body string) (int, []byte) {
+	t.Helper()
+
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	if depth != "" {
+		req.Header.Set("Depth", depth)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func syncCollection(t *testing.T, baseURL, token string) davMultistatus {
+	t.Helper()
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:">
+  <d:sync-token>` + token + `</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop><d:getetag/></d:prop>
+</d:sync-collection>`
+
+	status, data := doXMLRequest(t, "REPORT", baseURL+caldavBase+"/calendars/testuser/default/", "", body)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("sync-collection: expected 207, got %d. Body: %s", status, string(data))
+	}
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		t.Fatalf("Failed to decode sync-collection response: %v", err)
+	}
+	if ms.SyncToken == "" {
+		t.Fatal("sync-collection response is missing DAV:sync-token")
+	}
+	return ms
+}
+
+func TestCalDAV_SyncCollection_InitialAndDelta(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	putTestEvent(t, srv.URL, "sync-a")
+	putTestEvent(t, srv.URL, "sync-b")
+
+	initial := syncCollection(t, srv.URL, "")
+	if len(initial.Responses) != 2 {
+		t.Fatalf("Initial sync: expected 2 responses, got %d", len(initial.Responses))
+	}
+
+	// No changes since the initial token
+	unchanged := syncCollection(t, srv.URL, initial.SyncToken)
+	if len(unchanged.Responses) != 0 {
+		t.Errorf("Expected no changes, got %d responses", len(unchanged.Responses))
+	}
+	if unchanged.SyncToken != initial.SyncToken {
+		t.Errorf("Sync token changed without writes: %q -> %q", initial.SyncToken, unchanged.SyncToken)
+	}
+
+	// Update one event, delete the other, create a third
+	putTestEvent(t, srv.URL, "sync-a")
+	req, _ := http.NewRequest("DELETE", srv.URL+caldavBase+"/calendars/testuser/default/sync-b.ics", nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	resp.Body.Close()
+	putTestEvent(t, srv.URL, "sync-c")
+
+	delta := syncCollection(t, srv.URL, initial.SyncToken)
+	if len(delta.Responses) != 3 {
+		t.Fatalf("Delta sync: expected 3 responses, got %d", len(delta.Responses))
+	}
+
+	statuses := map[string]string{}
+	for _, r := range delta.Responses {
+		statuses[r.Hrefs[0]] = r.Status
+		if r.Status == "" && len(r.PropStats) == 0 {
+			t.Errorf("Changed object %s has no propstat", r.Hrefs[0])
+		}
+	}
+	deleted := caldavBase + "/calendars/testuser/default/sync-b.ics"
+	if statuses[deleted] != "HTTP/1.1 404 Not Found" {
+		t.Errorf("Expected tombstone for %s, got status %q", deleted, statuses[deleted])
+	}
+	if delta.SyncToken == initial.SyncToken {
+		t.Error("Sync token should advance after writes")
+	}
+}
+
+func TestCalDAV_SyncCollection_InvalidToken(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:">
+  <d:sync-token>v1700000000000000000</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop><d:getetag/></d:prop>
+</d:sync-collection>`
+
+	status, data := doXMLRequest(t, "REPORT", srv.URL+caldavBase+"/calendars/testuser/default/", "", body)
+	if status != http.StatusForbidden {
+		t.Fatalf("Expected 403 for invalid sync token, got %d", status)
+	}
+	if !strings.Contains(string(data), "valid-sync-token") {
+		t.Errorf("Expected DAV:valid-sync-token precondition, got: %s", string(data))
+	}
+}
+
+func TestCalDAV_SyncCollection_TokenOfAnotherCalendar(t *testing.T) {
+	srv, userRepo, calendarRepo, _ := setupTestServer(t)
+	ctx := context.Background()
+
+	user, _ := userRepo.GetByUsername(ctx, "testuser")
+	work := &domain.Calendar{UserID: user.ID, Name: "work", DisplayName: "Work"}
+	if err := calendarRepo.Create(ctx, work); err != nil {
+		t.Fatalf("Failed to create calendar: %v", err)
+	}
+	foreign, err := calendarRepo.RecordChange(ctx, work.ID, "work-event", domain.CalendarChangeCreated)
+	if err != nil {
+		t.Fatalf("RecordChange failed: %v", err)
+	}
+	// The default calendar moves past the work calendar's revision
+	putTestEvent(t, srv.URL, "sync-a")
+	putTestEvent(t, srv.URL, "sync-b")
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:">
+  <d:sync-token>` + foreign + `</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop><d:getetag/></d:prop>
+</d:sync-collection>`
+	status, data := doXMLRequest(t, "REPORT", srv.URL+caldavBase+"/calendars/testuser/default/", "", body)
+	if status != http.StatusForbidden || !strings.Contains(string(data), "valid-sync-token") {
+		t.Fatalf("Token of another calendar: expected 403 valid-sync-token, got %d: %s", status, string(data))
+	}
+
+	// The calendar's own tokens still work
+	initial := syncCollection(t, srv.URL, "")
+	if delta := syncCollection(t, srv.URL, initial.SyncToken); len(delta.Responses) != 0 {
+		t.Errorf("Expected no changes since the current token, got %d responses", len(delta.Responses))
+	}
+}
+
+func TestCalDAV_PROPFIND_SyncToken(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	putTestEvent(t, srv.URL, "propfind-sync")
+
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+  <d:prop><d:displayname/><d:sync-token/><cs:getctag/><d:supported-report-set/></d:prop>
+</d:propfind>`
+
+	status, data := doXMLRequest(t, "PROPFIND", srv.URL+caldavBase+"/calendars/testuser/default/", "0", body)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("Expected 207, got %d. Body: %s", status, string(data))
+	}
+
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		t.Fatalf("Failed to decode PROPFIND response: %v", err)
+	}
+	if len(ms.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(ms.Responses))
+	}
+
+	found := map[string]string{}
+	for _, ps := range ms.Responses[0].PropStats {
+		for _, v := range ps.Prop.Values {
+			found[v.XMLName.Local] = ps.Status
+		}
+	}
+	for _, name := range []string{"displayname", "sync-token", "getctag", "supported-report-set"} {
+		if found[name] != "HTTP/1.1 200 OK" {
+			t.Errorf("Expected %s with 200 OK, got %q", name, found[name])
+		}
+	}
+	if !strings.Contains(string(data), "urn:calendarapp:sync:") {
+		t.Errorf("Expected sync-token URI in response, got: %s", string(data))
+	}
+}
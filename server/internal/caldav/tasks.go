@@ -0,0 +1,230 @@
+package caldav
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+	"github.com/emersion/go-webdav/caldav"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// VTODO support. Tasks live in the tasks table (shared with Todoist sync) and
+// are exposed over CalDAV when they belong to a calendar whose component set
+// includes VTODO. Objects in a collection are addressed by UID whether they are
+// events or tasks, so the helpers below look in both repositories.
+
+// putTask creates or updates a VTODO calendar object.
+// Task write and sync token bump are atomic (single transaction).
+func (b *Backend) putTask(ctx context.Context, user *domain.User, cal *domain.Calendar, urlPath, uid string, icalData *ical.Calendar, icsBytes []byte, opts *caldav.PutCalendarObjectOptions) (*caldav.CalendarObject, error) {
+	etag := domain.GenerateETag(icsBytes)
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	taskRepoTx := b.taskRepo.WithTx(tx)
+	calendarRepoTx := b.calendarRepo.WithTx(tx)
+
+	existing, err := taskRepoTx.GetByUID(ctx, cal.ID, uid)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	isNew := err == domain.ErrNotFound
+
+	task := existing
+	changeType := domain.CalendarChangeUpdated
+	if isNew {
+		if opts != nil && opts.IfMatch.IsSet() && !checkIfMatch(ctx, opts.IfMatch, "") {
+			return nil, webdav.NewHTTPError(412, fmt.Errorf("resource does not exist"))
+		}
+		if opts != nil && opts.IfNoneMatch.IsSet() {
+			recordETagOutcome(ctx, domain.CalDAVETagMissing)
+		}
+		calendarID := cal.ID
+		task = &domain.Task{UserID: user.ID, CalendarID: &calendarID, UID: uid}
+		changeType = domain.CalendarChangeCreated
+	} else {
+		if opts != nil && opts.IfNoneMatch.IsSet() && opts.IfNoneMatch.IsWildcard() {
+			recordETagOutcome(ctx, domain.CalDAVETagMismatched)
+			return nil, webdav.NewHTTPError(412, fmt.Errorf("resource exists"))
+		}
+		if opts != nil && opts.IfMatch.IsSet() && !checkIfMatch(ctx, opts.IfMatch, existing.ETag) {
+			return nil, webdav.NewHTTPError(412, fmt.Errorf("ETag mismatch"))
+		}
+	}
+
+	task.ICS = string(icsBytes)
+	task.ETag = etag
+	applyTaskMetadata(task, icalData)
+
+	if isNew {
+		err = taskRepoTx.Create(ctx, task)
+	} else {
+		err = taskRepoTx.Update(ctx, task)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write task: %w", err)
+	}
+
+	// Record the change and bump the calendar sync token (in same transaction)
+	if _, err := calendarRepoTx.RecordChange(ctx, cal.ID, uid, changeType); err != nil {
+		return nil, fmt.Errorf("failed to increment sync token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Info("caldav.task."+string(changeType), "username", user.Username, "calendar", cal.Name, "uid", uid, "etag", etag)
+	return b.domainTaskToCalDAV(task, urlPath)
+}
+
+// applyTaskMetadata copies the VTODO fields we index onto task.
+func applyTaskMetadata(task *domain.Task, cal *ical.Calendar) {
+	comp := masterComponent(cal, "VTODO")
+	if comp == nil {
+		return
+	}
+
+	task.Content = "Untitled task"
+	if prop := comp.Props.Get("SUMMARY"); prop != nil && strings.TrimSpace(prop.Value) != "" {
+		task.Content = prop.Value
+	}
+	task.Description = ""
+	if prop := comp.Props.Get("DESCRIPTION"); prop != nil {
+		task.Description = prop.Value
+	}
+
+	icalPriority := 0
+	if prop := comp.Props.Get("PRIORITY"); prop != nil {
+		icalPriority, _ = strconv.Atoi(prop.Value)
+	}
+	task.Priority = domain.PriorityFromICal(icalPriority)
+
+	task.DueDate = nil
+	if prop := comp.Props.Get("DUE"); prop != nil {
+		if due, err := parseICalTime(prop); err == nil {
+			task.DueDate = &due
+		}
+	}
+
+	task.Status = "NEEDS-ACTION"
+	if prop := comp.Props.Get("STATUS"); prop != nil && prop.Value != "" {
+		task.Status = strings.ToUpper(prop.Value)
+	}
+
+	task.CompletedAt = nil
+	if prop := comp.Props.Get("COMPLETED"); prop != nil {
+		if completed, err := parseICalTime(prop); err == nil {
+			task.CompletedAt = &completed
+		}
+	}
+	task.Completed = task.Status == "COMPLETED" || task.CompletedAt != nil
+	if task.Completed && task.CompletedAt == nil {
+		now := time.Now().UTC()
+		task.CompletedAt = &now
+	}
+}
+
+func (b *Backend) domainTaskToCalDAV(task *domain.Task, urlPath string) (*caldav.CalendarObject, error) {
+	icalCal, err := parseICalendar(task.ICS)
+	if err != nil {
+		// Log error with safe fields only (never log raw ICS - security)
+		slog.Error("failed to parse stored ICS",
+			"error", err,
+			"uid", task.UID,
+			"task_id", task.ID,
+		)
+		return nil, webdav.NewHTTPError(500, fmt.Errorf("corrupt stored ICS for task %s", task.UID))
+	}
+
+	return &caldav.CalendarObject{
+		Path:    urlPath,
+		ModTime: task.UpdatedAt,
+		ETag:    task.ETag,
+		Data:    icalCal,
+	}, nil
+}
+
+// getCalendarObject loads the event or task with the given UID.
+// Returns domain.ErrNotFound when neither exists.
+func (b *Backend) getCalendarObject(ctx context.Context, cal *domain.Calendar, uid, objPath string) (*caldav.CalendarObject, error) {
+	event, err := b.eventRepo.GetByUID(ctx, cal.ID, uid)
+	if err == nil {
+		return b.domainEventToCalDAV(event, objPath)
+	}
+	if err != domain.ErrNotFound {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	task, err := b.taskRepo.GetByUID(ctx, cal.ID, uid)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return b.domainTaskToCalDAV(task, objPath)
+}
+
+// listTaskObjects returns the tasks of a VTODO collection as calendar objects.
+func (b *Backend) listTaskObjects(ctx context.Context, cal *domain.Calendar, urlPath string) ([]caldav.CalendarObject, error) {
+	if !cal.SupportsComponent(domain.ComponentTodo) {
+		return nil, nil
+	}
+
+	tasks, err := b.taskRepo.ListByCalendar(ctx, cal.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	result := make([]caldav.CalendarObject, 0, len(tasks))
+	for _, task := range tasks {
+		calObj, err := b.domainTaskToCalDAV(task, ensureTrailingSlash(urlPath)+task.UID+".ics")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *calObj)
+	}
+	return result, nil
+}
+
+// objectETagInTx returns the ETag of the event or task with the given UID, or
+// "" when neither exists.
+func (b *Backend) objectETagInTx(ctx context.Context, tx *sql.Tx, calendarID int64, uid string) (string, error) {
+	event, err := b.eventRepo.WithTx(tx).GetByUID(ctx, calendarID, uid)
+	if err == nil {
+		return event.ETag, nil
+	}
+	if err != domain.ErrNotFound {
+		return "", fmt.Errorf("failed to get event: %w", err)
+	}
+	task, err := b.taskRepo.WithTx(tx).GetByUID(ctx, calendarID, uid)
+	if err == nil {
+		return task.ETag, nil
+	}
+	if err != domain.ErrNotFound {
+		return "", fmt.Errorf("failed to get task: %w", err)
+	}
+	return "", nil
+}
+
+// deleteTaskInTx removes the task with the given UID inside tx.
+func (b *Backend) deleteTaskInTx(ctx context.Context, tx *sql.Tx, calendarID int64, uid string) error {
+	taskRepoTx := b.taskRepo.WithTx(tx)
+	task, err := taskRepoTx.GetByUID(ctx, calendarID, uid)
+	if err != nil {
+		return err
+	}
+	return taskRepoTx.Delete(ctx, task.ID)
+}
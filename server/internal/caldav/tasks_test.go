@@ -0,0 +1,143 @@
+package caldav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func putTodo(t *testing.T, baseURL, calendar, uid, extra string) *http.Response {
+	t.Helper()
+
+	icsData := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VTODO\r\nUID:" + uid +
+		"\r\nDTSTAMP:20260116T080000Z\r\nSUMMARY:Buy milk\r\nPRIORITY:1\r\nDUE:20260120T170000Z\r\n" + extra +
+		"END:VTODO\r\nEND:VCALENDAR\r\n"
+
+	req, _ := http.NewRequest("PUT", baseURL+caldavBase+"/calendars/testuser/"+calendar+"/"+uid+".ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func createTasksCalendar(t *testing.T, userRepo domain.UserRepo, calRepo interface {
+	Create(context.Context, *domain.Calendar) error
+}) {
+	t.Helper()
+
+	user, err := userRepo.GetByUsername(context.Background(), "testuser")
+	if err != nil {
+		t.Fatalf("Failed to get test user: %v", err)
+	}
+	cal := &domain.Calendar{UserID: user.ID, Name: "tasks", DisplayName: "Tasks", ComponentSet: []string{domain.ComponentTodo}}
+	if err := calRepo.Create(context.Background(), cal); err != nil {
+		t.Fatalf("Failed to create tasks calendar: %v", err)
+	}
+}
+
+func TestCalDAV_VTODO_Lifecycle(t *testing.T) {
+	srv, userRepo, calRepo, _ := setupTestServer(t)
+	createTasksCalendar(t, userRepo, calRepo)
+
+	// The collection advertises VTODO
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><c:supported-calendar-component-set/></d:prop>
+</d:propfind>`
+	status, data := doXMLRequest(t, "PROPFIND", srv.URL+caldavBase+"/calendars/testuser/tasks/", "0", body)
+	if status != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND: expected 207, got %d", status)
+	}
+	if !strings.Contains(string(data), `name="VTODO"`) || strings.Contains(string(data), `name="VEVENT"`) {
+		t.Errorf("Expected VTODO-only component set, got: %s", string(data))
+	}
+
+	if resp := putTodo(t, srv.URL, "tasks", "todo-1", ""); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT VTODO: expected 201, got %d", resp.StatusCode)
+	}
+
+	// Round-trip
+	req, _ := http.NewRequest("GET", srv.URL+caldavBase+"/calendars/testuser/tasks/todo-1.ics", nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	ics, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(ics), "BEGIN:VTODO") {
+		t.Fatalf("GET: expected VTODO, got %d: %s", resp.StatusCode, string(ics))
+	}
+	etag := resp.Header.Get("ETag")
+
+	// Complete the task with If-Match
+	icsData := strings.Replace(string(ics), "END:VTODO", "STATUS:COMPLETED\r\nCOMPLETED:20260118T120000Z\r\nEND:VTODO", 1)
+	req, _ = http.NewRequest("PUT", srv.URL+caldavBase+"/calendars/testuser/tasks/todo-1.ics", strings.NewReader(icsData))
+	req.SetBasicAuth("testuser", "testpass")
+	req.Header.Set("Content-Type", "text/calendar")
+	req.Header.Set("If-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Completing PUT: expected 204, got %d", resp.StatusCode)
+	}
+
+	// The change shows up in sync-collection like events do
+	status, data = doXMLRequest(t, "REPORT", srv.URL+caldavBase+"/calendars/testuser/tasks/", "", `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:"><d:sync-token/><d:sync-level>1</d:sync-level><d:prop><d:getetag/></d:prop></d:sync-collection>`)
+	if status != http.StatusMultiStatus || !strings.Contains(string(data), "todo-1.ics") {
+		t.Fatalf("sync-collection: expected todo-1, got %d: %s", status, string(data))
+	}
+
+	req, _ = http.NewRequest("DELETE", srv.URL+caldavBase+"/calendars/testuser/tasks/todo-1.ics", nil)
+	req.SetBasicAuth("testuser", "testpass")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE: expected 204, got %d", resp.StatusCode)
+	}
+}
+
+func TestCalDAV_VTODO_RejectedByEventCalendar(t *testing.T) {
+	srv, _, _, _ := setupTestServer(t)
+
+	if resp := putTodo(t, srv.URL, "default", "todo-wrong", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for VTODO in a VEVENT calendar, got %d", resp.StatusCode)
+	}
+}
+
+func TestApplyTaskMetadata(t *testing.T) {
+	cal, err := parseICalendar("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\nBEGIN:VTODO\r\nUID:t\r\n" +
+		"DTSTAMP:20260116T080000Z\r\nSUMMARY:Ship it\r\nPRIORITY:5\r\nDUE:20260120T170000Z\r\n" +
+		"STATUS:COMPLETED\r\nCOMPLETED:20260118T120000Z\r\nEND:VTODO\r\nEND:VCALENDAR\r\n")
+	if err != nil {
+		t.Fatalf("parseICalendar failed: %v", err)
+	}
+
+	var task domain.Task
+	applyTaskMetadata(&task, cal)
+	if task.Content != "Ship it" || task.Priority != 3 || task.Status != "COMPLETED" || !task.Completed {
+		t.Errorf("Unexpected metadata: %+v", task)
+	}
+	if task.DueDate == nil || task.DueDate.Format("20060102T150405Z") != "20260120T170000Z" {
+		t.Errorf("Unexpected due date: %v", task.DueDate)
+	}
+	if task.CompletedAt == nil || task.CompletedAt.Format("20060102T150405Z") != "20260118T120000Z" {
+		t.Errorf("Unexpected completion time: %v", task.CompletedAt)
+	}
+}
@@ -5,10 +5,10 @@ import (
 )
 
 // WellKnownHandler handles /.well-known/caldav requests
-// Redirects to the CalDAV principal URL for auto-discovery
+// Redirects to the CalDAV context path for auto-discovery
 type WellKnownHandler struct {
-	// PrincipalPath is the path to redirect to
-	// For MVP single-user: /dav/principals/testuser/
+	// PrincipalPath is the path to redirect to: /dav/, where clients find
+	// their own principal through current-user-principal
 	PrincipalPath string
 }
 
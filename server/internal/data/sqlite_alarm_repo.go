@@ -0,0 +1,211 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const reminderColumns = `f.id, f.user_id, f.event_id, e.calendar_id, e.uid, f.alarm_key, f.action, f.summary, f.description,
+	f.occurrence, f.instance_start, f.trigger_at, f.fired_at, f.acknowledged_at`
+
+// SQLiteAlarmRepo implements domain.AlarmRepo using SQLite
+type SQLiteAlarmRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteAlarmRepo creates a new SQLite alarm repository
+func NewSQLiteAlarmRepo(db *sql.DB) *SQLiteAlarmRepo {
+	return &SQLiteAlarmRepo{db: db}
+}
+
+// WithTx returns a new SQLiteAlarmRepo that operates within the given transaction.
+func (r *SQLiteAlarmRepo) WithTx(tx *sql.Tx) *SQLiteAlarmRepo {
+	return &SQLiteAlarmRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteAlarmRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// ListDueEvents returns events with alarms that may trigger in [from, to),
+// ordered by ID. Overrides are not loaded; the scheduler works from the ICS.
+func (r *SQLiteAlarmRepo) ListDueEvents(ctx context.Context, from, to time.Time) ([]*domain.Event, error) {
+	query := `
+		SELECT id, calendar_id, uid, ics, summary, description, location,
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
+		FROM events
+		WHERE id IN (
+			SELECT event_id FROM event_alarms
+			WHERE recurring = 1 OR (first_trigger_at < ? AND last_trigger_at >= ?)
+		)
+		ORDER BY id ASC
+	`
+	return r.queryEvents(ctx, query, to.UTC(), from.UTC())
+}
+
+// ListUnindexedEvents returns events whose ICS has a VALARM but that have no
+// indexed alarms, e.g. events stored before alarms were indexed.
+func (r *SQLiteAlarmRepo) ListUnindexedEvents(ctx context.Context) ([]*domain.Event, error) {
+	query := `
+		SELECT id, calendar_id, uid, ics, summary, description, location,
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
+		FROM events
+		WHERE ics LIKE '%BEGIN:VALARM%'
+			AND id NOT IN (SELECT event_id FROM event_alarms)
+		ORDER BY id ASC
+	`
+	return r.queryEvents(ctx, query)
+}
+
+func (r *SQLiteAlarmRepo) queryEvents(ctx context.Context, query string, args ...interface{}) ([]*domain.Event, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events with alarms: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ReplaceEventAlarms rewrites the alarm index of an event without changing
+// the event, e.g. to index events stored before alarms were.
+func (r *SQLiteAlarmRepo) ReplaceEventAlarms(ctx context.Context, eventID int64, alarms []domain.EventAlarm) error {
+	if r.tx == nil {
+		return WithTx(ctx, r.db, func(tx *sql.Tx) error {
+			return replaceAlarms(ctx, tx, eventID, alarms)
+		})
+	}
+	return replaceAlarms(ctx, r.tx, eventID, alarms)
+}
+
+// RecordFiring stores a fired reminder. Returns domain.ErrConflict when the
+// same trigger of the same instance was already recorded.
+func (r *SQLiteAlarmRepo) RecordFiring(ctx context.Context, reminder *domain.Reminder) error {
+	query := `
+		INSERT INTO alarm_firings (
+			user_id, event_id, alarm_key, action, summary, description,
+			occurrence, instance_start, trigger_at, fired_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if reminder.FiredAt.IsZero() {
+		reminder.FiredAt = time.Now()
+	}
+	result, err := r.execer().ExecContext(ctx, query,
+		reminder.UserID,
+		reminder.EventID,
+		reminder.AlarmKey,
+		reminder.Action,
+		reminder.Summary,
+		reminder.Description,
+		reminder.Occurrence.UTC(),
+		reminder.InstanceStart.UTC(),
+		reminder.TriggerAt.UTC(),
+		reminder.FiredAt.UTC(),
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to record alarm firing: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	reminder.ID = id
+	return nil
+}
+
+func (r *SQLiteAlarmRepo) GetFiring(ctx context.Context, id int64) (*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM alarm_firings f JOIN events e ON e.id = f.event_id WHERE f.id = ?`
+
+	reminder, err := scanReminder(r.execer().QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get alarm firing: %w", err)
+	}
+	return reminder, nil
+}
+
+// ListFirings returns the reminders fired for a user since the given time,
+// newest first.
+func (r *SQLiteAlarmRepo) ListFirings(ctx context.Context, userID int64, since time.Time) ([]*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM alarm_firings f JOIN events e ON e.id = f.event_id
+		WHERE f.user_id = ? AND f.fired_at >= ? ORDER BY f.fired_at DESC, f.id DESC`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alarm firings: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []*domain.Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alarm firing: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+// AcknowledgeFiring marks a reminder dismissed. Acknowledging it again keeps
+// the first time.
+func (r *SQLiteAlarmRepo) AcknowledgeFiring(ctx context.Context, id int64, at time.Time) error {
+	result, err := r.execer().ExecContext(ctx,
+		`UPDATE alarm_firings SET acknowledged_at = COALESCE(acknowledged_at, ?) WHERE id = ?`, at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge alarm firing: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func scanReminder(row interface{ Scan(dest ...any) error }) (*domain.Reminder, error) {
+	var m domain.Reminder
+	var acknowledgedAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.UserID, &m.EventID, &m.CalendarID, &m.UID, &m.AlarmKey, &m.Action, &m.Summary, &m.Description,
+		&m.Occurrence, &m.InstanceStart, &m.TriggerAt, &m.FiredAt, &acknowledgedAt); err != nil {
+		return nil, err
+	}
+	if acknowledgedAt.Valid {
+		m.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return &m, nil
+}
@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteAlarmRepo_ListDueEvents(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	cal := createTestCalendar(t, db, createTestUser(t, db))
+	events := NewSQLiteEventRepo(db)
+	repo := NewSQLiteAlarmRepo(db)
+	base := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	create := func(uid string, alarms ...domain.EventAlarm) *domain.Event {
+		t.Helper()
+		ics := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:" + uid + "\r\nBEGIN:VALARM\r\nEND:VALARM\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+		event := &domain.Event{CalendarID: cal.ID, UID: uid, ICS: ics, StartTime: base, EndTime: base.Add(time.Hour),
+			ETag: domain.GenerateETag([]byte(ics)), Status: "CONFIRMED", Alarms: alarms}
+		if err := events.Create(ctx, event); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return event
+	}
+	soon := create("soon", domain.EventAlarm{Key: "1", Action: "DISPLAY", FirstTrigger: base.Add(-15 * time.Minute), LastTrigger: base.Add(-5 * time.Minute)})
+	create("later", domain.EventAlarm{Key: "1", Action: "DISPLAY", FirstTrigger: base.Add(24 * time.Hour), LastTrigger: base.Add(24 * time.Hour)})
+	weekly := create("weekly", domain.EventAlarm{Key: "1", Action: "DISPLAY", Recurring: true})
+	create("acknowledged", domain.EventAlarm{Key: "1", Action: "DISPLAY"})
+	if err := events.Create(ctx, &domain.Event{CalendarID: cal.ID, UID: "legacy", ICS: "BEGIN:VALARM", StartTime: base, EndTime: base, ETag: `"legacy"`, Status: "CONFIRMED"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	due, err := repo.ListDueEvents(ctx, base.Add(-10*time.Minute), base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ListDueEvents failed: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != soon.ID || due[1].ID != weekly.ID {
+		t.Fatalf("Expected the overlapping and the recurring event, got %d events", len(due))
+	}
+
+	// Updating an event replaces its index
+	soon.Alarms = nil
+	if err := events.Update(ctx, soon, soon.ETag); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	due, err = repo.ListDueEvents(ctx, base.Add(-10*time.Minute), base.Add(time.Hour))
+	if err != nil || len(due) != 1 {
+		t.Fatalf("Expected only the recurring event after removing alarms, got %d, %v", len(due), err)
+	}
+
+	unindexed, err := repo.ListUnindexedEvents(ctx)
+	if err != nil {
+		t.Fatalf("ListUnindexedEvents failed: %v", err)
+	}
+	if len(unindexed) != 2 {
+		t.Fatalf("Expected the legacy event and the one whose alarms were removed, got %d", len(unindexed))
+	}
+	if err := repo.ReplaceEventAlarms(ctx, unindexed[0].ID, []domain.EventAlarm{{Key: "1"}}); err != nil {
+		t.Fatalf("ReplaceEventAlarms failed: %v", err)
+	}
+	if unindexed, _ := repo.ListUnindexedEvents(ctx); len(unindexed) != 1 {
+		t.Errorf("Expected 1 unindexed event after reindexing, got %d", len(unindexed))
+	}
+}
+
+func TestSQLiteAlarmRepo_Firings(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+	event := &domain.Event{CalendarID: cal.ID, UID: "standup", ICS: "x", StartTime: time.Now(), EndTime: time.Now(), ETag: `"x"`, Status: "CONFIRMED"}
+	if err := NewSQLiteEventRepo(db).Create(ctx, event); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	repo := NewSQLiteAlarmRepo(db)
+	occurrence := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	reminder := &domain.Reminder{UserID: userID, EventID: event.ID, AlarmKey: "1", Action: "DISPLAY", Summary: "Standup",
+		Occurrence: occurrence, InstanceStart: occurrence, TriggerAt: occurrence.Add(-15 * time.Minute)}
+	if err := repo.RecordFiring(ctx, reminder); err != nil {
+		t.Fatalf("RecordFiring failed: %v", err)
+	}
+	if reminder.ID == 0 || reminder.FiredAt.IsZero() {
+		t.Errorf("RecordFiring did not fill in the reminder: %+v", reminder)
+	}
+	again := *reminder
+	again.ID = 0
+	if err := repo.RecordFiring(ctx, &again); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for a trigger that already fired, got %v", err)
+	}
+
+	got, err := repo.GetFiring(ctx, reminder.ID)
+	if err != nil {
+		t.Fatalf("GetFiring failed: %v", err)
+	}
+	if got.UID != "standup" || got.CalendarID != cal.ID || !got.TriggerAt.Equal(reminder.TriggerAt) || got.AcknowledgedAt != nil {
+		t.Errorf("Unexpected reminder: %+v", got)
+	}
+
+	ackAt := time.Now().Truncate(time.Second)
+	if err := repo.AcknowledgeFiring(ctx, reminder.ID, ackAt); err != nil {
+		t.Fatalf("AcknowledgeFiring failed: %v", err)
+	}
+	if err := repo.AcknowledgeFiring(ctx, reminder.ID, ackAt.Add(time.Hour)); err != nil {
+		t.Fatalf("AcknowledgeFiring failed: %v", err)
+	}
+	list, err := repo.ListFirings(ctx, userID, time.Now().Add(-time.Hour))
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListFirings = %v, %v; want 1 reminder", list, err)
+	}
+	if list[0].AcknowledgedAt == nil || !list[0].AcknowledgedAt.Equal(ackAt) {
+		t.Errorf("Expected the first acknowledgement to stick, got %v", list[0].AcknowledgedAt)
+	}
+	if err := repo.AcknowledgeFiring(ctx, 999, ackAt); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
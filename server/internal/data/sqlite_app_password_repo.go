@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const appPasswordColumns = `id, user_id, name, token_hash, created_at, last_used_at, last_client_fingerprint, revoked_at`
+
+// SQLiteAppPasswordRepo implements domain.AppPasswordRepo using SQLite
+type SQLiteAppPasswordRepo struct {
+	db *sql.DB
+}
+
+// NewSQLiteAppPasswordRepo creates a new SQLite app password repository
+func NewSQLiteAppPasswordRepo(db *sql.DB) *SQLiteAppPasswordRepo {
+	return &SQLiteAppPasswordRepo{db: db}
+}
+
+func (r *SQLiteAppPasswordRepo) Create(ctx context.Context, password *domain.AppPassword) error {
+	query := `INSERT INTO app_passwords (user_id, name, token_hash, created_at) VALUES (?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, password.UserID, password.Name, password.TokenHash, now)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to create app password: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	password.ID = id
+	password.CreatedAt = now
+	return nil
+}
+
+// GetByTokenHash returns the app password with the given hash, revoked or not
+func (r *SQLiteAppPasswordRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.AppPassword, error) {
+	query := `SELECT ` + appPasswordColumns + ` FROM app_passwords WHERE token_hash = ?`
+
+	p, err := scanAppPassword(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get app password: %w", err)
+	}
+	return p, nil
+}
+
+// ListByUser returns a user's app passwords, newest first, including revoked ones
+func (r *SQLiteAppPasswordRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.AppPassword, error) {
+	query := `SELECT ` + appPasswordColumns + ` FROM app_passwords WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list app passwords: %w", err)
+	}
+	defer rows.Close()
+
+	var passwords []*domain.AppPassword
+	for rows.Next() {
+		p, err := scanAppPassword(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan app password: %w", err)
+		}
+		passwords = append(passwords, p)
+	}
+	return passwords, rows.Err()
+}
+
+func (r *SQLiteAppPasswordRepo) Revoke(ctx context.Context, userID, id int64, at time.Time) error {
+	query := `UPDATE app_passwords SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, at, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke app password: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteAppPasswordRepo) RecordUse(ctx context.Context, id int64, at time.Time, clientFingerprint string) error {
+	query := `UPDATE app_passwords SET last_used_at = ?, last_client_fingerprint = ? WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, at, clientFingerprint, id); err != nil {
+		return fmt.Errorf("failed to record app password use: %w", err)
+	}
+	return nil
+}
+
+func scanAppPassword(row interface{ Scan(dest ...any) error }) (*domain.AppPassword, error) {
+	var p domain.AppPassword
+	var lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.TokenHash, &p.CreatedAt, &lastUsedAt, &p.LastClientFingerprint, &revokedAt); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		p.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		p.RevokedAt = &revokedAt.Time
+	}
+	return &p, nil
+}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteAppPasswordRepo_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteAppPasswordRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+
+	phone := &domain.AppPassword{UserID: userID, Name: "iPhone", TokenHash: "hash-phone"}
+	if err := repo.Create(ctx, phone); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	laptop := &domain.AppPassword{UserID: userID, Name: "Thunderbird laptop", TokenHash: "hash-laptop"}
+	if err := repo.Create(ctx, laptop); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, &domain.AppPassword{UserID: userID, Name: "dup", TokenHash: "hash-phone"}); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for duplicate hash, got %v", err)
+	}
+
+	usedAt := time.Now().UTC().Truncate(time.Second)
+	if err := repo.RecordUse(ctx, phone.ID, usedAt, domain.CalDAVClientAppleCalendar); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	got, err := repo.GetByTokenHash(ctx, "hash-phone")
+	if err != nil {
+		t.Fatalf("GetByTokenHash failed: %v", err)
+	}
+	if got.Name != "iPhone" || got.LastUsedAt == nil || !got.LastUsedAt.Equal(usedAt) || got.LastClientFingerprint != domain.CalDAVClientAppleCalendar {
+		t.Errorf("Use not recorded: %+v", got)
+	}
+
+	if err := repo.Revoke(ctx, userID+1, phone.ID, usedAt); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound revoking another user's app password, got %v", err)
+	}
+	if err := repo.Revoke(ctx, userID, phone.ID, usedAt); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if err := repo.Revoke(ctx, userID, phone.ID, usedAt); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound revoking twice, got %v", err)
+	}
+
+	list, err := repo.ListByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 app passwords, got %d", len(list))
+	}
+	for _, p := range list {
+		if p.Revoked() != (p.ID == phone.ID) {
+			t.Errorf("Only the phone should be revoked, got %+v", p)
+		}
+	}
+
+	if _, err := repo.GetByTokenHash(ctx, "missing"); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const auditLogColumns = `id, user_id, action, entity_type, entity_id, summary, created_at`
+
+// SQLiteAuditLogRepo implements domain.AuditLogRepo using SQLite
+type SQLiteAuditLogRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteAuditLogRepo creates a new SQLite audit log repository
+func NewSQLiteAuditLogRepo(db *sql.DB) *SQLiteAuditLogRepo {
+	return &SQLiteAuditLogRepo{db: db}
+}
+
+// WithTx returns a new SQLiteAuditLogRepo that operates within the given transaction.
+func (r *SQLiteAuditLogRepo) WithTx(tx *sql.Tx) *SQLiteAuditLogRepo {
+	return &SQLiteAuditLogRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteAuditLogRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Create stores the entry with its changes
+func (r *SQLiteAuditLogRepo) Create(ctx context.Context, entry *domain.AuditLogEntry) error {
+	if r.tx != nil {
+		return r.createInTx(ctx, r.tx, entry)
+	}
+	return WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.createInTx(ctx, tx, entry)
+	})
+}
+
+func (r *SQLiteAuditLogRepo) createInTx(ctx context.Context, tx *sql.Tx, entry *domain.AuditLogEntry) error {
+	query := `INSERT INTO audit_log (user_id, action, entity_type, entity_id, summary, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := tx.ExecContext(ctx, query, entry.UserID, string(entry.Action), entry.EntityType, entry.EntityID, entry.Summary, now)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log entry: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	changeQuery := `INSERT INTO audit_log_changes (entry_id, position, calendar_id, uid, summary, before_ics, after_ics, before_etag, after_etag)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for i, c := range entry.Changes {
+		if _, err := tx.ExecContext(ctx, changeQuery, id, i, c.CalendarID, c.UID, c.Summary, c.BeforeICS, c.AfterICS,
+			c.BeforeETag, c.AfterETag); err != nil {
+			return fmt.Errorf("failed to store audit log change: %w", err)
+		}
+	}
+
+	entry.ID = id
+	entry.CreatedAt = now
+	return nil
+}
+
+func (r *SQLiteAuditLogRepo) GetByID(ctx context.Context, id int64) (*domain.AuditLogEntry, error) {
+	query := `SELECT ` + auditLogColumns + ` FROM audit_log WHERE id = ?`
+
+	entry, err := scanAuditLogEntry(r.execer().QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get audit log entry: %w", err)
+	}
+	if err := r.loadChanges(ctx, []*domain.AuditLogEntry{entry}); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ListByUser returns a user's most recent entries, newest first
+func (r *SQLiteAuditLogRepo) ListByUser(ctx context.Context, userID int64, limit int) ([]*domain.AuditLogEntry, error) {
+	query := `SELECT ` + auditLogColumns + ` FROM audit_log WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditLogEntry
+	for rows.Next() {
+		entry, err := scanAuditLogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadChanges(ctx, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// MarkReverted records that rollback entry by reverted the changes of entryID
+// at the given positions
+func (r *SQLiteAuditLogRepo) MarkReverted(ctx context.Context, entryID int64, positions []int, by int64) error {
+	query := `UPDATE audit_log_changes SET reverted_by = ? WHERE entry_id = ? AND position = ? AND reverted_by IS NULL`
+
+	for _, position := range positions {
+		result, err := r.execer().ExecContext(ctx, query, by, entryID, position)
+		if err != nil {
+			return fmt.Errorf("failed to mark audit log change reverted: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return domain.ErrConflict
+		}
+	}
+	return nil
+}
+
+// loadChanges fills in the changes of the given entries
+func (r *SQLiteAuditLogRepo) loadChanges(ctx context.Context, entries []*domain.AuditLogEntry) error {
+	for _, entry := range entries {
+		rows, err := r.execer().QueryContext(ctx, `SELECT calendar_id, uid, summary, before_ics, after_ics, before_etag, after_etag, reverted_by
+			FROM audit_log_changes WHERE entry_id = ? ORDER BY position`, entry.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load audit log changes: %w", err)
+		}
+		for rows.Next() {
+			var c domain.AuditChange
+			var revertedBy sql.NullInt64
+			if err := rows.Scan(&c.CalendarID, &c.UID, &c.Summary, &c.BeforeICS, &c.AfterICS, &c.BeforeETag, &c.AfterETag, &revertedBy); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan audit log change: %w", err)
+			}
+			c.RevertedBy = revertedBy.Int64
+			entry.Changes = append(entry.Changes, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanAuditLogEntry(row interface{ Scan(dest ...any) error }) (*domain.AuditLogEntry, error) {
+	var entry domain.AuditLogEntry
+	var action string
+	var entityType, entityID sql.NullString
+	if err := row.Scan(&entry.ID, &entry.UserID, &action, &entityType, &entityID, &entry.Summary, &entry.CreatedAt); err != nil {
+		return nil, err
+	}
+	entry.Action = domain.AuditAction(action)
+	entry.EntityType = entityType.String
+	entry.EntityID = entityID.String
+	return &entry, nil
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteAuditLogRepo_CreateInTxAndList(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteAuditLogRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+
+	entry := &domain.AuditLogEntry{
+		UserID:     userID,
+		Action:     domain.AuditPlanApply,
+		EntityType: domain.AuditEntityPlanProposal,
+		EntityID:   "7",
+		Summary:    "Protect Monday morning",
+		Changes: []domain.AuditChange{
+			{CalendarID: cal.ID, UID: "focus-1", Summary: "Created Focus time", AfterICS: "BEGIN:VCALENDAR...", AfterETag: `"new"`},
+			{CalendarID: cal.ID, UID: "standup", Summary: "Shortened standup", BeforeICS: "before", AfterICS: "after", BeforeETag: `"a"`, AfterETag: `"b"`},
+		},
+	}
+
+	// A failed transaction leaves no entry behind
+	err := WithTx(ctx, db, func(tx *sql.Tx) error {
+		if err := repo.WithTx(tx).Create(ctx, entry); err != nil {
+			return err
+		}
+		return sql.ErrTxDone
+	})
+	if err != sql.ErrTxDone {
+		t.Fatalf("WithTx error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, entry.ID); err != domain.ErrNotFound {
+		t.Fatalf("GetByID after rollback error = %v, want ErrNotFound", err)
+	}
+
+	entry.ID = 0
+	if err := WithTx(ctx, db, func(tx *sql.Tx) error { return repo.WithTx(tx).Create(ctx, entry) }); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	got, err := repo.GetByID(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Action != domain.AuditPlanApply || got.EntityID != "7" || got.Summary != entry.Summary || got.CreatedAt.IsZero() || len(got.Changes) != 2 {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	if c := got.Changes[1]; c != entry.Changes[1] || !got.RollbackAvailable() {
+		t.Errorf("second change = %+v, want %+v", c, entry.Changes[1])
+	}
+
+	// Each change is reverted at most once
+	if err := repo.MarkReverted(ctx, entry.ID, []int{1}, 99); err != nil {
+		t.Fatalf("MarkReverted failed: %v", err)
+	}
+	if err := repo.MarkReverted(ctx, entry.ID, []int{1}, 100); err != domain.ErrConflict {
+		t.Fatalf("second MarkReverted error = %v, want ErrConflict", err)
+	}
+	got, err = repo.GetByID(ctx, entry.ID)
+	if err != nil || got.Changes[0].RevertedBy != 0 || got.Changes[1].RevertedBy != 99 || !got.RollbackAvailable() {
+		t.Fatalf("after MarkReverted = %+v, %v", got, err)
+	}
+
+	second := &domain.AuditLogEntry{UserID: userID, Action: domain.AuditPlanApply, Summary: "second"}
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	entries, err := repo.ListByUser(ctx, userID, 10)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != second.ID || len(entries[1].Changes) != 2 {
+		t.Fatalf("ListByUser = %+v", entries)
+	}
+}
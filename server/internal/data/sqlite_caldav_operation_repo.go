@@ -53,9 +53,9 @@ func (r *SQLiteCalDAVOperationRepo) Record(operation *domain.CalDAVOperation) er
 	query := `
 		INSERT INTO caldav_operations (
 			operation_id, occurred_at, method, path_pattern, status_code, duration_ms,
-			client_fingerprint, etag_outcome, operation_kind, outcome,
+			client_fingerprint, credential, etag_outcome, operation_kind, outcome,
 			error_code, redacted_error, request_size_bytes, response_size_bytes
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err := r.db.ExecContext(context.Background(), query,
 		operation.ID,
@@ -65,6 +65,7 @@ func (r *SQLiteCalDAVOperationRepo) Record(operation *domain.CalDAVOperation) er
 		operation.StatusCode,
 		operation.DurationMillis,
 		operation.ClientFingerprint,
+		operation.Credential,
 		string(operation.ETagOutcome),
 		string(operation.OperationKind),
 		string(operation.Outcome),
@@ -105,7 +106,7 @@ func (r *SQLiteCalDAVOperationRepo) ListRecent(ctx context.Context, limit int) (
 	}
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT operation_id, occurred_at, method, path_pattern, status_code, duration_ms,
-		       client_fingerprint, etag_outcome, operation_kind, outcome,
+		       client_fingerprint, credential, etag_outcome, operation_kind, outcome,
 		       error_code, redacted_error, request_size_bytes, response_size_bytes
 		FROM caldav_operations
 		ORDER BY occurred_at DESC, id DESC
@@ -128,6 +129,7 @@ func (r *SQLiteCalDAVOperationRepo) ListRecent(ctx context.Context, limit int) (
 			&op.StatusCode,
 			&op.DurationMillis,
 			&op.ClientFingerprint,
+			&op.Credential,
 			&etagOutcome,
 			&kind,
 			&outcome,
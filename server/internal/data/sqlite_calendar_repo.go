@@ -43,22 +43,27 @@ func (r *SQLiteCalendarRepo) execer() interface {
 	return r.db
 }
 
+const calendarColumns = `id, user_id, name, display_name, color, description, calendar_order, timezone, sync_token, component_set, created_at, updated_at`
+
 func (r *SQLiteCalendarRepo) Create(ctx context.Context, calendar *domain.Calendar) error {
 	if err := calendar.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	query := `INSERT INTO calendars (user_id, name, display_name, color, description, sync_token, created_at, updated_at)
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO calendars (user_id, name, display_name, color, description, calendar_order, timezone, sync_token, component_set, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.execer().ExecContext(ctx, query,
 		calendar.UserID,
 		calendar.Name,
 		calendar.DisplayName,
 		nullString(calendar.Color),
 		nullString(calendar.Description),
+		calendar.Order,
+		nullString(calendar.Timezone),
 		nullString(calendar.SyncToken),
+		formatComponentSet(calendar.Components()),
 		now,
 		now,
 	)
@@ -82,10 +87,10 @@ func (r *SQLiteCalendarRepo) Create(ctx context.Context, calendar *domain.Calend
 }
 
 func (r *SQLiteCalendarRepo) GetByID(ctx context.Context, id int64) (*domain.Calendar, error) {
-	query := `SELECT id, user_id, name, display_name, color, description, sync_token, created_at, updated_at
+	query := `SELECT ` + calendarColumns + `
 	          FROM calendars WHERE id = ?`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	row := r.execer().QueryRowContext(ctx, query, id)
 	calendar, err := scanCalendar(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -98,10 +103,10 @@ func (r *SQLiteCalendarRepo) GetByID(ctx context.Context, id int64) (*domain.Cal
 }
 
 func (r *SQLiteCalendarRepo) GetByName(ctx context.Context, userID int64, name string) (*domain.Calendar, error) {
-	query := `SELECT id, user_id, name, display_name, color, description, sync_token, created_at, updated_at
+	query := `SELECT ` + calendarColumns + `
 	          FROM calendars WHERE user_id = ? AND name = ?`
 
-	row := r.db.QueryRowContext(ctx, query, userID, name)
+	row := r.execer().QueryRowContext(ctx, query, userID, name)
 	calendar, err := scanCalendar(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -114,10 +119,10 @@ func (r *SQLiteCalendarRepo) GetByName(ctx context.Context, userID int64, name s
 }
 
 func (r *SQLiteCalendarRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.Calendar, error) {
-	query := `SELECT id, user_id, name, display_name, color, description, sync_token, created_at, updated_at
+	query := `SELECT ` + calendarColumns + `
 	          FROM calendars WHERE user_id = ? ORDER BY created_at`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.execer().QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list calendars: %w", err)
 	}
@@ -145,15 +150,18 @@ func (r *SQLiteCalendarRepo) Update(ctx context.Context, calendar *domain.Calend
 	}
 
 	query := `UPDATE calendars
-	          SET name = ?, display_name = ?, color = ?, description = ?, updated_at = ?
+	          SET name = ?, display_name = ?, color = ?, description = ?, calendar_order = ?, timezone = ?, component_set = ?, updated_at = ?
 	          WHERE id = ?`
 
 	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.execer().ExecContext(ctx, query,
 		calendar.Name,
 		calendar.DisplayName,
 		nullString(calendar.Color),
 		nullString(calendar.Description),
+		calendar.Order,
+		nullString(calendar.Timezone),
+		formatComponentSet(calendar.Components()),
 		now,
 		calendar.ID,
 	)
@@ -180,7 +188,7 @@ func (r *SQLiteCalendarRepo) Update(ctx context.Context, calendar *domain.Calend
 func (r *SQLiteCalendarRepo) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM calendars WHERE id = ?`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.execer().ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete calendar: %w", err)
 	}
@@ -196,12 +204,38 @@ func (r *SQLiteCalendarRepo) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// IncrementSyncToken bumps the calendar's sync token without attributing the
+// change to a specific object. Object writes should use RecordChange instead so
+// sync-collection can report them.
 func (r *SQLiteCalendarRepo) IncrementSyncToken(ctx context.Context, calendarID int64) (string, error) {
-	newToken := fmt.Sprintf("v%d", time.Now().UnixNano())
+	return r.RecordChange(ctx, calendarID, "", domain.CalendarChangeUpdated)
+}
 
+// RecordChange appends an entry to the calendar change log and sets the
+// calendar's sync token to the new revision. Call it in the same transaction as
+// the object write so the token never runs ahead of (or behind) the data.
+func (r *SQLiteCalendarRepo) RecordChange(ctx context.Context, calendarID int64, uid string, changeType domain.CalendarChangeType) (string, error) {
+	now := time.Now()
 	result, err := r.execer().ExecContext(ctx,
+		"INSERT INTO calendar_changes (calendar_id, uid, change_type, created_at) VALUES (?, ?, ?, ?)",
+		calendarID, uid, string(changeType), now,
+	)
+	if err != nil {
+		if isForeignKeyConstraintError(err) {
+			return "", domain.ErrNotFound
+		}
+		return "", fmt.Errorf("failed to record calendar change: %w", err)
+	}
+
+	revision, err := result.LastInsertId()
+	if err != nil {
+		return "", fmt.Errorf("failed to get change revision: %w", err)
+	}
+	newToken := domain.FormatSyncToken(revision)
+
+	result, err = r.execer().ExecContext(ctx,
 		"UPDATE calendars SET sync_token = ?, updated_at = ? WHERE id = ?",
-		newToken, time.Now(), calendarID,
+		newToken, now, calendarID,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to update sync token: %w", err)
@@ -218,10 +252,94 @@ func (r *SQLiteCalendarRepo) IncrementSyncToken(ctx context.Context, calendarID
 	return newToken, nil
 }
 
+// ListChangesSince returns object-level changes with a revision greater than the
+// given one, oldest first. Collection-level entries (empty UID) are omitted.
+func (r *SQLiteCalendarRepo) ListChangesSince(ctx context.Context, calendarID int64, revision int64) ([]*domain.CalendarChange, error) {
+	query := `SELECT id, calendar_id, uid, change_type, created_at
+	          FROM calendar_changes
+	          WHERE calendar_id = ? AND id > ? AND uid <> ''
+	          ORDER BY id ASC`
+
+	rows, err := r.execer().QueryContext(ctx, query, calendarID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*domain.CalendarChange
+	for rows.Next() {
+		var c domain.CalendarChange
+		var changeType string
+		if err := rows.Scan(&c.Revision, &c.CalendarID, &c.UID, &changeType, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar change: %w", err)
+		}
+		c.Type = domain.CalendarChangeType(changeType)
+		changes = append(changes, &c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return changes, nil
+}
+
+// HasChange reports whether revision is an entry of the calendar's change
+// log, collection-level entries included. Every sync token a calendar issued
+// past revision 0 names one.
+func (r *SQLiteCalendarRepo) HasChange(ctx context.Context, calendarID int64, revision int64) (bool, error) {
+	var exists bool
+	err := r.execer().QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM calendar_changes WHERE calendar_id = ? AND id = ?)",
+		calendarID, revision,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up calendar change: %w", err)
+	}
+	return exists, nil
+}
+
+// RecordTombstone remembers a calendar that is about to be deleted along with
+// the change-log revision it had reached.
+func (r *SQLiteCalendarRepo) RecordTombstone(ctx context.Context, calendar *domain.Calendar) error {
+	revision, err := domain.ParseSyncToken(calendar.SyncToken)
+	if err != nil {
+		revision = 0
+	}
+
+	_, err = r.execer().ExecContext(ctx,
+		"INSERT INTO calendar_tombstones (user_id, name, revision, deleted_at) VALUES (?, ?, ?, ?)",
+		calendar.UserID, calendar.Name, revision, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record calendar tombstone: %w", err)
+	}
+	return nil
+}
+
+// GetTombstone returns the most recent tombstone for a calendar name
+func (r *SQLiteCalendarRepo) GetTombstone(ctx context.Context, userID int64, name string) (*domain.CalendarTombstone, error) {
+	query := `SELECT id, user_id, name, revision, deleted_at
+	          FROM calendar_tombstones WHERE user_id = ? AND name = ?
+	          ORDER BY id DESC LIMIT 1`
+
+	var t domain.CalendarTombstone
+	err := r.execer().QueryRowContext(ctx, query, userID, name).Scan(&t.ID, &t.UserID, &t.Name, &t.Revision, &t.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar tombstone: %w", err)
+	}
+	return &t, nil
+}
+
 // scanCalendar scans a row into a Calendar struct
 func scanCalendar(row interface{ Scan(...interface{}) error }) (*domain.Calendar, error) {
 	var c domain.Calendar
-	var color, description, syncToken sql.NullString
+	var color, description, timezone, syncToken sql.NullString
+	var order sql.NullInt64
+	var componentSet string
 
 	err := row.Scan(
 		&c.ID,
@@ -230,7 +348,10 @@ func scanCalendar(row interface{ Scan(...interface{}) error }) (*domain.Calendar
 		&c.DisplayName,
 		&color,
 		&description,
+		&order,
+		&timezone,
 		&syncToken,
+		&componentSet,
 		&c.CreatedAt,
 		&c.UpdatedAt,
 	)
@@ -240,11 +361,33 @@ func scanCalendar(row interface{ Scan(...interface{}) error }) (*domain.Calendar
 
 	c.Color = fromNullString(color)
 	c.Description = fromNullString(description)
+	c.Timezone = fromNullString(timezone)
 	c.SyncToken = fromNullString(syncToken)
+	if order.Valid {
+		value := int(order.Int64)
+		c.Order = &value
+	}
+	c.ComponentSet = parseComponentSet(componentSet)
 
 	return &c, nil
 }
 
+// formatComponentSet stores a component set as a comma-separated list
+func formatComponentSet(components []string) string {
+	return strings.Join(components, ",")
+}
+
+// parseComponentSet is the inverse of formatComponentSet
+func parseComponentSet(s string) []string {
+	var components []string
+	for _, comp := range strings.Split(s, ",") {
+		if comp = strings.TrimSpace(comp); comp != "" {
+			components = append(components, comp)
+		}
+	}
+	return components
+}
+
 // nullString converts a string to sql.NullString
 func nullString(s string) sql.NullString {
 	if s == "" {
@@ -269,3 +412,11 @@ func isUniqueConstraintError(err error) bool {
 	// SQLite unique constraint error message contains "UNIQUE constraint failed"
 	return strings.Contains(err.Error(), "UNIQUE constraint failed")
 }
+
+// isForeignKeyConstraintError checks if error is a foreign key violation
+func isForeignKeyConstraintError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
@@ -272,6 +272,8 @@ func TestSQLiteCalendarRepo_Update(t *testing.T) {
 	calendar.DisplayName = "Updated Personal Calendar"
 	calendar.Color = "#00FF00"
 	calendar.Description = "New description"
+	order := 3
+	calendar.Order = &order
 
 	if err := repo.Update(ctx, calendar); err != nil {
 		t.Fatalf("Update failed: %v", err)
@@ -288,6 +290,9 @@ func TestSQLiteCalendarRepo_Update(t *testing.T) {
 	if got.Description != "New description" {
 		t.Errorf("Description not updated: got %s", got.Description)
 	}
+	if got.Order == nil || *got.Order != 3 {
+		t.Errorf("Order not updated: got %v", got.Order)
+	}
 }
 
 func TestSQLiteCalendarRepo_Update_NotFound(t *testing.T) {
@@ -418,3 +423,120 @@ func TestSQLiteCalendarRepo_IncrementSyncToken_NotFound(t *testing.T) {
 		t.Errorf("Expected ErrNotFound, got: %v", err)
 	}
 }
+
+func TestSQLiteCalendarRepo_RecordChangeAndListSince(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	repo := NewSQLiteCalendarRepo(db)
+	ctx := context.Background()
+
+	calendar := &domain.Calendar{UserID: userID, Name: "changes", DisplayName: "Changes"}
+	if err := repo.Create(ctx, calendar); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	token1, err := repo.RecordChange(ctx, calendar.ID, "event-1", domain.CalendarChangeCreated)
+	if err != nil {
+		t.Fatalf("RecordChange failed: %v", err)
+	}
+	rev1, err := domain.ParseSyncToken(token1)
+	if err != nil {
+		t.Fatalf("RecordChange returned unparseable token %q: %v", token1, err)
+	}
+
+	// Collection-level bumps advance the token but are not object changes
+	if _, err := repo.IncrementSyncToken(ctx, calendar.ID); err != nil {
+		t.Fatalf("IncrementSyncToken failed: %v", err)
+	}
+	token3, err := repo.RecordChange(ctx, calendar.ID, "event-1", domain.CalendarChangeDeleted)
+	if err != nil {
+		t.Fatalf("RecordChange (delete) failed: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, calendar.ID)
+	if got.SyncToken != token3 {
+		t.Errorf("Sync token not persisted: got %s, want %s", got.SyncToken, token3)
+	}
+
+	changes, err := repo.ListChangesSince(ctx, calendar.ID, 0)
+	if err != nil {
+		t.Fatalf("ListChangesSince failed: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 object changes, got %d", len(changes))
+	}
+	if changes[1].Type != domain.CalendarChangeDeleted || changes[1].UID != "event-1" {
+		t.Errorf("Expected tombstone for event-1, got %+v", changes[1])
+	}
+
+	changes, err = repo.ListChangesSince(ctx, calendar.ID, rev1)
+	if err != nil {
+		t.Fatalf("ListChangesSince failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != domain.CalendarChangeDeleted {
+		t.Errorf("Expected only the tombstone after revision %d, got %d changes", rev1, len(changes))
+	}
+
+	// Collection-level entries are in the log too; other calendars' are not
+	rev2 := rev1 + 1
+	for revision, want := range map[int64]bool{rev1: true, rev2: true, rev2 + 1: true, rev2 + 2: false} {
+		if has, err := repo.HasChange(ctx, calendar.ID, revision); err != nil || has != want {
+			t.Errorf("HasChange(%d) = %v, %v; want %v", revision, has, err, want)
+		}
+	}
+	if has, _ := repo.HasChange(ctx, calendar.ID+1, rev1); has {
+		t.Error("HasChange found a revision in another calendar's log")
+	}
+}
+
+func TestSQLiteCalendarRepo_RecordChange_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteCalendarRepo(db)
+	_, err := repo.RecordChange(context.Background(), 99999, "event-1", domain.CalendarChangeCreated)
+	if err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestSQLiteCalendarRepo_Tombstone(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	repo := NewSQLiteCalendarRepo(db)
+	ctx := context.Background()
+
+	if _, err := repo.GetTombstone(ctx, userID, "trips"); err != domain.ErrNotFound {
+		t.Fatalf("Expected ErrNotFound before any deletion, got %v", err)
+	}
+
+	calendar := &domain.Calendar{UserID: userID, Name: "trips", DisplayName: "Trips"}
+	if err := repo.Create(ctx, calendar); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	token, err := repo.IncrementSyncToken(ctx, calendar.ID)
+	if err != nil {
+		t.Fatalf("IncrementSyncToken failed: %v", err)
+	}
+	calendar.SyncToken = token
+
+	if err := repo.RecordTombstone(ctx, calendar); err != nil {
+		t.Fatalf("RecordTombstone failed: %v", err)
+	}
+	if err := repo.Delete(ctx, calendar.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	tombstone, err := repo.GetTombstone(ctx, userID, "trips")
+	if err != nil {
+		t.Fatalf("GetTombstone failed: %v", err)
+	}
+	revision, _ := domain.ParseSyncToken(token)
+	if tombstone.Revision != revision {
+		t.Errorf("Tombstone revision: got %d, want %d", tombstone.Revision, revision)
+	}
+}
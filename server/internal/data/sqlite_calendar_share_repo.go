@@ -0,0 +1,129 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const calendarShareColumns = `id, calendar_id, grantee_user_id, privilege, created_at, updated_at`
+
+// SQLiteCalendarShareRepo implements domain.CalendarShareRepo using SQLite
+type SQLiteCalendarShareRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteCalendarShareRepo creates a new SQLite calendar share repository
+func NewSQLiteCalendarShareRepo(db *sql.DB) *SQLiteCalendarShareRepo {
+	return &SQLiteCalendarShareRepo{db: db}
+}
+
+// WithTx returns a new SQLiteCalendarShareRepo that operates within the given transaction.
+func (r *SQLiteCalendarShareRepo) WithTx(tx *sql.Tx) *SQLiteCalendarShareRepo {
+	return &SQLiteCalendarShareRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteCalendarShareRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Put creates the share, or changes the privilege of an existing share with
+// the same calendar and grantee. share is filled in with the stored row.
+func (r *SQLiteCalendarShareRepo) Put(ctx context.Context, share *domain.CalendarShare) error {
+	query := `INSERT INTO calendar_shares (calendar_id, grantee_user_id, privilege, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (calendar_id, grantee_user_id) DO UPDATE SET privilege = excluded.privilege, updated_at = excluded.updated_at`
+	now := time.Now()
+
+	if _, err := r.execer().ExecContext(ctx, query, share.CalendarID, share.GranteeUserID, string(share.Privilege), now, now); err != nil {
+		return fmt.Errorf("failed to put calendar share: %w", err)
+	}
+	stored, err := r.Get(ctx, share.CalendarID, share.GranteeUserID)
+	if err != nil {
+		return err
+	}
+	*share = *stored
+	return nil
+}
+
+func (r *SQLiteCalendarShareRepo) Get(ctx context.Context, calendarID, granteeUserID int64) (*domain.CalendarShare, error) {
+	query := `SELECT ` + calendarShareColumns + ` FROM calendar_shares WHERE calendar_id = ? AND grantee_user_id = ?`
+
+	s, err := scanCalendarShare(r.execer().QueryRowContext(ctx, query, calendarID, granteeUserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar share: %w", err)
+	}
+	return s, nil
+}
+
+// ListByCalendar returns the shares of a calendar in the order they were made
+func (r *SQLiteCalendarShareRepo) ListByCalendar(ctx context.Context, calendarID int64) ([]*domain.CalendarShare, error) {
+	query := `SELECT ` + calendarShareColumns + ` FROM calendar_shares WHERE calendar_id = ? ORDER BY id`
+	return r.list(ctx, query, calendarID)
+}
+
+// ListByGrantee returns the calendars shared with a user in the order they were shared
+func (r *SQLiteCalendarShareRepo) ListByGrantee(ctx context.Context, granteeUserID int64) ([]*domain.CalendarShare, error) {
+	query := `SELECT ` + calendarShareColumns + ` FROM calendar_shares WHERE grantee_user_id = ? ORDER BY id`
+	return r.list(ctx, query, granteeUserID)
+}
+
+func (r *SQLiteCalendarShareRepo) Delete(ctx context.Context, calendarID, granteeUserID int64) error {
+	result, err := r.execer().ExecContext(ctx, `DELETE FROM calendar_shares WHERE calendar_id = ? AND grantee_user_id = ?`, calendarID, granteeUserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar share: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteCalendarShareRepo) list(ctx context.Context, query string, args ...any) ([]*domain.CalendarShare, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []*domain.CalendarShare
+	for rows.Next() {
+		s, err := scanCalendarShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar share: %w", err)
+		}
+		shares = append(shares, s)
+	}
+	return shares, rows.Err()
+}
+
+func scanCalendarShare(row interface{ Scan(dest ...any) error }) (*domain.CalendarShare, error) {
+	var s domain.CalendarShare
+	var privilege string
+	if err := row.Scan(&s.ID, &s.CalendarID, &s.GranteeUserID, &privilege, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	s.Privilege = domain.SharePrivilege(privilege)
+	return &s, nil
+}
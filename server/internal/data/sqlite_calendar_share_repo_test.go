@@ -0,0 +1,79 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteCalendarShareRepo_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteCalendarShareRepo(db)
+	ctx := context.Background()
+	ownerID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, ownerID)
+	bob, err := NewSQLiteUserRepo(db).Create(ctx, "bob")
+	if err != nil {
+		t.Fatalf("Create user failed: %v", err)
+	}
+
+	share := &domain.CalendarShare{CalendarID: cal.ID, GranteeUserID: bob.ID, Privilege: domain.SharePrivilegeRead}
+	if err := repo.Put(ctx, share); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if share.ID == 0 || share.CreatedAt.IsZero() {
+		t.Errorf("Put did not fill in the stored share: %+v", share)
+	}
+
+	// Sharing again changes the privilege of the same share
+	upgraded := &domain.CalendarShare{CalendarID: cal.ID, GranteeUserID: bob.ID, Privilege: domain.SharePrivilegeReadWrite}
+	if err := repo.Put(ctx, upgraded); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if upgraded.ID != share.ID || upgraded.Privilege != domain.SharePrivilegeReadWrite {
+		t.Errorf("Expected share %d upgraded to read-write, got %+v", share.ID, upgraded)
+	}
+
+	byCalendar, err := repo.ListByCalendar(ctx, cal.ID)
+	if err != nil || len(byCalendar) != 1 {
+		t.Fatalf("ListByCalendar = %v, %v; want 1 share", byCalendar, err)
+	}
+	byGrantee, err := repo.ListByGrantee(ctx, bob.ID)
+	if err != nil || len(byGrantee) != 1 || byGrantee[0].CalendarID != cal.ID {
+		t.Fatalf("ListByGrantee = %v, %v; want the calendar", byGrantee, err)
+	}
+
+	if err := repo.Delete(ctx, cal.ID, bob.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, cal.ID, bob.ID); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	if _, err := repo.Get(ctx, cal.ID, bob.ID); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+}
+
+func TestSQLiteCalendarShareRepo_DeletedWithCalendar(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteCalendarShareRepo(db)
+	ctx := context.Background()
+	cal := createTestCalendar(t, db, createTestUser(t, db))
+	bob, _ := NewSQLiteUserRepo(db).Create(ctx, "bob")
+	if err := repo.Put(ctx, &domain.CalendarShare{CalendarID: cal.ID, GranteeUserID: bob.ID, Privilege: domain.SharePrivilegeRead}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	if err := NewSQLiteCalendarRepo(db).Delete(ctx, cal.ID); err != nil {
+		t.Fatalf("Delete calendar failed: %v", err)
+	}
+	shares, err := repo.ListByGrantee(ctx, bob.ID)
+	if err != nil || len(shares) != 0 {
+		t.Errorf("ListByGrantee after calendar delete = %v, %v; want none", shares, err)
+	}
+}
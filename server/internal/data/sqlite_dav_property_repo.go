@@ -0,0 +1,107 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// SQLiteDeadPropertyRepo implements domain.DeadPropertyRepo using SQLite
+type SQLiteDeadPropertyRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteDeadPropertyRepo creates a new SQLite dead property repository
+func NewSQLiteDeadPropertyRepo(db *sql.DB) *SQLiteDeadPropertyRepo {
+	return &SQLiteDeadPropertyRepo{db: db}
+}
+
+// WithTx returns a new SQLiteDeadPropertyRepo that operates within the given transaction.
+func (r *SQLiteDeadPropertyRepo) WithTx(tx *sql.Tx) *SQLiteDeadPropertyRepo {
+	return &SQLiteDeadPropertyRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteDeadPropertyRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Set inserts a dead property or replaces its value
+func (r *SQLiteDeadPropertyRepo) Set(ctx context.Context, prop *domain.DeadProperty) error {
+	if err := prop.Validate(); err != nil {
+		return fmt.Errorf("invalid dead property: %w", err)
+	}
+
+	query := `INSERT INTO dav_properties (user_id, path, namespace, name, value, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(user_id, path, namespace, name) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`
+
+	now := time.Now()
+	if _, err := r.execer().ExecContext(ctx, query, prop.UserID, prop.Path, prop.Namespace, prop.Name, prop.Value, now); err != nil {
+		return fmt.Errorf("failed to set dead property: %w", err)
+	}
+	prop.UpdatedAt = now
+	return nil
+}
+
+// Remove deletes a dead property. Removing a property that does not exist is
+// not an error (RFC 4918 §14.23).
+func (r *SQLiteDeadPropertyRepo) Remove(ctx context.Context, userID int64, path, namespace, name string) error {
+	query := `DELETE FROM dav_properties WHERE user_id = ? AND path = ? AND namespace = ? AND name = ?`
+	if _, err := r.execer().ExecContext(ctx, query, userID, path, namespace, name); err != nil {
+		return fmt.Errorf("failed to remove dead property: %w", err)
+	}
+	return nil
+}
+
+// ListByPath retrieves the dead properties of one resource
+func (r *SQLiteDeadPropertyRepo) ListByPath(ctx context.Context, userID int64, path string) ([]*domain.DeadProperty, error) {
+	query := `SELECT user_id, path, namespace, name, value, updated_at
+	          FROM dav_properties WHERE user_id = ? AND path = ? ORDER BY id`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead properties: %w", err)
+	}
+	defer rows.Close()
+
+	var props []*domain.DeadProperty
+	for rows.Next() {
+		var p domain.DeadProperty
+		if err := rows.Scan(&p.UserID, &p.Path, &p.Namespace, &p.Name, &p.Value, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dead property: %w", err)
+		}
+		props = append(props, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return props, nil
+}
+
+// DeleteByPathPrefix drops the dead properties of a resource and, for
+// collections, of every member
+func (r *SQLiteDeadPropertyRepo) DeleteByPathPrefix(ctx context.Context, userID int64, prefix string) error {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	query := `DELETE FROM dav_properties WHERE user_id = ? AND (path = ? OR path LIKE ? ESCAPE '\')`
+	if _, err := r.execer().ExecContext(ctx, query, userID, prefix, escaped+"%"); err != nil {
+		return fmt.Errorf("failed to delete dead properties: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteDeadPropertyRepo_SetListRemove(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	repo := NewSQLiteDeadPropertyRepo(db)
+	ctx := context.Background()
+
+	path := "/dav/calendars/testuser/default/"
+	prop := &domain.DeadProperty{UserID: userID, Path: path, Namespace: "urn:example", Name: "note", Value: "first"}
+	if err := repo.Set(ctx, prop); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	prop.Value = "second"
+	if err := repo.Set(ctx, prop); err != nil {
+		t.Fatalf("Set (replace) failed: %v", err)
+	}
+
+	props, err := repo.ListByPath(ctx, userID, path)
+	if err != nil {
+		t.Fatalf("ListByPath failed: %v", err)
+	}
+	if len(props) != 1 || props[0].Value != "second" {
+		t.Fatalf("Expected one property with the replaced value, got %+v", props)
+	}
+
+	if err := repo.Remove(ctx, userID, path, "urn:example", "note"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := repo.Remove(ctx, userID, path, "urn:example", "note"); err != nil {
+		t.Errorf("Removing a missing property should succeed, got %v", err)
+	}
+	props, _ = repo.ListByPath(ctx, userID, path)
+	if len(props) != 0 {
+		t.Errorf("Expected no properties after remove, got %d", len(props))
+	}
+}
+
+func TestSQLiteDeadPropertyRepo_DeleteByPathPrefix(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	repo := NewSQLiteDeadPropertyRepo(db)
+	ctx := context.Background()
+
+	for _, path := range []string{
+		"/dav/calendars/testuser/work/",
+		"/dav/calendars/testuser/work/a.ics",
+		"/dav/calendars/testuser/work_old/",
+	} {
+		if err := repo.Set(ctx, &domain.DeadProperty{UserID: userID, Path: path, Namespace: "urn:example", Name: "note", Value: "x"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	if err := repo.DeleteByPathPrefix(ctx, userID, "/dav/calendars/testuser/work/"); err != nil {
+		t.Fatalf("DeleteByPathPrefix failed: %v", err)
+	}
+
+	for path, want := range map[string]int{
+		"/dav/calendars/testuser/work/":      0,
+		"/dav/calendars/testuser/work/a.ics": 0,
+		"/dav/calendars/testuser/work_old/":  1, // '_' must not act as a wildcard
+	} {
+		props, _ := repo.ListByPath(ctx, userID, path)
+		if len(props) != want {
+			t.Errorf("%s: expected %d properties, got %d", path, want, len(props))
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/airplne/calendar-app/server/internal/domain"
@@ -51,16 +52,31 @@ func (r *SQLiteEventRepo) Create(ctx context.Context, event *domain.Event) error
 		return fmt.Errorf("invalid event: %w", err)
 	}
 
+	if r.tx != nil {
+		return r.createInTx(ctx, r.tx, event)
+	}
+
+	// Event row and override index are written atomically
+	return WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.createInTx(ctx, tx, event)
+	})
+}
+
+// createInTx performs the actual insert within a transaction
+func (r *SQLiteEventRepo) createInTx(ctx context.Context, tx *sql.Tx, event *domain.Event) error {
 	query := `
 		INSERT INTO events (
 			calendar_id, uid, ics, summary, description, location,
-			start_time, end_time, all_day, recurrence_rule, etag,
-			sequence, status, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			etag, schedule_tag, sequence, status, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	if event.ScheduleTag == "" {
+		event.ScheduleTag = event.ETag
+	}
 	now := time.Now()
-	result, err := r.execer().ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		event.CalendarID,
 		event.UID,
 		event.ICS,
@@ -71,7 +87,9 @@ func (r *SQLiteEventRepo) Create(ctx context.Context, event *domain.Event) error
 		event.EndTime,
 		event.AllDay,
 		nullString(event.RecurrenceRule),
+		nullString(event.RecurrenceDates),
 		event.ETag,
+		event.ScheduleTag,
 		event.Sequence,
 		event.Status,
 		now,
@@ -90,6 +108,13 @@ func (r *SQLiteEventRepo) Create(ctx context.Context, event *domain.Event) error
 		return fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
+	if err := replaceOverrides(ctx, tx, id, event.Overrides); err != nil {
+		return err
+	}
+	if err := replaceAlarms(ctx, tx, id, event.Alarms); err != nil {
+		return err
+	}
+
 	event.ID = id
 	event.CreatedAt = now
 	event.UpdatedAt = now
@@ -101,8 +126,8 @@ func (r *SQLiteEventRepo) Create(ctx context.Context, event *domain.Event) error
 func (r *SQLiteEventRepo) GetByUID(ctx context.Context, calendarID int64, uid string) (*domain.Event, error) {
 	query := `
 		SELECT id, calendar_id, uid, ics, summary, description, location,
-			   start_time, end_time, all_day, recurrence_rule, etag,
-			   sequence, status, created_at, updated_at
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
 		FROM events
 		WHERE calendar_id = ? AND uid = ?
 	`
@@ -116,15 +141,75 @@ func (r *SQLiteEventRepo) GetByUID(ctx context.Context, calendarID int64, uid st
 		return nil, fmt.Errorf("failed to get event by UID: %w", err)
 	}
 
+	if err := r.loadOverrides(ctx, []*domain.Event{event}); err != nil {
+		return nil, err
+	}
+
 	return event, nil
 }
 
+// maxUIDsPerQuery bounds the IN list of batched lookups (999 is SQLite's
+// historical host-parameter limit). Multigets of typical size take one query.
+const maxUIDsPerQuery = 999
+
+// GetByUIDs retrieves the events of a calendar with the given UIDs. UIDs
+// without an event are simply absent from the result.
+func (r *SQLiteEventRepo) GetByUIDs(ctx context.Context, calendarID int64, uids []string) ([]*domain.Event, error) {
+	var events []*domain.Event
+	for start := 0; start < len(uids); start += maxUIDsPerQuery {
+		end := min(start+maxUIDsPerQuery, len(uids))
+		batch := uids[start:end]
+
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, calendarID)
+		for i, uid := range batch {
+			placeholders[i] = "?"
+			args = append(args, uid)
+		}
+
+		query := `
+			SELECT id, calendar_id, uid, ics, summary, description, location,
+				   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+				   etag, schedule_tag, sequence, status, created_at, updated_at
+			FROM events
+			WHERE calendar_id = ? AND uid IN (` + strings.Join(placeholders, ", ") + `)
+		`
+
+		rows, err := r.execer().QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events by UIDs: %w", err)
+		}
+		var found []*domain.Event
+		for rows.Next() {
+			event, err := scanEvent(rows)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan event: %w", err)
+			}
+			found = append(found, event)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error iterating events: %w", err)
+		}
+		rows.Close()
+
+		if err := r.loadOverrides(ctx, found); err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+
+	return events, nil
+}
+
 // GetByID retrieves an event by its ID
 func (r *SQLiteEventRepo) GetByID(ctx context.Context, id int64) (*domain.Event, error) {
 	query := `
 		SELECT id, calendar_id, uid, ics, summary, description, location,
-			   start_time, end_time, all_day, recurrence_rule, etag,
-			   sequence, status, created_at, updated_at
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
 		FROM events
 		WHERE id = ?
 	`
@@ -138,22 +223,29 @@ func (r *SQLiteEventRepo) GetByID(ctx context.Context, id int64) (*domain.Event,
 		return nil, fmt.Errorf("failed to get event by ID: %w", err)
 	}
 
+	if err := r.loadOverrides(ctx, []*domain.Event{event}); err != nil {
+		return nil, err
+	}
+
 	return event, nil
 }
 
 // List retrieves events within a time range for a calendar
-// Returns events that overlap with the [start, end] range
+// Returns events whose master or any RECURRENCE-ID override overlaps the [start, end] range
 func (r *SQLiteEventRepo) List(ctx context.Context, calendarID int64, start, end time.Time) ([]*domain.Event, error) {
 	query := `
 		SELECT id, calendar_id, uid, ics, summary, description, location,
-			   start_time, end_time, all_day, recurrence_rule, etag,
-			   sequence, status, created_at, updated_at
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
 		FROM events
-		WHERE calendar_id = ? AND start_time < ? AND end_time > ?
+		WHERE calendar_id = ? AND (
+			(start_time < ? AND end_time > ?)
+			OR id IN (SELECT event_id FROM event_overrides WHERE start_time < ? AND end_time > ?)
+		)
 		ORDER BY start_time ASC
 	`
 
-	rows, err := r.execer().QueryContext(ctx, query, calendarID, end, start)
+	rows, err := r.execer().QueryContext(ctx, query, calendarID, end, start, end, start)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list events: %w", err)
 	}
@@ -171,6 +263,11 @@ func (r *SQLiteEventRepo) List(ctx context.Context, calendarID int64, start, end
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating events: %w", err)
 	}
+	rows.Close()
+
+	if err := r.loadOverrides(ctx, events); err != nil {
+		return nil, err
+	}
 
 	return events, nil
 }
@@ -179,8 +276,8 @@ func (r *SQLiteEventRepo) List(ctx context.Context, calendarID int64, start, end
 func (r *SQLiteEventRepo) ListAll(ctx context.Context, calendarID int64) ([]*domain.Event, error) {
 	query := `
 		SELECT id, calendar_id, uid, ics, summary, description, location,
-			   start_time, end_time, all_day, recurrence_rule, etag,
-			   sequence, status, created_at, updated_at
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
 		FROM events
 		WHERE calendar_id = ?
 		ORDER BY start_time ASC
@@ -204,6 +301,51 @@ func (r *SQLiteEventRepo) ListAll(ctx context.Context, calendarID int64) ([]*dom
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating events: %w", err)
 	}
+	rows.Close()
+
+	if err := r.loadOverrides(ctx, events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ListRecurring retrieves the recurring events (RRULE or RDATE) of a calendar.
+// Their stored start/end only describe the first instance, so time-range
+// queries must consider them regardless of range and expand them in memory.
+func (r *SQLiteEventRepo) ListRecurring(ctx context.Context, calendarID int64) ([]*domain.Event, error) {
+	query := `
+		SELECT id, calendar_id, uid, ics, summary, description, location,
+			   start_time, end_time, all_day, recurrence_rule, recurrence_dates,
+			   etag, schedule_tag, sequence, status, created_at, updated_at
+		FROM events
+		WHERE calendar_id = ? AND (recurrence_rule IS NOT NULL OR recurrence_dates IS NOT NULL)
+		ORDER BY start_time ASC
+	`
+
+	rows, err := r.execer().QueryContext(ctx, query, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+	rows.Close()
+
+	if err := r.loadOverrides(ctx, events); err != nil {
+		return nil, err
+	}
 
 	return events, nil
 }
@@ -260,10 +402,13 @@ func (r *SQLiteEventRepo) updateInTx(ctx context.Context, tx *sql.Tx, event *dom
 		UPDATE events
 		SET ics = ?, summary = ?, description = ?, location = ?,
 			start_time = ?, end_time = ?, all_day = ?, recurrence_rule = ?,
-			etag = ?, sequence = ?, status = ?, updated_at = ?
+			recurrence_dates = ?, etag = ?, schedule_tag = ?, sequence = ?, status = ?, updated_at = ?
 		WHERE calendar_id = ? AND uid = ?
 	`
 
+	if event.ScheduleTag == "" {
+		event.ScheduleTag = event.ETag
+	}
 	now := time.Now()
 	result, err := tx.ExecContext(ctx, query,
 		event.ICS,
@@ -274,7 +419,9 @@ func (r *SQLiteEventRepo) updateInTx(ctx context.Context, tx *sql.Tx, event *dom
 		event.EndTime,
 		event.AllDay,
 		nullString(event.RecurrenceRule),
+		nullString(event.RecurrenceDates),
 		event.ETag,
+		event.ScheduleTag,
 		event.Sequence,
 		event.Status,
 		now,
@@ -294,6 +441,20 @@ func (r *SQLiteEventRepo) updateInTx(ctx context.Context, tx *sql.Tx, event *dom
 		return domain.ErrNotFound
 	}
 
+	var eventID int64
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id FROM events WHERE calendar_id = ? AND uid = ?",
+		event.CalendarID, event.UID,
+	).Scan(&eventID); err != nil {
+		return fmt.Errorf("failed to get event id: %w", err)
+	}
+	if err := replaceOverrides(ctx, tx, eventID, event.Overrides); err != nil {
+		return err
+	}
+	if err := replaceAlarms(ctx, tx, eventID, event.Alarms); err != nil {
+		return err
+	}
+
 	event.UpdatedAt = now
 	return nil
 }
@@ -319,6 +480,130 @@ func (r *SQLiteEventRepo) Delete(ctx context.Context, calendarID int64, uid stri
 	return nil
 }
 
+// replaceOverrides rewrites the override index of an event to match overrides.
+func replaceOverrides(ctx context.Context, tx *sql.Tx, eventID int64, overrides []domain.EventOverride) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM event_overrides WHERE event_id = ?", eventID); err != nil {
+		return fmt.Errorf("failed to clear event overrides: %w", err)
+	}
+
+	query := `
+		INSERT INTO event_overrides (
+			event_id, recurrence_id, this_and_future, summary,
+			start_time, end_time, all_day, sequence, status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	for _, o := range overrides {
+		_, err := tx.ExecContext(ctx, query,
+			eventID,
+			o.RecurrenceID,
+			o.ThisAndFuture,
+			nullString(o.Summary),
+			o.StartTime,
+			o.EndTime,
+			o.AllDay,
+			o.Sequence,
+			o.Status,
+		)
+		if err != nil {
+			if isUniqueConstraintError(err) {
+				return domain.ErrConflict
+			}
+			return fmt.Errorf("failed to create event override: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceAlarms rewrites the alarm index of an event to match alarms.
+func replaceAlarms(ctx context.Context, tx *sql.Tx, eventID int64, alarms []domain.EventAlarm) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM event_alarms WHERE event_id = ?", eventID); err != nil {
+		return fmt.Errorf("failed to clear event alarms: %w", err)
+	}
+
+	query := `
+		INSERT INTO event_alarms (
+			event_id, alarm_key, action, description, recurring,
+			first_trigger_at, last_trigger_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	for _, a := range alarms {
+		_, err := tx.ExecContext(ctx, query,
+			eventID,
+			a.Key,
+			a.Action,
+			a.Description,
+			a.Recurring,
+			sql.NullTime{Time: a.FirstTrigger, Valid: !a.FirstTrigger.IsZero()},
+			sql.NullTime{Time: a.LastTrigger, Valid: !a.LastTrigger.IsZero()},
+		)
+		if err != nil {
+			if isUniqueConstraintError(err) {
+				return domain.ErrConflict
+			}
+			return fmt.Errorf("failed to create event alarm: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadOverrides fills in the Overrides of events with a single query.
+func (r *SQLiteEventRepo) loadOverrides(ctx context.Context, events []*domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*domain.Event, len(events))
+	placeholders := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events))
+	for _, e := range events {
+		byID[e.ID] = e
+		placeholders = append(placeholders, "?")
+		args = append(args, e.ID)
+	}
+
+	query := `
+		SELECT event_id, recurrence_id, this_and_future, summary,
+			   start_time, end_time, all_day, sequence, status
+		FROM event_overrides
+		WHERE event_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY recurrence_id ASC
+	`
+
+	rows, err := r.execer().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load event overrides: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID int64
+		var o domain.EventOverride
+		var summary sql.NullString
+		if err := rows.Scan(
+			&eventID,
+			&o.RecurrenceID,
+			&o.ThisAndFuture,
+			&summary,
+			&o.StartTime,
+			&o.EndTime,
+			&o.AllDay,
+			&o.Sequence,
+			&o.Status,
+		); err != nil {
+			return fmt.Errorf("failed to scan event override: %w", err)
+		}
+		o.Summary = fromNullString(summary)
+		if e := byID[eventID]; e != nil {
+			e.Overrides = append(e.Overrides, o)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating event overrides: %w", err)
+	}
+	return nil
+}
+
 // Helper functions are defined in sqlite_calendar_repo.go:
 // - nullString
 // - fromNullString
@@ -327,7 +612,7 @@ func (r *SQLiteEventRepo) Delete(ctx context.Context, calendarID int64, uid stri
 // scanEvent scans a row into an Event struct
 func scanEvent(row interface{ Scan(...interface{}) error }) (*domain.Event, error) {
 	var e domain.Event
-	var description, location, recurrenceRule sql.NullString
+	var description, location, recurrenceRule, recurrenceDates sql.NullString
 
 	err := row.Scan(
 		&e.ID,
@@ -341,7 +626,9 @@ func scanEvent(row interface{ Scan(...interface{}) error }) (*domain.Event, erro
 		&e.EndTime,
 		&e.AllDay,
 		&recurrenceRule,
+		&recurrenceDates,
 		&e.ETag,
+		&e.ScheduleTag,
 		&e.Sequence,
 		&e.Status,
 		&e.CreatedAt,
@@ -354,6 +641,7 @@ func scanEvent(row interface{ Scan(...interface{}) error }) (*domain.Event, erro
 	e.Description = fromNullString(description)
 	e.Location = fromNullString(location)
 	e.RecurrenceRule = fromNullString(recurrenceRule)
+	e.RecurrenceDates = fromNullString(recurrenceDates)
 
 	return &e, nil
 }
@@ -617,3 +617,151 @@ func TestSQLiteEventRepo_WithTx_Commit(t *testing.T) {
 	t.Logf("Transaction atomicity verified: both event and sync token committed together (%s -> %s)",
 		initialSyncToken, newToken)
 }
+
+func TestSQLiteEventRepo_ListRecurring(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+	repo := NewSQLiteEventRepo(db)
+	ctx := context.Background()
+
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	events := []*domain.Event{
+		{CalendarID: cal.ID, UID: "single", ICS: "BEGIN:VCALENDAR...", StartTime: start, EndTime: start.Add(time.Hour), ETag: `"1"`},
+		{CalendarID: cal.ID, UID: "rrule", ICS: "BEGIN:VCALENDAR...", StartTime: start, EndTime: start.Add(time.Hour), ETag: `"2"`, RecurrenceRule: "FREQ=WEEKLY"},
+		{CalendarID: cal.ID, UID: "rdate", ICS: "BEGIN:VCALENDAR...", StartTime: start, EndTime: start.Add(time.Hour), ETag: `"3"`, RecurrenceDates: "20260110T090000Z"},
+	}
+	for _, e := range events {
+		if err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("Create %s failed: %v", e.UID, err)
+		}
+	}
+
+	list, err := repo.ListRecurring(ctx, cal.ID)
+	if err != nil {
+		t.Fatalf("ListRecurring failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 recurring events, got %d", len(list))
+	}
+	got := map[string]*domain.Event{}
+	for _, e := range list {
+		got[e.UID] = e
+	}
+	if got["rrule"] == nil || got["rdate"] == nil {
+		t.Errorf("Expected rrule and rdate events, got %v", got)
+	}
+	if got["rdate"] != nil && got["rdate"].RecurrenceDates != "20260110T090000Z" {
+		t.Errorf("RecurrenceDates not round-tripped: %q", got["rdate"].RecurrenceDates)
+	}
+}
+
+func TestSQLiteEventRepo_Overrides(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+	repo := NewSQLiteEventRepo(db)
+	ctx := context.Background()
+
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	moved := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	event := &domain.Event{
+		CalendarID:     cal.ID,
+		UID:            "with-override",
+		ICS:            "BEGIN:VCALENDAR...",
+		StartTime:      start,
+		EndTime:        start.Add(time.Hour),
+		RecurrenceRule: "FREQ=WEEKLY;COUNT=4",
+		ETag:           `"1"`,
+		Overrides: []domain.EventOverride{{
+			RecurrenceID: start.AddDate(0, 0, 7),
+			Summary:      "Moved",
+			StartTime:    moved,
+			EndTime:      moved.Add(time.Hour),
+			Status:       "CONFIRMED",
+		}},
+	}
+	if err := repo.Create(ctx, event); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByUID(ctx, cal.ID, event.UID)
+	if err != nil {
+		t.Fatalf("GetByUID failed: %v", err)
+	}
+	if len(got.Overrides) != 1 || got.Overrides[0].Summary != "Moved" || !got.Overrides[0].StartTime.Equal(moved) {
+		t.Fatalf("Overrides not round-tripped: %+v", got.Overrides)
+	}
+
+	// The moved instance is found in its own week even though the master is not.
+	list, err := repo.List(ctx, cal.ID, moved.Add(-time.Hour), moved.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 1 || list[0].UID != event.UID {
+		t.Fatalf("Expected the event via its override, got %d events", len(list))
+	}
+
+	// Update replaces the override index
+	got.Overrides = nil
+	got.ETag = `"2"`
+	if err := repo.Update(ctx, got, `"1"`); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	list, err = repo.List(ctx, cal.ID, moved.Add(-time.Hour), moved.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("Expected no events after removing the override, got %d", len(list))
+	}
+}
+
+func TestSQLiteEventRepo_GetByUIDs(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+
+	repo := NewSQLiteEventRepo(db)
+	ctx := context.Background()
+
+	for _, uid := range []string{"batch-1", "batch-2", "batch-3"} {
+		ics := "BEGIN:VCALENDAR\nVERSION:2.0\nBEGIN:VEVENT\nUID:" + uid + "\nSUMMARY:Test\nEND:VEVENT\nEND:VCALENDAR"
+		event := &domain.Event{
+			CalendarID: cal.ID,
+			UID:        uid,
+			ICS:        ics,
+			Summary:    "Test Event",
+			StartTime:  time.Now().Truncate(time.Second),
+			EndTime:    time.Now().Add(time.Hour).Truncate(time.Second),
+			ETag:       domain.GenerateETag([]byte(ics)),
+			Status:     "CONFIRMED",
+		}
+		if err := repo.Create(ctx, event); err != nil {
+			t.Fatalf("Create %s failed: %v", uid, err)
+		}
+	}
+
+	events, err := repo.GetByUIDs(ctx, cal.ID, []string{"batch-1", "batch-3", "missing"})
+	if err != nil {
+		t.Fatalf("GetByUIDs failed: %v", err)
+	}
+	found := map[string]bool{}
+	for _, e := range events {
+		found[e.UID] = true
+	}
+	if len(events) != 2 || !found["batch-1"] || !found["batch-3"] {
+		t.Errorf("Expected batch-1 and batch-3, got %v", found)
+	}
+
+	events, err = repo.GetByUIDs(ctx, cal.ID, nil)
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no events for an empty UID list, got %d (err %v)", len(events), err)
+	}
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const personalAccessTokenColumns = `id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+// SQLitePersonalAccessTokenRepo implements domain.PersonalAccessTokenRepo using SQLite
+type SQLitePersonalAccessTokenRepo struct {
+	db *sql.DB
+}
+
+// NewSQLitePersonalAccessTokenRepo creates a new SQLite personal access token repository
+func NewSQLitePersonalAccessTokenRepo(db *sql.DB) *SQLitePersonalAccessTokenRepo {
+	return &SQLitePersonalAccessTokenRepo{db: db}
+}
+
+func (r *SQLitePersonalAccessTokenRepo) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	var expiresAt sql.NullTime
+	if token.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *token.ExpiresAt, Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, token.UserID, token.Name, token.TokenHash, joinScopes(token.Scopes), now, expiresAt)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	token.ID = id
+	token.CreatedAt = now
+	return nil
+}
+
+// GetByTokenHash returns the token with the given hash, revoked or expired or not
+func (r *SQLitePersonalAccessTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = ?`
+
+	t, err := scanPersonalAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+	return t, nil
+}
+
+// ListByUser returns a user's tokens, newest first, including revoked ones
+func (r *SQLitePersonalAccessTokenRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.PersonalAccessToken, error) {
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*domain.PersonalAccessToken
+	for rows.Next() {
+		t, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *SQLitePersonalAccessTokenRepo) Revoke(ctx context.Context, userID, id int64, at time.Time) error {
+	query := `UPDATE personal_access_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, at, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLitePersonalAccessTokenRepo) RecordUse(ctx context.Context, id int64, at time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?`, at, id); err != nil {
+		return fmt.Errorf("failed to record personal access token use: %w", err)
+	}
+	return nil
+}
+
+func scanPersonalAccessToken(row interface{ Scan(dest ...any) error }) (*domain.PersonalAccessToken, error) {
+	var t domain.PersonalAccessToken
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &scopes, &t.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	for _, scope := range strings.Fields(scopes) {
+		t.Scopes = append(t.Scopes, domain.TokenScope(scope))
+	}
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+func joinScopes(scopes []domain.TokenScope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
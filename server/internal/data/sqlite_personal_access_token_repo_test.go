@@ -0,0 +1,59 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLitePersonalAccessTokenRepo_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLitePersonalAccessTokenRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+
+	token := &domain.PersonalAccessToken{
+		UserID:    userID,
+		Name:      "backup script",
+		TokenHash: "hash-backup",
+		Scopes:    []domain.TokenScope{domain.ScopeRead, domain.ScopeWrite},
+		ExpiresAt: &expiresAt,
+	}
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	usedAt := time.Now().UTC().Truncate(time.Second)
+	if err := repo.RecordUse(ctx, token.ID, usedAt); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	got, err := repo.GetByTokenHash(ctx, "hash-backup")
+	if err != nil {
+		t.Fatalf("GetByTokenHash failed: %v", err)
+	}
+	if len(got.Scopes) != 2 || got.Scopes[0] != domain.ScopeRead || got.Scopes[1] != domain.ScopeWrite {
+		t.Errorf("Scopes not round-tripped: %v", got.Scopes)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) || got.LastUsedAt == nil || !got.LastUsedAt.Equal(usedAt) {
+		t.Errorf("Timestamps not round-tripped: %+v", got)
+	}
+
+	if err := repo.Revoke(ctx, userID+1, token.ID, usedAt); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound revoking another user's token, got %v", err)
+	}
+	if err := repo.Revoke(ctx, userID, token.ID, usedAt); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	tokens, err := repo.ListByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].RevokedAt == nil {
+		t.Errorf("Expected one revoked token, got %+v", tokens)
+	}
+}
@@ -0,0 +1,244 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const planProposalColumns = `id, user_id, status, status_reason, summary, expires_at, created_at, updated_at`
+
+// SQLitePlanProposalRepo implements domain.PlanProposalRepo using SQLite
+type SQLitePlanProposalRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLitePlanProposalRepo creates a new SQLite plan proposal repository
+func NewSQLitePlanProposalRepo(db *sql.DB) *SQLitePlanProposalRepo {
+	return &SQLitePlanProposalRepo{db: db}
+}
+
+// WithTx returns a new SQLitePlanProposalRepo that operates within the given transaction.
+func (r *SQLitePlanProposalRepo) WithTx(tx *sql.Tx) *SQLitePlanProposalRepo {
+	return &SQLitePlanProposalRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLitePlanProposalRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Create stores the proposal with its changes and violations
+func (r *SQLitePlanProposalRepo) Create(ctx context.Context, proposal *domain.PlanProposal) error {
+	if r.tx != nil {
+		return r.createInTx(ctx, r.tx, proposal)
+	}
+	return WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.createInTx(ctx, tx, proposal)
+	})
+}
+
+func (r *SQLitePlanProposalRepo) createInTx(ctx context.Context, tx *sql.Tx, proposal *domain.PlanProposal) error {
+	query := `INSERT INTO plan_proposals (user_id, status, status_reason, summary, expires_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := tx.ExecContext(ctx, query, proposal.UserID, string(proposal.Status), proposal.StatusReason, proposal.Summary,
+		proposal.ExpiresAt, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create plan proposal: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	changeQuery := `INSERT INTO plan_proposal_changes (proposal_id, position, type, calendar_id, uid, etag, summary, start_time, end_time, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for i, c := range proposal.Changes {
+		if _, err := tx.ExecContext(ctx, changeQuery, id, i, string(c.Type), c.CalendarID, c.UID, c.ETag, c.Summary,
+			nullTime(c.Start), nullTime(c.End), c.Reason); err != nil {
+			return fmt.Errorf("failed to store proposed change: %w", err)
+		}
+	}
+	if err := replaceProposalViolations(ctx, tx, id, proposal.Violations); err != nil {
+		return err
+	}
+
+	proposal.ID = id
+	proposal.CreatedAt = now
+	proposal.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLitePlanProposalRepo) GetByID(ctx context.Context, id int64) (*domain.PlanProposal, error) {
+	query := `SELECT ` + planProposalColumns + ` FROM plan_proposals WHERE id = ?`
+
+	p, err := scanPlanProposal(r.execer().QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get plan proposal: %w", err)
+	}
+	if err := r.loadDetails(ctx, []*domain.PlanProposal{p}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListByUser returns a user's most recent proposals, newest first
+func (r *SQLitePlanProposalRepo) ListByUser(ctx context.Context, userID int64, limit int) ([]*domain.PlanProposal, error) {
+	query := `SELECT ` + planProposalColumns + ` FROM plan_proposals WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plan proposals: %w", err)
+	}
+	defer rows.Close()
+
+	var proposals []*domain.PlanProposal
+	for rows.Next() {
+		p, err := scanPlanProposal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plan proposal: %w", err)
+		}
+		proposals = append(proposals, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadDetails(ctx, proposals); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+func (r *SQLitePlanProposalRepo) Update(ctx context.Context, proposal *domain.PlanProposal, expected domain.ProposalStatus) error {
+	if r.tx != nil {
+		return r.updateInTx(ctx, r.tx, proposal, expected)
+	}
+	return WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.updateInTx(ctx, tx, proposal, expected)
+	})
+}
+
+func (r *SQLitePlanProposalRepo) updateInTx(ctx context.Context, tx *sql.Tx, proposal *domain.PlanProposal, expected domain.ProposalStatus) error {
+	query := `UPDATE plan_proposals SET status = ?, status_reason = ?, updated_at = ? WHERE id = ? AND status = ?`
+	now := time.Now()
+
+	result, err := tx.ExecContext(ctx, query, string(proposal.Status), proposal.StatusReason, now, proposal.ID, string(expected))
+	if err != nil {
+		return fmt.Errorf("failed to update plan proposal: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrConflict
+	}
+	if err := replaceProposalViolations(ctx, tx, proposal.ID, proposal.Violations); err != nil {
+		return err
+	}
+	proposal.UpdatedAt = now
+	return nil
+}
+
+// loadDetails fills in the changes and violations of the given proposals
+func (r *SQLitePlanProposalRepo) loadDetails(ctx context.Context, proposals []*domain.PlanProposal) error {
+	for _, p := range proposals {
+		rows, err := r.execer().QueryContext(ctx, `SELECT type, calendar_id, uid, etag, summary, start_time, end_time, reason
+			FROM plan_proposal_changes WHERE proposal_id = ? ORDER BY position`, p.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load proposed changes: %w", err)
+		}
+		for rows.Next() {
+			var c domain.ProposalChange
+			var changeType string
+			var start, end sql.NullTime
+			if err := rows.Scan(&changeType, &c.CalendarID, &c.UID, &c.ETag, &c.Summary, &start, &end, &c.Reason); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan proposed change: %w", err)
+			}
+			c.Type = domain.ProposalChangeType(changeType)
+			c.Start = start.Time
+			c.End = end.Time
+			p.Changes = append(p.Changes, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = r.execer().QueryContext(ctx, `SELECT change_position, code, message
+			FROM plan_proposal_violations WHERE proposal_id = ? ORDER BY id`, p.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load proposal violations: %w", err)
+		}
+		for rows.Next() {
+			var v domain.ProposalViolation
+			var position sql.NullInt64
+			if err := rows.Scan(&position, &v.Code, &v.Message); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan proposal violation: %w", err)
+			}
+			v.Change = -1
+			if position.Valid {
+				v.Change = int(position.Int64)
+			}
+			p.Violations = append(p.Violations, v)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceProposalViolations stores the violations of a proposal in place of the
+// previous ones
+func replaceProposalViolations(ctx context.Context, tx *sql.Tx, proposalID int64, violations []domain.ProposalViolation) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM plan_proposal_violations WHERE proposal_id = ?`, proposalID); err != nil {
+		return fmt.Errorf("failed to clear proposal violations: %w", err)
+	}
+	for _, v := range violations {
+		var position sql.NullInt64
+		if v.Change >= 0 {
+			position = sql.NullInt64{Int64: int64(v.Change), Valid: true}
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO plan_proposal_violations (proposal_id, change_position, code, message) VALUES (?, ?, ?, ?)`,
+			proposalID, position, v.Code, v.Message); err != nil {
+			return fmt.Errorf("failed to store proposal violation: %w", err)
+		}
+	}
+	return nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func scanPlanProposal(row interface{ Scan(dest ...any) error }) (*domain.PlanProposal, error) {
+	var p domain.PlanProposal
+	var status string
+	if err := row.Scan(&p.ID, &p.UserID, &status, &p.StatusReason, &p.Summary, &p.ExpiresAt, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Status = domain.ProposalStatus(status)
+	return &p, nil
+}
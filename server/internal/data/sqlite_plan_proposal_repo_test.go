@@ -0,0 +1,90 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLitePlanProposalRepo_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLitePlanProposalRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	proposal := &domain.PlanProposal{
+		UserID:  userID,
+		Status:  domain.ProposalDraft,
+		Summary: "Protect Monday morning",
+		Changes: []domain.ProposalChange{
+			{Type: domain.ChangeCreateFocusBlock, CalendarID: cal.ID, UID: "focus-1", Summary: "Focus", Start: start, End: start.Add(2 * time.Hour), Reason: "Longest free slot"},
+			{Type: domain.ChangeShortenEvent, CalendarID: cal.ID, UID: "standup", ETag: `"abc"`, End: start.Add(-15 * time.Minute)},
+			{Type: domain.ChangeDeclineEvent, CalendarID: cal.ID, UID: "optional", ETag: `"def"`},
+		},
+		Violations: []domain.ProposalViolation{
+			{Code: "event_not_found", Change: 2, Message: "The event no longer exists."},
+			{Code: "no_changes", Change: -1, Message: "Whole proposal"},
+		},
+		ExpiresAt: start.Add(15 * time.Minute),
+	}
+	if err := repo.Create(ctx, proposal); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if proposal.ID == 0 || proposal.CreatedAt.IsZero() {
+		t.Fatalf("Create did not set ID and timestamps: %+v", proposal)
+	}
+
+	got, err := repo.GetByID(ctx, proposal.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Status != domain.ProposalDraft || got.Summary != "Protect Monday morning" || !got.ExpiresAt.Equal(proposal.ExpiresAt) || len(got.Changes) != 3 {
+		t.Fatalf("GetByID = %+v", got)
+	}
+	if c := got.Changes[0]; c.Type != domain.ChangeCreateFocusBlock || c.UID != "focus-1" || !c.Start.Equal(start) || !c.End.Equal(start.Add(2*time.Hour)) || c.ETag != "" {
+		t.Errorf("first change = %+v", c)
+	}
+	if c := got.Changes[1]; c.ETag != `"abc"` || !c.Start.IsZero() || !c.End.Equal(start.Add(-15*time.Minute)) {
+		t.Errorf("second change = %+v", c)
+	}
+	if len(got.Violations) != 2 || got.Violations[0].Change != 2 || got.Violations[1].Change != -1 {
+		t.Errorf("violations = %+v", got.Violations)
+	}
+
+	// Status changes only from the expected status
+	got.Status = domain.ProposalValid
+	got.Violations = nil
+	if err := repo.Update(ctx, got, domain.ProposalDraft); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got.Status = domain.ProposalStale
+	got.StatusReason = domain.ProposalReasonETagMismatch
+	if err := repo.Update(ctx, got, domain.ProposalDraft); err != domain.ErrConflict {
+		t.Errorf("Update from a changed status: got %v, want ErrConflict", err)
+	}
+	updated, _ := repo.GetByID(ctx, proposal.ID)
+	if updated.Status != domain.ProposalValid || len(updated.Violations) != 0 {
+		t.Errorf("after Update = %+v", updated)
+	}
+
+	second := &domain.PlanProposal{UserID: userID, Status: domain.ProposalValid, ExpiresAt: start}
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	list, err := repo.ListByUser(ctx, userID, 10)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != second.ID || len(list[1].Changes) != 3 {
+		t.Errorf("ListByUser = %+v, want newest first with changes", list)
+	}
+	if _, err := repo.GetByID(ctx, second.ID+100); err != domain.ErrNotFound {
+		t.Errorf("GetByID of a missing proposal: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,94 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// SQLitePreferenceRepo implements domain.PreferenceRepo using SQLite
+type SQLitePreferenceRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLitePreferenceRepo creates a new SQLite preference repository
+func NewSQLitePreferenceRepo(db *sql.DB) *SQLitePreferenceRepo {
+	return &SQLitePreferenceRepo{db: db}
+}
+
+// WithTx returns a new SQLitePreferenceRepo that operates within the given transaction.
+func (r *SQLitePreferenceRepo) WithTx(tx *sql.Tx) *SQLitePreferenceRepo {
+	return &SQLitePreferenceRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLitePreferenceRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// ListByUser retrieves every preference of a user, ordered by key
+func (r *SQLitePreferenceRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.Preference, error) {
+	query := `SELECT user_id, key, COALESCE(value, ''), updated_at FROM preferences WHERE user_id = ? ORDER BY key`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var prefs []*domain.Preference
+	for rows.Next() {
+		var p domain.Preference
+		if err := rows.Scan(&p.UserID, &p.Key, &p.Value, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan preference: %w", err)
+		}
+		prefs = append(prefs, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// Set inserts a preference or replaces its value
+func (r *SQLitePreferenceRepo) Set(ctx context.Context, pref *domain.Preference) error {
+	if err := pref.Validate(); err != nil {
+		return fmt.Errorf("invalid preference: %w", err)
+	}
+
+	query := `INSERT INTO preferences (user_id, key, value, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT(user_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`
+
+	now := time.Now()
+	if _, err := r.execer().ExecContext(ctx, query, pref.UserID, pref.Key, pref.Value, now, now); err != nil {
+		return fmt.Errorf("failed to set preference: %w", err)
+	}
+	pref.UpdatedAt = now
+	return nil
+}
+
+// Delete drops a preference so its default applies again. Deleting a
+// preference that is not set is not an error.
+func (r *SQLitePreferenceRepo) Delete(ctx context.Context, userID int64, key string) error {
+	query := `DELETE FROM preferences WHERE user_id = ? AND key = ?`
+	if _, err := r.execer().ExecContext(ctx, query, userID, key); err != nil {
+		return fmt.Errorf("failed to delete preference: %w", err)
+	}
+	return nil
+}
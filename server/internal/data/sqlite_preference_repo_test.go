@@ -0,0 +1,51 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLitePreferenceRepo_SetListDelete(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	repo := NewSQLitePreferenceRepo(db)
+	ctx := context.Background()
+
+	if err := repo.Set(ctx, &domain.Preference{UserID: userID, Key: " "}); err == nil {
+		t.Fatal("Set without a key should fail")
+	}
+	pref := &domain.Preference{UserID: userID, Key: domain.PrefTimeZone, Value: `"UTC"`}
+	if err := repo.Set(ctx, pref); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	pref.Value = `"Europe/Berlin"`
+	if err := repo.Set(ctx, pref); err != nil {
+		t.Fatalf("Set (replace) failed: %v", err)
+	}
+	if err := repo.Set(ctx, &domain.Preference{UserID: userID, Key: domain.PrefMinBufferMinutes, Value: "10"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	prefs, err := repo.ListByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(prefs) != 2 || prefs[0].Key != domain.PrefMinBufferMinutes || prefs[1].Value != `"Europe/Berlin"` || prefs[1].UpdatedAt.IsZero() {
+		t.Fatalf("Expected both preferences ordered by key, got %+v", prefs)
+	}
+
+	if err := repo.Delete(ctx, userID, domain.PrefTimeZone); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, userID, domain.PrefTimeZone); err != nil {
+		t.Errorf("Deleting a missing preference should succeed, got %v", err)
+	}
+	prefs, _ = repo.ListByUser(ctx, userID)
+	if len(prefs) != 1 {
+		t.Errorf("Expected one preference after delete, got %d", len(prefs))
+	}
+}
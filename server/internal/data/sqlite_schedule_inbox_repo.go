@@ -0,0 +1,123 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const scheduleMessageColumns = `id, user_id, name, uid, method, ics, etag, created_at`
+
+// SQLiteScheduleInboxRepo implements domain.ScheduleInboxRepo using SQLite
+type SQLiteScheduleInboxRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteScheduleInboxRepo creates a new SQLite schedule inbox repository
+func NewSQLiteScheduleInboxRepo(db *sql.DB) *SQLiteScheduleInboxRepo {
+	return &SQLiteScheduleInboxRepo{db: db}
+}
+
+// WithTx returns a new SQLiteScheduleInboxRepo that operates within the given transaction.
+func (r *SQLiteScheduleInboxRepo) WithTx(tx *sql.Tx) *SQLiteScheduleInboxRepo {
+	return &SQLiteScheduleInboxRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteScheduleInboxRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// Create stores a message in a user's inbox. Returns domain.ErrConflict when
+// the inbox already has a message with the same name.
+func (r *SQLiteScheduleInboxRepo) Create(ctx context.Context, msg *domain.ScheduleMessage) error {
+	query := `INSERT INTO schedule_inbox (user_id, name, uid, method, ics, etag, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.execer().ExecContext(ctx, query, msg.UserID, msg.Name, msg.UID, msg.Method, msg.ICS, msg.ETag, now)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to create schedule message: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	msg.ID = id
+	msg.CreatedAt = now
+	return nil
+}
+
+func (r *SQLiteScheduleInboxRepo) Get(ctx context.Context, userID int64, name string) (*domain.ScheduleMessage, error) {
+	query := `SELECT ` + scheduleMessageColumns + ` FROM schedule_inbox WHERE user_id = ? AND name = ?`
+
+	m, err := scanScheduleMessage(r.execer().QueryRowContext(ctx, query, userID, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get schedule message: %w", err)
+	}
+	return m, nil
+}
+
+// ListByUser returns the messages in a user's inbox, oldest first
+func (r *SQLiteScheduleInboxRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.ScheduleMessage, error) {
+	query := `SELECT ` + scheduleMessageColumns + ` FROM schedule_inbox WHERE user_id = ? ORDER BY id`
+
+	rows, err := r.execer().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*domain.ScheduleMessage
+	for rows.Next() {
+		m, err := scanScheduleMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+func (r *SQLiteScheduleInboxRepo) Delete(ctx context.Context, userID int64, name string) error {
+	result, err := r.execer().ExecContext(ctx, `DELETE FROM schedule_inbox WHERE user_id = ? AND name = ?`, userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule message: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func scanScheduleMessage(row interface{ Scan(dest ...any) error }) (*domain.ScheduleMessage, error) {
+	var m domain.ScheduleMessage
+	if err := row.Scan(&m.ID, &m.UserID, &m.Name, &m.UID, &m.Method, &m.ICS, &m.ETag, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteScheduleInboxRepo_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteScheduleInboxRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+
+	msg := &domain.ScheduleMessage{
+		UserID: userID,
+		Name:   "request-1.ics",
+		UID:    "meeting-1",
+		Method: domain.ITIPRequest,
+		ICS:    "BEGIN:VCALENDAR\r\nMETHOD:REQUEST\r\nEND:VCALENDAR\r\n",
+		ETag:   `"etag-1"`,
+	}
+	if err := repo.Create(ctx, msg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if msg.ID == 0 || msg.CreatedAt.IsZero() {
+		t.Errorf("Create did not fill in the stored message: %+v", msg)
+	}
+	if err := repo.Create(ctx, &domain.ScheduleMessage{UserID: userID, Name: "request-1.ics", UID: "x", Method: domain.ITIPCancel, ICS: "x", ETag: "x"}); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for a duplicate name, got %v", err)
+	}
+
+	got, err := repo.Get(ctx, userID, "request-1.ics")
+	if err != nil || got.Method != domain.ITIPRequest || got.UID != "meeting-1" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	list, err := repo.ListByUser(ctx, userID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListByUser = %v, %v; want 1 message", list, err)
+	}
+
+	if err := repo.Delete(ctx, userID, "request-1.ics"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, userID, "request-1.ics"); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	if _, err := repo.Get(ctx, userID, "request-1.ics"); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// SQLiteSessionRepo implements domain.SessionRepo using SQLite
+type SQLiteSessionRepo struct {
+	db *sql.DB
+}
+
+// NewSQLiteSessionRepo creates a new SQLite session repository
+func NewSQLiteSessionRepo(db *sql.DB) *SQLiteSessionRepo {
+	return &SQLiteSessionRepo{db: db}
+}
+
+func (r *SQLiteSessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	query := `INSERT INTO sessions (user_id, token_hash, csrf_token, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, session.UserID, session.TokenHash, session.CSRFToken, now, session.ExpiresAt)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	session.ID = id
+	session.CreatedAt = now
+	return nil
+}
+
+// GetByTokenHash returns the session with the given hash, expired or not
+func (r *SQLiteSessionRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	query := `SELECT id, user_id, token_hash, csrf_token, created_at, expires_at FROM sessions WHERE token_hash = ?`
+
+	var s domain.Session
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&s.ID, &s.UserID, &s.TokenHash, &s.CSRFToken, &s.CreatedAt, &s.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *SQLiteSessionRepo) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes sessions that expired at or before now
+func (r *SQLiteSessionRepo) DeleteExpired(ctx context.Context, now time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, now); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return nil
+}
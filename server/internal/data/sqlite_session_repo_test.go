@@ -0,0 +1,50 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteSessionRepo_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteSessionRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	current := &domain.Session{UserID: userID, TokenHash: "hash-current", CSRFToken: "csrf", ExpiresAt: now.Add(time.Hour)}
+	if err := repo.Create(ctx, current); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	expired := &domain.Session{UserID: userID, TokenHash: "hash-expired", CSRFToken: "csrf", ExpiresAt: now.Add(-time.Hour)}
+	if err := repo.Create(ctx, expired); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByTokenHash(ctx, "hash-current")
+	if err != nil {
+		t.Fatalf("GetByTokenHash failed: %v", err)
+	}
+	if got.ID != current.ID || got.CSRFToken != "csrf" || !got.ExpiresAt.Equal(current.ExpiresAt) {
+		t.Errorf("Unexpected session: %+v", got)
+	}
+
+	if err := repo.DeleteExpired(ctx, now); err != nil {
+		t.Fatalf("DeleteExpired failed: %v", err)
+	}
+	if _, err := repo.GetByTokenHash(ctx, "hash-expired"); err != domain.ErrNotFound {
+		t.Errorf("Expected expired session to be deleted, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, current.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByTokenHash(ctx, "hash-current"); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+}
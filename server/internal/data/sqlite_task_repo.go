@@ -0,0 +1,315 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// SQLiteTaskRepo implements domain.TaskRepo using SQLite
+type SQLiteTaskRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteTaskRepo creates a new SQLite task repository
+func NewSQLiteTaskRepo(db *sql.DB) *SQLiteTaskRepo {
+	return &SQLiteTaskRepo{db: db}
+}
+
+// WithTx returns a new SQLiteTaskRepo that operates within the given transaction.
+// The returned instance shares the same db reference but uses tx for all operations.
+func (r *SQLiteTaskRepo) WithTx(tx *sql.Tx) *SQLiteTaskRepo {
+	return &SQLiteTaskRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteTaskRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+const taskColumns = `id, user_id, todoist_id, calendar_id, uid, ics, etag, status,
+		content, description, priority, due_date, completed, completed_at,
+		created_at, updated_at`
+
+// Create inserts a new task into the database
+func (r *SQLiteTaskRepo) Create(ctx context.Context, task *domain.Task) error {
+	if err := task.Validate(); err != nil {
+		return fmt.Errorf("invalid task: %w", err)
+	}
+
+	query := `
+		INSERT INTO tasks (
+			user_id, todoist_id, calendar_id, uid, ics, etag, status,
+			content, description, priority, due_date, completed, completed_at,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.execer().ExecContext(ctx, query,
+		task.UserID,
+		task.TodoistID,
+		task.CalendarID,
+		nullString(task.UID),
+		nullString(task.ICS),
+		nullString(task.ETag),
+		nullString(task.Status),
+		task.Content,
+		nullString(task.Description),
+		task.Priority,
+		task.DueDate,
+		task.Completed,
+		task.CompletedAt,
+		now,
+		now,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	task.ID = id
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	return nil
+}
+
+// GetByID retrieves a task by its ID
+func (r *SQLiteTaskRepo) GetByID(ctx context.Context, id int64) (*domain.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ?`
+
+	task, err := scanTask(r.execer().QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get task by ID: %w", err)
+	}
+	return task, nil
+}
+
+// GetByUID retrieves a CalDAV task by calendar ID and UID
+func (r *SQLiteTaskRepo) GetByUID(ctx context.Context, calendarID int64, uid string) (*domain.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE calendar_id = ? AND uid = ?`
+
+	task, err := scanTask(r.execer().QueryRowContext(ctx, query, calendarID, uid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get task by UID: %w", err)
+	}
+	return task, nil
+}
+
+// GetByUIDs retrieves the CalDAV tasks of a calendar with the given UIDs.
+// UIDs without a task are simply absent from the result.
+func (r *SQLiteTaskRepo) GetByUIDs(ctx context.Context, calendarID int64, uids []string) ([]*domain.Task, error) {
+	var tasks []*domain.Task
+	for start := 0; start < len(uids); start += maxUIDsPerQuery {
+		end := min(start+maxUIDsPerQuery, len(uids))
+		batch := uids[start:end]
+
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, calendarID)
+		for i, uid := range batch {
+			placeholders[i] = "?"
+			args = append(args, uid)
+		}
+
+		query := `SELECT ` + taskColumns + ` FROM tasks WHERE calendar_id = ? AND uid IN (` + strings.Join(placeholders, ", ") + `)`
+		found, err := r.list(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, found...)
+	}
+	return tasks, nil
+}
+
+// ListByUser retrieves all tasks of a user, CalDAV or not
+func (r *SQLiteTaskRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = ? ORDER BY created_at ASC`
+	return r.list(ctx, query, userID)
+}
+
+// ListPending retrieves the incomplete tasks of a user, soonest due first
+func (r *SQLiteTaskRepo) ListPending(ctx context.Context, userID int64) ([]*domain.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks
+		WHERE user_id = ? AND completed = 0
+		ORDER BY due_date IS NULL, due_date ASC, created_at ASC`
+	return r.list(ctx, query, userID)
+}
+
+// ListByCalendar retrieves the tasks stored in a VTODO calendar collection
+func (r *SQLiteTaskRepo) ListByCalendar(ctx context.Context, calendarID int64) ([]*domain.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE calendar_id = ? ORDER BY created_at ASC`
+	return r.list(ctx, query, calendarID)
+}
+
+func (r *SQLiteTaskRepo) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Task, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// Update overwrites a task. CalDAV callers check the ETag precondition in the
+// same transaction before calling Update.
+func (r *SQLiteTaskRepo) Update(ctx context.Context, task *domain.Task) error {
+	if err := task.Validate(); err != nil {
+		return fmt.Errorf("invalid task: %w", err)
+	}
+
+	query := `
+		UPDATE tasks
+		SET todoist_id = ?, calendar_id = ?, uid = ?, ics = ?, etag = ?, status = ?,
+			content = ?, description = ?, priority = ?, due_date = ?,
+			completed = ?, completed_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now()
+	result, err := r.execer().ExecContext(ctx, query,
+		task.TodoistID,
+		task.CalendarID,
+		nullString(task.UID),
+		nullString(task.ICS),
+		nullString(task.ETag),
+		nullString(task.Status),
+		task.Content,
+		nullString(task.Description),
+		task.Priority,
+		task.DueDate,
+		task.Completed,
+		task.CompletedAt,
+		now,
+		task.ID,
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	task.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a task from the database
+func (r *SQLiteTaskRepo) Delete(ctx context.Context, id int64) error {
+	result, err := r.execer().ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// scanTask scans a row into a Task struct
+func scanTask(row interface{ Scan(...interface{}) error }) (*domain.Task, error) {
+	var t domain.Task
+	var todoistID, uid, ics, etag, status, description sql.NullString
+	var calendarID sql.NullInt64
+	var dueDate, completedAt sql.NullTime
+
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&todoistID,
+		&calendarID,
+		&uid,
+		&ics,
+		&etag,
+		&status,
+		&t.Content,
+		&description,
+		&t.Priority,
+		&dueDate,
+		&t.Completed,
+		&completedAt,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if todoistID.Valid {
+		t.TodoistID = &todoistID.String
+	}
+	if calendarID.Valid {
+		t.CalendarID = &calendarID.Int64
+	}
+	if dueDate.Valid {
+		t.DueDate = &dueDate.Time
+	}
+	if completedAt.Valid {
+		t.CompletedAt = &completedAt.Time
+	}
+	t.UID = fromNullString(uid)
+	t.ICS = fromNullString(ics)
+	t.ETag = fromNullString(etag)
+	t.Status = fromNullString(status)
+	t.Description = fromNullString(description)
+
+	return &t, nil
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteTaskRepo_LocalTaskLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	repo := NewSQLiteTaskRepo(db)
+	ctx := context.Background()
+
+	due := time.Date(2026, 2, 1, 17, 0, 0, 0, time.UTC)
+	task := &domain.Task{UserID: userID, Content: "File taxes", Priority: 4, DueDate: &due}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, &domain.Task{UserID: userID, Content: "Someday", Priority: 1}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Content != "File taxes" || got.DueDate == nil || !got.DueDate.Equal(due) || got.CalendarID != nil {
+		t.Errorf("Unexpected task: %+v", got)
+	}
+
+	pending, err := repo.ListPending(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListPending failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].ID != task.ID {
+		t.Fatalf("Expected 2 pending tasks with the dated one first, got %d", len(pending))
+	}
+
+	now := time.Now()
+	got.Completed = true
+	got.CompletedAt = &now
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	pending, err = repo.ListPending(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListPending failed: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("Expected 1 pending task after completion, got %d", len(pending))
+	}
+
+	if err := repo.Delete(ctx, task.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, task.ID); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := repo.Delete(ctx, task.ID); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestSQLiteTaskRepo_CalendarTasks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userID := createTestUser(t, db)
+	cal := createTestCalendar(t, db, userID)
+	repo := NewSQLiteTaskRepo(db)
+	ctx := context.Background()
+
+	task := &domain.Task{
+		UserID:     userID,
+		CalendarID: &cal.ID,
+		UID:        "todo-1",
+		ICS:        "BEGIN:VCALENDAR...",
+		ETag:       `"1"`,
+		Status:     "NEEDS-ACTION",
+		Content:    "Buy milk",
+		Priority:   1,
+	}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	dup := *task
+	if err := repo.Create(ctx, &dup); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for duplicate UID, got %v", err)
+	}
+
+	got, err := repo.GetByUID(ctx, cal.ID, "todo-1")
+	if err != nil {
+		t.Fatalf("GetByUID failed: %v", err)
+	}
+	if got.CalendarID == nil || *got.CalendarID != cal.ID || got.ETag != `"1"` {
+		t.Errorf("Unexpected task: %+v", got)
+	}
+
+	list, err := repo.ListByCalendar(ctx, cal.ID)
+	if err != nil {
+		t.Fatalf("ListByCalendar failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("Expected 1 calendar task, got %d", len(list))
+	}
+
+	// Calendar tasks need their ICS
+	if err := repo.Create(ctx, &domain.Task{UserID: userID, CalendarID: &cal.ID, Content: "x", Priority: 1}); err == nil {
+		t.Error("Expected validation error for calendar task without UID/ICS")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/airplne/calendar-app/server/internal/domain"
 )
 
+const userColumns = `id, username, email, password_hash, is_admin, disabled_at, created_at, updated_at`
+
 // SQLiteUserRepo implements domain.UserRepo using SQLite
 type SQLiteUserRepo struct {
 	db *sql.DB
@@ -20,10 +22,16 @@ func NewSQLiteUserRepo(db *sql.DB) *SQLiteUserRepo {
 }
 
 func (r *SQLiteUserRepo) Create(ctx context.Context, username string) (*domain.User, error) {
-	query := `INSERT INTO users (username, created_at, updated_at) VALUES (?, ?, ?)`
+	return r.CreateWithPassword(ctx, username, "", false)
+}
+
+// CreateWithPassword inserts a user together with its password hash, so an
+// account never exists without the credential it was created with.
+func (r *SQLiteUserRepo) CreateWithPassword(ctx context.Context, username, passwordHash string, isAdmin bool) (*domain.User, error) {
+	query := `INSERT INTO users (username, password_hash, is_admin, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
 	now := time.Now()
 
-	result, err := r.db.ExecContext(ctx, query, username, now, now)
+	result, err := r.db.ExecContext(ctx, query, username, passwordHash, isAdmin, now, now)
 	if err != nil {
 		if isUniqueConstraintError(err) {
 			return nil, domain.ErrConflict
@@ -37,43 +45,134 @@ func (r *SQLiteUserRepo) Create(ctx context.Context, username string) (*domain.U
 	}
 
 	return &domain.User{
-		ID:        id,
-		Username:  username,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:           id,
+		Username:     username,
+		PasswordHash: passwordHash,
+		IsAdmin:      isAdmin,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}, nil
 }
 
 func (r *SQLiteUserRepo) GetByID(ctx context.Context, id int64) (*domain.User, error) {
-	query := `SELECT id, username, created_at, updated_at FROM users WHERE id = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+	return r.get(ctx, query, id)
+}
 
-	var u domain.User
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&u.ID, &u.Username, &u.CreatedAt, &u.UpdatedAt,
-	)
+func (r *SQLiteUserRepo) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
+	return r.get(ctx, query, username)
+}
+
+// GetByEmail finds the user with an email address, ignoring case
+func (r *SQLiteUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ? COLLATE NOCASE AND email != ''`
+	return r.get(ctx, query, email)
+}
+
+// List returns all users ordered by username
+func (r *SQLiteUserRepo) List(ctx context.Context) ([]*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY username`
+
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, domain.ErrNotFound
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		users = append(users, u)
 	}
+	return users, rows.Err()
+}
 
-	return &u, nil
+// Update writes the email, credential and account state of a user. Returns
+// domain.ErrConflict when another user has the email.
+func (r *SQLiteUserRepo) Update(ctx context.Context, user *domain.User) error {
+	query := `UPDATE users SET email = ?, password_hash = ?, is_admin = ?, disabled_at = ?, updated_at = ? WHERE id = ?`
+	now := time.Now()
+
+	var disabledAt sql.NullTime
+	if user.DisabledAt != nil {
+		disabledAt = sql.NullTime{Time: *user.DisabledAt, Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, user.Email, user.PasswordHash, user.IsAdmin, disabledAt, now, user.ID)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	user.UpdatedAt = now
+	return nil
 }
 
-func (r *SQLiteUserRepo) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
-	query := `SELECT id, username, created_at, updated_at FROM users WHERE username = ?`
+// SetPassword replaces the password hash of a user and signs out all of its
+// sessions in one transaction. With revokeCredentials, the app passwords and
+// personal access tokens of the user are revoked as well.
+func (r *SQLiteUserRepo) SetPassword(ctx context.Context, userID int64, passwordHash string, revokeCredentials bool) error {
+	now := time.Now()
+	return WithTx(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`, passwordHash, now, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return domain.ErrNotFound
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("failed to delete sessions: %w", err)
+		}
+		if !revokeCredentials {
+			return nil
+		}
+		for _, table := range []string{"app_passwords", "personal_access_tokens"} {
+			query := `UPDATE ` + table + ` SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+			if _, err := tx.ExecContext(ctx, query, now, userID); err != nil {
+				return fmt.Errorf("failed to revoke %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
 
-	var u domain.User
-	err := r.db.QueryRowContext(ctx, query, username).Scan(
-		&u.ID, &u.Username, &u.CreatedAt, &u.UpdatedAt,
-	)
+func (r *SQLiteUserRepo) get(ctx context.Context, query string, arg any) (*domain.User, error) {
+	u, err := scanUser(r.db.QueryRowContext(ctx, query, arg))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	return u, nil
+}
 
+func scanUser(row interface{ Scan(dest ...any) error }) (*domain.User, error) {
+	var u domain.User
+	var disabledAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.IsAdmin, &disabledAt, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if disabledAt.Valid {
+		u.DisabledAt = &disabledAt.Time
+	}
 	return &u, nil
 }
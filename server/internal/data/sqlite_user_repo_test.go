@@ -0,0 +1,153 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteUserRepo_UpdateAndList(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteUserRepo(db)
+	ctx := context.Background()
+
+	alice, err := repo.Create(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, "alice"); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for duplicate username, got %v", err)
+	}
+	if _, err := repo.Create(ctx, "bob"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	disabledAt := time.Now().UTC().Truncate(time.Second)
+	alice.PasswordHash = "$2a$10$hash"
+	alice.IsAdmin = true
+	alice.DisabledAt = &disabledAt
+	if err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	got, err := repo.GetByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetByUsername failed: %v", err)
+	}
+	if got.PasswordHash != "$2a$10$hash" || !got.IsAdmin || got.DisabledAt == nil || !got.DisabledAt.Equal(disabledAt) {
+		t.Errorf("Update not persisted: %+v", got)
+	}
+
+	got.DisabledAt = nil
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, alice.ID); got.Disabled() {
+		t.Error("Expected user to be enabled again")
+	}
+
+	users, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[1].Username != "bob" {
+		t.Errorf("Expected alice and bob, got %d users", len(users))
+	}
+
+	if err := repo.Update(ctx, &domain.User{ID: 999}); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound updating a missing user, got %v", err)
+	}
+}
+
+func TestSQLiteUserRepo_CreateWithPasswordAndSetPassword(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteUserRepo(db)
+	sessions := NewSQLiteSessionRepo(db)
+	appPasswords := NewSQLiteAppPasswordRepo(db)
+	tokens := NewSQLitePersonalAccessTokenRepo(db)
+	ctx := context.Background()
+
+	alice, err := repo.CreateWithPassword(ctx, "alice", "$2a$10$first", true)
+	if err != nil {
+		t.Fatalf("CreateWithPassword failed: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, alice.ID); got.PasswordHash != "$2a$10$first" || !got.IsAdmin {
+		t.Errorf("CreateWithPassword not persisted: %+v", got)
+	}
+	if _, err := repo.CreateWithPassword(ctx, "alice", "$2a$10$other", false); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for duplicate username, got %v", err)
+	}
+
+	now := time.Now()
+	if err := sessions.Create(ctx, &domain.Session{UserID: alice.ID, TokenHash: "session", CSRFToken: "csrf", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("Create session failed: %v", err)
+	}
+	if err := appPasswords.Create(ctx, &domain.AppPassword{UserID: alice.ID, Name: "phone", TokenHash: "app", CreatedAt: now}); err != nil {
+		t.Fatalf("Create app password failed: %v", err)
+	}
+	if err := tokens.Create(ctx, &domain.PersonalAccessToken{UserID: alice.ID, Name: "script", TokenHash: "pat", Scopes: []domain.TokenScope{domain.ScopeRead}, CreatedAt: now}); err != nil {
+		t.Fatalf("Create token failed: %v", err)
+	}
+
+	// A plain reset signs out sessions but keeps other credentials
+	if err := repo.SetPassword(ctx, alice.ID, "$2a$10$second", false); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, alice.ID); got.PasswordHash != "$2a$10$second" {
+		t.Errorf("SetPassword not persisted: %+v", got)
+	}
+	if _, err := sessions.GetByTokenHash(ctx, "session"); err != domain.ErrNotFound {
+		t.Errorf("Expected the session to be deleted, got %v", err)
+	}
+	if p, err := appPasswords.GetByTokenHash(ctx, "app"); err != nil || p.Revoked() {
+		t.Errorf("App password should stay active: %+v, %v", p, err)
+	}
+
+	if err := repo.SetPassword(ctx, alice.ID, "$2a$10$third", true); err != nil {
+		t.Fatalf("SetPassword with revocation failed: %v", err)
+	}
+	if p, err := appPasswords.GetByTokenHash(ctx, "app"); err != nil || !p.Revoked() {
+		t.Errorf("Expected the app password to be revoked: %+v, %v", p, err)
+	}
+	if token, err := tokens.GetByTokenHash(ctx, "pat"); err != nil || token.RevokedAt == nil {
+		t.Errorf("Expected the token to be revoked: %+v, %v", token, err)
+	}
+
+	if err := repo.SetPassword(ctx, 999, "$2a$10$hash", false); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a missing user, got %v", err)
+	}
+}
+
+func TestSQLiteUserRepo_Email(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteUserRepo(db)
+	ctx := context.Background()
+
+	alice, _ := repo.Create(ctx, "alice")
+	bob, _ := repo.Create(ctx, "bob")
+	if _, err := repo.GetByEmail(ctx, ""); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for an empty email, got %v", err)
+	}
+
+	alice.Email = "Alice@Example.com"
+	if err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := repo.GetByEmail(ctx, "alice@example.COM")
+	if err != nil || got.ID != alice.ID || got.Email != "Alice@Example.com" {
+		t.Fatalf("GetByEmail = %+v, %v; want alice", got, err)
+	}
+
+	bob.Email = "alice@example.com"
+	if err := repo.Update(ctx, bob); err != domain.ErrConflict {
+		t.Errorf("Expected ErrConflict for another user's email, got %v", err)
+	}
+}
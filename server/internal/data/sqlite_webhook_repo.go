@@ -0,0 +1,267 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const (
+	webhookSubscriptionColumns = `id, user_id, url, secret, event_types, include_content, created_at`
+	webhookDeliveryColumns     = `id, subscription_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at, last_status_code, last_error, created_at, delivered_at`
+)
+
+// SQLiteWebhookRepo implements domain.WebhookRepo using SQLite
+type SQLiteWebhookRepo struct {
+	db *sql.DB
+	tx *sql.Tx // optional transaction; when set, used instead of db
+}
+
+// NewSQLiteWebhookRepo creates a new SQLite webhook repository
+func NewSQLiteWebhookRepo(db *sql.DB) *SQLiteWebhookRepo {
+	return &SQLiteWebhookRepo{db: db}
+}
+
+// WithTx returns a new SQLiteWebhookRepo that operates within the given
+// transaction, so deliveries are queued together with the change they report.
+func (r *SQLiteWebhookRepo) WithTx(tx *sql.Tx) *SQLiteWebhookRepo {
+	return &SQLiteWebhookRepo{
+		db: r.db,
+		tx: tx,
+	}
+}
+
+// execer returns either the transaction or the database for executing queries.
+func (r *SQLiteWebhookRepo) execer() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+func (r *SQLiteWebhookRepo) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscriptions (user_id, url, secret, event_types, include_content, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.execer().ExecContext(ctx, query, subscription.UserID, subscription.URL, subscription.Secret,
+		joinEventTypes(subscription.EventTypes), subscription.IncludeContent, now)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	subscription.ID = id
+	subscription.CreatedAt = now
+	return nil
+}
+
+func (r *SQLiteWebhookRepo) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = ?`
+
+	s, err := scanWebhookSubscription(r.execer().QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return s, nil
+}
+
+// ListSubscriptionsByUsers returns the subscriptions of the given users, or
+// of every user when there are none
+func (r *SQLiteWebhookRepo) ListSubscriptionsByUsers(ctx context.Context, userIDs []int64) ([]*domain.WebhookSubscription, error) {
+	if len(userIDs) == 0 {
+		return r.listSubscriptions(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+	}
+	args := make([]any, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions
+		WHERE user_id IN (?` + strings.Repeat(", ?", len(userIDs)-1) + `) ORDER BY id`
+	return r.listSubscriptions(ctx, query, args...)
+}
+
+// ListSubscriptionsByUser returns a user's subscriptions, newest first
+func (r *SQLiteWebhookRepo) ListSubscriptionsByUser(ctx context.Context, userID int64) ([]*domain.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE user_id = ? ORDER BY id DESC`
+	return r.listSubscriptions(ctx, query, userID)
+}
+
+func (r *SQLiteWebhookRepo) listSubscriptions(ctx context.Context, query string, args ...any) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*domain.WebhookSubscription
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *SQLiteWebhookRepo) DeleteSubscription(ctx context.Context, userID, id int64) error {
+	query := `DELETE FROM webhook_subscriptions WHERE id = ? AND user_id = ?`
+
+	result, err := r.execer().ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteWebhookRepo) Enqueue(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	if delivery.Status == "" {
+		delivery.Status = domain.WebhookDeliveryPending
+	}
+	if delivery.NextAttemptAt == nil {
+		delivery.NextAttemptAt = &now
+	}
+
+	result, err := r.execer().ExecContext(ctx, query, delivery.SubscriptionID, string(delivery.EventType), string(delivery.Payload),
+		string(delivery.Status), delivery.NextAttemptAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	delivery.ID = id
+	delivery.CreatedAt = now
+	return nil
+}
+
+func (r *SQLiteWebhookRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?`
+	return r.listDeliveries(ctx, query, string(domain.WebhookDeliveryPending), now, limit)
+}
+
+func (r *SQLiteWebhookRepo) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_attempt_at = ?,
+		last_status_code = ?, last_error = ?, delivered_at = ? WHERE id = ?`
+
+	result, err := r.execer().ExecContext(ctx, query, string(delivery.Status), delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteWebhookRepo) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = ? ORDER BY id DESC LIMIT ?`
+	return r.listDeliveries(ctx, query, subscriptionID, limit)
+}
+
+func (r *SQLiteWebhookRepo) PruneDeliveries(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM webhook_deliveries WHERE status IN (?, ?) AND created_at < ?`
+
+	if _, err := r.execer().ExecContext(ctx, query, string(domain.WebhookDeliveryDelivered), string(domain.WebhookDeliveryDead), before); err != nil {
+		return fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteWebhookRepo) listDeliveries(ctx context.Context, query string, args ...any) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.execer().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func joinEventTypes(types []domain.NotificationType) string {
+	values := make([]string, len(types))
+	for i, t := range types {
+		values[i] = string(t)
+	}
+	return strings.Join(values, ",")
+}
+
+func scanWebhookSubscription(row interface{ Scan(dest ...any) error }) (*domain.WebhookSubscription, error) {
+	var s domain.WebhookSubscription
+	var eventTypes string
+	var includeContent sql.NullBool
+	if err := row.Scan(&s.ID, &s.UserID, &s.URL, &s.Secret, &eventTypes, &includeContent, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	if eventTypes != "" {
+		for _, t := range strings.Split(eventTypes, ",") {
+			s.EventTypes = append(s.EventTypes, domain.NotificationType(t))
+		}
+	}
+	s.IncludeContent = includeContent.Bool
+	return &s, nil
+}
+
+func scanWebhookDelivery(row interface{ Scan(dest ...any) error }) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var eventType, payload, status string
+	var nextAttemptAt, lastAttemptAt, deliveredAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.SubscriptionID, &eventType, &payload, &status, &d.Attempts, &nextAttemptAt, &lastAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &deliveredAt); err != nil {
+		return nil, err
+	}
+	d.EventType = domain.NotificationType(eventType)
+	d.Payload = []byte(payload)
+	d.Status = domain.WebhookDeliveryStatus(status)
+	if nextAttemptAt.Valid {
+		d.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastAttemptAt.Valid {
+		d.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
@@ -0,0 +1,173 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSQLiteWebhookRepo_SubscriptionsAndQueue(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteWebhookRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+
+	sub := &domain.WebhookSubscription{
+		UserID:         userID,
+		URL:            "https://hooks.example.com/calendar",
+		Secret:         "whsec_test",
+		EventTypes:     []domain.NotificationType{"event.*", domain.NotificationSyncHealthChanged},
+		IncludeContent: true,
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("CreateSubscription failed: %v", err)
+	}
+	all := &domain.WebhookSubscription{UserID: userID, URL: "https://hooks.example.com/all", Secret: "whsec_all"}
+	if err := repo.CreateSubscription(ctx, all); err != nil {
+		t.Fatalf("CreateSubscription failed: %v", err)
+	}
+
+	got, err := repo.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription failed: %v", err)
+	}
+	if got.URL != sub.URL || got.Secret != "whsec_test" || !got.IncludeContent || len(got.EventTypes) != 2 || got.EventTypes[1] != domain.NotificationSyncHealthChanged {
+		t.Errorf("GetSubscription = %+v", got)
+	}
+	if list, err := repo.ListSubscriptionsByUser(ctx, userID); err != nil || len(list) != 2 || list[0].ID != all.ID || len(list[0].EventTypes) != 0 {
+		t.Fatalf("ListSubscriptionsByUser = %v, %v; want newest first", list, err)
+	}
+
+	// Queue: due deliveries come oldest first; later ones wait
+	now := time.Now().UTC().Truncate(time.Second)
+	later := now.Add(time.Hour)
+	first := &domain.WebhookDelivery{SubscriptionID: sub.ID, EventType: domain.NotificationEventCreated, Payload: []byte(`{"type":"event.created"}`), NextAttemptAt: &now}
+	second := &domain.WebhookDelivery{SubscriptionID: sub.ID, EventType: domain.NotificationEventDeleted, Payload: []byte(`{"type":"event.deleted"}`), NextAttemptAt: &now}
+	waiting := &domain.WebhookDelivery{SubscriptionID: all.ID, EventType: domain.NotificationCalendarCreated, Payload: []byte(`{}`), NextAttemptAt: &later}
+	for _, d := range []*domain.WebhookDelivery{first, second, waiting} {
+		if err := repo.Enqueue(ctx, d); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+	due, err := repo.ListDue(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("ListDue failed: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != first.ID || due[0].Status != domain.WebhookDeliveryPending || string(due[0].Payload) != `{"type":"event.created"}` {
+		t.Fatalf("ListDue = %+v, want the two due deliveries", due)
+	}
+
+	// One is delivered, the other retried later
+	first.Status = domain.WebhookDeliveryDelivered
+	first.Attempts = 1
+	first.LastAttemptAt = &now
+	first.LastStatusCode = 204
+	first.DeliveredAt = &now
+	first.NextAttemptAt = nil
+	if err := repo.RecordAttempt(ctx, first); err != nil {
+		t.Fatalf("RecordAttempt failed: %v", err)
+	}
+	second.Attempts = 1
+	second.LastAttemptAt = &now
+	second.LastStatusCode = 500
+	second.LastError = "webhook answered 500 Internal Server Error"
+	second.NextAttemptAt = &later
+	if err := repo.RecordAttempt(ctx, second); err != nil {
+		t.Fatalf("RecordAttempt failed: %v", err)
+	}
+	if due, err := repo.ListDue(ctx, now, 10); err != nil || len(due) != 0 {
+		t.Fatalf("ListDue after attempts = %v, %v; want none", due, err)
+	}
+
+	log, err := repo.ListDeliveries(ctx, sub.ID, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries failed: %v", err)
+	}
+	if len(log) != 2 || log[0].ID != second.ID || log[0].LastStatusCode != 500 || log[0].LastError == "" ||
+		log[1].Status != domain.WebhookDeliveryDelivered || log[1].DeliveredAt == nil || log[1].NextAttemptAt != nil {
+		t.Fatalf("ListDeliveries = %+v", log)
+	}
+
+	// Pruning drops finished deliveries only
+	if err := repo.PruneDeliveries(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PruneDeliveries failed: %v", err)
+	}
+	if log, _ := repo.ListDeliveries(ctx, sub.ID, 10); len(log) != 1 || log[0].ID != second.ID {
+		t.Fatalf("after pruning = %+v, want the pending delivery", log)
+	}
+
+	if err := repo.DeleteSubscription(ctx, userID+1, sub.ID); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting another user's subscription, got %v", err)
+	}
+	if err := repo.DeleteSubscription(ctx, userID, sub.ID); err != nil {
+		t.Fatalf("DeleteSubscription failed: %v", err)
+	}
+	if _, err := repo.GetSubscription(ctx, sub.ID); err != domain.ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if log, _ := repo.ListDeliveries(ctx, sub.ID, 10); len(log) != 0 {
+		t.Errorf("deliveries of a deleted subscription = %d, want 0", len(log))
+	}
+}
+
+func TestSQLiteWebhookRepo_ListSubscriptionsByUsersAndTx(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteWebhookRepo(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	other, err := NewSQLiteUserRepo(db).Create(ctx, "other")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	mine := &domain.WebhookSubscription{UserID: userID, URL: "https://hooks.example.com/mine", Secret: "whsec_mine"}
+	theirs := &domain.WebhookSubscription{UserID: other.ID, URL: "https://hooks.example.com/theirs", Secret: "whsec_theirs"}
+	for _, sub := range []*domain.WebhookSubscription{mine, theirs} {
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			t.Fatalf("CreateSubscription failed: %v", err)
+		}
+	}
+	if list, err := repo.ListSubscriptionsByUsers(ctx, []int64{userID}); err != nil || len(list) != 1 || list[0].ID != mine.ID {
+		t.Fatalf("ListSubscriptionsByUsers(mine) = %v, %v", list, err)
+	}
+	if list, err := repo.ListSubscriptionsByUsers(ctx, []int64{userID, other.ID}); err != nil || len(list) != 2 {
+		t.Fatalf("ListSubscriptionsByUsers(both) = %v, %v", list, err)
+	}
+	if list, err := repo.ListSubscriptionsByUsers(ctx, nil); err != nil || len(list) != 2 {
+		t.Fatalf("ListSubscriptionsByUsers(nil) = %v, %v; want every subscription", list, err)
+	}
+
+	// Deliveries queued in a transaction go with it
+	enqueueInTx := func(fail bool) error {
+		return WithTx(ctx, db, func(tx *sql.Tx) error {
+			delivery := &domain.WebhookDelivery{SubscriptionID: mine.ID, EventType: domain.NotificationEventCreated, Payload: []byte(`{}`)}
+			if err := repo.WithTx(tx).Enqueue(ctx, delivery); err != nil {
+				return err
+			}
+			if fail {
+				return errors.New("change failed")
+			}
+			return nil
+		})
+	}
+	if err := enqueueInTx(true); err == nil {
+		t.Fatal("expected the failing transaction to return its error")
+	}
+	if log, _ := repo.ListDeliveries(ctx, mine.ID, 10); len(log) != 0 {
+		t.Fatalf("deliveries after rollback = %d, want 0", len(log))
+	}
+	if err := enqueueInTx(false); err != nil {
+		t.Fatalf("enqueue in transaction failed: %v", err)
+	}
+	if log, _ := repo.ListDeliveries(ctx, mine.ID, 10); len(log) != 1 {
+		t.Fatalf("deliveries after commit = %d, want 1", len(log))
+	}
+}
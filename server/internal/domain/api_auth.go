@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Session is a web UI login, carried in an HttpOnly cookie. Requests that
+// change state must echo CSRFToken in the X-CSRF-Token header.
+type Session struct {
+	ID        int64
+	UserID    int64
+	TokenHash string // Hex SHA-256 of the cookie value; the value itself is never stored
+	CSRFToken string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the session can no longer be used at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// TokenScope limits what a personal access token may do.
+type TokenScope string
+
+const (
+	ScopeRead  TokenScope = "read"  // Safe methods (GET, HEAD) on the API and the event stream
+	ScopeWrite TokenScope = "write" // Requests that change state; implies read
+	ScopeAdmin TokenScope = "admin" // User administration; also needs an admin account
+)
+
+// ValidTokenScope reports whether scope is a known scope.
+func ValidTokenScope(scope TokenScope) bool {
+	return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
+
+// PersonalAccessToken authenticates scripts against the JSON API with
+// Authorization: Bearer.
+type PersonalAccessToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	TokenHash  string // Hex SHA-256 of the token; the token itself is never stored
+	Scopes     []TokenScope
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time // nil: does not expire
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Active reports whether the token can authenticate at now.
+func (t *PersonalAccessToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// HasScope reports whether the token grants scope. Write implies read.
+func (t *PersonalAccessToken) HasScope(scope TokenScope) bool {
+	if slices.Contains(t.Scopes, scope) {
+		return true
+	}
+	return scope == ScopeRead && slices.Contains(t.Scopes, ScopeWrite)
+}
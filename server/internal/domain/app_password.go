@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// AppPassword is a generated password for a single device. It signs in to
+// CalDAV in place of the account password and can be revoked on its own.
+type AppPassword struct {
+	ID                    int64
+	UserID                int64
+	Name                  string // Label chosen by the user, e.g. "iPhone"
+	TokenHash             string // Hex SHA-256 of the normalized secret; the secret itself is never stored
+	CreatedAt             time.Time
+	LastUsedAt            *time.Time
+	LastClientFingerprint string // Normalized CalDAV client fingerprint of the last use
+	RevokedAt             *time.Time
+}
+
+// Revoked reports whether the app password can no longer sign in.
+func (p *AppPassword) Revoked() bool {
+	return p.RevokedAt != nil
+}
+
+// Credential labels identify how a CalDAV request authenticated. They are
+// recorded with operation metadata and contain no secrets.
+const CredentialAccountPassword = "account"
+
+// AppPasswordCredential returns the credential label of an app password.
+func AppPasswordCredential(id int64) string {
+	return fmt.Sprintf("app-password:%d", id)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// AuditAction names what an audit log entry records.
+type AuditAction string
+
+const (
+	AuditPlanApply    AuditAction = "plan_apply"    // A plan proposal's changes were written
+	AuditPlanRollback AuditAction = "plan_rollback" // Changes of a plan_apply entry were reverted
+)
+
+// Entity types audit log entries refer to
+const (
+	AuditEntityPlanProposal = "plan_proposal"
+	AuditEntityAuditLog     = "audit_log" // A rollback refers to the entry it reverted
+)
+
+// AuditLogEntry records calendar changes made for a user by the server rather
+// than by their calendar clients, with the state of every event before and
+// after so the entry can be rolled back.
+type AuditLogEntry struct {
+	ID         int64
+	UserID     int64
+	Action     AuditAction
+	EntityType string // What caused the changes, e.g. AuditEntityPlanProposal
+	EntityID   string // ID of that entity
+	Summary    string // What the entry did, for the user
+	Changes    []AuditChange
+	CreatedAt  time.Time
+}
+
+// AuditChange is the change of one event within an audit log entry.
+type AuditChange struct {
+	CalendarID int64
+	UID        string
+	Summary    string // What the change did, for the user
+	BeforeICS  string // Empty when the change created the event
+	AfterICS   string // Empty when the change deleted the event
+	BeforeETag string
+	AfterETag  string // ETag the change left the event with; empty when it deleted the event
+	RevertedBy int64  // ID of the rollback entry that reverted the change; 0 while it is in effect
+}
+
+// RollbackAvailable reports whether the entry has changes a rollback could
+// still revert.
+func (e *AuditLogEntry) RollbackAvailable() bool {
+	if e.Action != AuditPlanApply {
+		return false
+	}
+	for _, c := range e.Changes {
+		if c.RevertedBy == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -42,6 +42,7 @@ const (
 	CalDAVErrorCorruptICS        CalDAVErrorCode = "corrupt_ics"
 	CalDAVErrorWriteFailed       CalDAVErrorCode = "write_failed"
 	CalDAVErrorAuthFailed        CalDAVErrorCode = "auth_failed"
+	CalDAVErrorAuthLockedOut     CalDAVErrorCode = "auth_locked_out"
 	CalDAVErrorUnsupportedMethod CalDAVErrorCode = "unsupported_method"
 	CalDAVErrorUnknown           CalDAVErrorCode = "unknown_error"
 )
@@ -66,6 +67,7 @@ type CalDAVOperation struct {
 	StatusCode        int
 	DurationMillis    int64
 	ClientFingerprint string
+	Credential        string // CredentialAccountPassword or AppPasswordCredential; "" when unauthenticated
 	ETagOutcome       CalDAVETagOutcome
 	OperationKind     CalDAVOperationKind
 	Outcome           CalDAVOperationOutcome
@@ -119,6 +121,8 @@ func SummarizeCalDAVOperations(operations []*CalDAVOperation) RecentOperationSum
 			summary.CorruptICSIncidents++
 		case CalDAVErrorDuplicateUID:
 			summary.UnresolvedDuplicateUIDs++
+		case CalDAVErrorAuthLockedOut:
+			summary.AuthLockouts++
 		case CalDAVErrorWriteFailed:
 			if op.OperationKind == CalDAVOperationWrite {
 				summary.CalendarWritePathFailing = true
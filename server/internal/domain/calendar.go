@@ -2,9 +2,19 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
+// DefaultCalendarName is the calendar every user gets. It cannot be deleted.
+const DefaultCalendarName = "default"
+
+// iCalendar component types a calendar collection can hold
+const (
+	ComponentEvent = "VEVENT"
+	ComponentTodo  = "VTODO"
+)
+
 // Calendar represents a CalDAV calendar collection
 type Calendar struct {
 	ID          int64
@@ -13,9 +23,14 @@ type Calendar struct {
 	DisplayName string // Human-readable name
 	Color       string // Hex color code (e.g., "#FF5733")
 	Description string
+	Order       *int   // Apple calendar-order (sidebar position); nil when never set
+	Timezone    string // CALDAV:calendar-timezone as sent by the client (VCALENDAR with one VTIMEZONE)
 	SyncToken   string // Internal revision token for change tracking
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// Supported iCalendar components (CALDAV:supported-calendar-component-set);
+	// empty means VEVENT only.
+	ComponentSet []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 // Validate checks required fields
@@ -26,5 +41,39 @@ func (c *Calendar) Validate() error {
 	if c.UserID <= 0 {
 		return errors.New("calendar UserID must be greater than 0")
 	}
+	for _, comp := range c.ComponentSet {
+		if comp != ComponentEvent && comp != ComponentTodo {
+			return fmt.Errorf("calendar component %q is not supported", comp)
+		}
+	}
 	return nil
 }
+
+// Components returns the supported component set, defaulting to VEVENT.
+func (c *Calendar) Components() []string {
+	if len(c.ComponentSet) == 0 {
+		return []string{ComponentEvent}
+	}
+	return c.ComponentSet
+}
+
+// SupportsComponent reports whether objects of the given component type
+// (VEVENT or VTODO) may be stored in the calendar.
+func (c *Calendar) SupportsComponent(name string) bool {
+	for _, comp := range c.Components() {
+		if comp == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CalendarTombstone records a deleted calendar so sync tokens it issued can be
+// told apart from tokens of a later calendar with the same name.
+type CalendarTombstone struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Revision  int64 // Change-log revision of the calendar at deletion
+	DeletedAt time.Time
+}
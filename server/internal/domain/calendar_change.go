@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncTokenPrefix is the URI prefix for RFC 6578 sync tokens. The suffix is the
+// calendar_changes revision the token was issued at.
+const SyncTokenPrefix = "urn:calendarapp:sync:"
+
+// ErrInvalidSyncToken is returned when a client presents a sync token that was
+// not issued by this server or is ahead of the calendar's current revision.
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// CalendarChangeType describes what happened to a calendar object.
+type CalendarChangeType string
+
+const (
+	CalendarChangeCreated CalendarChangeType = "created"
+	CalendarChangeUpdated CalendarChangeType = "updated"
+	CalendarChangeDeleted CalendarChangeType = "deleted"
+)
+
+// CalendarChange is one entry in a calendar's change log. Deleted entries act as
+// tombstones so sync-collection can report removals after the row is gone.
+// An empty UID marks a collection-level change (e.g. a bare sync token bump).
+type CalendarChange struct {
+	Revision   int64 // Monotonically increasing across all calendars
+	CalendarID int64
+	UID        string
+	Type       CalendarChangeType
+	CreatedAt  time.Time
+}
+
+// FormatSyncToken renders a change-log revision as a sync-token URI.
+func FormatSyncToken(revision int64) string {
+	return fmt.Sprintf("%s%d", SyncTokenPrefix, revision)
+}
+
+// ParseSyncToken extracts the change-log revision from a sync-token URI.
+// Tokens from the legacy "v<nanos>" format are rejected so clients fall back to
+// a full resync.
+func ParseSyncToken(token string) (int64, error) {
+	if !strings.HasPrefix(token, SyncTokenPrefix) {
+		return 0, ErrInvalidSyncToken
+	}
+	revision, err := strconv.ParseInt(strings.TrimPrefix(token, SyncTokenPrefix), 10, 64)
+	if err != nil || revision < 0 {
+		return 0, ErrInvalidSyncToken
+	}
+	return revision, nil
+}
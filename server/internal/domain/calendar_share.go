@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// SharePrivilege is what a grantee may do with a shared calendar.
+type SharePrivilege string
+
+const (
+	SharePrivilegeRead      SharePrivilege = "read"       // Read objects and free-busy
+	SharePrivilegeReadWrite SharePrivilege = "read-write" // Also create, change and delete objects
+)
+
+// ValidSharePrivilege reports whether p is a known privilege.
+func ValidSharePrivilege(p SharePrivilege) bool {
+	return p == SharePrivilegeRead || p == SharePrivilegeReadWrite
+}
+
+// CanWrite reports whether the privilege allows changing calendar objects.
+func (p SharePrivilege) CanWrite() bool {
+	return p == SharePrivilegeReadWrite
+}
+
+// SharedCalendarSeparator joins a calendar name and its owner's username into
+// the name the calendar has in a grantee's calendar home ("family@alice").
+// Usernames and calendar names cannot contain it.
+const SharedCalendarSeparator = "@"
+
+// CalendarShare grants another user access to a calendar.
+type CalendarShare struct {
+	ID            int64
+	CalendarID    int64
+	GranteeUserID int64
+	Privilege     SharePrivilege
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// SharedCalendarName returns the name of owner's calendar in a grantee's
+// calendar home.
+func SharedCalendarName(calendarName, owner string) string {
+	return calendarName + SharedCalendarSeparator + owner
+}
+
+// ParseSharedCalendarName splits a name made by SharedCalendarName. It
+// reports false for names of the user's own calendars.
+func ParseSharedCalendarName(name string) (calendarName, owner string, ok bool) {
+	calendarName, owner, ok = strings.Cut(name, SharedCalendarSeparator)
+	if !ok || calendarName == "" || owner == "" {
+		return "", "", false
+	}
+	return calendarName, owner, true
+}
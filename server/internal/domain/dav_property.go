@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// DeadProperty is a WebDAV property the server does not interpret (RFC 4918
+// §4.2). Clients set them with PROPPATCH and expect them back from PROPFIND,
+// so they are stored verbatim per resource.
+type DeadProperty struct {
+	UserID    int64
+	Path      string // Resource path as addressed by the client
+	Namespace string
+	Name      string
+	Value     string // Inner XML of the property element
+	UpdatedAt time.Time
+}
+
+// Validate checks required fields
+func (p *DeadProperty) Validate() error {
+	if p.UserID <= 0 {
+		return errors.New("dead property UserID must be greater than 0")
+	}
+	if p.Path == "" {
+		return errors.New("dead property Path is required")
+	}
+	if p.Name == "" {
+		return errors.New("dead property Name is required")
+	}
+	return nil
+}
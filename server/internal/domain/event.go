@@ -17,19 +17,59 @@ type Event struct {
 	CalendarID int64
 	UID        string // iCalendar UID (globally unique)
 	ICS        string // Full VEVENT component (stored as-is for CalDAV roundtrip)
-	// Extracted metadata for efficient queries:
-	Summary        string
-	Description    string
-	Location       string
-	StartTime      time.Time
-	EndTime        time.Time
-	AllDay         bool
-	RecurrenceRule string // RRULE string if recurring
-	ETag           string // SHA-256 hash of ICS for conflict detection
-	Sequence       int    // iCalendar SEQUENCE for versioning
-	Status         string // TENTATIVE, CONFIRMED, CANCELLED
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// Extracted metadata of the master component for efficient queries:
+	Summary         string
+	Description     string
+	Location        string
+	StartTime       time.Time
+	EndTime         time.Time
+	AllDay          bool
+	RecurrenceRule  string          // RRULE string if recurring
+	RecurrenceDates string          // Comma-separated RDATE values if recurring
+	ETag            string          // SHA-256 hash of ICS for conflict detection
+	ScheduleTag     string          // RFC 6638 Schedule-Tag; unchanged by PARTSTAT-only updates. Defaults to ETag when empty
+	Sequence        int             // iCalendar SEQUENCE for versioning
+	Status          string          // TENTATIVE, CONFIRMED, CANCELLED
+	Overrides       []EventOverride // RECURRENCE-ID instances stored in the same ICS
+	Alarms          []EventAlarm    // VALARMs, indexed on write for the reminder scheduler; not loaded on reads
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// EventOverride is the indexed metadata of a RECURRENCE-ID component that
+// modifies one instance (or, with RANGE=THISANDFUTURE, all following instances)
+// of a recurring event. Its ICS lives in the parent Event's ICS.
+type EventOverride struct {
+	RecurrenceID  time.Time // Original start of the overridden instance
+	ThisAndFuture bool      // RANGE=THISANDFUTURE: applies to this and all later instances
+	Summary       string
+	StartTime     time.Time
+	EndTime       time.Time
+	AllDay        bool
+	Sequence      int
+	Status        string
+}
+
+// EventAlarm is the indexed metadata of a VALARM of an event or one of its
+// overrides. Its ICS lives in the parent Event's ICS.
+type EventAlarm struct {
+	Key         string // VALARM UID, or its position within its component
+	Action      string // DISPLAY, AUDIO, EMAIL, ...
+	Description string
+	Recurring   bool // Triggers follow the recurrence set and are computed when near
+	// Earliest and latest trigger (including REPEAT) of a non-recurring event.
+	// Zero when every trigger has been acknowledged.
+	FirstTrigger time.Time
+	LastTrigger  time.Time
+}
+
+// EventWrite is one change of an event made outside a CalDAV request, e.g.
+// by applying a plan.
+type EventWrite struct {
+	CalendarID   int64
+	UID          string
+	ExpectedETag string // Current ETag of the event; empty when it must not exist yet
+	ICS          string // New calendar object; empty deletes the event
 }
 
 // GenerateETag computes SHA-256 hash of ICS data for conflict detection
@@ -103,3 +103,17 @@ func TestEvent_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSyncToken(t *testing.T) {
+	token := FormatSyncToken(42)
+	revision, err := ParseSyncToken(token)
+	if err != nil || revision != 42 {
+		t.Errorf("ParseSyncToken(%q) = %d, %v; want 42, nil", token, revision, err)
+	}
+
+	for _, invalid := range []string{"", "v1700000000000000000", SyncTokenPrefix + "abc", SyncTokenPrefix + "-1"} {
+		if _, err := ParseSyncToken(invalid); err != ErrInvalidSyncToken {
+			t.Errorf("ParseSyncToken(%q) error = %v, want ErrInvalidSyncToken", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// FreeBusyType is the FBTYPE of a busy period (RFC 5545 §3.2.9).
+type FreeBusyType string
+
+const (
+	FreeBusyBusy        FreeBusyType = "BUSY"
+	FreeBusyTentative   FreeBusyType = "BUSY-TENTATIVE"
+	FreeBusyUnavailable FreeBusyType = "BUSY-UNAVAILABLE"
+)
+
+// BusyPeriod is a span of time during which a user is not free. It carries no
+// event details so it can be shared with people who may not see the events.
+type BusyPeriod struct {
+	Start time.Time
+	End   time.Time
+	Type  FreeBusyType
+}
+
+// MergeBusyPeriods sorts periods and coalesces overlapping or adjacent periods
+// of the same type.
+func MergeBusyPeriods(periods []BusyPeriod) []BusyPeriod {
+	sorted := make([]BusyPeriod, 0, len(periods))
+	for _, p := range periods {
+		if p.End.After(p.Start) {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Type != sorted[j].Type {
+			return sorted[i].Type < sorted[j].Type
+		}
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var merged []BusyPeriod
+	for _, p := range sorted {
+		if n := len(merged); n > 0 && merged[n-1].Type == p.Type && !p.Start.After(merged[n-1].End) {
+			if p.End.After(merged[n-1].End) {
+				merged[n-1].End = p.End
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Start.Before(merged[j].Start)
+	})
+	return merged
+}
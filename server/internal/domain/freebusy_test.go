@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeBusyPeriods(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2026, 1, 5, hour, 0, 0, 0, time.UTC) }
+
+	merged := MergeBusyPeriods([]BusyPeriod{
+		{Start: at(13), End: at(14), Type: FreeBusyBusy},
+		{Start: at(9), End: at(11), Type: FreeBusyBusy},
+		{Start: at(10), End: at(12), Type: FreeBusyBusy},
+		{Start: at(12), End: at(13), Type: FreeBusyTentative},
+		{Start: at(15), End: at(15), Type: FreeBusyBusy}, // empty, dropped
+	})
+
+	want := []BusyPeriod{
+		{Start: at(9), End: at(12), Type: FreeBusyBusy},
+		{Start: at(12), End: at(13), Type: FreeBusyTentative},
+		{Start: at(13), End: at(14), Type: FreeBusyBusy},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("Expected %d periods, got %d: %+v", len(want), len(merged), merged)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Errorf("Period %d: expected %+v, got %+v", i, want[i], merged[i])
+		}
+	}
+}
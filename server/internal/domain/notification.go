@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// NotificationType names what a notification reports. Values are what the
+// event stream sends as "type".
+type NotificationType string
+
+const (
+	NotificationEventCreated      NotificationType = "event.created"
+	NotificationEventUpdated      NotificationType = "event.updated"
+	NotificationEventDeleted      NotificationType = "event.deleted"
+	NotificationCalendarCreated   NotificationType = "calendar.created"
+	NotificationCalendarUpdated   NotificationType = "calendar.updated"
+	NotificationCalendarDeleted   NotificationType = "calendar.deleted"
+	NotificationCalendarShared    NotificationType = "calendar.shared"
+	NotificationCalendarUnshared  NotificationType = "calendar.unshared"
+	NotificationSyncHealthChanged NotificationType = "sync_health.changed"
+	NotificationReminder          NotificationType = "reminder"
+)
+
+// Notification is one change published to the users it concerns once it is
+// stored. Which fields are set depends on Type. Notifications are shared
+// between subscribers and must not be modified after publishing.
+type Notification struct {
+	ID         int64 // Assigned when published; increases with every notification
+	Type       NotificationType
+	UserIDs    []int64 // Users who may see the notification; empty means every user
+	OccurredAt time.Time
+
+	Calendar   *Calendar             // calendar.* and event.* notifications
+	UID        string                // event.* notifications
+	Event      *Event                // event.created and event.updated
+	Grantee    *User                 // calendar.shared and calendar.unshared
+	SyncHealth *SyncHealthTransition // sync_health.changed
+	Reminder   *Reminder             // reminder
+}
+
+// SyncHealthTransition is a change of the Sync Health status.
+type SyncHealthTransition struct {
+	From    SyncHealthStatus
+	To      SyncHealthStatus
+	Reasons []SyncHealthReason // Reasons for To
+}
+
+// NotifiesUser reports whether userID is among the users n concerns.
+func (n *Notification) NotifiesUser(userID int64) bool {
+	if len(n.UserIDs) == 0 {
+		return true
+	}
+	for _, id := range n.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationPublisher passes notifications on to whoever subscribed to
+// them. Publish does not block on slow subscribers.
+type NotificationPublisher interface {
+	Publish(ctx context.Context, n *Notification)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+// ProposalStatus is where a plan proposal is in its lifecycle. Only valid
+// proposals can be applied; stale, applied, rejected, rolled_back and expired
+// are final, except that an applied proposal can still be rolled back.
+type ProposalStatus string
+
+const (
+	ProposalDraft      ProposalStatus = "draft"       // Has violations; cannot be applied as it is
+	ProposalValid      ProposalStatus = "valid"       // Passed validation; can be applied until it expires
+	ProposalStale      ProposalStatus = "stale"       // An event it touches changed since it was made
+	ProposalApplied    ProposalStatus = "applied"     // Its changes were written
+	ProposalRejected   ProposalStatus = "rejected"    // The user turned it down
+	ProposalRolledBack ProposalStatus = "rolled_back" // Applied, then undone
+	ProposalExpired    ProposalStatus = "expired"     // Was not applied in time
+)
+
+// Pending reports whether a proposal with this status may still be applied
+// or rejected.
+func (s ProposalStatus) Pending() bool {
+	return s == ProposalDraft || s == ProposalValid
+}
+
+// ProposalChangeType names what a proposed change does.
+type ProposalChangeType string
+
+const (
+	ChangeCreateFocusBlock ProposalChangeType = "create_focus_block" // New event that protects time for focused work
+	ChangeMoveEvent        ProposalChangeType = "move_event"         // New start and end for an existing event
+	ChangeShortenEvent     ProposalChangeType = "shorten_event"      // Earlier end for an existing event
+	ChangeDeclineEvent     ProposalChangeType = "decline_event"      // Decline an invitation the user received
+)
+
+// Machine-readable reasons a proposal went stale or expired
+const (
+	ProposalReasonETagMismatch = "etag_mismatch" // An event it touches was edited
+	ProposalReasonEventDeleted = "event_deleted" // An event it touches was deleted
+	ProposalReasonEventExists  = "event_exists"  // An event with the UID of a new block appeared
+	ProposalReasonConstraints  = "constraints"   // Other events or the preferences changed and a constraint now fails
+	ProposalReasonExpired      = "expired"
+)
+
+// PlanProposal is a set of calendar changes offered to a user for review.
+// Nothing is written until the proposal is applied.
+type PlanProposal struct {
+	ID           int64
+	UserID       int64
+	Status       ProposalStatus
+	StatusReason string // Machine-readable cause of stale or expired; empty otherwise
+	Summary      string // What the proposal does, for the user
+	Changes      []ProposalChange
+	Violations   []ProposalViolation // Why a draft cannot be applied
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ProposalChange is one typed change of a proposal. Each change touches one
+// event; ETag records the version of that event the change was made against.
+type ProposalChange struct {
+	Type       ProposalChangeType
+	CalendarID int64
+	UID        string    // Event the change touches; for create_focus_block the UID the block gets
+	ETag       string    // ETag of the event when the proposal was made; empty for create_focus_block
+	Summary    string    // create_focus_block: title of the block
+	Start      time.Time // create_focus_block and move_event: new start
+	End        time.Time // create_focus_block, move_event and shorten_event: new end
+	Reason     string    // Why the change is proposed, for the user
+}
+
+// ProposalViolation is a reason a proposal cannot be applied. Codes are
+// stable; messages explain them to the user.
+type ProposalViolation struct {
+	Code    string
+	Change  int // Index of the change it concerns; -1 for the whole proposal
+	Message string
+}
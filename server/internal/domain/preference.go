@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Keys of the planning preferences. Values are stored JSON-encoded:
+//
+//	planning.time_zone                    "Europe/Berlin"
+//	planning.working_hours                {"mon": {"start": "09:00", "end": "17:00"}, ...}; weekdays left out are days off
+//	planning.min_buffer_minutes           10
+//	planning.max_meeting_minutes_per_day  300; 0 means no limit
+//	planning.no_meeting_days              ["wed"]
+//	planning.travel_minutes               30; 0 means events with a LOCATION need no travel time
+const (
+	PrefTimeZone          = "planning.time_zone"
+	PrefWorkingHours      = "planning.working_hours"
+	PrefMinBufferMinutes  = "planning.min_buffer_minutes"
+	PrefMaxMeetingMinutes = "planning.max_meeting_minutes_per_day"
+	PrefNoMeetingDays     = "planning.no_meeting_days"
+	PrefTravelMinutes     = "planning.travel_minutes"
+)
+
+// Preference is one setting of a user, stored by key.
+type Preference struct {
+	UserID    int64
+	Key       string
+	Value     string // JSON-encoded
+	UpdatedAt time.Time
+}
+
+// Validate checks required fields
+func (p *Preference) Validate() error {
+	if p.UserID <= 0 {
+		return errors.New("preference UserID must be greater than 0")
+	}
+	if strings.TrimSpace(p.Key) == "" {
+		return errors.New("preference Key is required")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Reminder is one alarm trigger of one event instance that has fired. Each
+// trigger is recorded once, so it fires at most once, across restarts.
+type Reminder struct {
+	ID             int64
+	UserID         int64 // Owner of the calendar the event is in
+	EventID        int64
+	CalendarID     int64
+	UID            string
+	AlarmKey       string
+	Action         string    // VALARM ACTION: DISPLAY, AUDIO, EMAIL, ...
+	Summary        string    // SUMMARY of the instance
+	Description    string    // VALARM DESCRIPTION
+	Occurrence     time.Time // RECURRENCE-ID of the instance, or its start when not recurring
+	InstanceStart  time.Time
+	TriggerAt      time.Time  // When the alarm was due
+	FiredAt        time.Time  // When the reminder was sent
+	AcknowledgedAt *time.Time // Nil until the user dismisses the reminder
+}
+
+// ReminderChannel delivers fired reminders to their user, e.g. over the event
+// stream, a webhook or email. Channels ignore reminders they do not handle.
+type ReminderChannel interface {
+	Notify(ctx context.Context, reminder *Reminder) error
+}
+
+// AlarmAcknowledger writes ACKNOWLEDGED (RFC 9074) into the VALARM of a
+// stored event, for clients that dismiss alarms they have seen elsewhere.
+// Returns ErrNotFound when the event no longer has the alarm.
+type AlarmAcknowledger interface {
+	AcknowledgeAlarm(ctx context.Context, eventID int64, alarmKey string, at time.Time) error
+}
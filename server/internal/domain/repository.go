@@ -11,16 +11,21 @@ var (
 	ErrNotFound           = errors.New("not found")
 	ErrPreconditionFailed = errors.New("precondition failed: ETag mismatch")
 	ErrConflict           = errors.New("conflict: resource already exists")
+
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserDisabled       = errors.New("user is disabled")
 )
 
 // EventRepo defines the data access contract for events
 type EventRepo interface {
 	Create(ctx context.Context, event *Event) error
 	GetByUID(ctx context.Context, calendarID int64, uid string) (*Event, error)
+	GetByUIDs(ctx context.Context, calendarID int64, uids []string) ([]*Event, error) // Missing UIDs are omitted
 	GetByID(ctx context.Context, id int64) (*Event, error)
 	List(ctx context.Context, calendarID int64, start, end time.Time) ([]*Event, error)
 	ListAll(ctx context.Context, calendarID int64) ([]*Event, error)
-	Update(ctx context.Context, event *Event, expectedETag string) error // Returns ErrPreconditionFailed if ETag mismatch
+	ListRecurring(ctx context.Context, calendarID int64) ([]*Event, error) // Events with RRULE or RDATE, whatever their first instance
+	Update(ctx context.Context, event *Event, expectedETag string) error   // Returns ErrPreconditionFailed if ETag mismatch
 	Delete(ctx context.Context, calendarID int64, uid string) error
 }
 
@@ -33,6 +38,41 @@ type CalendarRepo interface {
 	Update(ctx context.Context, calendar *Calendar) error
 	Delete(ctx context.Context, id int64) error
 	IncrementSyncToken(ctx context.Context, calendarID int64) (string, error)
+	RecordChange(ctx context.Context, calendarID int64, uid string, changeType CalendarChangeType) (string, error) // Appends to the change log and returns the new sync token
+	ListChangesSince(ctx context.Context, calendarID int64, revision int64) ([]*CalendarChange, error)
+	HasChange(ctx context.Context, calendarID int64, revision int64) (bool, error)           // Whether revision is in the calendar's change log
+	RecordTombstone(ctx context.Context, calendar *Calendar) error                           // Call before Delete, in the same transaction
+	GetTombstone(ctx context.Context, userID int64, name string) (*CalendarTombstone, error) // Latest tombstone for the name; ErrNotFound if none
+}
+
+// CalendarShareRepo defines the data access contract for calendar shares
+type CalendarShareRepo interface {
+	Put(ctx context.Context, share *CalendarShare) error // Creates the share or changes the grantee's privilege
+	Get(ctx context.Context, calendarID, granteeUserID int64) (*CalendarShare, error)
+	ListByCalendar(ctx context.Context, calendarID int64) ([]*CalendarShare, error)
+	ListByGrantee(ctx context.Context, granteeUserID int64) ([]*CalendarShare, error)
+	Delete(ctx context.Context, calendarID, granteeUserID int64) error // ErrNotFound when there is no such share
+}
+
+// ScheduleInboxRepo defines the data access contract for schedule inbox messages
+type ScheduleInboxRepo interface {
+	Create(ctx context.Context, msg *ScheduleMessage) error
+	Get(ctx context.Context, userID int64, name string) (*ScheduleMessage, error)
+	ListByUser(ctx context.Context, userID int64) ([]*ScheduleMessage, error)
+	Delete(ctx context.Context, userID int64, name string) error // ErrNotFound when there is no such message
+}
+
+// AlarmRepo defines the data access contract for the alarm index and fired
+// reminders. The index itself is written with events (Event.Alarms).
+type AlarmRepo interface {
+	// ListDueEvents returns events with alarms that may trigger in
+	// [from, to): recurring events with alarms and events whose trigger
+	// bounds overlap the range.
+	ListDueEvents(ctx context.Context, from, to time.Time) ([]*Event, error)
+	RecordFiring(ctx context.Context, reminder *Reminder) error // ErrConflict when the trigger already fired
+	GetFiring(ctx context.Context, id int64) (*Reminder, error)
+	ListFirings(ctx context.Context, userID int64, since time.Time) ([]*Reminder, error) // Newest first
+	AcknowledgeFiring(ctx context.Context, id int64, at time.Time) error                 // ErrNotFound when there is no such reminder
 }
 
 // TaskRepo defines the data access contract for tasks
@@ -43,19 +83,114 @@ type TaskRepo interface {
 	ListPending(ctx context.Context, userID int64) ([]*Task, error)
 	Update(ctx context.Context, task *Task) error
 	Delete(ctx context.Context, id int64) error
+	GetByUID(ctx context.Context, calendarID int64, uid string) (*Task, error)
+	GetByUIDs(ctx context.Context, calendarID int64, uids []string) ([]*Task, error) // Missing UIDs are omitted
+	ListByCalendar(ctx context.Context, calendarID int64) ([]*Task, error)
+}
+
+// DeadPropertyRepo defines the data access contract for WebDAV dead properties
+type DeadPropertyRepo interface {
+	Set(ctx context.Context, prop *DeadProperty) error // Inserts or replaces the value
+	Remove(ctx context.Context, userID int64, path, namespace, name string) error
+	ListByPath(ctx context.Context, userID int64, path string) ([]*DeadProperty, error)
+	DeleteByPathPrefix(ctx context.Context, userID int64, prefix string) error // Drops the properties of a resource and everything below it
 }
 
 // UserRepo defines the data access contract for users
 type UserRepo interface {
 	Create(ctx context.Context, username string) (*User, error)
+	CreateWithPassword(ctx context.Context, username, passwordHash string, isAdmin bool) (*User, error)
 	GetByID(ctx context.Context, id int64) (*User, error)
 	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error) // Case-insensitive
+	List(ctx context.Context) ([]*User, error)
+	Update(ctx context.Context, user *User) error // Writes Email, PasswordHash, IsAdmin and DisabledAt
+	// SetPassword also deletes the user's sessions and, with revokeCredentials,
+	// revokes its app passwords and personal access tokens
+	SetPassword(ctx context.Context, userID int64, passwordHash string, revokeCredentials bool) error
+}
+
+// AppPasswordRepo defines the data access contract for app-specific passwords
+type AppPasswordRepo interface {
+	Create(ctx context.Context, password *AppPassword) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*AppPassword, error)
+	ListByUser(ctx context.Context, userID int64) ([]*AppPassword, error)
+	Revoke(ctx context.Context, userID, id int64, at time.Time) error // ErrNotFound unless the user owns an unrevoked app password with this ID
+	RecordUse(ctx context.Context, id int64, at time.Time, clientFingerprint string) error
+}
+
+// SessionRepo defines the data access contract for web UI login sessions
+type SessionRepo interface {
+	Create(ctx context.Context, session *Session) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*Session, error)
+	Delete(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// PersonalAccessTokenRepo defines the data access contract for API tokens
+type PersonalAccessTokenRepo interface {
+	Create(ctx context.Context, token *PersonalAccessToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	ListByUser(ctx context.Context, userID int64) ([]*PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id int64, at time.Time) error // ErrNotFound unless the user owns an unrevoked token with this ID
+	RecordUse(ctx context.Context, id int64, at time.Time) error
+}
+
+// WebhookRepo defines the data access contract for webhook subscriptions and
+// their delivery queue
+type WebhookRepo interface {
+	CreateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*WebhookSubscription, error)
+	ListSubscriptionsByUsers(ctx context.Context, userIDs []int64) ([]*WebhookSubscription, error) // Every user's when userIDs is empty
+	ListSubscriptionsByUser(ctx context.Context, userID int64) ([]*WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, userID, id int64) error // ErrNotFound unless the user owns the subscription; drops its deliveries
+	Enqueue(ctx context.Context, delivery *WebhookDelivery) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)               // Pending deliveries whose next attempt is due, oldest first
+	RecordAttempt(ctx context.Context, delivery *WebhookDelivery) error                              // Writes the status, attempt and retry fields
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*WebhookDelivery, error) // Newest first
+	PruneDeliveries(ctx context.Context, before time.Time) error                                     // Drops delivered and dead deliveries created before the given time
+}
+
+// PlanProposalRepo defines the data access contract for plan proposals
+type PlanProposalRepo interface {
+	Create(ctx context.Context, proposal *PlanProposal) error // Stores the proposal with its changes and violations
+	GetByID(ctx context.Context, id int64) (*PlanProposal, error)
+	ListByUser(ctx context.Context, userID int64, limit int) ([]*PlanProposal, error) // Newest first
+	// Update writes the status, status reason and violations if the stored
+	// status is still expected; ErrConflict when it changed meanwhile.
+	Update(ctx context.Context, proposal *PlanProposal, expected ProposalStatus) error
+}
+
+// AuditLogRepo defines the data access contract for the audit log
+type AuditLogRepo interface {
+	Create(ctx context.Context, entry *AuditLogEntry) error // Stores the entry with its changes
+	GetByID(ctx context.Context, id int64) (*AuditLogEntry, error)
+	ListByUser(ctx context.Context, userID int64, limit int) ([]*AuditLogEntry, error) // Newest first
+	// MarkReverted records that rollback entry by reverted the changes of
+	// entryID at the given positions.
+	MarkReverted(ctx context.Context, entryID int64, positions []int, by int64) error
+}
+
+// PreferenceRepo defines the data access contract for user preferences
+type PreferenceRepo interface {
+	ListByUser(ctx context.Context, userID int64) ([]*Preference, error)
+	Set(ctx context.Context, pref *Preference) error // Inserts the preference or replaces its value
+	Delete(ctx context.Context, userID int64, key string) error
 }
 
 // User represents an authenticated user
 type User struct {
-	ID        int64
-	Username  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           int64
+	Username     string
+	Email        string     // Calendar user address for scheduling (mailto:); empty if unset
+	PasswordHash string     // bcrypt; empty for accounts that only have the bootstrap credentials
+	IsAdmin      bool       // May manage other users through the admin API
+	DisabledAt   *time.Time // Disabled users cannot sign in
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Disabled reports whether the user is barred from signing in.
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
 }
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// iTIP methods (RFC 5546) of the scheduling messages the server sends.
+const (
+	ITIPRequest = "REQUEST"
+	ITIPCancel  = "CANCEL"
+	ITIPReply   = "REPLY"
+)
+
+// ErrUnsupportedITIPMethod is returned for incoming iTIP messages whose method
+// the server does not accept from outside.
+var ErrUnsupportedITIPMethod = errors.New("unsupported iTIP method")
+
+// Participation statuses (RFC 5545 §3.2.12) set by attendees.
+const (
+	PartStatNeedsAction = "NEEDS-ACTION"
+	PartStatAccepted    = "ACCEPTED"
+	PartStatDeclined    = "DECLINED"
+	PartStatTentative   = "TENTATIVE"
+)
+
+// ScheduleMessage is an iTIP message in a user's schedule inbox (RFC 6638
+// §2.2). Clients read and delete them like calendar objects.
+type ScheduleMessage struct {
+	ID        int64
+	UserID    int64
+	Name      string // Resource name in the inbox, "{random}.ics"
+	UID       string // UID of the scheduled event
+	Method    string // REQUEST, CANCEL or REPLY
+	ICS       string // VCALENDAR with METHOD
+	ETag      string
+	CreatedAt time.Time
+}
+
+// ITIPMessage is a scheduling message for calendar users who are not local to
+// this server.
+type ITIPMessage struct {
+	Method     string
+	UID        string
+	Originator string   // Calendar user address of the sender, e.g. mailto:alice@example.com
+	Recipients []string // Calendar user addresses
+	ICS        string   // VCALENDAR with METHOD
+}
+
+// ScheduleDelivery sends iTIP messages to calendar users outside this server.
+type ScheduleDelivery interface {
+	Deliver(ctx context.Context, msg *ITIPMessage) error
+}
+
+// ScheduleInbound accepts iTIP messages that calendar users outside this
+// server sent to local users, e.g. iMIP replies read from email. It returns
+// ErrNotFound when no recipient is local and ErrUnsupportedITIPMethod for
+// messages other than replies.
+type ScheduleInbound interface {
+	ReceiveITIP(ctx context.Context, msg *ITIPMessage) error
+}
+
+// MailtoAddress returns the calendar user address of an email address.
+func MailtoAddress(email string) string {
+	return "mailto:" + strings.ToLower(email)
+}
+
+// AddressEmail returns the email address of a mailto: calendar user address.
+// It reports false for other addresses.
+func AddressEmail(address string) (string, bool) {
+	if len(address) < len("mailto:") || !strings.EqualFold(address[:len("mailto:")], "mailto:") {
+		return "", false
+	}
+	email := strings.TrimSpace(address[len("mailto:"):])
+	return email, email != ""
+}
+
+// SameCalendarAddress reports whether two calendar user addresses name the
+// same calendar user. mailto: addresses compare case-insensitively.
+func SameCalendarAddress(a, b string) bool {
+	if emailA, ok := AddressEmail(a); ok {
+		emailB, ok := AddressEmail(b)
+		return ok && strings.EqualFold(emailA, emailB)
+	}
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
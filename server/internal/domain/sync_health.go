@@ -41,6 +41,7 @@ const (
 	SyncHealthReasonRecentWriteFailures         = "recent_write_failures"
 	SyncHealthReasonETagConflictThreshold       = "etag_conflict_threshold_exceeded"
 	SyncHealthReasonRecoverableClientFailures   = "recoverable_client_sync_failures"
+	SyncHealthReasonAuthLockouts                = "auth_lockouts_detected"
 	SyncHealthReasonCorruptICSDetected          = "corrupt_ics_detected"
 	SyncHealthReasonDuplicateUIDUnresolved      = "duplicate_uid_unresolved"
 	SyncHealthReasonRoundtripValidationFailed   = "roundtrip_validation_failed"
@@ -82,6 +83,7 @@ type RecentOperationSummary struct {
 	WriteFailures                 int
 	ETagConflicts                 int
 	RecoverableClientSyncFailures int
+	AuthLockouts                  int
 	CorruptICSIncidents           int
 	UnresolvedDuplicateUIDs       int
 	RoundtripValidationFailed     bool
@@ -289,6 +291,13 @@ func (e SyncHealthEvaluator) warningReasons(input SyncHealthEvaluationInput, now
 			Message:  "Recent recoverable client sync failures were observed.",
 		})
 	}
+	if input.Operations.AuthLockouts > 0 {
+		reasons = append(reasons, SyncHealthReason{
+			Code:     SyncHealthReasonAuthLockouts,
+			Severity: SyncHealthReasonWarning,
+			Message:  "A client was temporarily locked out after repeated failed sign-ins.",
+		})
+	}
 
 	return reasons
 }
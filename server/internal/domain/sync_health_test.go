@@ -122,6 +122,24 @@ func TestSyncHealthEvaluator_WarningWhenRecentRecoverableClientFailuresExist(t *
 	assertReason(t, health, SyncHealthReasonRecoverableClientFailures)
 }
 
+func TestSyncHealthEvaluator_WarningWhenAuthLockoutsExist(t *testing.T) {
+	evaluator := NewDefaultSyncHealthEvaluator()
+	now := time.Date(2026, 4, 26, 12, 0, 0, 0, time.UTC)
+	completedAt := now.Add(-time.Hour)
+
+	health := evaluator.Evaluate(SyncHealthEvaluationInput{
+		Now:       now,
+		GreenSync: passedGreenSync(completedAt),
+		Operations: SummarizeCalDAVOperations([]*CalDAVOperation{
+			{StatusCode: 401, OperationKind: CalDAVOperationRead, Outcome: CalDAVOperationRecoverableFailure, ErrorCode: CalDAVErrorAuthFailed},
+			{StatusCode: 429, OperationKind: CalDAVOperationRead, Outcome: CalDAVOperationRecoverableFailure, ErrorCode: CalDAVErrorAuthLockedOut},
+		}),
+	})
+
+	assertStatus(t, health, SyncHealthWarning)
+	assertReason(t, health, SyncHealthReasonAuthLockouts)
+}
+
 func TestSyncHealthEvaluator_WarningWhenRecentWriteFailuresExist(t *testing.T) {
 	evaluator := NewDefaultSyncHealthEvaluator()
 	now := time.Date(2026, 4, 26, 12, 0, 0, 0, time.UTC)
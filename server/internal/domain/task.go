@@ -7,10 +7,16 @@ import (
 )
 
 // Task represents a Todoist-synced or local task
+// Tasks synced over CalDAV also keep their full VTODO ICS (hybrid storage, like Event)
 type Task struct {
 	ID          int64
 	UserID      int64
 	TodoistID   *string // Nullable - nil for local tasks
+	CalendarID  *int64  // Nullable - set for tasks stored in a VTODO calendar collection
+	UID         string  // iCalendar UID (CalDAV tasks only)
+	ICS         string  // Full VTODO calendar object (stored as-is for CalDAV roundtrip)
+	ETag        string  // SHA-256 hash of ICS for conflict detection
+	Status      string  // NEEDS-ACTION, IN-PROCESS, COMPLETED, CANCELLED
 	Content     string
 	Description string
 	Priority    int // 1-4 (Todoist priority levels)
@@ -21,6 +27,21 @@ type Task struct {
 	UpdatedAt   time.Time
 }
 
+// PriorityFromICal maps an iCalendar PRIORITY (0 undefined, 1 highest .. 9
+// lowest) onto the Todoist 1-4 scale (4 most urgent).
+func PriorityFromICal(priority int) int {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return 4
+	case priority == 5:
+		return 3
+	case priority >= 6 && priority <= 9:
+		return 2
+	default:
+		return 1
+	}
+}
+
 // Validate checks required fields
 func (t *Task) Validate() error {
 	if t.Content == "" {
@@ -29,5 +50,8 @@ func (t *Task) Validate() error {
 	if t.Priority < 1 || t.Priority > 4 {
 		return fmt.Errorf("task Priority must be between 1 and 4, got %d", t.Priority)
 	}
+	if t.CalendarID != nil && (t.UID == "" || t.ICS == "") {
+		return errors.New("task UID and ICS are required for calendar tasks")
+	}
 	return nil
 }
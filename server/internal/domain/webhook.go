@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription sends the notifications of one user to a URL of their
+// choosing. Deliveries are signed with Secret (HMAC-SHA256).
+type WebhookSubscription struct {
+	ID             int64
+	UserID         int64
+	URL            string
+	Secret         string             // Signing key; shown to the user once, kept to sign deliveries
+	EventTypes     []NotificationType // Filters; "event.*" matches a family. Empty means every type
+	IncludeContent bool               // Send titles, times and locations; otherwise payloads are redacted like CalDAVOperation
+	CreatedAt      time.Time
+}
+
+// Matches reports whether notifications of type t go to the subscription.
+func (s *WebhookSubscription) Matches(t NotificationType) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, filter := range s.EventTypes {
+		if filter == t {
+			return true
+		}
+		if family, ok := strings.CutSuffix(string(filter), ".*"); ok && strings.HasPrefix(string(t), family+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is where a delivery is in the queue.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // Waiting for its first or next attempt
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered" // The receiver answered 2xx
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"      // Out of attempts; kept in the log, not retried
+)
+
+// WebhookDelivery is one notification queued for one subscription. The
+// payload is built when the notification is queued and sent as-is on every
+// attempt.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventType      NotificationType
+	Payload        []byte // JSON body
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time // Nil once delivered or dead
+	LastAttemptAt  *time.Time
+	LastStatusCode int    // HTTP status of the last attempt; 0 when no response arrived
+	LastError      string // Why the last attempt failed; never contains the payload
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// WebhookSender POSTs a delivery's payload to a subscription's URL. It
+// returns the HTTP status code, 0 when no response arrived, and an error
+// unless the receiver answered 2xx.
+type WebhookSender interface {
+	Send(ctx context.Context, subscription *WebhookSubscription, delivery *WebhookDelivery) (int, error)
+}
+
+// WebhookURLChecker is implemented by senders that refuse to deliver to some
+// URLs, such as those of the server's own network. Subscriptions to a URL the
+// sender refuses are rejected when they are created.
+type WebhookURLChecker interface {
+	CheckURL(u *url.URL) error
+}
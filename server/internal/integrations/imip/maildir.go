@@ -0,0 +1,78 @@
+package imip
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// ProcessMaildir reads every message in the new/ directory of a maildir and
+// hands its iTIP message to inbound. Processed messages move to cur/ marked
+// seen (":2,S"); messages that could not be parsed or delivered move to cur/
+// flagged (":2,F") for someone to look at, so each is only tried once.
+func ProcessMaildir(ctx context.Context, dir string, inbound domain.ScheduleInbound) (delivered, failed int, err error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read maildir: %w", err)
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return delivered, failed, ctx.Err()
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		flag := "S"
+		if err := receiveFile(ctx, filepath.Join(dir, "new", entry.Name()), inbound); err != nil {
+			slog.Warn("imip.maildir.rejected", "file", entry.Name(), "error", err)
+			flag = "F"
+			failed++
+		} else {
+			delivered++
+		}
+		name, _, _ := strings.Cut(entry.Name(), ":")
+		if err := os.Rename(filepath.Join(dir, "new", entry.Name()), filepath.Join(dir, "cur", name+":2,"+flag)); err != nil {
+			return delivered, failed, fmt.Errorf("failed to move processed message: %w", err)
+		}
+	}
+	return delivered, failed, nil
+}
+
+func receiveFile(ctx context.Context, path string, inbound domain.ScheduleInbound) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	msg, err := ParseMessage(f)
+	if err != nil {
+		return err
+	}
+	return inbound.ReceiveITIP(ctx, msg)
+}
+
+// WatchMaildir processes the maildir every interval until ctx is done.
+func WatchMaildir(ctx context.Context, dir string, interval time.Duration, inbound domain.ScheduleInbound) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		delivered, failed, err := ProcessMaildir(ctx, dir, inbound)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("imip.maildir.failed", "dir", dir, "error", err)
+		}
+		if delivered > 0 || failed > 0 {
+			slog.Info("imip.maildir.processed", "delivered", delivered, "failed", failed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
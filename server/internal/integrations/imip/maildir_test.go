@@ -0,0 +1,63 @@
+package imip
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type recordingInbound struct {
+	received []*domain.ITIPMessage
+	err      error
+}
+
+func (r *recordingInbound) ReceiveITIP(ctx context.Context, msg *domain.ITIPMessage) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.received = append(r.received, msg)
+	return nil
+}
+
+func TestProcessMaildir(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reply := testICS("REPLY", "ATTENDEE;PARTSTAT=ACCEPTED:mailto:carol@remote.example\n")
+	files := map[string]string{
+		"1.reply":  replyEmail("carol@remote.example", "text/calendar; method=REPLY", "7bit", reply),
+		"2.forged": replyEmail("mallory@evil.example", "text/calendar; method=REPLY", "7bit", reply),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, "new", name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inbound := &recordingInbound{}
+	delivered, failed, err := ProcessMaildir(context.Background(), dir, inbound)
+	if err != nil {
+		t.Fatalf("ProcessMaildir failed: %v", err)
+	}
+	if delivered != 1 || failed != 1 || len(inbound.received) != 1 || inbound.received[0].UID != "planning" {
+		t.Errorf("delivered %d, failed %d, received %+v", delivered, failed, inbound.received)
+	}
+	for _, name := range []string{"1.reply:2,S", "2.forged:2,F"} {
+		if _, err := os.Stat(filepath.Join(dir, "cur", name)); err != nil {
+			t.Errorf("Expected cur/%s: %v", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "new")); len(entries) != 0 {
+		t.Errorf("Expected new/ to be empty, got %d entries", len(entries))
+	}
+
+	if delivered, failed, _ := ProcessMaildir(context.Background(), dir, inbound); delivered != 0 || failed != 0 {
+		t.Error("Processed messages should not be read again")
+	}
+}
@@ -0,0 +1,163 @@
+// Package imip sends and reads iTIP scheduling messages as email (iMIP, RFC
+// 6047) for calendar users outside this server.
+package imip
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Mailer sends an RFC 5322 message to the given envelope recipients.
+type Mailer interface {
+	Send(ctx context.Context, from string, to []string, msg []byte) error
+}
+
+// SMTPConfig configures the SMTP relay invitations are sent through.
+type SMTPConfig struct {
+	Host     string
+	Port     int // 465 uses implicit TLS; other ports upgrade with STARTTLS when offered
+	Username string
+	Password string
+	From     string // Envelope sender and From address of every message
+}
+
+// LoadSMTPConfig reads the SMTP relay from CALENDARAPP_SMTP_HOST, _PORT
+// (default 587), _USER, _PASS and _FROM. It reports false when no host is set,
+// which leaves outbound scheduling disabled.
+func LoadSMTPConfig() (SMTPConfig, bool, error) {
+	config := SMTPConfig{
+		Host:     os.Getenv("CALENDARAPP_SMTP_HOST"),
+		Port:     587,
+		Username: os.Getenv("CALENDARAPP_SMTP_USER"),
+		Password: os.Getenv("CALENDARAPP_SMTP_PASS"),
+		From:     os.Getenv("CALENDARAPP_SMTP_FROM"),
+	}
+	if config.Host == "" {
+		return config, false, nil
+	}
+	if port := os.Getenv("CALENDARAPP_SMTP_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return config, false, fmt.Errorf("invalid CALENDARAPP_SMTP_PORT %q", port)
+		}
+		config.Port = p
+	}
+	if config.From == "" {
+		return config, false, errors.New("CALENDARAPP_SMTP_FROM is required when CALENDARAPP_SMTP_HOST is set")
+	}
+	return config, true, nil
+}
+
+// SMTPMailer sends mail through an SMTP relay. Credentials are only sent over
+// TLS (or to localhost), as net/smtp enforces.
+type SMTPMailer struct {
+	config  SMTPConfig
+	timeout time.Duration
+}
+
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config, timeout: 30 * time.Second}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, from string, to []string, msg []byte) error {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: m.timeout}
+	tlsConfig := &tls.Config{ServerName: m.config.Host}
+
+	var conn net.Conn
+	var err error
+	if m.config.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP relay: %w", err)
+	}
+	deadline := time.Now().Add(m.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && m.config.Port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("SMTP RCPT TO rejected: %w", err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA rejected: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP relay rejected message: %w", err)
+	}
+	return client.Quit()
+}
+
+// SentMail is a message captured by MemoryMailer.
+type SentMail struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// MemoryMailer keeps sent messages in memory instead of sending them, for
+// tests and development. Set Err to make Send fail.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []SentMail
+	Err  error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, from string, to []string, msg []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.sent = append(m.sent, SentMail{From: from, To: append([]string(nil), to...), Data: append([]byte(nil), msg...)})
+	return nil
+}
+
+// Sent returns the messages sent so far.
+func (m *MemoryMailer) Sent() []SentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SentMail(nil), m.sent...)
+}
@@ -0,0 +1,141 @@
+package imip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	// ErrNoCalendarPart is returned for email without an iTIP text/calendar part.
+	ErrNoCalendarPart = errors.New("message has no iTIP calendar part")
+
+	// ErrOriginatorMismatch is returned when the email's From address is not
+	// the calendar user the iTIP message claims to come from.
+	ErrOriginatorMismatch = errors.New("sender does not match the iTIP originator")
+)
+
+// maxMessageSize bounds how much of an email is read.
+const maxMessageSize = 10 << 20
+
+// ParseMessage reads an iMIP email (RFC 6047) and returns its iTIP message.
+// The originator is the ORGANIZER of a REQUEST or CANCEL and the ATTENDEE of a
+// REPLY, and must be the email's From address; anyone can write a calendar
+// object naming someone else.
+func ParseMessage(r io.Reader) (*domain.ITIPMessage, error) {
+	m, err := mail.ReadMessage(io.LimitReader(r, maxMessageSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email: %w", err)
+	}
+	from, err := mail.ParseAddress(m.Header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid From address: %w", err)
+	}
+	data, err := findCalendarPart(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoCalendarPart
+	}
+
+	cal, err := ical.NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar part: %w", err)
+	}
+	msg := &domain.ITIPMessage{ICS: string(data)}
+	if method := cal.Props.Get(ical.PropMethod); method != nil {
+		msg.Method = strings.ToUpper(method.Value)
+	}
+	event := firstEvent(cal)
+	if event == nil || msg.Method == "" {
+		return nil, ErrNoCalendarPart
+	}
+	if uid := event.Props.Get(ical.PropUID); uid != nil {
+		msg.UID = uid.Value
+	}
+	organizer := event.Props.Get(ical.PropOrganizer)
+	if msg.UID == "" || organizer == nil {
+		return nil, fmt.Errorf("invalid calendar part: UID and ORGANIZER are required")
+	}
+
+	switch msg.Method {
+	case domain.ITIPReply:
+		attendees := event.Props.Values(ical.PropAttendee)
+		if len(attendees) != 1 {
+			return nil, fmt.Errorf("invalid calendar part: a REPLY has exactly one ATTENDEE")
+		}
+		msg.Originator = attendees[0].Value
+		msg.Recipients = []string{organizer.Value}
+	case domain.ITIPRequest, domain.ITIPCancel:
+		msg.Originator = organizer.Value
+		for _, attendee := range event.Props.Values(ical.PropAttendee) {
+			if !domain.SameCalendarAddress(attendee.Value, organizer.Value) {
+				msg.Recipients = append(msg.Recipients, attendee.Value)
+			}
+		}
+	default:
+		return nil, domain.ErrUnsupportedITIPMethod
+	}
+
+	if !domain.SameCalendarAddress(msg.Originator, domain.MailtoAddress(from.Address)) {
+		return nil, ErrOriginatorMismatch
+	}
+	return msg, nil
+}
+
+// findCalendarPart returns the decoded body of the first text/calendar (or
+// application/ics) part with a METHOD, searching nested multiparts, or nil.
+func findCalendarPart(contentType, transferEncoding string, body io.Reader) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := parts.NextRawPart()
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid multipart body: %w", err)
+			}
+			data, err := findCalendarPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil || data != nil {
+				return data, err
+			}
+		}
+	}
+
+	if mediaType != "text/calendar" && mediaType != "application/ics" {
+		return nil, nil
+	}
+	var decoded io.Reader = body
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		decoded = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		decoded = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode calendar part: %w", err)
+	}
+	if !bytes.Contains(data, []byte("METHOD:")) {
+		return nil, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package imip
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func replyEmail(from, contentType, encoding, body string) string {
+	return "From: " + from + "\r\nTo: alice@example.com\r\nSubject: Accepted: Planning\r\nMIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=outer\r\n\r\n" +
+		"--outer\r\nContent-Type: text/plain\r\n\r\nSee attached.\r\n" +
+		"--outer\r\nContent-Type: " + contentType + "\r\nContent-Transfer-Encoding: " + encoding + "\r\n\r\n" + body + "\r\n" +
+		"--outer--\r\n"
+}
+
+func TestParseMessage(t *testing.T) {
+	reply := testICS("REPLY", "ATTENDEE;PARTSTAT=ACCEPTED:mailto:Carol@Remote.example\n")
+	qp := strings.ReplaceAll(reply, "=", "=3D")
+
+	msg, err := ParseMessage(strings.NewReader(replyEmail("Carol <carol@remote.example>", "text/calendar; method=REPLY", "quoted-printable", qp)))
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	if msg.Method != domain.ITIPReply || msg.Originator != "mailto:Carol@Remote.example" || msg.Recipients[0] != "mailto:alice@example.com" {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+	if !strings.Contains(msg.ICS, "PARTSTAT=ACCEPTED") {
+		t.Errorf("quoted-printable part not decoded: %s", msg.ICS)
+	}
+
+	tests := []struct {
+		name  string
+		email string
+		want  error
+	}{
+		{"forged sender", replyEmail("mallory@evil.example", "text/calendar", "7bit", reply), ErrOriginatorMismatch},
+		{"no calendar part", replyEmail("carol@remote.example", "text/html", "7bit", "<p>hi</p>"), ErrNoCalendarPart},
+		{"plain ics attachment", replyEmail("carol@remote.example", "text/calendar", "7bit", strings.Replace(reply, "METHOD:REPLY\r\n", "", 1)), ErrNoCalendarPart},
+		{"unsupported method", replyEmail("alice@example.com", "text/calendar", "7bit", strings.Replace(reply, "METHOD:REPLY", "METHOD:COUNTER", 1)), domain.ErrUnsupportedITIPMethod},
+	}
+	for _, tt := range tests {
+		if _, err := ParseMessage(strings.NewReader(tt.email)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
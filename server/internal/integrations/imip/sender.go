@@ -0,0 +1,226 @@
+package imip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// Sender delivers iTIP messages as iMIP email (RFC 6047), one message per
+// recipient. It implements domain.ScheduleDelivery.
+type Sender struct {
+	mailer Mailer
+	from   string
+	now    func() time.Time
+}
+
+// NewSender returns a Sender that sends from the given address. Replies go to
+// the originator of each message through Reply-To, since relays rarely allow
+// sending as arbitrary users.
+func NewSender(mailer Mailer, from string) *Sender {
+	return &Sender{mailer: mailer, from: from, now: time.Now}
+}
+
+// Deliver emails msg to each of its mailto: recipients. Other calendar user
+// addresses cannot be reached by email and fail the delivery, after the
+// remaining recipients have been tried.
+func (s *Sender) Deliver(ctx context.Context, msg *domain.ITIPMessage) error {
+	cal, err := ical.NewDecoder(strings.NewReader(msg.ICS)).Decode()
+	if err != nil {
+		return fmt.Errorf("invalid iTIP message: %w", err)
+	}
+
+	var errs []error
+	for _, recipient := range msg.Recipients {
+		to, ok := domain.AddressEmail(recipient)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not an email address", recipient))
+			continue
+		}
+		data, err := s.compose(msg, cal, to)
+		if err != nil {
+			return err
+		}
+		if err := s.mailer.Send(ctx, s.from, []string{to}, data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send to %s: %w", to, err))
+			continue
+		}
+		slog.Debug("imip.sent", "method", msg.Method, "uid", msg.UID)
+	}
+	return errors.Join(errs...)
+}
+
+// compose builds a multipart/alternative message with a plain-text summary
+// and the iTIP object as text/calendar (RFC 6047 §2.4).
+func (s *Sender) compose(msg *domain.ITIPMessage, cal *ical.Calendar, to string) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	text, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(text)
+	qp.Write([]byte(describe(msg, cal)))
+	qp.Close()
+
+	calendar, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("text/calendar", map[string]string{"method": msg.Method, "charset": "utf-8"})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64Lines(calendar, []byte(msg.ICS))
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	messageID, err := newMessageID(s.from)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&out, "%s: %s\r\n", name, value)
+	}
+	header("From", (&mail.Address{Address: s.from}).String())
+	if email, ok := domain.AddressEmail(msg.Originator); ok && !strings.EqualFold(email, s.from) {
+		header("Reply-To", (&mail.Address{Address: email}).String())
+	}
+	header("To", (&mail.Address{Address: to}).String())
+	header("Subject", mime.QEncoding.Encode("utf-8", subject(msg, cal)))
+	header("Date", s.now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// subject returns the Subject of a message, e.g. "Invitation: Planning".
+func subject(msg *domain.ITIPMessage, cal *ical.Calendar) string {
+	summary := eventSummary(cal)
+	switch msg.Method {
+	case domain.ITIPRequest:
+		return "Invitation: " + summary
+	case domain.ITIPCancel:
+		return "Cancelled: " + summary
+	case domain.ITIPReply:
+		switch replyPartStat(cal) {
+		case domain.PartStatAccepted:
+			return "Accepted: " + summary
+		case domain.PartStatDeclined:
+			return "Declined: " + summary
+		case domain.PartStatTentative:
+			return "Tentative: " + summary
+		}
+		return "Reply: " + summary
+	}
+	return summary
+}
+
+// describe returns the plain-text part, for mail clients that do not
+// understand text/calendar.
+func describe(msg *domain.ITIPMessage, cal *ical.Calendar) string {
+	who, _ := domain.AddressEmail(msg.Originator)
+	if who == "" {
+		who = msg.Originator
+	}
+	summary := eventSummary(cal)
+
+	var b strings.Builder
+	switch msg.Method {
+	case domain.ITIPRequest:
+		fmt.Fprintf(&b, "%s invited you to %q.\r\n", who, summary)
+	case domain.ITIPCancel:
+		fmt.Fprintf(&b, "%s cancelled %q.\r\n", who, summary)
+	case domain.ITIPReply:
+		fmt.Fprintf(&b, "%s replied %s to %q.\r\n", who, strings.ToLower(replyPartStat(cal)), summary)
+	}
+	if event := firstEvent(cal); event != nil {
+		if start, err := event.Props.DateTime(ical.PropDateTimeStart, time.UTC); err == nil {
+			fmt.Fprintf(&b, "\r\nWhen: %s\r\n", start.Format("Mon Jan 2, 2006 15:04 MST"))
+		}
+		if location := event.Props.Get(ical.PropLocation); location != nil && location.Value != "" {
+			fmt.Fprintf(&b, "Where: %s\r\n", location.Value)
+		}
+	}
+	if msg.Method == domain.ITIPRequest {
+		b.WriteString("\r\nOpen the invitation in your calendar to respond.\r\n")
+	}
+	return b.String()
+}
+
+func firstEvent(cal *ical.Calendar) *ical.Component {
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompEvent {
+			return comp
+		}
+	}
+	return nil
+}
+
+func eventSummary(cal *ical.Calendar) string {
+	if event := firstEvent(cal); event != nil {
+		if summary := event.Props.Get(ical.PropSummary); summary != nil && summary.Value != "" {
+			return summary.Value
+		}
+	}
+	return "(no title)"
+}
+
+// replyPartStat returns the PARTSTAT of the attendee in a REPLY.
+func replyPartStat(cal *ical.Calendar) string {
+	if event := firstEvent(cal); event != nil {
+		if attendee := event.Props.Get(ical.PropAttendee); attendee != nil {
+			return strings.ToUpper(attendee.Params.Get(ical.ParamParticipationStatus))
+		}
+	}
+	return ""
+}
+
+// writeBase64Lines writes data base64-encoded in 76-character lines (RFC 2045
+// §6.8).
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// newMessageID returns a random Message-ID in the domain of from.
+func newMessageID(from string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate Message-ID: %w", err)
+	}
+	host := "calendar-app.invalid"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		host = strings.Trim(from[at+1:], "> ")
+	}
+	return "<" + hex.EncodeToString(buf) + "@" + host + ">", nil
+}
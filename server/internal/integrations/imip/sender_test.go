@@ -0,0 +1,110 @@
+package imip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func testICS(method, attendees string) string {
+	return strings.ReplaceAll(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//Test//EN
+METHOD:`+method+`
+BEGIN:VEVENT
+UID:planning
+DTSTAMP:20260116T080000Z
+DTSTART:20260120T100000Z
+DTEND:20260120T110000Z
+SUMMARY:Planning
+LOCATION:Room 1
+ORGANIZER:mailto:alice@example.com
+`+attendees+`END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n")
+}
+
+func TestSenderDeliver(t *testing.T) {
+	mailer := NewMemoryMailer()
+	sender := NewSender(mailer, "calendar@example.com")
+	msg := &domain.ITIPMessage{
+		Method:     domain.ITIPRequest,
+		UID:        "planning",
+		Originator: "mailto:alice@example.com",
+		Recipients: []string{"mailto:carol@remote.example", "mailto:dave@remote.example"},
+		ICS:        testICS("REQUEST", "ATTENDEE:mailto:carol@remote.example\nATTENDEE:mailto:dave@remote.example\n"),
+	}
+	if err := sender.Deliver(context.Background(), msg); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	sent := mailer.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("Expected one email per recipient, got %d", len(sent))
+	}
+	if sent[0].From != "calendar@example.com" || len(sent[0].To) != 1 || sent[0].To[0] != "carol@remote.example" {
+		t.Errorf("Unexpected envelope: from %s to %v", sent[0].From, sent[0].To)
+	}
+	m, err := mail.ReadMessage(bytes.NewReader(sent[0].Data))
+	if err != nil {
+		t.Fatalf("Sent message is not valid email: %v", err)
+	}
+	if got := m.Header.Get("Subject"); got != "Invitation: Planning" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := m.Header.Get("Reply-To"); got != "<alice@example.com>" {
+		t.Errorf("Reply-To = %q, want the organizer", got)
+	}
+	if !strings.HasPrefix(m.Header.Get("Content-Type"), "multipart/alternative;") {
+		t.Errorf("Content-Type = %q", m.Header.Get("Content-Type"))
+	}
+	data, err := findCalendarPart(m.Header.Get("Content-Type"), "", m.Body)
+	if err != nil || string(data) != msg.ICS {
+		t.Errorf("calendar part does not round-trip: %v\n%s", err, data)
+	}
+	if !bytes.Contains(sent[0].Data, []byte("text/calendar; charset=utf-8; method=REQUEST")) {
+		t.Error("Expected a text/calendar part with method=REQUEST")
+	}
+
+	mailer.Err = errors.New("relay down")
+	msg.Recipients = []string{"/dav/principals/bob/", "mailto:carol@remote.example"}
+	if err := sender.Deliver(context.Background(), msg); err == nil {
+		t.Error("Expected an error for a non-mailto recipient and a failing relay")
+	}
+}
+
+func TestSenderReplyRoundTrip(t *testing.T) {
+	mailer := NewMemoryMailer()
+	sender := NewSender(mailer, "carol@remote.example")
+	reply := &domain.ITIPMessage{
+		Method:     domain.ITIPReply,
+		UID:        "planning",
+		Originator: "mailto:carol@remote.example",
+		Recipients: []string{"mailto:alice@example.com"},
+		ICS:        testICS("REPLY", "ATTENDEE;PARTSTAT=DECLINED:mailto:carol@remote.example\n"),
+	}
+	if err := sender.Deliver(context.Background(), reply); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	sent := mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Expected one email, got %d", len(sent))
+	}
+	if !bytes.Contains(sent[0].Data, []byte("Subject: Declined: Planning")) {
+		t.Errorf("Expected a Declined subject, got:\n%s", sent[0].Data)
+	}
+
+	parsed, err := ParseMessage(bytes.NewReader(sent[0].Data))
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	if parsed.Method != domain.ITIPReply || parsed.UID != "planning" || parsed.Originator != reply.Originator ||
+		len(parsed.Recipients) != 1 || parsed.Recipients[0] != "mailto:alice@example.com" || parsed.ICS != reply.ICS {
+		t.Errorf("Parsed message = %+v, want %+v", parsed, reply)
+	}
+}
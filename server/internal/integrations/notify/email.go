@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/integrations/imip"
+)
+
+// Email sends ACTION:EMAIL reminders to the email address of the calendar's
+// owner. Other actions are left to the clients. The ATTENDEEs of the VALARM
+// are not mailed: anyone who can write an event could otherwise send mail to
+// arbitrary addresses through the server. It implements
+// domain.ReminderChannel.
+type Email struct {
+	mailer imip.Mailer
+	from   string
+	users  domain.UserRepo
+	now    func() time.Time
+}
+
+func NewEmail(mailer imip.Mailer, from string, users domain.UserRepo) *Email {
+	return &Email{mailer: mailer, from: from, users: users, now: time.Now}
+}
+
+func (e *Email) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	if reminder.Action != "EMAIL" {
+		return nil
+	}
+	user, err := e.users.GetByID(ctx, reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get reminder owner: %w", err)
+	}
+	if user.Email == "" {
+		slog.Debug("notify.email.no_address", "user_id", user.ID, "reminder_id", reminder.ID)
+		return nil
+	}
+	return e.mailer.Send(ctx, e.from, []string{user.Email}, e.compose(reminder, user.Email))
+}
+
+// compose builds a plain-text message describing the reminder.
+func (e *Email) compose(reminder *domain.Reminder, to string) []byte {
+	summary := reminder.Summary
+	if summary == "" {
+		summary = "(no title)"
+	}
+	var text bytes.Buffer
+	qp := quotedprintable.NewWriter(&text)
+	fmt.Fprintf(qp, "Reminder: %q\r\n\r\nWhen: %s\r\n", summary, reminder.InstanceStart.UTC().Format("Mon Jan 2, 2006 15:04 MST"))
+	if reminder.Description != "" {
+		fmt.Fprintf(qp, "\r\n%s\r\n", reminder.Description)
+	}
+	qp.Close()
+
+	var out bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&out, "%s: %s\r\n", name, value)
+	}
+	header("From", (&mail.Address{Address: e.from}).String())
+	header("To", (&mail.Address{Address: to}).String())
+	header("Subject", mime.QEncoding.Encode("utf-8", "Reminder: "+summary))
+	header("Date", e.now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	out.WriteString("\r\n")
+	out.Write(text.Bytes())
+	return out.Bytes()
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/integrations/imip"
+)
+
+// fakeUserRepo serves fixed users; other methods are unused.
+type fakeUserRepo struct {
+	domain.UserRepo
+	users map[int64]*domain.User
+}
+
+func (f *fakeUserRepo) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	if user, ok := f.users[id]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func TestEmailNotify(t *testing.T) {
+	mailer := imip.NewMemoryMailer()
+	users := &fakeUserRepo{users: map[int64]*domain.User{
+		42: {ID: 42, Username: "alice", Email: "alice@example.com"},
+		43: {ID: 43, Username: "bob"},
+	}}
+	email := NewEmail(mailer, "calendar@example.com", users)
+	ctx := context.Background()
+
+	if err := email.Notify(ctx, testReminder("DISPLAY")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	noAddress := testReminder("EMAIL")
+	noAddress.UserID = 43
+	if err := email.Notify(ctx, noAddress); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if sent := mailer.Sent(); len(sent) != 0 {
+		t.Fatalf("expected no mail for DISPLAY alarms or users without email, got %d", len(sent))
+	}
+
+	if err := email.Notify(ctx, testReminder("EMAIL")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	sent := mailer.Sent()
+	if len(sent) != 1 || sent[0].From != "calendar@example.com" || len(sent[0].To) != 1 || sent[0].To[0] != "alice@example.com" {
+		t.Fatalf("unexpected mail: %+v", sent)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(sent[0].Data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "Reminder: Weekly review" {
+		t.Errorf("Subject = %q", subject)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if !strings.Contains(string(body), "Bring notes") || !strings.Contains(string(body), "Mon Mar 9, 2026 09:00 UTC") {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
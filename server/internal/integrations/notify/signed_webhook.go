@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// Headers of a signed webhook delivery. Receivers verify the signature with
+// the subscription's secret and should reject old timestamps.
+const (
+	HeaderWebhookEvent     = "X-CalendarApp-Event"
+	HeaderWebhookDelivery  = "X-CalendarApp-Delivery"
+	HeaderWebhookTimestamp = "X-CalendarApp-Timestamp"
+	HeaderWebhookSignature = "X-CalendarApp-Signature"
+)
+
+// ErrWebhookAddressNotAllowed is returned for webhook URLs and connections
+// to addresses that are not public.
+var ErrWebhookAddressNotAllowed = errors.New("webhook address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip.Addr.IsPrivate does not cover.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// SignedWebhookSender POSTs queued webhook deliveries, signed with the
+// subscription's secret. It implements domain.WebhookSender and
+// domain.WebhookURLChecker.
+//
+// Subscription URLs are chosen by users, so the sender only connects to
+// public addresses: loopback, private, link-local, multicast and unspecified
+// addresses are refused when the connection is dialed, after DNS resolution,
+// unless they are in one of the allowed networks. Redirects are not followed;
+// they count as failed deliveries.
+type SignedWebhookSender struct {
+	client  *http.Client
+	allowed []netip.Prefix
+	now     func() time.Time
+}
+
+// NewSignedWebhookSender returns a sender that may also deliver to the
+// allowed networks, for receivers on the server's own network.
+func NewSignedWebhookSender(allowed ...netip.Prefix) *SignedWebhookSender {
+	s := &SignedWebhookSender{allowed: allowed, now: time.Now}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on the sender's behalf, past the address check
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: webhookTimeout, Control: s.controlDial}).DialContext
+	s.client = &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return s
+}
+
+// ParseWebhookNetworks parses a comma-separated list of CIDR prefixes and
+// single IP addresses, as in CALENDARAPP_WEBHOOK_ALLOWED_NETWORKS.
+func ParseWebhookNetworks(value string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(field); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook network %q", field)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// CheckURL refuses URLs whose host is an address that is not public, or
+// localhost. Host names are checked again, resolved, on every delivery.
+func (s *SignedWebhookSender) CheckURL(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		host = "127.0.0.1"
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil
+	}
+	if !s.allows(addr) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, u.Hostname())
+	}
+	return nil
+}
+
+// controlDial checks the resolved address of every connection.
+func (s *SignedWebhookSender) controlDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !s.allows(addr) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, addr)
+	}
+	return nil
+}
+
+func (s *SignedWebhookSender) allows(addr netip.Addr) bool {
+	addr = addr.WithZone("").Unmap()
+	for _, prefix := range s.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return !(addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr))
+}
+
+// SignWebhook returns the X-CalendarApp-Signature value for a body sent at
+// the given Unix time: "sha256=" and the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the secret.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send delivers the payload. Any response other than 2xx is an error.
+func (s *SignedWebhookSender) Send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CalendarApp-Webhooks/1")
+	req.Header.Set(HeaderWebhookEvent, string(delivery.EventType))
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(subscription.Secret, timestamp, delivery.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestSignedWebhookSenderSignsPayload(t *testing.T) {
+	subscription := &domain.WebhookSubscription{ID: 2, Secret: "whsec_test"}
+	delivery := &domain.WebhookDelivery{ID: 17, EventType: domain.NotificationEventUpdated, Payload: []byte(`{"type":"event.updated"}`)}
+
+	status := http.StatusAccepted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != string(delivery.Payload) {
+			t.Errorf("body = %s", body)
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderWebhookTimestamp), 10, 64)
+		if err != nil || timestamp != 1767225600 {
+			t.Errorf("timestamp header = %q", r.Header.Get(HeaderWebhookTimestamp))
+		}
+		if got, want := r.Header.Get(HeaderWebhookSignature), SignWebhook("whsec_test", timestamp, body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if r.Header.Get(HeaderWebhookEvent) != "event.updated" || r.Header.Get(HeaderWebhookDelivery) != "17" {
+			t.Errorf("event headers = %q, %q", r.Header.Get(HeaderWebhookEvent), r.Header.Get(HeaderWebhookDelivery))
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	subscription.URL = srv.URL
+
+	sender := NewSignedWebhookSender(netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128"))
+	sender.now = func() time.Time { return time.Unix(1767225600, 0) }
+	if code, err := sender.Send(context.Background(), subscription, delivery); err != nil || code != http.StatusAccepted {
+		t.Fatalf("Send = %d, %v; want 202", code, err)
+	}
+
+	status = http.StatusGone
+	if code, err := sender.Send(context.Background(), subscription, delivery); err == nil || code != http.StatusGone {
+		t.Errorf("Send = %d, %v; want 410 and an error", code, err)
+	}
+}
+
+func TestSignedWebhookSenderRefusesInternalAddresses(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	delivery := &domain.WebhookDelivery{ID: 1, EventType: domain.NotificationEventCreated, Payload: []byte(`{}`)}
+
+	// A host name that resolves to loopback is refused when dialing
+	_, port, _ := strings.Cut(srv.Listener.Addr().String(), "127.0.0.1:")
+	sender := NewSignedWebhookSender()
+	if code, err := sender.Send(context.Background(), &domain.WebhookSubscription{URL: "http://localhost:" + port}, delivery); !errors.Is(err, ErrWebhookAddressNotAllowed) || code != 0 {
+		t.Fatalf("Send to localhost = %d, %v; want ErrWebhookAddressNotAllowed", code, err)
+	}
+	if hits != 0 {
+		t.Fatalf("receiver was hit %d times", hits)
+	}
+
+	// Allowed networks are reached, but redirects are not followed
+	allowed, err := ParseWebhookNetworks(" 127.0.0.0/8, ::1")
+	if err != nil || len(allowed) != 2 {
+		t.Fatalf("ParseWebhookNetworks = %v, %v", allowed, err)
+	}
+	sender = NewSignedWebhookSender(allowed...)
+	if code, err := sender.Send(context.Background(), &domain.WebhookSubscription{URL: srv.URL + "/redirect"}, delivery); err == nil || code != http.StatusFound || hits != 1 {
+		t.Fatalf("Send to a redirect = %d, %v after %d requests; want 302 and an error", code, err, hits)
+	}
+	if _, err := ParseWebhookNetworks("10.0.0.0/33"); err == nil {
+		t.Error("ParseWebhookNetworks accepted an invalid prefix")
+	}
+
+	for endpoint, want := range map[string]bool{
+		"https://example.com/hook":  true,
+		"https://93.184.216.34/":    true,
+		"http://127.0.0.1:8080/":    false,
+		"http://LOCALHOST./":        false,
+		"http://api.localhost/":     false,
+		"http://10.1.2.3/":          false,
+		"http://192.168.0.10/":      false,
+		"http://169.254.169.254/":   false,
+		"http://100.64.0.1/":        false,
+		"http://[::1]/":             false,
+		"http://[fe80::1]/":         false,
+		"http://[fd00::1]/":         false,
+		"http://[::ffff:10.0.0.1]/": false,
+		"http://0.0.0.0/":           false,
+	} {
+		u, _ := url.Parse(endpoint)
+		if err := NewSignedWebhookSender().CheckURL(u); (err == nil) != want {
+			t.Errorf("CheckURL(%s) = %v, want allowed %v", endpoint, err, want)
+		}
+	}
+	u, _ := url.Parse("http://10.1.2.3/")
+	if err := NewSignedWebhookSender(netip.MustParsePrefix("10.0.0.0/8")).CheckURL(u); err != nil {
+		t.Errorf("CheckURL with the network allowed = %v", err)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{}" keyed with "secret"
+	const want = "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := SignWebhook("secret", 1700000000, []byte("{}")); got != want {
+		t.Errorf("SignWebhook = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+// Package notify delivers notifications outside the server: fired reminders
+// as a webhook POST or as email, and signed deliveries of users' webhook
+// subscriptions.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// webhookTimeout bounds a webhook request, so a slow receiver cannot hold up
+// the reminder scheduler.
+const webhookTimeout = 10 * time.Second
+
+// Webhook POSTs every fired reminder as JSON to a fixed URL. It implements
+// domain.ReminderChannel.
+type Webhook struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewWebhook returns a Webhook posting to endpoint, which must be an http or
+// https URL.
+func NewWebhook(endpoint string) (*Webhook, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", endpoint)
+	}
+	return &Webhook{endpoint: endpoint, client: &http.Client{Timeout: webhookTimeout}}, nil
+}
+
+type reminderPayload struct {
+	Type     string       `json:"type"`
+	Reminder reminderJSON `json:"reminder"`
+}
+
+type reminderJSON struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	CalendarID    int64     `json:"calendar_id"`
+	UID           string    `json:"uid"`
+	AlarmKey      string    `json:"alarm_key"`
+	Action        string    `json:"action"`
+	Summary       string    `json:"summary"`
+	Description   string    `json:"description,omitempty"`
+	Occurrence    time.Time `json:"occurrence"`
+	InstanceStart time.Time `json:"instance_start"`
+	TriggerAt     time.Time `json:"trigger_at"`
+	FiredAt       time.Time `json:"fired_at"`
+}
+
+// Notify sends the reminder. Any response other than 2xx is an error.
+func (w *Webhook) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	body, err := json.Marshal(reminderPayload{Type: "reminder", Reminder: reminderJSON{
+		ID:            reminder.ID,
+		UserID:        reminder.UserID,
+		CalendarID:    reminder.CalendarID,
+		UID:           reminder.UID,
+		AlarmKey:      reminder.AlarmKey,
+		Action:        reminder.Action,
+		Summary:       reminder.Summary,
+		Description:   reminder.Description,
+		Occurrence:    reminder.Occurrence.UTC(),
+		InstanceStart: reminder.InstanceStart.UTC(),
+		TriggerAt:     reminder.TriggerAt.UTC(),
+		FiredAt:       reminder.FiredAt.UTC(),
+	}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
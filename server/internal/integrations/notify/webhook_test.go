@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func testReminder(action string) *domain.Reminder {
+	start := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	return &domain.Reminder{ID: 1, UserID: 42, CalendarID: 3, UID: "review", AlarmKey: "remind", Action: action,
+		Summary: "Weekly review", Description: "Bring notes", Occurrence: start, InstanceStart: start,
+		TriggerAt: start.Add(-15 * time.Minute), FiredAt: start.Add(-14 * time.Minute)}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var got reminderPayload
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	webhook, err := NewWebhook(srv.URL + "/hooks/reminders")
+	if err != nil {
+		t.Fatalf("NewWebhook failed: %v", err)
+	}
+	if err := webhook.Notify(context.Background(), testReminder("DISPLAY")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got.Type != "reminder" || got.Reminder.UID != "review" || got.Reminder.UserID != 42 || !got.Reminder.TriggerAt.Equal(testReminder("").TriggerAt) {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+
+	status = http.StatusInternalServerError
+	if err := webhook.Notify(context.Background(), testReminder("DISPLAY")); err == nil {
+		t.Error("expected an error for a failed delivery")
+	}
+}
+
+func TestNewWebhookRejectsInvalidURLs(t *testing.T) {
+	for _, endpoint := range []string{"", "ftp://example.com/hook", "/relative", "http://"} {
+		if _, err := NewWebhook(endpoint); err == nil {
+			t.Errorf("NewWebhook(%q) should fail", endpoint)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	ErrProposalStale         = errors.New("an event the proposal changes was changed since it was made")
+	ErrProposalExpired       = errors.New("proposal expired")
+	ErrProposalHasViolations = errors.New("proposal has violations")
+)
+
+// EventWriter writes events the way CalDAV requests do, with sync-token
+// bumps and change notifications, and runs inTx in the same transaction.
+// *caldav.Backend implements it.
+type EventWriter interface {
+	WriteEvents(ctx context.Context, writes []domain.EventWrite, inTx func(tx *sql.Tx, written []*domain.Event) error) error
+}
+
+// ApplyService writes the changes of valid proposals to the user's calendars
+// and records them in the audit log. The audit log and proposal repositories
+// must be the SQLite ones so they can join the transaction of the writes.
+type ApplyService struct {
+	proposals    *ProposalService
+	proposalRepo *data.SQLitePlanProposalRepo
+	audit        *data.SQLiteAuditLogRepo
+	writer       EventWriter
+}
+
+func NewApplyService(proposals *ProposalService, proposalRepo *data.SQLitePlanProposalRepo, audit *data.SQLiteAuditLogRepo, writer EventWriter) *ApplyService {
+	return &ApplyService{proposals: proposals, proposalRepo: proposalRepo, audit: audit, writer: writer}
+}
+
+// AppliedPlan is the outcome of applying a proposal.
+type AppliedPlan struct {
+	Proposal *domain.PlanProposal
+	Entry    *domain.AuditLogEntry // Holds the before and after state of every event
+}
+
+// Apply writes the changes of a valid proposal of the user, their sync-token
+// bumps, the audit log entry and the proposal's new status in one
+// transaction. When an event the proposal touches no longer has the ETag it
+// had when the proposal was made, or the proposal now breaks a constraint,
+// nothing is written: the proposal goes stale, with the cause in its
+// StatusReason, and Apply returns ErrProposalStale.
+func (s *ApplyService) Apply(ctx context.Context, user *domain.User, id int64) (*AppliedPlan, error) {
+	proposal, err := s.proposals.Get(ctx, user.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch proposal.Status {
+	case domain.ProposalValid:
+	case domain.ProposalDraft:
+		return nil, ErrProposalHasViolations
+	case domain.ProposalExpired:
+		return nil, ErrProposalExpired
+	case domain.ProposalStale:
+		return nil, ErrProposalStale
+	default:
+		return nil, ErrProposalNotPending
+	}
+
+	current, err := s.revalidate(ctx, user, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.proposals.now().UTC()
+	entry := &domain.AuditLogEntry{
+		UserID:     user.ID,
+		Action:     domain.AuditPlanApply,
+		EntityType: domain.AuditEntityPlanProposal,
+		EntityID:   strconv.FormatInt(proposal.ID, 10),
+		Summary:    proposal.Summary,
+	}
+	if entry.Summary == "" {
+		entry.Summary = fmt.Sprintf("Applied %d planned changes", len(proposal.Changes))
+	}
+	writes := make([]domain.EventWrite, 0, len(proposal.Changes))
+	for i, change := range proposal.Changes {
+		write := domain.EventWrite{CalendarID: change.CalendarID, UID: change.UID, ExpectedETag: change.ETag}
+		auditChange := domain.AuditChange{CalendarID: change.CalendarID, UID: change.UID, BeforeETag: change.ETag}
+		if change.Type == domain.ChangeCreateFocusBlock {
+			write.ICS, err = focusBlockICS(change, now)
+		} else {
+			write.ICS, err = editedEventICS(user, current[i], change, now)
+			auditChange.BeforeICS = current[i].ICS
+		}
+		if err != nil {
+			return nil, err
+		}
+		auditChange.Summary = changeSummary(change, current[i])
+		writes = append(writes, write)
+		entry.Changes = append(entry.Changes, auditChange)
+	}
+
+	err = s.writer.WriteEvents(ctx, writes, func(tx *sql.Tx, written []*domain.Event) error {
+		for i, event := range written {
+			entry.Changes[i].AfterICS = event.ICS
+			entry.Changes[i].AfterETag = event.ETag
+		}
+		if err := s.audit.WithTx(tx).Create(ctx, entry); err != nil {
+			return err
+		}
+		proposal.Status = domain.ProposalApplied
+		proposal.StatusReason = ""
+		if err := s.proposalRepo.WithTx(tx).Update(ctx, proposal, domain.ProposalValid); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				return ErrProposalNotPending
+			}
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, domain.ErrPreconditionFailed) {
+		// An event changed between the checks above and the write
+		proposal.Status = domain.ProposalValid
+		return nil, s.markStale(ctx, proposal, domain.ProposalReasonETagMismatch)
+	}
+	if err != nil {
+		proposal.Status = domain.ProposalValid
+		return nil, err
+	}
+
+	slog.Info("planner.proposal.applied", "user_id", user.ID, "proposal_id", proposal.ID, "audit_log_entry_id", entry.ID, "changes", len(entry.Changes))
+	return &AppliedPlan{Proposal: proposal, Entry: entry}, nil
+}
+
+// revalidate checks the ETags of the events the proposal touches and then,
+// since other events may have moved into its way, the constraints against
+// the calendars as they are now. It marks the proposal stale when either
+// fails, and otherwise returns the events the changes touch, nil for new
+// focus blocks.
+func (s *ApplyService) revalidate(ctx context.Context, user *domain.User, proposal *domain.PlanProposal) ([]*domain.Event, error) {
+	current := make([]*domain.Event, len(proposal.Changes))
+	for i, change := range proposal.Changes {
+		event, err := s.proposals.events.GetByUID(ctx, change.CalendarID, change.UID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		if reason := staleReason(change, event); reason != "" {
+			return nil, s.markStale(ctx, proposal, reason)
+		}
+		current[i] = event
+	}
+	changes := append([]domain.ProposalChange(nil), proposal.Changes...)
+	violations, err := s.proposals.check(ctx, user, changes)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		proposal.Violations = violations
+		return nil, s.markStale(ctx, proposal, domain.ProposalReasonConstraints)
+	}
+	return current, nil
+}
+
+// markStale records why a valid proposal went stale and returns
+// ErrProposalStale.
+func (s *ApplyService) markStale(ctx context.Context, proposal *domain.PlanProposal, reason string) error {
+	if err := s.proposals.transition(ctx, proposal, domain.ProposalStale, reason); err != nil {
+		return err
+	}
+	slog.Info("planner.proposal.stale", "user_id", proposal.UserID, "proposal_id", proposal.ID, "reason", reason)
+	return ErrProposalStale
+}
+
+// staleReason returns why change can no longer be made to current, the event
+// it touches as it is now (nil if there is none), or "" if it still can.
+func staleReason(change domain.ProposalChange, current *domain.Event) string {
+	switch {
+	case change.Type == domain.ChangeCreateFocusBlock && current != nil:
+		return domain.ProposalReasonEventExists
+	case change.Type == domain.ChangeCreateFocusBlock:
+		return ""
+	case current == nil:
+		return domain.ProposalReasonEventDeleted
+	case current.ETag != change.ETag:
+		return domain.ProposalReasonETagMismatch
+	}
+	return ""
+}
+
+// changeSummary describes a change for the audit log. event is the event it
+// touches, nil for new focus blocks.
+func changeSummary(change domain.ProposalChange, event *domain.Event) string {
+	const layout = "2006-01-02 15:04 MST"
+	switch change.Type {
+	case domain.ChangeCreateFocusBlock:
+		return fmt.Sprintf("Created %q from %s to %s", change.Summary, change.Start.Format(layout), change.End.Format(layout))
+	case domain.ChangeMoveEvent:
+		return fmt.Sprintf("Moved %q to %s - %s", event.Summary, change.Start.Format(layout), change.End.Format(layout))
+	case domain.ChangeShortenEvent:
+		return fmt.Sprintf("Shortened %q to end at %s", event.Summary, change.End.Format(layout))
+	case domain.ChangeDeclineEvent:
+		return fmt.Sprintf("Declined %q", event.Summary)
+	}
+	return string(change.Type)
+}
@@ -0,0 +1,186 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/caldav"
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func newApplyService(f *plannerFixture) (*ProposalService, *ApplyService) {
+	proposals := NewProposalService(f.proposals, f.calendars, f.events, f.preferences)
+	backend := caldav.NewBackend(f.db, data.NewSQLiteUserRepo(f.db), f.calendars, f.events)
+	return proposals, NewApplyService(proposals, f.proposals, data.NewSQLiteAuditLogRepo(f.db), backend)
+}
+
+func TestApplyService_Apply(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	proposals, apply := newApplyService(f)
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	standup := f.putEvent(t, "standup", start, start.Add(time.Hour))
+	f.putEvent(t, "review", start.Add(4*time.Hour), start.Add(5*time.Hour),
+		"ORGANIZER:mailto:bob@example.com", "ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:alice@example.com")
+	before, err := f.calendars.GetByID(ctx, f.calendar.ID)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+	stored, err := f.calendars.ListChangesSince(ctx, f.calendar.ID, 0)
+	if err != nil {
+		t.Fatalf("list changes: %v", err)
+	}
+
+	proposal, err := proposals.Create(ctx, f.user, "Protect the afternoon", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(6 * time.Hour), End: start.Add(8 * time.Hour)},
+		{Type: domain.ChangeShortenEvent, CalendarID: f.calendar.ID, UID: "standup", End: start.Add(30 * time.Minute)},
+		{Type: domain.ChangeDeclineEvent, CalendarID: f.calendar.ID, UID: "review"},
+	})
+	if err != nil || proposal.Status != domain.ProposalValid {
+		t.Fatalf("Create = %+v, %v", proposal, err)
+	}
+
+	applied, err := apply.Apply(ctx, f.user, proposal.ID)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if applied.Proposal.Status != domain.ProposalApplied || applied.Entry.ID == 0 || len(applied.Entry.Changes) != 3 {
+		t.Fatalf("Apply = %+v", applied)
+	}
+
+	focus, err := f.events.GetByUID(ctx, f.calendar.ID, proposal.Changes[0].UID)
+	if err != nil {
+		t.Fatalf("focus block not created: %v", err)
+	}
+	if !strings.Contains(focus.ICS, PropFocus+":TRUE") || !focus.StartTime.Equal(start.Add(6*time.Hour)) {
+		t.Errorf("focus block = %s", focus.ICS)
+	}
+	shortened, err := f.events.GetByUID(ctx, f.calendar.ID, "standup")
+	if err != nil {
+		t.Fatalf("get standup: %v", err)
+	}
+	if !shortened.EndTime.Equal(start.Add(30*time.Minute)) || shortened.ETag == standup.ETag || !strings.Contains(shortened.ICS, "SEQUENCE:1") {
+		t.Errorf("standup = %+v", shortened)
+	}
+	declined, err := f.events.GetByUID(ctx, f.calendar.ID, "review")
+	if err != nil {
+		t.Fatalf("get review: %v", err)
+	}
+	if !strings.Contains(declined.ICS, "PARTSTAT=DECLINED") {
+		t.Errorf("review = %s", declined.ICS)
+	}
+
+	// The audit log holds the state before and after each change
+	entry, err := data.NewSQLiteAuditLogRepo(f.db).GetByID(ctx, applied.Entry.ID)
+	if err != nil {
+		t.Fatalf("get audit log entry: %v", err)
+	}
+	if entry.Action != domain.AuditPlanApply || entry.EntityID != strconv.FormatInt(proposal.ID, 10) || entry.Summary != "Protect the afternoon" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if c := entry.Changes[0]; c.BeforeICS != "" || c.AfterICS != focus.ICS || c.AfterETag != focus.ETag {
+		t.Errorf("focus change = %+v", c)
+	}
+	if c := entry.Changes[1]; c.BeforeICS != standup.ICS || c.BeforeETag != standup.ETag || c.AfterETag != shortened.ETag {
+		t.Errorf("standup change = %+v", c)
+	}
+
+	// Every change bumped the sync token
+	after, err := f.calendars.GetByID(ctx, f.calendar.ID)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+	changes, err := f.calendars.ListChangesSince(ctx, f.calendar.ID, 0)
+	if err != nil || after.SyncToken == before.SyncToken || len(changes) != len(stored)+3 {
+		t.Fatalf("changes since apply = %d (%v), sync token %s -> %s", len(changes), err, before.SyncToken, after.SyncToken)
+	}
+
+	if _, err := apply.Apply(ctx, f.user, proposal.ID); !errors.Is(err, ErrProposalNotPending) {
+		t.Fatalf("second Apply error = %v, want ErrProposalNotPending", err)
+	}
+}
+
+func TestApplyService_ApplyStale(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	proposals, apply := newApplyService(f)
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	f.putEvent(t, "standup", start, start.Add(time.Hour))
+	proposal, err := proposals.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(2 * time.Hour), End: start.Add(4 * time.Hour)},
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "standup", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A CalDAV client edits the standup after the proposal was made
+	standup, err := f.events.GetByUID(ctx, f.calendar.ID, "standup")
+	if err != nil {
+		t.Fatalf("get standup: %v", err)
+	}
+	oldETag := standup.ETag
+	standup.ICS = strings.Replace(standup.ICS, "SUMMARY:standup", "SUMMARY:Daily standup", 1)
+	standup.ETag = domain.GenerateETag([]byte(standup.ICS))
+	if err := f.events.Update(ctx, standup, oldETag); err != nil {
+		t.Fatalf("update standup: %v", err)
+	}
+
+	if _, err := apply.Apply(ctx, f.user, proposal.ID); !errors.Is(err, ErrProposalStale) {
+		t.Fatalf("Apply error = %v, want ErrProposalStale", err)
+	}
+	stored, err := proposals.Get(ctx, f.user.ID, proposal.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Status != domain.ProposalStale || stored.StatusReason != domain.ProposalReasonETagMismatch {
+		t.Fatalf("proposal = %s (%s), want stale etag_mismatch", stored.Status, stored.StatusReason)
+	}
+	if _, err := f.events.GetByUID(ctx, f.calendar.ID, proposal.Changes[0].UID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("focus block of a stale proposal was written: %v", err)
+	}
+	if entries, err := data.NewSQLiteAuditLogRepo(f.db).ListByUser(ctx, f.user.ID, 10); err != nil || len(entries) != 0 {
+		t.Fatalf("audit log = %d entries (%v), want none", len(entries), err)
+	}
+	if _, err := apply.Apply(ctx, f.user, proposal.ID); !errors.Is(err, ErrProposalStale) {
+		t.Fatalf("second Apply error = %v, want ErrProposalStale", err)
+	}
+}
+
+func TestApplyService_ApplyRevalidatesConstraints(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	proposals, apply := newApplyService(f)
+
+	start := time.Date(2026, 11, 2, 13, 0, 0, 0, time.UTC)
+	proposal, err := proposals.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start, End: start.Add(2 * time.Hour)},
+	})
+	if err != nil || proposal.Status != domain.ProposalValid {
+		t.Fatalf("Create = %+v, %v", proposal, err)
+	}
+
+	// A meeting lands in the block before it is applied
+	f.putEvent(t, "interview", start.Add(time.Hour), start.Add(90*time.Minute))
+	if _, err := apply.Apply(ctx, f.user, proposal.ID); !errors.Is(err, ErrProposalStale) {
+		t.Fatalf("Apply error = %v, want ErrProposalStale", err)
+	}
+	stored, err := proposals.Get(ctx, f.user.ID, proposal.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Status != domain.ProposalStale || stored.StatusReason != domain.ProposalReasonConstraints ||
+		len(stored.Violations) != 1 || stored.Violations[0].Code != ViolationTimeConflict {
+		t.Fatalf("proposal = %s (%s) %+v, want stale with a time conflict", stored.Status, stored.StatusReason, stored.Violations)
+	}
+	if _, err := f.events.GetByUID(ctx, f.calendar.ID, proposal.Changes[0].UID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("focus block of a stale proposal was written: %v", err)
+	}
+}
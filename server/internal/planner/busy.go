@@ -0,0 +1,156 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/recurrence"
+)
+
+// Block is a span of time an event instance keeps busy.
+type Block struct {
+	Change     int // Index of the proposed change that puts the event here; -1 for events left as they are
+	CalendarID int64
+	UID        string
+	Summary    string
+	Location   string
+	Start      time.Time
+	End        time.Time
+	AllDay     bool
+	Focus      bool // A focus block the planner created
+}
+
+// Meeting reports whether the block counts as a meeting: a timed event that
+// is not a focus block.
+func (b Block) Meeting() bool {
+	return !b.AllDay && !b.Focus
+}
+
+// Travels reports whether the block needs travel time: it has a LOCATION
+// that is not a link to an online meeting.
+func (b Block) Travels() bool {
+	return !b.AllDay && b.Location != "" && !strings.Contains(b.Location, "://")
+}
+
+// overlaps reports whether the blocks share time.
+func (b Block) overlaps(other Block) bool {
+	return b.Start.Before(other.End) && other.Start.Before(b.End)
+}
+
+// gap returns the free time between two blocks that do not overlap.
+func (b Block) gap(other Block) time.Duration {
+	if other.Start.Before(b.Start) {
+		return b.Start.Sub(other.End)
+	}
+	return other.Start.Sub(b.End)
+}
+
+// loadBlocks returns the busy blocks of the user's event calendars that
+// overlap [start, end), sorted by start. Recurring events are expanded, with
+// floating and all-day times in loc; transparent and cancelled instances are
+// left out.
+func loadBlocks(ctx context.Context, calendars domain.CalendarRepo, events domain.EventRepo, userID int64, start, end time.Time, loc *time.Location) ([]Block, error) {
+	cals, err := calendars.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	var blocks []Block
+	for _, cal := range cals {
+		if !cal.SupportsComponent(domain.ComponentEvent) {
+			continue
+		}
+		listed, err := events.List(ctx, cal.ID, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+		recurring, err := events.ListRecurring(ctx, cal.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list recurring events: %w", err)
+		}
+		seen := make(map[int64]bool, len(listed))
+		for _, event := range listed {
+			seen[event.ID] = true
+		}
+		for _, event := range recurring {
+			if !seen[event.ID] {
+				listed = append(listed, event)
+			}
+		}
+
+		for _, event := range listed {
+			icalCal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+			if err != nil {
+				slog.Warn("planner.corrupt_ics", "calendar_id", cal.ID, "event_id", event.ID, "error", err)
+				continue
+			}
+			instances, err := recurrence.Expand(icalCal, ical.CompEvent, start, end, loc)
+			if err != nil {
+				slog.Warn("planner.expand_failed", "calendar_id", cal.ID, "event_id", event.ID, "error", err)
+				continue
+			}
+			for _, inst := range instances {
+				block, busy := componentBlock(inst.Component)
+				if !busy {
+					continue
+				}
+				block.CalendarID = cal.ID
+				block.UID = event.UID
+				block.Start, block.End, block.AllDay = inst.Start, inst.End, inst.AllDay
+				blocks = append(blocks, block)
+			}
+		}
+	}
+	sortBlocks(blocks)
+	return blocks, nil
+}
+
+// componentBlock returns the block of a VEVENT without its times, reporting
+// false when the event does not keep its time busy.
+func componentBlock(comp *ical.Component) (Block, bool) {
+	block := Block{Change: -1}
+	if prop := comp.Props.Get(ical.PropTransparency); prop != nil && strings.EqualFold(prop.Value, "TRANSPARENT") {
+		return block, false
+	}
+	if prop := comp.Props.Get(ical.PropStatus); prop != nil && strings.EqualFold(prop.Value, "CANCELLED") {
+		return block, false
+	}
+	if prop := comp.Props.Get(ical.PropSummary); prop != nil {
+		block.Summary = prop.Value
+	}
+	if prop := comp.Props.Get(ical.PropLocation); prop != nil {
+		block.Location = strings.TrimSpace(prop.Value)
+	}
+	if prop := comp.Props.Get(PropFocus); prop != nil && strings.EqualFold(prop.Value, "TRUE") {
+		block.Focus = true
+	}
+	return block, true
+}
+
+func sortBlocks(blocks []Block) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if !blocks[i].Start.Equal(blocks[j].Start) {
+			return blocks[i].Start.Before(blocks[j].Start)
+		}
+		return blocks[i].UID < blocks[j].UID
+	})
+}
+
+// startOfDay returns local midnight of the day t falls on in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// atClock returns the wall clock time offset from the start of day, so the
+// hours of days with a DST change stay as configured.
+func atClock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
@@ -0,0 +1,267 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// Violation codes of the planning constraints. They are stable; clients map
+// them to their own copy.
+const (
+	ViolationOutsideWorkingHours = "outside_working_hours" // The event does not fit the working hours of its day
+	ViolationTimeConflict        = "time_conflict"         // The event overlaps another busy event
+	ViolationInsufficientBuffer  = "insufficient_buffer"   // The meeting leaves less than the minimum buffer to another
+	ViolationMeetingLoad         = "meeting_load_exceeded" // The meetings of the day add up to more than the maximum
+	ViolationNoMeetingDay        = "no_meeting_day"        // The meeting falls on a protected no-meeting day
+	ViolationTravelTime          = "travel_time"           // The event leaves no time to travel to or from an event with a LOCATION
+)
+
+// Plan is the user's calendars around a proposal, with its changes made, as
+// the constraints see them.
+type Plan struct {
+	Preferences Preferences
+	Blocks      []Block // Busy blocks sorted by start; those the proposal places have Change >= 0
+}
+
+// Constraint is a deterministic planning rule. Check returns a violation,
+// naming the change in Change, for every block the proposal places that
+// breaks the rule.
+type Constraint interface {
+	Check(plan *Plan) []domain.ProposalViolation
+}
+
+// DefaultConstraints returns the built-in rules every proposal is validated
+// against.
+func DefaultConstraints() []Constraint {
+	return []Constraint{
+		WorkingHoursConstraint{},
+		TimeConflictConstraint{},
+		BufferConstraint{},
+		MeetingLoadConstraint{},
+		NoMeetingDayConstraint{},
+		TravelTimeConstraint{},
+	}
+}
+
+// Validate returns the violations of plan against constraints, in their order.
+func Validate(plan *Plan, constraints []Constraint) []domain.ProposalViolation {
+	var violations []domain.ProposalViolation
+	for _, c := range constraints {
+		violations = append(violations, c.Check(plan)...)
+	}
+	return violations
+}
+
+// placed returns the blocks the proposal places.
+func (p *Plan) placed() []Block {
+	var placed []Block
+	for _, b := range p.Blocks {
+		if b.Change >= 0 {
+			placed = append(placed, b)
+		}
+	}
+	return placed
+}
+
+// others returns the blocks other than b.
+func (p *Plan) others(b Block) []Block {
+	var others []Block
+	for _, o := range p.Blocks {
+		if o.Change != b.Change || o.CalendarID != b.CalendarID || o.UID != b.UID || !o.Start.Equal(b.Start) {
+			others = append(others, o)
+		}
+	}
+	return others
+}
+
+// WorkingHoursConstraint keeps the events a proposal places within the
+// working hours of the day they start on.
+type WorkingHoursConstraint struct{}
+
+func (WorkingHoursConstraint) Check(plan *Plan) []domain.ProposalViolation {
+	var violations []domain.ProposalViolation
+	loc := plan.Preferences.Location
+	for _, b := range plan.placed() {
+		day := startOfDay(b.Start, loc)
+		hours := plan.Preferences.WorkingHours[day.Weekday()]
+		if !hours.Working() {
+			violations = append(violations, violation(b, ViolationOutsideWorkingHours,
+				fmt.Sprintf("%s are not working days.", weekdayPlural(day.Weekday()))))
+			continue
+		}
+		if b.Start.Before(atClock(day, hours.Start)) || b.End.After(atClock(day, hours.End)) {
+			violations = append(violations, violation(b, ViolationOutsideWorkingHours,
+				fmt.Sprintf("Working hours on %s are %s-%s.", weekdayPlural(day.Weekday()), formatClock(hours.Start), formatClock(hours.End))))
+		}
+	}
+	return violations
+}
+
+// TimeConflictConstraint keeps the events a proposal places from overlapping
+// other busy events, all-day ones included.
+type TimeConflictConstraint struct{}
+
+func (TimeConflictConstraint) Check(plan *Plan) []domain.ProposalViolation {
+	var violations []domain.ProposalViolation
+	for _, b := range plan.placed() {
+		for _, o := range plan.others(b) {
+			if b.overlaps(o) {
+				violations = append(violations, violation(b, ViolationTimeConflict, fmt.Sprintf("Overlaps %s.", describe(o))))
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// BufferConstraint keeps the minimum buffer between a meeting a proposal
+// places and the other meetings.
+type BufferConstraint struct{}
+
+func (BufferConstraint) Check(plan *Plan) []domain.ProposalViolation {
+	buffer := plan.Preferences.MinBuffer
+	if buffer <= 0 {
+		return nil
+	}
+	var violations []domain.ProposalViolation
+	for _, b := range plan.placed() {
+		if !b.Meeting() {
+			continue
+		}
+		for _, o := range plan.others(b) {
+			if o.Meeting() && !b.overlaps(o) && b.gap(o) < buffer {
+				violations = append(violations, violation(b, ViolationInsufficientBuffer,
+					fmt.Sprintf("Leaves less than %s between it and %s.", formatDuration(buffer), describe(o))))
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// MeetingLoadConstraint keeps the meetings of a day a proposal places a
+// meeting on within the maximum meeting time per day.
+type MeetingLoadConstraint struct{}
+
+func (MeetingLoadConstraint) Check(plan *Plan) []domain.ProposalViolation {
+	limit := plan.Preferences.MaxMeetingLoad
+	if limit <= 0 {
+		return nil
+	}
+	loc := plan.Preferences.Location
+	var violations []domain.ProposalViolation
+	for _, b := range plan.placed() {
+		if !b.Meeting() {
+			continue
+		}
+		day := startOfDay(b.Start, loc)
+		next := day.AddDate(0, 0, 1)
+		var load time.Duration
+		for _, o := range plan.Blocks {
+			if !o.Meeting() {
+				continue
+			}
+			start, end := o.Start, o.End
+			if start.Before(day) {
+				start = day
+			}
+			if end.After(next) {
+				end = next
+			}
+			if end.After(start) {
+				load += end.Sub(start)
+			}
+		}
+		if load > limit {
+			violations = append(violations, violation(b, ViolationMeetingLoad,
+				fmt.Sprintf("Brings meetings on %s to %s, over the limit of %s.", day.Format("Monday, January 2"), formatDuration(load), formatDuration(limit))))
+		}
+	}
+	return violations
+}
+
+// NoMeetingDayConstraint keeps the meetings a proposal places off protected
+// no-meeting days. Focus blocks may go there.
+type NoMeetingDayConstraint struct{}
+
+func (NoMeetingDayConstraint) Check(plan *Plan) []domain.ProposalViolation {
+	loc := plan.Preferences.Location
+	var violations []domain.ProposalViolation
+	for _, b := range plan.placed() {
+		if !b.Meeting() {
+			continue
+		}
+		for day := startOfDay(b.Start, loc); day.Before(b.End); day = day.AddDate(0, 0, 1) {
+			if plan.Preferences.NoMeetingDays[day.Weekday()] {
+				violations = append(violations, violation(b, ViolationNoMeetingDay,
+					fmt.Sprintf("%s are kept free of meetings.", weekdayPlural(day.Weekday()))))
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// TravelTimeConstraint keeps travel time free before and after events with a
+// physical LOCATION: an event a proposal places must not fall into the travel
+// time of another, nor leave too little travel time of its own.
+type TravelTimeConstraint struct{}
+
+func (TravelTimeConstraint) Check(plan *Plan) []domain.ProposalViolation {
+	travel := plan.Preferences.TravelTime
+	if travel <= 0 {
+		return nil
+	}
+	var violations []domain.ProposalViolation
+	for _, b := range plan.placed() {
+		if b.AllDay {
+			continue
+		}
+		for _, o := range plan.others(b) {
+			if o.AllDay || b.overlaps(o) || (!b.Travels() && !o.Travels()) || b.gap(o) >= travel {
+				continue
+			}
+			where := o
+			if !o.Travels() {
+				where = b
+			}
+			violations = append(violations, violation(b, ViolationTravelTime,
+				fmt.Sprintf("Leaves less than %s to travel between it and %s at %s.", formatDuration(travel), describe(o), where.Location)))
+			break
+		}
+	}
+	return violations
+}
+
+func violation(b Block, code, message string) domain.ProposalViolation {
+	return domain.ProposalViolation{Code: code, Change: b.Change, Message: message}
+}
+
+// describe names a block in violation messages.
+func describe(b Block) string {
+	if b.Summary == "" {
+		return "another event"
+	}
+	return fmt.Sprintf("%q", b.Summary)
+}
+
+func weekdayPlural(day time.Weekday) string {
+	return day.String() + "s"
+}
+
+// formatDuration formats whole minutes as 1h30m, 2h or 45m.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	var b strings.Builder
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dh", hours)
+	}
+	if minutes > 0 || hours == 0 {
+		fmt.Fprintf(&b, "%dm", minutes)
+	}
+	return b.String()
+}
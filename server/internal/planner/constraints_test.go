@@ -0,0 +1,103 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// monday is 2026-11-02 in UTC.
+func monday(hour, minute int) time.Time {
+	return time.Date(2026, 11, 2, hour, minute, 0, 0, time.UTC)
+}
+
+func codes(violations []domain.ProposalViolation) []string {
+	var codes []string
+	for _, v := range violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestConstraints(t *testing.T) {
+	standup := Block{Change: -1, UID: "standup", Summary: "Standup", Start: monday(9, 0), End: monday(9, 30)}
+	offsite := Block{Change: -1, UID: "offsite", Summary: "Offsite", Location: "Main St 1", Start: monday(14, 0), End: monday(15, 0)}
+	call := Block{Change: -1, UID: "call", Summary: "Call", Location: "https://meet.example.com/x", Start: monday(16, 0), End: monday(16, 30)}
+	holiday := Block{Change: -1, UID: "holiday", Summary: "Holiday", Start: time.Date(2026, 11, 4, 0, 0, 0, 0, time.UTC),
+		End: time.Date(2026, 11, 5, 0, 0, 0, 0, time.UTC), AllDay: true}
+
+	configured := DefaultPreferences()
+	configured.MinBuffer = 15 * time.Minute
+	configured.MaxMeetingLoad = 3 * time.Hour
+	configured.TravelTime = 30 * time.Minute
+	configured.NoMeetingDays[time.Tuesday] = true
+
+	tests := []struct {
+		name   string
+		prefs  Preferences
+		placed Block
+		want   []string
+	}{
+		{"focus block inside working hours", DefaultPreferences(),
+			Block{Focus: true, Start: monday(10, 0), End: monday(12, 0)}, nil},
+		{"focus block past the end of the day", DefaultPreferences(),
+			Block{Focus: true, Start: monday(16, 0), End: monday(18, 0)}, []string{ViolationOutsideWorkingHours, ViolationTimeConflict}},
+		{"focus block on a Saturday", DefaultPreferences(),
+			Block{Focus: true, Start: monday(10, 0).AddDate(0, 0, 5), End: monday(12, 0).AddDate(0, 0, 5)}, []string{ViolationOutsideWorkingHours}},
+		{"focus block on an opaque all-day event", DefaultPreferences(),
+			Block{Focus: true, Start: monday(10, 0).AddDate(0, 0, 2), End: monday(12, 0).AddDate(0, 0, 2)}, []string{ViolationTimeConflict}},
+		{"meeting right after another without buffers", DefaultPreferences(),
+			Block{UID: "sync", Start: monday(9, 30), End: monday(10, 0)}, nil},
+		{"meeting right after another with buffers", configured,
+			Block{UID: "sync", Start: monday(9, 30), End: monday(10, 0)}, []string{ViolationInsufficientBuffer}},
+		{"focus block right after a meeting with buffers", configured,
+			Block{Focus: true, Start: monday(9, 30), End: monday(11, 0)}, nil},
+		{"meeting over the daily load", configured,
+			Block{UID: "planning", Start: monday(10, 0), End: monday(11, 15)}, []string{ViolationMeetingLoad}},
+		{"meeting on a no-meeting day", configured,
+			Block{UID: "planning", Start: monday(10, 0).AddDate(0, 0, 1), End: monday(11, 0).AddDate(0, 0, 1)}, []string{ViolationNoMeetingDay}},
+		{"focus block on a no-meeting day", configured,
+			Block{Focus: true, Start: monday(10, 0).AddDate(0, 0, 1), End: monday(12, 0).AddDate(0, 0, 1)}, nil},
+		{"focus block within the travel time of an offsite", configured,
+			Block{Focus: true, Start: monday(12, 0), End: monday(13, 45)}, []string{ViolationTravelTime}},
+		{"focus block next to an online meeting", configured,
+			Block{Focus: true, Start: monday(16, 30), End: monday(17, 0)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.placed.Change = 0
+			blocks := []Block{standup, offsite, call, holiday, tt.placed}
+			sortBlocks(blocks)
+			got := codes(Validate(&Plan{Preferences: tt.prefs, Blocks: blocks}, DefaultConstraints()))
+			if len(got) != len(tt.want) {
+				t.Fatalf("violations = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("violations = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkingHoursConstraint_TimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	prefs := DefaultPreferences()
+	prefs.Location = berlin
+
+	// 08:00-10:00 UTC is 09:00-11:00 in Berlin
+	plan := &Plan{Preferences: prefs, Blocks: []Block{{Change: 0, Focus: true, Start: monday(8, 0), End: monday(10, 0)}}}
+	if v := (WorkingHoursConstraint{}).Check(plan); len(v) != 0 {
+		t.Fatalf("violations = %+v, want none", v)
+	}
+	plan.Blocks[0].End = monday(16, 30)
+	v := (WorkingHoursConstraint{}).Check(plan)
+	if len(v) != 1 || v[0].Change != 0 || v[0].Message != "Working hours on Mondays are 09:00-17:00." {
+		t.Fatalf("violations = %+v", v)
+	}
+}
@@ -0,0 +1,184 @@
+package planner
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const (
+	// PropFocus marks the events the planner created to protect focus time.
+	PropFocus = "X-CALENDARAPP-FOCUS"
+
+	plannerProdID = "-//airplne//calendar-app planner//EN"
+)
+
+// focusBlockICS returns the calendar object of a new focus block.
+func focusBlockICS(change domain.ProposalChange, now time.Time) (string, error) {
+	event := ical.NewEvent()
+	event.Props.SetText(ical.PropUID, change.UID)
+	event.Props.SetDateTime(ical.PropDateTimeStamp, now.UTC())
+	event.Props.SetDateTime(ical.PropDateTimeStart, change.Start.UTC())
+	event.Props.SetDateTime(ical.PropDateTimeEnd, change.End.UTC())
+	event.Props.SetText(ical.PropSummary, change.Summary)
+	if change.Reason != "" {
+		event.Props.SetText(ical.PropDescription, change.Reason)
+	}
+	event.Props.SetText(ical.PropTransparency, "OPAQUE")
+	focus := ical.NewProp(PropFocus)
+	focus.Value = "TRUE"
+	event.Props.Set(focus)
+
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, plannerProdID)
+	cal.Children = append(cal.Children, event.Component)
+	return encodeCalendar(cal)
+}
+
+// editedEventICS returns the calendar object of event with change made: new
+// times for move_event and shorten_event, the user's PARTSTAT=DECLINED for
+// decline_event. Times keep the time zone the event uses.
+func editedEventICS(user *domain.User, event *domain.Event, change domain.ProposalChange, now time.Time) (string, error) {
+	cal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse event %s: %w", event.UID, err)
+	}
+	master := masterEvent(cal)
+	if master == nil {
+		return "", fmt.Errorf("event %s has no VEVENT", event.UID)
+	}
+
+	switch change.Type {
+	case domain.ChangeMoveEvent:
+		setEventTime(master, ical.PropDateTimeStart, change.Start)
+		setEventTime(master, ical.PropDateTimeEnd, change.End)
+		master.Props.Del(ical.PropDuration)
+		bumpSequence(master)
+	case domain.ChangeShortenEvent:
+		setEventTime(master, ical.PropDateTimeEnd, change.End)
+		master.Props.Del(ical.PropDuration)
+		bumpSequence(master)
+	case domain.ChangeDeclineEvent:
+		if !declineAttendance(master, domain.MailtoAddress(user.Email)) {
+			return "", fmt.Errorf("%s is not an attendee of event %s", user.Email, event.UID)
+		}
+	default:
+		return "", fmt.Errorf("cannot edit an event with a %s change", change.Type)
+	}
+	master.Props.SetDateTime(ical.PropDateTimeStamp, now.UTC())
+	return encodeCalendar(cal)
+}
+
+// restoredEventICS returns the calendar object that reverts change: its
+// before state, with a SEQUENCE past the one the change left so attendees
+// take the restored times as the newer version. It returns "" when the change
+// created the event, which the rollback then deletes.
+func restoredEventICS(change domain.AuditChange, now time.Time) (string, error) {
+	if change.BeforeICS == "" {
+		return "", nil
+	}
+	cal, err := ical.NewDecoder(strings.NewReader(change.BeforeICS)).Decode()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse event %s: %w", change.UID, err)
+	}
+	master := masterEvent(cal)
+	if master == nil {
+		return "", fmt.Errorf("event %s has no VEVENT", change.UID)
+	}
+	if change.AfterICS != "" {
+		after, err := ical.NewDecoder(strings.NewReader(change.AfterICS)).Decode()
+		if err != nil {
+			return "", fmt.Errorf("failed to parse event %s: %w", change.UID, err)
+		}
+		if afterMaster := masterEvent(after); afterMaster != nil && sequenceOf(afterMaster) > sequenceOf(master) {
+			setSequence(master, sequenceOf(afterMaster)+1)
+		}
+	}
+	master.Props.SetDateTime(ical.PropDateTimeStamp, now.UTC())
+	return encodeCalendar(cal)
+}
+
+// masterEvent returns the VEVENT without RECURRENCE-ID.
+func masterEvent(cal *ical.Calendar) *ical.Component {
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompEvent && comp.Props.Get(ical.PropRecurrenceID) == nil {
+			return comp
+		}
+	}
+	return nil
+}
+
+// setEventTime sets a DTSTART or DTEND of comp to t, in the time zone of the
+// value it replaces, or else of DTSTART, when that is a known zone, and in UTC
+// otherwise. All-day values stay dates: they take the date of t.
+func setEventTime(comp *ical.Component, name string, t time.Time) {
+	loc := time.UTC
+	for _, from := range []string{name, ical.PropDateTimeStart} {
+		prop := comp.Props.Get(from)
+		if prop == nil {
+			continue
+		}
+		if prop.ValueType() == ical.ValueDate {
+			comp.Props.SetDate(name, t)
+			return
+		}
+		if prop.Params.Get(ical.ParamTimezoneID) == "" {
+			continue
+		}
+		if zone, err := time.LoadLocation(prop.Params.Get(ical.ParamTimezoneID)); err == nil {
+			loc = zone
+		}
+		break
+	}
+	comp.Props.SetDateTime(name, t.In(loc))
+}
+
+// bumpSequence increments SEQUENCE, as for any change of an event's times.
+func bumpSequence(comp *ical.Component) {
+	setSequence(comp, sequenceOf(comp)+1)
+}
+
+func sequenceOf(comp *ical.Component) int {
+	sequence := 0
+	if prop := comp.Props.Get(ical.PropSequence); prop != nil {
+		sequence, _ = strconv.Atoi(strings.TrimSpace(prop.Value))
+	}
+	return sequence
+}
+
+func setSequence(comp *ical.Component, sequence int) {
+	prop := ical.NewProp(ical.PropSequence)
+	prop.Value = strconv.Itoa(sequence)
+	comp.Props.Set(prop)
+}
+
+// declineAttendance sets PARTSTAT=DECLINED on the ATTENDEE with address and
+// reports whether there was one.
+func declineAttendance(comp *ical.Component, address string) bool {
+	attendees := comp.Props[ical.PropAttendee]
+	for i := range attendees {
+		if domain.SameCalendarAddress(attendees[i].Value, address) {
+			if attendees[i].Params == nil {
+				attendees[i].Params = make(ical.Params)
+			}
+			attendees[i].Params.Set(ical.ParamParticipationStatus, domain.PartStatDeclined)
+			return true
+		}
+	}
+	return false
+}
+
+func encodeCalendar(cal *ical.Calendar) (string, error) {
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(cal); err != nil {
+		return "", fmt.Errorf("failed to encode event: %w", err)
+	}
+	return buf.String(), nil
+}
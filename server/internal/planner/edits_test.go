@@ -0,0 +1,58 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestEditedEventICSKeepsValueTypes(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	now := monday(8, 0)
+	edit := func(t *testing.T, uid string, props []string, change domain.ProposalChange) *ical.Component {
+		t.Helper()
+		ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+			"\r\nDTSTAMP:20261001T000000Z\r\n" + strings.Join(props, "\r\n") + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+		edited, err := editedEventICS(&domain.User{}, &domain.Event{UID: uid, ICS: ics}, change, now)
+		if err != nil {
+			t.Fatalf("editedEventICS: %v", err)
+		}
+		cal, err := ical.NewDecoder(strings.NewReader(edited)).Decode()
+		if err != nil {
+			t.Fatalf("edited event does not parse: %v\n%s", err, edited)
+		}
+		return masterEvent(cal)
+	}
+
+	// Zoned times stay in their zone
+	zoned := edit(t, "zoned", []string{"DTSTART;TZID=Europe/Berlin:20261102T100000", "DTEND;TZID=Europe/Berlin:20261102T110000"},
+		domain.ProposalChange{Type: domain.ChangeMoveEvent, Start: monday(13, 0), End: monday(14, 0)})
+	for name, want := range map[string]string{ical.PropDateTimeStart: "20261102T140000", ical.PropDateTimeEnd: "20261102T150000"} {
+		prop := zoned.Props.Get(name)
+		if prop.Params.Get(ical.ParamTimezoneID) != "Europe/Berlin" || prop.Value != want {
+			t.Errorf("%s = %v %s, want TZID=Europe/Berlin %s", name, prop.Params, prop.Value, want)
+		}
+	}
+
+	// All-day values stay dates
+	allDay := edit(t, "all-day", []string{"DTSTART;VALUE=DATE:20261102", "DTEND;VALUE=DATE:20261103"},
+		domain.ProposalChange{Type: domain.ChangeMoveEvent, Start: time.Date(2026, 11, 4, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 11, 6, 0, 0, 0, 0, time.UTC)})
+	for name, want := range map[string]string{ical.PropDateTimeStart: "20261104", ical.PropDateTimeEnd: "20261106"} {
+		prop := allDay.Props.Get(name)
+		if prop.ValueType() != ical.ValueDate || prop.Value != want {
+			t.Errorf("%s = %v %s, want VALUE=DATE %s", name, prop.Params, prop.Value, want)
+		}
+	}
+	shortened := edit(t, "no-end", []string{"DTSTART;VALUE=DATE:20261102", "DURATION:P3D"},
+		domain.ProposalChange{Type: domain.ChangeShortenEvent, End: time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)})
+	if prop := shortened.Props.Get(ical.PropDateTimeEnd); prop.ValueType() != ical.ValueDate || prop.Value != "20261103" ||
+		shortened.Props.Get(ical.PropDuration) != nil {
+		t.Errorf("shortened DTEND = %v %s, want VALUE=DATE 20261103 without DURATION", prop.Params, prop.Value)
+	}
+}
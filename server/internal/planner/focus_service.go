@@ -0,0 +1,334 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// Defaults and bounds of focus time suggestions
+const (
+	DefaultFocusMinDuration = time.Hour
+	DefaultFocusMaxDuration = 3 * time.Hour
+	MaxFocusDuration        = 12 * time.Hour
+	DefaultFocusSuggestions = 5
+	MaxFocusSuggestions     = 50
+
+	// focusStep aligns the first suggestion of today to the quarter hour.
+	focusStep = 15 * time.Minute
+)
+
+// Weights of the parts of a suggestion's score; they add up to 100.
+const (
+	focusLengthWeight        = 50
+	focusTimeOfDayWeight     = 30
+	focusFragmentationWeight = 20
+)
+
+var ErrInvalidFocusQuery = errors.New("invalid focus query")
+
+// TimeOfDay is when in the working day focus blocks are preferred.
+type TimeOfDay string
+
+const (
+	TimeOfDayAny       TimeOfDay = "any"
+	TimeOfDayMorning   TimeOfDay = "morning"
+	TimeOfDayAfternoon TimeOfDay = "afternoon"
+)
+
+// FocusQuery asks for focus blocks on a day or in a week.
+type FocusQuery struct {
+	Date        time.Time     // Only its date counts, taken in the user's time zone; zero means today
+	Week        bool          // Search Monday to Sunday of the week of Date instead of the day
+	MinDuration time.Duration // Shortest block worth suggesting; 0 means DefaultFocusMinDuration
+	MaxDuration time.Duration // Longest block suggested; 0 means DefaultFocusMaxDuration, or MinDuration if longer
+	TimeOfDay   TimeOfDay     // "" means TimeOfDayAny
+	Limit       int           // 0 means DefaultFocusSuggestions
+}
+
+// FocusInterval is a span of free working time.
+type FocusInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FocusSuggestion is a candidate focus block.
+type FocusSuggestion struct {
+	Start         time.Time
+	End           time.Time
+	Score         int           // 0-100; higher is better
+	Fragmentation time.Duration // Free time the block leaves around it that is too short for another
+}
+
+// FocusSuggestions are the free working time of the searched range and the
+// best focus blocks in it, by score.
+type FocusSuggestions struct {
+	Location    *time.Location // The user's time zone; days and working hours are in it
+	Start       time.Time
+	End         time.Time
+	Free        []FocusInterval
+	Suggestions []FocusSuggestion
+}
+
+// FocusBlock is a focus block to book.
+type FocusBlock struct {
+	CalendarID int64
+	Start      time.Time
+	End        time.Time
+	Summary    string // DefaultFocusBlockSummary when empty
+}
+
+// FocusBooking is the outcome of booking a focus block.
+type FocusBooking struct {
+	Proposal *domain.PlanProposal // Carries the violations when the block was not booked
+	Applied  *AppliedPlan         // nil unless the block was booked
+}
+
+// FocusService finds free time for focused work across all of a user's
+// calendars and books focus blocks through plan proposals.
+type FocusService struct {
+	proposals *ProposalService
+	apply     *ApplyService
+}
+
+func NewFocusService(proposals *ProposalService, apply *ApplyService) *FocusService {
+	return &FocusService{proposals: proposals, apply: apply}
+}
+
+// Suggest returns the free working time of the user on the day or week of
+// the query and the focus blocks that fit it best. Busy time is every opaque,
+// not cancelled event instance of the user's event calendars, recurrences
+// expanded and all-day events taking their whole day, plus the travel time
+// around events with a physical LOCATION. Time already past is not free.
+//
+// Candidates start or end with a free interval and are as long as it allows,
+// up to MaxDuration. They are scored by length, by how well they match the
+// preferred time of day and by how little free time they leave too short for
+// another block; overlapping candidates give way to the better one.
+func (s *FocusService) Suggest(ctx context.Context, userID int64, q FocusQuery) (*FocusSuggestions, error) {
+	q, err := normalizeFocusQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.proposals.preferences.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := prefs.Location
+	now := s.proposals.now()
+
+	date := q.Date
+	if date.IsZero() {
+		date = now.In(loc)
+	}
+	first := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	days := 1
+	if q.Week {
+		first = first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+		days = 7
+	}
+	end := first.AddDate(0, 0, days)
+
+	blocks, err := loadBlocks(ctx, s.proposals.calendars, s.proposals.events, userID,
+		first.Add(-prefs.TravelTime), end.Add(prefs.TravelTime), loc)
+	if err != nil {
+		return nil, err
+	}
+	busy := busyIntervals(blocks, prefs.TravelTime)
+
+	result := &FocusSuggestions{Location: loc, Start: first, End: end}
+	var candidates []FocusSuggestion
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		hours := prefs.WorkingHours[day.Weekday()]
+		if !hours.Working() {
+			continue
+		}
+		opens, closes := atClock(day, hours.Start), atClock(day, hours.End)
+		from := opens
+		if from.Before(now) {
+			from = day.Add((now.Sub(day) + focusStep - 1) / focusStep * focusStep)
+		}
+		for _, free := range freeIntervals(from, closes, busy) {
+			result.Free = append(result.Free, free)
+			candidates = append(candidates, focusCandidates(free, opens, closes, q)...)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Start.Before(candidates[j].Start)
+	})
+	for _, c := range candidates {
+		if len(result.Suggestions) == q.Limit {
+			break
+		}
+		overlaps := false
+		for _, picked := range result.Suggestions {
+			if c.Start.Before(picked.End) && picked.Start.Before(c.End) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			result.Suggestions = append(result.Suggestions, c)
+		}
+	}
+	return result, nil
+}
+
+// Book writes a focus event, marked with PropFocus, to one of the user's
+// calendars. It goes through a proposal of one create_focus_block change that
+// is applied right away, so the block is checked against the constraints and
+// can be rolled back from the audit log like any applied plan. A block with
+// violations is not booked: Book returns the draft proposal with them and
+// ErrProposalHasViolations. Malformed blocks are rejected with
+// ErrInvalidProposal.
+func (s *FocusService) Book(ctx context.Context, user *domain.User, block FocusBlock) (*FocusBooking, error) {
+	proposal, err := s.proposals.Create(ctx, user, "Book focus time", []domain.ProposalChange{{
+		Type:       domain.ChangeCreateFocusBlock,
+		CalendarID: block.CalendarID,
+		Summary:    block.Summary,
+		Start:      block.Start,
+		End:        block.End,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	booking := &FocusBooking{Proposal: proposal}
+	if proposal.Status != domain.ProposalValid {
+		return booking, ErrProposalHasViolations
+	}
+	applied, err := s.apply.Apply(ctx, user, proposal.ID)
+	if err != nil {
+		return booking, err
+	}
+	booking.Proposal, booking.Applied = applied.Proposal, applied
+	slog.Info("planner.focus.booked", "user_id", user.ID, "calendar_id", block.CalendarID, "uid", applied.Proposal.Changes[0].UID)
+	return booking, nil
+}
+
+// normalizeFocusQuery fills in the defaults of q and checks its bounds.
+func normalizeFocusQuery(q FocusQuery) (FocusQuery, error) {
+	if q.MinDuration == 0 {
+		q.MinDuration = DefaultFocusMinDuration
+	}
+	if q.MaxDuration == 0 {
+		q.MaxDuration = max(DefaultFocusMaxDuration, q.MinDuration)
+	}
+	if q.MinDuration < focusStep || q.MaxDuration < q.MinDuration || q.MaxDuration > MaxFocusDuration {
+		return q, fmt.Errorf("%w: durations must be from %s to %s, the minimum not above the maximum",
+			ErrInvalidFocusQuery, formatDuration(focusStep), formatDuration(MaxFocusDuration))
+	}
+	switch q.TimeOfDay {
+	case "":
+		q.TimeOfDay = TimeOfDayAny
+	case TimeOfDayAny, TimeOfDayMorning, TimeOfDayAfternoon:
+	default:
+		return q, fmt.Errorf("%w: unknown time of day %q", ErrInvalidFocusQuery, q.TimeOfDay)
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultFocusSuggestions
+	}
+	if q.Limit > MaxFocusSuggestions {
+		q.Limit = MaxFocusSuggestions
+	}
+	return q, nil
+}
+
+// busyIntervals returns the time blocks keep busy, with travel time around
+// those that need it, merged and sorted.
+func busyIntervals(blocks []Block, travel time.Duration) []FocusInterval {
+	intervals := make([]FocusInterval, 0, len(blocks))
+	for _, b := range blocks {
+		interval := FocusInterval{Start: b.Start, End: b.End}
+		if b.Travels() {
+			interval.Start, interval.End = interval.Start.Add(-travel), interval.End.Add(travel)
+		}
+		intervals = append(intervals, interval)
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start.Before(intervals[j].Start) })
+
+	var merged []FocusInterval
+	for _, interval := range intervals {
+		if n := len(merged); n > 0 && !interval.Start.After(merged[n-1].End) {
+			if interval.End.After(merged[n-1].End) {
+				merged[n-1].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// freeIntervals returns the parts of [from, until) that no busy interval
+// covers.
+func freeIntervals(from, until time.Time, busy []FocusInterval) []FocusInterval {
+	var free []FocusInterval
+	cursor := from
+	for _, b := range busy {
+		if !b.End.After(cursor) {
+			continue
+		}
+		if !b.Start.Before(until) {
+			break
+		}
+		if b.Start.After(cursor) {
+			free = append(free, FocusInterval{Start: cursor, End: b.Start})
+		}
+		cursor = b.End
+	}
+	if cursor.Before(until) {
+		free = append(free, FocusInterval{Start: cursor, End: until})
+	}
+	return free
+}
+
+// focusCandidates returns the scored blocks that start or end with free, on
+// a working day from dayStart to dayEnd.
+func focusCandidates(free FocusInterval, dayStart, dayEnd time.Time, q FocusQuery) []FocusSuggestion {
+	length := free.End.Sub(free.Start)
+	if length < q.MinDuration {
+		return nil
+	}
+	size := min(length, q.MaxDuration)
+	starts := []time.Time{free.Start}
+	if size < length {
+		starts = append(starts, free.End.Add(-size))
+	}
+
+	var candidates []FocusSuggestion
+	for _, start := range starts {
+		c := FocusSuggestion{Start: start, End: start.Add(size)}
+		for _, left := range []time.Duration{c.Start.Sub(free.Start), free.End.Sub(c.End)} {
+			if left > 0 && left < q.MinDuration {
+				c.Fragmentation += left
+			}
+		}
+
+		fit := 1.0
+		if q.TimeOfDay != TimeOfDayAny {
+			middle := c.Start.Add(size / 2)
+			position := float64(middle.Sub(dayStart)) / float64(dayEnd.Sub(dayStart))
+			position = math.Max(0, math.Min(1, position))
+			fit = 1 - position
+			if q.TimeOfDay == TimeOfDayAfternoon {
+				fit = position
+			}
+		}
+		score := focusLengthWeight*float64(size)/float64(q.MaxDuration) +
+			focusTimeOfDayWeight*fit +
+			focusFragmentationWeight*(1-float64(c.Fragmentation)/float64(length))
+		c.Score = int(math.Round(score))
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
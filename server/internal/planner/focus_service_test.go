@@ -0,0 +1,163 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func newFocusService(f *plannerFixture, now time.Time) *FocusService {
+	proposals, apply := newApplyService(f)
+	proposals.now = func() time.Time { return now }
+	return NewFocusService(proposals, apply)
+}
+
+func intervals(t *testing.T, free []FocusInterval) string {
+	t.Helper()
+	var spans []string
+	for _, i := range free {
+		spans = append(spans, i.Start.Format("Mon 15:04")+"-"+i.End.Format("15:04"))
+	}
+	return strings.Join(spans, ", ")
+}
+
+func TestFocusService_Suggest(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	sunday := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	focus := newFocusService(f, sunday)
+	if _, err := f.preferences.Update(ctx, f.user.ID, map[string]json.RawMessage{"travel_minutes": json.RawMessage(`30`)}); err != nil {
+		t.Fatalf("set preferences: %v", err)
+	}
+
+	f.putEvent(t, "standup", monday(9, 0), monday(9, 30))
+	f.putEvent(t, "notes", monday(13, 0), monday(17, 0), "TRANSP:TRANSPARENT")
+	f.putEvent(t, "moved", monday(14, 0), monday(15, 0), "STATUS:CANCELLED")
+	f.putEvent(t, "offsite", monday(15, 30), monday(16, 0), "LOCATION:Main St 1")
+	f.putObject(t, "holiday", "DTSTART;VALUE=DATE:20261103", "DTEND;VALUE=DATE:20261104")
+
+	// Busy time in every calendar counts, recurrences expanded
+	work := f.calendar
+	f.calendar = &domain.Calendar{UserID: f.user.ID, Name: "personal", DisplayName: "Personal"}
+	if err := f.calendars.Create(ctx, f.calendar); err != nil {
+		t.Fatalf("create calendar: %v", err)
+	}
+	f.putEvent(t, "lunch", monday(12, 0).AddDate(0, 0, -7), monday(13, 0).AddDate(0, 0, -7), "RRULE:FREQ=WEEKLY")
+	f.calendar = work
+
+	day, err := focus.Suggest(ctx, f.user.ID, FocusQuery{Date: monday(0, 0), TimeOfDay: TimeOfDayMorning})
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if got, want := intervals(t, day.Free), "Mon 09:30-12:00, Mon 13:00-15:00, Mon 16:30-17:00"; got != want {
+		t.Fatalf("free = %s, want %s", got, want)
+	}
+	if got, want := intervals(t, []FocusInterval{{day.Suggestions[0].Start, day.Suggestions[0].End}, {day.Suggestions[1].Start, day.Suggestions[1].End}}),
+		"Mon 09:30-12:00, Mon 13:00-15:00"; len(day.Suggestions) != 2 || got != want {
+		t.Fatalf("suggestions = %+v, want %s", day.Suggestions, want)
+	}
+	if day.Suggestions[0].Score != 85 || day.Suggestions[1].Score != 65 {
+		t.Errorf("scores = %d, %d; want 85, 65", day.Suggestions[0].Score, day.Suggestions[1].Score)
+	}
+
+	afternoon, err := focus.Suggest(ctx, f.user.ID, FocusQuery{Date: monday(0, 0), TimeOfDay: TimeOfDayAfternoon})
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if !afternoon.Suggestions[0].Start.Equal(monday(13, 0)) {
+		t.Errorf("afternoon suggestions = %+v, want 13:00 first", afternoon.Suggestions)
+	}
+
+	// The week of a Wednesday runs from Monday; the all-day holiday and the
+	// weekend have no free time, and long free days leave no fragments
+	week, err := focus.Suggest(ctx, f.user.ID, FocusQuery{Date: monday(0, 0).AddDate(0, 0, 2), Week: true, MaxDuration: 3 * time.Hour, Limit: 3})
+	if err != nil {
+		t.Fatalf("Suggest week: %v", err)
+	}
+	if !week.Start.Equal(monday(0, 0)) || len(week.Free) != 6 {
+		t.Fatalf("week = %s from %s", intervals(t, week.Free), week.Start)
+	}
+	if got, want := intervals(t, []FocusInterval{{week.Suggestions[0].Start, week.Suggestions[0].End}, {week.Suggestions[1].Start, week.Suggestions[1].End},
+		{week.Suggestions[2].Start, week.Suggestions[2].End}}), "Wed 09:00-12:00, Wed 14:00-17:00, Thu 09:00-12:00"; got != want || week.Suggestions[0].Score != 100 {
+		t.Fatalf("week suggestions = %s, want %s", got, want)
+	}
+
+	// Time already past is not free
+	later := newFocusService(f, monday(10, 7))
+	today, err := later.Suggest(ctx, f.user.ID, FocusQuery{})
+	if err != nil {
+		t.Fatalf("Suggest today: %v", err)
+	}
+	if !today.Free[0].Start.Equal(monday(10, 15)) {
+		t.Errorf("free today = %s, want from 10:15", intervals(t, today.Free))
+	}
+
+	if _, err := focus.Suggest(ctx, f.user.ID, FocusQuery{MinDuration: 2 * time.Hour, MaxDuration: time.Hour}); !errors.Is(err, ErrInvalidFocusQuery) {
+		t.Errorf("Suggest with max below min = %v, want ErrInvalidFocusQuery", err)
+	}
+	if _, err := focus.Suggest(ctx, f.user.ID, FocusQuery{TimeOfDay: "evening"}); !errors.Is(err, ErrInvalidFocusQuery) {
+		t.Errorf("Suggest in the evening = %v, want ErrInvalidFocusQuery", err)
+	}
+}
+
+func TestFocusService_SuggestTimeZone(t *testing.T) {
+	ctx := context.Background()
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	f := newPlannerFixture(t)
+	focus := newFocusService(f, time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC))
+	if _, err := f.preferences.Update(ctx, f.user.ID, map[string]json.RawMessage{"time_zone": json.RawMessage(`"Europe/Berlin"`)}); err != nil {
+		t.Fatalf("set preferences: %v", err)
+	}
+	f.putEvent(t, "standup", monday(9, 0), monday(9, 30))
+
+	// 09:00-17:00 in Berlin is 08:00-16:00 UTC
+	day, err := focus.Suggest(ctx, f.user.ID, FocusQuery{Date: monday(0, 0)})
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	var utc []FocusInterval
+	for _, i := range day.Free {
+		utc = append(utc, FocusInterval{i.Start.UTC(), i.End.UTC()})
+	}
+	if got, want := intervals(t, utc), "Mon 08:00-09:00, Mon 09:30-16:00"; got != want {
+		t.Fatalf("free = %s, want %s", got, want)
+	}
+}
+
+func TestFocusService_Book(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	focus := newFocusService(f, time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC))
+
+	booking, err := focus.Book(ctx, f.user, FocusBlock{CalendarID: f.calendar.ID, Start: monday(10, 0), End: monday(12, 0)})
+	if err != nil {
+		t.Fatalf("Book: %v", err)
+	}
+	if booking.Applied == nil || booking.Proposal.Status != domain.ProposalApplied || booking.Applied.Entry.ID == 0 {
+		t.Fatalf("Book = %+v", booking)
+	}
+	event, err := f.events.GetByUID(ctx, f.calendar.ID, booking.Proposal.Changes[0].UID)
+	if err != nil {
+		t.Fatalf("focus event not created: %v", err)
+	}
+	if !strings.Contains(event.ICS, PropFocus+":TRUE") || !strings.Contains(event.ICS, "SUMMARY:"+DefaultFocusBlockSummary) {
+		t.Errorf("focus event = %s", event.ICS)
+	}
+
+	// The booked block is busy now
+	booking, err = focus.Book(ctx, f.user, FocusBlock{CalendarID: f.calendar.ID, Start: monday(11, 0), End: monday(12, 0), Summary: "Write"})
+	if !errors.Is(err, ErrProposalHasViolations) || booking.Applied != nil || len(booking.Proposal.Violations) != 1 ||
+		booking.Proposal.Violations[0].Code != ViolationTimeConflict {
+		t.Fatalf("Book over the first block = %+v, %v", booking, err)
+	}
+	if _, err := focus.Book(ctx, f.user, FocusBlock{CalendarID: f.calendar.ID, Start: monday(12, 0), End: monday(11, 0)}); !errors.Is(err, ErrInvalidProposal) {
+		t.Errorf("Book ending before it starts = %v, want ErrInvalidProposal", err)
+	}
+}
@@ -0,0 +1,270 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// Defaults of the planning preferences
+const (
+	DefaultWorkdayStart = 9 * time.Hour
+	DefaultWorkdayEnd   = 17 * time.Hour
+)
+
+// MaxPreferenceMinutes bounds the minute-valued preferences.
+const MaxPreferenceMinutes = 24 * 60
+
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+var weekdayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// preferenceKeys maps the names preferences go by in the API to their keys.
+var preferenceKeys = map[string]string{
+	"time_zone":                   domain.PrefTimeZone,
+	"working_hours":               domain.PrefWorkingHours,
+	"min_buffer_minutes":          domain.PrefMinBufferMinutes,
+	"max_meeting_minutes_per_day": domain.PrefMaxMeetingMinutes,
+	"no_meeting_days":             domain.PrefNoMeetingDays,
+	"travel_minutes":              domain.PrefTravelMinutes,
+}
+
+// WorkingHours is the working time of one weekday as offsets from local
+// midnight. The zero value is a day off.
+type WorkingHours struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Working reports whether the day has working time.
+func (w WorkingHours) Working() bool {
+	return w.End > w.Start
+}
+
+// Preferences is a user's planning configuration with defaults filled in.
+type Preferences struct {
+	Location       *time.Location  // Time zone days and working hours are in
+	WorkingHours   [7]WorkingHours // By time.Weekday
+	MinBuffer      time.Duration   // Free time required between meetings
+	MaxMeetingLoad time.Duration   // Meeting time allowed per day; 0 means no limit
+	NoMeetingDays  [7]bool         // By time.Weekday
+	TravelTime     time.Duration   // Kept free before and after events with a physical LOCATION
+}
+
+// DefaultPreferences returns the preferences of a user who set none: UTC,
+// 09:00-17:00 on weekdays, no buffers, no meeting limit and no travel time.
+func DefaultPreferences() Preferences {
+	p := Preferences{Location: time.UTC}
+	for day := time.Monday; day <= time.Friday; day++ {
+		p.WorkingHours[day] = WorkingHours{Start: DefaultWorkdayStart, End: DefaultWorkdayEnd}
+	}
+	return p
+}
+
+// PreferenceService reads and writes users' planning preferences.
+type PreferenceService struct {
+	preferences domain.PreferenceRepo
+}
+
+func NewPreferenceService(preferences domain.PreferenceRepo) *PreferenceService {
+	return &PreferenceService{preferences: preferences}
+}
+
+// Get returns the user's planning preferences. Stored values that no longer
+// decode are logged and replaced by their default.
+func (s *PreferenceService) Get(ctx context.Context, userID int64) (Preferences, error) {
+	p := DefaultPreferences()
+	if s == nil || s.preferences == nil {
+		return p, nil
+	}
+	stored, err := s.preferences.ListByUser(ctx, userID)
+	if err != nil {
+		return p, err
+	}
+	for _, pref := range stored {
+		if err := p.decode(pref.Key, []byte(pref.Value)); err != nil {
+			slog.Warn("planner.preference.invalid", "user_id", userID, "key", pref.Key, "error", err)
+		}
+	}
+	return p, nil
+}
+
+// Update sets the preferences named in values, by their API name, and
+// returns the result. A null value resets the preference to its default.
+// Unknown names and invalid values are rejected with ErrInvalidPreferences
+// before anything is written.
+func (s *PreferenceService) Update(ctx context.Context, userID int64, values map[string]json.RawMessage) (Preferences, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	check := DefaultPreferences()
+	for _, name := range names {
+		key, ok := preferenceKeys[name]
+		if !ok {
+			return Preferences{}, fmt.Errorf("%w: unknown preference %q", ErrInvalidPreferences, name)
+		}
+		if isNull(values[name]) {
+			continue
+		}
+		if err := check.decode(key, values[name]); err != nil {
+			return Preferences{}, fmt.Errorf("%w: %s: %v", ErrInvalidPreferences, name, err)
+		}
+	}
+
+	for _, name := range names {
+		key := preferenceKeys[name]
+		if isNull(values[name]) {
+			if err := s.preferences.Delete(ctx, userID, key); err != nil {
+				return Preferences{}, err
+			}
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, values[name]); err != nil {
+			return Preferences{}, fmt.Errorf("%w: %s: %v", ErrInvalidPreferences, name, err)
+		}
+		if err := s.preferences.Set(ctx, &domain.Preference{UserID: userID, Key: key, Value: compact.String()}); err != nil {
+			return Preferences{}, err
+		}
+	}
+	slog.Info("planner.preferences.updated", "user_id", userID, "names", strings.Join(names, ","))
+	return s.Get(ctx, userID)
+}
+
+// Values returns the preferences by their API name, in the form they are
+// stored and updated in.
+func (p Preferences) Values() map[string]any {
+	workingHours := make(map[string]map[string]string)
+	var noMeetingDays []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if hours := p.WorkingHours[day]; hours.Working() {
+			workingHours[weekdayNames[day]] = map[string]string{"start": formatClock(hours.Start), "end": formatClock(hours.End)}
+		}
+		if p.NoMeetingDays[day] {
+			noMeetingDays = append(noMeetingDays, weekdayNames[day])
+		}
+	}
+	if noMeetingDays == nil {
+		noMeetingDays = []string{}
+	}
+	return map[string]any{
+		"time_zone":                   p.Location.String(),
+		"working_hours":               workingHours,
+		"min_buffer_minutes":          int(p.MinBuffer / time.Minute),
+		"max_meeting_minutes_per_day": int(p.MaxMeetingLoad / time.Minute),
+		"no_meeting_days":             noMeetingDays,
+		"travel_minutes":              int(p.TravelTime / time.Minute),
+	}
+}
+
+// decode sets the preference stored under key from its JSON value.
+func (p *Preferences) decode(key string, value []byte) error {
+	switch key {
+	case domain.PrefTimeZone:
+		var name string
+		if err := json.Unmarshal(value, &name); err != nil {
+			return errors.New("must be a time zone name")
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil || name == "" || strings.EqualFold(name, "Local") {
+			return fmt.Errorf("unknown time zone %q", name)
+		}
+		p.Location = loc
+	case domain.PrefWorkingHours:
+		var days map[string]struct {
+			Start string `json:"start"`
+			End   string `json:"end"`
+		}
+		if err := json.Unmarshal(value, &days); err != nil {
+			return errors.New(`must map weekdays to {"start": "HH:MM", "end": "HH:MM"}`)
+		}
+		var hours [7]WorkingHours
+		for name, span := range days {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return fmt.Errorf("unknown weekday %q", name)
+			}
+			start, okStart := parseClock(span.Start)
+			end, okEnd := parseClock(span.End)
+			if !okStart || !okEnd || end <= start {
+				return fmt.Errorf("%s needs a start before its end, as HH:MM", name)
+			}
+			hours[day] = WorkingHours{Start: start, End: end}
+		}
+		p.WorkingHours = hours
+	case domain.PrefMinBufferMinutes:
+		return decodeMinutes(value, &p.MinBuffer)
+	case domain.PrefMaxMeetingMinutes:
+		return decodeMinutes(value, &p.MaxMeetingLoad)
+	case domain.PrefTravelMinutes:
+		return decodeMinutes(value, &p.TravelTime)
+	case domain.PrefNoMeetingDays:
+		var names []string
+		if err := json.Unmarshal(value, &names); err != nil {
+			return errors.New("must be a list of weekdays")
+		}
+		var days [7]bool
+		for _, name := range names {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return fmt.Errorf("unknown weekday %q", name)
+			}
+			days[day] = true
+		}
+		p.NoMeetingDays = days
+	}
+	return nil
+}
+
+func decodeMinutes(value []byte, d *time.Duration) error {
+	var minutes int
+	if err := json.Unmarshal(value, &minutes); err != nil || minutes < 0 || minutes > MaxPreferenceMinutes {
+		return fmt.Errorf("must be a number of minutes from 0 to %d", MaxPreferenceMinutes)
+	}
+	*d = time.Duration(minutes) * time.Minute
+	return nil
+}
+
+func isNull(value json.RawMessage) bool {
+	return len(value) == 0 || string(bytes.TrimSpace(value)) == "null"
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day, short := range weekdayNames {
+		if strings.EqualFold(name, short) {
+			return time.Weekday(day), true
+		}
+	}
+	return 0, false
+}
+
+// parseClock parses HH:MM, 00:00 to 24:00, into an offset from midnight.
+func parseClock(value string) (time.Duration, bool) {
+	var hours, minutes int
+	if len(value) != 5 || value[2] != ':' {
+		return 0, false
+	}
+	if _, err := fmt.Sscanf(value, "%02d:%02d", &hours, &minutes); err != nil {
+		return 0, false
+	}
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if minutes > 59 || d > 24*time.Hour {
+		return 0, false
+	}
+	return d, true
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
@@ -0,0 +1,66 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPreferenceService_Update(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	service := f.preferences
+
+	prefs, err := service.Get(ctx, f.user.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if prefs.Location != time.UTC || !prefs.WorkingHours[time.Monday].Working() || prefs.WorkingHours[time.Sunday].Working() {
+		t.Fatalf("defaults = %+v", prefs)
+	}
+
+	for _, values := range []map[string]json.RawMessage{
+		{"lunch_minutes": json.RawMessage(`60`)},
+		{"time_zone": json.RawMessage(`"Mars/Olympus"`)},
+		{"working_hours": json.RawMessage(`{"mon": {"start": "17:00", "end": "09:00"}}`)},
+		{"working_hours": json.RawMessage(`{"someday": {"start": "09:00", "end": "17:00"}}`)},
+		{"min_buffer_minutes": json.RawMessage(`-5`)},
+		{"no_meeting_days": json.RawMessage(`"wed"`)},
+		// Nothing is written when one value is invalid
+		{"travel_minutes": json.RawMessage(`20`), "max_meeting_minutes_per_day": json.RawMessage(`"lots"`)},
+	} {
+		if _, err := service.Update(ctx, f.user.ID, values); !errors.Is(err, ErrInvalidPreferences) {
+			t.Fatalf("Update(%s) error = %v, want ErrInvalidPreferences", values, err)
+		}
+	}
+
+	prefs, err = service.Update(ctx, f.user.ID, map[string]json.RawMessage{
+		"time_zone":          json.RawMessage(`"America/New_York"`),
+		"working_hours":      json.RawMessage(`{"mon": {"start": "08:30", "end": "16:00"}, "sat": {"start": "10:00", "end": "12:00"}}`),
+		"min_buffer_minutes": json.RawMessage(`10`),
+		"no_meeting_days":    json.RawMessage(`["wed", "FRI"]`),
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if prefs.Location.String() != "America/New_York" || prefs.WorkingHours[time.Monday] != (WorkingHours{Start: 8*time.Hour + 30*time.Minute, End: 16 * time.Hour}) ||
+		prefs.WorkingHours[time.Tuesday].Working() || !prefs.WorkingHours[time.Saturday].Working() ||
+		prefs.MinBuffer != 10*time.Minute || prefs.TravelTime != 0 || !prefs.NoMeetingDays[time.Wednesday] || !prefs.NoMeetingDays[time.Friday] {
+		t.Fatalf("updated = %+v", prefs)
+	}
+	values := prefs.Values()
+	if values["time_zone"] != "America/New_York" || values["min_buffer_minutes"] != 10 || len(values["no_meeting_days"].([]string)) != 2 {
+		t.Fatalf("Values = %v", values)
+	}
+
+	// null resets a preference to its default
+	prefs, err = service.Update(ctx, f.user.ID, map[string]json.RawMessage{"working_hours": json.RawMessage(`null`)})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if prefs.WorkingHours != DefaultPreferences().WorkingHours || prefs.MinBuffer != 10*time.Minute {
+		t.Fatalf("after reset = %+v", prefs)
+	}
+}
@@ -0,0 +1,504 @@
+// Package planner turns planning suggestions into plan proposals: typed,
+// reviewable calendar changes that are validated before the user sees them
+// and written only when the user applies them.
+package planner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const (
+	// DefaultProposalTTL is how long a proposal can be applied after it was
+	// made. Calendars change; an old proposal is regenerated, not applied.
+	DefaultProposalTTL = 15 * time.Minute
+
+	// MaxProposalChanges bounds the changes of one proposal.
+	MaxProposalChanges = 20
+
+	// MaxProposalList bounds the proposals listed at once.
+	MaxProposalList = 100
+
+	// MaxProposalTextLength bounds summaries and reasons.
+	MaxProposalTextLength = 500
+
+	// DefaultFocusBlockSummary is the title of focus blocks proposed without one.
+	DefaultFocusBlockSummary = "Focus time"
+)
+
+var (
+	ErrInvalidProposal    = errors.New("invalid proposal")
+	ErrProposalNotPending = errors.New("proposal can no longer be applied or rejected")
+)
+
+// Violation codes reported when a proposed change cannot be applied. They are
+// stable; clients map them to their own copy.
+const (
+	ViolationCalendarNotFound       = "calendar_not_found"       // Not one of the user's event calendars
+	ViolationEventNotFound          = "event_not_found"          // The event to change does not exist
+	ViolationEventCancelled         = "event_cancelled"          // Cancelled events are left alone
+	ViolationRecurringEvent         = "recurring_event"          // Recurring events cannot be changed as a whole
+	ViolationAllDayEvent            = "all_day_event"            // All-day events cannot be moved or shortened
+	ViolationNotShorter             = "not_shorter"              // The new end is not between the event's start and end
+	ViolationNotInvited             = "not_invited"              // The user is not an attendee of the event to decline
+	ViolationOrganizerCannotDecline = "organizer_cannot_decline" // The user organizes the event to decline
+	ViolationAlreadyDeclined        = "already_declined"         // The user declined the event already
+)
+
+// ProposalService creates plan proposals and tracks their lifecycle.
+type ProposalService struct {
+	proposals   domain.PlanProposalRepo
+	calendars   domain.CalendarRepo
+	events      domain.EventRepo
+	preferences *PreferenceService
+	constraints []Constraint
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// NewProposalService returns a service that validates proposals against the
+// DefaultConstraints under the users' planning preferences.
+func NewProposalService(proposals domain.PlanProposalRepo, calendars domain.CalendarRepo, events domain.EventRepo, preferences *PreferenceService) *ProposalService {
+	return &ProposalService{
+		proposals:   proposals,
+		calendars:   calendars,
+		events:      events,
+		preferences: preferences,
+		constraints: DefaultConstraints(),
+		ttl:         DefaultProposalTTL,
+		now:         time.Now,
+	}
+}
+
+// Create stores a proposal of the given changes for user. Malformed changes
+// are rejected with ErrInvalidProposal. Changes that cannot be applied to the
+// user's calendars as they are now, or that break a planning constraint, are
+// recorded as violations and leave the
+// proposal a draft; otherwise it is valid. Focus blocks get their UID here,
+// and every existing event a change touches is recorded with its ETag.
+func (s *ProposalService) Create(ctx context.Context, user *domain.User, summary string, changes []domain.ProposalChange) (*domain.PlanProposal, error) {
+	summary = strings.TrimSpace(summary)
+	if utf8.RuneCountInString(summary) > MaxProposalTextLength {
+		return nil, fmt.Errorf("%w: summary is longer than %d characters", ErrInvalidProposal, MaxProposalTextLength)
+	}
+	if len(changes) == 0 || len(changes) > MaxProposalChanges {
+		return nil, fmt.Errorf("%w: a proposal has 1-%d changes", ErrInvalidProposal, MaxProposalChanges)
+	}
+
+	proposal := &domain.PlanProposal{UserID: user.ID, Summary: summary}
+	touched := make(map[string]bool)
+	for i, change := range changes {
+		change, err := normalizeChange(change)
+		if err != nil {
+			return nil, fmt.Errorf("%w: change %d: %v", ErrInvalidProposal, i+1, err)
+		}
+		if change.Type == domain.ChangeCreateFocusBlock {
+			if change.UID, err = generateFocusUID(); err != nil {
+				return nil, err
+			}
+		}
+		key := fmt.Sprintf("%d/%s", change.CalendarID, change.UID)
+		if touched[key] {
+			return nil, fmt.Errorf("%w: change %d: the event is already changed by another change", ErrInvalidProposal, i+1)
+		}
+		touched[key] = true
+		proposal.Changes = append(proposal.Changes, change)
+	}
+
+	violations, err := s.check(ctx, user, proposal.Changes)
+	if err != nil {
+		return nil, err
+	}
+	proposal.Violations = violations
+	proposal.Status = domain.ProposalValid
+	if len(violations) > 0 {
+		proposal.Status = domain.ProposalDraft
+	}
+	proposal.ExpiresAt = s.now().UTC().Add(s.ttl)
+	if err := s.proposals.Create(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	slog.Info("planner.proposal.created", "user_id", user.ID, "proposal_id", proposal.ID, "status", proposal.Status, "changes", len(proposal.Changes))
+	return proposal, nil
+}
+
+// Get returns a proposal of the user. Returns domain.ErrNotFound when the user
+// has no proposal with this ID.
+func (s *ProposalService) Get(ctx context.Context, userID, id int64) (*domain.PlanProposal, error) {
+	proposal, err := s.proposals.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	if err := s.expire(ctx, proposal); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// List returns the user's most recent proposals, newest first.
+func (s *ProposalService) List(ctx context.Context, userID int64, limit int) ([]*domain.PlanProposal, error) {
+	if limit <= 0 || limit > MaxProposalList {
+		limit = MaxProposalList
+	}
+	proposals, err := s.proposals.ListByUser(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, proposal := range proposals {
+		if err := s.expire(ctx, proposal); err != nil {
+			return nil, err
+		}
+	}
+	return proposals, nil
+}
+
+// Reject records that the user turned a pending proposal down. Returns
+// ErrProposalNotPending for proposals that were applied, expired or decided
+// otherwise.
+func (s *ProposalService) Reject(ctx context.Context, userID, id int64) (*domain.PlanProposal, error) {
+	proposal, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !proposal.Status.Pending() {
+		return nil, ErrProposalNotPending
+	}
+	if err := s.transition(ctx, proposal, domain.ProposalRejected, ""); err != nil {
+		return nil, err
+	}
+	slog.Info("planner.proposal.rejected", "user_id", userID, "proposal_id", id)
+	return proposal, nil
+}
+
+// expire marks a pending proposal past its expiry as expired.
+func (s *ProposalService) expire(ctx context.Context, proposal *domain.PlanProposal) error {
+	if !proposal.Status.Pending() || s.now().Before(proposal.ExpiresAt) {
+		return nil
+	}
+	return s.transition(ctx, proposal, domain.ProposalExpired, domain.ProposalReasonExpired)
+}
+
+// transition moves a proposal to a new status. Returns ErrProposalNotPending
+// when its status changed since it was loaded, e.g. by a concurrent apply.
+func (s *ProposalService) transition(ctx context.Context, proposal *domain.PlanProposal, status domain.ProposalStatus, reason string) error {
+	from := proposal.Status
+	proposal.Status = status
+	proposal.StatusReason = reason
+	if err := s.proposals.Update(ctx, proposal, from); err != nil {
+		proposal.Status = from
+		if errors.Is(err, domain.ErrConflict) {
+			return ErrProposalNotPending
+		}
+		return err
+	}
+	return nil
+}
+
+// check returns the violations of changes against the user's calendars as
+// they are now and against the constraints, and records the ETag of every
+// existing event they touch. Changes that cannot be made at all are not
+// checked against the constraints.
+func (s *ProposalService) check(ctx context.Context, user *domain.User, changes []domain.ProposalChange) ([]domain.ProposalViolation, error) {
+	var violations []domain.ProposalViolation
+	violate := func(i int, code, message string) {
+		violations = append(violations, domain.ProposalViolation{Code: code, Change: i, Message: message})
+	}
+	feasible := make(map[int]*domain.Event) // Changes that can be made, with the events they touch
+	calendars := make(map[int64]*domain.Calendar)
+	for i := range changes {
+		change := &changes[i]
+		cal, ok := calendars[change.CalendarID]
+		if !ok {
+			var err error
+			cal, err = s.calendars.GetByID(ctx, change.CalendarID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return nil, err
+			}
+			if cal != nil && (cal.UserID != user.ID || !cal.SupportsComponent(domain.ComponentEvent)) {
+				cal = nil
+			}
+			calendars[change.CalendarID] = cal
+		}
+		if cal == nil {
+			violate(i, ViolationCalendarNotFound, "The calendar is not one of your event calendars.")
+			continue
+		}
+		if change.Type == domain.ChangeCreateFocusBlock {
+			feasible[i] = nil
+			continue
+		}
+
+		event, err := s.events.GetByUID(ctx, cal.ID, change.UID)
+		if errors.Is(err, domain.ErrNotFound) {
+			violate(i, ViolationEventNotFound, "The event does not exist.")
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		change.ETag = event.ETag
+		problems := checkEventChange(user, change, event)
+		for _, v := range problems {
+			violate(i, v.Code, v.Message)
+		}
+		if len(problems) == 0 {
+			feasible[i] = event
+		}
+	}
+
+	plan, err := s.plan(ctx, user, changes, feasible)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		violations = append(violations, Validate(plan, s.constraints)...)
+	}
+	return violations, nil
+}
+
+// plan returns the user's calendars with the feasible changes made, over the
+// days around the events the changes place; nil when they place none.
+func (s *ProposalService) plan(ctx context.Context, user *domain.User, changes []domain.ProposalChange, feasible map[int]*domain.Event) (*Plan, error) {
+	var placed []Block
+	for i, change := range changes {
+		event, ok := feasible[i]
+		if !ok {
+			continue
+		}
+		switch change.Type {
+		case domain.ChangeCreateFocusBlock:
+			placed = append(placed, Block{Change: i, CalendarID: change.CalendarID, UID: change.UID, Summary: change.Summary,
+				Start: change.Start, End: change.End, Focus: true})
+		case domain.ChangeMoveEvent:
+			if block, busy := eventBlock(event); busy {
+				block.Change, block.CalendarID, block.UID, block.Start, block.End = i, change.CalendarID, change.UID, change.Start, change.End
+				placed = append(placed, block)
+			}
+		}
+	}
+	if len(placed) == 0 {
+		return nil, nil
+	}
+
+	prefs, err := s.preferences.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Whole days around the placed events, so buffers and travel time across
+	// midnight and the meeting load of every day are seen
+	start, end := placed[0].Start, placed[0].End
+	for _, b := range placed[1:] {
+		if b.Start.Before(start) {
+			start = b.Start
+		}
+		if b.End.After(end) {
+			end = b.End
+		}
+	}
+	start = startOfDay(start, prefs.Location).AddDate(0, 0, -1)
+	end = startOfDay(end, prefs.Location).AddDate(0, 0, 2)
+	blocks, err := loadBlocks(ctx, s.calendars, s.events, user.ID, start, end, prefs.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the changes: moved and declined events leave their old time,
+	// shortened ones end earlier
+	edited := make(map[string]domain.ProposalChange)
+	for i, change := range changes {
+		if _, ok := feasible[i]; ok && change.Type != domain.ChangeCreateFocusBlock {
+			edited[fmt.Sprintf("%d/%s", change.CalendarID, change.UID)] = change
+		}
+	}
+	kept := blocks[:0]
+	for _, b := range blocks {
+		change, ok := edited[fmt.Sprintf("%d/%s", b.CalendarID, b.UID)]
+		switch {
+		case !ok:
+		case change.Type == domain.ChangeMoveEvent || change.Type == domain.ChangeDeclineEvent:
+			continue
+		case change.Type == domain.ChangeShortenEvent:
+			b.End = change.End
+		}
+		kept = append(kept, b)
+	}
+	blocks = append(kept, placed...)
+	sortBlocks(blocks)
+	return &Plan{Preferences: prefs, Blocks: blocks}, nil
+}
+
+// eventBlock returns the block of a stored event without its times,
+// reporting false when the event does not keep its time busy.
+func eventBlock(event *domain.Event) (Block, bool) {
+	cal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+	if err != nil {
+		return Block{Change: -1, Summary: event.Summary, Location: event.Location}, true
+	}
+	master := masterEvent(cal)
+	if master == nil {
+		return Block{Change: -1, Summary: event.Summary, Location: event.Location}, true
+	}
+	return componentBlock(master)
+}
+
+// checkEventChange returns why a change of an existing event cannot be made.
+// Change is -1 in the returned violations.
+func checkEventChange(user *domain.User, change *domain.ProposalChange, event *domain.Event) []domain.ProposalViolation {
+	violation := func(code, message string) []domain.ProposalViolation {
+		return []domain.ProposalViolation{{Code: code, Change: -1, Message: message}}
+	}
+	cancelled, allDay := eventState(event)
+	if cancelled {
+		return violation(ViolationEventCancelled, "The event is cancelled.")
+	}
+	if event.RecurrenceRule != "" || event.RecurrenceDates != "" {
+		return violation(ViolationRecurringEvent, "Recurring events cannot be changed by a plan.")
+	}
+
+	switch change.Type {
+	case domain.ChangeMoveEvent, domain.ChangeShortenEvent:
+		if allDay {
+			return violation(ViolationAllDayEvent, "All-day events cannot be moved or shortened.")
+		}
+		if change.Type == domain.ChangeShortenEvent && (!change.End.After(event.StartTime) || !change.End.Before(event.EndTime)) {
+			return violation(ViolationNotShorter, "The new end must be after the event starts and before it ends now.")
+		}
+	case domain.ChangeDeclineEvent:
+		return checkDecline(user, event)
+	}
+	return nil
+}
+
+// eventState reports whether event is cancelled and whether it is an all-day
+// event, by its columns or else by its master VEVENT, so events stored before
+// the columns were kept in sync with the ICS are judged right too.
+func eventState(event *domain.Event) (cancelled, allDay bool) {
+	cancelled, allDay = strings.EqualFold(event.Status, "CANCELLED"), event.AllDay
+	cal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+	if err != nil {
+		return cancelled, allDay
+	}
+	master := masterEvent(cal)
+	if master == nil {
+		return cancelled, allDay
+	}
+	if prop := master.Props.Get(ical.PropStatus); prop != nil && strings.EqualFold(prop.Value, "CANCELLED") {
+		cancelled = true
+	}
+	if prop := master.Props.Get(ical.PropDateTimeStart); prop != nil && prop.ValueType() == ical.ValueDate {
+		allDay = true
+	}
+	return cancelled, allDay
+}
+
+// checkDecline returns why user cannot decline event: they must be one of its
+// attendees and not its organizer.
+func checkDecline(user *domain.User, event *domain.Event) []domain.ProposalViolation {
+	violation := func(code, message string) []domain.ProposalViolation {
+		return []domain.ProposalViolation{{Code: code, Change: -1, Message: message}}
+	}
+	notInvited := violation(ViolationNotInvited, "You are not invited to this event.")
+	if user.Email == "" {
+		return notInvited
+	}
+	cal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+	if err != nil {
+		return notInvited
+	}
+	address := domain.MailtoAddress(user.Email)
+	for _, comp := range cal.Children {
+		if comp.Name != ical.CompEvent || comp.Props.Get(ical.PropRecurrenceID) != nil {
+			continue
+		}
+		if organizer := comp.Props.Get(ical.PropOrganizer); organizer != nil && domain.SameCalendarAddress(organizer.Value, address) {
+			return violation(ViolationOrganizerCannotDecline, "You organize this event; cancel it instead of declining.")
+		}
+		for _, attendee := range comp.Props.Values(ical.PropAttendee) {
+			if domain.SameCalendarAddress(attendee.Value, address) {
+				if strings.EqualFold(attendee.Params.Get(ical.ParamParticipationStatus), domain.PartStatDeclined) {
+					return violation(ViolationAlreadyDeclined, "You already declined this event.")
+				}
+				return nil
+			}
+		}
+	}
+	return notInvited
+}
+
+// normalizeChange checks the fields a change of its type needs and returns it
+// with times in UTC and the fields its type does not use cleared.
+func normalizeChange(change domain.ProposalChange) (domain.ProposalChange, error) {
+	change.UID = strings.TrimSpace(change.UID)
+	change.Summary = strings.TrimSpace(change.Summary)
+	change.Reason = strings.TrimSpace(change.Reason)
+	change.ETag = ""
+	if change.CalendarID <= 0 {
+		return change, errors.New("calendar_id is required")
+	}
+	if utf8.RuneCountInString(change.Summary) > MaxProposalTextLength || utf8.RuneCountInString(change.Reason) > MaxProposalTextLength {
+		return change, fmt.Errorf("summary and reason are limited to %d characters", MaxProposalTextLength)
+	}
+	start, end := change.Start.UTC().Truncate(time.Second), change.End.UTC().Truncate(time.Second)
+
+	switch change.Type {
+	case domain.ChangeCreateFocusBlock:
+		if change.Start.IsZero() || change.End.IsZero() || !end.After(start) {
+			return change, errors.New("a focus block needs a start before its end")
+		}
+		if change.Summary == "" {
+			change.Summary = DefaultFocusBlockSummary
+		}
+		change.UID = ""
+		change.Start, change.End = start, end
+	case domain.ChangeMoveEvent:
+		if change.UID == "" {
+			return change, errors.New("uid is required")
+		}
+		if change.Start.IsZero() || change.End.IsZero() || !end.After(start) {
+			return change, errors.New("a move needs a start before its end")
+		}
+		change.Summary = ""
+		change.Start, change.End = start, end
+	case domain.ChangeShortenEvent:
+		if change.UID == "" {
+			return change, errors.New("uid is required")
+		}
+		if change.End.IsZero() {
+			return change, errors.New("shortening needs the new end")
+		}
+		change.Summary = ""
+		change.Start, change.End = time.Time{}, end
+	case domain.ChangeDeclineEvent:
+		if change.UID == "" {
+			return change, errors.New("uid is required")
+		}
+		change.Summary = ""
+		change.Start, change.End = time.Time{}, time.Time{}
+	default:
+		return change, fmt.Errorf("unknown change type %q", change.Type)
+	}
+	return change, nil
+}
+
+// generateFocusUID returns a UID for a new focus block.
+func generateFocusUID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "focus-" + hex.EncodeToString(buf), nil
+}
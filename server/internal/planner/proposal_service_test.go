@@ -0,0 +1,304 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/caldav"
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type plannerFixture struct {
+	db          *sql.DB
+	user        *domain.User
+	calendars   *data.SQLiteCalendarRepo
+	events      *data.SQLiteEventRepo
+	proposals   *data.SQLitePlanProposalRepo
+	calendar    *domain.Calendar
+	preferences *PreferenceService
+}
+
+func newPlannerFixture(t *testing.T) *plannerFixture {
+	t.Helper()
+	ctx := context.Background()
+	db, err := data.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	wd, _ := os.Getwd()
+	if err := data.RunMigrations(db, filepath.Join(wd, "..", "..", "migrations")); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	user, err := data.NewSQLiteUserRepo(db).Create(ctx, "alice")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	user.Email = "alice@example.com"
+	f := &plannerFixture{
+		db:          db,
+		user:        user,
+		calendars:   data.NewSQLiteCalendarRepo(db),
+		events:      data.NewSQLiteEventRepo(db),
+		proposals:   data.NewSQLitePlanProposalRepo(db),
+		preferences: NewPreferenceService(data.NewSQLitePreferenceRepo(db)),
+	}
+	f.calendar = &domain.Calendar{UserID: user.ID, Name: "work", DisplayName: "Work"}
+	if err := f.calendars.Create(ctx, f.calendar); err != nil {
+		t.Fatalf("create calendar: %v", err)
+	}
+	return f
+}
+
+// putEvent stores an event with the given extra VEVENT properties.
+func (f *plannerFixture) putEvent(t *testing.T, uid string, start, end time.Time, props ...string) *domain.Event {
+	t.Helper()
+	times := []string{"DTSTART:" + start.UTC().Format("20060102T150405Z"), "DTEND:" + end.UTC().Format("20060102T150405Z")}
+	return f.putObject(t, uid, append(times, props...)...)
+}
+
+// putObject stores a VEVENT with the given UID and properties in f.calendar
+// through a CalDAV PUT, so the stored columns are derived from the ICS as for
+// any client.
+func (f *plannerFixture) putObject(t *testing.T, uid string, props ...string) *domain.Event {
+	t.Helper()
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//test//EN", "BEGIN:VEVENT",
+		"UID:" + uid, "DTSTAMP:20260101T000000Z", "SUMMARY:" + uid}
+	lines = append(lines, props...)
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR", "")
+	cal, err := ical.NewDecoder(strings.NewReader(strings.Join(lines, "\r\n"))).Decode()
+	if err != nil {
+		t.Fatalf("parse event %s: %v", uid, err)
+	}
+	ctx := caldav.SetUserInContext(context.Background(), f.user)
+	backend := caldav.NewBackend(f.db, data.NewSQLiteUserRepo(f.db), f.calendars, f.events)
+	path := "/calendars/" + f.user.Username + "/" + f.calendar.Name + "/" + uid + ".ics"
+	if _, err := backend.PutCalendarObject(ctx, path, cal, nil); err != nil {
+		t.Fatalf("put event %s: %v", uid, err)
+	}
+	event, err := f.events.GetByUID(ctx, f.calendar.ID, uid)
+	if err != nil {
+		t.Fatalf("get event %s: %v", uid, err)
+	}
+	return event
+}
+
+func TestProposalService_CreateValidates(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	service := NewProposalService(f.proposals, f.calendars, f.events, f.preferences)
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	standup := f.putEvent(t, "standup", start, start.Add(time.Hour))
+	f.putEvent(t, "weekly", start.Add(2*time.Hour), start.Add(3*time.Hour), "RRULE:FREQ=WEEKLY")
+	f.putEvent(t, "review", start.Add(4*time.Hour), start.Add(5*time.Hour),
+		"ORGANIZER:mailto:bob@example.com", "ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:alice@example.com")
+
+	// Malformed changes are refused outright
+	for _, changes := range [][]domain.ProposalChange{
+		nil,
+		{{Type: "delete_event", CalendarID: f.calendar.ID, UID: "standup"}},
+		{{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, Start: start, End: start.Add(time.Hour)}},
+		{{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start, End: start}},
+		{{Type: domain.ChangeDeclineEvent, CalendarID: f.calendar.ID, UID: "review"}, {Type: domain.ChangeDeclineEvent, CalendarID: f.calendar.ID, UID: "review"}},
+	} {
+		if _, err := service.Create(ctx, f.user, "", changes); !errors.Is(err, ErrInvalidProposal) {
+			t.Fatalf("Create(%+v) error = %v, want ErrInvalidProposal", changes, err)
+		}
+	}
+
+	// Well-formed changes that can be applied make a valid proposal
+	proposal, err := service.Create(ctx, f.user, "Protect the afternoon", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(6 * time.Hour), End: start.Add(8 * time.Hour)},
+		{Type: domain.ChangeShortenEvent, CalendarID: f.calendar.ID, UID: "standup", End: start.Add(30 * time.Minute)},
+		{Type: domain.ChangeDeclineEvent, CalendarID: f.calendar.ID, UID: "review", Reason: "Conflicts with focus time"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if proposal.Status != domain.ProposalValid || len(proposal.Violations) != 0 {
+		t.Fatalf("proposal = %s %+v, want valid", proposal.Status, proposal.Violations)
+	}
+	if c := proposal.Changes[0]; !strings.HasPrefix(c.UID, "focus-") || c.Summary != DefaultFocusBlockSummary || c.ETag != "" {
+		t.Fatalf("focus block = %+v", c)
+	}
+	if proposal.Changes[1].ETag != standup.ETag {
+		t.Fatalf("recorded ETag = %q, want %q", proposal.Changes[1].ETag, standup.ETag)
+	}
+
+	// Changes that cannot be applied leave a draft with violations
+	tasks := &domain.Calendar{UserID: f.user.ID, Name: "tasks", ComponentSet: []string{domain.ComponentTodo}}
+	if err := f.calendars.Create(ctx, tasks); err != nil {
+		t.Fatalf("create calendar: %v", err)
+	}
+	draft, err := service.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "weekly", Start: start, End: start.Add(time.Hour)},
+		{Type: domain.ChangeShortenEvent, CalendarID: f.calendar.ID, UID: "standup", End: start.Add(2 * time.Hour)},
+		{Type: domain.ChangeDeclineEvent, CalendarID: f.calendar.ID, UID: "missing"},
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: tasks.ID, Start: start, End: start.Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Create draft: %v", err)
+	}
+	if draft.Status != domain.ProposalDraft {
+		t.Fatalf("draft status = %s, want draft", draft.Status)
+	}
+	want := []domain.ProposalViolation{
+		{Code: ViolationRecurringEvent, Change: 0},
+		{Code: ViolationNotShorter, Change: 1},
+		{Code: ViolationEventNotFound, Change: 2},
+		{Code: ViolationCalendarNotFound, Change: 3},
+	}
+	stored, err := service.Get(ctx, f.user.ID, draft.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(stored.Violations) != len(want) {
+		t.Fatalf("violations = %+v, want %+v", stored.Violations, want)
+	}
+	for i, v := range stored.Violations {
+		if v.Code != want[i].Code || v.Change != want[i].Change || v.Message == "" {
+			t.Fatalf("violation %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+}
+
+func TestProposalService_CreateChecksEventState(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	service := NewProposalService(f.proposals, f.calendars, f.events, f.preferences)
+
+	start := time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+	f.putEvent(t, "cancelled", start, start.Add(time.Hour), "STATUS:CANCELLED",
+		"ORGANIZER:mailto:bob@example.com", "ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:alice@example.com")
+	f.putObject(t, "offsite", "DTSTART;VALUE=DATE:20261103", "DTEND;VALUE=DATE:20261104")
+
+	// Both stored over CalDAV, as clients do
+	draft, err := service.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeDeclineEvent, CalendarID: f.calendar.ID, UID: "cancelled"},
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "offsite", Start: start, End: start.Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := codes(draft.Violations); len(got) != 2 || got[0] != ViolationEventCancelled || got[1] != ViolationAllDayEvent {
+		t.Fatalf("violations = %v, want event_cancelled and all_day_event", got)
+	}
+}
+
+func TestProposalService_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	service := NewProposalService(f.proposals, f.calendars, f.events, f.preferences)
+	now := time.Date(2026, 11, 2, 8, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	block := []domain.ProposalChange{{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}}
+	first, err := service.Create(ctx, f.user, "first", block)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	second, err := service.Create(ctx, f.user, "second", block)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := service.Get(ctx, f.user.ID+1, first.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Get of another user's proposal error = %v, want ErrNotFound", err)
+	}
+
+	rejected, err := service.Reject(ctx, f.user.ID, first.ID)
+	if err != nil || rejected.Status != domain.ProposalRejected {
+		t.Fatalf("Reject = %v, %v", rejected, err)
+	}
+	if _, err := service.Reject(ctx, f.user.ID, first.ID); !errors.Is(err, ErrProposalNotPending) {
+		t.Fatalf("second Reject error = %v, want ErrProposalNotPending", err)
+	}
+
+	// Pending proposals expire once their time is up; decided ones stay as they are
+	now = now.Add(DefaultProposalTTL)
+	list, err := service.List(ctx, f.user.ID, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != second.ID {
+		t.Fatalf("List = %d proposals, want newest first", len(list))
+	}
+	if list[0].Status != domain.ProposalExpired || list[0].StatusReason != domain.ProposalReasonExpired {
+		t.Fatalf("second = %s (%s), want expired", list[0].Status, list[0].StatusReason)
+	}
+	if list[1].Status != domain.ProposalRejected {
+		t.Fatalf("first = %s, want rejected", list[1].Status)
+	}
+	if _, err := service.Reject(ctx, f.user.ID, second.ID); !errors.Is(err, ErrProposalNotPending) {
+		t.Fatalf("Reject of expired proposal error = %v, want ErrProposalNotPending", err)
+	}
+}
+
+func TestProposalService_CreateChecksConstraints(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	service := NewProposalService(f.proposals, f.calendars, f.events, f.preferences)
+	if _, err := f.preferences.Update(ctx, f.user.ID, map[string]json.RawMessage{
+		"min_buffer_minutes": json.RawMessage(`15`),
+		"no_meeting_days":    json.RawMessage(`["tue"]`),
+	}); err != nil {
+		t.Fatalf("set preferences: %v", err)
+	}
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	f.putEvent(t, "standup", start, start.Add(30*time.Minute))
+	f.putEvent(t, "review", start.Add(4*time.Hour), start.Add(5*time.Hour))
+	f.putEvent(t, "lunch", start.Add(3*time.Hour), start.Add(4*time.Hour), "TRANSP:TRANSPARENT")
+	f.putEvent(t, "weekly", start.Add(-7*24*time.Hour+2*time.Hour), start.Add(-7*24*time.Hour+3*time.Hour), "RRULE:FREQ=WEEKLY")
+
+	proposal, err := service.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(9 * time.Hour), End: start.Add(10 * time.Hour)},
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(90 * time.Minute), End: start.Add(150 * time.Minute)},
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "standup", Start: start.Add(5 * time.Hour), End: start.Add(330 * time.Minute)},
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "review", Start: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour)},
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// The focus block in the evening is outside working hours, the morning
+	// one overlaps an instance of the weekly meeting, the standup lands right
+	// after the review's old slot - free now, since the review moves to a
+	// no-meeting day - and the lunch is transparent
+	want := []domain.ProposalViolation{
+		{Code: ViolationOutsideWorkingHours, Change: 0},
+		{Code: ViolationTimeConflict, Change: 1},
+		{Code: ViolationNoMeetingDay, Change: 3},
+	}
+	if proposal.Status != domain.ProposalDraft || len(proposal.Violations) != len(want) {
+		t.Fatalf("proposal = %s %+v, want %+v", proposal.Status, proposal.Violations, want)
+	}
+	for i, v := range proposal.Violations {
+		if v.Code != want[i].Code || v.Change != want[i].Change || v.Message == "" {
+			t.Fatalf("violation %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+
+	// Without the review moving away, the standup would sit right after it
+	proposal, err = service.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "standup", Start: start.Add(5 * time.Hour), End: start.Add(330 * time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(proposal.Violations) != 1 || proposal.Violations[0].Code != ViolationInsufficientBuffer {
+		t.Fatalf("violations = %+v, want insufficient_buffer", proposal.Violations)
+	}
+}
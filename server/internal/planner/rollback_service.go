@@ -0,0 +1,236 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	ErrRollbackUnavailable = errors.New("audit log entry has nothing to roll back")
+	ErrRollbackConflict    = errors.New("every event left to roll back was changed since")
+)
+
+// Why a change of an audit log entry could not be rolled back
+const (
+	RollbackConflictEdited  = "event_edited"  // The event no longer has the ETag the change left it with
+	RollbackConflictDeleted = "event_deleted" // The event the change wrote is gone
+)
+
+// RollbackService reverts the changes recorded by plan_apply audit log
+// entries. The repositories must be the SQLite ones so they can join the
+// transaction of the writes.
+type RollbackService struct {
+	events       domain.EventRepo
+	proposalRepo *data.SQLitePlanProposalRepo
+	audit        *data.SQLiteAuditLogRepo
+	writer       EventWriter
+	now          func() time.Time
+}
+
+func NewRollbackService(events domain.EventRepo, proposalRepo *data.SQLitePlanProposalRepo, audit *data.SQLiteAuditLogRepo, writer EventWriter) *RollbackService {
+	return &RollbackService{events: events, proposalRepo: proposalRepo, audit: audit, writer: writer, now: time.Now}
+}
+
+// RollbackConflict is a change a rollback left alone because the event was
+// changed after it.
+type RollbackConflict struct {
+	CalendarID int64
+	UID        string
+	Summary    string // Summary of the change in the original entry
+	Reason     string // RollbackConflictEdited or RollbackConflictDeleted
+}
+
+// RolledBackPlan is the outcome of a rollback.
+type RolledBackPlan struct {
+	Original  *domain.AuditLogEntry // The entry rolled back, with the reverted changes marked
+	Entry     *domain.AuditLogEntry // The plan_rollback entry recording the reverts; nil if nothing was reverted
+	Conflicts []RollbackConflict
+}
+
+// Audit returns the user's audit log entry id, or domain.ErrNotFound if the
+// user has none with that ID.
+func (s *RollbackService) Audit(ctx context.Context, userID, id int64) (*domain.AuditLogEntry, error) {
+	entry, err := s.audit.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	return entry, nil
+}
+
+// ListAudit returns the user's most recent audit log entries, newest first.
+func (s *RollbackService) ListAudit(ctx context.Context, userID int64, limit int) ([]*domain.AuditLogEntry, error) {
+	if limit <= 0 || limit > MaxProposalList {
+		limit = MaxProposalList
+	}
+	return s.audit.ListByUser(ctx, userID, limit)
+}
+
+// Rollback reverts the changes of the user's plan_apply entry id that are
+// still in effect: edited events get their prior calendar object back and
+// created events are deleted. A change whose event was edited or deleted
+// since, by a CalDAV client or otherwise, is left alone and reported as a
+// conflict. The reverts, their sync-token bumps, a plan_rollback audit log
+// entry and, once every change is reverted, the proposal's rolled_back status
+// are written in one transaction. Rollback returns ErrRollbackConflict when
+// every change left to revert conflicts, and ErrRollbackUnavailable when
+// there is none.
+func (s *RollbackService) Rollback(ctx context.Context, user *domain.User, id int64) (*RolledBackPlan, error) {
+	original, err := s.Audit(ctx, user.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !original.RollbackAvailable() {
+		return nil, ErrRollbackUnavailable
+	}
+
+	now := s.now().UTC()
+	result := &RolledBackPlan{Original: original}
+	entry := &domain.AuditLogEntry{
+		UserID:     user.ID,
+		Action:     domain.AuditPlanRollback,
+		EntityType: domain.AuditEntityAuditLog,
+		EntityID:   strconv.FormatInt(original.ID, 10),
+		Summary:    fmt.Sprintf("Rolled back %q", original.Summary),
+	}
+	var writes []domain.EventWrite
+	var positions []int
+	for i, change := range original.Changes {
+		if change.RevertedBy != 0 {
+			continue
+		}
+		current, err := s.events.GetByUID(ctx, change.CalendarID, change.UID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		if reason := rollbackConflict(change, current); reason != "" {
+			result.Conflicts = append(result.Conflicts, RollbackConflict{
+				CalendarID: change.CalendarID,
+				UID:        change.UID,
+				Summary:    change.Summary,
+				Reason:     reason,
+			})
+			continue
+		}
+
+		restored, err := restoredEventICS(change, now)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, domain.EventWrite{CalendarID: change.CalendarID, UID: change.UID, ExpectedETag: change.AfterETag, ICS: restored})
+		positions = append(positions, i)
+		entry.Changes = append(entry.Changes, domain.AuditChange{
+			CalendarID: change.CalendarID,
+			UID:        change.UID,
+			Summary:    revertSummary(change),
+			BeforeICS:  change.AfterICS,
+			BeforeETag: change.AfterETag,
+		})
+	}
+	if len(writes) == 0 {
+		return result, ErrRollbackConflict
+	}
+
+	err = s.writer.WriteEvents(ctx, writes, func(tx *sql.Tx, written []*domain.Event) error {
+		for i, event := range written {
+			if event != nil {
+				entry.Changes[i].AfterICS = event.ICS
+				entry.Changes[i].AfterETag = event.ETag
+			}
+		}
+		audit := s.audit.WithTx(tx)
+		if err := audit.Create(ctx, entry); err != nil {
+			return err
+		}
+		if err := audit.MarkReverted(ctx, original.ID, positions, entry.ID); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				// A concurrent rollback reverted the change first
+				return ErrRollbackUnavailable
+			}
+			return err
+		}
+		for _, i := range positions {
+			original.Changes[i].RevertedBy = entry.ID
+		}
+		if original.RollbackAvailable() || original.EntityType != domain.AuditEntityPlanProposal {
+			return nil
+		}
+		return s.markProposalRolledBack(ctx, tx, original)
+	})
+	if errors.Is(err, domain.ErrPreconditionFailed) {
+		// An event changed between the checks above and the write
+		for _, i := range positions {
+			original.Changes[i].RevertedBy = 0
+		}
+		return result, ErrRollbackConflict
+	}
+	if err != nil {
+		for _, i := range positions {
+			original.Changes[i].RevertedBy = 0
+		}
+		return nil, err
+	}
+
+	result.Entry = entry
+	slog.Info("planner.audit.rolled_back", "user_id", user.ID, "audit_log_entry_id", original.ID,
+		"rollback_audit_log_entry_id", entry.ID, "reverted", len(entry.Changes), "conflicts", len(result.Conflicts))
+	return result, nil
+}
+
+// markProposalRolledBack moves the applied proposal behind entry to
+// rolled_back.
+func (s *RollbackService) markProposalRolledBack(ctx context.Context, tx *sql.Tx, entry *domain.AuditLogEntry) error {
+	proposalID, err := strconv.ParseInt(entry.EntityID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	proposals := s.proposalRepo.WithTx(tx)
+	proposal, err := proposals.GetByID(ctx, proposalID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	proposal.Status = domain.ProposalRolledBack
+	proposal.StatusReason = ""
+	if err := proposals.Update(ctx, proposal, domain.ProposalApplied); err != nil && !errors.Is(err, domain.ErrConflict) {
+		return err
+	}
+	return nil
+}
+
+// rollbackConflict returns why change can no longer be reverted given
+// current, the event as it is now (nil if there is none), or "" if it can.
+func rollbackConflict(change domain.AuditChange, current *domain.Event) string {
+	switch {
+	case change.AfterETag == "" && current != nil:
+		// The change deleted the event and something created it again
+		return RollbackConflictEdited
+	case change.AfterETag == "":
+		return ""
+	case current == nil:
+		return RollbackConflictDeleted
+	case current.ETag != change.AfterETag:
+		return RollbackConflictEdited
+	}
+	return ""
+}
+
+// revertSummary describes the revert of change for the audit log.
+func revertSummary(change domain.AuditChange) string {
+	if change.BeforeICS == "" {
+		return fmt.Sprintf("Removed: %s", change.Summary)
+	}
+	return fmt.Sprintf("Reverted: %s", change.Summary)
+}
@@ -0,0 +1,154 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/caldav"
+	"github.com/airplne/calendar-app/server/internal/data"
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func newRollbackService(f *plannerFixture) *RollbackService {
+	backend := caldav.NewBackend(f.db, data.NewSQLiteUserRepo(f.db), f.calendars, f.events)
+	return NewRollbackService(f.events, f.proposals, data.NewSQLiteAuditLogRepo(f.db), backend)
+}
+
+func TestRollbackService_Rollback(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	proposals, apply := newApplyService(f)
+	rollback := newRollbackService(f)
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	standup := f.putEvent(t, "standup", start, start.Add(time.Hour))
+	proposal, err := proposals.Create(ctx, f.user, "Protect the afternoon", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(6 * time.Hour), End: start.Add(8 * time.Hour)},
+		{Type: domain.ChangeMoveEvent, CalendarID: f.calendar.ID, UID: "standup", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	applied, err := apply.Apply(ctx, f.user, proposal.ID)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	before, err := f.calendars.GetByID(ctx, f.calendar.ID)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+	changesBefore, _ := f.calendars.ListChangesSince(ctx, f.calendar.ID, 0)
+
+	other := &domain.User{ID: f.user.ID + 1}
+	if _, err := rollback.Rollback(ctx, other, applied.Entry.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("other user's Rollback error = %v, want ErrNotFound", err)
+	}
+
+	rolledBack, err := rollback.Rollback(ctx, f.user, applied.Entry.ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if rolledBack.Entry == nil || len(rolledBack.Entry.Changes) != 2 || len(rolledBack.Conflicts) != 0 || rolledBack.Original.RollbackAvailable() {
+		t.Fatalf("Rollback = %+v", rolledBack)
+	}
+	if _, err := f.events.GetByUID(ctx, f.calendar.ID, proposal.Changes[0].UID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("focus block not deleted: %v", err)
+	}
+	restored, err := f.events.GetByUID(ctx, f.calendar.ID, "standup")
+	if err != nil {
+		t.Fatalf("get standup: %v", err)
+	}
+	if !restored.StartTime.Equal(standup.StartTime) || !restored.EndTime.Equal(standup.EndTime) || !strings.Contains(restored.ICS, "SEQUENCE:2") {
+		t.Errorf("standup = %s", restored.ICS)
+	}
+
+	// The rollback is audited and bumped the sync token for both events
+	entry, err := data.NewSQLiteAuditLogRepo(f.db).GetByID(ctx, rolledBack.Entry.ID)
+	if err != nil {
+		t.Fatalf("get rollback entry: %v", err)
+	}
+	if entry.Action != domain.AuditPlanRollback || entry.EntityType != domain.AuditEntityAuditLog || entry.RollbackAvailable() ||
+		entry.Changes[0].AfterICS != "" || entry.Changes[1].AfterETag != restored.ETag {
+		t.Errorf("rollback entry = %+v", entry)
+	}
+	after, err := f.calendars.GetByID(ctx, f.calendar.ID)
+	if err != nil {
+		t.Fatalf("get calendar: %v", err)
+	}
+	changesAfter, _ := f.calendars.ListChangesSince(ctx, f.calendar.ID, 0)
+	if after.SyncToken == before.SyncToken || len(changesAfter) != len(changesBefore)+2 {
+		t.Errorf("changes %d -> %d, sync token %s -> %s", len(changesBefore), len(changesAfter), before.SyncToken, after.SyncToken)
+	}
+
+	stored, err := proposals.Get(ctx, f.user.ID, proposal.ID)
+	if err != nil || stored.Status != domain.ProposalRolledBack {
+		t.Fatalf("proposal = %+v, %v; want rolled_back", stored, err)
+	}
+	if _, err := rollback.Rollback(ctx, f.user, applied.Entry.ID); !errors.Is(err, ErrRollbackUnavailable) {
+		t.Fatalf("second Rollback error = %v, want ErrRollbackUnavailable", err)
+	}
+	if _, err := rollback.Rollback(ctx, f.user, rolledBack.Entry.ID); !errors.Is(err, ErrRollbackUnavailable) {
+		t.Fatalf("Rollback of a rollback error = %v, want ErrRollbackUnavailable", err)
+	}
+}
+
+func TestRollbackService_RollbackConflict(t *testing.T) {
+	ctx := context.Background()
+	f := newPlannerFixture(t)
+	proposals, apply := newApplyService(f)
+	rollback := newRollbackService(f)
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	f.putEvent(t, "standup", start, start.Add(time.Hour))
+	proposal, err := proposals.Create(ctx, f.user, "", []domain.ProposalChange{
+		{Type: domain.ChangeCreateFocusBlock, CalendarID: f.calendar.ID, Start: start.Add(6 * time.Hour), End: start.Add(8 * time.Hour)},
+		{Type: domain.ChangeShortenEvent, CalendarID: f.calendar.ID, UID: "standup", End: start.Add(30 * time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	applied, err := apply.Apply(ctx, f.user, proposal.ID)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// A CalDAV client edits the shortened standup after the apply
+	standup, err := f.events.GetByUID(ctx, f.calendar.ID, "standup")
+	if err != nil {
+		t.Fatalf("get standup: %v", err)
+	}
+	oldETag := standup.ETag
+	standup.ICS = strings.Replace(standup.ICS, "SUMMARY:standup", "SUMMARY:Daily standup", 1)
+	standup.ETag = domain.GenerateETag([]byte(standup.ICS))
+	if err := f.events.Update(ctx, standup, oldETag); err != nil {
+		t.Fatalf("update standup: %v", err)
+	}
+
+	rolledBack, err := rollback.Rollback(ctx, f.user, applied.Entry.ID)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if rolledBack.Entry == nil || len(rolledBack.Entry.Changes) != 1 || len(rolledBack.Conflicts) != 1 ||
+		rolledBack.Conflicts[0].UID != "standup" || rolledBack.Conflicts[0].Reason != RollbackConflictEdited {
+		t.Fatalf("Rollback = %+v", rolledBack)
+	}
+	kept, err := f.events.GetByUID(ctx, f.calendar.ID, "standup")
+	if err != nil || kept.ETag != standup.ETag {
+		t.Fatalf("client edit clobbered: %+v, %v", kept, err)
+	}
+	if _, err := f.events.GetByUID(ctx, f.calendar.ID, proposal.Changes[0].UID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("focus block not deleted: %v", err)
+	}
+
+	// The partly rolled back proposal stays applied, and only conflicts remain
+	if stored, err := proposals.Get(ctx, f.user.ID, proposal.ID); err != nil || stored.Status != domain.ProposalApplied {
+		t.Fatalf("proposal = %+v, %v; want applied", stored, err)
+	}
+	rolledBack, err = rollback.Rollback(ctx, f.user, applied.Entry.ID)
+	if !errors.Is(err, ErrRollbackConflict) || len(rolledBack.Conflicts) != 1 || rolledBack.Entry != nil {
+		t.Fatalf("second Rollback = %+v, %v; want ErrRollbackConflict", rolledBack, err)
+	}
+}
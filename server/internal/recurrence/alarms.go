@@ -0,0 +1,201 @@
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// ical.PropAcknowledged is not defined by go-ical (RFC 9074 §6).
+const propAcknowledged = "ACKNOWLEDGED"
+
+// Alarm is a VALARM (RFC 5545 §3.6.6) of one component with its trigger
+// parsed. A relative trigger applies to every instance the component
+// describes.
+type Alarm struct {
+	Key          string // VALARM UID (RFC 9074), or its position within the component
+	Action       string // DISPLAY, AUDIO, EMAIL, ...
+	Description  string
+	Related      string        // START or END; empty for absolute triggers
+	Offset       time.Duration // Relative to the instance start or end
+	Absolute     time.Time     // Set for TRIGGER;VALUE=DATE-TIME
+	Repeat       int           // Additional repetitions after the first trigger
+	Interval     time.Duration // Time between repetitions
+	Acknowledged time.Time     // ACKNOWLEDGED (RFC 9074): triggers up to this time were dismissed
+	Component    *ical.Component
+}
+
+// ComponentAlarms returns the alarms of a VEVENT or VTODO. Alarms without a
+// usable TRIGGER are skipped. Keys of alarms without a UID are their position
+// among the component's alarms, prefixed with the RECURRENCE-ID for overrides,
+// so they stay stable while the object is edited elsewhere.
+func ComponentAlarms(comp *ical.Component) []Alarm {
+	prefix := ""
+	if rid := comp.Props.Get(ical.PropRecurrenceID); rid != nil {
+		prefix = rid.Value + "/"
+	}
+
+	var alarms []Alarm
+	index := 0
+	for _, child := range comp.Children {
+		if child.Name != ical.CompAlarm {
+			continue
+		}
+		index++
+		alarm, ok := parseAlarm(child)
+		if !ok {
+			continue
+		}
+		alarm.Key = prefix + strconv.Itoa(index)
+		if uid := child.Props.Get(ical.PropUID); uid != nil && uid.Value != "" {
+			alarm.Key = uid.Value
+		}
+		alarms = append(alarms, alarm)
+	}
+	return alarms
+}
+
+func parseAlarm(comp *ical.Component) (Alarm, bool) {
+	trigger := comp.Props.Get(ical.PropTrigger)
+	if trigger == nil {
+		return Alarm{}, false
+	}
+	alarm := Alarm{Component: comp}
+	if action := comp.Props.Get(ical.PropAction); action != nil {
+		alarm.Action = strings.ToUpper(action.Value)
+	}
+	if description := comp.Props.Get(ical.PropDescription); description != nil {
+		alarm.Description = description.Value
+	}
+
+	if trigger.ValueType() == ical.ValueDateTime {
+		t, err := trigger.DateTime(time.UTC)
+		if err != nil {
+			return Alarm{}, false
+		}
+		alarm.Absolute = t
+	} else {
+		offset, err := trigger.Duration()
+		if err != nil {
+			return Alarm{}, false
+		}
+		alarm.Offset = offset
+		alarm.Related = "START"
+		if strings.EqualFold(trigger.Params.Get(ical.ParamRelated), "END") {
+			alarm.Related = "END"
+		}
+	}
+
+	if repeat := comp.Props.Get(ical.PropRepeat); repeat != nil {
+		if n, err := repeat.Int(); err == nil && n > 0 {
+			if duration := comp.Props.Get(ical.PropDuration); duration != nil {
+				if interval, err := duration.Duration(); err == nil && interval > 0 {
+					alarm.Repeat, alarm.Interval = n, interval
+				}
+			}
+		}
+	}
+	if ack := comp.Props.Get(propAcknowledged); ack != nil {
+		if t, err := ack.DateTime(time.UTC); err == nil {
+			alarm.Acknowledged = t
+		}
+	}
+	return alarm, true
+}
+
+// Trigger is one alarm of one instance coming due.
+type Trigger struct {
+	Alarm      Alarm
+	Instance   Instance
+	Occurrence time.Time // RECURRENCE-ID of the instance, or its start when not recurring
+	At         time.Time
+}
+
+// triggerMargin widens expansion so instances whose alarms fire well before
+// or after them are found.
+const triggerMargin = 7 * 24 * time.Hour
+
+// Triggers returns the alarm triggers of components named compName in cal
+// that fall in [start, end), including repetitions, in time order. Cancelled
+// instances and triggers at or before an alarm's ACKNOWLEDGED time are left
+// out. An absolute trigger fires once, with the first instance.
+func Triggers(cal *ical.Calendar, compName string, start, end time.Time, loc *time.Location) ([]Trigger, error) {
+	masters, overrides := splitComponents(cal, compName)
+	margin := triggerMargin
+	for _, comp := range append(masters, overrides...) {
+		for _, alarm := range ComponentAlarms(comp) {
+			reach := alarm.Offset + time.Duration(alarm.Repeat)*alarm.Interval
+			if reach < 0 {
+				reach = -reach
+			}
+			if reach+triggerMargin > margin {
+				margin = reach + triggerMargin
+			}
+		}
+	}
+
+	instances, err := Expand(cal, compName, start.Add(-margin), end.Add(margin), loc)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(instances, func(i, j int) bool { return instances[i].Start.Before(instances[j].Start) })
+
+	var triggers []Trigger
+	absoluteSeen := make(map[*ical.Component]bool)
+	for _, inst := range instances {
+		if status := inst.Component.Props.Get(ical.PropStatus); status != nil && strings.EqualFold(status.Value, "CANCELLED") {
+			continue
+		}
+		occurrence := inst.RecurrenceID
+		if occurrence.IsZero() {
+			occurrence = inst.Start
+		}
+		for _, alarm := range ComponentAlarms(inst.Component) {
+			first := alarm.Absolute
+			if first.IsZero() {
+				first = inst.Start.Add(alarm.Offset)
+				if alarm.Related == "END" {
+					first = inst.End.Add(alarm.Offset)
+				}
+			} else if absoluteSeen[alarm.Component] {
+				continue
+			} else {
+				absoluteSeen[alarm.Component] = true
+			}
+			for n := 0; n <= alarm.Repeat; n++ {
+				at := first.Add(time.Duration(n) * alarm.Interval)
+				if at.Before(start) || !at.Before(end) || (!alarm.Acknowledged.IsZero() && !at.After(alarm.Acknowledged)) {
+					continue
+				}
+				triggers = append(triggers, Trigger{Alarm: alarm, Instance: inst, Occurrence: occurrence, At: at})
+			}
+		}
+	}
+	sort.SliceStable(triggers, func(i, j int) bool { return triggers[i].At.Before(triggers[j].At) })
+	return triggers, nil
+}
+
+// Acknowledge sets ACKNOWLEDGED on the alarm with the given key in cal
+// (RFC 9074 §6). It reports an error when no component has such an alarm.
+func Acknowledge(cal *ical.Calendar, compName, key string, at time.Time) error {
+	for _, comp := range cal.Children {
+		if comp.Name != compName {
+			continue
+		}
+		for _, alarm := range ComponentAlarms(comp) {
+			if alarm.Key != key {
+				continue
+			}
+			prop := ical.NewProp(propAcknowledged)
+			prop.SetDateTime(at.UTC())
+			prop.Params.Del(ical.ParamValue) // DATE-TIME is the default
+			alarm.Component.Props.Set(prop)
+			return nil
+		}
+	}
+	return fmt.Errorf("recurrence: no alarm %q", key)
+}
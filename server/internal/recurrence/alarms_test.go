@@ -0,0 +1,118 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+const standupAlarm = "BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Standup soon\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\n"
+
+func withAlarm(component, alarm string) string {
+	return component[:len(component)-len("END:VEVENT\r\n")] + alarm + "END:VEVENT\r\n"
+}
+
+func TestComponentAlarms(t *testing.T) {
+	ics := calendar("BEGIN:VEVENT\r\nUID:review\r\nDTSTAMP:20260101T000000Z\r\nDTSTART:20260105T090000Z\r\nDTEND:20260105T100000Z\r\n" +
+		"BEGIN:VALARM\r\nUID:alarm-1\r\nACTION:EMAIL\r\nTRIGGER;RELATED=END:PT5M\r\nREPEAT:2\r\nDURATION:PT10M\r\nEND:VALARM\r\n" +
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER;VALUE=DATE-TIME:20260104T180000Z\r\nEND:VALARM\r\n" +
+		"BEGIN:VALARM\r\nACTION:AUDIO\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\n")
+	cal := decodeCalendar(t, ics)
+
+	alarms := ComponentAlarms(cal.Children[0])
+	if len(alarms) != 2 {
+		t.Fatalf("Expected 2 alarms (one without TRIGGER skipped), got %d", len(alarms))
+	}
+	first := alarms[0]
+	if first.Key != "alarm-1" || first.Action != "EMAIL" || first.Related != "END" || first.Offset != 5*time.Minute {
+		t.Errorf("Unexpected first alarm: %+v", first)
+	}
+	if first.Repeat != 2 || first.Interval != 10*time.Minute {
+		t.Errorf("Expected REPEAT 2 every 10m, got %d every %v", first.Repeat, first.Interval)
+	}
+	if second := alarms[1]; second.Key != "2" || !second.Absolute.Equal(date("20260104T180000Z")) {
+		t.Errorf("Unexpected second alarm: %+v", second)
+	}
+}
+
+func TestTriggers_RecurringRelative(t *testing.T) {
+	cal := decodeCalendar(t, calendar(withAlarm(weeklyStandup, standupAlarm), withAlarm(movedStandup, standupAlarm)))
+
+	triggers, err := Triggers(cal, ical.CompEvent, date("20260101T000000Z"), date("20260201T000000Z"), nil)
+	if err != nil {
+		t.Fatalf("Triggers failed: %v", err)
+	}
+	// Jan 5 and 12; Jan 19 is excluded and Jan 26 moved to the 27th.
+	want := []time.Time{date("20260105T084500Z"), date("20260112T084500Z"), date("20260127T134500Z")}
+	if len(triggers) != len(want) {
+		t.Fatalf("Expected %d triggers, got %d", len(want), len(triggers))
+	}
+	for i, trigger := range triggers {
+		if !trigger.At.Equal(want[i]) {
+			t.Errorf("Trigger %d: expected %v, got %v", i, want[i], trigger.At)
+		}
+	}
+	if got := triggers[2]; !got.Occurrence.Equal(date("20260126T090000Z")) || got.Alarm.Key != "20260126T090000Z/1" {
+		t.Errorf("Expected the moved instance's own alarm, got occurrence %v key %q", got.Occurrence, got.Alarm.Key)
+	}
+}
+
+func TestTriggers_AbsoluteRepeatAndAcknowledged(t *testing.T) {
+	ics := calendar("BEGIN:VEVENT\r\nUID:launch\r\nDTSTAMP:20260101T000000Z\r\nDTSTART:20260110T090000Z\r\nDTEND:20260110T100000Z\r\n" +
+		"RRULE:FREQ=DAILY;COUNT=3\r\n" +
+		"BEGIN:VALARM\r\nUID:once\r\nACTION:DISPLAY\r\nTRIGGER;VALUE=DATE-TIME:20260109T120000Z\r\nREPEAT:1\r\nDURATION:PT1H\r\nEND:VALARM\r\n" +
+		"BEGIN:VALARM\r\nUID:daily\r\nACTION:DISPLAY\r\nTRIGGER:-PT1H\r\nACKNOWLEDGED:20260110T080000Z\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\n")
+	cal := decodeCalendar(t, ics)
+
+	triggers, err := Triggers(cal, ical.CompEvent, date("20260101T000000Z"), date("20260201T000000Z"), nil)
+	if err != nil {
+		t.Fatalf("Triggers failed: %v", err)
+	}
+	var got []string
+	for _, trigger := range triggers {
+		got = append(got, trigger.Alarm.Key+"@"+trigger.At.Format("02T15"))
+	}
+	want := []string{"once@09T12", "once@09T13", "daily@11T08", "daily@12T08"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestTriggers_SkipsCancelledInstances(t *testing.T) {
+	cancelled := "BEGIN:VEVENT\r\nUID:standup\r\nDTSTAMP:20260101T000000Z\r\nRECURRENCE-ID:20260112T090000Z\r\n" +
+		"DTSTART:20260112T090000Z\r\nDTEND:20260112T093000Z\r\nSTATUS:CANCELLED\r\n" + standupAlarm + "END:VEVENT\r\n"
+	cal := decodeCalendar(t, calendar(withAlarm(weeklyStandup, standupAlarm), cancelled))
+
+	triggers, err := Triggers(cal, ical.CompEvent, date("20260110T000000Z"), date("20260115T000000Z"), nil)
+	if err != nil {
+		t.Fatalf("Triggers failed: %v", err)
+	}
+	if len(triggers) != 0 {
+		t.Errorf("Expected no triggers for a cancelled instance, got %d", len(triggers))
+	}
+}
+
+func TestAcknowledge(t *testing.T) {
+	cal := decodeCalendar(t, calendar(withAlarm(weeklyStandup, standupAlarm)))
+	at := date("20260105T084600Z")
+
+	if err := Acknowledge(cal, ical.CompEvent, "1", at); err != nil {
+		t.Fatalf("Acknowledge failed: %v", err)
+	}
+	alarms := ComponentAlarms(cal.Children[0])
+	if !alarms[0].Acknowledged.Equal(at) {
+		t.Errorf("Expected ACKNOWLEDGED %v, got %v", at, alarms[0].Acknowledged)
+	}
+	if err := Acknowledge(cal, ical.CompEvent, "missing", at); err == nil {
+		t.Error("Expected an error for an unknown alarm")
+	}
+}
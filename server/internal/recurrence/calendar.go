@@ -0,0 +1,105 @@
+package recurrence
+
+import (
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// ExpandCalendar rewrites cal per RFC 4791 §9.6.5 (CALDAV:expand): every
+// instance of compName overlapping [start, end) becomes a standalone component
+// with a RECURRENCE-ID, recurrence properties are removed and date-times are
+// converted to UTC. VTIMEZONE components are dropped since no TZIDs remain.
+func ExpandCalendar(cal *ical.Calendar, compName string, start, end time.Time) (*ical.Calendar, error) {
+	instances, err := Expand(cal, compName, start, end, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ical.Calendar{Component: &ical.Component{Name: cal.Name, Props: cal.Props}}
+	for _, child := range cal.Children {
+		if child.Name != compName && child.Name != ical.CompTimezone {
+			out.Children = append(out.Children, child)
+		}
+	}
+
+	for _, inst := range instances {
+		comp := &ical.Component{
+			Name:     inst.Component.Name,
+			Props:    make(ical.Props, len(inst.Component.Props)),
+			Children: inst.Component.Children,
+		}
+		for name, values := range inst.Component.Props {
+			comp.Props[name] = values
+		}
+		comp.Props.Del(ical.PropRecurrenceRule)
+		comp.Props.Del(ical.PropRecurrenceDates)
+		comp.Props.Del(ical.PropExceptionDates)
+		comp.Props.Del(ical.PropDuration)
+
+		setInstanceTime(comp.Props, ical.PropDateTimeStart, inst.Start, inst.AllDay)
+		endName := ical.PropDateTimeEnd
+		if comp.Name == ical.CompToDo {
+			endName = ical.PropDue
+		}
+		if !inst.End.Equal(inst.Start) || comp.Props.Get(endName) != nil {
+			setInstanceTime(comp.Props, endName, inst.End, inst.AllDay)
+		}
+		if !inst.RecurrenceID.IsZero() {
+			setInstanceTime(comp.Props, ical.PropRecurrenceID, inst.RecurrenceID, inst.AllDay)
+		}
+		out.Children = append(out.Children, comp)
+	}
+
+	return out, nil
+}
+
+// LimitRecurrenceSet rewrites cal per RFC 4791 §9.6.6
+// (CALDAV:limit-recurrence-set): master components are kept, but overrides
+// whose instance does not overlap [start, end) are removed.
+func LimitRecurrenceSet(cal *ical.Calendar, compName string, start, end time.Time) (*ical.Calendar, error) {
+	_, overrides := splitComponents(cal, compName)
+	keep := make(map[*ical.Component]bool, len(overrides))
+	for _, override := range overrides {
+		inst, err := singleInstance(override, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		ridProp := override.Props.Get(ical.PropRecurrenceID)
+		recurrenceID, err := parseTime(ridProp, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		// THISANDFUTURE overrides shape every later instance.
+		if strings.EqualFold(ridProp.Params.Get(ical.ParamRange), "THISANDFUTURE") && (end.IsZero() || recurrenceID.Before(end)) {
+			keep[override] = true
+			continue
+		}
+		// An override is relevant if either its original or its moved slot
+		// falls in the range.
+		originalEnd := recurrenceID.Add(inst.End.Sub(inst.Start))
+		if Overlaps(inst.Start, inst.End, start, end) || Overlaps(recurrenceID, originalEnd, start, end) {
+			keep[override] = true
+		}
+	}
+
+	out := &ical.Calendar{Component: &ical.Component{Name: cal.Name, Props: cal.Props}}
+	for _, child := range cal.Children {
+		if child.Name == compName && child.Props.Get(ical.PropRecurrenceID) != nil && !keep[child] {
+			continue
+		}
+		out.Children = append(out.Children, child)
+	}
+	return out, nil
+}
+
+func setInstanceTime(props ical.Props, name string, t time.Time, allDay bool) {
+	prop := ical.NewProp(name)
+	if allDay {
+		prop.SetDate(t)
+	} else {
+		prop.SetDateTime(t.UTC())
+	}
+	props.Set(prop)
+}
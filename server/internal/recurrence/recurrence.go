@@ -0,0 +1,407 @@
+// Package recurrence expands iCalendar recurrence sets (RRULE, RDATE, EXDATE
+// and RECURRENCE-ID overrides) into concrete instances. It backs CalDAV
+// time-range matching and any feature that needs to reason about when a
+// recurring event actually happens.
+package recurrence
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/teambition/rrule-go"
+)
+
+// MaxInstances bounds expansion of unbounded rules so a single object cannot
+// make a query run away.
+const MaxInstances = 5000
+
+// Instance is one occurrence of a (possibly recurring) component.
+type Instance struct {
+	RecurrenceID time.Time // Original start of the instance; zero for non-recurring components
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Component    *ical.Component // Master or overriding component this instance came from
+	Override     bool            // True when Component carries a RECURRENCE-ID
+}
+
+// Expand returns the instances of components named compName (VEVENT or VTODO)
+// in cal that overlap [start, end). A zero start or end leaves that side of the
+// range open. Floating and all-day times are interpreted in loc (UTC if nil).
+// Instances are returned in start order per master component.
+func Expand(cal *ical.Calendar, compName string, start, end time.Time, loc *time.Location) ([]Instance, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	masters, overrides := splitComponents(cal, compName)
+
+	var instances []Instance
+	overridden := make(map[string]map[int64]bool)
+	futures := make(map[string][]futureOverride)
+	for _, override := range overrides {
+		inst, err := singleInstance(override, loc)
+		if err != nil {
+			return nil, err
+		}
+		ridProp := override.Props.Get(ical.PropRecurrenceID)
+		recurrenceID, err := parseTime(ridProp, loc)
+		if err != nil {
+			return nil, fmt.Errorf("recurrence: invalid RECURRENCE-ID: %w", err)
+		}
+		inst.RecurrenceID = recurrenceID
+		inst.Override = true
+
+		uid := componentUID(override)
+		if overridden[uid] == nil {
+			overridden[uid] = make(map[int64]bool)
+		}
+		overridden[uid][recurrenceID.Unix()] = true
+		if strings.EqualFold(ridProp.Params.Get(ical.ParamRange), "THISANDFUTURE") {
+			futures[uid] = append(futures[uid], futureOverride{
+				recurrenceID: recurrenceID,
+				offset:       inst.Start.Sub(recurrenceID),
+				duration:     inst.End.Sub(inst.Start),
+				component:    override,
+			})
+		}
+
+		if Overlaps(inst.Start, inst.End, start, end) {
+			instances = append(instances, inst)
+		}
+	}
+
+	for _, master := range masters {
+		uid := componentUID(master)
+		from, to := widenRange(start, end, futures[uid])
+		masterInstances, err := expandMaster(master, from, to, loc)
+		if err != nil {
+			return nil, err
+		}
+		skip := overridden[uid]
+		for _, inst := range masterInstances {
+			if skip != nil && skip[inst.RecurrenceID.Unix()] {
+				continue
+			}
+			if f := latestFuture(futures[uid], inst.RecurrenceID); f != nil {
+				inst.Start = inst.RecurrenceID.Add(f.offset)
+				inst.End = inst.Start.Add(f.duration)
+				inst.Component = f.component
+				inst.Override = true
+			}
+			if Overlaps(inst.Start, inst.End, start, end) {
+				instances = append(instances, inst)
+			}
+		}
+	}
+
+	return instances, nil
+}
+
+// futureOverride is a RECURRENCE-ID;RANGE=THISANDFUTURE override: later
+// instances are shifted by offset and take its duration and properties.
+type futureOverride struct {
+	recurrenceID time.Time
+	offset       time.Duration
+	duration     time.Duration
+	component    *ical.Component
+}
+
+// latestFuture returns the THISANDFUTURE override that governs the instance
+// originally starting at recurrenceID, if any.
+func latestFuture(futures []futureOverride, recurrenceID time.Time) *futureOverride {
+	var latest *futureOverride
+	for i := range futures {
+		f := &futures[i]
+		if f.recurrenceID.Before(recurrenceID) && (latest == nil || f.recurrenceID.After(latest.recurrenceID)) {
+			latest = f
+		}
+	}
+	return latest
+}
+
+// widenRange grows [start, end) so instances that THISANDFUTURE overrides
+// shift or stretch into the range are still generated.
+func widenRange(start, end time.Time, futures []futureOverride) (time.Time, time.Time) {
+	var widen time.Duration
+	for _, f := range futures {
+		shift := f.offset
+		if shift < 0 {
+			shift = -shift
+		}
+		shift += f.duration
+		if shift > widen {
+			widen = shift
+		}
+	}
+	if widen == 0 {
+		return start, end
+	}
+	if !start.IsZero() {
+		start = start.Add(-widen)
+	}
+	if !end.IsZero() {
+		end = end.Add(widen)
+	}
+	return start, end
+}
+
+// Overlaps reports whether an instance spanning [instStart, instEnd) overlaps
+// the range [start, end) per RFC 4791 §9.9. Zero-duration instances overlap when
+// they start inside the range. A zero start or end leaves that side open.
+func Overlaps(instStart, instEnd, start, end time.Time) bool {
+	if !end.IsZero() && !instStart.Before(end) {
+		return false
+	}
+	if start.IsZero() {
+		return true
+	}
+	if instEnd.After(instStart) {
+		return instEnd.After(start)
+	}
+	return !instStart.Before(start)
+}
+
+// IsRecurring reports whether comp defines a recurrence set.
+func IsRecurring(comp *ical.Component) bool {
+	return comp.Props.Get(ical.PropRecurrenceRule) != nil || comp.Props.Get(ical.PropRecurrenceDates) != nil
+}
+
+// splitComponents separates master components from RECURRENCE-ID overrides.
+func splitComponents(cal *ical.Calendar, compName string) (masters, overrides []*ical.Component) {
+	if cal == nil || cal.Component == nil {
+		return nil, nil
+	}
+	for _, child := range cal.Children {
+		if child.Name != compName {
+			continue
+		}
+		if child.Props.Get(ical.PropRecurrenceID) != nil {
+			overrides = append(overrides, child)
+		} else {
+			masters = append(masters, child)
+		}
+	}
+	return masters, overrides
+}
+
+func componentUID(comp *ical.Component) string {
+	if prop := comp.Props.Get(ical.PropUID); prop != nil {
+		return prop.Value
+	}
+	return ""
+}
+
+// expandMaster expands a master component's recurrence set within the range.
+func expandMaster(master *ical.Component, start, end time.Time, loc *time.Location) ([]Instance, error) {
+	first, err := singleInstance(master, loc)
+	if err != nil {
+		return nil, err
+	}
+	if !IsRecurring(master) {
+		if Overlaps(first.Start, first.End, start, end) {
+			return []Instance{first}, nil
+		}
+		return nil, nil
+	}
+
+	set, err := recurrenceSet(master, first.Start, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := first.End.Sub(first.Start)
+	var starts []time.Time
+	if end.IsZero() {
+		// Open-ended range: walk forward from the first candidate.
+		from := first.Start
+		if !start.IsZero() && start.Add(-duration).After(from) {
+			from = start.Add(-duration)
+		}
+		next := set.Iterator()
+		for len(starts) < MaxInstances {
+			t, ok := next()
+			if !ok {
+				break
+			}
+			if t.Before(from) {
+				continue
+			}
+			starts = append(starts, t)
+		}
+	} else {
+		from := first.Start
+		if !start.IsZero() {
+			from = start.Add(-duration)
+		}
+		starts = set.Between(from, end, true)
+		if len(starts) > MaxInstances {
+			starts = starts[:MaxInstances]
+		}
+	}
+
+	instances := make([]Instance, 0, len(starts))
+	for _, s := range starts {
+		inst := Instance{
+			RecurrenceID: s,
+			Start:        s,
+			End:          s.Add(duration),
+			AllDay:       first.AllDay,
+			Component:    master,
+		}
+		if Overlaps(inst.Start, inst.End, start, end) {
+			instances = append(instances, inst)
+		}
+	}
+	return instances, nil
+}
+
+// recurrenceSet builds the rrule set for a master component.
+func recurrenceSet(master *ical.Component, dtStart time.Time, loc *time.Location) (*rrule.Set, error) {
+	set := &rrule.Set{}
+	set.DTStart(dtStart)
+
+	if prop := master.Props.Get(ical.PropRecurrenceRule); prop != nil {
+		option, err := rrule.StrToROptionInLocation(prop.Value, dtStart.Location())
+		if err != nil {
+			return nil, fmt.Errorf("recurrence: invalid RRULE: %w", err)
+		}
+		option.Dtstart = dtStart
+		rule, err := rrule.NewRRule(*option)
+		if err != nil {
+			return nil, fmt.Errorf("recurrence: invalid RRULE: %w", err)
+		}
+		set.RRule(rule)
+	} else {
+		// RDATE-only sets still include DTSTART as the first instance.
+		set.RDate(dtStart)
+	}
+
+	for _, prop := range master.Props.Values(ical.PropRecurrenceDates) {
+		dates, err := parseTimeList(&prop, dtStart.Location())
+		if err != nil {
+			return nil, fmt.Errorf("recurrence: invalid RDATE: %w", err)
+		}
+		for _, d := range dates {
+			set.RDate(d)
+		}
+	}
+	for _, prop := range master.Props.Values(ical.PropExceptionDates) {
+		dates, err := parseTimeList(&prop, dtStart.Location())
+		if err != nil {
+			return nil, fmt.Errorf("recurrence: invalid EXDATE: %w", err)
+		}
+		for _, d := range dates {
+			set.ExDate(d)
+		}
+	}
+
+	return set, nil
+}
+
+// singleInstance computes the first (or only) instance of a component from its
+// DTSTART and DTEND/DUE/DURATION.
+func singleInstance(comp *ical.Component, loc *time.Location) (Instance, error) {
+	startProp := comp.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		// VTODOs may only carry DUE; treat it as a zero-length instance.
+		if due := comp.Props.Get(ical.PropDue); due != nil {
+			t, err := parseTime(due, loc)
+			if err != nil {
+				return Instance{}, fmt.Errorf("recurrence: invalid DUE: %w", err)
+			}
+			return Instance{Start: t, End: t, Component: comp}, nil
+		}
+		return Instance{}, fmt.Errorf("recurrence: %s has no DTSTART", comp.Name)
+	}
+
+	start, err := parseTime(startProp, loc)
+	if err != nil {
+		return Instance{}, fmt.Errorf("recurrence: invalid DTSTART: %w", err)
+	}
+	allDay := isDate(startProp)
+
+	end := start
+	switch {
+	case comp.Props.Get(ical.PropDateTimeEnd) != nil:
+		end, err = parseTime(comp.Props.Get(ical.PropDateTimeEnd), loc)
+		if err != nil {
+			return Instance{}, fmt.Errorf("recurrence: invalid DTEND: %w", err)
+		}
+	case comp.Props.Get(ical.PropDue) != nil:
+		end, err = parseTime(comp.Props.Get(ical.PropDue), loc)
+		if err != nil {
+			return Instance{}, fmt.Errorf("recurrence: invalid DUE: %w", err)
+		}
+	case comp.Props.Get(ical.PropDuration) != nil:
+		duration, err := comp.Props.Get(ical.PropDuration).Duration()
+		if err != nil {
+			return Instance{}, fmt.Errorf("recurrence: invalid DURATION: %w", err)
+		}
+		end = start.Add(duration)
+	case allDay:
+		end = start.AddDate(0, 0, 1)
+	}
+	if end.Before(start) {
+		end = start
+	}
+
+	return Instance{Start: start, End: end, AllDay: allDay, Component: comp}, nil
+}
+
+func isDate(prop *ical.Prop) bool {
+	return prop.ValueType() == ical.ValueDate || (prop.ValueType() == ical.ValueDefault && len(prop.Value) == len("20060102"))
+}
+
+// parseTime parses a DATE or DATE-TIME property. Unknown TZIDs (common with
+// Outlook's Windows zone names) fall back to floating time in loc rather than
+// failing the whole object.
+func parseTime(prop *ical.Prop, loc *time.Location) (time.Time, error) {
+	if prop == nil {
+		return time.Time{}, fmt.Errorf("missing value")
+	}
+	t, err := prop.DateTime(loc)
+	if err == nil {
+		return t, nil
+	}
+	if prop.Params.Get(ical.ParamTimezoneID) == "" {
+		return time.Time{}, err
+	}
+	floating := ical.NewProp(prop.Name)
+	floating.Value = prop.Value
+	if valueType := prop.Params.Get(ical.ParamValue); valueType != "" {
+		floating.Params.Set(ical.ParamValue, valueType)
+	}
+	return floating.DateTime(loc)
+}
+
+// parseTimeList parses a comma-separated RDATE/EXDATE value. PERIOD values
+// contribute their start.
+func parseTimeList(prop *ical.Prop, loc *time.Location) ([]time.Time, error) {
+	var times []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if i := strings.Index(value, "/"); i >= 0 {
+			value = value[:i]
+		}
+		single := ical.NewProp(prop.Name)
+		single.Value = value
+		for name, values := range prop.Params {
+			if name == ical.ParamValue && strings.EqualFold(values[0], "PERIOD") {
+				continue
+			}
+			single.Params[name] = values
+		}
+		t, err := parseTime(single, loc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
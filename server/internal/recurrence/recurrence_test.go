@@ -0,0 +1,206 @@
+package recurrence
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+func decodeCalendar(t *testing.T, ics string) *ical.Calendar {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(ics)).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode calendar: %v", err)
+	}
+	return cal
+}
+
+func calendar(components ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Test//EN\r\n" + strings.Join(components, "") + "END:VCALENDAR\r\n"
+}
+
+const weeklyStandup = "BEGIN:VEVENT\r\nUID:standup\r\nDTSTAMP:20260101T000000Z\r\nSUMMARY:Standup\r\n" +
+	"DTSTART:20260105T090000Z\r\nDTEND:20260105T093000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=MO\r\n" +
+	"EXDATE:20260119T090000Z\r\nEND:VEVENT\r\n"
+
+const movedStandup = "BEGIN:VEVENT\r\nUID:standup\r\nDTSTAMP:20260101T000000Z\r\nSUMMARY:Standup (moved)\r\n" +
+	"RECURRENCE-ID:20260126T090000Z\r\nDTSTART:20260127T140000Z\r\nDTEND:20260127T143000Z\r\nEND:VEVENT\r\n"
+
+func date(s string) time.Time {
+	t, err := time.Parse("20060102T150405Z", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestExpand_WeeklyRule(t *testing.T) {
+	cal := decodeCalendar(t, calendar(weeklyStandup))
+
+	// A range months after DTSTART still matches the rule.
+	instances, err := Expand(cal, ical.CompEvent, date("20260601T000000Z"), date("20260608T000000Z"), nil)
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("Expected 1 instance, got %d", len(instances))
+	}
+	if want := date("20260601T090000Z"); !instances[0].Start.Equal(want) {
+		t.Errorf("Expected instance at %v, got %v", want, instances[0].Start)
+	}
+	if got := instances[0].End.Sub(instances[0].Start); got != 30*time.Minute {
+		t.Errorf("Expected 30m duration, got %v", got)
+	}
+}
+
+func TestExpand_ExdateAndOverride(t *testing.T) {
+	cal := decodeCalendar(t, calendar(weeklyStandup, movedStandup))
+
+	instances, err := Expand(cal, ical.CompEvent, date("20260112T000000Z"), date("20260131T000000Z"), nil)
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+
+	var starts []time.Time
+	for _, inst := range instances {
+		starts = append(starts, inst.Start)
+	}
+	// Jan 12 from the rule, Jan 19 excluded, Jan 26 moved to Jan 27.
+	if len(instances) != 2 {
+		t.Fatalf("Expected 2 instances, got %d: %v", len(instances), starts)
+	}
+	var sawOverride bool
+	for _, inst := range instances {
+		if inst.Start.Equal(date("20260119T090000Z")) || inst.Start.Equal(date("20260126T090000Z")) {
+			t.Errorf("Unexpected instance at %v", inst.Start)
+		}
+		if inst.Override {
+			sawOverride = true
+			if !inst.RecurrenceID.Equal(date("20260126T090000Z")) {
+				t.Errorf("Override has RECURRENCE-ID %v", inst.RecurrenceID)
+			}
+		}
+	}
+	if !sawOverride {
+		t.Error("Expected the moved instance to come from the override")
+	}
+}
+
+func TestExpand_AllDayAndOpenRange(t *testing.T) {
+	cal := decodeCalendar(t, calendar("BEGIN:VEVENT\r\nUID:holiday\r\nDTSTAMP:20260101T000000Z\r\n"+
+		"DTSTART;VALUE=DATE:20260101\r\nRRULE:FREQ=YEARLY;COUNT=3\r\nEND:VEVENT\r\n"))
+
+	instances, err := Expand(cal, ical.CompEvent, date("20270101T120000Z"), time.Time{}, nil)
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("Expected 2 instances (2027, 2028), got %d", len(instances))
+	}
+	for _, inst := range instances {
+		if !inst.AllDay {
+			t.Error("Expected all-day instance")
+		}
+		if got := inst.End.Sub(inst.Start); got != 24*time.Hour {
+			t.Errorf("Expected one-day duration, got %v", got)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	start, end := date("20260105T000000Z"), date("20260106T000000Z")
+	tests := []struct {
+		name       string
+		instStart  time.Time
+		instEnd    time.Time
+		start, end time.Time
+		want       bool
+	}{
+		{"inside", date("20260105T090000Z"), date("20260105T100000Z"), start, end, true},
+		{"ends at range start", date("20260104T230000Z"), start, start, end, false},
+		{"starts at range end", end, date("20260106T010000Z"), start, end, false},
+		{"zero duration at start", start, start, start, end, true},
+		{"open end", date("20300101T000000Z"), date("20300101T010000Z"), start, time.Time{}, true},
+		{"open start", date("20000101T000000Z"), date("20000101T010000Z"), time.Time{}, end, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlaps(tt.instStart, tt.instEnd, tt.start, tt.end); got != tt.want {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandCalendar(t *testing.T) {
+	cal := decodeCalendar(t, calendar(weeklyStandup, movedStandup))
+
+	expanded, err := ExpandCalendar(cal, ical.CompEvent, date("20260112T000000Z"), date("20260131T000000Z"))
+	if err != nil {
+		t.Fatalf("ExpandCalendar failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(expanded); err != nil {
+		t.Fatalf("Failed to encode expanded calendar: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "RRULE") || strings.Contains(out, "EXDATE") {
+		t.Errorf("Expanded calendar still carries recurrence properties:\n%s", out)
+	}
+	for _, want := range []string{"RECURRENCE-ID:20260112T090000Z", "RECURRENCE-ID:20260126T090000Z", "DTSTART:20260127T140000Z"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in expanded calendar:\n%s", want, out)
+		}
+	}
+}
+
+func TestLimitRecurrenceSet(t *testing.T) {
+	cal := decodeCalendar(t, calendar(weeklyStandup, movedStandup))
+
+	limited, err := LimitRecurrenceSet(cal, ical.CompEvent, date("20260201T000000Z"), date("20260301T000000Z"))
+	if err != nil {
+		t.Fatalf("LimitRecurrenceSet failed: %v", err)
+	}
+	if len(limited.Children) != 1 {
+		t.Fatalf("Expected only the master to remain, got %d components", len(limited.Children))
+	}
+	if limited.Children[0].Props.Get(ical.PropRecurrenceID) != nil {
+		t.Error("Expected the override to be dropped")
+	}
+}
+
+func TestExpand_ThisAndFuture(t *testing.T) {
+	// From Jan 19 on, the standup moves one hour later and lasts an hour.
+	future := "BEGIN:VEVENT\r\nUID:standup\r\nDTSTAMP:20260101T000000Z\r\nSUMMARY:Standup (later)\r\n" +
+		"RECURRENCE-ID;RANGE=THISANDFUTURE:20260119T090000Z\r\nDTSTART:20260119T100000Z\r\nDTEND:20260119T110000Z\r\nEND:VEVENT\r\n"
+	master := strings.Replace(weeklyStandup, "EXDATE:20260119T090000Z\r\n", "", 1)
+	cal := decodeCalendar(t, calendar(master, future))
+
+	instances, err := Expand(cal, ical.CompEvent, date("20260112T000000Z"), date("20260203T000000Z"), nil)
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+
+	got := map[time.Time]time.Time{}
+	for _, inst := range instances {
+		got[inst.Start] = inst.End
+	}
+	want := map[time.Time]time.Time{
+		date("20260112T090000Z"): date("20260112T093000Z"),
+		date("20260119T100000Z"): date("20260119T110000Z"),
+		date("20260126T100000Z"): date("20260126T110000Z"),
+		date("20260202T100000Z"): date("20260202T110000Z"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d instances, got %d: %v", len(want), len(got), got)
+	}
+	for s, e := range want {
+		if !got[s].Equal(e) {
+			t.Errorf("Instance at %v: expected end %v, got %v", s, e, got[s])
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const (
+	// SessionTTL is how long a web UI login lasts.
+	SessionTTL = 14 * 24 * time.Hour
+
+	// PersonalAccessTokenPrefix marks tokens so they are recognizable in
+	// scripts and secret scanners.
+	PersonalAccessTokenPrefix = "cat_"
+
+	// MaxTokenNameLength bounds the label of a personal access token.
+	MaxTokenNameLength = 64
+
+	// tokenUseResolution limits how often a token's last-used time is written.
+	tokenUseResolution = time.Minute
+)
+
+var (
+	ErrInvalidTokenName   = errors.New("token name must be 1-64 characters")
+	ErrInvalidTokenScopes = errors.New("token scopes must be one or more of read, write, admin")
+	ErrAdminScopeDenied   = errors.New("only admins can create tokens with the admin scope")
+)
+
+var tokenEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// APIAuthService signs users in to the JSON API and web UI with session
+// cookies, and manages personal access tokens for scripts.
+type APIAuthService struct {
+	users    *UserService
+	userRepo domain.UserRepo
+	sessions domain.SessionRepo
+	tokens   domain.PersonalAccessTokenRepo
+	now      func() time.Time
+}
+
+func NewAPIAuthService(users *UserService, userRepo domain.UserRepo, sessions domain.SessionRepo, tokens domain.PersonalAccessTokenRepo) *APIAuthService {
+	return &APIAuthService{users: users, userRepo: userRepo, sessions: sessions, tokens: tokens, now: time.Now}
+}
+
+// Login checks the account password and starts a session. The returned secret
+// goes in the session cookie; only its hash is stored.
+func (s *APIAuthService) Login(ctx context.Context, username, password string) (*domain.User, *domain.Session, string, error) {
+	user, err := s.users.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	now := s.now().UTC()
+	if err := s.sessions.DeleteExpired(ctx, now); err != nil {
+		slog.Warn("failed to delete expired sessions", "error", err)
+	}
+
+	secret, err := randomSecret(32)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	csrf, err := randomSecret(32)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	session := &domain.Session{UserID: user.ID, TokenHash: hashSecret(secret), CSRFToken: csrf, ExpiresAt: now.Add(SessionTTL)}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, nil, "", err
+	}
+
+	slog.Info("session.created", "username", user.Username, "session_id", session.ID)
+	return user, session, secret, nil
+}
+
+// Logout ends a session.
+func (s *APIAuthService) Logout(ctx context.Context, session *domain.Session) error {
+	return s.sessions.Delete(ctx, session.ID)
+}
+
+// AuthenticateSession resolves a session cookie. Returns
+// domain.ErrInvalidCredentials for unknown or expired sessions and
+// domain.ErrUserDisabled when the account was disabled since login.
+func (s *APIAuthService) AuthenticateSession(ctx context.Context, secret string) (*domain.User, *domain.Session, error) {
+	session, err := s.sessions.GetByTokenHash(ctx, hashSecret(secret))
+	if err == domain.ErrNotFound {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.Expired(s.now()) {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	user, err := s.activeUser(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
+
+// CreateToken mints a personal access token. The returned secret is shown to
+// the user once; only its hash is stored. expiresAt may be nil.
+func (s *APIAuthService) CreateToken(ctx context.Context, user *domain.User, name string, scopes []domain.TokenScope, expiresAt *time.Time) (*domain.PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxTokenNameLength {
+		return nil, "", ErrInvalidTokenName
+	}
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidTokenScopes
+	}
+	var unique []domain.TokenScope
+	for _, scope := range scopes {
+		if !domain.ValidTokenScope(scope) {
+			return nil, "", ErrInvalidTokenScopes
+		}
+		if scope == domain.ScopeAdmin && !user.IsAdmin {
+			return nil, "", ErrAdminScopeDenied
+		}
+		if !slices.Contains(unique, scope) {
+			unique = append(unique, scope)
+		}
+	}
+
+	secret, err := generateAccessToken()
+	if err != nil {
+		return nil, "", err
+	}
+	token := &domain.PersonalAccessToken{UserID: user.ID, Name: name, TokenHash: hashSecret(secret), Scopes: unique, ExpiresAt: expiresAt}
+	if err := s.tokens.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	slog.Info("access_token.created", "username", user.Username, "token_id", token.ID, "scopes", unique)
+	return token, secret, nil
+}
+
+// ListTokens returns a user's personal access tokens, including revoked ones.
+func (s *APIAuthService) ListTokens(ctx context.Context, userID int64) ([]*domain.PersonalAccessToken, error) {
+	return s.tokens.ListByUser(ctx, userID)
+}
+
+// RevokeToken stops a personal access token from authenticating. Returns
+// domain.ErrNotFound when the user has no active token with this ID.
+func (s *APIAuthService) RevokeToken(ctx context.Context, user *domain.User, id int64) error {
+	if err := s.tokens.Revoke(ctx, user.ID, id, s.now().UTC()); err != nil {
+		return err
+	}
+	slog.Info("access_token.revoked", "username", user.Username, "token_id", id)
+	return nil
+}
+
+// AuthenticateToken resolves a bearer token. Returns
+// domain.ErrInvalidCredentials for unknown, revoked or expired tokens and
+// domain.ErrUserDisabled for disabled accounts.
+func (s *APIAuthService) AuthenticateToken(ctx context.Context, secret string) (*domain.User, *domain.PersonalAccessToken, error) {
+	if !strings.HasPrefix(secret, PersonalAccessTokenPrefix) {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	token, err := s.tokens.GetByTokenHash(ctx, hashSecret(secret))
+	if err == domain.ErrNotFound {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.now().UTC()
+	if !token.Active(now) {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	user, err := s.activeUser(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenUseResolution {
+		if err := s.tokens.RecordUse(ctx, token.ID, now); err != nil {
+			slog.Warn("failed to record access token use", "token_id", token.ID, "error", err)
+		} else {
+			token.LastUsedAt = &now
+		}
+	}
+	return user, token, nil
+}
+
+func (s *APIAuthService) activeUser(ctx context.Context, id int64) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err == domain.ErrNotFound {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+	return user, nil
+}
+
+// randomSecret returns n random bytes, encoded for use in a cookie or header.
+func randomSecret(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// generateAccessToken returns a prefixed 160-bit token in lowercase base32,
+// which scripts can paste without quoting.
+func generateAccessToken() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + strings.ToLower(tokenEncoding.EncodeToString(buf)), nil
+}
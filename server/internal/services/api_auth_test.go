@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type fakeSessionRepo struct {
+	sessions map[string]*domain.Session
+}
+
+func (f *fakeSessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	session.ID = int64(len(f.sessions) + 1)
+	copied := *session
+	f.sessions[session.TokenHash] = &copied
+	return nil
+}
+
+func (f *fakeSessionRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	if session, ok := f.sessions[tokenHash]; ok {
+		copied := *session
+		return &copied, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeSessionRepo) Delete(ctx context.Context, id int64) error {
+	for hash, session := range f.sessions {
+		if session.ID == id {
+			delete(f.sessions, hash)
+		}
+	}
+	return nil
+}
+
+func (f *fakeSessionRepo) DeleteExpired(ctx context.Context, now time.Time) error {
+	for hash, session := range f.sessions {
+		if session.Expired(now) {
+			delete(f.sessions, hash)
+		}
+	}
+	return nil
+}
+
+type fakeTokenRepo struct {
+	tokens []*domain.PersonalAccessToken
+}
+
+func (f *fakeTokenRepo) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	token.ID = int64(len(f.tokens) + 1)
+	copied := *token
+	f.tokens = append(f.tokens, &copied)
+	return nil
+}
+
+func (f *fakeTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	for _, token := range f.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeTokenRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.PersonalAccessToken, error) {
+	var result []*domain.PersonalAccessToken
+	for _, token := range f.tokens {
+		if token.UserID == userID {
+			result = append(result, token)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeTokenRepo) Revoke(ctx context.Context, userID, id int64, at time.Time) error {
+	for _, token := range f.tokens {
+		if token.ID == id && token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (f *fakeTokenRepo) RecordUse(ctx context.Context, id int64, at time.Time) error {
+	for _, token := range f.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+func newTestAPIAuthService(t *testing.T) (*APIAuthService, *UserService) {
+	t.Helper()
+	users, userRepo, _ := newTestUserService()
+	service := NewAPIAuthService(users, userRepo, &fakeSessionRepo{sessions: make(map[string]*domain.Session)}, &fakeTokenRepo{})
+	return service, users
+}
+
+func TestAPIAuthServiceSessions(t *testing.T) {
+	ctx := context.Background()
+	service, users := newTestAPIAuthService(t)
+	if _, err := users.CreateUser(ctx, "alice", "correct horse", false); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	if _, _, _, err := service.Login(ctx, "alice", "wrong"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("wrong password error = %v", err)
+	}
+	_, session, secret, err := service.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if session.CSRFToken == "" || session.TokenHash == secret || !session.ExpiresAt.Equal(now.Add(SessionTTL)) {
+		t.Fatalf("session = %+v", session)
+	}
+
+	user, got, err := service.AuthenticateSession(ctx, secret)
+	if err != nil || user.Username != "alice" || got.ID != session.ID {
+		t.Fatalf("AuthenticateSession() = %v, %v, %v", user, got, err)
+	}
+
+	// Disabling the account ends its sessions
+	if _, err := users.SetDisabled(ctx, "alice", true); err != nil {
+		t.Fatalf("SetDisabled() error = %v", err)
+	}
+	if _, _, err := service.AuthenticateSession(ctx, secret); !errors.Is(err, domain.ErrUserDisabled) {
+		t.Fatalf("disabled user error = %v", err)
+	}
+	users.SetDisabled(ctx, "alice", false)
+
+	service.now = func() time.Time { return now.Add(SessionTTL) }
+	if _, _, err := service.AuthenticateSession(ctx, secret); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expired session error = %v", err)
+	}
+
+	service.now = func() time.Time { return now }
+	if err := service.Logout(ctx, got); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, _, err := service.AuthenticateSession(ctx, secret); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("logged out session error = %v", err)
+	}
+}
+
+func TestAPIAuthServiceTokens(t *testing.T) {
+	ctx := context.Background()
+	service, users := newTestAPIAuthService(t)
+	alice, err := users.CreateUser(ctx, "alice", "correct horse", false)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	if _, _, err := service.CreateToken(ctx, alice, "backup", nil, nil); !errors.Is(err, ErrInvalidTokenScopes) {
+		t.Fatalf("no scopes error = %v", err)
+	}
+	if _, _, err := service.CreateToken(ctx, alice, "backup", []domain.TokenScope{"delete-everything"}, nil); !errors.Is(err, ErrInvalidTokenScopes) {
+		t.Fatalf("unknown scope error = %v", err)
+	}
+	if _, _, err := service.CreateToken(ctx, alice, "backup", []domain.TokenScope{domain.ScopeAdmin}, nil); !errors.Is(err, ErrAdminScopeDenied) {
+		t.Fatalf("admin scope for non-admin error = %v", err)
+	}
+
+	token, secret, err := service.CreateToken(ctx, alice, "backup", []domain.TokenScope{domain.ScopeRead, domain.ScopeRead}, nil)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if !strings.HasPrefix(secret, PersonalAccessTokenPrefix) || len(token.Scopes) != 1 {
+		t.Fatalf("CreateToken() = %+v, %q", token, secret)
+	}
+
+	user, got, err := service.AuthenticateToken(ctx, secret)
+	if err != nil || user.ID != alice.ID || got.LastUsedAt == nil {
+		t.Fatalf("AuthenticateToken() = %v, %+v, %v", user, got, err)
+	}
+	if !got.HasScope(domain.ScopeRead) || got.HasScope(domain.ScopeWrite) {
+		t.Fatalf("scopes = %v", got.Scopes)
+	}
+
+	if err := service.RevokeToken(ctx, alice, token.ID); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, _, err := service.AuthenticateToken(ctx, secret); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("revoked token error = %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	_, expiredSecret, _ := service.CreateToken(ctx, alice, "old", []domain.TokenScope{domain.ScopeWrite}, &past)
+	if _, _, err := service.AuthenticateToken(ctx, expiredSecret); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expired token error = %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// App password secrets are four groups of four characters from an alphabet
+// without look-alikes (80 bits). The secret is random enough that a plain
+// SHA-256 hash is safe to store and look up directly.
+const (
+	appPasswordAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+	appPasswordLength   = 16
+	appPasswordGroup    = 4
+
+	// MaxAppPasswordNameLength bounds the label chosen by the user.
+	MaxAppPasswordNameLength = 64
+
+	// appPasswordUseResolution limits how often a busy client's last-used time
+	// is written back; CalDAV clients send many requests per sync.
+	appPasswordUseResolution = time.Minute
+)
+
+var ErrInvalidAppPasswordName = errors.New("app password name must be 1-64 characters")
+
+// AppPasswordService mints, verifies and revokes per-device app passwords.
+type AppPasswordService struct {
+	passwords domain.AppPasswordRepo
+	users     domain.UserRepo
+	now       func() time.Time
+}
+
+func NewAppPasswordService(passwords domain.AppPasswordRepo, users domain.UserRepo) *AppPasswordService {
+	return &AppPasswordService{passwords: passwords, users: users, now: time.Now}
+}
+
+// Create mints an app password for user. The returned secret is shown to the
+// user once; only its hash is stored.
+func (s *AppPasswordService) Create(ctx context.Context, user *domain.User, name string) (*domain.AppPassword, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxAppPasswordNameLength {
+		return nil, "", ErrInvalidAppPasswordName
+	}
+
+	secret, err := generateAppPassword()
+	if err != nil {
+		return nil, "", err
+	}
+	password := &domain.AppPassword{UserID: user.ID, Name: name, TokenHash: hashSecret(secret)}
+	if err := s.passwords.Create(ctx, password); err != nil {
+		return nil, "", err
+	}
+
+	slog.Info("app_password.created", "username", user.Username, "app_password_id", password.ID)
+	return password, secret, nil
+}
+
+// List returns a user's app passwords, including revoked ones.
+func (s *AppPasswordService) List(ctx context.Context, userID int64) ([]*domain.AppPassword, error) {
+	return s.passwords.ListByUser(ctx, userID)
+}
+
+// Revoke stops an app password from signing in. Returns domain.ErrNotFound
+// when the user has no active app password with this ID.
+func (s *AppPasswordService) Revoke(ctx context.Context, user *domain.User, id int64) error {
+	if err := s.passwords.Revoke(ctx, user.ID, id, s.now().UTC()); err != nil {
+		return err
+	}
+	slog.Info("app_password.revoked", "username", user.Username, "app_password_id", id)
+	return nil
+}
+
+// Authenticate checks a username and app password and records the use with
+// the client's normalized fingerprint. Returns domain.ErrInvalidCredentials
+// when the secret is not an active app password of that user, and
+// domain.ErrUserDisabled for disabled accounts.
+func (s *AppPasswordService) Authenticate(ctx context.Context, username, secret, clientFingerprint string) (*domain.User, *domain.AppPassword, error) {
+	normalized, ok := normalizeAppPassword(secret)
+	if !ok {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	password, err := s.passwords.GetByTokenHash(ctx, hashSecret(normalized))
+	if err == domain.ErrNotFound {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if password.Revoked() {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+
+	user, err := s.users.GetByID(ctx, password.UserID)
+	if err == domain.ErrNotFound {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Username != username {
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if user.Disabled() {
+		return nil, nil, domain.ErrUserDisabled
+	}
+
+	now := s.now().UTC()
+	stale := password.LastUsedAt == nil || now.Sub(*password.LastUsedAt) >= appPasswordUseResolution
+	if stale || password.LastClientFingerprint != clientFingerprint {
+		if err := s.passwords.RecordUse(ctx, password.ID, now, clientFingerprint); err != nil {
+			// Sign-in must not fail because the bookkeeping did
+			slog.Warn("failed to record app password use", "app_password_id", password.ID, "error", err)
+		} else {
+			password.LastUsedAt = &now
+			password.LastClientFingerprint = clientFingerprint
+		}
+	}
+	return user, password, nil
+}
+
+func generateAppPassword() (string, error) {
+	buf := make([]byte, appPasswordLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, v := range buf {
+		if i > 0 && i%appPasswordGroup == 0 {
+			b.WriteByte('-')
+		}
+		// 256 is a multiple of the alphabet size, so there is no modulo bias
+		b.WriteByte(appPasswordAlphabet[int(v)%len(appPasswordAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// normalizeAppPassword accepts a secret typed with or without the group
+// separators and in any case. It reports false for anything that cannot be an
+// app password, such as an account password.
+func normalizeAppPassword(secret string) (string, bool) {
+	var b strings.Builder
+	for _, r := range strings.ToLower(secret) {
+		switch {
+		case r == '-' || r == ' ':
+			continue
+		case r < utf8.RuneSelf && strings.IndexByte(appPasswordAlphabet, byte(r)) >= 0:
+			b.WriteRune(r)
+		default:
+			return "", false
+		}
+	}
+	if b.Len() != appPasswordLength {
+		return "", false
+	}
+	normalized := b.String()
+	var grouped strings.Builder
+	for i := 0; i < len(normalized); i += appPasswordGroup {
+		if i > 0 {
+			grouped.WriteByte('-')
+		}
+		grouped.WriteString(normalized[i : i+appPasswordGroup])
+	}
+	return grouped.String(), true
+}
+
+// hashSecret returns the stored form of a generated secret: app passwords,
+// session cookies and personal access tokens.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
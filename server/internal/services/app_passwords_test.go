@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type fakeAppPasswordRepo struct {
+	passwords []*domain.AppPassword
+	uses      int
+}
+
+func (f *fakeAppPasswordRepo) Create(ctx context.Context, password *domain.AppPassword) error {
+	password.ID = int64(len(f.passwords) + 1)
+	copied := *password
+	f.passwords = append(f.passwords, &copied)
+	return nil
+}
+
+func (f *fakeAppPasswordRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.AppPassword, error) {
+	for _, p := range f.passwords {
+		if p.TokenHash == tokenHash {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeAppPasswordRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.AppPassword, error) {
+	var result []*domain.AppPassword
+	for _, p := range f.passwords {
+		if p.UserID == userID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAppPasswordRepo) Revoke(ctx context.Context, userID, id int64, at time.Time) error {
+	for _, p := range f.passwords {
+		if p.ID == id && p.UserID == userID && p.RevokedAt == nil {
+			p.RevokedAt = &at
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (f *fakeAppPasswordRepo) RecordUse(ctx context.Context, id int64, at time.Time, clientFingerprint string) error {
+	f.uses++
+	for _, p := range f.passwords {
+		if p.ID == id {
+			p.LastUsedAt = &at
+			p.LastClientFingerprint = clientFingerprint
+		}
+	}
+	return nil
+}
+
+func TestAppPasswordServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	users := newFakeUserRepo()
+	alice, _ := users.Create(ctx, "alice")
+	users.Create(ctx, "bob")
+	repo := &fakeAppPasswordRepo{}
+	service := NewAppPasswordService(repo, users)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	phone, secret, err := service.Create(ctx, alice, "  iPhone ")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if phone.Name != "iPhone" || len(secret) != 19 || phone.TokenHash == secret || strings.Contains(phone.TokenHash, secret) {
+		t.Fatalf("Create() = %+v, %q; want a hashed 4x4 secret", phone, secret)
+	}
+
+	user, used, err := service.Authenticate(ctx, "alice", secret, domain.CalDAVClientAppleCalendar)
+	if err != nil || user.ID != alice.ID || used.ID != phone.ID {
+		t.Fatalf("Authenticate() = %v, %v, %v", user, used, err)
+	}
+	if used.LastUsedAt == nil || !used.LastUsedAt.Equal(now) || used.LastClientFingerprint != domain.CalDAVClientAppleCalendar {
+		t.Fatalf("use not recorded: %+v", used)
+	}
+
+	// Typed without separators and in capitals; within a minute, same client: no write
+	if _, _, err := service.Authenticate(ctx, "alice", strings.ToUpper(strings.ReplaceAll(secret, "-", "")), domain.CalDAVClientAppleCalendar); err != nil {
+		t.Fatalf("normalized secret error = %v", err)
+	}
+	if repo.uses != 1 {
+		t.Fatalf("RecordUse calls = %d, want 1", repo.uses)
+	}
+
+	if _, _, err := service.Authenticate(ctx, "bob", secret, ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("other user's app password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := service.Authenticate(ctx, "alice", "correct horse", ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("account password error = %v, want ErrInvalidCredentials", err)
+	}
+
+	// Revoking the phone leaves other devices working
+	_, laptopSecret, _ := service.Create(ctx, alice, "Thunderbird laptop")
+	if err := service.Revoke(ctx, alice, phone.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, _, err := service.Authenticate(ctx, "alice", secret, ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("revoked app password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := service.Authenticate(ctx, "alice", laptopSecret, domain.CalDAVClientThunderbird); err != nil {
+		t.Fatalf("remaining app password error = %v", err)
+	}
+
+	if _, _, err := service.Create(ctx, alice, " "); !errors.Is(err, ErrInvalidAppPasswordName) {
+		t.Fatalf("blank name error = %v", err)
+	}
+}
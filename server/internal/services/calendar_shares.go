@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+var (
+	ErrInvalidSharePrivilege = errors.New("share privilege must be read or read-write")
+	ErrShareWithSelf         = errors.New("a calendar cannot be shared with its owner")
+	ErrShareGranteeNotFound  = errors.New("no user with that username")
+)
+
+// CalendarGrant is one share of a calendar with the grantee's username.
+type CalendarGrant struct {
+	Share   *domain.CalendarShare
+	Grantee string
+}
+
+// CalendarShareService lets users share their calendars with other users.
+type CalendarShareService struct {
+	shares    domain.CalendarShareRepo
+	calendars domain.CalendarRepo
+	users     domain.UserRepo
+	publisher domain.NotificationPublisher // tells owner and grantee; may be nil
+}
+
+func NewCalendarShareService(shares domain.CalendarShareRepo, calendars domain.CalendarRepo, users domain.UserRepo, publisher domain.NotificationPublisher) *CalendarShareService {
+	return &CalendarShareService{shares: shares, calendars: calendars, users: users, publisher: publisher}
+}
+
+// Share grants grantee access to owner's calendar, or changes the privilege of
+// an existing share. Returns domain.ErrNotFound when owner has no such
+// calendar.
+func (s *CalendarShareService) Share(ctx context.Context, owner *domain.User, calendarName, grantee string, privilege domain.SharePrivilege) (*CalendarGrant, error) {
+	if !domain.ValidSharePrivilege(privilege) {
+		return nil, ErrInvalidSharePrivilege
+	}
+	cal, err := s.calendars.GetByName(ctx, owner.ID, calendarName)
+	if err != nil {
+		return nil, err
+	}
+	granteeUser, err := s.grantee(ctx, owner, grantee)
+	if err != nil {
+		return nil, err
+	}
+
+	share := &domain.CalendarShare{CalendarID: cal.ID, GranteeUserID: granteeUser.ID, Privilege: privilege}
+	if err := s.shares.Put(ctx, share); err != nil {
+		return nil, err
+	}
+
+	slog.Info("calendar.shared", "username", owner.Username, "calendar", cal.Name, "grantee", granteeUser.Username, "privilege", privilege)
+	s.publish(ctx, domain.NotificationCalendarShared, cal, granteeUser)
+	return &CalendarGrant{Share: share, Grantee: granteeUser.Username}, nil
+}
+
+// Unshare revokes grantee's access to owner's calendar. Returns
+// domain.ErrNotFound when there is no such calendar or share.
+func (s *CalendarShareService) Unshare(ctx context.Context, owner *domain.User, calendarName, grantee string) error {
+	cal, err := s.calendars.GetByName(ctx, owner.ID, calendarName)
+	if err != nil {
+		return err
+	}
+	granteeUser, err := s.users.GetByUsername(ctx, grantee)
+	if err != nil {
+		return err
+	}
+	if err := s.shares.Delete(ctx, cal.ID, granteeUser.ID); err != nil {
+		return err
+	}
+
+	slog.Info("calendar.unshared", "username", owner.Username, "calendar", cal.Name, "grantee", granteeUser.Username)
+	s.publish(ctx, domain.NotificationCalendarUnshared, cal, granteeUser)
+	return nil
+}
+
+// List returns the shares of owner's calendar. Returns domain.ErrNotFound when
+// owner has no such calendar.
+func (s *CalendarShareService) List(ctx context.Context, owner *domain.User, calendarName string) ([]CalendarGrant, error) {
+	cal, err := s.calendars.GetByName(ctx, owner.ID, calendarName)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := s.shares.ListByCalendar(ctx, cal.ID)
+	if err != nil {
+		return nil, err
+	}
+	grants := make([]CalendarGrant, 0, len(shares))
+	for _, share := range shares {
+		user, err := s.users.GetByID(ctx, share.GranteeUserID)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, CalendarGrant{Share: share, Grantee: user.Username})
+	}
+	return grants, nil
+}
+
+func (s *CalendarShareService) grantee(ctx context.Context, owner *domain.User, username string) (*domain.User, error) {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err == domain.ErrNotFound {
+		return nil, ErrShareGranteeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == owner.ID {
+		return nil, ErrShareWithSelf
+	}
+	return user, nil
+}
+
+func (s *CalendarShareService) publish(ctx context.Context, typ domain.NotificationType, cal *domain.Calendar, grantee *domain.User) {
+	if s.publisher == nil {
+		return
+	}
+	s.publisher.Publish(ctx, &domain.Notification{
+		Type:     typ,
+		UserIDs:  []int64{cal.UserID, grantee.ID},
+		Calendar: cal,
+		Grantee:  grantee,
+	})
+}
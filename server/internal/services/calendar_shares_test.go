@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type fakeCalendarShareRepo struct {
+	shares []*domain.CalendarShare
+}
+
+func (f *fakeCalendarShareRepo) Put(ctx context.Context, share *domain.CalendarShare) error {
+	for _, s := range f.shares {
+		if s.CalendarID == share.CalendarID && s.GranteeUserID == share.GranteeUserID {
+			s.Privilege = share.Privilege
+			*share = *s
+			return nil
+		}
+	}
+	share.ID = int64(len(f.shares) + 1)
+	copied := *share
+	f.shares = append(f.shares, &copied)
+	return nil
+}
+
+func (f *fakeCalendarShareRepo) Get(ctx context.Context, calendarID, granteeUserID int64) (*domain.CalendarShare, error) {
+	for _, s := range f.shares {
+		if s.CalendarID == calendarID && s.GranteeUserID == granteeUserID {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeCalendarShareRepo) ListByCalendar(ctx context.Context, calendarID int64) ([]*domain.CalendarShare, error) {
+	var result []*domain.CalendarShare
+	for _, s := range f.shares {
+		if s.CalendarID == calendarID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeCalendarShareRepo) ListByGrantee(ctx context.Context, granteeUserID int64) ([]*domain.CalendarShare, error) {
+	var result []*domain.CalendarShare
+	for _, s := range f.shares {
+		if s.GranteeUserID == granteeUserID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeCalendarShareRepo) Delete(ctx context.Context, calendarID, granteeUserID int64) error {
+	for i, s := range f.shares {
+		if s.CalendarID == calendarID && s.GranteeUserID == granteeUserID {
+			f.shares = append(f.shares[:i], f.shares[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func TestCalendarShareServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	users := newFakeUserRepo()
+	alice, _ := users.Create(ctx, "alice")
+	users.Create(ctx, "bob")
+	calendars := &fakeCalendarRepo{calendars: map[string]*domain.Calendar{
+		"family": {ID: 7, UserID: alice.ID, Name: "family"},
+	}}
+	repo := &fakeCalendarShareRepo{}
+	publisher := &recordingPublisher{}
+	service := NewCalendarShareService(repo, calendars, users, publisher)
+
+	grant, err := service.Share(ctx, alice, "family", "bob", domain.SharePrivilegeRead)
+	if err != nil {
+		t.Fatalf("Share() error = %v", err)
+	}
+	if grant.Grantee != "bob" || grant.Share.CalendarID != 7 || grant.Share.Privilege != domain.SharePrivilegeRead {
+		t.Fatalf("Share() = %+v", grant.Share)
+	}
+	if _, err := service.Share(ctx, alice, "family", "bob", domain.SharePrivilegeReadWrite); err != nil {
+		t.Fatalf("Share() upgrade error = %v", err)
+	}
+	grants, err := service.List(ctx, alice, "family")
+	if err != nil || len(grants) != 1 || grants[0].Grantee != "bob" || grants[0].Share.Privilege != domain.SharePrivilegeReadWrite {
+		t.Fatalf("List() = %+v, %v; want bob with read-write", grants, err)
+	}
+
+	if _, err := service.Share(ctx, alice, "family", "bob", "admin"); !errors.Is(err, ErrInvalidSharePrivilege) {
+		t.Errorf("invalid privilege error = %v", err)
+	}
+	if _, err := service.Share(ctx, alice, "family", "alice", domain.SharePrivilegeRead); !errors.Is(err, ErrShareWithSelf) {
+		t.Errorf("share with self error = %v", err)
+	}
+	if _, err := service.Share(ctx, alice, "family", "carol", domain.SharePrivilegeRead); !errors.Is(err, ErrShareGranteeNotFound) {
+		t.Errorf("unknown grantee error = %v", err)
+	}
+	if _, err := service.Share(ctx, alice, "work", "bob", domain.SharePrivilegeRead); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("unknown calendar error = %v", err)
+	}
+
+	if err := service.Unshare(ctx, alice, "family", "bob"); err != nil {
+		t.Fatalf("Unshare() error = %v", err)
+	}
+	if err := service.Unshare(ctx, alice, "family", "bob"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("second Unshare() error = %v, want ErrNotFound", err)
+	}
+
+	// Owner and grantee hear of every change that succeeded
+	var types []domain.NotificationType
+	for _, n := range publisher.notifications {
+		types = append(types, n.Type)
+		if len(n.UserIDs) != 2 || n.UserIDs[0] != alice.ID || n.Grantee.Username != "bob" || n.Calendar.ID != 7 {
+			t.Errorf("notification %s = %+v, want one for alice and bob about family", n.Type, n)
+		}
+	}
+	want := []domain.NotificationType{domain.NotificationCalendarShared, domain.NotificationCalendarShared, domain.NotificationCalendarUnshared}
+	if len(types) != len(want) || types[0] != want[0] || types[1] != want[1] || types[2] != want[2] {
+		t.Errorf("published %v, want %v", types, want)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/recurrence"
+)
+
+// FreeBusyService computes busy time from stored events. Results only contain
+// time spans and FBTYPEs, never titles or other event content.
+type FreeBusyService struct {
+	calendars domain.CalendarRepo
+	events    domain.EventRepo
+}
+
+func NewFreeBusyService(calendars domain.CalendarRepo, events domain.EventRepo) *FreeBusyService {
+	return &FreeBusyService{calendars: calendars, events: events}
+}
+
+// BusyPeriods returns the merged busy periods of a user's calendars within
+// [start, end). calendarNames restricts the calendars considered; empty means
+// every calendar that holds events. Transparent and cancelled instances are
+// ignored and recurring events are expanded.
+func (s *FreeBusyService) BusyPeriods(ctx context.Context, userID int64, calendarNames []string, start, end time.Time) ([]domain.BusyPeriod, error) {
+	cals, err := s.calendars.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(calendarNames))
+	for _, name := range calendarNames {
+		wanted[name] = true
+	}
+
+	var periods []domain.BusyPeriod
+	for _, cal := range cals {
+		if len(wanted) > 0 && !wanted[cal.Name] {
+			continue
+		}
+		if !cal.SupportsComponent(domain.ComponentEvent) {
+			continue
+		}
+		calPeriods, err := s.calendarBusyPeriods(ctx, cal, start, end)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, calPeriods...)
+	}
+
+	return domain.MergeBusyPeriods(periods), nil
+}
+
+func (s *FreeBusyService) calendarBusyPeriods(ctx context.Context, cal *domain.Calendar, start, end time.Time) ([]domain.BusyPeriod, error) {
+	events, err := s.events.List(ctx, cal.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	recurring, err := s.events.ListRecurring(ctx, cal.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring events: %w", err)
+	}
+	seen := make(map[int64]bool, len(events))
+	for _, event := range events {
+		seen[event.ID] = true
+	}
+	for _, event := range recurring {
+		if !seen[event.ID] {
+			events = append(events, event)
+		}
+	}
+
+	var periods []domain.BusyPeriod
+	for _, event := range events {
+		icalCal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+		if err != nil {
+			// Log error with safe fields only (never log raw ICS - security)
+			slog.Warn("freebusy.corrupt_ics", "calendar_id", cal.ID, "event_id", event.ID, "error", err)
+			continue
+		}
+		instances, err := recurrence.Expand(icalCal, ical.CompEvent, start, end, time.UTC)
+		if err != nil {
+			slog.Warn("freebusy.expand_failed", "calendar_id", cal.ID, "event_id", event.ID, "error", err)
+			continue
+		}
+		for _, inst := range instances {
+			fbType, busy := instanceFreeBusyType(inst.Component)
+			if !busy {
+				continue
+			}
+			period := domain.BusyPeriod{Start: inst.Start.UTC(), End: inst.End.UTC(), Type: fbType}
+			if period.Start.Before(start) {
+				period.Start = start
+			}
+			if period.End.After(end) {
+				period.End = end
+			}
+			periods = append(periods, period)
+		}
+	}
+	return periods, nil
+}
+
+// instanceFreeBusyType maps TRANSP and STATUS of an event instance to an
+// FBTYPE, reporting false when the instance does not block time.
+func instanceFreeBusyType(comp *ical.Component) (domain.FreeBusyType, bool) {
+	if prop := comp.Props.Get(ical.PropTransparency); prop != nil && strings.EqualFold(prop.Value, "TRANSPARENT") {
+		return "", false
+	}
+	status := ""
+	if prop := comp.Props.Get(ical.PropStatus); prop != nil {
+		status = strings.ToUpper(prop.Value)
+	}
+	switch status {
+	case "CANCELLED":
+		return "", false
+	case "TENTATIVE":
+		return domain.FreeBusyTentative, true
+	default:
+		return domain.FreeBusyBusy, true
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginThrottleConfig controls brute-force protection for password sign-ins.
+// After the free attempts, each further failure locks the key for BaseLockout,
+// doubling per failure up to MaxLockout. A key's failures are forgotten
+// ResetAfter its last failure.
+type LoginThrottleConfig struct {
+	UserFreeAttempts int // Per username
+	IPFreeAttempts   int // Per client IP; higher, since clients may share a NAT
+	BaseLockout      time.Duration
+	MaxLockout       time.Duration
+	ResetAfter       time.Duration
+}
+
+// DefaultLoginThrottleConfig returns the defaults: five failures per username
+// and twenty per IP before lockouts start at one second, capped at 15 minutes.
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		UserFreeAttempts: 5,
+		IPFreeAttempts:   20,
+		BaseLockout:      time.Second,
+		MaxLockout:       15 * time.Minute,
+		ResetAfter:       15 * time.Minute,
+	}
+}
+
+// maxTrackedLoginKeys bounds the failure table. Stale keys are pruned first;
+// if that is not enough, the least recently failed keys are evicted down to
+// 90% of the bound so the next full scan is some failures away.
+const maxTrackedLoginKeys = 10000
+
+type loginFailures struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LoginThrottle tracks failed sign-ins per username and per client IP in
+// memory and locks out keys with exponential backoff. It is safe for
+// concurrent use.
+type LoginThrottle struct {
+	config  LoginThrottleConfig
+	now     func() time.Time
+	maxKeys int
+
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+}
+
+func NewLoginThrottle(config LoginThrottleConfig) *LoginThrottle {
+	defaults := DefaultLoginThrottleConfig()
+	if config.UserFreeAttempts <= 0 {
+		config.UserFreeAttempts = defaults.UserFreeAttempts
+	}
+	if config.IPFreeAttempts <= 0 {
+		config.IPFreeAttempts = defaults.IPFreeAttempts
+	}
+	if config.BaseLockout <= 0 {
+		config.BaseLockout = defaults.BaseLockout
+	}
+	if config.MaxLockout <= 0 {
+		config.MaxLockout = defaults.MaxLockout
+	}
+	if config.ResetAfter <= 0 {
+		config.ResetAfter = defaults.ResetAfter
+	}
+	return &LoginThrottle{config: config, now: time.Now, maxKeys: maxTrackedLoginKeys, failures: make(map[string]*loginFailures)}
+}
+
+// Check reports how long the client IP or the username is still locked out;
+// zero when the attempt may proceed. username may be empty.
+func (t *LoginThrottle) Check(ip, username string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	wait := t.lockedFor(ipKey(ip), now)
+	if username != "" {
+		wait = max(wait, t.lockedFor(userKey(username), now))
+	}
+	return wait
+}
+
+// Failure records a failed sign-in and returns the lockout it triggered, zero
+// while the key is within its free attempts.
+func (t *LoginThrottle) Failure(ip, username string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if len(t.failures) >= t.maxKeys {
+		t.prune(now)
+	}
+	lockout := t.fail(ipKey(ip), t.config.IPFreeAttempts, now)
+	if username != "" {
+		lockout = max(lockout, t.fail(userKey(username), t.config.UserFreeAttempts, now))
+	}
+	if lockout > 0 {
+		slog.Warn("auth.locked_out", "ip", ip, "username", username, "lockout_seconds", int(lockout.Seconds()))
+	}
+	return lockout
+}
+
+// Success forgets the failures of a username after a correct password. The
+// IP's failures are kept, so one valid account cannot reset an IP that is
+// guessing at others.
+func (t *LoginThrottle) Success(username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, userKey(username))
+}
+
+func (t *LoginThrottle) lockedFor(key string, now time.Time) time.Duration {
+	f, ok := t.failures[key]
+	if !ok || !now.Before(f.lockedUntil) {
+		return 0
+	}
+	return f.lockedUntil.Sub(now)
+}
+
+func (t *LoginThrottle) fail(key string, freeAttempts int, now time.Time) time.Duration {
+	f, ok := t.failures[key]
+	if !ok || now.Sub(f.lastFailure) >= t.config.ResetAfter {
+		f = &loginFailures{}
+		t.failures[key] = f
+	}
+	f.count++
+	f.lastFailure = now
+	if f.count <= freeAttempts {
+		return 0
+	}
+
+	lockout := t.config.BaseLockout
+	for i := freeAttempts + 1; i < f.count && lockout < t.config.MaxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, t.config.MaxLockout)
+	f.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+func (t *LoginThrottle) prune(now time.Time) {
+	for key, f := range t.failures {
+		if now.Sub(f.lastFailure) >= t.config.ResetAfter && !now.Before(f.lockedUntil) {
+			delete(t.failures, key)
+		}
+	}
+	if len(t.failures) < t.maxKeys {
+		return
+	}
+
+	// Every key is fresh: evict the oldest failures, unlocked keys before
+	// locked ones so running lockouts survive a flood of new keys
+	keys := make([]string, 0, len(t.failures))
+	for key := range t.failures {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := t.failures[keys[i]], t.failures[keys[j]]
+		if aLocked, bLocked := now.Before(a.lockedUntil), now.Before(b.lockedUntil); aLocked != bLocked {
+			return bLocked
+		}
+		return a.lastFailure.Before(b.lastFailure)
+	})
+	evict := len(keys) - t.maxKeys*9/10
+	for _, key := range keys[:evict] {
+		delete(t.failures, key)
+	}
+	slog.Warn("auth.throttle_evicted", "keys", evict)
+}
+
+func ipKey(ip string) string { return "ip:" + ip }
+
+func userKey(username string) string { return "user:" + strings.ToLower(username) }
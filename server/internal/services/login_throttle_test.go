@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleBacksOffPerUsername(t *testing.T) {
+	throttle := NewLoginThrottle(LoginThrottleConfig{UserFreeAttempts: 3, IPFreeAttempts: 100, BaseLockout: time.Second, MaxLockout: 4 * time.Second, ResetAfter: time.Hour})
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	throttle.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if lockout := throttle.Failure("10.0.0.1", "alice"); lockout != 0 {
+			t.Fatalf("free failure %d locked out for %v", i+1, lockout)
+		}
+	}
+	if wait := throttle.Check("10.0.0.1", "alice"); wait != 0 {
+		t.Fatalf("Check() within free attempts = %v, want 0", wait)
+	}
+
+	// 1s, 2s, 4s, then capped at MaxLockout
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if lockout := throttle.Failure("10.0.0.1", "Alice"); lockout != want {
+			t.Fatalf("Failure() = %v, want %v", lockout, want)
+		}
+	}
+	if wait := throttle.Check("10.0.0.2", "alice"); wait != 4*time.Second {
+		t.Fatalf("Check() from another IP = %v, want the username's 4s", wait)
+	}
+	if wait := throttle.Check("10.0.0.1", "bob"); wait != 0 {
+		t.Fatalf("Check() for another user = %v, want 0", wait)
+	}
+
+	now = now.Add(4 * time.Second)
+	if wait := throttle.Check("10.0.0.1", "alice"); wait != 0 {
+		t.Fatalf("Check() after the lockout = %v, want 0", wait)
+	}
+	throttle.Success("alice")
+	if lockout := throttle.Failure("10.0.0.1", "alice"); lockout != 0 {
+		t.Fatalf("Failure() after a success = %v, want a fresh free attempt", lockout)
+	}
+}
+
+func TestLoginThrottleLocksOutIPAcrossUsernames(t *testing.T) {
+	throttle := NewLoginThrottle(LoginThrottleConfig{UserFreeAttempts: 10, IPFreeAttempts: 2, BaseLockout: time.Minute, ResetAfter: time.Hour})
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	throttle.now = func() time.Time { return now }
+
+	throttle.Failure("10.0.0.1", "alice")
+	throttle.Failure("10.0.0.1", "bob")
+	if lockout := throttle.Failure("10.0.0.1", "carol"); lockout != time.Minute {
+		t.Fatalf("third failure from one IP = %v, want 1m", lockout)
+	}
+	if wait := throttle.Check("10.0.0.1", "dave"); wait != time.Minute {
+		t.Fatalf("Check() from the locked IP = %v, want 1m", wait)
+	}
+	if wait := throttle.Check("10.0.0.9", "alice"); wait != 0 {
+		t.Fatalf("Check() from another IP = %v, want 0", wait)
+	}
+
+	// A success does not clear the IP, and old failures expire
+	throttle.Success("dave")
+	if wait := throttle.Check("10.0.0.1", "dave"); wait == 0 {
+		t.Fatal("Success() cleared the IP lockout")
+	}
+	now = now.Add(time.Hour)
+	if lockout := throttle.Failure("10.0.0.1", "alice"); lockout != 0 {
+		t.Fatalf("Failure() after ResetAfter = %v, want 0", lockout)
+	}
+}
+
+func TestLoginThrottleEvictsOldestKeysAtCapacity(t *testing.T) {
+	throttle := NewLoginThrottle(LoginThrottleConfig{UserFreeAttempts: 1, IPFreeAttempts: 100, BaseLockout: time.Minute, ResetAfter: time.Hour})
+	throttle.maxKeys = 20
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	throttle.now = func() time.Time { return now }
+
+	// One IP and 19 usernames, all fresh; the oldest username is locked out
+	throttle.Failure("10.0.0.1", "locked")
+	throttle.Failure("10.0.0.1", "locked")
+	for i := 0; i < 18; i++ {
+		now = now.Add(time.Second)
+		throttle.Failure("10.0.0.1", fmt.Sprintf("user%d", i))
+	}
+	if len(throttle.failures) != 20 {
+		t.Fatalf("tracked %d keys, want 20", len(throttle.failures))
+	}
+
+	now = now.Add(time.Second)
+	throttle.Failure("10.0.0.1", "newcomer")
+	if len(throttle.failures) > 20 {
+		t.Fatalf("tracked %d keys, want at most 20", len(throttle.failures))
+	}
+	if wait := throttle.Check("10.0.0.2", "locked"); wait == 0 {
+		t.Fatal("running lockout was evicted")
+	}
+	for _, key := range []string{userKey("user0"), userKey("user1")} {
+		if _, ok := throttle.failures[key]; ok {
+			t.Errorf("oldest key %s was kept", key)
+		}
+	}
+	for _, key := range []string{ipKey("10.0.0.1"), userKey("user17"), userKey("newcomer")} {
+		if _, ok := throttle.failures[key]; !ok {
+			t.Errorf("recent key %s was evicted", key)
+		}
+	}
+
+	// The next failures do not rescan a full table
+	now = now.Add(time.Second)
+	throttle.Failure("10.0.0.1", "another")
+	if _, ok := throttle.failures[userKey("user2")]; !ok {
+		t.Error("key evicted before the table was full again")
+	}
+}
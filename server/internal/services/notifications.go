@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const (
+	// DefaultNotificationHistory is how many notifications the bus keeps for
+	// streams that reconnect.
+	DefaultNotificationHistory = 1024
+
+	// notificationSubscriberBuffer is how many notifications a subscriber may
+	// fall behind before it is disconnected.
+	notificationSubscriberBuffer = 64
+)
+
+// ErrNotificationsExpired is returned when a stream resumes after a
+// notification the bus no longer holds, e.g. after a restart. The client has
+// to refetch what it shows.
+var ErrNotificationsExpired = errors.New("notifications since the given ID are no longer available")
+
+// NotificationBus is the in-process event bus behind the /events stream. It
+// numbers the notifications published to it, passes them to the subscribed
+// users they concern and keeps the most recent ones, so a stream that
+// reconnects with the ID of the last notification it saw gets what it missed.
+type NotificationBus struct {
+	mu          sync.Mutex
+	lastID      int64
+	retained    int64                  // Notifications after this ID are in history
+	history     []*domain.Notification // Oldest first
+	capacity    int
+	subscribers map[*NotificationSubscription]struct{}
+	now         func() time.Time
+}
+
+// NewNotificationBus returns a bus that keeps the given number of
+// notifications for reconnecting streams. IDs start from the current time so
+// they keep increasing across restarts.
+func NewNotificationBus(history int) *NotificationBus {
+	if history <= 0 {
+		history = DefaultNotificationHistory
+	}
+	start := time.Now().UnixMicro()
+	return &NotificationBus{
+		lastID:      start,
+		retained:    start,
+		capacity:    history,
+		subscribers: make(map[*NotificationSubscription]struct{}),
+		now:         time.Now,
+	}
+}
+
+// NotificationSubscription is one user's feed of notifications.
+type NotificationSubscription struct {
+	// C receives the notifications for the user as they are published. It is
+	// closed when the subscriber falls too far behind; the stream should end
+	// so the client reconnects and catches up.
+	C <-chan *domain.Notification
+
+	// Missed holds the notifications published after the ID the subscriber
+	// resumed from, oldest first.
+	Missed []*domain.Notification
+
+	// LastID is the ID of the newest notification published before the
+	// subscription started. Clients resume from it when nothing else arrives.
+	LastID int64
+
+	bus    *NotificationBus
+	userID int64
+	ch     chan *domain.Notification
+}
+
+// Subscribe starts a feed of the notifications for a user. A positive after
+// resumes a previous feed: the notifications published since that ID are in
+// Missed. Returns ErrNotificationsExpired with a fresh subscription when some
+// of them are no longer held.
+func (b *NotificationBus) Subscribe(userID, after int64) (*NotificationSubscription, error) {
+	ch := make(chan *domain.Notification, notificationSubscriberBuffer)
+	sub := &NotificationSubscription{C: ch, bus: b, userID: userID, ch: ch}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub.LastID = b.lastID
+	b.subscribers[sub] = struct{}{}
+
+	if after <= 0 {
+		return sub, nil
+	}
+	if after < b.retained || after > b.lastID {
+		return sub, ErrNotificationsExpired
+	}
+	for _, n := range b.history {
+		if n.ID > after && n.NotifiesUser(userID) {
+			sub.Missed = append(sub.Missed, n)
+		}
+	}
+	return sub, nil
+}
+
+// Close ends the subscription.
+func (s *NotificationSubscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// Publish implements domain.NotificationPublisher. It assigns n its ID.
+func (b *NotificationBus) Publish(ctx context.Context, n *domain.Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	n.ID = b.lastID
+	if n.OccurredAt.IsZero() {
+		n.OccurredAt = b.now().UTC()
+	}
+	b.history = append(b.history, n)
+	if len(b.history) > b.capacity {
+		b.retained = b.history[0].ID
+		b.history[0] = nil
+		b.history = b.history[1:]
+	}
+
+	for sub := range b.subscribers {
+		if !n.NotifiesUser(sub.userID) {
+			continue
+		}
+		select {
+		case sub.ch <- n:
+		default:
+			// The client reconnects and gets the rest from history
+			slog.Warn("notifications.subscriber.behind", "user_id", sub.userID, "notification_id", n.ID)
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// NotificationFanout publishes every notification to each of its publishers
+// in order. Put the NotificationBus first so the others see its IDs.
+type NotificationFanout []domain.NotificationPublisher
+
+// Publish implements domain.NotificationPublisher.
+func (f NotificationFanout) Publish(ctx context.Context, n *domain.Notification) {
+	for _, publisher := range f {
+		publisher.Publish(ctx, n)
+	}
+}
+
+// Notify implements domain.ReminderChannel: fired reminders go to their
+// user's streams. Users without an open stream find the reminder through the
+// reminders API instead.
+func (b *NotificationBus) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	b.Publish(ctx, &domain.Notification{
+		Type:     domain.NotificationReminder,
+		UserIDs:  []int64{reminder.UserID},
+		Reminder: reminder,
+	})
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+func TestNotificationBusFiltersByUser(t *testing.T) {
+	ctx := context.Background()
+	bus := NewNotificationBus(0)
+	alice, err := bus.Subscribe(1, 0)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer alice.Close()
+	bob, err := bus.Subscribe(2, 0)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer bob.Close()
+
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventCreated, UserIDs: []int64{1}, UID: "mine"})
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationSyncHealthChanged})
+	bus.Notify(ctx, &domain.Reminder{ID: 5, UserID: 1})
+
+	var got []*domain.Notification
+	for len(alice.C) > 0 {
+		got = append(got, <-alice.C)
+	}
+	if len(got) != 3 || got[0].UID != "mine" || got[1].Type != domain.NotificationSyncHealthChanged || got[2].Reminder.ID != 5 {
+		t.Fatalf("alice got %+v, want her event, the broadcast and her reminder", got)
+	}
+	if got[0].ID <= alice.LastID || got[1].ID != got[0].ID+1 || got[0].OccurredAt.IsZero() {
+		t.Errorf("notification IDs %d, %d after %d; want consecutive IDs after the subscription", got[0].ID, got[1].ID, alice.LastID)
+	}
+	if len(bob.C) != 1 {
+		t.Errorf("bob got %d notifications, want only the broadcast", len(bob.C))
+	}
+}
+
+func TestNotificationBusResume(t *testing.T) {
+	ctx := context.Background()
+	bus := NewNotificationBus(3)
+	first, _ := bus.Subscribe(1, 0)
+	first.Close()
+	if _, ok := <-first.C; ok {
+		t.Fatal("Close() must close the channel")
+	}
+
+	for _, uid := range []string{"a", "b", "c"} {
+		bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventUpdated, UserIDs: []int64{1}, UID: uid})
+	}
+	bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventUpdated, UserIDs: []int64{2}, UID: "other"})
+
+	// Resuming after "a" replays the rest of the user's notifications
+	resumed, err := bus.Subscribe(1, first.LastID+1)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer resumed.Close()
+	if len(resumed.Missed) != 2 || resumed.Missed[0].UID != "b" || resumed.Missed[1].UID != "c" {
+		t.Errorf("Missed = %+v, want b and c", resumed.Missed)
+	}
+	if resumed.LastID != first.LastID+4 {
+		t.Errorf("LastID = %d, want %d", resumed.LastID, first.LastID+4)
+	}
+
+	// Only three notifications are kept, so "a" is gone
+	if _, err := bus.Subscribe(1, first.LastID); err != ErrNotificationsExpired {
+		t.Errorf("resuming before the history error = %v, want ErrNotificationsExpired", err)
+	}
+	if _, err := bus.Subscribe(1, resumed.LastID+1); err != ErrNotificationsExpired {
+		t.Errorf("resuming from an unknown ID error = %v, want ErrNotificationsExpired", err)
+	}
+	if sub, err := bus.Subscribe(1, resumed.LastID); err != nil || len(sub.Missed) != 0 {
+		t.Errorf("resuming from the newest ID = %v, %v; want nothing missed", sub.Missed, err)
+	}
+}
+
+func TestNotificationBusDisconnectsSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	bus := NewNotificationBus(0)
+	sub, _ := bus.Subscribe(1, 0)
+	for i := 0; i < notificationSubscriberBuffer+1; i++ {
+		bus.Publish(ctx, &domain.Notification{Type: domain.NotificationEventUpdated, UserIDs: []int64{1}})
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	if received != notificationSubscriberBuffer {
+		t.Errorf("slow subscriber received %d notifications, want %d before disconnecting", received, notificationSubscriberBuffer)
+	}
+	sub.Close() // no-op once disconnected
+
+	resumed, err := bus.Subscribe(1, sub.LastID+notificationSubscriberBuffer)
+	if err != nil || len(resumed.Missed) != 1 {
+		t.Errorf("resuming after the last received = %d missed, %v; want 1", len(resumed.Missed), err)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+	"github.com/airplne/calendar-app/server/internal/recurrence"
+)
+
+const (
+	// DefaultReminderInterval is how often the scheduler looks for due alarms.
+	DefaultReminderInterval = 30 * time.Second
+
+	// DefaultReminderCatchUp is how far back the scheduler looks for triggers
+	// that have not fired, e.g. because the server was down. Older triggers
+	// are dropped rather than delivered long after the fact.
+	DefaultReminderCatchUp = 12 * time.Hour
+)
+
+// ReminderService fires the VALARMs of stored events. Each trigger is
+// recorded before it is sent, so a reminder goes out at most once even
+// across restarts, and triggers missed while the server was down are caught
+// up on the next scan.
+type ReminderService struct {
+	alarms       domain.AlarmRepo
+	calendars    domain.CalendarRepo
+	acknowledger domain.AlarmAcknowledger
+	channels     []domain.ReminderChannel
+	catchUp      time.Duration
+	now          func() time.Time
+}
+
+func NewReminderService(alarms domain.AlarmRepo, calendars domain.CalendarRepo, acknowledger domain.AlarmAcknowledger, channels ...domain.ReminderChannel) *ReminderService {
+	return &ReminderService{
+		alarms:       alarms,
+		calendars:    calendars,
+		acknowledger: acknowledger,
+		channels:     channels,
+		catchUp:      DefaultReminderCatchUp,
+		now:          time.Now,
+	}
+}
+
+// Tick fires every trigger that came due since the catch-up window began and
+// has not fired yet. It returns how many reminders it fired. Triggers that
+// were already past when their event was stored do not fire.
+func (s *ReminderService) Tick(ctx context.Context) (int, error) {
+	now := s.now()
+	from := now.Add(-s.catchUp)
+	events, err := s.alarms.ListDueEvents(ctx, from, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list events with due alarms: %w", err)
+	}
+
+	owners := make(map[int64]int64)
+	fired := 0
+	for _, event := range events {
+		cal, err := ical.NewDecoder(strings.NewReader(event.ICS)).Decode()
+		if err != nil {
+			slog.Warn("reminders.event.unparseable", "event_id", event.ID, "error", err)
+			continue
+		}
+		triggers, err := recurrence.Triggers(cal, ical.CompEvent, from, now, time.UTC)
+		if err != nil {
+			slog.Warn("reminders.event.unexpandable", "event_id", event.ID, "error", err)
+			continue
+		}
+		for _, trigger := range triggers {
+			if trigger.At.Before(event.CreatedAt) {
+				continue
+			}
+			userID, ok := owners[event.CalendarID]
+			if !ok {
+				calendar, err := s.calendars.GetByID(ctx, event.CalendarID)
+				if err != nil {
+					return fired, fmt.Errorf("failed to get calendar: %w", err)
+				}
+				userID = calendar.UserID
+				owners[event.CalendarID] = userID
+			}
+
+			reminder := &domain.Reminder{
+				UserID:        userID,
+				EventID:       event.ID,
+				CalendarID:    event.CalendarID,
+				UID:           event.UID,
+				AlarmKey:      trigger.Alarm.Key,
+				Action:        trigger.Alarm.Action,
+				Summary:       event.Summary,
+				Description:   trigger.Alarm.Description,
+				Occurrence:    trigger.Occurrence,
+				InstanceStart: trigger.Instance.Start,
+				TriggerAt:     trigger.At,
+				FiredAt:       now,
+			}
+			if summary := trigger.Instance.Component.Props.Get(ical.PropSummary); summary != nil {
+				reminder.Summary = summary.Value
+			}
+			if err := s.alarms.RecordFiring(ctx, reminder); err == domain.ErrConflict {
+				continue
+			} else if err != nil {
+				return fired, err
+			}
+			fired++
+			s.notify(ctx, reminder)
+		}
+	}
+	return fired, nil
+}
+
+// notify hands a fired reminder to every channel. A failing channel does not
+// keep the others from delivering it; the reminder is not retried.
+func (s *ReminderService) notify(ctx context.Context, reminder *domain.Reminder) {
+	slog.Info("reminders.fired", "user_id", reminder.UserID, "event_id", reminder.EventID, "alarm", reminder.AlarmKey, "action", reminder.Action)
+	for _, channel := range s.channels {
+		if err := channel.Notify(ctx, reminder); err != nil {
+			slog.Warn("reminders.channel.failed", "reminder_id", reminder.ID, "error", err)
+		}
+	}
+}
+
+// Run fires due reminders every interval until ctx is done.
+func (s *ReminderService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("reminders.tick.failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListRecent returns the reminders fired for a user since the given time,
+// newest first.
+func (s *ReminderService) ListRecent(ctx context.Context, userID int64, since time.Time) ([]*domain.Reminder, error) {
+	return s.alarms.ListFirings(ctx, userID, since)
+}
+
+// Acknowledge dismisses a reminder of the user and writes ACKNOWLEDGED into
+// its VALARM, so other clients stop showing the alarm. Returns
+// domain.ErrNotFound for reminders of other users.
+func (s *ReminderService) Acknowledge(ctx context.Context, userID, reminderID int64) (*domain.Reminder, error) {
+	reminder, err := s.alarms.GetFiring(ctx, reminderID)
+	if err != nil {
+		return nil, err
+	}
+	if reminder.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	now := s.now()
+	if err := s.alarms.AcknowledgeFiring(ctx, reminderID, now); err != nil {
+		return nil, err
+	}
+	if reminder.AcknowledgedAt == nil {
+		reminder.AcknowledgedAt = &now
+	}
+	// The alarm may have been edited away since it fired
+	if err := s.acknowledger.AcknowledgeAlarm(ctx, reminder.EventID, reminder.AlarmKey, now); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to write back acknowledgement: %w", err)
+	}
+	return reminder, nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// fakeAlarmRepo returns every event as due and records firings in memory.
+type fakeAlarmRepo struct {
+	events  []*domain.Event
+	firings []*domain.Reminder
+}
+
+func (f *fakeAlarmRepo) ListDueEvents(ctx context.Context, from, to time.Time) ([]*domain.Event, error) {
+	return f.events, nil
+}
+
+func (f *fakeAlarmRepo) RecordFiring(ctx context.Context, reminder *domain.Reminder) error {
+	for _, r := range f.firings {
+		if r.EventID == reminder.EventID && r.AlarmKey == reminder.AlarmKey && r.Occurrence.Equal(reminder.Occurrence) && r.TriggerAt.Equal(reminder.TriggerAt) {
+			return domain.ErrConflict
+		}
+	}
+	reminder.ID = int64(len(f.firings) + 1)
+	copied := *reminder
+	f.firings = append(f.firings, &copied)
+	return nil
+}
+
+func (f *fakeAlarmRepo) GetFiring(ctx context.Context, id int64) (*domain.Reminder, error) {
+	for _, r := range f.firings {
+		if r.ID == id {
+			copied := *r
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeAlarmRepo) ListFirings(ctx context.Context, userID int64, since time.Time) ([]*domain.Reminder, error) {
+	var result []*domain.Reminder
+	for i := len(f.firings) - 1; i >= 0; i-- {
+		if r := f.firings[i]; r.UserID == userID && !r.FiredAt.Before(since) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAlarmRepo) AcknowledgeFiring(ctx context.Context, id int64, at time.Time) error {
+	for _, r := range f.firings {
+		if r.ID == id {
+			if r.AcknowledgedAt == nil {
+				r.AcknowledgedAt = &at
+			}
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+type recordingReminderChannel struct {
+	reminders []*domain.Reminder
+	err       error
+}
+
+func (c *recordingReminderChannel) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	c.reminders = append(c.reminders, reminder)
+	return c.err
+}
+
+type recordingAcknowledger struct {
+	alarms []string
+}
+
+func (a *recordingAcknowledger) AcknowledgeAlarm(ctx context.Context, eventID int64, alarmKey string, at time.Time) error {
+	a.alarms = append(a.alarms, alarmKey)
+	return nil
+}
+
+const weeklyReviewICS = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VEVENT\r\nUID:review\r\nDTSTAMP:20260101T000000Z\r\n" +
+	"DTSTART:20260302T090000Z\r\nDTEND:20260302T100000Z\r\nRRULE:FREQ=WEEKLY\r\nSUMMARY:Weekly review\r\n" +
+	"BEGIN:VALARM\r\nUID:remind\r\nACTION:DISPLAY\r\nDESCRIPTION:Review soon\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\n" +
+	"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+func newTestReminderService(now time.Time) (*ReminderService, *fakeAlarmRepo, *recordingReminderChannel, *recordingAcknowledger) {
+	alarms := &fakeAlarmRepo{events: []*domain.Event{{
+		ID: 7, CalendarID: 3, UID: "review", ICS: weeklyReviewICS, Summary: "Weekly review",
+		CreatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}}}
+	calendars := &fakeCalendarRepo{calendars: map[string]*domain.Calendar{"default": {ID: 3, UserID: 42, Name: "default"}}}
+	channel := &recordingReminderChannel{}
+	acknowledger := &recordingAcknowledger{}
+	service := NewReminderService(alarms, calendars, acknowledger, channel)
+	service.now = func() time.Time { return now }
+	return service, alarms, channel, acknowledger
+}
+
+func TestReminderServiceTick(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 9, 8, 50, 0, 0, time.UTC)
+	service, alarms, channel, _ := newTestReminderService(now)
+
+	// The second week's trigger (08:45) is due; the first week's is older
+	// than the catch-up window.
+	fired, err := service.Tick(ctx)
+	if err != nil || fired != 1 {
+		t.Fatalf("Tick() = %d, %v; want 1 reminder", fired, err)
+	}
+	if len(channel.reminders) != 1 {
+		t.Fatalf("channel got %d reminders, want 1", len(channel.reminders))
+	}
+	got := channel.reminders[0]
+	if got.UserID != 42 || got.AlarmKey != "remind" || got.Summary != "Weekly review" || got.Description != "Review soon" {
+		t.Errorf("unexpected reminder: %+v", got)
+	}
+	if want := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC); !got.Occurrence.Equal(want) || !got.TriggerAt.Equal(want.Add(-15*time.Minute)) {
+		t.Errorf("reminder for %v at %v, want %v at 08:45", got.Occurrence, got.TriggerAt, want)
+	}
+
+	// A restart scans the same window without firing again
+	restarted := NewReminderService(alarms, &fakeCalendarRepo{calendars: map[string]*domain.Calendar{"default": {ID: 3, UserID: 42}}}, &recordingAcknowledger{}, channel)
+	restarted.now = service.now
+	if fired, err := restarted.Tick(ctx); err != nil || fired != 0 {
+		t.Fatalf("Tick() after restart = %d, %v; want 0", fired, err)
+	}
+	if len(channel.reminders) != 1 {
+		t.Errorf("channel got %d reminders after restart, want 1", len(channel.reminders))
+	}
+}
+
+func TestReminderServiceTickSkipsTriggersBeforeCreation(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 50, 0, 0, time.UTC)
+	service, alarms, channel, _ := newTestReminderService(now)
+	alarms.events[0].CreatedAt = now.Add(-time.Minute)
+
+	if fired, err := service.Tick(context.Background()); err != nil || fired != 0 {
+		t.Fatalf("Tick() = %d, %v; want 0 for an alarm already past when stored", fired, err)
+	}
+	if len(channel.reminders) != 0 {
+		t.Errorf("channel got %d reminders, want 0", len(channel.reminders))
+	}
+}
+
+func TestReminderServiceTickChannelFailure(t *testing.T) {
+	service, alarms, channel, _ := newTestReminderService(time.Date(2026, 3, 9, 8, 50, 0, 0, time.UTC))
+	channel.err = errors.New("unreachable")
+	second := &recordingReminderChannel{}
+	service.channels = append(service.channels, second)
+
+	if fired, err := service.Tick(context.Background()); err != nil || fired != 1 {
+		t.Fatalf("Tick() = %d, %v; want 1", fired, err)
+	}
+	if len(second.reminders) != 1 || len(alarms.firings) != 1 {
+		t.Errorf("a failing channel must not stop the others: got %d, recorded %d", len(second.reminders), len(alarms.firings))
+	}
+}
+
+func TestReminderServiceAcknowledge(t *testing.T) {
+	ctx := context.Background()
+	service, _, _, acknowledger := newTestReminderService(time.Date(2026, 3, 9, 8, 50, 0, 0, time.UTC))
+	if _, err := service.Tick(ctx); err != nil {
+		t.Fatalf("Tick() error = %v", err)
+	}
+
+	if _, err := service.Acknowledge(ctx, 99, 1); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Acknowledge() by another user error = %v, want ErrNotFound", err)
+	}
+	reminder, err := service.Acknowledge(ctx, 42, 1)
+	if err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if reminder.AcknowledgedAt == nil {
+		t.Error("Acknowledge() should set AcknowledgedAt")
+	}
+	if len(acknowledger.alarms) != 1 || acknowledger.alarms[0] != "remind" {
+		t.Errorf("ACKNOWLEDGED written for %v, want [remind]", acknowledger.alarms)
+	}
+	recent, err := service.ListRecent(ctx, 42, time.Time{})
+	if err != nil || len(recent) != 1 || recent[0].AcknowledgedAt == nil {
+		t.Errorf("ListRecent() = %v, %v; want the acknowledged reminder", recent, err)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"slices"
 	"sort"
 	"time"
 
@@ -38,6 +39,9 @@ type SyncHealthService struct {
 	greenSync  GreenSyncProvider
 	evaluator   domain.SyncHealthEvaluator
 	limit       int
+
+	// Status changes are published when set; see PublishTransitions
+	transitions *syncHealthTransitions
 }
 
 func NewSyncHealthService(operations CalDAVOperationLister, greenSync GreenSyncProvider) *SyncHealthService {
@@ -95,6 +99,7 @@ type SyncClientSummary struct {
 	LastSeenAt        time.Time
 	OperationCount    int
 	OperationCount24h int
+	Credentials       []string // Credential labels the client signed in with, most recent first
 }
 
 func (s *SyncHealthService) Summary(ctx context.Context) (*SyncHealthSummary, error) {
@@ -112,6 +117,7 @@ func (s *SyncHealthService) Summary(ctx context.Context) (*SyncHealthSummary, er
 		GreenSync:  greenSync,
 		Operations: domain.SummarizeCalDAVOperations(operations),
 	})
+	s.transitions.observe(ctx, health)
 
 	return &SyncHealthSummary{
 		Health:          health,
@@ -205,6 +211,10 @@ func SummarizeClients(operations []*domain.CalDAVOperation, since time.Time) []S
 		if client.LastSeenAt.IsZero() || op.OccurredAt.After(client.LastSeenAt) {
 			client.LastSeenAt = op.OccurredAt
 		}
+		// Operations arrive newest first, so the first label seen is the current one
+		if op.Credential != "" && !slices.Contains(client.Credentials, op.Credential) {
+			client.Credentials = append(client.Credentials, op.Credential)
+		}
 	}
 
 	clients := make([]SyncClientSummary, 0, len(byClient))
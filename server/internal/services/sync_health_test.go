@@ -134,6 +134,33 @@ func TestSyncHealthServiceCriticalForCorruptICSAndDuplicateUID(t *testing.T) {
 	assertReason(t, summary.Health.Reasons, domain.SyncHealthReasonDuplicateUIDUnresolved)
 }
 
+func TestSummarizeClientsListsCredentials(t *testing.T) {
+	now := time.Now().UTC()
+	operations := []*domain.CalDAVOperation{
+		{OccurredAt: now, ClientFingerprint: domain.CalDAVClientAppleCalendar, Credential: domain.AppPasswordCredential(2)},
+		{OccurredAt: now.Add(-time.Minute), ClientFingerprint: domain.CalDAVClientAppleCalendar, Credential: domain.AppPasswordCredential(2)},
+		{OccurredAt: now.Add(-time.Hour), ClientFingerprint: domain.CalDAVClientAppleCalendar, Credential: domain.CredentialAccountPassword},
+		{OccurredAt: now, ClientFingerprint: domain.CalDAVClientThunderbird},
+	}
+
+	clients := SummarizeClients(operations, now.Add(-24*time.Hour))
+	if len(clients) != 2 {
+		t.Fatalf("clients = %+v", clients)
+	}
+	for _, client := range clients {
+		switch client.Fingerprint {
+		case domain.CalDAVClientAppleCalendar:
+			if len(client.Credentials) != 2 || client.Credentials[0] != "app-password:2" || client.Credentials[1] != domain.CredentialAccountPassword {
+				t.Fatalf("apple credentials = %v, want current app password first", client.Credentials)
+			}
+		case domain.CalDAVClientThunderbird:
+			if len(client.Credentials) != 0 {
+				t.Fatalf("thunderbird credentials = %v, want none", client.Credentials)
+			}
+		}
+	}
+}
+
 func passedGreenSync(completedAt time.Time) domain.GreenSyncValidation {
 	return domain.GreenSyncValidation{
 		Status:           domain.GreenSyncPassed,
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// DefaultSyncHealthWatchInterval is how often Watch re-evaluates Sync Health.
+const DefaultSyncHealthWatchInterval = time.Minute
+
+// PublishTransitions makes every summary whose status differs from the
+// previous one publish a sync_health.changed notification to every user,
+// whether the summary was asked for through the API or by Watch. The first
+// summary only sets the baseline.
+func (s *SyncHealthService) PublishTransitions(publisher domain.NotificationPublisher) {
+	s.transitions = &syncHealthTransitions{publisher: publisher}
+}
+
+// Watch evaluates Sync Health every interval until ctx is done, so status
+// changes are published while nobody has the Sync Health page open.
+func (s *SyncHealthService) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Summary(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("sync_health.watch.failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type syncHealthTransitions struct {
+	publisher domain.NotificationPublisher
+	mu        sync.Mutex
+	last      domain.SyncHealthStatus
+}
+
+func (t *syncHealthTransitions) observe(ctx context.Context, health domain.SyncHealth) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	previous := t.last
+	t.last = health.Status
+	t.mu.Unlock()
+	if previous == "" || previous == health.Status {
+		return
+	}
+
+	slog.Info("sync_health.status_changed", "from", previous, "to", health.Status)
+	t.publisher.Publish(ctx, &domain.Notification{
+		Type:       domain.NotificationSyncHealthChanged,
+		OccurredAt: health.EvaluatedAt,
+		SyncHealth: &domain.SyncHealthTransition{From: previous, To: health.Status, Reasons: health.Reasons},
+	})
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type recordingPublisher struct {
+	notifications []*domain.Notification
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, n *domain.Notification) {
+	p.notifications = append(p.notifications, n)
+}
+
+func TestSyncHealthServicePublishesTransitions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	lister := &fakeOperationLister{}
+	service := NewSyncHealthService(lister, StaticGreenSyncProvider{Validation: passedGreenSync(now)})
+	publisher := &recordingPublisher{}
+	service.PublishTransitions(publisher)
+
+	summarize := func(want domain.SyncHealthStatus) {
+		t.Helper()
+		summary, err := service.Summary(ctx)
+		if err != nil {
+			t.Fatalf("Summary() error = %v", err)
+		}
+		if summary.Health.Status != want {
+			t.Fatalf("status = %q, want %q", summary.Health.Status, want)
+		}
+	}
+
+	// The first summary sets the baseline; unchanged statuses are not published
+	summarize(domain.SyncHealthUnknown)
+	summarize(domain.SyncHealthUnknown)
+	if len(publisher.notifications) != 0 {
+		t.Fatalf("published %d notifications without a status change", len(publisher.notifications))
+	}
+
+	lister.operations = []*domain.CalDAVOperation{{
+		OccurredAt:    now,
+		Method:        "GET",
+		StatusCode:    500,
+		OperationKind: domain.CalDAVOperationRead,
+		Outcome:       domain.CalDAVOperationIntegrityFailure,
+		ErrorCode:     domain.CalDAVErrorCorruptICS,
+	}}
+	summarize(domain.SyncHealthCritical)
+	if len(publisher.notifications) != 1 {
+		t.Fatalf("published %d notifications, want 1", len(publisher.notifications))
+	}
+	n := publisher.notifications[0]
+	if n.Type != domain.NotificationSyncHealthChanged || len(n.UserIDs) != 0 || n.SyncHealth == nil {
+		t.Fatalf("notification = %+v, want a sync_health.changed broadcast", n)
+	}
+	if n.SyncHealth.From != domain.SyncHealthUnknown || n.SyncHealth.To != domain.SyncHealthCritical {
+		t.Errorf("transition = %s -> %s, want unknown -> critical", n.SyncHealth.From, n.SyncHealth.To)
+	}
+	assertReason(t, n.SyncHealth.Reasons, domain.SyncHealthReasonCorruptICSDetected)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+// MinPasswordLength is the shortest password accepted for an account.
+const MinPasswordLength = 8
+
+// usernamePattern keeps usernames safe to embed in principal and calendar
+// home URLs.
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+var (
+	ErrInvalidUsername = errors.New("username must be 1-64 lowercase letters, digits, '.', '_' or '-'")
+	ErrWeakPassword    = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	ErrInvalidEmail    = errors.New("email must be a plain address such as alice@example.com")
+)
+
+// dummyPasswordHash is compared against when the username does not exist, so
+// unknown and known usernames take the same time to reject.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("calendar-app-dummy-password"), bcrypt.DefaultCost)
+
+// UserService manages accounts and verifies their credentials.
+type UserService struct {
+	users     domain.UserRepo
+	calendars domain.CalendarRepo
+	now       func() time.Time
+}
+
+func NewUserService(users domain.UserRepo, calendars domain.CalendarRepo) *UserService {
+	return &UserService{users: users, calendars: calendars, now: time.Now}
+}
+
+// HashPassword returns the bcrypt hash stored for password.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Authenticate checks a username and password against the stored hash.
+// Returns domain.ErrInvalidCredentials for unknown users, wrong passwords and
+// accounts without a password, and domain.ErrUserDisabled for disabled accounts
+// whose password is correct.
+func (s *UserService) Authenticate(ctx context.Context, username, password string) (*domain.User, error) {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, err
+	}
+	if user == nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, domain.ErrInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+	return user, nil
+}
+
+// CreateUser adds an account with its default and tasks calendars.
+// Returns domain.ErrConflict when the username is taken.
+func (s *UserService) CreateUser(ctx context.Context, username, password string, admin bool) (*domain.User, error) {
+	if !usernamePattern.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.CreateWithPassword(ctx, username, hash, admin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ProvisionCalendars(ctx, user); err != nil {
+		return nil, err
+	}
+
+	slog.Info("user.created", "username", username, "admin", admin)
+	return user, nil
+}
+
+// EnsureBootstrapUser makes sure the account configured through
+// CALENDARAPP_USER/CALENDARAPP_PASS exists and can sign in. An account without
+// a stored password (first start, or an install that predates per-user
+// passwords) gets the configured password and becomes an admin. Once stored,
+// the password is managed like any other: changing CALENDARAPP_PASS does not
+// reset it.
+func (s *UserService) EnsureBootstrapUser(ctx context.Context, username, password string) (*domain.User, error) {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err == domain.ErrNotFound {
+		user, err = s.users.Create(ctx, username)
+		if err == nil {
+			slog.Info("Created default user", "username", username)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == "" {
+		// Not HashPassword: existing deployments may use shorter passwords
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		user.PasswordHash = string(hash)
+		user.IsAdmin = true
+		if err := s.users.Update(ctx, user); err != nil {
+			return nil, err
+		}
+		slog.Info("Stored bootstrap credentials", "username", username)
+	}
+
+	if err := s.ProvisionCalendars(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ProvisionCalendars creates the default calendar and the VTODO collection of
+// a user when they are missing.
+func (s *UserService) ProvisionCalendars(ctx context.Context, user *domain.User) error {
+	defaults := []*domain.Calendar{
+		{UserID: user.ID, Name: domain.DefaultCalendarName, DisplayName: "Calendar"},
+		{UserID: user.ID, Name: "tasks", DisplayName: "Tasks", ComponentSet: []string{domain.ComponentTodo}},
+	}
+	for _, cal := range defaults {
+		_, err := s.calendars.GetByName(ctx, user.ID, cal.Name)
+		if err == nil {
+			continue
+		}
+		if err != domain.ErrNotFound {
+			return err
+		}
+		if err := s.calendars.Create(ctx, cal); err != nil {
+			return fmt.Errorf("failed to create %s calendar: %w", cal.Name, err)
+		}
+		slog.Info("Created calendar for user", "username", user.Username, "calendar", cal.Name)
+	}
+	return nil
+}
+
+// ResetPassword replaces a user's password and signs out its sessions. With
+// revokeCredentials, the user's app passwords and personal access tokens are
+// revoked too, for when the account may have been compromised.
+func (s *UserService) ResetPassword(ctx context.Context, username, password string, revokeCredentials bool) (*domain.User, error) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.SetPassword(ctx, user.ID, hash, revokeCredentials); err != nil {
+		return nil, err
+	}
+	user.PasswordHash = hash
+	slog.Info("user.password_reset", "username", username, "credentials_revoked", revokeCredentials)
+	return user, nil
+}
+
+// SetDisabled disables or re-enables an account.
+func (s *UserService) SetDisabled(ctx context.Context, username string, disabled bool) (*domain.User, error) {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if disabled == user.Disabled() {
+		return user, nil
+	}
+	user.DisabledAt = nil
+	if disabled {
+		now := s.now().UTC()
+		user.DisabledAt = &now
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	slog.Info("user.disabled_changed", "username", username, "disabled", disabled)
+	return user, nil
+}
+
+// SetEmail sets the address a user is known by in ORGANIZER and ATTENDEE
+// properties, or clears it when email is empty. Returns domain.ErrConflict
+// when another user has the address.
+func (s *UserService) SetEmail(ctx context.Context, username, email string) (*domain.User, error) {
+	email = strings.TrimSpace(email)
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return nil, ErrInvalidEmail
+		}
+	}
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = email
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	slog.Info("user.email_changed", "username", username)
+	return user, nil
+}
+
+// List returns every account.
+func (s *UserService) List(ctx context.Context) ([]*domain.User, error) {
+	return s.users.List(ctx)
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type fakeUserRepo struct {
+	users map[string]*domain.User
+	// revoked lists the users whose credentials SetPassword revoked
+	revoked []int64
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: make(map[string]*domain.User)}
+}
+
+func (f *fakeUserRepo) Create(ctx context.Context, username string) (*domain.User, error) {
+	return f.CreateWithPassword(ctx, username, "", false)
+}
+
+func (f *fakeUserRepo) CreateWithPassword(ctx context.Context, username, passwordHash string, isAdmin bool) (*domain.User, error) {
+	if _, ok := f.users[username]; ok {
+		return nil, domain.ErrConflict
+	}
+	user := &domain.User{ID: int64(len(f.users) + 1), Username: username, PasswordHash: passwordHash, IsAdmin: isAdmin}
+	f.users[username] = user
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUserRepo) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	for _, user := range f.users {
+		if user.ID == id {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeUserRepo) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	user, ok := f.users[username]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range f.users {
+		if user.Email != "" && strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeUserRepo) List(ctx context.Context) ([]*domain.User, error) {
+	var users []*domain.User
+	for _, user := range f.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (f *fakeUserRepo) Update(ctx context.Context, user *domain.User) error {
+	if _, ok := f.users[user.Username]; !ok {
+		return domain.ErrNotFound
+	}
+	if other, err := f.GetByEmail(ctx, user.Email); err == nil && other.ID != user.ID {
+		return domain.ErrConflict
+	}
+	copied := *user
+	f.users[user.Username] = &copied
+	return nil
+}
+
+func (f *fakeUserRepo) SetPassword(ctx context.Context, userID int64, passwordHash string, revokeCredentials bool) error {
+	for _, user := range f.users {
+		if user.ID == userID {
+			user.PasswordHash = passwordHash
+			if revokeCredentials {
+				f.revoked = append(f.revoked, userID)
+			}
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+// fakeCalendarRepo records provisioned calendars; other methods are unused.
+type fakeCalendarRepo struct {
+	domain.CalendarRepo
+	calendars map[string]*domain.Calendar
+}
+
+func (f *fakeCalendarRepo) GetByName(ctx context.Context, userID int64, name string) (*domain.Calendar, error) {
+	if cal, ok := f.calendars[name]; ok && cal.UserID == userID {
+		return cal, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeCalendarRepo) GetByID(ctx context.Context, id int64) (*domain.Calendar, error) {
+	for _, cal := range f.calendars {
+		if cal.ID == id {
+			return cal, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeCalendarRepo) Create(ctx context.Context, cal *domain.Calendar) error {
+	f.calendars[cal.Name] = cal
+	return nil
+}
+
+func newTestUserService() (*UserService, *fakeUserRepo, *fakeCalendarRepo) {
+	users := newFakeUserRepo()
+	calendars := &fakeCalendarRepo{calendars: make(map[string]*domain.Calendar)}
+	return NewUserService(users, calendars), users, calendars
+}
+
+func TestUserServiceAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	service, users, calendars := newTestUserService()
+
+	if _, err := service.CreateUser(ctx, "alice", "correct horse", false); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, ok := calendars.calendars[domain.DefaultCalendarName]; !ok {
+		t.Fatal("CreateUser() should provision the default calendar")
+	}
+
+	user, err := service.Authenticate(ctx, "alice", "correct horse")
+	if err != nil || user.Username != "alice" {
+		t.Fatalf("Authenticate() = %v, %v; want alice", user, err)
+	}
+	if user.PasswordHash == "correct horse" {
+		t.Fatal("password must be stored hashed")
+	}
+	if _, err := service.Authenticate(ctx, "alice", "wrong password"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := service.Authenticate(ctx, "nobody", "correct horse"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := service.SetDisabled(ctx, "alice", true); err != nil {
+		t.Fatalf("SetDisabled() error = %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "alice", "correct horse"); !errors.Is(err, domain.ErrUserDisabled) {
+		t.Fatalf("disabled user error = %v, want ErrUserDisabled", err)
+	}
+	if _, err := service.SetDisabled(ctx, "alice", false); err != nil {
+		t.Fatalf("SetDisabled(false) error = %v", err)
+	}
+
+	if _, err := service.ResetPassword(ctx, "alice", "battery staple", false); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if len(users.revoked) != 0 {
+		t.Fatalf("ResetPassword() revoked credentials of %v without being asked", users.revoked)
+	}
+	if _, err := service.Authenticate(ctx, "alice", "correct horse"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatal("old password must stop working after a reset")
+	}
+	if _, err := service.Authenticate(ctx, "alice", "battery staple"); err != nil {
+		t.Fatalf("new password error = %v", err)
+	}
+	if _, err := service.ResetPassword(ctx, "alice", "another battery", true); err != nil {
+		t.Fatalf("ResetPassword(revoke) error = %v", err)
+	}
+	if len(users.revoked) != 1 {
+		t.Fatalf("ResetPassword(revoke) revoked %v, want alice's credentials", users.revoked)
+	}
+}
+
+func TestUserServiceCreateUserValidation(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newTestUserService()
+
+	if _, err := service.CreateUser(ctx, "Bad Name", "long enough", false); !errors.Is(err, ErrInvalidUsername) {
+		t.Fatalf("invalid username error = %v", err)
+	}
+	if _, err := service.CreateUser(ctx, "bob", "short", false); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("short password error = %v", err)
+	}
+	if _, err := service.CreateUser(ctx, "bob", "long enough", false); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := service.CreateUser(ctx, "bob", "long enough", false); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("duplicate username error = %v, want ErrConflict", err)
+	}
+}
+
+func TestUserServiceEnsureBootstrapUser(t *testing.T) {
+	ctx := context.Background()
+	service, users, _ := newTestUserService()
+
+	// An install that predates per-user passwords has the user but no hash
+	if _, err := users.Create(ctx, "owner"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	user, err := service.EnsureBootstrapUser(ctx, "owner", "envpass")
+	if err != nil {
+		t.Fatalf("EnsureBootstrapUser() error = %v", err)
+	}
+	if !user.IsAdmin || user.PasswordHash == "" {
+		t.Fatal("bootstrap user should become an admin with a stored password")
+	}
+
+	// Later starts keep the stored password even if the environment changes
+	if _, err := service.EnsureBootstrapUser(ctx, "owner", "changed-env-pass"); err != nil {
+		t.Fatalf("EnsureBootstrapUser() second call error = %v", err)
+	}
+	if _, err := service.Authenticate(ctx, "owner", "envpass"); err != nil {
+		t.Fatalf("stored bootstrap password error = %v", err)
+	}
+}
+
+func TestUserServiceSetEmail(t *testing.T) {
+	ctx := context.Background()
+	service, users, _ := newTestUserService()
+	for _, username := range []string{"alice", "bob"} {
+		if _, err := service.CreateUser(ctx, username, "correct horse", false); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+	}
+
+	if _, err := service.SetEmail(ctx, "alice", "not an address"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("SetEmail(invalid) error = %v, want ErrInvalidEmail", err)
+	}
+	user, err := service.SetEmail(ctx, "alice", " Alice@Example.com ")
+	if err != nil || user.Email != "Alice@Example.com" {
+		t.Fatalf("SetEmail() = %+v, %v", user, err)
+	}
+	if found, err := users.GetByEmail(ctx, "alice@example.com"); err != nil || found.Username != "alice" {
+		t.Errorf("GetByEmail() = %+v, %v; want alice", found, err)
+	}
+	if _, err := service.SetEmail(ctx, "bob", "alice@example.com"); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("SetEmail(taken) error = %v, want ErrConflict", err)
+	}
+	if user, err := service.SetEmail(ctx, "alice", ""); err != nil || user.Email != "" {
+		t.Errorf("SetEmail(\"\") = %+v, %v; want the email cleared", user, err)
+	}
+}
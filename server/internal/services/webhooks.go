@@ -0,0 +1,468 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+const (
+	// DefaultWebhookInterval is how often the queue is checked for retries
+	// that came due. New deliveries are sent right away.
+	DefaultWebhookInterval = 15 * time.Second
+
+	// DefaultWebhookRetention is how long delivered and dead deliveries stay
+	// in the delivery log.
+	DefaultWebhookRetention = 30 * 24 * time.Hour
+
+	// MaxWebhookDeliveryLog bounds the delivery log returned at once.
+	MaxWebhookDeliveryLog = 200
+
+	// A failed delivery is retried after 30s, 1m, 2m, ... and goes dead after
+	// webhookMaxAttempts attempts, a little over an hour after it was queued.
+	webhookMaxAttempts = 8
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = time.Hour
+
+	// webhookBatch is how many due deliveries one tick sends.
+	webhookBatch = 50
+
+	// webhookMaxErrorLength bounds the error kept with a failed attempt.
+	webhookMaxErrorLength = 512
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an http or https URL")
+	ErrInvalidWebhookEventType = errors.New("unknown webhook event type")
+	ErrWebhookURLNotAllowed    = errors.New("webhook URL must point to a public address")
+)
+
+// webhookEventTypes are the filters a subscription may use. A family such as
+// "event.*" matches every type that starts with "event.".
+var webhookEventTypes = map[domain.NotificationType]bool{
+	domain.NotificationEventCreated:      true,
+	domain.NotificationEventUpdated:      true,
+	domain.NotificationEventDeleted:      true,
+	domain.NotificationCalendarCreated:   true,
+	domain.NotificationCalendarUpdated:   true,
+	domain.NotificationCalendarDeleted:   true,
+	domain.NotificationCalendarShared:    true,
+	domain.NotificationCalendarUnshared:  true,
+	domain.NotificationSyncHealthChanged: true,
+	domain.NotificationReminder:          true,
+	"event.*":                            true,
+	"calendar.*":                         true,
+}
+
+// WebhookService manages users' webhook subscriptions and delivers the
+// notifications they subscribed to. Every matching notification is queued in
+// the database before it is sent, so deliveries survive restarts; failed
+// ones are retried with exponential backoff until they go dead.
+type WebhookService struct {
+	webhooks  domain.WebhookRepo
+	sender    domain.WebhookSender
+	retention time.Duration
+	now       func() time.Time
+	wake      chan struct{}
+}
+
+func NewWebhookService(webhooks domain.WebhookRepo, sender domain.WebhookSender) *WebhookService {
+	return &WebhookService{
+		webhooks:  webhooks,
+		sender:    sender,
+		retention: DefaultWebhookRetention,
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Create subscribes a URL to the given notification types, or to every type
+// when there are none. The returned subscription carries the generated
+// signing secret, which is shown to the user only in this response.
+func (s *WebhookService) Create(ctx context.Context, user *domain.User, endpoint string, eventTypes []string, includeContent bool) (*domain.WebhookSubscription, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if checker, ok := s.sender.(domain.WebhookURLChecker); ok {
+		if checker.CheckURL(u) != nil {
+			return nil, ErrWebhookURLNotAllowed
+		}
+	}
+	var types []domain.NotificationType
+	seen := make(map[domain.NotificationType]bool)
+	for _, value := range eventTypes {
+		t := domain.NotificationType(strings.TrimSpace(value))
+		if !webhookEventTypes[t] {
+			return nil, ErrInvalidWebhookEventType
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	subscription := &domain.WebhookSubscription{
+		UserID:         user.ID,
+		URL:            endpoint,
+		Secret:         secret,
+		EventTypes:     types,
+		IncludeContent: includeContent,
+	}
+	if err := s.webhooks.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	slog.Info("webhook.created", "username", user.Username, "webhook_id", subscription.ID, "include_content", includeContent)
+	return subscription, nil
+}
+
+// List returns a user's subscriptions, newest first.
+func (s *WebhookService) List(ctx context.Context, userID int64) ([]*domain.WebhookSubscription, error) {
+	return s.webhooks.ListSubscriptionsByUser(ctx, userID)
+}
+
+// Delete removes a subscription and its queued and logged deliveries.
+// Returns domain.ErrNotFound when the user has no subscription with this ID.
+func (s *WebhookService) Delete(ctx context.Context, user *domain.User, id int64) error {
+	if err := s.webhooks.DeleteSubscription(ctx, user.ID, id); err != nil {
+		return err
+	}
+	slog.Info("webhook.deleted", "username", user.Username, "webhook_id", id)
+	return nil
+}
+
+// Deliveries returns the most recent deliveries of a subscription, newest
+// first. Returns domain.ErrNotFound when the user has no subscription with
+// this ID.
+func (s *WebhookService) Deliveries(ctx context.Context, userID, id int64, limit int) ([]*domain.WebhookDelivery, error) {
+	subscription, err := s.webhooks.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	if limit <= 0 || limit > MaxWebhookDeliveryLog {
+		limit = MaxWebhookDeliveryLog
+	}
+	return s.webhooks.ListDeliveries(ctx, id, limit)
+}
+
+// Publish implements domain.NotificationPublisher for notifications that are
+// not part of a stored change, such as Sync Health transitions: the
+// notification is queued for every subscription of the users it concerns
+// that matches its type. Publish it after the NotificationBus, which assigns
+// its ID.
+func (s *WebhookService) Publish(ctx context.Context, n *domain.Notification) {
+	queued, err := s.Queue(ctx, s.webhooks, n)
+	if err != nil {
+		slog.Error("webhooks.enqueue.failed", "notification_type", n.Type, "error", err)
+	}
+	if queued > 0 {
+		s.Wake()
+	}
+}
+
+// Queue stores the deliveries of n through webhooks and returns how many it
+// queued. To report a change, pass the repository bound to the transaction
+// that stores the change, so the deliveries commit or roll back with it, and
+// call Wake once it has committed. Only the subscriptions of the users n
+// concerns are read.
+func (s *WebhookService) Queue(ctx context.Context, webhooks domain.WebhookRepo, n *domain.Notification) (int, error) {
+	subscriptions, err := webhooks.ListSubscriptionsByUsers(ctx, n.UserIDs)
+	if err != nil {
+		return 0, err
+	}
+	if n.OccurredAt.IsZero() {
+		n.OccurredAt = s.now().UTC()
+	}
+	payloads := make(map[bool][]byte)
+	queued := 0
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(n.Type) {
+			continue
+		}
+		payload, ok := payloads[subscription.IncludeContent]
+		if !ok {
+			payload, err = json.Marshal(newWebhookPayload(n, subscription.IncludeContent))
+			if err != nil {
+				return queued, err
+			}
+			payloads[subscription.IncludeContent] = payload
+		}
+		now := s.now().UTC()
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventType:      n.Type,
+			Payload:        payload,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		}
+		if err := webhooks.Enqueue(ctx, delivery); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// Wake makes Run send the deliveries that are due without waiting for the
+// next interval.
+func (s *WebhookService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Notify implements domain.ReminderChannel, so fired reminders reach
+// subscriptions to "reminder".
+func (s *WebhookService) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	s.Publish(ctx, &domain.Notification{
+		Type:       domain.NotificationReminder,
+		UserIDs:    []int64{reminder.UserID},
+		OccurredAt: reminder.FiredAt,
+		Reminder:   reminder,
+	})
+	return nil
+}
+
+// Tick sends the deliveries that are due and drops finished deliveries older
+// than the retention period. It returns how many deliveries it attempted.
+func (s *WebhookService) Tick(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+	if err := s.webhooks.PruneDeliveries(ctx, now.Add(-s.retention)); err != nil {
+		return 0, err
+	}
+	due, err := s.webhooks.ListDue(ctx, now, webhookBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[int64]*domain.WebhookSubscription)
+	attempted := 0
+	for _, delivery := range due {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = s.webhooks.GetSubscription(ctx, delivery.SubscriptionID)
+			if errors.Is(err, domain.ErrNotFound) {
+				continue // Deleted since; its deliveries went with it
+			} else if err != nil {
+				return attempted, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		status, sendErr := s.sender.Send(ctx, subscription, delivery)
+		if ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+		attempted++
+		s.recordAttempt(delivery, status, sendErr, s.now().UTC())
+		if err := s.webhooks.RecordAttempt(ctx, delivery); err != nil {
+			return attempted, err
+		}
+	}
+	return attempted, nil
+}
+
+// recordAttempt updates delivery with the outcome of an attempt at the given
+// time and schedules the next one.
+func (s *WebhookService) recordAttempt(delivery *domain.WebhookDelivery, status int, err error, at time.Time) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = &at
+	delivery.LastStatusCode = status
+	if err == nil {
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &at
+		slog.Info("webhooks.delivered", "webhook_id", delivery.SubscriptionID, "delivery_id", delivery.ID, "attempts", delivery.Attempts)
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > webhookMaxErrorLength {
+		delivery.LastError = delivery.LastError[:webhookMaxErrorLength]
+	}
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = domain.WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+		slog.Warn("webhooks.dead", "webhook_id", delivery.SubscriptionID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "status_code", status)
+		return
+	}
+	next := at.Add(webhookBackoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+	slog.Warn("webhooks.failed", "webhook_id", delivery.SubscriptionID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "status_code", status)
+}
+
+// webhookBackoff returns the wait after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookRetryBase
+	for i := 1; i < attempts && wait < webhookRetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, webhookRetryMax)
+}
+
+// Run sends queued deliveries as they are published, and retries every
+// interval, until ctx is done.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("webhooks.tick.failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// webhookPayload is the JSON body of a delivery. Without IncludeContent it
+// follows the CalDAVOperation redaction rules: IDs, ETags, Sync Health
+// reason codes and timestamps of the notification, but no UIDs, titles,
+// descriptions, locations, event times or usernames.
+type webhookPayload struct {
+	Type            string                 `json:"type"`
+	NotificationID  int64                  `json:"notification_id,omitempty"` // ID on the /events stream; unset for stored changes, which are queued before they are published
+	OccurredAt      time.Time              `json:"occurred_at"`
+	Calendar        *webhookCalendarJSON   `json:"calendar,omitempty"`
+	UID             string                 `json:"uid,omitempty"`
+	Event           *webhookEventJSON      `json:"event,omitempty"`
+	Grantee         *webhookUserJSON       `json:"grantee,omitempty"`
+	SyncHealth      *webhookSyncHealthJSON `json:"sync_health,omitempty"`
+	Reminder        *webhookReminderJSON   `json:"reminder,omitempty"`
+	ContentIncluded bool                   `json:"content_included"`
+}
+
+type webhookCalendarJSON struct {
+	ID          int64  `json:"id"`
+	OwnerID     int64  `json:"owner_id"`
+	Name        string `json:"name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+type webhookEventJSON struct {
+	ETag        string     `json:"etag"`
+	Summary     string     `json:"summary,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Start       *time.Time `json:"start,omitempty"`
+	End         *time.Time `json:"end,omitempty"`
+	AllDay      *bool      `json:"all_day,omitempty"`
+	Recurring   *bool      `json:"recurring,omitempty"`
+}
+
+type webhookUserJSON struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+type webhookSyncHealthJSON struct {
+	From    string                        `json:"from"`
+	To      string                        `json:"to"`
+	Reasons []webhookSyncHealthReasonJSON `json:"reasons"`
+}
+
+type webhookSyncHealthReasonJSON struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+}
+
+type webhookReminderJSON struct {
+	ID            int64      `json:"id"`
+	CalendarID    int64      `json:"calendar_id"`
+	Action        string     `json:"action"`
+	TriggerAt     time.Time  `json:"trigger_at"`
+	UID           string     `json:"uid,omitempty"`
+	Summary       string     `json:"summary,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	InstanceStart *time.Time `json:"instance_start,omitempty"`
+}
+
+func newWebhookPayload(n *domain.Notification, includeContent bool) webhookPayload {
+	payload := webhookPayload{
+		Type:            string(n.Type),
+		NotificationID:  n.ID,
+		OccurredAt:      n.OccurredAt.UTC(),
+		ContentIncluded: includeContent,
+	}
+	if n.Calendar != nil {
+		payload.Calendar = &webhookCalendarJSON{ID: n.Calendar.ID, OwnerID: n.Calendar.UserID}
+		if includeContent {
+			payload.Calendar.Name = n.Calendar.Name
+			payload.Calendar.DisplayName = n.Calendar.DisplayName
+		}
+	}
+	if includeContent {
+		payload.UID = n.UID
+	}
+	if n.Event != nil {
+		payload.Event = &webhookEventJSON{ETag: n.Event.ETag}
+		if includeContent {
+			start, end := n.Event.StartTime.UTC(), n.Event.EndTime.UTC()
+			allDay, recurring := n.Event.AllDay, n.Event.RecurrenceRule != "" || n.Event.RecurrenceDates != ""
+			payload.Event.Summary = n.Event.Summary
+			payload.Event.Location = n.Event.Location
+			payload.Event.Description = n.Event.Description
+			payload.Event.Start = &start
+			payload.Event.End = &end
+			payload.Event.AllDay = &allDay
+			payload.Event.Recurring = &recurring
+		}
+	}
+	if n.Grantee != nil {
+		payload.Grantee = &webhookUserJSON{ID: n.Grantee.ID}
+		if includeContent {
+			payload.Grantee.Username = n.Grantee.Username
+		}
+	}
+	if n.SyncHealth != nil {
+		payload.SyncHealth = &webhookSyncHealthJSON{
+			From:    string(n.SyncHealth.From),
+			To:      string(n.SyncHealth.To),
+			Reasons: make([]webhookSyncHealthReasonJSON, 0, len(n.SyncHealth.Reasons)),
+		}
+		for _, reason := range n.SyncHealth.Reasons {
+			payload.SyncHealth.Reasons = append(payload.SyncHealth.Reasons, webhookSyncHealthReasonJSON{Code: reason.Code, Severity: string(reason.Severity)})
+		}
+	}
+	if r := n.Reminder; r != nil {
+		payload.Reminder = &webhookReminderJSON{ID: r.ID, CalendarID: r.CalendarID, Action: r.Action, TriggerAt: r.TriggerAt.UTC()}
+		if includeContent {
+			instanceStart := r.InstanceStart.UTC()
+			payload.Reminder.UID = r.UID
+			payload.Reminder.Summary = r.Summary
+			payload.Reminder.Description = r.Description
+			payload.Reminder.InstanceStart = &instanceStart
+		}
+	}
+	return payload
+}
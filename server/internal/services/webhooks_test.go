@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/airplne/calendar-app/server/internal/domain"
+)
+
+type fakeWebhookRepo struct {
+	subscriptions []*domain.WebhookSubscription
+	deliveries    []*domain.WebhookDelivery
+}
+
+func (f *fakeWebhookRepo) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	subscription.ID = int64(len(f.subscriptions) + 1)
+	copied := *subscription
+	f.subscriptions = append(f.subscriptions, &copied)
+	return nil
+}
+
+func (f *fakeWebhookRepo) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	for _, s := range f.subscriptions {
+		if s.ID == id {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeWebhookRepo) ListSubscriptionsByUsers(ctx context.Context, userIDs []int64) ([]*domain.WebhookSubscription, error) {
+	var result []*domain.WebhookSubscription
+	for _, s := range f.subscriptions {
+		if len(userIDs) == 0 || slices.Contains(userIDs, s.UserID) {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeWebhookRepo) ListSubscriptionsByUser(ctx context.Context, userID int64) ([]*domain.WebhookSubscription, error) {
+	var result []*domain.WebhookSubscription
+	for _, s := range f.subscriptions {
+		if s.UserID == userID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeWebhookRepo) DeleteSubscription(ctx context.Context, userID, id int64) error {
+	for i, s := range f.subscriptions {
+		if s.ID == id && s.UserID == userID {
+			f.subscriptions = append(f.subscriptions[:i], f.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (f *fakeWebhookRepo) Enqueue(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	delivery.ID = int64(len(f.deliveries) + 1)
+	copied := *delivery
+	f.deliveries = append(f.deliveries, &copied)
+	return nil
+}
+
+func (f *fakeWebhookRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var result []*domain.WebhookDelivery
+	for _, d := range f.deliveries {
+		if d.Status == domain.WebhookDeliveryPending && !d.NextAttemptAt.After(now) && len(result) < limit {
+			copied := *d
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeWebhookRepo) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	for i, d := range f.deliveries {
+		if d.ID == delivery.ID {
+			copied := *delivery
+			f.deliveries[i] = &copied
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (f *fakeWebhookRepo) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	var result []*domain.WebhookDelivery
+	for i := len(f.deliveries) - 1; i >= 0 && len(result) < limit; i-- {
+		if f.deliveries[i].SubscriptionID == subscriptionID {
+			result = append(result, f.deliveries[i])
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeWebhookRepo) PruneDeliveries(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+// fakeWebhookSender answers every delivery with status.
+type fakeWebhookSender struct {
+	status int
+	sent   []*domain.WebhookDelivery
+}
+
+func (f *fakeWebhookSender) Send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	f.sent = append(f.sent, delivery)
+	if f.status < 200 || f.status > 299 {
+		return f.status, errors.New("webhook answered an error")
+	}
+	return f.status, nil
+}
+
+func TestWebhookServiceCreateValidates(t *testing.T) {
+	ctx := context.Background()
+	service := NewWebhookService(&fakeWebhookRepo{}, &fakeWebhookSender{})
+	alice := &domain.User{ID: 1, Username: "alice"}
+
+	if _, err := service.Create(ctx, alice, "ftp://example.com/hook", nil, false); err != ErrInvalidWebhookURL {
+		t.Errorf("ftp URL: got %v, want ErrInvalidWebhookURL", err)
+	}
+	if _, err := service.Create(ctx, alice, "https://example.com/hook", []string{"event.moved"}, false); err != ErrInvalidWebhookEventType {
+		t.Errorf("unknown type: got %v, want ErrInvalidWebhookEventType", err)
+	}
+	sub, err := service.Create(ctx, alice, " https://example.com/hook ", []string{"event.*", "sync_health.changed", "event.*"}, false)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if sub.URL != "https://example.com/hook" || len(sub.EventTypes) != 2 || !strings.HasPrefix(sub.Secret, "whsec_") || len(sub.Secret) != len("whsec_")+64 {
+		t.Errorf("created subscription = %+v", sub)
+	}
+	if !sub.Matches(domain.NotificationEventDeleted) || sub.Matches(domain.NotificationCalendarCreated) {
+		t.Error("event.* filter does not match the event family only")
+	}
+}
+
+// checkingWebhookSender refuses URLs on the host refused.
+type checkingWebhookSender struct {
+	fakeWebhookSender
+	refused string
+}
+
+func (c *checkingWebhookSender) CheckURL(u *url.URL) error {
+	if u.Hostname() == c.refused {
+		return errors.New("refused")
+	}
+	return nil
+}
+
+func TestWebhookServiceCreateChecksURLWithSender(t *testing.T) {
+	ctx := context.Background()
+	service := NewWebhookService(&fakeWebhookRepo{}, &checkingWebhookSender{refused: "10.0.0.1"})
+	alice := &domain.User{ID: 1, Username: "alice"}
+
+	if _, err := service.Create(ctx, alice, "http://10.0.0.1/hook", nil, false); !errors.Is(err, ErrWebhookURLNotAllowed) {
+		t.Errorf("refused URL: got %v, want ErrWebhookURLNotAllowed", err)
+	}
+	if _, err := service.Create(ctx, alice, "https://example.com/hook", nil, false); err != nil {
+		t.Errorf("accepted URL: %v", err)
+	}
+}
+
+func TestWebhookServiceQueuesRedactedPayloads(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeWebhookRepo{}
+	service := NewWebhookService(repo, &fakeWebhookSender{})
+	alice := &domain.User{ID: 1, Username: "alice"}
+	bob := &domain.User{ID: 2, Username: "bob"}
+
+	redacted, _ := service.Create(ctx, alice, "https://example.com/redacted", []string{"event.*"}, false)
+	content, _ := service.Create(ctx, alice, "https://example.com/content", nil, true)
+	health, _ := service.Create(ctx, bob, "https://example.com/health", []string{"sync_health.changed"}, false)
+
+	calendar := &domain.Calendar{ID: 7, UserID: 1, Name: "default", DisplayName: "Personal"}
+	service.Publish(ctx, &domain.Notification{ID: 41, Type: domain.NotificationEventUpdated, UserIDs: []int64{1}, Calendar: calendar, UID: "standup",
+		Event: &domain.Event{UID: "standup", ETag: `"abc"`, Summary: "Salary review", Location: "Room 4", Description: "Bring numbers",
+			StartTime: time.Date(2026, 1, 20, 9, 0, 0, 0, time.UTC), EndTime: time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC)}})
+	service.Publish(ctx, &domain.Notification{ID: 42, Type: domain.NotificationSyncHealthChanged,
+		SyncHealth: &domain.SyncHealthTransition{From: domain.SyncHealthHealthy, To: domain.SyncHealthCritical,
+			Reasons: []domain.SyncHealthReason{{Code: domain.SyncHealthReasonRecentWriteFailures, Severity: domain.SyncHealthReasonCritical}}}})
+
+	payloads := make(map[int64][]string)
+	for _, d := range repo.deliveries {
+		payloads[d.SubscriptionID] = append(payloads[d.SubscriptionID], string(d.Payload))
+	}
+	if len(payloads[redacted.ID]) != 1 || len(payloads[content.ID]) != 2 || len(payloads[health.ID]) != 1 {
+		t.Fatalf("queued payloads = %v", payloads)
+	}
+	for _, private := range []string{"Salary review", "Room 4", "Bring numbers", "standup", "Personal", "2026-01-20"} {
+		if strings.Contains(payloads[redacted.ID][0], private) {
+			t.Errorf("redacted payload contains %q: %s", private, payloads[redacted.ID][0])
+		}
+	}
+	if !strings.Contains(payloads[redacted.ID][0], `"etag":"\"abc\""`) || !strings.Contains(payloads[redacted.ID][0], `"notification_id":41`) {
+		t.Errorf("redacted payload = %s", payloads[redacted.ID][0])
+	}
+	for _, field := range []string{`"summary":"Salary review"`, `"location":"Room 4"`, `"uid":"standup"`, `"display_name":"Personal"`} {
+		if !strings.Contains(payloads[content.ID][0], field) {
+			t.Errorf("content payload lacks %s: %s", field, payloads[content.ID][0])
+		}
+	}
+	if !strings.Contains(payloads[health.ID][0], `"to":"critical"`) || !strings.Contains(payloads[health.ID][0], `"code":"recent_write_failures"`) {
+		t.Errorf("sync health payload = %s", payloads[health.ID][0])
+	}
+
+	// The delivery log is the owner's only
+	if _, err := service.Deliveries(ctx, bob.ID, redacted.ID, 0); err != domain.ErrNotFound {
+		t.Errorf("Deliveries of another user's webhook: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestWebhookServiceRetriesWithBackoffUntilDead(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeWebhookRepo{}
+	sender := &fakeWebhookSender{status: 503}
+	service := NewWebhookService(repo, sender)
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	alice := &domain.User{ID: 1, Username: "alice"}
+	sub, _ := service.Create(ctx, alice, "https://example.com/hook", nil, false)
+
+	service.Publish(ctx, &domain.Notification{ID: 1, Type: domain.NotificationCalendarCreated, UserIDs: []int64{1}, Calendar: &domain.Calendar{ID: 3, UserID: 1}})
+	var waits []time.Duration
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		if n, err := service.Tick(ctx); err != nil || n != 1 {
+			t.Fatalf("attempt %d: Tick = %d, %v", attempt, n, err)
+		}
+		d := repo.deliveries[0]
+		if d.Attempts != attempt || d.LastStatusCode != 503 || d.LastError == "" {
+			t.Fatalf("attempt %d: delivery = %+v", attempt, d)
+		}
+		if d.Status == domain.WebhookDeliveryDead {
+			break
+		}
+		if n, _ := service.Tick(ctx); n != 0 {
+			t.Fatalf("attempt %d: retried before the backoff", attempt)
+		}
+		waits = append(waits, d.NextAttemptAt.Sub(now))
+		now = *d.NextAttemptAt
+	}
+	if d := repo.deliveries[0]; d.Status != domain.WebhookDeliveryDead || d.NextAttemptAt != nil || d.Attempts != webhookMaxAttempts {
+		t.Fatalf("after %d failures delivery = %+v, want dead", webhookMaxAttempts, d)
+	}
+	if len(waits) != webhookMaxAttempts-1 || waits[0] != 30*time.Second || waits[1] != time.Minute || waits[6] != 32*time.Minute {
+		t.Errorf("backoff = %v", waits)
+	}
+	if n, _ := service.Tick(ctx); n != 0 {
+		t.Error("dead delivery was retried")
+	}
+
+	// A receiver that recovers gets the next notification at once
+	sender.status = 204
+	service.Publish(ctx, &domain.Notification{ID: 2, Type: domain.NotificationCalendarDeleted, UserIDs: []int64{1}, Calendar: &domain.Calendar{ID: 3, UserID: 1}})
+	if n, err := service.Tick(ctx); err != nil || n != 1 {
+		t.Fatalf("Tick = %d, %v", n, err)
+	}
+	log, _ := service.Deliveries(ctx, alice.ID, sub.ID, 0)
+	if len(log) != 2 || log[0].Status != domain.WebhookDeliveryDelivered || log[0].DeliveredAt == nil || log[0].LastStatusCode != 204 {
+		t.Errorf("delivery log = %+v", log)
+	}
+}
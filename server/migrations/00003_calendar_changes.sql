@@ -0,0 +1,19 @@
+-- +goose Up
+-- Per-object change log for RFC 6578 sync-collection.
+-- The autoincrement id is the revision embedded in sync tokens; deleted rows are
+-- tombstones so clients holding an older token learn about removals.
+
+CREATE TABLE IF NOT EXISTS calendar_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    calendar_id INTEGER NOT NULL,
+    uid TEXT NOT NULL,  -- Empty for collection-level changes
+    change_type TEXT NOT NULL,  -- created, updated, deleted
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (calendar_id) REFERENCES calendars(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_changes_calendar_id_id ON calendar_changes(calendar_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calendar_changes_calendar_id_id;
+DROP TABLE IF EXISTS calendar_changes;
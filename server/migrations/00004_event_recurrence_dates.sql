@@ -0,0 +1,8 @@
+-- +goose Up
+-- RDATE values of recurring events, so time-range queries can find RDATE-only
+-- recurrence sets without parsing every stored ICS.
+
+ALTER TABLE events ADD COLUMN recurrence_dates TEXT;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN recurrence_dates;
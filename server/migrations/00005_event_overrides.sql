@@ -0,0 +1,28 @@
+-- +goose Up
+-- RECURRENCE-ID overrides of recurring events.
+-- The full calendar object (master + overrides) stays in events.ics for CalDAV
+-- roundtrip; each override is indexed here so time-range queries see moved or
+-- cancelled instances at their actual time.
+
+CREATE TABLE IF NOT EXISTS event_overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    recurrence_id DATETIME NOT NULL,  -- Original start of the overridden instance
+    this_and_future BOOLEAN DEFAULT 0,  -- RECURRENCE-ID;RANGE=THISANDFUTURE
+    summary TEXT,
+    start_time DATETIME NOT NULL,
+    end_time DATETIME NOT NULL,
+    all_day BOOLEAN DEFAULT 0,
+    sequence INTEGER DEFAULT 0,
+    status TEXT DEFAULT 'CONFIRMED',
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    UNIQUE(event_id, recurrence_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_overrides_start_time ON event_overrides(start_time);
+CREATE INDEX IF NOT EXISTS idx_event_overrides_end_time ON event_overrides(end_time);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_event_overrides_end_time;
+DROP INDEX IF EXISTS idx_event_overrides_start_time;
+DROP TABLE IF EXISTS event_overrides;
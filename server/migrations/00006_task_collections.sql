@@ -0,0 +1,41 @@
+-- +goose Up
+-- VTODO collections: calendars declare the components they accept, and tasks
+-- synced over CalDAV keep their full VTODO ICS next to the extracted fields.
+
+ALTER TABLE calendars ADD COLUMN component_set TEXT NOT NULL DEFAULT 'VEVENT';  -- Comma-separated, e.g. 'VEVENT' or 'VTODO'
+
+ALTER TABLE tasks ADD COLUMN calendar_id INTEGER REFERENCES calendars(id) ON DELETE CASCADE;  -- NULL for tasks not exposed over CalDAV
+ALTER TABLE tasks ADD COLUMN uid TEXT;  -- iCalendar UID
+ALTER TABLE tasks ADD COLUMN ics TEXT;  -- Full VTODO calendar object (stored as-is for CalDAV roundtrip)
+ALTER TABLE tasks ADD COLUMN etag TEXT;  -- SHA-256(ics) for conflict detection
+ALTER TABLE tasks ADD COLUMN status TEXT;  -- NEEDS-ACTION, IN-PROCESS, COMPLETED, CANCELLED
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_calendar_id_uid ON tasks(calendar_id, uid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_calendar_id_uid;
+
+-- calendar_id carries a foreign key, so SQLite cannot DROP COLUMN it; rebuild the table instead.
+CREATE TABLE tasks_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    todoist_id TEXT UNIQUE,
+    content TEXT NOT NULL,
+    description TEXT,
+    priority INTEGER DEFAULT 1,
+    due_date DATETIME,
+    completed BOOLEAN DEFAULT 0,
+    completed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO tasks_old (id, user_id, todoist_id, content, description, priority, due_date, completed, completed_at, created_at, updated_at)
+    SELECT id, user_id, todoist_id, content, description, priority, due_date, completed, completed_at, created_at, updated_at FROM tasks;
+DROP TABLE tasks;
+ALTER TABLE tasks_old RENAME TO tasks;
+CREATE INDEX idx_tasks_user_id ON tasks(user_id);
+CREATE INDEX idx_tasks_todoist_id ON tasks(todoist_id);
+CREATE INDEX idx_tasks_due_date ON tasks(due_date);
+
+ALTER TABLE calendars DROP COLUMN component_set;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- PROPPATCH persistence: Apple's calendar-order is stored on the calendar next
+-- to display_name/color/description, and any other (dead) property a client
+-- sets is kept per resource path as raw XML.
+
+ALTER TABLE calendars ADD COLUMN calendar_order INTEGER;  -- Apple calendar-order; NULL when never set
+
+CREATE TABLE IF NOT EXISTS dav_properties (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    path TEXT NOT NULL,  -- Resource path as addressed by the client, e.g. /dav/calendars/alice/default/
+    namespace TEXT NOT NULL,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,  -- Inner XML of the property element
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, path, namespace, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS dav_properties;
+ALTER TABLE calendars DROP COLUMN calendar_order;
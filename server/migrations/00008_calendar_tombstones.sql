@@ -0,0 +1,22 @@
+-- +goose Up
+-- Calendar management over CalDAV: MKCALENDAR may set a time zone, and deleted
+-- calendars leave a tombstone so sync tokens issued before the deletion are
+-- rejected if a calendar with the same name is created again.
+
+ALTER TABLE calendars ADD COLUMN timezone TEXT;  -- CALDAV:calendar-timezone (VCALENDAR with one VTIMEZONE)
+
+CREATE TABLE IF NOT EXISTS calendar_tombstones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    revision INTEGER NOT NULL,  -- Change-log revision of the calendar when it was deleted
+    deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_tombstones_user_id_name ON calendar_tombstones(user_id, name);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calendar_tombstones_user_id_name;
+DROP TABLE IF EXISTS calendar_tombstones;
+ALTER TABLE calendars DROP COLUMN timezone;
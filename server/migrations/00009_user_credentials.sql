@@ -0,0 +1,13 @@
+-- +goose Up
+-- Multi-user accounts: each user signs in with their own password, stored as a
+-- bcrypt hash. Users without a hash can only use the bootstrap credentials from
+-- CALENDARAPP_USER/CALENDARAPP_PASS.
+
+ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;  -- Set while the account cannot sign in
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN disabled_at;
+ALTER TABLE users DROP COLUMN is_admin;
+ALTER TABLE users DROP COLUMN password_hash;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- App-specific passwords: one generated credential per device, so a lost phone
+-- can be revoked without changing the account password on every other client.
+-- Only a SHA-256 hash of the generated secret is stored.
+
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,                 -- Label chosen by the user, e.g. "iPhone"
+    token_hash TEXT NOT NULL UNIQUE,    -- Hex SHA-256 of the normalized secret
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME,
+    last_client_fingerprint TEXT NOT NULL DEFAULT '',  -- Normalized CalDAV client, never the raw user-agent
+    revoked_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_app_passwords_user_id ON app_passwords(user_id);
+
+-- Which credential each CalDAV request authenticated with ("account" or "app-password:{id}")
+ALTER TABLE caldav_operations ADD COLUMN credential TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE caldav_operations DROP COLUMN credential;
+DROP INDEX IF EXISTS idx_app_passwords_user_id;
+DROP TABLE IF EXISTS app_passwords;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Calendars shared with other users. The owner keeps full control; each
+-- grantee may read, or read and write, the calendar's objects. Shared calendars
+-- appear in the grantee's calendar home as {calendar}@{owner}.
+
+CREATE TABLE IF NOT EXISTS calendar_shares (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    calendar_id INTEGER NOT NULL,
+    grantee_user_id INTEGER NOT NULL,
+    privilege TEXT NOT NULL CHECK (privilege IN ('read', 'read-write')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (calendar_id, grantee_user_id),
+    FOREIGN KEY (calendar_id) REFERENCES calendars(id) ON DELETE CASCADE,
+    FOREIGN KEY (grantee_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_shares_grantee ON calendar_shares(grantee_user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calendar_shares_grantee;
+DROP TABLE IF EXISTS calendar_shares;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- RFC 6638 scheduling between local users. A user's email is their calendar
+-- user address (mailto:) in ORGANIZER and ATTENDEE properties. iTIP messages
+-- delivered to a user land in their schedule inbox. Events carry a
+-- Schedule-Tag, which only changes with changes that matter to attendees.
+
+ALTER TABLE users ADD COLUMN email TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email COLLATE NOCASE) WHERE email != '';
+
+ALTER TABLE events ADD COLUMN schedule_tag TEXT NOT NULL DEFAULT '';
+
+UPDATE events SET schedule_tag = etag;
+
+CREATE TABLE IF NOT EXISTS schedule_inbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,    -- Resource name in the inbox collection
+    uid TEXT NOT NULL,     -- UID of the scheduled event
+    method TEXT NOT NULL,  -- iTIP METHOD: REQUEST, CANCEL or REPLY
+    ics TEXT NOT NULL,
+    etag TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS schedule_inbox;
+ALTER TABLE events DROP COLUMN schedule_tag;
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users DROP COLUMN email;
@@ -0,0 +1,47 @@
+-- +goose Up
+-- VALARM reminders. Alarms of each event are indexed from its ICS so the
+-- reminder scheduler finds events with alarms coming due without parsing
+-- every object; triggers of recurring events are computed from the ICS when
+-- they are near. Every trigger fired is recorded so a restart neither fires
+-- it again nor skips it.
+
+CREATE TABLE IF NOT EXISTS event_alarms (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    alarm_key TEXT NOT NULL,      -- VALARM UID, or its position in the component
+    action TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    recurring BOOLEAN DEFAULT 0,  -- Triggers follow the event's recurrence set
+    first_trigger_at DATETIME,    -- Trigger bounds of non-recurring events; NULL when none are left
+    last_trigger_at DATETIME,
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    UNIQUE(event_id, alarm_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_alarms_last_trigger_at ON event_alarms(last_trigger_at);
+
+CREATE TABLE IF NOT EXISTS alarm_firings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    alarm_key TEXT NOT NULL,
+    action TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    occurrence DATETIME NOT NULL,      -- RECURRENCE-ID of the instance, or its start
+    instance_start DATETIME NOT NULL,
+    trigger_at DATETIME NOT NULL,
+    fired_at DATETIME NOT NULL,
+    acknowledged_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    UNIQUE(event_id, alarm_key, occurrence, trigger_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alarm_firings_user_fired_at ON alarm_firings(user_id, fired_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_alarm_firings_user_fired_at;
+DROP TABLE IF EXISTS alarm_firings;
+DROP INDEX IF EXISTS idx_event_alarms_last_trigger_at;
+DROP TABLE IF EXISTS event_alarms;
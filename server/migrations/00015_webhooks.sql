@@ -0,0 +1,44 @@
+-- +goose Up
+-- Outgoing webhooks. Users subscribe URLs to notification types; every
+-- matching notification is queued as a delivery with its payload already
+-- built, so a restart neither loses nor changes what is sent. Failed
+-- deliveries are retried with exponential backoff until they go dead.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,                 -- HMAC-SHA256 signing key; kept to sign every delivery
+    event_types TEXT NOT NULL DEFAULT '', -- Comma-separated filters; empty means every type
+    include_content BOOLEAN DEFAULT 0,    -- Send titles, times and locations instead of redacted payloads
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_user_id ON webhook_subscriptions(user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL,                 -- pending, delivered or dead
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME,             -- NULL once delivered or dead
+    last_attempt_at DATETIME,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    delivered_at DATETIME,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries(next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription_id;
+DROP INDEX IF EXISTS idx_webhook_deliveries_next_attempt_at;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_user_id;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,53 @@
+-- +goose Up
+-- Plan proposals: a set of typed calendar changes the user reviews before
+-- anything is written. Each change remembers the ETag of the event it
+-- touches, so a proposal made against an older version of an event goes
+-- stale instead of overwriting the newer one.
+
+CREATE TABLE IF NOT EXISTS plan_proposals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL,                  -- draft, valid, stale, applied, rejected, rolled_back or expired
+    status_reason TEXT NOT NULL DEFAULT '', -- Machine-readable cause of stale or expired
+    summary TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_plan_proposals_user_id ON plan_proposals(user_id, id);
+
+CREATE TABLE IF NOT EXISTS plan_proposal_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    proposal_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,             -- Order of the change within the proposal
+    type TEXT NOT NULL,                    -- create_focus_block, move_event, shorten_event or decline_event
+    calendar_id INTEGER NOT NULL,
+    uid TEXT NOT NULL,
+    etag TEXT NOT NULL DEFAULT '',         -- ETag of the event when the proposal was made; empty for new events
+    summary TEXT NOT NULL DEFAULT '',
+    start_time DATETIME,
+    end_time DATETIME,
+    reason TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (proposal_id) REFERENCES plan_proposals(id) ON DELETE CASCADE,
+    UNIQUE(proposal_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS plan_proposal_violations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    proposal_id INTEGER NOT NULL,
+    change_position INTEGER,               -- NULL when the violation concerns the whole proposal
+    code TEXT NOT NULL,
+    message TEXT NOT NULL,
+    FOREIGN KEY (proposal_id) REFERENCES plan_proposals(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_plan_proposal_violations_proposal_id ON plan_proposal_violations(proposal_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_plan_proposal_violations_proposal_id;
+DROP TABLE IF EXISTS plan_proposal_violations;
+DROP TABLE IF EXISTS plan_proposal_changes;
+DROP INDEX IF EXISTS idx_plan_proposals_user_id;
+DROP TABLE IF EXISTS plan_proposals;
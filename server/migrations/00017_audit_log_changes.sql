@@ -0,0 +1,27 @@
+-- +goose Up
+-- Audit log entries for applied plans. The before and after state of every
+-- event an entry changed is kept row by row next to it (rather than in the
+-- JSON changes column) so the entry can be rolled back: an event is restored
+-- to before_ics, or deleted when it was created, as long as it still has
+-- after_etag.
+
+ALTER TABLE audit_log ADD COLUMN summary TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS audit_log_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,             -- Order of the change within the entry
+    calendar_id INTEGER NOT NULL,
+    uid TEXT NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',      -- What the change did, for the user
+    before_ics TEXT NOT NULL DEFAULT '',   -- Empty when the change created the event
+    after_ics TEXT NOT NULL DEFAULT '',    -- Empty when the change deleted the event
+    before_etag TEXT NOT NULL DEFAULT '',
+    after_etag TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (entry_id) REFERENCES audit_log(id) ON DELETE CASCADE,
+    UNIQUE(entry_id, position)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log_changes;
+ALTER TABLE audit_log DROP COLUMN summary;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Rolling back an audit log entry reverts its changes one event at a time:
+-- an event edited since the entry was made is left alone and reported as a
+-- conflict, so an entry can be partly rolled back. reverted_by is the
+-- rollback entry that reverted the change; NULL while it is in effect.
+
+ALTER TABLE audit_log_changes ADD COLUMN reverted_by INTEGER;
+
+-- +goose Down
+ALTER TABLE audit_log_changes DROP COLUMN reverted_by;
@@ -1,9 +1,27 @@
 import { HashRouter, Routes, Route, Link } from 'react-router-dom'
+import { useQuery, useQueryClient } from '@tanstack/react-query'
+import { fetchSession, logout } from './api/client'
 import { CalendarPage } from './pages/CalendarPage'
 import { SettingsPage } from './pages/SettingsPage'
 import { AuditPage } from './pages/AuditPage'
+import { LoginPage } from './pages/LoginPage'
 
 function App() {
+  const queryClient = useQueryClient()
+  const { data: session, isPending } = useQuery({ queryKey: ['session'], queryFn: fetchSession, retry: false })
+
+  if (isPending) {
+    return null
+  }
+  if (!session) {
+    return <LoginPage onLogin={(s) => queryClient.setQueryData(['session'], s)} />
+  }
+
+  async function handleLogout() {
+    await logout()
+    queryClient.setQueryData(['session'], null)
+  }
+
   return (
     <HashRouter>
       <div className="min-h-screen bg-gray-50">
@@ -30,6 +48,16 @@ function App() {
                   Audit Log
                 </Link>
               </div>
+              <div className="flex items-center space-x-4">
+                <span className="text-sm text-gray-600">{session.username}</span>
+                <button
+                  type="button"
+                  onClick={handleLogout}
+                  className="text-sm font-medium text-gray-900 hover:text-gray-600"
+                >
+                  Sign out
+                </button>
+              </div>
             </div>
           </div>
         </nav>
@@ -0,0 +1,73 @@
+// JSON API client. The session lives in an HttpOnly cookie the browser sends
+// automatically; requests that change state must also carry the session's
+// CSRF token, which the server hands out at login and from /auth/session.
+
+export interface Session {
+  username: string
+  admin: boolean
+  csrf_token: string
+  expires_at: string
+}
+
+export class ApiError extends Error {
+  constructor(
+    public status: number,
+    public code: string,
+    message: string,
+  ) {
+    super(message)
+  }
+}
+
+const CSRF_HEADER = 'X-CSRF-Token'
+let csrfToken = ''
+
+export async function apiFetch<T>(path: string, init: RequestInit = {}): Promise<T> {
+  const method = (init.method ?? 'GET').toUpperCase()
+  const headers = new Headers(init.headers)
+  if (init.body !== undefined && !headers.has('Content-Type')) {
+    headers.set('Content-Type', 'application/json')
+  }
+  if (method !== 'GET' && method !== 'HEAD' && csrfToken) {
+    headers.set(CSRF_HEADER, csrfToken)
+  }
+
+  const response = await fetch(`/api/v1${path}`, { ...init, method, headers, credentials: 'same-origin' })
+  if (!response.ok) {
+    const body = await response.json().catch(() => ({}))
+    throw new ApiError(response.status, body.error_code ?? 'unknown_error', body.message ?? response.statusText)
+  }
+  if (response.status === 204) {
+    return undefined as T
+  }
+  return response.json() as Promise<T>
+}
+
+// fetchSession returns the signed-in session, or null when signed out.
+export async function fetchSession(): Promise<Session | null> {
+  try {
+    const session = await apiFetch<Session>('/auth/session')
+    csrfToken = session.csrf_token
+    return session
+  } catch (err) {
+    if (err instanceof ApiError && (err.status === 401 || err.status === 403)) {
+      csrfToken = ''
+      return null
+    }
+    throw err
+  }
+}
+
+export async function login(username: string, password: string): Promise<Session> {
+  const session = await apiFetch<Session>('/auth/login', {
+    method: 'POST',
+    body: JSON.stringify({ username, password }),
+  })
+  csrfToken = session.csrf_token
+  return session
+}
+
+export async function logout(): Promise<void> {
+  await apiFetch<void>('/auth/logout', { method: 'POST' })
+  csrfToken = ''
+}
@@ -0,0 +1,64 @@
+import { useState, type FormEvent } from 'react'
+import { ApiError, login, type Session } from '../api/client'
+
+interface LoginPageProps {
+  onLogin: (session: Session) => void
+}
+
+export function LoginPage({ onLogin }: LoginPageProps) {
+  const [username, setUsername] = useState('')
+  const [password, setPassword] = useState('')
+  const [error, setError] = useState('')
+  const [submitting, setSubmitting] = useState(false)
+
+  async function handleSubmit(event: FormEvent) {
+    event.preventDefault()
+    setSubmitting(true)
+    setError('')
+    try {
+      onLogin(await login(username, password))
+    } catch (err) {
+      setError(err instanceof ApiError && err.status === 401 ? 'Invalid username or password.' : 'Sign-in failed. Try again.')
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  return (
+    <div className="min-h-screen bg-gray-50 flex items-center justify-center px-4">
+      <form onSubmit={handleSubmit} className="w-full max-w-sm bg-white shadow rounded-lg p-6 space-y-4">
+        <h1 className="text-2xl font-semibold text-gray-900">Sign in</h1>
+        <label className="block">
+          <span className="text-sm font-medium text-gray-700">Username</span>
+          <input
+            type="text"
+            autoComplete="username"
+            value={username}
+            onChange={(e) => setUsername(e.target.value)}
+            className="mt-1 block w-full rounded border border-gray-300 px-3 py-2"
+            required
+          />
+        </label>
+        <label className="block">
+          <span className="text-sm font-medium text-gray-700">Password</span>
+          <input
+            type="password"
+            autoComplete="current-password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+            className="mt-1 block w-full rounded border border-gray-300 px-3 py-2"
+            required
+          />
+        </label>
+        {error && <p className="text-sm text-red-600">{error}</p>}
+        <button
+          type="submit"
+          disabled={submitting}
+          className="w-full rounded bg-gray-900 px-4 py-2 text-sm font-medium text-white disabled:opacity-50"
+        >
+          {submitting ? 'Signing in…' : 'Sign in'}
+        </button>
+      </form>
+    </div>
+  )
+}